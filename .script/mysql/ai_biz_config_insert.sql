@@ -0,0 +1,29 @@
+USE `webook`;
+
+CREATE TABLE IF NOT EXISTS `ai_biz_configs` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT 'AI biz 配置表ID',
+    `biz` varchar(256) NOT NULL COMMENT '业务类型名',
+    `max_input` bigint DEFAULT NULL COMMENT '最大输入长度',
+    `model` varchar(256) DEFAULT NULL,
+    `price` bigint DEFAULT NULL,
+    `temperature` double DEFAULT NULL,
+    `top_p` double DEFAULT NULL,
+    `system_prompt` longtext,
+    `prompt_template` longtext,
+    `knowledge_id` varchar(256) NOT NULL COMMENT '使用的知识库 ID',
+    `cache_ttl` bigint NOT NULL DEFAULT '0' COMMENT '回答缓存时间，单位秒，0 表示不缓存',
+    `version` bigint NOT NULL DEFAULT '0' COMMENT '当前生效的版本',
+    `experiment_version` bigint NOT NULL DEFAULT '0' COMMENT '实验组使用的版本',
+    `experiment_ratio` bigint NOT NULL DEFAULT '0' COMMENT '实验组的流量百分比，0 表示没有实验',
+    `ctime` bigint DEFAULT NULL,
+    `utime` bigint DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_ai_biz_configs_biz` (`biz`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 多轮对话摘要旧消息使用的配置，摘要是系统内部的调用，不扣积分
+-- 没有这条配置的时候不会摘要，超出预算的旧消息直接丢掉
+INSERT IGNORE INTO `ai_biz_configs` (biz, max_input, model, price, temperature, top_p, system_prompt, prompt_template, knowledge_id, cache_ttl, version, experiment_version, experiment_ratio, ctime, utime)
+VALUES ('conversation_summary', 8000, 'glm-4-flash', 0, 0.3, 0.7,
+        '你是一个对话摘要助手。请把给出的对话内容压缩成一段简洁的摘要，保留用户的问题、关键结论以及后续对话需要用到的上下文，不要添加对话中没有的信息，不超过 500 字。',
+        '%s', '', 0, 0, 0, 0, UNIX_TIMESTAMP() * 1000, UNIX_TIMESTAMP() * 1000);
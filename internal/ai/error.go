@@ -14,6 +14,16 @@
 
 package ai
 
-import "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
+import (
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/ratelimit"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+)
 
 var ErrInsufficientCredit = credit.ErrInsufficientCredit
+var ErrRateLimited = ratelimit.ErrRateLimited
+var ErrMalformedOutput = structured.ErrMalformedOutput
+
+// ErrContentBlocked 用户的输入或者大模型的回答没有通过内容审核
+var ErrContentBlocked = moderation.ErrBlocked
@@ -15,22 +15,69 @@
 package ai
 
 import (
-	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"fmt"
+	"time"
+
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/config"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/log"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/moderation"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/ali_deepseek"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/router"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/zhipu"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/ratelimit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/record"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
+	"github.com/ecodeclub/webook/internal/member"
 	"github.com/gotomicro/ego/core/econf"
+	"github.com/redis/go-redis/v9"
 )
 
-func InitCompositionHandlerUsingZhipu(common []handler.Builder,
-	root *zhipu.Handler) handler.Handler {
+func InitCompositionHandler(common []handler.Builder,
+	root *router.Handler) handler.Handler {
 	return handler.NewCompositionHandler(common, root)
 }
 
+// InitPlatformRouter 按照 llm.platforms 的配置组装多个平台。
+// 没有配置的时候只使用智谱
+func InitPlatformRouter(zp *zhipu.Handler, ali *ali_deepseek.Handler) *router.Handler {
+	type Config struct {
+		Name    string            `yaml:"name"`
+		Weight  int               `yaml:"weight"`
+		Timeout time.Duration     `yaml:"timeout"`
+		Models  map[string]string `yaml:"models"`
+	}
+	var cfgs []Config
+	err := econf.UnmarshalKey("llm.platforms", &cfgs)
+	if err != nil {
+		panic(err)
+	}
+	if len(cfgs) == 0 {
+		cfgs = []Config{{Name: zp.Name(), Weight: 100}}
+	}
+	handlers := map[string]handler.Handler{
+		zp.Name():  zp,
+		ali.Name(): ali,
+	}
+	platforms := make([]router.Platform, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		hdl, ok := handlers[cfg.Name]
+		if !ok {
+			panic(fmt.Errorf("未知的大模型平台 %s", cfg.Name))
+		}
+		platforms = append(platforms, router.Platform{
+			Name:    cfg.Name,
+			Weight:  cfg.Weight,
+			Timeout: cfg.Timeout,
+			Models:  cfg.Models,
+			Handler: hdl,
+		})
+	}
+	return router.NewHandler(platforms)
+}
+
 func InitZhipu() *zhipu.Handler {
 	type Config struct {
 		APIKey string `yaml:"apikey"`
@@ -47,14 +94,33 @@ func InitZhipu() *zhipu.Handler {
 	return h
 }
 
+// InitCommonHandlers record 要在 cache 之前，这样命中缓存的请求也会被记录下来；
+// cache 要在 credit 之前，命中缓存的请求不需要扣费；
+// 限流要在 credit 之前，被限流的请求不需要预扣积分；
+// structured 要在 credit 之后，修正输出的费用也要算进去；
+// 审核回答要在 credit 之后、structured 之前，回答被拦截的时候退回预扣的积分，打码的时候 Object 也要跟着打码
 func InitCommonHandlers(log *log.HandlerBuilder,
 	cfg *config.HandlerBuilder,
 	credit *credit.HandlerBuilder,
-	record *record.HandlerBuilder) []handler.Builder {
-	return []handler.Builder{log, cfg, credit, record}
+	record *record.HandlerBuilder,
+	cache *cache.HandlerBuilder,
+	moderation *moderation.HandlerBuilder,
+	limit *ratelimit.HandlerBuilder,
+	structured *structured.HandlerBuilder) []handler.Builder {
+	return []handler.Builder{log, cfg, record, moderation, limit, cache, credit, moderation.Output(), structured}
 }
 
-func InitAliDeepSeekHandler(configRepo repository.ConfigRepository, logRepo repository.LLMLogRepo) handler.StreamHandler {
+// InitRateLimitBuilder 按照 llm.ratelimit 的配置限流，没有配置就不限流
+func InitRateLimitBuilder(cmd redis.Cmdable, memberSvc member.Service) *ratelimit.HandlerBuilder {
+	var rules []ratelimit.Rule
+	err := econf.UnmarshalKey("llm.ratelimit", &rules)
+	if err != nil {
+		panic(err)
+	}
+	return ratelimit.NewHandlerBuilder(cmd, memberSvc, rules)
+}
+
+func InitAliDeepSeekHandler() *ali_deepseek.Handler {
 	type Config struct {
 		APIKey string `yaml:"apikey"`
 	}
@@ -63,5 +129,21 @@ func InitAliDeepSeekHandler(configRepo repository.ConfigRepository, logRepo repo
 	if err != nil {
 		panic(err)
 	}
-	return ali_deepseek.NewHandler(cfg.APIKey, logRepo, configRepo)
+	return ali_deepseek.NewHandler(cfg.APIKey)
+}
+
+// InitCommonStreamHandlers record 和同步调用一样放在限流和 credit 之前，被限流或者积分不足的请求也会被记录下来；
+// 审核要在 credit 之后，回答被拦截的时候退回预扣的积分
+func InitCommonStreamHandlers(log *log.HandlerBuilder,
+	cfg *config.HandlerBuilder,
+	credit *credit.HandlerBuilder,
+	record *record.HandlerBuilder,
+	moderation *moderation.HandlerBuilder,
+	limit *ratelimit.HandlerBuilder) []handler.StreamBuilder {
+	return []handler.StreamBuilder{log, cfg, record, limit, credit, moderation}
+}
+
+func InitCompositionStreamHandler(common []handler.StreamBuilder,
+	root *ali_deepseek.Handler) handler.StreamHandler {
+	return handler.NewCompositionStreamHandler(common, root)
 }
@@ -0,0 +1,36 @@
+package domain
+
+// BizConversationSummary 多轮对话中，摘要旧消息所使用的业务配置
+const BizConversationSummary = "conversation_summary"
+
+// Conversation 一次多轮对话
+type Conversation struct {
+	Id int64
+	// 会话 SN，由前端生成
+	Sn  string
+	Uid int64
+	Biz string
+	// 第一次提问的内容
+	Title string
+	// 已经被摘要的旧消息
+	Summary string
+	// Summary 覆盖到的最后一条消息的 id
+	SummaryMsgId int64
+	Ctime        int64
+	Utime        int64
+}
+
+// ConversationMessage 对话中保存下来的一条消息
+type ConversationMessage struct {
+	Id      int64
+	Sn      string
+	Role    string
+	Content string
+	// 估算的 token 数量
+	Tokens int64
+	Ctime  int64
+}
+
+func (m ConversationMessage) Message() Message {
+	return Message{Role: m.Role, Content: m.Content}
+}
@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EvalCase 离线评估的一条用例
+type EvalCase struct {
+	Id  int64
+	Biz string
+	// 和线上调用一样，会和 PromptTemplate 结合成 Prompt
+	Input []string
+	// 期望的评级，例如 "通过"。为空则不参与评级一致率的统计
+	ExpectedLevel string
+	// 回答中应当出现的关键字
+	Keywords []string
+	Ctime    int64
+	Utime    int64
+}
+
+type EvalRunStatus uint8
+
+func (s EvalRunStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	EvalRunStatusRunning EvalRunStatus = iota
+	EvalRunStatusSuccess
+	EvalRunStatusFailed
+)
+
+// EvalRun 使用某个版本的配置和模型跑一遍某个业务的所有用例
+type EvalRun struct {
+	Id  int64
+	Biz string
+	// 为 0 则使用当前版本的配置
+	ConfigVersion int64
+	// 为空则使用配置中的模型
+	Model string
+	// 可能出现的评级。为空则使用用例中出现过的期望评级
+	Levels []string
+	Status EvalRunStatus
+	// 运行结束之后才有
+	Report EvalReport
+	Ctime  int64
+	Utime  int64
+}
+
+// EvalResult 一条用例的评估结果
+type EvalResult struct {
+	Id            int64
+	RunId         int64
+	CaseId        int64
+	Answer        string
+	ExpectedLevel string
+	// 从回答中识别出来的评级，识别不出来则为空
+	Level string
+	// 回答中出现了的关键字
+	HitKeywords []string
+	// 回答中没有出现的关键字
+	MissedKeywords []string
+	Tokens         int64
+	Amount         int64
+	Latency        time.Duration
+	// 调用大模型失败的原因
+	Err   string
+	Ctime int64
+}
+
+func (r EvalResult) Failed() bool {
+	return r.Err != ""
+}
+
+// LevelMatched 识别出来的评级和期望的一致
+func (r EvalResult) LevelMatched() bool {
+	return r.ExpectedLevel != "" && r.Level == r.ExpectedLevel
+}
+
+// NewEvalResult 对照用例的期望给大模型的回答打分
+func NewEvalResult(c EvalCase, levels []string, resp LLMResponse, latency time.Duration) EvalResult {
+	res := EvalResult{
+		CaseId:        c.Id,
+		Answer:        resp.Answer,
+		ExpectedLevel: c.ExpectedLevel,
+		Level:         ParseLevel(resp.Answer, levels),
+		Tokens:        resp.Tokens,
+		Amount:        resp.Amount,
+		Latency:       latency,
+	}
+	answer := strings.ToLower(resp.Answer)
+	for _, kw := range c.Keywords {
+		if strings.Contains(answer, strings.ToLower(kw)) {
+			res.HitKeywords = append(res.HitKeywords, kw)
+		} else {
+			res.MissedKeywords = append(res.MissedKeywords, kw)
+		}
+	}
+	return res
+}
+
+// ParseLevel 找出回答中最先出现的评级。
+// 出现的位置相同的时候以长的为准，这样 "不通过" 就不会被识别成 "通过"
+func ParseLevel(answer string, levels []string) string {
+	res, pos := "", -1
+	for _, level := range levels {
+		if level == "" {
+			continue
+		}
+		idx := strings.Index(answer, level)
+		if idx < 0 {
+			continue
+		}
+		if pos < 0 || idx < pos || (idx == pos && len(level) > len(res)) {
+			res, pos = level, idx
+		}
+	}
+	return res
+}
+
+// EvalReport 一次离线评估的汇总
+type EvalReport struct {
+	Total int
+	// 调用大模型失败的用例数量
+	Failed int
+	// 有期望评级的用例数量
+	Leveled      int
+	LevelMatched int
+	// 评级一致率，失败的用例算作不一致
+	LevelAgreement float64
+	Keywords       int
+	HitKeywords    int
+	// 关键字召回率，失败的用例算作一个都没有命中
+	KeywordRecall float64
+	Tokens        int64
+	Amount        int64
+	// 只统计成功的用例
+	AvgLatency time.Duration
+	P90Latency time.Duration
+	MaxLatency time.Duration
+}
+
+func NewEvalReport(results []EvalResult) EvalReport {
+	res := EvalReport{Total: len(results)}
+	latencies := make([]time.Duration, 0, len(results))
+	var totalLatency time.Duration
+	for _, r := range results {
+		if r.ExpectedLevel != "" {
+			res.Leveled++
+		}
+		res.Keywords += len(r.HitKeywords) + len(r.MissedKeywords)
+		res.Tokens += r.Tokens
+		res.Amount += r.Amount
+		if r.Failed() {
+			res.Failed++
+			continue
+		}
+		if r.LevelMatched() {
+			res.LevelMatched++
+		}
+		res.HitKeywords += len(r.HitKeywords)
+		latencies = append(latencies, r.Latency)
+		totalLatency += r.Latency
+	}
+	res.LevelAgreement = ratio(res.LevelMatched, res.Leveled)
+	res.KeywordRecall = ratio(res.HitKeywords, res.Keywords)
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		res.AvgLatency = totalLatency / time.Duration(len(latencies))
+		res.P90Latency = latencies[int(math.Ceil(float64(len(latencies))*0.9))-1]
+		res.MaxLatency = latencies[len(latencies)-1]
+	}
+	return res
+}
+
+// ratio 保留四位小数
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return math.Round(float64(a)/float64(b)*10000) / 10000
+}
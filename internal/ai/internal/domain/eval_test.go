@@ -0,0 +1,142 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		name   string
+		answer string
+		levels []string
+		want   string
+	}{
+		{
+			name:   "不通过不会被识别成通过",
+			answer: "不通过，缺少对索引的分析",
+			levels: []string{"通过", "不通过"},
+			want:   "不通过",
+		},
+		{
+			name:   "以最先出现的为准",
+			answer: "通过。虽然有一些不通过的地方",
+			levels: []string{"不通过", "通过"},
+			want:   "通过",
+		},
+		{
+			name:   "识别不出来",
+			answer: "无法评价",
+			levels: []string{"通过", "不通过"},
+			want:   "",
+		},
+		{
+			name:   "没有候选评级",
+			answer: "通过",
+			want:   "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ParseLevel(tc.answer, tc.levels))
+		})
+	}
+}
+
+func TestNewEvalResult(t *testing.T) {
+	c := EvalCase{
+		Id:            1,
+		ExpectedLevel: "通过",
+		Keywords:      []string{"MySQL", "索引", "锁"},
+	}
+	res := NewEvalResult(c, []string{"通过", "不通过"}, LLMResponse{
+		Answer: "通过，mysql 的索引分析得不错",
+		Tokens: 10,
+		Amount: 2,
+	}, time.Second)
+	assert.Equal(t, EvalResult{
+		CaseId:         1,
+		Answer:         "通过，mysql 的索引分析得不错",
+		ExpectedLevel:  "通过",
+		Level:          "通过",
+		HitKeywords:    []string{"MySQL", "索引"},
+		MissedKeywords: []string{"锁"},
+		Tokens:         10,
+		Amount:         2,
+		Latency:        time.Second,
+	}, res)
+	assert.True(t, res.LevelMatched())
+}
+
+func TestNewEvalReport(t *testing.T) {
+	testCases := []struct {
+		name    string
+		results []EvalResult
+		want    EvalReport
+	}{
+		{
+			name: "没有结果",
+			want: EvalReport{},
+		},
+		{
+			name: "失败的用例算作不一致，也不统计耗时",
+			results: []EvalResult{
+				{
+					ExpectedLevel: "通过", Level: "通过",
+					HitKeywords: []string{"a"}, MissedKeywords: []string{"b"},
+					Tokens: 10, Amount: 1, Latency: time.Second,
+				},
+				{
+					ExpectedLevel: "通过", Level: "不通过",
+					HitKeywords: []string{"a", "b"},
+					Tokens:      20, Amount: 2, Latency: 3 * time.Second,
+				},
+				{
+					// 没有期望评级
+					Level:  "通过",
+					Tokens: 5, Amount: 1, Latency: 2 * time.Second,
+				},
+				{
+					ExpectedLevel: "不通过", MissedKeywords: []string{"c"},
+					Tokens: 1, Err: "mock error", Latency: 10 * time.Second,
+				},
+			},
+			want: EvalReport{
+				Total:          4,
+				Failed:         1,
+				Leveled:        3,
+				LevelMatched:   1,
+				LevelAgreement: 0.3333,
+				Keywords:       5,
+				HitKeywords:    3,
+				KeywordRecall:  0.6,
+				Tokens:         36,
+				Amount:         4,
+				AvgLatency:     2 * time.Second,
+				P90Latency:     3 * time.Second,
+				MaxLatency:     3 * time.Second,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, NewEvalReport(tc.results))
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package domain
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ecodeclub/ekit/slice"
 )
@@ -18,6 +22,16 @@ type LLMRequest struct {
 	Input []string
 	// 业务相关的配置
 	Config BizConfig
+	// 要求大模型按照 JSON Schema 输出，为 nil 则是普通的文本
+	Schema *JSONSchema
+	// 多轮对话中之前的消息，按照时间先后排列，已经按照 token 预算截断过
+	History []Message
+	// 系统内部发起的请求，例如离线评估、对话摘要，不走缓存，不限流，也不扣积分
+	Internal bool
+	// 指定使用某个版本的配置，为 0 则按照线上的规则选择版本
+	ConfigVersion int64
+	// 覆盖配置中的模型，为空则使用配置中的模型
+	Model string
 
 	// prompt 将 input 和 PromptTemplate 结合之后生成的正儿八经的 Prompt
 	prompt string
@@ -33,6 +47,29 @@ func (req *LLMRequest) Prompt() string {
 	return req.prompt
 }
 
+// Messages 发给大模型的完整消息列表：系统 Prompt、之前的对话以及本次的 Prompt
+func (req *LLMRequest) Messages() []Message {
+	msgs := make([]Message, 0, len(req.History)+2)
+	if req.Config.SystemPrompt != "" {
+		msgs = append(msgs, Message{Role: RoleSystem, Content: req.Config.SystemPrompt})
+	}
+	msgs = append(msgs, req.History...)
+	return append(msgs, Message{Role: RoleUser, Content: req.Prompt()})
+}
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message 对话中的一条消息
+type Message struct {
+	// RoleSystem、RoleUser 或者 RoleAssistant
+	Role    string
+	Content string
+}
+
 type LLMResponse struct {
 	// 花费的token
 	Tokens int64
@@ -40,8 +77,29 @@ type LLMResponse struct {
 	Amount int64
 	// llm 的回答
 	Answer string
+	// 实际提供服务的平台，例如 zhipu
+	Platform string
+	// 结构化输出的时候，校验过的 JSON
+	Object json.RawMessage
+	// 内容审核的结论，只有没有直接通过的才会记录
+	Moderations []Moderation
+}
+
+// Decode 把结构化输出的结果解析到 val 里面
+func (r LLMResponse) Decode(val any) error {
+	return json.Unmarshal(r.Object, val)
 }
 
+// JSONSchema 结构化输出的格式
+type JSONSchema struct {
+	// 只能包含字母、数字、下划线和中划线
+	Name   string
+	Schema json.RawMessage
+}
+
+// PlatformCache 命中缓存的时候，LLMResponse.Platform 的取值
+const PlatformCache = "cache"
+
 type BizConfig struct {
 	Id  int64
 	Biz string
@@ -65,7 +123,34 @@ type BizConfig struct {
 	// 这里一般使用 %s
 	// 后续考虑 key value 的形式
 	PromptTemplate string
-	Utime          int64
+	// 相同输入的回答缓存多久，为 0 则不缓存
+	CacheTTL time.Duration
+	// 版本号，每次保存都会生成一个新的版本
+	Version int64
+	// A/B 实验，部分用户会使用实验版本
+	Experiment Experiment
+	Utime      int64
+}
+
+// Experiment 按照 uid 哈希分桶，Ratio% 的用户使用 Version 版本的配置
+type Experiment struct {
+	Version int64
+	// 0-100，为 0 说明没有实验
+	Ratio int
+}
+
+func (e Experiment) Enabled() bool {
+	return e.Ratio > 0 && e.Version > 0
+}
+
+// Hit 用户是否落在实验组。同一个用户在同一个业务里面总是落在同一个组
+func (e Experiment) Hit(biz string, uid int64) bool {
+	if !e.Enabled() {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%s:%d", biz, uid)))
+	return int(h.Sum32()%100) < e.Ratio
 }
 
 type LLMCredit struct {
@@ -92,8 +177,14 @@ type LLMRecord struct {
 	KnowledgeId    string
 	PromptTemplate string
 	Answer         string
-	Ctime          int64
-	Utime          int64
+	// 实际提供服务的平台
+	Platform string
+	// 使用的配置版本
+	ConfigVersion int64
+	// 内容审核的结论
+	Moderations []Moderation
+	Ctime       int64
+	Utime       int64
 }
 
 type CreditStatus uint8
@@ -128,4 +219,35 @@ type StreamEvent struct {
 	Error error
 	// 是否结束
 	Done bool
+	// 以下字段只会出现在最后一个事件（Done 为 true）里面。
+	// 如果流被中途取消，那么这里是按照已经输出的内容估算的
+	// 花费的 token
+	Tokens int64
+	// 花费的金额
+	Amount int64
+	// 没有直接通过的审核结论
+	Moderations []Moderation
+}
+
+// EstimateTokens 粗略估算一段文本的 token 数量。
+// 中文等非 ASCII 字符按照一个字符一个 token 计算，ASCII 字符按照四个字符一个 token 计算
+func EstimateTokens(text string) int64 {
+	var tokens, ascii int64
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+			continue
+		}
+		tokens++
+	}
+	return tokens + (ascii+3)/4
+}
+
+// EstimateMessagesTokens 粗略估算一组消息的 token 数量
+func EstimateMessagesTokens(msgs []Message) int64 {
+	var tokens int64
+	for _, msg := range msgs {
+		tokens += EstimateTokens(msg.Content)
+	}
+	return tokens
 }
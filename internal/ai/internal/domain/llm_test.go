@@ -0,0 +1,48 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperiment_Hit(t *testing.T) {
+	testCases := []struct {
+		name    string
+		exp     Experiment
+		wantMin int
+		wantMax int
+	}{
+		{name: "没有实验", exp: Experiment{Version: 2}, wantMin: 0, wantMax: 0},
+		{name: "全部进入实验组", exp: Experiment{Version: 2, Ratio: 100}, wantMin: 10000, wantMax: 10000},
+		{name: "一半进入实验组", exp: Experiment{Version: 2, Ratio: 50}, wantMin: 4500, wantMax: 5500},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hit := 0
+			for uid := int64(1); uid <= 10000; uid++ {
+				if tc.exp.Hit(BizQuestionExamine, uid) {
+					hit++
+				}
+				// 同一个用户总是落在同一个组
+				assert.Equal(t, tc.exp.Hit(BizQuestionExamine, uid), tc.exp.Hit(BizQuestionExamine, uid))
+			}
+			assert.GreaterOrEqual(t, hit, tc.wantMin)
+			assert.LessOrEqual(t, hit, tc.wantMax)
+		})
+	}
+}
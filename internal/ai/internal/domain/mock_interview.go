@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"math"
 	"strings"
 )
 
@@ -12,8 +13,15 @@ type MockInterview struct {
 	Uid        int64
 	Title      string
 	Evaluation map[string]any
-	Ctime      int64
-	Utime      int64
+	// 面试结束之后才有
+	Report *MockInterviewReport
+	Ctime  int64
+	Utime  int64
+}
+
+// Finished 是否已经结束，结束之后会生成报告
+func (mi MockInterview) Finished() bool {
+	return mi.Report != nil
 }
 
 // MockInterviewQuestion 表示某场模拟面试中的一道题
@@ -47,3 +55,126 @@ func (q MockInterviewQuestion) Validate() error {
 	}
 	return nil
 }
+
+// BizMockInterviewReport 生成模拟面试报告所使用的业务配置
+const BizMockInterviewReport = "mock_interview_report"
+
+const (
+	// BizQuestion 题库中的题目
+	BizQuestion = "question"
+	// BizQuestionSet 题集
+	BizQuestionSet = "questionSet"
+	// BizCase 案例
+	BizCase = "case"
+	// BizCaseSet 案例集
+	BizCaseSet = "caseSet"
+)
+
+// InterviewResult 单道题目以及整场面试的等级，和题库中 question/domain.Result 保持一致
+type InterviewResult uint8
+
+const (
+	// InterviewResultFailed 完全没有回答出来
+	InterviewResultFailed InterviewResult = iota
+	// InterviewResultBasic 只回答出来了 15K 的部分
+	InterviewResultBasic
+	// InterviewResultIntermediate 回答了 25K 部分
+	InterviewResultIntermediate
+	// InterviewResultAdvanced 回答出来了 35K 部分
+	InterviewResultAdvanced
+)
+
+func (r InterviewResult) ToUint8() uint8 {
+	return uint8(r)
+}
+
+// Salary 等级对应的薪资水平
+func (r InterviewResult) Salary() string {
+	switch r {
+	case InterviewResultBasic:
+		return "15K"
+	case InterviewResultIntermediate:
+		return "25K"
+	case InterviewResultAdvanced:
+		return "35K"
+	default:
+		return "未达到 15K"
+	}
+}
+
+// MockInterviewReport 面试结束之后生成的总结报告
+type MockInterviewReport struct {
+	// 整体等级
+	Result InterviewResult
+	// 0-100 分
+	Score      float64
+	Strengths  []string
+	Weaknesses []string
+	Questions  []QuestionResult
+	// 推荐练习的题目、题集和案例
+	Recommendations []Recommendation
+	Ctime           int64
+}
+
+// QuestionResult 单道题目的评级
+type QuestionResult struct {
+	QuestionID int64
+	Biz        string
+	BizID      int64
+	Title      string
+	Result     InterviewResult
+}
+
+type Recommendation struct {
+	// BizQuestion、BizQuestionSet、BizCase 或者 BizCaseSet
+	Biz    string
+	BizID  int64
+	Title  string
+	Reason string
+}
+
+// Aggregate 根据每道题的等级计算得分和整体等级。
+// 得分是平均等级折算成的百分制，整体等级是平均等级向下取整，也就是说要大多数题目都达到才算
+func (r *MockInterviewReport) Aggregate() {
+	if len(r.Questions) == 0 {
+		return
+	}
+	var sum int
+	for _, q := range r.Questions {
+		sum += int(q.Result)
+	}
+	avg := float64(sum) / float64(len(r.Questions))
+	r.Score = math.Round(avg/float64(InterviewResultAdvanced)*1000) / 10
+	r.Result = InterviewResult(avg)
+}
+
+const (
+	// StreamEventDone 流正常结束
+	StreamEventDone = "done"
+	// StreamEventError 流出错结束
+	StreamEventError = "error"
+)
+
+// StreamFrame 模拟面试流中的一个事件，缓存下来用于断线重连
+type StreamFrame struct {
+	// 从 1 开始的序号
+	Seq int64
+	// 为空是普通的数据，否则是 StreamEventDone 或者 StreamEventError
+	Event string
+	Data  string
+}
+
+// Terminal 是否是最后一个事件
+func (f StreamFrame) Terminal() bool {
+	return f.Event == StreamEventDone || f.Event == StreamEventError
+}
+
+// MockInterviewInput 模拟面试中用户的一次输入
+type MockInterviewInput struct {
+	ChatSN   string
+	Uid      int64
+	Content  string
+	AudioURL string
+	// 大模型调用配置的 ID
+	ConfigID int64
+}
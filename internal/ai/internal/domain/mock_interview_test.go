@@ -0,0 +1,63 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockInterviewReport_Aggregate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		results    []InterviewResult
+		wantScore  float64
+		wantResult InterviewResult
+	}{
+		{
+			name: "没有题目",
+		},
+		{
+			name:       "全部达到 35K",
+			results:    []InterviewResult{InterviewResultAdvanced, InterviewResultAdvanced},
+			wantScore:  100,
+			wantResult: InterviewResultAdvanced,
+		},
+		{
+			name:       "向下取整",
+			results:    []InterviewResult{InterviewResultAdvanced, InterviewResultIntermediate},
+			wantScore:  83.3,
+			wantResult: InterviewResultIntermediate,
+		},
+		{
+			name:       "全部没有回答出来",
+			results:    []InterviewResult{InterviewResultFailed, InterviewResultFailed, InterviewResultBasic},
+			wantScore:  11.1,
+			wantResult: InterviewResultFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var report MockInterviewReport
+			for _, r := range tc.results {
+				report.Questions = append(report.Questions, QuestionResult{Result: r})
+			}
+			report.Aggregate()
+			assert.Equal(t, tc.wantScore, report.Score)
+			assert.Equal(t, tc.wantResult, report.Result)
+		})
+	}
+}
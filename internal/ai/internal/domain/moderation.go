@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+)
+
+const (
+	// ModerationStageInput 审核用户的输入
+	ModerationStageInput = "input"
+	// ModerationStageOutput 审核大模型的回答
+	ModerationStageOutput = "output"
+)
+
+// Moderation 一次内容审核的结论，只记录没有直接通过的
+type Moderation struct {
+	// ModerationStageInput 或者 ModerationStageOutput
+	Stage string
+	// mask 或者 block
+	Action string
+	// dict 或者 provider
+	Source string
+	// 命中的敏感词，或者审核服务给出的标签
+	Hits []string
+}
+
+func NewModeration(stage string, d moderation.Decision) Moderation {
+	return Moderation{
+		Stage:  stage,
+		Action: string(d.Action),
+		Source: d.Source,
+		Hits:   d.Hits,
+	}
+}
+
+// ModerationError 内容被拦截，携带了拦截之前的所有审核结论，方便记录在 LLMRecord 上
+type ModerationError struct {
+	Moderations []Moderation
+}
+
+func (e *ModerationError) Error() string {
+	return moderation.ErrBlocked.Error()
+}
+
+// Unwrap 使得 errors.Is(err, moderation.ErrBlocked) 成立
+func (e *ModerationError) Unwrap() error {
+	return moderation.ErrBlocked
+}
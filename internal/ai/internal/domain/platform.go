@@ -0,0 +1,19 @@
+package domain
+
+// PlatformHealth 大模型平台的健康状态
+type PlatformHealth struct {
+	Name   string
+	Weight int
+	// 是否可用。连续失败次数过多之后，会在一段时间内被标记为不可用
+	Healthy bool
+	// 连续失败的次数
+	ConsecutiveFailures int
+	// 累计成功、失败次数
+	SuccessCnt int64
+	FailureCnt int64
+	// 最近一次失败的原因和时间
+	LastErr     string
+	LastErrTime int64
+	// 不可用状态的截止时间
+	UnhealthyUntil int64
+}
@@ -1,8 +1,18 @@
 package errs
 
 var (
-	SystemError        = ErrorCode{Code: 516001, Msg: "系统错误"}
-	InsufficientCredit = ErrorCode{Code: 516002, Msg: "积分不足"}
+	SystemError              = ErrorCode{Code: 516001, Msg: "系统错误"}
+	InsufficientCredit       = ErrorCode{Code: 516002, Msg: "积分不足"}
+	RateLimited              = ErrorCode{Code: 516003, Msg: "请求过于频繁，请稍后再试"}
+	MalformedOutput          = ErrorCode{Code: 516004, Msg: "AI 返回的结果格式错误，请稍后再试"}
+	ConversationNotFound     = ErrorCode{Code: 516005, Msg: "对话不存在"}
+	MockInterviewNotFound    = ErrorCode{Code: 516006, Msg: "模拟面试不存在"}
+	MockInterviewNoQuestion  = ErrorCode{Code: 516007, Msg: "模拟面试还没有回答任何题目"}
+	MockInterviewNotFinished = ErrorCode{Code: 516008, Msg: "模拟面试还没有结束"}
+	EvalCaseNotFound         = ErrorCode{Code: 516009, Msg: "业务没有离线评估的用例"}
+	EvalRunNotFound          = ErrorCode{Code: 516010, Msg: "离线评估不存在"}
+	ContentBlocked           = ErrorCode{Code: 516011, Msg: "内容包含敏感信息"}
+	MockInterviewReporting   = ErrorCode{Code: 516012, Msg: "面试报告正在生成，请稍后再试"}
 )
 
 type ErrorCode struct {
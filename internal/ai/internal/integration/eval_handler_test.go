@@ -0,0 +1,141 @@
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/integration/startup"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
+	hdlmocks "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/mocks"
+	"github.com/ecodeclub/webook/internal/ai/internal/web"
+	"github.com/ecodeclub/webook/internal/credit"
+	"github.com/ecodeclub/webook/internal/test"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/server/egin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+const evalBiz = "eval_test"
+
+type EvalSuite struct {
+	suite.Suite
+	db     *gorm.DB
+	server *egin.Component
+}
+
+func TestEvalSuite(t *testing.T) {
+	suite.Run(t, new(EvalSuite))
+}
+
+func (s *EvalSuite) SetupSuite() {
+	s.db = testioc.InitDB()
+	require.NoError(s.T(), dao.InitTables(s.db))
+	now := time.Now().UnixMilli()
+	err := s.db.Create(&dao.BizConfig{
+		Biz:            evalBiz,
+		Model:          "online",
+		Price:          100,
+		MaxInput:       100,
+		PromptTemplate: "%s",
+		Ctime:          now,
+		Utime:          now,
+	}).Error
+	require.NoError(s.T(), err)
+
+	ctrl := gomock.NewController(s.T())
+	// 桩平台，回答取决于输入
+	hdl := hdlmocks.NewMockHandler(ctrl)
+	hdl.EXPECT().Handle(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+			assert.True(s.T(), req.Internal)
+			assert.Equal(s.T(), "glm-4", req.Config.Model)
+			if req.Prompt() == "pass" {
+				return domain.LLMResponse{Answer: "通过，索引分析得很好", Tokens: 10, Amount: 1}, nil
+			}
+			return domain.LLMResponse{Answer: "不通过", Tokens: 20, Amount: 2}, nil
+		}).AnyTimes()
+	// 离线评估不扣积分，所以积分服务是空的
+	mou, err := startup.InitModule(s.db, hdl, nil, nil, &credit.Module{}, nil)
+	require.NoError(s.T(), err)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	server := egin.Load("server").Build()
+	mou.AdminHandler.RegisterRoutes(server.Engine)
+	s.server = server
+}
+
+func (s *EvalSuite) TearDownSuite() {
+	err := s.db.Where("biz = ?", evalBiz).Delete(&dao.BizConfig{}).Error
+	require.NoError(s.T(), err)
+	for _, table := range []string{"ai_eval_cases", "ai_eval_runs", "ai_eval_results", "llm_records"} {
+		err = s.db.Exec("TRUNCATE TABLE `" + table + "`").Error
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *EvalSuite) TestRun() {
+	t := s.T()
+	ids := evalPost[[]int64](t, s.server, "/ai/eval/case/save", web.EvalCaseSaveReq{
+		Biz: evalBiz,
+		Cases: []web.EvalCase{
+			{Input: []string{"pass"}, ExpectedLevel: "通过", Keywords: []string{"索引", "锁"}},
+			{Input: []string{"fail"}, ExpectedLevel: "通过"},
+		},
+	}).Data
+	require.Len(t, ids, 2)
+
+	// 没有用例的业务
+	res := evalPost[int64](t, s.server, "/ai/eval/run", web.EvalRunReq{Biz: "unknown"})
+	assert.Equal(t, 516009, res.Code)
+
+	runID := evalPost[int64](t, s.server, "/ai/eval/run", web.EvalRunReq{
+		Biz:    evalBiz,
+		Model:  "glm-4",
+		Levels: []string{"通过", "不通过"},
+	}).Data
+	require.True(t, runID > 0)
+
+	var detail web.EvalRunDetail
+	require.Eventually(t, func() bool {
+		detail = evalPost[web.EvalRunDetail](t, s.server, "/ai/eval/run/detail", web.EvalRunDetailReq{Id: runID}).Data
+		return detail.Run.Status != domain.EvalRunStatusRunning.ToUint8()
+	}, time.Second*5, time.Millisecond*100)
+
+	assert.Equal(t, domain.EvalRunStatusSuccess.ToUint8(), detail.Run.Status)
+	report := detail.Run.Report
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 0, report.Failed)
+	assert.Equal(t, 1, report.LevelMatched)
+	assert.Equal(t, 0.5, report.LevelAgreement)
+	assert.Equal(t, 0.5, report.KeywordRecall)
+	assert.Equal(t, int64(30), report.Tokens)
+	require.Len(t, detail.Results, 2)
+	assert.Equal(t, []string{"锁"}, detail.Results[0].MissedKeywords)
+	assert.Equal(t, "不通过", detail.Results[1].Level)
+
+	// 离线评估不扣积分
+	var cnt int64
+	err := s.db.Model(&dao.LLMCredit{}).Where("biz = ?", evalBiz).Count(&cnt).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), cnt)
+}
+
+func evalPost[T any](t *testing.T, server *egin.Component, path string, body any) test.Result[T] {
+	req, err := http.NewRequest(http.MethodPost, path, iox.NewJSONReader(body))
+	require.NoError(t, err)
+	req.Header.Set("content-type", "application/json")
+	recorder := test.NewJSONResponseRecorder[T]()
+	server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+	return recorder.MustScan()
+}
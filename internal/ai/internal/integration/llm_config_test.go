@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"testing"
 
 	"github.com/ecodeclub/ekit/iox"
@@ -95,6 +96,7 @@ func (s *ConfigSuite) TestConfig_Save() {
 					SystemPrompt:   "testPrompt",
 					PromptTemplate: "testTemplate",
 					KnowledgeId:    "testKnowledgeId",
+					Version:        1,
 				}, conf)
 			},
 		},
@@ -151,6 +153,7 @@ func (s *ConfigSuite) TestConfig_Save() {
 					SystemPrompt:   "testPrompt2",
 					PromptTemplate: "testTemplate2",
 					KnowledgeId:    "testKnowledgeId2",
+					Version:        1,
 				}, conf)
 			},
 			wantCode: 200,
@@ -172,8 +175,119 @@ func (s *ConfigSuite) TestConfig_Save() {
 			tc.after(t, id)
 			err = s.db.Exec("TRUNCATE TABLE `ai_biz_configs`").Error
 			require.NoError(s.T(), err)
+			err = s.db.Exec("TRUNCATE TABLE `ai_biz_config_versions`").Error
+			require.NoError(s.T(), err)
+		})
+	}
+}
+
+func (s *ConfigSuite) TestConfig_Versions() {
+	t := s.T()
+	defer func() {
+		err := s.db.Exec("TRUNCATE TABLE `ai_biz_configs`").Error
+		require.NoError(t, err)
+		err = s.db.Exec("TRUNCATE TABLE `ai_biz_config_versions`").Error
+		require.NoError(t, err)
+	}()
+	// 保存两次，生成两个版本
+	for i := 1; i <= 2; i++ {
+		code := s.post(t, "/ai/config/save", web.ConfigRequest{
+			Config: web.Config{
+				Id:             10,
+				Biz:            "version_test",
+				MaxInput:       100,
+				Model:          "testModel",
+				PromptTemplate: fmt.Sprintf("testTemplate%d", i),
+			},
+		})
+		require.Equal(t, 200, code)
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		"/ai/config/versions", iox.NewJSONReader(web.ConfigInfoReq{Id: 10}))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(t, err)
+	recorder := test.NewJSONResponseRecorder[[]web.Config]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+	versions := recorder.MustScan().Data
+	require.Len(t, versions, 2)
+	assert.Equal(t, int64(2), versions[0].Version)
+	assert.Equal(t, "testTemplate2", versions[0].PromptTemplate)
+	assert.Equal(t, int64(1), versions[1].Version)
+	assert.Equal(t, "testTemplate1", versions[1].PromptTemplate)
+
+	// 回滚到第一个版本
+	code := s.post(t, "/ai/config/rollback", web.ConfigVersionReq{Id: 10, Version: 1})
+	require.Equal(t, 200, code)
+	var conf dao.BizConfig
+	err = s.db.Where("id = ?", 10).First(&conf).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), conf.Version)
+	assert.Equal(t, "testTemplate1", conf.PromptTemplate)
+
+	// 一半的用户使用第二个版本
+	code = s.post(t, "/ai/config/experiment", web.ExperimentReq{Id: 10, Version: 2, Ratio: 50})
+	require.Equal(t, 200, code)
+	err = s.db.Where("id = ?", 10).First(&conf).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), conf.ExperimentVersion)
+	assert.Equal(t, 50, conf.ExperimentRatio)
+
+	// 不存在的版本
+	code = s.post(t, "/ai/config/experiment", web.ExperimentReq{Id: 10, Version: 3, Ratio: 50})
+	require.Equal(t, 500, code)
+}
+
+func (s *ConfigSuite) TestConfig_ConcurrentSave() {
+	t := s.T()
+	defer func() {
+		err := s.db.Exec("TRUNCATE TABLE `ai_biz_configs`").Error
+		require.NoError(t, err)
+		err = s.db.Exec("TRUNCATE TABLE `ai_biz_config_versions`").Error
+		require.NoError(t, err)
+	}()
+	save := func(i int) int {
+		return s.post(t, "/ai/config/save", web.ConfigRequest{
+			Config: web.Config{
+				Id:             11,
+				Biz:            "concurrent_test",
+				MaxInput:       100,
+				Model:          "testModel",
+				PromptTemplate: fmt.Sprintf("testTemplate%d", i),
+			},
 		})
 	}
+	require.Equal(t, 200, save(0))
+	// 同时保存，每次都会生成一个新的版本，不会因为版本号冲突而失败
+	const n = 5
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = save(i + 1)
+		}(i)
+	}
+	wg.Wait()
+	for _, code := range codes {
+		assert.Equal(t, 200, code)
+	}
+	var versions []int64
+	err := s.db.Model(&dao.BizConfigVersion{}).Where("biz = ?", "concurrent_test").
+		Order("version").Pluck("version", &versions).Error
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6}, versions)
+}
+
+func (s *ConfigSuite) post(t *testing.T, path string, body any) int {
+	req, err := http.NewRequest(http.MethodPost, path, iox.NewJSONReader(body))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(t, err)
+	recorder := test.NewJSONResponseRecorder[any]()
+	s.server.ServeHTTP(recorder, req)
+	return recorder.Code
 }
 
 func (s *ConfigSuite) TestConfig_List() {
@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	streamhdlmocks "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/stream_mocks"
 
 	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/webook/internal/ai/internal/web"
 	"github.com/ecodeclub/webook/internal/test"
@@ -26,9 +28,14 @@ import (
 	"github.com/ecodeclub/ekit/sqlx"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
 	hdlmocks "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/mocks"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/ratelimit"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/errs"
 	"github.com/ecodeclub/webook/internal/ai/internal/integration/startup"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/credit"
 	creditmocks "github.com/ecodeclub/webook/internal/credit/mocks"
@@ -134,6 +141,10 @@ func (s *LLMServiceSuite) TearDownTest() {
 	require.NoError(s.T(), err)
 	err = s.db.Exec("TRUNCATE TABLE `llm_credits`").Error
 	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE TABLE `ai_conversations`").Error
+	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE TABLE `ai_conversation_messages`").Error
+	require.NoError(s.T(), err)
 }
 
 func (s *LLMServiceSuite) TestService() {
@@ -171,7 +182,9 @@ func (s *LLMServiceSuite) TestService() {
 				creditSvc.EXPECT().GetCreditsByUID(gomock.Any(), gomock.Any()).Return(credit.Credit{
 					TotalAmount: 1000,
 				}, nil)
-				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil)
+				// 预扣的积分不够支付实际费用，结算之后还要再扣一次超出的部分
+				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil).Times(2)
+				creditSvc.EXPECT().SettleDeductCredits(gomock.Any(), int64(123), int64(11), int64(2)).Return(nil)
 				creditSvc.EXPECT().ConfirmDeductCredits(gomock.Any(), int64(123), int64(11)).Return(nil)
 				return llmHdl, creditSvc
 			},
@@ -247,7 +260,9 @@ func (s *LLMServiceSuite) TestService() {
 				creditSvc.EXPECT().GetCreditsByUID(gomock.Any(), gomock.Any()).Return(credit.Credit{
 					TotalAmount: 1000,
 				}, nil)
-				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil)
+				// 预扣的积分不够支付实际费用，结算之后还要再扣一次超出的部分
+				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil).Times(2)
+				creditSvc.EXPECT().SettleDeductCredits(gomock.Any(), int64(123), int64(11), int64(2)).Return(nil)
 				creditSvc.EXPECT().ConfirmDeductCredits(gomock.Any(), int64(123), int64(11)).Return(nil)
 				return llmHdl, creditSvc
 			},
@@ -365,6 +380,9 @@ func (s *LLMServiceSuite) TestService() {
 				creditSvc.EXPECT().GetCreditsByUID(gomock.Any(), gomock.Any()).Return(credit.Credit{
 					TotalAmount: 1000,
 				}, nil)
+				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(12, nil)
+				// 调用失败，退回预扣的积分
+				creditSvc.EXPECT().CancelDeductCredits(gomock.Any(), int64(125), int64(12)).Return(nil)
 				return llmHdl, creditSvc
 			},
 			after: func(t *testing.T, resp domain.LLMResponse) {
@@ -423,13 +441,8 @@ func (s *LLMServiceSuite) TestService() {
 			assertFunc: assert.Error,
 			before: func(t *testing.T,
 				ctrl *gomock.Controller) (*hdlmocks.MockHandler, credit.Service) {
+				// 预扣失败，不会调用大模型
 				llmHdl := hdlmocks.NewMockHandler(ctrl)
-				llmHdl.EXPECT().Handle(gomock.Any(), gomock.Any()).
-					Return(domain.LLMResponse{
-						Tokens: 100,
-						Amount: 100,
-						Answer: "aians",
-					}, nil)
 				creditSvc := creditmocks.NewMockService(ctrl)
 				creditSvc.EXPECT().GetCreditsByUID(gomock.Any(), gomock.Any()).Return(credit.Credit{
 					TotalAmount: 1000,
@@ -441,7 +454,8 @@ func (s *LLMServiceSuite) TestService() {
 						Uid: 126,
 						Logs: []credit.CreditLog{
 							{
-								ChangeAmount: 100,
+								// 按照 prompt 预估的费用
+								ChangeAmount: 2,
 								Uid:          126,
 								Biz:          "ai-llm",
 								Desc:         "ai-llm服务",
@@ -498,6 +512,43 @@ func (s *LLMServiceSuite) TestService() {
 				}, creditLogModel)
 			},
 		},
+		{
+			name: "结构化输出修正之后依旧不合法",
+			req: domain.LLMRequest{
+				Biz: domain.BizQuestionExamine,
+				Uid: 128,
+				Tid: "11",
+				Input: []string{
+					"问题1",
+					"问题1内容",
+					"用户输入1",
+				},
+				Schema: &domain.JSONSchema{Name: "result", Schema: json.RawMessage(`{"type":"object"}`)},
+			},
+			assertFunc: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, structured.ErrMalformedOutput, i...)
+			},
+			before: func(t *testing.T,
+				ctrl *gomock.Controller) (*hdlmocks.MockHandler, credit.Service) {
+				// 第一次调用和修正都不是 JSON
+				llmHdl := hdlmocks.NewMockHandler(ctrl)
+				llmHdl.EXPECT().Handle(gomock.Any(), gomock.Any()).
+					Return(domain.LLMResponse{
+						Tokens: 100,
+						Amount: 100,
+						Answer: "aians",
+					}, nil).Times(2)
+				creditSvc := creditmocks.NewMockService(ctrl)
+				creditSvc.EXPECT().GetCreditsByUID(gomock.Any(), gomock.Any()).Return(credit.Credit{
+					TotalAmount: 1000,
+				}, nil)
+				// 两次调用的费用都要结算，不能退回预扣的积分
+				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(13, nil).Times(2)
+				creditSvc.EXPECT().SettleDeductCredits(gomock.Any(), int64(128), int64(13), gomock.Any()).Return(nil)
+				creditSvc.EXPECT().ConfirmDeductCredits(gomock.Any(), int64(128), int64(13)).Return(nil)
+				return llmHdl, creditSvc
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -518,6 +569,124 @@ func (s *LLMServiceSuite) TestService() {
 	}
 }
 
+func (s *LLMServiceSuite) TestService_Cache() {
+	t := s.T()
+	const biz = "cache_test"
+	now := time.Now().UnixMilli()
+	err := s.db.Create(&dao.BizConfig{
+		Id:       100,
+		Biz:      biz,
+		MaxInput: 100,
+		// 每次运行的模板都不一样，避免命中上一次运行留下的缓存
+		PromptTemplate: fmt.Sprintf("%d 这是用户输入 %%s", now),
+		CacheTTL:       60,
+		Ctime:          now,
+		Utime:          now,
+	}).Error
+	require.NoError(t, err)
+	// 模板一样的另一个版本，用来验证不同版本不会共用缓存
+	err = s.db.Create(&dao.BizConfigVersion{
+		Id:             100,
+		Biz:            biz,
+		Version:        1,
+		MaxInput:       100,
+		PromptTemplate: fmt.Sprintf("%d 这是用户输入 %%s", now),
+		CacheTTL:       60,
+		Ctime:          now,
+	}).Error
+	require.NoError(t, err)
+	ec := testioc.InitCache()
+	cacheRepo := repository.NewLLMCacheRepo(cache.NewLLMCache(ec))
+	defer func() {
+		err = s.db.Where("id = ?", 100).Delete(&dao.BizConfig{}).Error
+		require.NoError(t, err)
+		err = s.db.Where("id = ?", 100).Delete(&dao.BizConfigVersion{}).Error
+		require.NoError(t, err)
+		require.NoError(t, cacheRepo.SetEnabled(context.Background(), true))
+	}()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	llmHdl := hdlmocks.NewMockHandler(ctrl)
+	// 输入一样但是 Schema 不一样，各自调用一次大模型
+	llmHdl.EXPECT().Handle(gomock.Any(), gomock.Cond(func(req domain.LLMRequest) bool {
+		return req.Schema != nil
+	})).Return(domain.LLMResponse{
+		Tokens: 100,
+		Amount: 100,
+		Answer: `{"ok":true}`,
+	}, nil).Times(2)
+	// 关闭缓存之前，同一个版本只会调用一次大模型
+	llmHdl.EXPECT().Handle(gomock.Any(), gomock.Any()).Return(domain.LLMResponse{
+		Tokens: 100,
+		Amount: 100,
+		Answer: "aians",
+	}, nil).Times(3)
+	mou, err := startup.InitModule(s.db, llmHdl, nil, nil, &credit.Module{}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	// 忽略大小写和多余的空白字符之后，输入是一样的
+	inputs := []string{"Hello  World", " hello world "}
+	wantResps := []domain.LLMResponse{
+		{Tokens: 100, Amount: 100, Answer: "aians"},
+		{Answer: "aians", Platform: domain.PlatformCache},
+	}
+	for i, input := range inputs {
+		resp, err := mou.Svc.Invoke(ctx, domain.LLMRequest{
+			Biz:   biz,
+			Uid:   127,
+			Tid:   fmt.Sprintf("cache-%d", i),
+			Input: []string{input},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, wantResps[i], resp)
+	}
+	// 命中缓存的请求也会被记录下来，但是没有消耗 token
+	var logModel dao.LLMRecord
+	err = s.db.WithContext(ctx).Where("tid = ?", "cache-1").First(&logModel).Error
+	require.NoError(t, err)
+	assert.Equal(t, domain.PlatformCache, logModel.Platform)
+	assert.Equal(t, int64(0), logModel.Tokens)
+	assert.Equal(t, int64(0), logModel.Amount)
+
+	// 输入一样，但是指定了另一个配置版本，不会命中缓存
+	resp, err := mou.Svc.Invoke(ctx, domain.LLMRequest{
+		Biz:           biz,
+		Uid:           127,
+		Tid:           "cache-version",
+		Input:         []string{"hello world"},
+		ConfigVersion: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, wantResps[0], resp)
+
+	// 输入一样，但是 Schema 不一样，不会命中缓存
+	for i, schema := range []string{`{"type":"object"}`, `{"type":"object","required":["ok"]}`} {
+		resp, err = mou.Svc.Invoke(ctx, domain.LLMRequest{
+			Biz:    biz,
+			Uid:    127,
+			Tid:    fmt.Sprintf("cache-schema-%d", i),
+			Input:  []string{"hello world"},
+			Schema: &domain.JSONSchema{Name: "result", Schema: json.RawMessage(schema)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), resp.Tokens)
+	}
+
+	// 关闭缓存之后，会调用大模型
+	require.NoError(t, cacheRepo.SetEnabled(ctx, false))
+	resp, err = mou.Svc.Invoke(ctx, domain.LLMRequest{
+		Biz:   biz,
+		Uid:   127,
+		Tid:   "cache-2",
+		Input: []string{"hello world"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, wantResps[0], resp)
+}
+
 func (s *LLMServiceSuite) TestHandler_Ask() {
 	testCases := []struct {
 		name       string
@@ -551,7 +720,9 @@ func (s *LLMServiceSuite) TestHandler_Ask() {
 				creditSvc.EXPECT().GetCreditsByUID(gomock.Any(), gomock.Any()).Return(credit.Credit{
 					TotalAmount: 1000,
 				}, nil)
-				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil)
+				// 预扣的积分不够支付实际费用，结算之后还要再扣一次超出的部分
+				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil).Times(2)
+				creditSvc.EXPECT().SettleDeductCredits(gomock.Any(), int64(123), int64(11), int64(2)).Return(nil)
 				creditSvc.EXPECT().ConfirmDeductCredits(gomock.Any(), int64(123), int64(11)).Return(nil)
 				return llmHdl, creditSvc
 			},
@@ -636,6 +807,91 @@ func (s *LLMServiceSuite) TestHandler_Ask() {
 	}
 }
 
+func (s *LLMServiceSuite) TestHandler_Conversation() {
+	t := s.T()
+	const biz = "conversation_test"
+	now := time.Now().UnixMilli()
+	err := s.db.Create(&dao.BizConfig{
+		Id:             101,
+		Biz:            biz,
+		MaxInput:       100,
+		SystemPrompt:   "你是一个面试官",
+		PromptTemplate: "%s",
+		Ctime:          now,
+		Utime:          now,
+	}).Error
+	require.NoError(t, err)
+	defer func() {
+		err = s.db.Where("id = ?", 101).Delete(&dao.BizConfig{}).Error
+		require.NoError(t, err)
+	}()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	llmHdl := hdlmocks.NewMockHandler(ctrl)
+	gomock.InOrder(
+		llmHdl.EXPECT().Handle(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+				assert.Equal(t, []domain.Message{
+					{Role: domain.RoleSystem, Content: "你是一个面试官"},
+					{Role: domain.RoleUser, Content: "什么是 GMP"},
+				}, req.Messages())
+				return domain.LLMResponse{Answer: "GMP 是 Go 的调度模型"}, nil
+			}),
+		// 第二轮会带上第一轮的问答
+		llmHdl.EXPECT().Handle(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+				assert.Equal(t, []domain.Message{
+					{Role: domain.RoleSystem, Content: "你是一个面试官"},
+					{Role: domain.RoleUser, Content: "什么是 GMP"},
+					{Role: domain.RoleAssistant, Content: "GMP 是 Go 的调度模型"},
+					{Role: domain.RoleUser, Content: "P 是什么"},
+				}, req.Messages())
+				return domain.LLMResponse{Answer: "P 是处理器"}, nil
+			}),
+	)
+	mou, err := startup.InitModule(s.db, llmHdl, nil, nil, &credit.Module{}, nil)
+	require.NoError(t, err)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	server := egin.Load("server").Build()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set(session.CtxSessionKey,
+			session.NewMemorySession(session.Claims{
+				Uid: 123,
+			}))
+	})
+	mou.Hdl.MemberRoutes(server.Engine)
+
+	for _, input := range []string{"什么是 GMP", "P 是什么"} {
+		req, err := http.NewRequest(http.MethodPost,
+			"/ai/ask", iox.NewJSONReader(web.LLMRequest{
+				Biz:   biz,
+				Sn:    "conv-1",
+				Input: []string{input},
+			}))
+		req.Header.Set("content-type", "application/json")
+		require.NoError(t, err)
+		recorder := test.NewJSONResponseRecorder[web.LLMResponse]()
+		server.ServeHTTP(recorder, req)
+		require.Equal(t, 200, recorder.Code)
+	}
+
+	req, err := http.NewRequest(http.MethodPost,
+		"/ai/conversation/detail", iox.NewJSONReader(web.ConversationReq{Sn: "conv-1"}))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(t, err)
+	recorder := test.NewJSONResponseRecorder[web.Conversation]()
+	server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+	conv := recorder.MustScan().Data
+	assert.Equal(t, "什么是 GMP", conv.Title)
+	assert.Equal(t, biz, conv.Biz)
+	assert.Equal(t, []string{"什么是 GMP", "GMP 是 Go 的调度模型", "P 是什么", "P 是处理器"},
+		slice.Map(conv.Messages, func(_ int, src web.Message) string {
+			return src.Content
+		}))
+}
+
 func (s *LLMServiceSuite) TestHandler_AnalysisJD() {
 	testCases := []struct {
 		name       string
@@ -690,6 +946,7 @@ func (s *LLMServiceSuite) TestHandler_AnalysisJD() {
 					TotalAmount: 200000,
 				}, nil).AnyTimes()
 				creditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(11, nil).AnyTimes()
+				creditSvc.EXPECT().SettleDeductCredits(gomock.Any(), int64(123), int64(11), gomock.Any()).Return(nil).AnyTimes()
 				creditSvc.EXPECT().ConfirmDeductCredits(gomock.Any(), int64(123), int64(11)).Return(nil).AnyTimes()
 				return llmHdl, creditSvc
 			},
@@ -809,6 +1066,47 @@ func (s *LLMServiceSuite) TestHandler_Stream() {
 			},
 			wantCode: 200,
 		},
+		{
+			name: "被限流",
+			req: web.LLMRequest{
+				Biz:   domain.BizQuestionExamine,
+				Input: []string{"请说一下什么是deepseek ?"},
+			},
+			before: func(t *testing.T, ctrl *gomock.Controller) *streamhdlmocks.MockStreamHandler {
+				mockStreamHandler := streamhdlmocks.NewMockStreamHandler(ctrl)
+				mockStreamHandler.EXPECT().StreamHandle(gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w, uid 123", ratelimit.ErrRateLimited))
+				return mockStreamHandler
+			},
+			wantEvts: []web.Event{
+				{Type: "error", Code: errs.RateLimited.Code, Err: errs.RateLimited.Msg},
+			},
+			wantCode: 200,
+		},
+		{
+			name: "中途出错",
+			req: web.LLMRequest{
+				Biz:   domain.BizQuestionExamine,
+				Input: []string{"请说一下什么是deepseek ?"},
+			},
+			before: func(t *testing.T, ctrl *gomock.Controller) *streamhdlmocks.MockStreamHandler {
+				mockStreamHandler := streamhdlmocks.NewMockStreamHandler(ctrl)
+				mockStreamHandler.EXPECT().StreamHandle(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+					events := make(chan domain.StreamEvent, 2)
+					events <- domain.StreamEvent{Content: "msg1"}
+					// 原始的错误不会返回给前端
+					events <- domain.StreamEvent{Done: true, Error: errors.New("平台返回 500, uid 123")}
+					close(events)
+					return events, nil
+				})
+				return mockStreamHandler
+			},
+			wantEvts: []web.Event{
+				{Type: "msg", Data: web.EvtMsg{Content: "msg1"}},
+				{Type: "error", Code: errs.SystemError.Code, Err: errs.SystemError.Msg},
+			},
+			wantCode: 200,
+		},
 	}
 	for _, tc := range testcases {
 		tc := tc
@@ -1,6 +1,8 @@
 package integration
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -14,7 +16,10 @@ import (
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/ai/internal/service"
 	"github.com/ecodeclub/webook/internal/ai/internal/web"
+	aimocks "github.com/ecodeclub/webook/internal/ai/mocks"
 	"github.com/ecodeclub/webook/internal/credit"
+	"github.com/ecodeclub/webook/internal/pkg/pdf"
+	pdfmocks "github.com/ecodeclub/webook/internal/pkg/pdf/mocks"
 	testioc "github.com/ecodeclub/webook/internal/test/ioc"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -24,6 +29,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
 	"go.uber.org/zap/zapcore"
 	"gorm.io/gorm"
 )
@@ -45,7 +51,7 @@ func (s *MockInterviewTestSuite) SetupSuite() {
 	s.db = db
 	err := dao.InitTables(db)
 	s.NoError(err)
-	s.mockInterviewSvc = service.NewMockInterviewService(repository.NewMockInterviewRepository(dao.NewMockInterviewDAO(s.db)))
+	s.mockInterviewSvc = service.NewMockInterviewService(repository.NewMockInterviewRepository(dao.NewMockInterviewDAO(s.db)), nil, nil, nil)
 
 	// 先插入 BizConfig
 	mou, err := startup.InitModule(s.db, nil, nil, nil, &credit.Module{}, nil)
@@ -606,3 +612,112 @@ func (s *MockInterviewTestSuite) TestService_ListQuestions() {
 		})
 	}
 }
+
+func (s *MockInterviewTestSuite) TestService_Finish() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	llmSvc := aimocks.NewMockService(ctrl)
+	pdfConverter := pdfmocks.NewMockConverter(ctrl)
+	repo := repository.NewMockInterviewRepository(dao.NewMockInterviewDAO(s.db))
+	svc := service.NewMockInterviewService(repo,
+		repository.NewKnowledgeBaseRepo(dao.NewKnowledgeBaseDAO(s.db)), llmSvc, pdfConverter)
+	// 只有上传到知识库的案例才会被推荐
+	file := dao.KnowledgeBaseFile{
+		Biz: domain.BizCase, BizID: 2, Name: fmt.Sprintf("case_2_%d", time.Now().UnixNano()),
+		Platform: "zhipu", KnowledgeBaseID: "kb-report",
+	}
+	require.NoError(t, s.db.Create(&file).Error)
+	t.Cleanup(func() {
+		_ = s.db.Where("id = ?", file.Id).Delete(&dao.KnowledgeBaseFile{}).Error
+	})
+
+	sn := fmt.Sprintf("sn-%d", time.Now().UnixNano())
+	_, err := svc.SaveInterview(t.Context(), domain.MockInterview{Uid: 123, Title: "面试-报告", ChatSN: sn})
+	require.NoError(t, err)
+	// 还没有回答任何题目
+	_, err = svc.Finish(t.Context(), 123, sn)
+	assert.ErrorIs(t, err, service.ErrMockInterviewNoQuestion)
+	// 别人的面试
+	_, err = svc.Finish(t.Context(), 456, sn)
+	assert.ErrorIs(t, err, service.ErrMockInterviewNotFound)
+	// 还没有结束，不能导出
+	_, err = svc.ExportReport(t.Context(), 123, sn)
+	assert.ErrorIs(t, err, service.ErrMockInterviewNotFinished)
+
+	qid1, err := svc.SaveQuestion(t.Context(), domain.MockInterviewQuestion{
+		ChatSN: sn, Uid: 123, Biz: domain.BizQuestion, BizID: 1,
+		Answer: map[string]any{"content": "不知道"},
+	})
+	require.NoError(t, err)
+	qid2, err := svc.SaveQuestion(t.Context(), domain.MockInterviewQuestion{
+		ChatSN: sn, Uid: 123, Biz: "generated", Title: "如何设计秒杀系统",
+		Answer: map[string]any{"content": "限流、异步"},
+	})
+	require.NoError(t, err)
+
+	mi, err := repo.FindInterviewBySN(t.Context(), 123, sn)
+	require.NoError(t, err)
+	// 别的请求正在生成报告
+	require.NoError(t, repo.StartReport(t.Context(), mi.ID, time.Now().Add(-time.Minute).UnixMilli()))
+	_, err = svc.Finish(t.Context(), 123, sn)
+	assert.ErrorIs(t, err, service.ErrMockInterviewReporting)
+	require.NoError(t, repo.CancelReport(t.Context(), mi.ID))
+
+	llmSvc.EXPECT().Invoke(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+			assert.Equal(t, domain.BizMockInterviewReport, req.Biz)
+			assert.NotNil(t, req.Schema)
+			return domain.LLMResponse{
+				Object: json.RawMessage(fmt.Sprintf(`{
+  "questions": [{"id": %d, "result": 1}, {"id": %d, "result": 3}],
+  "strengths": ["系统设计"],
+  "weaknesses": ["基础知识"],
+  "recommendations": [
+    {"biz": "case", "bizId": 2, "title": "秒杀", "reason": "巩固"},
+    {"biz": "case", "bizId": 2, "title": "秒杀", "reason": "重复"},
+    {"biz": "case", "bizId": 999, "title": "编造的案例", "reason": "不存在"},
+    {"biz": "question", "bizId": 1, "title": "重复推荐", "reason": "已经推荐过"},
+    {"biz": "question", "bizId": 888, "title": "编造的题目", "reason": "不存在"}
+  ]
+}`, qid1, qid2)),
+			}, nil
+		})
+	mi, err = svc.Finish(t.Context(), 123, sn)
+	require.NoError(t, err)
+	want := &domain.MockInterviewReport{
+		Result:     domain.InterviewResultIntermediate,
+		Score:      66.7,
+		Strengths:  []string{"系统设计"},
+		Weaknesses: []string{"基础知识"},
+		Questions: []domain.QuestionResult{
+			{QuestionID: qid2, Biz: "generated", Title: "如何设计秒杀系统", Result: domain.InterviewResultAdvanced},
+			{QuestionID: qid1, Biz: domain.BizQuestion, BizID: 1, Result: domain.InterviewResultBasic},
+		},
+		Recommendations: []domain.Recommendation{
+			{Biz: domain.BizQuestion, BizID: 1, Reason: "这道题只达到了 15K 的水平，建议重新练习"},
+			{Biz: domain.BizCase, BizID: 2, Title: "秒杀", Reason: "巩固"},
+		},
+	}
+	// 题目是按照创建时间倒序查询出来的
+	if mi.Report.Questions[0].QuestionID != qid2 {
+		want.Questions[0], want.Questions[1] = want.Questions[1], want.Questions[0]
+	}
+	want.Ctime = mi.Report.Ctime
+	assert.Equal(t, want, mi.Report)
+
+	// 已经结束的面试直接返回保存下来的报告，不会再次调用大模型
+	mi, err = svc.Finish(t.Context(), 123, sn)
+	require.NoError(t, err)
+	assert.Equal(t, want, mi.Report)
+
+	pdfConverter.EXPECT().ConvertHTMLToPDF(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, html string, opts ...pdf.Option) ([]byte, error) {
+			assert.Contains(t, html, "面试-报告")
+			assert.Contains(t, html, "如何设计秒杀系统")
+			return []byte("pdf"), nil
+		})
+	data, err := svc.ExportReport(t.Context(), 123, sn)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pdf"), data)
+}
@@ -5,6 +5,7 @@ package startup
 import (
 	"sync"
 
+	"github.com/ecodeclub/ecache"
 	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
 	"github.com/ecodeclub/webook/internal/ai/internal/event"
 	"github.com/ecodeclub/webook/ioc"
@@ -23,14 +24,23 @@ import (
 	"github.com/ecodeclub/webook/internal/ai"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	aicache "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/config"
 	aicredit "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/log"
+	aimoderation "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/moderation"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/router"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/ratelimit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/record"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/credit"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+	"github.com/ecodeclub/webook/internal/pkg/pdf"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
 	"github.com/ego-component/egorm"
 	"github.com/google/wire"
 	"gorm.io/gorm"
@@ -49,16 +59,32 @@ func InitModule(db *egorm.Component,
 		repository.NewLLMCreditLogRepo,
 		repository.NewCachedConfigRepository,
 		repository.NewMockInterviewRepository,
+		repository.NewLLMCacheRepo,
+		repository.NewConversationRepository,
+		repository.NewMockInterviewStreamRepository,
+		repository.NewEvalRepository,
+		repository.NewKnowledgeBaseRepo,
 
 		InitLLMCreditLogDAO,
 		dao.NewGORMLLMLogDAO,
 		dao.NewGORMConfigDAO,
 		dao.NewMockInterviewDAO,
+		dao.NewGORMConversationDAO,
+		dao.NewGORMEvalDAO,
+		dao.NewKnowledgeBaseDAO,
+		cache.NewLLMCache,
+		cache.NewMockInterviewStreamCache,
+		InitCache,
+		testioc.InitRedis,
 
 		config.NewBuilder,
 		log.NewHandler,
 		record.NewHandler,
 		aicredit.NewHandlerBuilder,
+		aicache.NewHandlerBuilder,
+		InitRateLimitBuilder,
+		structured.NewHandlerBuilder,
+		InitModerationBuilder,
 
 		ai.InitCommonHandlers,
 		InitRootHandler,
@@ -67,10 +93,18 @@ func InitModule(db *egorm.Component,
 		service.NewJDService,
 		service.NewConfigService,
 		service.NewMockInterviewService,
+		service.NewPlatformService,
+		service.NewCacheService,
+		service.NewConversationService,
+		service.NewMockInterviewStreamService,
+		service.NewEvalService,
+		service.NewPrivacyService,
+		InitPlatformRouter,
 		web.NewHandler,
 		web.NewAdminHandler,
 
 		InitGRPCClient,
+		InitPDFConverter,
 		web.NewMockInterviewHandler,
 
 		wire.Struct(new(ai.Module), "*"),
@@ -93,6 +127,19 @@ func InitKnowledgeBaseSvc(db *egorm.Component, apikey string) knowledge_base.Rep
 func InitRootHandler(common []handler.Builder, hdl *hdlmocks.MockHandler) handler.Handler {
 	return handler.NewCompositionHandler(common, hdl)
 }
+
+// InitPlatformRouter 测试里面直接使用 mock 作为根 Handler，这里只是为了查看平台状态
+func InitPlatformRouter(hdl *hdlmocks.MockHandler) *router.Handler {
+	return router.NewHandler([]router.Platform{
+		{Name: "mock", Weight: 100, Handler: hdl},
+	})
+}
+
+// InitPDFConverter 测试里面不会真的导出 PDF
+func InitPDFConverter() pdf.Converter {
+	return pdf.NewChromeDPConverter("")
+}
+
 func InitStreamHandler(streamHdl *streamhdlmocks.MockStreamHandler) handler.StreamHandler {
 	return streamHdl
 }
@@ -113,6 +160,20 @@ func InitLLMCreditLogDAO(db *egorm.Component) dao.LLMCreditDAO {
 	return dao.NewLLMCreditLogDAO(db)
 }
 
+// InitRateLimitBuilder 测试里面默认不限流
+func InitRateLimitBuilder() *ratelimit.HandlerBuilder {
+	return ratelimit.NewHandlerBuilder(testioc.InitRedis(), nil, nil)
+}
+
+// InitModerationBuilder 测试的时候不审核内容
+func InitModerationBuilder() *aimoderation.HandlerBuilder {
+	return aimoderation.NewHandlerBuilder(moderation.NewDictFilter(moderation.Dict{}, nil))
+}
+
+func InitCache() ecache.Cache {
+	return testioc.InitCache()
+}
+
 func InitGRPCClient() chatv1.ServiceClient {
 	econf.Set("grpc.aiGateway.addr", "localhost:9090")
 	client, err := ioc.InitGrpcClient()
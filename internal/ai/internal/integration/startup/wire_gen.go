@@ -9,24 +9,34 @@ package startup
 import (
 	"sync"
 
+	"github.com/ecodeclub/ecache"
 	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
 	"github.com/ecodeclub/webook/internal/ai"
 	"github.com/ecodeclub/webook/internal/ai/internal/event"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/ai/internal/service"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	cache2 "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/config"
 	credit2 "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/log"
 	hdlmocks "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/mocks"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/moderation"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/router"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/ratelimit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/record"
 	hdlmocks2 "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/stream_mocks"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/knowledge_base"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/knowledge_base/zhipu"
 	"github.com/ecodeclub/webook/internal/ai/internal/web"
 	"github.com/ecodeclub/webook/internal/credit"
+	moderation2 "github.com/ecodeclub/webook/internal/pkg/moderation"
+	"github.com/ecodeclub/webook/internal/pkg/pdf"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
 	"github.com/ecodeclub/webook/ioc"
 	"github.com/ego-component/egorm"
 	"github.com/gotomicro/ego/core/econf"
@@ -47,20 +57,44 @@ func InitModule(db *gorm.DB, hdl *hdlmocks.MockHandler, streamHandler *hdlmocks2
 	llmRecordDAO := dao.NewGORMLLMLogDAO(db)
 	llmLogRepo := repository.NewLLMLogRepo(llmRecordDAO)
 	recordHandlerBuilder := record.NewHandler(llmLogRepo)
-	v := ai.InitCommonHandlers(handlerBuilder, configHandlerBuilder, creditHandlerBuilder, recordHandlerBuilder)
+	ecacheCache := InitCache()
+	llmCache := cache.NewLLMCache(ecacheCache)
+	llmCacheRepo := repository.NewLLMCacheRepo(llmCache)
+	cacheHandlerBuilder := cache2.NewHandlerBuilder(llmCacheRepo)
+	moderationHandlerBuilder := InitModerationBuilder()
+	ratelimitHandlerBuilder := InitRateLimitBuilder()
+	structuredHandlerBuilder := structured.NewHandlerBuilder()
+	v := ai.InitCommonHandlers(handlerBuilder, configHandlerBuilder, creditHandlerBuilder, recordHandlerBuilder, cacheHandlerBuilder, moderationHandlerBuilder, ratelimitHandlerBuilder, structuredHandlerBuilder)
 	handler := InitRootHandler(v, hdl)
 	handlerStreamHandler := InitStreamHandler(streamHandler)
 	llmService := llm.NewLLMService(handler, handlerStreamHandler)
-	generalService := service.NewGeneralService(llmService)
+	conversationDAO := dao.NewGORMConversationDAO(db)
+	conversationRepository := repository.NewConversationRepository(conversationDAO)
+	conversationService := service.NewConversationService(conversationRepository, llmService)
+	generalService := service.NewGeneralService(llmService, conversationService)
 	jdService := service.NewJDService(llmService)
-	webHandler := web.NewHandler(generalService, jdService)
+	webHandler := web.NewHandler(generalService, jdService, conversationService)
 	configService := service.NewConfigService(configRepository)
-	adminHandler := web.NewAdminHandler(configService)
+	routerHandler := InitPlatformRouter(hdl)
+	platformService := service.NewPlatformService(routerHandler)
+	cacheService := service.NewCacheService(llmCacheRepo)
+	evalDAO := dao.NewGORMEvalDAO(db)
+	evalRepository := repository.NewEvalRepository(evalDAO)
+	evalService := service.NewEvalService(evalRepository, llmService)
+	adminHandler := web.NewAdminHandler(configService, platformService, cacheService, evalService)
 	serviceClient := InitGRPCClient()
 	mockInterviewDAO := dao.NewMockInterviewDAO(db)
 	mockInterviewRepository := repository.NewMockInterviewRepository(mockInterviewDAO)
-	mockInterviewService := service.NewMockInterviewService(mockInterviewRepository)
-	mockInterviewHandler := web.NewMockInterviewHandler(serviceClient, mockInterviewService)
+	knowledgeBaseDAO := dao.NewKnowledgeBaseDAO(db)
+	knowledgeBaseRepo := repository.NewKnowledgeBaseRepo(knowledgeBaseDAO)
+	converter := InitPDFConverter()
+	mockInterviewService := service.NewMockInterviewService(mockInterviewRepository, knowledgeBaseRepo, llmService, converter)
+	cmdable := testioc.InitRedis()
+	mockInterviewStreamCache := cache.NewMockInterviewStreamCache(cmdable)
+	mockInterviewStreamRepository := repository.NewMockInterviewStreamRepository(mockInterviewStreamCache)
+	mockInterviewStreamService := service.NewMockInterviewStreamService(serviceClient, mockInterviewStreamRepository)
+	mockInterviewHandler := web.NewMockInterviewHandler(serviceClient, mockInterviewService, mockInterviewStreamService)
+	privacyService := service.NewPrivacyService(llmLogRepo, conversationRepository, mockInterviewRepository)
 	module := &ai.Module{
 		Svc:              llmService,
 		KnowledgeBaseSvc: baseSvc,
@@ -68,6 +102,7 @@ func InitModule(db *gorm.DB, hdl *hdlmocks.MockHandler, streamHandler *hdlmocks2
 		AdminHandler:     adminHandler,
 		MockInterviewHdl: mockInterviewHandler,
 		C:                consumer,
+		PrivacySvc:       privacyService,
 	}
 	return module, nil
 }
@@ -89,6 +124,18 @@ func InitRootHandler(common []handler.Builder, hdl *hdlmocks.MockHandler) handle
 	return handler.NewCompositionHandler(common, hdl)
 }
 
+// InitPlatformRouter 测试里面直接使用 mock 作为根 Handler，这里只是为了查看平台状态
+func InitPlatformRouter(hdl *hdlmocks.MockHandler) *router.Handler {
+	return router.NewHandler([]router.Platform{
+		{Name: "mock", Weight: 100, Handler: hdl},
+	})
+}
+
+// InitPDFConverter 测试里面不会真的导出 PDF
+func InitPDFConverter() pdf.Converter {
+	return pdf.NewChromeDPConverter("")
+}
+
 func InitStreamHandler(streamHdl *hdlmocks2.MockStreamHandler) handler.StreamHandler {
 	return streamHdl
 }
@@ -109,6 +156,20 @@ func InitLLMCreditLogDAO(db *egorm.Component) dao.LLMCreditDAO {
 	return dao.NewLLMCreditLogDAO(db)
 }
 
+// InitRateLimitBuilder 测试里面默认不限流
+func InitRateLimitBuilder() *ratelimit.HandlerBuilder {
+	return ratelimit.NewHandlerBuilder(testioc.InitRedis(), nil, nil)
+}
+
+// InitModerationBuilder 测试的时候不审核内容
+func InitModerationBuilder() *moderation.HandlerBuilder {
+	return moderation.NewHandlerBuilder(moderation2.NewDictFilter(moderation2.Dict{}, nil))
+}
+
+func InitCache() ecache.Cache {
+	return testioc.InitCache()
+}
+
 func InitGRPCClient() chatv1.ServiceClient {
 	econf.Set("grpc.aiGateway.addr", "localhost:9090")
 	client, err := ioc.InitGrpcClient()
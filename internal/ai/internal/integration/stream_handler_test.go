@@ -9,9 +9,6 @@ import (
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/ali_deepseek"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
-	"github.com/ecodeclub/webook/internal/ai/internal/repository"
-	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
-	testioc "github.com/ecodeclub/webook/internal/test/ioc"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,6 +20,12 @@ func TestHandler_StreamHandle(t *testing.T) {
 		Uid:   23,
 		Tid:   "tid1",
 		Input: []string{"上海"},
+		Config: domain.BizConfig{
+			Biz:            "case",
+			Price:          1,
+			PromptTemplate: `请说一下%s天气`,
+			Model:          "deepseek-r1",
+		},
 	})
 	require.NoError(t, err)
 	// 修改后：
@@ -48,20 +51,5 @@ func TestHandler_StreamHandle(t *testing.T) {
 }
 
 func initHandler(t *testing.T) *ali_deepseek.Handler {
-	db := testioc.InitDB()
-	err := dao.InitTables(db)
-	require.NoError(t, err)
-	configDao := dao.NewGORMConfigDAO(db)
-	logDao := dao.NewGORMLLMLogDAO(db)
-	configRepo := repository.NewCachedConfigRepository(configDao)
-	logRepo := repository.NewLLMLogRepo(logDao)
-	_, err = configDao.Save(context.Background(), dao.BizConfig{
-		Id:             1,
-		Biz:            "case",
-		Price:          1,
-		PromptTemplate: `请说一下%s天气`,
-		Model:          "deepseek-r1",
-	})
-	require.NoError(t, err)
-	return ali_deepseek.NewHandler("sk-1ff9e16afa654f50a0a9c759bd59274d", logRepo, configRepo)
+	return ali_deepseek.NewHandler("sk-1ff9e16afa654f50a0a9c759bd59274d")
 }
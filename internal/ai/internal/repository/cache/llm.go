@@ -0,0 +1,97 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/ecache"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+)
+
+var ErrLLMResponseNotFound = errors.New("大模型回答缓存不存在")
+
+// disabledKey 缓存开关，存在这个 key 就说明管理员关闭了缓存
+const disabledKey = "llm_resp:disabled"
+
+type LLMCache interface {
+	Get(ctx context.Context, biz, key string) (domain.LLMResponse, error)
+	Set(ctx context.Context, biz, key string, resp domain.LLMResponse, ttl time.Duration) error
+	Enabled(ctx context.Context) (bool, error)
+	SetEnabled(ctx context.Context, enabled bool) error
+}
+
+type llmCache struct {
+	ec ecache.Cache
+}
+
+func NewLLMCache(ec ecache.Cache) LLMCache {
+	return &llmCache{
+		ec: &ecache.NamespaceCache{
+			C:         ec,
+			Namespace: "ai:",
+		},
+	}
+}
+
+func (c *llmCache) Get(ctx context.Context, biz, key string) (domain.LLMResponse, error) {
+	val := c.ec.Get(ctx, c.respKey(biz, key))
+	if val.KeyNotFound() {
+		return domain.LLMResponse{}, ErrLLMResponseNotFound
+	}
+	if val.Err != nil {
+		return domain.LLMResponse{}, fmt.Errorf("查询缓存出错 %w", val.Err)
+	}
+	data, err := val.String()
+	if err != nil {
+		return domain.LLMResponse{}, err
+	}
+	var resp domain.LLMResponse
+	err = json.Unmarshal([]byte(data), &resp)
+	return resp, err
+}
+
+func (c *llmCache) Set(ctx context.Context, biz, key string, resp domain.LLMResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return c.ec.Set(ctx, c.respKey(biz, key), string(data), ttl)
+}
+
+func (c *llmCache) Enabled(ctx context.Context) (bool, error) {
+	val := c.ec.Get(ctx, disabledKey)
+	if val.KeyNotFound() {
+		return true, nil
+	}
+	return false, val.Err
+}
+
+func (c *llmCache) SetEnabled(ctx context.Context, enabled bool) error {
+	if enabled {
+		_, err := c.ec.Delete(ctx, disabledKey)
+		return err
+	}
+	// 不设置过期时间，直到管理员重新打开
+	return c.ec.Set(ctx, disabledKey, "1", 0)
+}
+
+func (c *llmCache) respKey(biz, key string) string {
+	return fmt.Sprintf("llm_resp:%s:%s", biz, key)
+}
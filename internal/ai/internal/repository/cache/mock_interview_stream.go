@@ -0,0 +1,131 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrStreamNotFound = errors.New("模拟面试的流不存在或者已经过期")
+
+// MockInterviewStreamCache 缓存模拟面试流中的事件，用于断线之后重放
+type MockInterviewStreamCache interface {
+	// Init 记录流的所有者，并且标记有人在读
+	Init(ctx context.Context, streamID string, uid int64) error
+	Owner(ctx context.Context, streamID string) (int64, error)
+	// Append 追加一个事件，返回它的序号
+	Append(ctx context.Context, streamID string, frame domain.StreamFrame) (int64, error)
+	// Range 返回序号大于 after 的事件
+	Range(ctx context.Context, streamID string, after int64) ([]domain.StreamFrame, error)
+	// Touch 读取方续约，说明还有人在读
+	Touch(ctx context.Context, streamID string) error
+	// Alive 最近是否还有人在读
+	Alive(ctx context.Context, streamID string) (bool, error)
+}
+
+type mockInterviewStreamCache struct {
+	cmd redis.Cmdable
+	// 事件保留多久
+	expiration time.Duration
+	// 多久没有人读就认为被放弃了
+	aliveExpiration time.Duration
+}
+
+func NewMockInterviewStreamCache(cmd redis.Cmdable) MockInterviewStreamCache {
+	return &mockInterviewStreamCache{
+		cmd:             cmd,
+		expiration:      time.Minute * 5,
+		aliveExpiration: time.Second * 30,
+	}
+}
+
+type frame struct {
+	Event string `json:"event,omitempty"`
+	Data  string `json:"data"`
+}
+
+func (c *mockInterviewStreamCache) Init(ctx context.Context, streamID string, uid int64) error {
+	pipe := c.cmd.TxPipeline()
+	pipe.Set(ctx, c.key(streamID, "owner"), uid, c.expiration)
+	pipe.Set(ctx, c.key(streamID, "alive"), 1, c.aliveExpiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *mockInterviewStreamCache) Owner(ctx context.Context, streamID string) (int64, error) {
+	uid, err := c.cmd.Get(ctx, c.key(streamID, "owner")).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrStreamNotFound
+	}
+	return uid, err
+}
+
+func (c *mockInterviewStreamCache) Append(ctx context.Context, streamID string, f domain.StreamFrame) (int64, error) {
+	val, err := json.Marshal(frame{Event: f.Event, Data: f.Data})
+	if err != nil {
+		return 0, err
+	}
+	key := c.key(streamID, "events")
+	pipe := c.cmd.TxPipeline()
+	seq := pipe.RPush(ctx, key, val)
+	pipe.Expire(ctx, key, c.expiration)
+	pipe.Expire(ctx, c.key(streamID, "owner"), c.expiration)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return seq.Val(), nil
+}
+
+func (c *mockInterviewStreamCache) Range(ctx context.Context, streamID string, after int64) ([]domain.StreamFrame, error) {
+	vals, err := c.cmd.LRange(ctx, c.key(streamID, "events"), after, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.StreamFrame, 0, len(vals))
+	for i, val := range vals {
+		var f frame
+		err = json.Unmarshal([]byte(val), &f)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, domain.StreamFrame{
+			Seq:   after + int64(i) + 1,
+			Event: f.Event,
+			Data:  f.Data,
+		})
+	}
+	return res, nil
+}
+
+func (c *mockInterviewStreamCache) Touch(ctx context.Context, streamID string) error {
+	return c.cmd.Set(ctx, c.key(streamID, "alive"), 1, c.aliveExpiration).Err()
+}
+
+func (c *mockInterviewStreamCache) Alive(ctx context.Context, streamID string) (bool, error) {
+	cnt, err := c.cmd.Exists(ctx, c.key(streamID, "alive")).Result()
+	return cnt > 0, err
+}
+
+func (c *mockInterviewStreamCache) key(streamID, typ string) string {
+	return fmt.Sprintf("webook:ai:mock_interview:stream:%s:%s", streamID, typ)
+}
@@ -16,17 +16,24 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 )
 
+var ErrBizConfigNotFound = dao.ErrBizConfigNotFound
+
 type ConfigRepository interface {
 	GetConfig(ctx context.Context, biz string) (domain.BizConfig, error)
 	Save(ctx context.Context, cfg domain.BizConfig) (int64, error)
 	List(ctx context.Context) ([]domain.BizConfig, error)
 	GetById(ctx context.Context, id int64) (domain.BizConfig, error)
+	GetVersion(ctx context.Context, biz string, version int64) (domain.BizConfig, error)
+	ListVersions(ctx context.Context, biz string) ([]domain.BizConfig, error)
+	Rollback(ctx context.Context, id int64, version int64) error
+	SetExperiment(ctx context.Context, id int64, experiment domain.Experiment) error
 }
 
 // CachedConfigRepository 这个是一定要搞缓存的
@@ -52,6 +59,7 @@ func (r *CachedConfigRepository) Save(ctx context.Context, cfg domain.BizConfig)
 		SystemPrompt:   cfg.SystemPrompt,
 		PromptTemplate: cfg.PromptTemplate,
 		KnowledgeId:    cfg.KnowledgeId,
+		CacheTTL:       int64(cfg.CacheTTL / time.Second),
 	})
 }
 func (r *CachedConfigRepository) List(ctx context.Context) ([]domain.BizConfig, error) {
@@ -80,6 +88,49 @@ func (repo *CachedConfigRepository) GetConfig(ctx context.Context, biz string) (
 	return repo.toDomain(res), nil
 }
 
+func (repo *CachedConfigRepository) GetVersion(ctx context.Context, biz string, version int64) (domain.BizConfig, error) {
+	res, err := repo.dao.GetVersion(ctx, biz, version)
+	if err != nil {
+		return domain.BizConfig{}, err
+	}
+	return repo.versionToDomain(res), nil
+}
+
+func (repo *CachedConfigRepository) ListVersions(ctx context.Context, biz string) ([]domain.BizConfig, error) {
+	versions, err := repo.dao.ListVersions(ctx, biz)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(versions, func(idx int, src dao.BizConfigVersion) domain.BizConfig {
+		return repo.versionToDomain(src)
+	}), nil
+}
+
+func (repo *CachedConfigRepository) Rollback(ctx context.Context, id int64, version int64) error {
+	return repo.dao.Rollback(ctx, id, version)
+}
+
+func (repo *CachedConfigRepository) SetExperiment(ctx context.Context, id int64, experiment domain.Experiment) error {
+	return repo.dao.SetExperiment(ctx, id, experiment.Version, experiment.Ratio)
+}
+
+func (repo *CachedConfigRepository) versionToDomain(src dao.BizConfigVersion) domain.BizConfig {
+	return domain.BizConfig{
+		Biz:            src.Biz,
+		Model:          src.Model,
+		Price:          src.Price,
+		Temperature:    src.Temperature,
+		TopP:           src.TopP,
+		SystemPrompt:   src.SystemPrompt,
+		MaxInput:       src.MaxInput,
+		KnowledgeId:    src.KnowledgeId,
+		PromptTemplate: src.PromptTemplate,
+		CacheTTL:       time.Duration(src.CacheTTL) * time.Second,
+		Version:        src.Version,
+		Utime:          src.Ctime,
+	}
+}
+
 func (repo *CachedConfigRepository) toDomain(src dao.BizConfig) domain.BizConfig {
 	return domain.BizConfig{
 		Id:             src.Id,
@@ -92,6 +143,12 @@ func (repo *CachedConfigRepository) toDomain(src dao.BizConfig) domain.BizConfig
 		MaxInput:       src.MaxInput,
 		KnowledgeId:    src.KnowledgeId,
 		PromptTemplate: src.PromptTemplate,
-		Utime:          src.Utime,
+		CacheTTL:       time.Duration(src.CacheTTL) * time.Second,
+		Version:        src.Version,
+		Experiment: domain.Experiment{
+			Version: src.ExperimentVersion,
+			Ratio:   src.ExperimentRatio,
+		},
+		Utime: src.Utime,
 	}
 }
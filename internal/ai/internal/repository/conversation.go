@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
+)
+
+var ErrConversationNotFound = dao.ErrConversationNotFound
+
+type ConversationRepository interface {
+	// Create sn 已经存在的时候什么也不做
+	Create(ctx context.Context, c domain.Conversation) error
+	FindBySn(ctx context.Context, uid int64, sn string) (domain.Conversation, error)
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.Conversation, error)
+	UpdateSummary(ctx context.Context, sn string, summary string, msgId int64) error
+
+	AppendMessages(ctx context.Context, sn string, msgs ...domain.ConversationMessage) error
+	// FindMessages 按照时间先后返回 id 大于 afterId 的消息
+	FindMessages(ctx context.Context, sn string, afterId int64) ([]domain.ConversationMessage, error)
+	// DeleteByUid 删除用户所有的对话以及消息
+	DeleteByUid(ctx context.Context, uid int64) error
+}
+
+type conversationRepository struct {
+	dao dao.ConversationDAO
+}
+
+func NewConversationRepository(d dao.ConversationDAO) ConversationRepository {
+	return &conversationRepository{dao: d}
+}
+
+func (r *conversationRepository) Create(ctx context.Context, c domain.Conversation) error {
+	return r.dao.Create(ctx, dao.Conversation{
+		Sn:    c.Sn,
+		Uid:   c.Uid,
+		Biz:   c.Biz,
+		Title: c.Title,
+	})
+}
+
+func (r *conversationRepository) FindBySn(ctx context.Context, uid int64, sn string) (domain.Conversation, error) {
+	c, err := r.dao.FindBySn(ctx, uid, sn)
+	if err != nil {
+		return domain.Conversation{}, err
+	}
+	return r.toDomain(c), nil
+}
+
+func (r *conversationRepository) List(ctx context.Context, uid int64, offset, limit int) ([]domain.Conversation, error) {
+	list, err := r.dao.List(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(list, func(_ int, src dao.Conversation) domain.Conversation {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *conversationRepository) UpdateSummary(ctx context.Context, sn string, summary string, msgId int64) error {
+	return r.dao.UpdateSummary(ctx, sn, summary, msgId)
+}
+
+func (r *conversationRepository) AppendMessages(ctx context.Context, sn string, msgs ...domain.ConversationMessage) error {
+	return r.dao.AppendMessages(ctx, sn, slice.Map(msgs, func(_ int, src domain.ConversationMessage) dao.ConversationMessage {
+		return dao.ConversationMessage{
+			Role:    src.Role,
+			Content: src.Content,
+			Tokens:  src.Tokens,
+		}
+	}))
+}
+
+func (r *conversationRepository) FindMessages(ctx context.Context, sn string, afterId int64) ([]domain.ConversationMessage, error) {
+	list, err := r.dao.FindMessages(ctx, sn, afterId)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(list, func(_ int, src dao.ConversationMessage) domain.ConversationMessage {
+		return domain.ConversationMessage{
+			Id:      src.Id,
+			Sn:      src.Sn,
+			Role:    src.Role,
+			Content: src.Content,
+			Tokens:  src.Tokens,
+			Ctime:   src.Ctime,
+		}
+	}), nil
+}
+
+func (r *conversationRepository) DeleteByUid(ctx context.Context, uid int64) error {
+	return r.dao.DeleteByUid(ctx, uid)
+}
+
+func (r *conversationRepository) toDomain(c dao.Conversation) domain.Conversation {
+	return domain.Conversation{
+		Id:           c.Id,
+		Sn:           c.Sn,
+		Uid:          c.Uid,
+		Biz:          c.Biz,
+		Title:        c.Title,
+		Summary:      c.Summary,
+		SummaryMsgId: c.SummaryMsgId,
+		Ctime:        c.Ctime,
+		Utime:        c.Utime,
+	}
+}
@@ -19,14 +19,26 @@ import (
 	"time"
 
 	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// ErrBizConfigNotFound 业务没有配置
+var ErrBizConfigNotFound = gorm.ErrRecordNotFound
+
 type ConfigDAO interface {
 	GetConfig(ctx context.Context, biz string) (BizConfig, error)
 	Save(ctx context.Context, cfg BizConfig) (int64, error)
 	List(ctx context.Context) ([]BizConfig, error)
 	GetById(ctx context.Context, id int64) (BizConfig, error)
+	// GetVersion 获取某个历史版本
+	GetVersion(ctx context.Context, biz string, version int64) (BizConfigVersion, error)
+	// ListVersions 按照版本号倒序返回所有的历史版本
+	ListVersions(ctx context.Context, biz string) ([]BizConfigVersion, error)
+	// Rollback 把配置恢复成某个历史版本
+	Rollback(ctx context.Context, id int64, version int64) error
+	// SetExperiment 设置 A/B 实验，ratio 为 0 则关闭实验
+	SetExperiment(ctx context.Context, id int64, version int64, ratio int) error
 }
 
 type GORMConfigDAO struct {
@@ -56,17 +68,123 @@ func (dao *GORMConfigDAO) GetConfig(ctx context.Context, biz string) (BizConfig,
 	err := dao.db.WithContext(ctx).Where("biz = ?", biz).First(&res).Error
 	return res, err
 }
+
+// Save 每次保存都会生成一个新的版本
 func (dao *GORMConfigDAO) Save(ctx context.Context, cfg BizConfig) (int64, error) {
 	now := time.Now().UnixMilli()
 	cfg.Utime = now
 	cfg.Ctime = now
-	err := dao.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"biz", "max_input", "model", "price", "temperature", "top_p", "system_prompt", "prompt_template", "knowledge_id", "utime"}),
-	}).Create(&cfg).Error
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住这个 biz 的配置，同一个 biz 的保存排队执行，不然会算出同一个版本号
+		var old []BizConfig
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("biz = ?", cfg.Biz).Find(&old).Error
+		if err != nil {
+			return err
+		}
+		var version int64
+		err = tx.Model(&BizConfigVersion{}).
+			Select("COALESCE(MAX(version), 0)").
+			Where("biz = ?", cfg.Biz).
+			Scan(&version).Error
+		if err != nil {
+			return err
+		}
+		cfg.Version = version + 1
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"biz", "max_input", "model", "price", "temperature", "top_p", "system_prompt", "prompt_template", "knowledge_id", "cache_ttl", "version", "utime"}),
+		}).Create(&cfg).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&BizConfigVersion{
+			Biz:            cfg.Biz,
+			Version:        cfg.Version,
+			MaxInput:       cfg.MaxInput,
+			Model:          cfg.Model,
+			Price:          cfg.Price,
+			Temperature:    cfg.Temperature,
+			TopP:           cfg.TopP,
+			SystemPrompt:   cfg.SystemPrompt,
+			PromptTemplate: cfg.PromptTemplate,
+			KnowledgeId:    cfg.KnowledgeId,
+			CacheTTL:       cfg.CacheTTL,
+			Ctime:          now,
+		}).Error
+	})
 	return cfg.Id, err
 }
 
+func (dao *GORMConfigDAO) GetVersion(ctx context.Context, biz string, version int64) (BizConfigVersion, error) {
+	var res BizConfigVersion
+	err := dao.db.WithContext(ctx).
+		Where("biz = ? AND version = ?", biz, version).
+		First(&res).Error
+	return res, err
+}
+
+func (dao *GORMConfigDAO) ListVersions(ctx context.Context, biz string) ([]BizConfigVersion, error) {
+	var res []BizConfigVersion
+	err := dao.db.WithContext(ctx).
+		Where("biz = ?", biz).
+		Order("version desc").
+		Find(&res).Error
+	return res, err
+}
+
+// Rollback 回滚不会生成新的版本，只是把当前版本指向历史版本
+func (dao *GORMConfigDAO) Rollback(ctx context.Context, id int64, version int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cfg BizConfig
+		err := tx.Where("id = ?", id).First(&cfg).Error
+		if err != nil {
+			return err
+		}
+		var v BizConfigVersion
+		err = tx.Where("biz = ? AND version = ?", cfg.Biz, version).First(&v).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&BizConfig{}).Where("id = ?", id).Updates(map[string]any{
+			"max_input":       v.MaxInput,
+			"model":           v.Model,
+			"price":           v.Price,
+			"temperature":     v.Temperature,
+			"top_p":           v.TopP,
+			"system_prompt":   v.SystemPrompt,
+			"prompt_template": v.PromptTemplate,
+			"knowledge_id":    v.KnowledgeId,
+			"cache_ttl":       v.CacheTTL,
+			"version":         v.Version,
+			"utime":           time.Now().UnixMilli(),
+		}).Error
+	})
+}
+
+func (dao *GORMConfigDAO) SetExperiment(ctx context.Context, id int64, version int64, ratio int) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cfg BizConfig
+		err := tx.Where("id = ?", id).First(&cfg).Error
+		if err != nil {
+			return err
+		}
+		if ratio > 0 {
+			// 确保实验版本是存在的
+			err = tx.Where("biz = ? AND version = ?", cfg.Biz, version).
+				First(&BizConfigVersion{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&BizConfig{}).Where("id = ?", id).Updates(map[string]any{
+			"experiment_version": version,
+			"experiment_ratio":   ratio,
+			"utime":              time.Now().UnixMilli(),
+		}).Error
+	})
+}
+
 type BizConfig struct {
 	Id          int64  `gorm:"primaryKey;autoIncrement;comment:AI biz 配置表ID"`
 	Biz         string `gorm:"type:varchar(256);uniqueIndex;not null;comment:业务类型名"`
@@ -79,6 +197,11 @@ type BizConfig struct {
 	SystemPrompt   string
 	PromptTemplate string
 	KnowledgeId    string `gorm:"type:varchar(256);not null;comment:使用的知识库 ID"`
+	CacheTTL       int64  `gorm:"not null;default:0;comment:回答缓存时间，单位秒，0 表示不缓存"`
+	Version        int64  `gorm:"not null;default:0;comment:当前生效的版本"`
+	// A/B 实验
+	ExperimentVersion int64 `gorm:"not null;default:0;comment:实验组使用的版本"`
+	ExperimentRatio   int   `gorm:"not null;default:0;comment:实验组的流量百分比，0 表示没有实验"`
 	// 其它字段按需添加
 	Ctime int64
 	Utime int64
@@ -87,3 +210,24 @@ type BizConfig struct {
 func (c BizConfig) TableName() string {
 	return "ai_biz_configs"
 }
+
+// BizConfigVersion BizConfig 的历史版本，只增不改
+type BizConfigVersion struct {
+	Id             int64  `gorm:"primaryKey;autoIncrement"`
+	Biz            string `gorm:"type:varchar(256);uniqueIndex:idx_biz_version;not null;comment:业务类型名"`
+	Version        int64  `gorm:"uniqueIndex:idx_biz_version;not null;comment:版本号，从 1 开始"`
+	MaxInput       int
+	Model          string `gorm:"type:varchar(256)"`
+	Price          int64
+	Temperature    float64
+	TopP           float64
+	SystemPrompt   string
+	PromptTemplate string
+	KnowledgeId    string `gorm:"type:varchar(256);not null"`
+	CacheTTL       int64  `gorm:"not null;default:0"`
+	Ctime          int64
+}
+
+func (c BizConfigVersion) TableName() string {
+	return "ai_biz_config_versions"
+}
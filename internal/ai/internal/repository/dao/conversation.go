@@ -0,0 +1,139 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrConversationNotFound = gorm.ErrRecordNotFound
+
+type ConversationDAO interface {
+	// Create sn 已经存在的时候什么也不做
+	Create(ctx context.Context, c Conversation) error
+	FindBySn(ctx context.Context, uid int64, sn string) (Conversation, error)
+	List(ctx context.Context, uid int64, offset, limit int) ([]Conversation, error)
+	UpdateSummary(ctx context.Context, sn string, summary string, msgId int64) error
+
+	// AppendMessages 保存消息，同时更新对话的更新时间
+	AppendMessages(ctx context.Context, sn string, msgs []ConversationMessage) error
+	// FindMessages 按照 id 升序返回 id 大于 afterId 的消息
+	FindMessages(ctx context.Context, sn string, afterId int64) ([]ConversationMessage, error)
+	// DeleteByUid 删除用户所有的对话以及消息
+	DeleteByUid(ctx context.Context, uid int64) error
+}
+
+type GORMConversationDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMConversationDAO(db *egorm.Component) ConversationDAO {
+	return &GORMConversationDAO{db: db}
+}
+
+func (d *GORMConversationDAO) Create(ctx context.Context, c Conversation) error {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sn"}},
+		DoNothing: true,
+	}).Create(&c).Error
+}
+
+func (d *GORMConversationDAO) FindBySn(ctx context.Context, uid int64, sn string) (Conversation, error) {
+	var res Conversation
+	err := d.db.WithContext(ctx).
+		Where("sn = ? AND uid = ?", sn, uid).
+		First(&res).Error
+	return res, err
+}
+
+func (d *GORMConversationDAO) List(ctx context.Context, uid int64, offset, limit int) ([]Conversation, error) {
+	var res []Conversation
+	err := d.db.WithContext(ctx).
+		Where("uid = ?", uid).
+		Order("utime DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *GORMConversationDAO) UpdateSummary(ctx context.Context, sn string, summary string, msgId int64) error {
+	return d.db.WithContext(ctx).Model(&Conversation{}).
+		Where("sn = ?", sn).
+		Updates(map[string]any{
+			"summary":        summary,
+			"summary_msg_id": msgId,
+			"utime":          time.Now().UnixMilli(),
+		}).Error
+}
+
+func (d *GORMConversationDAO) AppendMessages(ctx context.Context, sn string, msgs []ConversationMessage) error {
+	now := time.Now().UnixMilli()
+	for i := range msgs {
+		msgs[i].Sn = sn
+		msgs[i].Ctime = now
+	}
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&msgs).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Conversation{}).
+			Where("sn = ?", sn).
+			Update("utime", now).Error
+	})
+}
+
+func (d *GORMConversationDAO) FindMessages(ctx context.Context, sn string, afterId int64) ([]ConversationMessage, error) {
+	var res []ConversationMessage
+	err := d.db.WithContext(ctx).
+		Where("sn = ? AND id > ?", sn, afterId).
+		Order("id ASC").
+		Find(&res).Error
+	return res, err
+}
+
+func (d *GORMConversationDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sns := tx.Model(&Conversation{}).Select("sn").Where("uid = ?", uid)
+		err := tx.Where("sn IN (?)", sns).Delete(&ConversationMessage{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", uid).Delete(&Conversation{}).Error
+	})
+}
+
+type Conversation struct {
+	Id           int64  `gorm:"primaryKey;autoIncrement"`
+	Sn           string `gorm:"type:varchar(255);uniqueIndex;comment:前端生成的会话SN"`
+	Uid          int64  `gorm:"index:idx_uid_utime,priority:1"`
+	Biz          string `gorm:"type:varchar(256)"`
+	Title        string `gorm:"type:varchar(255);comment:第一次提问的内容"`
+	Summary      string `gorm:"type:text;comment:已经被摘要的旧消息"`
+	SummaryMsgId int64  `gorm:"comment:摘要覆盖到的最后一条消息"`
+	Ctime        int64
+	Utime        int64 `gorm:"index:idx_uid_utime,priority:2"`
+}
+
+func (Conversation) TableName() string {
+	return "ai_conversations"
+}
+
+type ConversationMessage struct {
+	Id      int64  `gorm:"primaryKey;autoIncrement"`
+	Sn      string `gorm:"type:varchar(255);index"`
+	Role    string `gorm:"type:varchar(32)"`
+	Content string `gorm:"type:text"`
+	Tokens  int64
+	Ctime   int64
+}
+
+func (ConversationMessage) TableName() string {
+	return "ai_conversation_messages"
+}
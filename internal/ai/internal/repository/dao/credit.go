@@ -45,7 +45,7 @@ func (g *GORMLLMCreditDAO) SaveCredit(ctx context.Context, l LLMCredit) (int64,
 	err := g.db.WithContext(ctx).Model(&LLMCredit{}).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"status", "utime"}),
+			DoUpdates: clause.AssignmentColumns([]string{"amount", "status", "utime"}),
 		}).Create(&l).Error
 	return l.Id, err
 }
@@ -0,0 +1,199 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ecodeclub/ekit/sqlx"
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrEvalRunNotFound = gorm.ErrRecordNotFound
+
+type EvalDAO interface {
+	SaveCase(ctx context.Context, c EvalCase) (int64, error)
+	DeleteCase(ctx context.Context, id int64) error
+	ListCases(ctx context.Context, biz string, offset, limit int) ([]EvalCase, error)
+	CountCases(ctx context.Context, biz string) (int64, error)
+	// FindAllCases 按照 id 升序返回业务的所有用例
+	FindAllCases(ctx context.Context, biz string) ([]EvalCase, error)
+
+	CreateRun(ctx context.Context, run EvalRun) (int64, error)
+	FinishRun(ctx context.Context, id int64, status uint8, report EvalReport) error
+	GetRun(ctx context.Context, id int64) (EvalRun, error)
+	ListRuns(ctx context.Context, biz string, offset, limit int) ([]EvalRun, error)
+	CountRuns(ctx context.Context, biz string) (int64, error)
+
+	SaveResults(ctx context.Context, results []EvalResult) error
+	FindResults(ctx context.Context, runId int64) ([]EvalResult, error)
+}
+
+type GORMEvalDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMEvalDAO(db *egorm.Component) EvalDAO {
+	return &GORMEvalDAO{db: db}
+}
+
+func (d *GORMEvalDAO) SaveCase(ctx context.Context, c EvalCase) (int64, error) {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	err := d.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{
+			"biz", "input", "expected_level", "keywords", "utime",
+		}),
+	}).Create(&c).Error
+	return c.Id, err
+}
+
+func (d *GORMEvalDAO) DeleteCase(ctx context.Context, id int64) error {
+	return d.db.WithContext(ctx).Where("id = ?", id).Delete(&EvalCase{}).Error
+}
+
+func (d *GORMEvalDAO) ListCases(ctx context.Context, biz string, offset, limit int) ([]EvalCase, error) {
+	var res []EvalCase
+	err := d.db.WithContext(ctx).Where("biz = ?", biz).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *GORMEvalDAO) CountCases(ctx context.Context, biz string) (int64, error) {
+	var res int64
+	err := d.db.WithContext(ctx).Model(&EvalCase{}).Where("biz = ?", biz).Count(&res).Error
+	return res, err
+}
+
+func (d *GORMEvalDAO) FindAllCases(ctx context.Context, biz string) ([]EvalCase, error) {
+	var res []EvalCase
+	err := d.db.WithContext(ctx).Where("biz = ?", biz).Order("id ASC").Find(&res).Error
+	return res, err
+}
+
+func (d *GORMEvalDAO) CreateRun(ctx context.Context, run EvalRun) (int64, error) {
+	now := time.Now().UnixMilli()
+	run.Ctime = now
+	run.Utime = now
+	err := d.db.WithContext(ctx).Create(&run).Error
+	return run.Id, err
+}
+
+func (d *GORMEvalDAO) FinishRun(ctx context.Context, id int64, status uint8, report EvalReport) error {
+	return d.db.WithContext(ctx).Model(&EvalRun{}).Where("id = ?", id).
+		Updates(map[string]any{
+			"status": status,
+			"report": sqlx.JsonColumn[EvalReport]{Val: report, Valid: true},
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+func (d *GORMEvalDAO) GetRun(ctx context.Context, id int64) (EvalRun, error) {
+	var res EvalRun
+	err := d.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (d *GORMEvalDAO) ListRuns(ctx context.Context, biz string, offset, limit int) ([]EvalRun, error) {
+	var res []EvalRun
+	err := d.db.WithContext(ctx).Where("biz = ?", biz).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (d *GORMEvalDAO) CountRuns(ctx context.Context, biz string) (int64, error) {
+	var res int64
+	err := d.db.WithContext(ctx).Model(&EvalRun{}).Where("biz = ?", biz).Count(&res).Error
+	return res, err
+}
+
+func (d *GORMEvalDAO) SaveResults(ctx context.Context, results []EvalResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range results {
+		results[i].Ctime = now
+	}
+	return d.db.WithContext(ctx).Create(&results).Error
+}
+
+func (d *GORMEvalDAO) FindResults(ctx context.Context, runId int64) ([]EvalResult, error) {
+	var res []EvalResult
+	err := d.db.WithContext(ctx).Where("run_id = ?", runId).Order("case_id ASC").Find(&res).Error
+	return res, err
+}
+
+// EvalCase 离线评估的用例
+type EvalCase struct {
+	Id            int64                     `gorm:"primaryKey;autoIncrement"`
+	Biz           string                    `gorm:"type:varchar(256);index"`
+	Input         sqlx.JsonColumn[[]string] `gorm:"type:json;comment:用户的输入"`
+	ExpectedLevel string                    `gorm:"type:varchar(64);comment:期望的评级"`
+	Keywords      sqlx.JsonColumn[[]string] `gorm:"type:json;comment:回答中应当出现的关键字"`
+	Ctime         int64
+	Utime         int64
+}
+
+func (EvalCase) TableName() string {
+	return "ai_eval_cases"
+}
+
+// EvalRun 一次离线评估
+type EvalRun struct {
+	Id            int64                       `gorm:"primaryKey;autoIncrement"`
+	Biz           string                      `gorm:"type:varchar(256);index"`
+	ConfigVersion int64                       `gorm:"comment:使用的配置版本，0 表示当前版本"`
+	Model         string                      `gorm:"type:varchar(256);comment:覆盖配置中的模型"`
+	Levels        sqlx.JsonColumn[[]string]   `gorm:"type:json;comment:可能出现的评级"`
+	Status        uint8                       `gorm:"type:tinyint(3);comment:0-运行中 1-成功 2-失败"`
+	Report        sqlx.JsonColumn[EvalReport] `gorm:"type:json;comment:运行结束之后的汇总"`
+	Ctime         int64
+	Utime         int64
+}
+
+func (EvalRun) TableName() string {
+	return "ai_eval_runs"
+}
+
+type EvalReport struct {
+	Total          int     `json:"total"`
+	Failed         int     `json:"failed"`
+	Leveled        int     `json:"leveled"`
+	LevelMatched   int     `json:"levelMatched"`
+	LevelAgreement float64 `json:"levelAgreement"`
+	Keywords       int     `json:"keywords"`
+	HitKeywords    int     `json:"hitKeywords"`
+	KeywordRecall  float64 `json:"keywordRecall"`
+	Tokens         int64   `json:"tokens"`
+	Amount         int64   `json:"amount"`
+	// 毫秒
+	AvgLatency int64 `json:"avgLatency"`
+	P90Latency int64 `json:"p90Latency"`
+	MaxLatency int64 `json:"maxLatency"`
+}
+
+// EvalResult 一条用例的评估结果
+type EvalResult struct {
+	Id             int64 `gorm:"primaryKey;autoIncrement"`
+	RunId          int64 `gorm:"index"`
+	CaseId         int64
+	Answer         string                    `gorm:"type:text"`
+	ExpectedLevel  string                    `gorm:"type:varchar(64)"`
+	Level          string                    `gorm:"type:varchar(64);comment:从回答中识别出来的评级"`
+	HitKeywords    sqlx.JsonColumn[[]string] `gorm:"type:json"`
+	MissedKeywords sqlx.JsonColumn[[]string] `gorm:"type:json"`
+	Tokens         int64
+	Amount         int64
+	Latency        int64  `gorm:"comment:耗时，毫秒"`
+	Err            string `gorm:"type:text;comment:调用大模型失败的原因"`
+	Ctime          int64
+}
+
+func (EvalResult) TableName() string {
+	return "ai_eval_results"
+}
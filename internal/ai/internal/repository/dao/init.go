@@ -7,8 +7,14 @@ func InitTables(db *egorm.Component) error {
 		&LLMCredit{},
 		&LLMRecord{},
 		&BizConfig{},
+		&BizConfigVersion{},
 		&KnowledgeBaseFile{},
 		&MockInterview{},
 		&MockInterviewQuestion{},
+		&Conversation{},
+		&ConversationMessage{},
+		&EvalCase{},
+		&EvalRun{},
+		&EvalResult{},
 	)
 }
@@ -3,6 +3,8 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ecodeclub/ekit/sqlx"
@@ -11,14 +13,30 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+var (
+	ErrMockInterviewNotFound = gorm.ErrRecordNotFound
+	// ErrReportConflict 报告已经生成，或者正在由别的请求生成
+	ErrReportConflict = errors.New("模拟面试报告已经生成或者正在生成")
+)
+
 type MockInterviewDAO interface {
 	SaveInterview(ctx context.Context, mi MockInterview) (int64, error)
 	FindInterviews(ctx context.Context, uid int64, limit, offset int) ([]MockInterview, error)
 	CountInterviews(ctx context.Context, uid int64) (int64, error)
+	FindInterviewBySN(ctx context.Context, uid int64, chatSN string) (MockInterview, error)
+	// StartReport 占用生成报告的资格，reporting_at 早于 expiredBefore 的占用视为已经失效
+	StartReport(ctx context.Context, id int64, expiredBefore int64) error
+	// CancelReport 生成报告失败之后释放占用
+	CancelReport(ctx context.Context, id int64) error
+	// SaveReport 只有还没有报告的时候才会保存
+	SaveReport(ctx context.Context, id int64, report MockInterviewReport) error
 
 	SaveQuestion(ctx context.Context, q MockInterviewQuestion) (int64, error)
 	FindQuestions(ctx context.Context, interviewID, uid int64, limit, offset int) ([]MockInterviewQuestion, error)
 	CountQuestions(ctx context.Context, interviewID int64, uid int64) (int64, error)
+
+	// DeleteByUid 删除用户所有的模拟面试以及题目
+	DeleteByUid(ctx context.Context, uid int64) error
 }
 
 type GORMMockInterviewDAO struct {
@@ -64,6 +82,56 @@ func (d *GORMMockInterviewDAO) CountInterviews(ctx context.Context, uid int64) (
 	return count, err
 }
 
+func (d *GORMMockInterviewDAO) FindInterviewBySN(ctx context.Context, uid int64, chatSN string) (MockInterview, error) {
+	var res MockInterview
+	err := d.db.WithContext(ctx).
+		Where("chat_sn = ? AND uid = ?", chatSN, uid).
+		First(&res).Error
+	return res, err
+}
+
+func (d *GORMMockInterviewDAO) StartReport(ctx context.Context, id int64, expiredBefore int64) error {
+	now := time.Now().UnixMilli()
+	res := d.db.WithContext(ctx).Model(&MockInterview{}).
+		Where("id = ? AND report IS NULL AND reporting_at <= ?", id, expiredBefore).
+		Updates(map[string]any{
+			"reporting_at": now,
+			"utime":        now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w, id %d", ErrReportConflict, id)
+	}
+	return nil
+}
+
+func (d *GORMMockInterviewDAO) CancelReport(ctx context.Context, id int64) error {
+	return d.db.WithContext(ctx).Model(&MockInterview{}).
+		Where("id = ? AND report IS NULL", id).
+		Updates(map[string]any{
+			"reporting_at": 0,
+			"utime":        time.Now().UnixMilli(),
+		}).Error
+}
+
+func (d *GORMMockInterviewDAO) SaveReport(ctx context.Context, id int64, report MockInterviewReport) error {
+	res := d.db.WithContext(ctx).Model(&MockInterview{}).
+		Where("id = ? AND report IS NULL", id).
+		Updates(map[string]any{
+			"report": sqlx.JsonColumn[MockInterviewReport]{Val: report, Valid: true},
+			"utime":  time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w, id %d", ErrReportConflict, id)
+	}
+	return nil
+}
+
 func (d *GORMMockInterviewDAO) SaveQuestion(ctx context.Context, q MockInterviewQuestion) (int64, error) {
 	now := time.Now().UnixMilli()
 	var retID int64
@@ -109,18 +177,56 @@ func (d *GORMMockInterviewDAO) CountQuestions(ctx context.Context, interviewID i
 	return count, err
 }
 
+func (d *GORMMockInterviewDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("uid = ?", uid).Delete(&MockInterviewQuestion{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", uid).Delete(&MockInterview{}).Error
+	})
+}
+
 type MockInterview struct {
-	ID         int64                           `gorm:"primaryKey;autoIncrement;comment:自增ID"`
-	ChatSN     string                          `gorm:"type:varchar(255);not null;uniqueIndex:uk_chat_sn;comment:外部会话SN"`
-	Uid        int64                           `gorm:"not null;index:idx_uid;comment:用户UID"`
-	Title      string                          `gorm:"type:varchar(255);not null;comment:面试名称"`
-	Evaluation sqlx.JsonColumn[map[string]any] `gorm:"type:json;comment:本场面试总体评价JSON"`
-	Ctime      int64                           `gorm:"not null;comment:创建时间"`
-	Utime      int64                           `gorm:"not null;comment:更新时间"`
+	ID         int64                                `gorm:"primaryKey;autoIncrement;comment:自增ID"`
+	ChatSN     string                               `gorm:"type:varchar(255);not null;uniqueIndex:uk_chat_sn;comment:外部会话SN"`
+	Uid        int64                                `gorm:"not null;index:idx_uid;comment:用户UID"`
+	Title      string                               `gorm:"type:varchar(255);not null;comment:面试名称"`
+	Evaluation sqlx.JsonColumn[map[string]any]      `gorm:"type:json;comment:本场面试总体评价JSON"`
+	Report     sqlx.JsonColumn[MockInterviewReport] `gorm:"type:json;comment:面试结束之后生成的报告JSON"`
+	// ReportingAt 开始生成报告的时间，避免并发结束面试的时候重复调用大模型
+	ReportingAt int64 `gorm:"not null;default:0;comment:开始生成报告的时间"`
+	Ctime       int64 `gorm:"not null;comment:创建时间"`
+	Utime       int64 `gorm:"not null;comment:更新时间"`
 }
 
 func (MockInterview) TableName() string { return "mock_interviews" }
 
+type MockInterviewReport struct {
+	Result          uint8                         `json:"result"`
+	Score           float64                       `json:"score"`
+	Strengths       []string                      `json:"strengths"`
+	Weaknesses      []string                      `json:"weaknesses"`
+	Questions       []MockInterviewResult         `json:"questions"`
+	Recommendations []MockInterviewRecommendation `json:"recommendations"`
+	Ctime           int64                         `json:"ctime"`
+}
+
+type MockInterviewResult struct {
+	QuestionID int64  `json:"questionId"`
+	Biz        string `json:"biz"`
+	BizID      int64  `json:"bizId"`
+	Title      string `json:"title"`
+	Result     uint8  `json:"result"`
+}
+
+type MockInterviewRecommendation struct {
+	Biz    string `json:"biz"`
+	BizID  int64  `json:"bizId"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
 type MockInterviewQuestion struct {
 	ID          int64                           `gorm:"primaryKey;autoIncrement;comment:自增ID"`
 	InterviewID int64                           `gorm:"not null;index:idx_miq_iid;comment:模拟面试ID"`
@@ -26,6 +26,9 @@ import (
 
 type LLMRecordDAO interface {
 	Save(ctx context.Context, r LLMRecord) (int64, error)
+	// FindByUid 按照 id 升序返回用户的调用记录
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]LLMRecord, error)
+	DeleteByUid(ctx context.Context, uid int64) error
 }
 
 // GORMLLMLogDAO => GORM LLM LogDAO
@@ -49,6 +52,18 @@ func (g *GORMLLMLogDAO) Save(ctx context.Context, record LLMRecord) (int64, erro
 	return record.Id, err
 }
 
+func (g *GORMLLMLogDAO) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]LLMRecord, error) {
+	var res []LLMRecord
+	err := g.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id ASC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (g *GORMLLMLogDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return g.db.WithContext(ctx).Where("uid = ?", uid).Delete(&LLMRecord{}).Error
+}
+
 func (g *GORMLLMLogDAO) FirstLog(ctx context.Context, id int64) (*LLMRecord, error) {
 	logModel := &LLMRecord{}
 	err := g.db.WithContext(ctx).Model(&LLMRecord{}).Where("id = ?", id).First(logModel).Error
@@ -56,17 +71,20 @@ func (g *GORMLLMLogDAO) FirstLog(ctx context.Context, id int64) (*LLMRecord, err
 }
 
 type LLMRecord struct {
-	Id             int64                     `gorm:"primaryKey;autoIncrement;comment:积分流水表自增ID"`
-	Tid            string                    `gorm:"type:varchar(256);not null;uniqueIndex:unq_tid;comment:一次请求的Tid只能有一次"`
-	Uid            int64                     `gorm:"not null;index:idx_user_id;comment:用户ID"`
-	Biz            string                    `gorm:"type:varchar(256);not null;comment:业务类型名"`
-	Tokens         int64                     `gorm:"type:int;default:0;comment:扣费token数"`
-	Amount         int64                     `gorm:"type:int;default:0;comment:具体扣费的换算的钱，分为单位"`
-	Status         uint8                     `gorm:"type:tinyint unsigned;not null;default:1;comment:调用状态 1=成功, 2=失败"`
-	Input          sqlx.JsonColumn[[]string] `gorm:"type:text;comment:调用请求的参数"`
-	KnowledgeId    string                    `gorm:"type:varchar(256);not null;comment:使用的知识库 ID"`
-	PromptTemplate sql.NullString            `gorm:"type:text;comment:PromptTemplate 模板，加上请求参数构成一个完整的 prompt"`
-	Answer         sql.NullString            `gorm:"type:text;comment:llm的回答"`
+	Id             int64                         `gorm:"primaryKey;autoIncrement;comment:积分流水表自增ID"`
+	Tid            string                        `gorm:"type:varchar(256);not null;uniqueIndex:unq_tid;comment:一次请求的Tid只能有一次"`
+	Uid            int64                         `gorm:"not null;index:idx_user_id;comment:用户ID"`
+	Biz            string                        `gorm:"type:varchar(256);not null;comment:业务类型名"`
+	Tokens         int64                         `gorm:"type:int;default:0;comment:扣费token数"`
+	Amount         int64                         `gorm:"type:int;default:0;comment:具体扣费的换算的钱，分为单位"`
+	Status         uint8                         `gorm:"type:tinyint unsigned;not null;default:1;comment:调用状态 1=成功, 2=失败"`
+	Input          sqlx.JsonColumn[[]string]     `gorm:"type:text;comment:调用请求的参数"`
+	KnowledgeId    string                        `gorm:"type:varchar(256);not null;comment:使用的知识库 ID"`
+	PromptTemplate sql.NullString                `gorm:"type:text;comment:PromptTemplate 模板，加上请求参数构成一个完整的 prompt"`
+	Answer         sql.NullString                `gorm:"type:text;comment:llm的回答"`
+	Platform       string                        `gorm:"type:varchar(64);not null;default:'';comment:实际提供服务的大模型平台"`
+	ConfigVersion  int64                         `gorm:"not null;default:0;comment:使用的 BizConfig 版本"`
+	Moderations    sqlx.JsonColumn[[]Moderation] `gorm:"type:json;comment:内容审核的结论"`
 	Ctime          int64
 	Utime          int64
 }
@@ -74,3 +92,10 @@ type LLMRecord struct {
 func (l LLMRecord) TableName() string {
 	return "llm_records"
 }
+
+type Moderation struct {
+	Stage  string   `json:"stage"`
+	Action string   `json:"action"`
+	Source string   `json:"source"`
+	Hits   []string `json:"hits"`
+}
@@ -16,6 +16,8 @@ var ErrBaseFileNotFound = gorm.ErrRecordNotFound
 type KnowledgeBaseDAO interface {
 	Save(ctx context.Context, file KnowledgeBaseFile) error
 	GetInfo(ctx context.Context, platform, baseID, name string) (KnowledgeBaseFile, error)
+	// FindBizIDs 找出 bizIDs 中已经上传到知识库的
+	FindBizIDs(ctx context.Context, biz string, bizIDs []int64) ([]int64, error)
 }
 
 type KnowledgeBaseFile struct {
@@ -77,3 +79,11 @@ func (r *knowledgeBaseDAO) GetInfo(ctx context.Context, platform, baseID, name s
 	}
 	return file, nil
 }
+
+func (r *knowledgeBaseDAO) FindBizIDs(ctx context.Context, biz string, bizIDs []int64) ([]int64, error) {
+	var res []int64
+	err := r.db.WithContext(ctx).Model(&KnowledgeBaseFile{}).
+		Where("biz = ? AND biz_id IN ?", biz, bizIDs).
+		Distinct().Pluck("biz_id", &res).Error
+	return res, err
+}
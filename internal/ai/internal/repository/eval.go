@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ekit/sqlx"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
+)
+
+var ErrEvalRunNotFound = dao.ErrEvalRunNotFound
+
+type EvalRepository interface {
+	SaveCase(ctx context.Context, c domain.EvalCase) (int64, error)
+	DeleteCase(ctx context.Context, id int64) error
+	ListCases(ctx context.Context, biz string, offset, limit int) ([]domain.EvalCase, int64, error)
+	FindAllCases(ctx context.Context, biz string) ([]domain.EvalCase, error)
+
+	CreateRun(ctx context.Context, run domain.EvalRun) (int64, error)
+	FinishRun(ctx context.Context, id int64, status domain.EvalRunStatus, report domain.EvalReport) error
+	GetRun(ctx context.Context, id int64) (domain.EvalRun, error)
+	ListRuns(ctx context.Context, biz string, offset, limit int) ([]domain.EvalRun, int64, error)
+
+	SaveResults(ctx context.Context, results []domain.EvalResult) error
+	FindResults(ctx context.Context, runId int64) ([]domain.EvalResult, error)
+}
+
+type evalRepository struct {
+	dao dao.EvalDAO
+}
+
+func NewEvalRepository(d dao.EvalDAO) EvalRepository {
+	return &evalRepository{dao: d}
+}
+
+func (r *evalRepository) SaveCase(ctx context.Context, c domain.EvalCase) (int64, error) {
+	return r.dao.SaveCase(ctx, dao.EvalCase{
+		Id:            c.Id,
+		Biz:           c.Biz,
+		Input:         sqlx.JsonColumn[[]string]{Val: c.Input, Valid: true},
+		ExpectedLevel: c.ExpectedLevel,
+		Keywords:      sqlx.JsonColumn[[]string]{Val: c.Keywords, Valid: true},
+	})
+}
+
+func (r *evalRepository) DeleteCase(ctx context.Context, id int64) error {
+	return r.dao.DeleteCase(ctx, id)
+}
+
+func (r *evalRepository) ListCases(ctx context.Context, biz string, offset, limit int) ([]domain.EvalCase, int64, error) {
+	cases, err := r.dao.ListCases(ctx, biz, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.dao.CountCases(ctx, biz)
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice.Map(cases, func(idx int, src dao.EvalCase) domain.EvalCase {
+		return r.caseToDomain(src)
+	}), total, nil
+}
+
+func (r *evalRepository) FindAllCases(ctx context.Context, biz string) ([]domain.EvalCase, error) {
+	cases, err := r.dao.FindAllCases(ctx, biz)
+	return slice.Map(cases, func(idx int, src dao.EvalCase) domain.EvalCase {
+		return r.caseToDomain(src)
+	}), err
+}
+
+func (r *evalRepository) CreateRun(ctx context.Context, run domain.EvalRun) (int64, error) {
+	return r.dao.CreateRun(ctx, dao.EvalRun{
+		Biz:           run.Biz,
+		ConfigVersion: run.ConfigVersion,
+		Model:         run.Model,
+		Levels:        sqlx.JsonColumn[[]string]{Val: run.Levels, Valid: true},
+		Status:        run.Status.ToUint8(),
+	})
+}
+
+func (r *evalRepository) FinishRun(ctx context.Context, id int64, status domain.EvalRunStatus, report domain.EvalReport) error {
+	return r.dao.FinishRun(ctx, id, status.ToUint8(), dao.EvalReport{
+		Total:          report.Total,
+		Failed:         report.Failed,
+		Leveled:        report.Leveled,
+		LevelMatched:   report.LevelMatched,
+		LevelAgreement: report.LevelAgreement,
+		Keywords:       report.Keywords,
+		HitKeywords:    report.HitKeywords,
+		KeywordRecall:  report.KeywordRecall,
+		Tokens:         report.Tokens,
+		Amount:         report.Amount,
+		AvgLatency:     report.AvgLatency.Milliseconds(),
+		P90Latency:     report.P90Latency.Milliseconds(),
+		MaxLatency:     report.MaxLatency.Milliseconds(),
+	})
+}
+
+func (r *evalRepository) GetRun(ctx context.Context, id int64) (domain.EvalRun, error) {
+	run, err := r.dao.GetRun(ctx, id)
+	if err != nil {
+		return domain.EvalRun{}, err
+	}
+	return r.runToDomain(run), nil
+}
+
+func (r *evalRepository) ListRuns(ctx context.Context, biz string, offset, limit int) ([]domain.EvalRun, int64, error) {
+	runs, err := r.dao.ListRuns(ctx, biz, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.dao.CountRuns(ctx, biz)
+	if err != nil {
+		return nil, 0, err
+	}
+	return slice.Map(runs, func(idx int, src dao.EvalRun) domain.EvalRun {
+		return r.runToDomain(src)
+	}), total, nil
+}
+
+func (r *evalRepository) SaveResults(ctx context.Context, results []domain.EvalResult) error {
+	return r.dao.SaveResults(ctx, slice.Map(results, func(idx int, src domain.EvalResult) dao.EvalResult {
+		return dao.EvalResult{
+			RunId:          src.RunId,
+			CaseId:         src.CaseId,
+			Answer:         src.Answer,
+			ExpectedLevel:  src.ExpectedLevel,
+			Level:          src.Level,
+			HitKeywords:    sqlx.JsonColumn[[]string]{Val: src.HitKeywords, Valid: true},
+			MissedKeywords: sqlx.JsonColumn[[]string]{Val: src.MissedKeywords, Valid: true},
+			Tokens:         src.Tokens,
+			Amount:         src.Amount,
+			Latency:        src.Latency.Milliseconds(),
+			Err:            src.Err,
+		}
+	}))
+}
+
+func (r *evalRepository) FindResults(ctx context.Context, runId int64) ([]domain.EvalResult, error) {
+	results, err := r.dao.FindResults(ctx, runId)
+	return slice.Map(results, func(idx int, src dao.EvalResult) domain.EvalResult {
+		return domain.EvalResult{
+			Id:             src.Id,
+			RunId:          src.RunId,
+			CaseId:         src.CaseId,
+			Answer:         src.Answer,
+			ExpectedLevel:  src.ExpectedLevel,
+			Level:          src.Level,
+			HitKeywords:    src.HitKeywords.Val,
+			MissedKeywords: src.MissedKeywords.Val,
+			Tokens:         src.Tokens,
+			Amount:         src.Amount,
+			Latency:        time.Duration(src.Latency) * time.Millisecond,
+			Err:            src.Err,
+			Ctime:          src.Ctime,
+		}
+	}), err
+}
+
+func (r *evalRepository) caseToDomain(c dao.EvalCase) domain.EvalCase {
+	return domain.EvalCase{
+		Id:            c.Id,
+		Biz:           c.Biz,
+		Input:         c.Input.Val,
+		ExpectedLevel: c.ExpectedLevel,
+		Keywords:      c.Keywords.Val,
+		Ctime:         c.Ctime,
+		Utime:         c.Utime,
+	}
+}
+
+func (r *evalRepository) runToDomain(run dao.EvalRun) domain.EvalRun {
+	report := run.Report.Val
+	return domain.EvalRun{
+		Id:            run.Id,
+		Biz:           run.Biz,
+		ConfigVersion: run.ConfigVersion,
+		Model:         run.Model,
+		Levels:        run.Levels.Val,
+		Status:        domain.EvalRunStatus(run.Status),
+		Report: domain.EvalReport{
+			Total:          report.Total,
+			Failed:         report.Failed,
+			Leveled:        report.Leveled,
+			LevelMatched:   report.LevelMatched,
+			LevelAgreement: report.LevelAgreement,
+			Keywords:       report.Keywords,
+			HitKeywords:    report.HitKeywords,
+			KeywordRecall:  report.KeywordRecall,
+			Tokens:         report.Tokens,
+			Amount:         report.Amount,
+			AvgLatency:     time.Duration(report.AvgLatency) * time.Millisecond,
+			P90Latency:     time.Duration(report.P90Latency) * time.Millisecond,
+			MaxLatency:     time.Duration(report.MaxLatency) * time.Millisecond,
+		},
+		Ctime: run.Ctime,
+		Utime: run.Utime,
+	}
+}
@@ -11,6 +11,8 @@ import (
 type KnowledgeBaseRepo interface {
 	Save(ctx context.Context, file domain.KnowledgeBaseFile) error
 	GetInfo(ctx context.Context, platform, baseID, name string) (domain.KnowledgeBaseFile, error)
+	// FindBizIDs 找出 bizIDs 中已经上传到知识库的
+	FindBizIDs(ctx context.Context, biz string, bizIDs []int64) ([]int64, error)
 }
 
 type repositoryBaseRepo struct {
@@ -46,3 +48,7 @@ func (r *repositoryBaseRepo) GetInfo(ctx context.Context, platform, baseID, name
 		Platform: file.Platform,
 	}, nil
 }
+
+func (r *repositoryBaseRepo) FindBizIDs(ctx context.Context, biz string, bizIDs []int64) ([]int64, error) {
+	return r.baseDao.FindBizIDs(ctx, biz, bizIDs)
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
+)
+
+var ErrLLMResponseNotFound = cache.ErrLLMResponseNotFound
+
+// LLMCacheRepo 缓存大模型的回答。
+// 相同的 Biz、配置版本、Model、SystemPrompt、PromptTemplate、Schema 和（规范化之后的）Input 会命中同一个缓存，
+// 所以 A/B 实验的不同分组不会共用缓存
+type LLMCacheRepo interface {
+	Get(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error)
+	Set(ctx context.Context, req domain.LLMRequest, resp domain.LLMResponse) error
+	Enabled(ctx context.Context) (bool, error)
+	SetEnabled(ctx context.Context, enabled bool) error
+}
+
+type llmCacheRepo struct {
+	cache cache.LLMCache
+}
+
+func NewLLMCacheRepo(c cache.LLMCache) LLMCacheRepo {
+	return &llmCacheRepo{cache: c}
+}
+
+func (r *llmCacheRepo) Get(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+	return r.cache.Get(ctx, req.Biz, r.key(req))
+}
+
+func (r *llmCacheRepo) Set(ctx context.Context, req domain.LLMRequest, resp domain.LLMResponse) error {
+	return r.cache.Set(ctx, req.Biz, r.key(req), resp, req.Config.CacheTTL)
+}
+
+func (r *llmCacheRepo) Enabled(ctx context.Context) (bool, error) {
+	return r.cache.Enabled(ctx)
+}
+
+func (r *llmCacheRepo) SetEnabled(ctx context.Context, enabled bool) error {
+	return r.cache.SetEnabled(ctx, enabled)
+}
+
+// key 用户的输入忽略大小写和多余的空白字符。
+// 配置版本、Model、SystemPrompt、PromptTemplate 和 Schema 也参与计算，
+// 这样修改了配置之后旧的缓存就自然失效了，实验组和对照组也各自使用自己的缓存
+func (r *llmCacheRepo) key(req domain.LLMRequest) string {
+	h := sha256.New()
+	h.Write([]byte(strconv.FormatInt(req.Config.Version, 10)))
+	for _, part := range []string{req.Config.Model, req.Config.SystemPrompt, req.Config.PromptTemplate} {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	// 输出格式不同，回答也不同
+	if req.Schema != nil {
+		h.Write([]byte{1})
+		h.Write([]byte(req.Schema.Name))
+		h.Write([]byte{0})
+		h.Write(req.Schema.Schema)
+	}
+	for _, input := range req.Input {
+		h.Write([]byte{0})
+		h.Write([]byte(normalize(input)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalize(input string) string {
+	return strings.ToLower(strings.Join(strings.Fields(input), " "))
+}
@@ -17,6 +17,7 @@ package repository
 import (
 	"context"
 
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ekit/sqlx"
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
@@ -24,6 +25,8 @@ import (
 
 type LLMLogRepo interface {
 	SaveLog(ctx context.Context, l domain.LLMRecord) (int64, error)
+	FindLogs(ctx context.Context, uid int64, offset, limit int) ([]domain.LLMRecord, error)
+	DeleteLogs(ctx context.Context, uid int64) error
 }
 
 // 调用日志
@@ -42,6 +45,17 @@ func (g *llmLogDAO) SaveLog(ctx context.Context, l domain.LLMRecord) (int64, err
 	return g.logDao.Save(ctx, logEntity)
 }
 
+func (g *llmLogDAO) FindLogs(ctx context.Context, uid int64, offset, limit int) ([]domain.LLMRecord, error) {
+	logs, err := g.logDao.FindByUid(ctx, uid, offset, limit)
+	return slice.Map(logs, func(idx int, src dao.LLMRecord) domain.LLMRecord {
+		return g.toDomain(src)
+	}), err
+}
+
+func (g *llmLogDAO) DeleteLogs(ctx context.Context, uid int64) error {
+	return g.logDao.DeleteByUid(ctx, uid)
+}
+
 func (g *llmLogDAO) toEntity(r domain.LLMRecord) dao.LLMRecord {
 	return dao.LLMRecord{
 		Id:          r.Id,
@@ -58,5 +72,46 @@ func (g *llmLogDAO) toEntity(r domain.LLMRecord) dao.LLMRecord {
 		Status:         r.Status.ToUint8(),
 		PromptTemplate: sqlx.NewNullString(r.PromptTemplate),
 		Answer:         sqlx.NewNullString(r.Answer),
+		Platform:       r.Platform,
+		ConfigVersion:  r.ConfigVersion,
+		Moderations: sqlx.JsonColumn[[]dao.Moderation]{
+			Valid: len(r.Moderations) > 0,
+			Val: slice.Map(r.Moderations, func(idx int, src domain.Moderation) dao.Moderation {
+				return dao.Moderation{
+					Stage:  src.Stage,
+					Action: src.Action,
+					Source: src.Source,
+					Hits:   src.Hits,
+				}
+			}),
+		},
+	}
+}
+
+func (g *llmLogDAO) toDomain(r dao.LLMRecord) domain.LLMRecord {
+	return domain.LLMRecord{
+		Id:             r.Id,
+		Tid:            r.Tid,
+		Uid:            r.Uid,
+		Biz:            r.Biz,
+		Tokens:         r.Tokens,
+		Amount:         r.Amount,
+		Input:          r.Input.Val,
+		Status:         domain.RecordStatus(r.Status),
+		KnowledgeId:    r.KnowledgeId,
+		PromptTemplate: r.PromptTemplate.String,
+		Answer:         r.Answer.String,
+		Platform:       r.Platform,
+		ConfigVersion:  r.ConfigVersion,
+		Moderations: slice.Map(r.Moderations.Val, func(idx int, src dao.Moderation) domain.Moderation {
+			return domain.Moderation{
+				Stage:  src.Stage,
+				Action: src.Action,
+				Source: src.Source,
+				Hits:   src.Hits,
+			}
+		}),
+		Ctime: r.Ctime,
+		Utime: r.Utime,
 	}
 }
@@ -10,14 +10,28 @@ import (
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 )
 
+var (
+	ErrMockInterviewNotFound = dao.ErrMockInterviewNotFound
+	ErrReportConflict        = dao.ErrReportConflict
+)
+
 type MockInterviewRepository interface {
 	SaveInterview(ctx context.Context, req domain.MockInterview) (int64, error)
 	FindInterviews(ctx context.Context, uid int64, limit, offset int) ([]domain.MockInterview, error)
 	CountInterviews(ctx context.Context, uid int64) (int64, error)
+	FindInterviewBySN(ctx context.Context, uid int64, chatSN string) (domain.MockInterview, error)
+	// StartReport 占用生成报告的资格，reporting_at 早于 expiredBefore 的占用视为已经失效
+	StartReport(ctx context.Context, id int64, expiredBefore int64) error
+	// CancelReport 生成报告失败之后释放占用
+	CancelReport(ctx context.Context, id int64) error
+	// SaveReport 只有还没有报告的时候才会保存
+	SaveReport(ctx context.Context, id int64, report domain.MockInterviewReport) error
 
 	SaveQuestion(ctx context.Context, req domain.MockInterviewQuestion) (int64, error)
 	FindQuestions(ctx context.Context, interviewID, uid int64, limit, offset int) ([]domain.MockInterviewQuestion, error)
 	CountQuestions(ctx context.Context, interviewID, uid int64) (int64, error)
+	// DeleteByUid 删除用户所有的模拟面试以及题目
+	DeleteByUid(ctx context.Context, uid int64) error
 }
 
 type mockInterviewRepository struct {
@@ -55,6 +69,49 @@ func (r *mockInterviewRepository) CountInterviews(ctx context.Context, uid int64
 	return r.dao.CountInterviews(ctx, uid)
 }
 
+func (r *mockInterviewRepository) FindInterviewBySN(ctx context.Context, uid int64, chatSN string) (domain.MockInterview, error) {
+	mi, err := r.dao.FindInterviewBySN(ctx, uid, chatSN)
+	if err != nil {
+		return domain.MockInterview{}, err
+	}
+	return r.toDomainMockInterview(mi), nil
+}
+
+func (r *mockInterviewRepository) StartReport(ctx context.Context, id int64, expiredBefore int64) error {
+	return r.dao.StartReport(ctx, id, expiredBefore)
+}
+
+func (r *mockInterviewRepository) CancelReport(ctx context.Context, id int64) error {
+	return r.dao.CancelReport(ctx, id)
+}
+
+func (r *mockInterviewRepository) SaveReport(ctx context.Context, id int64, report domain.MockInterviewReport) error {
+	return r.dao.SaveReport(ctx, id, dao.MockInterviewReport{
+		Result:     report.Result.ToUint8(),
+		Score:      report.Score,
+		Strengths:  report.Strengths,
+		Weaknesses: report.Weaknesses,
+		Questions: slice.Map(report.Questions, func(_ int, src domain.QuestionResult) dao.MockInterviewResult {
+			return dao.MockInterviewResult{
+				QuestionID: src.QuestionID,
+				Biz:        src.Biz,
+				BizID:      src.BizID,
+				Title:      src.Title,
+				Result:     src.Result.ToUint8(),
+			}
+		}),
+		Recommendations: slice.Map(report.Recommendations, func(_ int, src domain.Recommendation) dao.MockInterviewRecommendation {
+			return dao.MockInterviewRecommendation{
+				Biz:    src.Biz,
+				BizID:  src.BizID,
+				Title:  src.Title,
+				Reason: src.Reason,
+			}
+		}),
+		Ctime: report.Ctime,
+	})
+}
+
 func (r *mockInterviewRepository) SaveQuestion(ctx context.Context, req domain.MockInterviewQuestion) (int64, error) {
 
 	q := dao.MockInterviewQuestion{
@@ -90,12 +147,16 @@ func (r *mockInterviewRepository) CountQuestions(ctx context.Context, interviewI
 	return r.dao.CountQuestions(ctx, interviewID, uid)
 }
 
+func (r *mockInterviewRepository) DeleteByUid(ctx context.Context, uid int64) error {
+	return r.dao.DeleteByUid(ctx, uid)
+}
+
 func (r *mockInterviewRepository) toDomainMockInterview(mi dao.MockInterview) domain.MockInterview {
 	evaluation := make(map[string]any)
 	if mi.Evaluation.Valid {
 		evaluation = mi.Evaluation.Val
 	}
-	return domain.MockInterview{
+	res := domain.MockInterview{
 		ID:         mi.ID,
 		Uid:        mi.Uid,
 		Title:      mi.Title,
@@ -104,6 +165,38 @@ func (r *mockInterviewRepository) toDomainMockInterview(mi dao.MockInterview) do
 		Ctime:      mi.Ctime,
 		Utime:      mi.Utime,
 	}
+	if mi.Report.Valid {
+		report := r.toDomainReport(mi.Report.Val)
+		res.Report = &report
+	}
+	return res
+}
+
+func (r *mockInterviewRepository) toDomainReport(report dao.MockInterviewReport) domain.MockInterviewReport {
+	return domain.MockInterviewReport{
+		Result:     domain.InterviewResult(report.Result),
+		Score:      report.Score,
+		Strengths:  report.Strengths,
+		Weaknesses: report.Weaknesses,
+		Questions: slice.Map(report.Questions, func(_ int, src dao.MockInterviewResult) domain.QuestionResult {
+			return domain.QuestionResult{
+				QuestionID: src.QuestionID,
+				Biz:        src.Biz,
+				BizID:      src.BizID,
+				Title:      src.Title,
+				Result:     domain.InterviewResult(src.Result),
+			}
+		}),
+		Recommendations: slice.Map(report.Recommendations, func(_ int, src dao.MockInterviewRecommendation) domain.Recommendation {
+			return domain.Recommendation{
+				Biz:    src.Biz,
+				BizID:  src.BizID,
+				Title:  src.Title,
+				Reason: src.Reason,
+			}
+		}),
+		Ctime: report.Ctime,
+	}
 }
 
 func (r *mockInterviewRepository) toDomainMockInterviewQuestion(q dao.MockInterviewQuestion) domain.MockInterviewQuestion {
@@ -0,0 +1,66 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
+)
+
+var ErrStreamNotFound = cache.ErrStreamNotFound
+
+// MockInterviewStreamRepository 模拟面试流中的事件，只会在 Redis 里面保留一小段时间
+type MockInterviewStreamRepository interface {
+	Init(ctx context.Context, streamID string, uid int64) error
+	Owner(ctx context.Context, streamID string) (int64, error)
+	Append(ctx context.Context, streamID string, frame domain.StreamFrame) (int64, error)
+	Range(ctx context.Context, streamID string, after int64) ([]domain.StreamFrame, error)
+	Touch(ctx context.Context, streamID string) error
+	Alive(ctx context.Context, streamID string) (bool, error)
+}
+
+type mockInterviewStreamRepository struct {
+	cache cache.MockInterviewStreamCache
+}
+
+func NewMockInterviewStreamRepository(c cache.MockInterviewStreamCache) MockInterviewStreamRepository {
+	return &mockInterviewStreamRepository{cache: c}
+}
+
+func (r *mockInterviewStreamRepository) Init(ctx context.Context, streamID string, uid int64) error {
+	return r.cache.Init(ctx, streamID, uid)
+}
+
+func (r *mockInterviewStreamRepository) Owner(ctx context.Context, streamID string) (int64, error) {
+	return r.cache.Owner(ctx, streamID)
+}
+
+func (r *mockInterviewStreamRepository) Append(ctx context.Context, streamID string, frame domain.StreamFrame) (int64, error) {
+	return r.cache.Append(ctx, streamID, frame)
+}
+
+func (r *mockInterviewStreamRepository) Range(ctx context.Context, streamID string, after int64) ([]domain.StreamFrame, error) {
+	return r.cache.Range(ctx, streamID, after)
+}
+
+func (r *mockInterviewStreamRepository) Touch(ctx context.Context, streamID string) error {
+	return r.cache.Touch(ctx, streamID)
+}
+
+func (r *mockInterviewStreamRepository) Alive(ctx context.Context, streamID string) (bool, error) {
+	return r.cache.Alive(ctx, streamID)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+)
+
+// CacheService 大模型回答缓存相关的管理功能
+type CacheService interface {
+	// Enabled 缓存是否打开
+	Enabled(ctx context.Context) (bool, error)
+	// SetEnabled 打开或者关闭缓存，对所有业务生效
+	SetEnabled(ctx context.Context, enabled bool) error
+}
+
+type cacheService struct {
+	repo repository.LLMCacheRepo
+}
+
+func NewCacheService(repo repository.LLMCacheRepo) CacheService {
+	return &cacheService{
+		repo: repo,
+	}
+}
+
+func (s *cacheService) Enabled(ctx context.Context) (bool, error) {
+	return s.repo.Enabled(ctx)
+}
+
+func (s *cacheService) SetEnabled(ctx context.Context, enabled bool) error {
+	return s.repo.SetEnabled(ctx, enabled)
+}
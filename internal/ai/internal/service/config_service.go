@@ -13,6 +13,12 @@ type ConfigService interface {
 	Save(ctx context.Context, cfg domain.BizConfig) (int64, error)
 	List(ctx context.Context) ([]domain.BizConfig, error)
 	GetById(ctx context.Context, id int64) (domain.BizConfig, error)
+	// Versions 配置的所有历史版本
+	Versions(ctx context.Context, id int64) ([]domain.BizConfig, error)
+	// Rollback 回滚到某个历史版本
+	Rollback(ctx context.Context, id int64, version int64) error
+	// SetExperiment 设置 A/B 实验，Ratio 为 0 则关闭实验
+	SetExperiment(ctx context.Context, id int64, experiment domain.Experiment) error
 }
 
 // configService 具体实现
@@ -44,3 +50,25 @@ func (s *configService) GetById(ctx context.Context, id int64) (domain.BizConfig
 	}
 	return s.repo.GetById(ctx, id)
 }
+
+func (s *configService) Versions(ctx context.Context, id int64) ([]domain.BizConfig, error) {
+	cfg, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListVersions(ctx, cfg.Biz)
+}
+
+func (s *configService) Rollback(ctx context.Context, id int64, version int64) error {
+	if version <= 0 {
+		return fmt.Errorf("无效的版本 %d", version)
+	}
+	return s.repo.Rollback(ctx, id, version)
+}
+
+func (s *configService) SetExperiment(ctx context.Context, id int64, experiment domain.Experiment) error {
+	if experiment.Ratio < 0 || experiment.Ratio > 100 {
+		return fmt.Errorf("无效的实验流量比例 %d", experiment.Ratio)
+	}
+	return s.repo.SetExperiment(ctx, id, experiment)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/lithammer/shortuuid/v4"
+)
+
+var (
+	ErrConversationNotFound = repository.ErrConversationNotFound
+	ErrBizConfigNotFound    = repository.ErrBizConfigNotFound
+)
+
+const (
+	// defaultHistoryTokens 默认每次最多带上多少 token 的历史消息
+	defaultHistoryTokens = 2000
+	titleLen             = 64
+	summaryPrefix        = "之前对话的摘要：\n"
+)
+
+// ConversationService 多轮对话的记忆
+type ConversationService interface {
+	// History 返回 sn 对应的对话历史，对话不存在就创建一个。
+	// 历史消息超出 token 预算的时候，会把旧消息摘要之后放在最前面
+	History(ctx context.Context, uid int64, biz, sn, question string) ([]domain.Message, error)
+	// Append 保存一轮问答
+	Append(ctx context.Context, sn string, question, answer string) error
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.Conversation, error)
+	Detail(ctx context.Context, uid int64, sn string) (domain.Conversation, []domain.ConversationMessage, error)
+}
+
+type conversationSvc struct {
+	repo  repository.ConversationRepository
+	aiSvc llm.Service
+	// 历史消息的 token 预算
+	historyTokens int64
+	logger        *elog.Component
+}
+
+func NewConversationService(repo repository.ConversationRepository, aiSvc llm.Service) ConversationService {
+	return &conversationSvc{
+		repo:          repo,
+		aiSvc:         aiSvc,
+		historyTokens: defaultHistoryTokens,
+		logger:        elog.DefaultLogger,
+	}
+}
+
+func (s *conversationSvc) History(ctx context.Context, uid int64, biz, sn, question string) ([]domain.Message, error) {
+	err := s.repo.Create(ctx, domain.Conversation{
+		Sn:    sn,
+		Uid:   uid,
+		Biz:   biz,
+		Title: s.title(question),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// sn 可能属于别的用户，所以这里要带上 uid 再查一次
+	conv, err := s.repo.FindBySn(ctx, uid, sn)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.repo.FindMessages(ctx, sn, conv.SummaryMsgId)
+	if err != nil {
+		return nil, err
+	}
+	summary := conv.Summary
+	start := truncate(msgs, s.historyTokens)
+	if start > 0 {
+		// 每次都摘要的话，后面每一轮都要多调用一次大模型，
+		// 所以这里一次性摘要到只剩一半的预算
+		end := truncate(msgs, s.historyTokens/2)
+		summary, err = s.summarize(ctx, uid, conv, msgs[:end])
+		switch {
+		case err == nil:
+			start = end
+		case errors.Is(err, ErrBizConfigNotFound):
+			// 没有配置摘要用的模型，退化成直接丢掉旧消息
+			summary = conv.Summary
+			s.logger.Warn("没有配置对话摘要，直接丢掉旧消息",
+				elog.String("biz", domain.BizConversationSummary),
+				elog.String("sn", sn),
+				elog.FieldErr(err))
+		default:
+			// 摘要失败就直接丢掉旧消息
+			summary = conv.Summary
+			s.logger.Error("摘要对话历史失败",
+				elog.String("sn", sn),
+				elog.Int64("uid", uid),
+				elog.FieldErr(err))
+		}
+	}
+	res := make([]domain.Message, 0, len(msgs)-start+1)
+	if summary != "" {
+		res = append(res, domain.Message{Role: domain.RoleSystem, Content: summaryPrefix + summary})
+	}
+	for _, msg := range msgs[start:] {
+		res = append(res, msg.Message())
+	}
+	return res, nil
+}
+
+// truncate 返回按照 token 预算能够保留的第一条消息的下标，越新的消息越优先保留
+func truncate(msgs []domain.ConversationMessage, budget int64) int {
+	var tokens int64
+	for i := len(msgs) - 1; i >= 0; i-- {
+		tokens += msgs[i].Tokens
+		if tokens > budget {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// summarize 把之前的摘要和 msgs 合并成新的摘要
+func (s *conversationSvc) summarize(ctx context.Context, uid int64,
+	conv domain.Conversation, msgs []domain.ConversationMessage) (string, error) {
+	if len(msgs) == 0 {
+		return conv.Summary, nil
+	}
+	var sb strings.Builder
+	if conv.Summary != "" {
+		sb.WriteString(summaryPrefix)
+		sb.WriteString(conv.Summary)
+		sb.WriteString("\n")
+	}
+	for _, msg := range msgs {
+		role := "用户"
+		if msg.Role == domain.RoleAssistant {
+			role = "助手"
+		}
+		sb.WriteString(fmt.Sprintf("%s：%s\n", role, msg.Content))
+	}
+	resp, err := s.aiSvc.Invoke(ctx, domain.LLMRequest{
+		Uid:   uid,
+		Tid:   shortuuid.New(),
+		Biz:   domain.BizConversationSummary,
+		Input: []string{sb.String()},
+		// 摘要是为了节省上下文，不应该算到用户头上
+		Internal: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Answer == "" {
+		return "", errors.New("大模型返回的摘要为空")
+	}
+	err = s.repo.UpdateSummary(ctx, conv.Sn, resp.Answer, msgs[len(msgs)-1].Id)
+	return resp.Answer, err
+}
+
+func (s *conversationSvc) Append(ctx context.Context, sn string, question, answer string) error {
+	return s.repo.AppendMessages(ctx, sn,
+		domain.ConversationMessage{
+			Role:    domain.RoleUser,
+			Content: question,
+			Tokens:  domain.EstimateTokens(question),
+		},
+		domain.ConversationMessage{
+			Role:    domain.RoleAssistant,
+			Content: answer,
+			Tokens:  domain.EstimateTokens(answer),
+		})
+}
+
+func (s *conversationSvc) List(ctx context.Context, uid int64, offset, limit int) ([]domain.Conversation, error) {
+	return s.repo.List(ctx, uid, offset, limit)
+}
+
+func (s *conversationSvc) Detail(ctx context.Context, uid int64, sn string) (domain.Conversation, []domain.ConversationMessage, error) {
+	conv, err := s.repo.FindBySn(ctx, uid, sn)
+	if err != nil {
+		return domain.Conversation{}, nil, err
+	}
+	msgs, err := s.repo.FindMessages(ctx, sn, 0)
+	return conv, msgs, err
+}
+
+func (s *conversationSvc) title(question string) string {
+	if utf8.RuneCountInString(question) <= titleLen {
+		return question
+	}
+	return string([]rune(question)[:titleLen])
+}
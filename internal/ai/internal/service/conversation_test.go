@@ -0,0 +1,136 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	msgs := []domain.ConversationMessage{
+		{Id: 1, Tokens: 30},
+		{Id: 2, Tokens: 20},
+		{Id: 3, Tokens: 40},
+		{Id: 4, Tokens: 10},
+	}
+	testCases := []struct {
+		name   string
+		msgs   []domain.ConversationMessage
+		budget int64
+		want   int
+	}{
+		{
+			name:   "没有消息",
+			budget: 100,
+			want:   0,
+		},
+		{
+			name:   "全部保留",
+			msgs:   msgs,
+			budget: 100,
+			want:   0,
+		},
+		{
+			name:   "丢掉最旧的消息",
+			msgs:   msgs,
+			budget: 70,
+			want:   1,
+		},
+		{
+			name:   "只保留最新的消息",
+			msgs:   msgs,
+			budget: 10,
+			want:   3,
+		},
+		{
+			name:   "一条都保留不了",
+			msgs:   msgs,
+			budget: 5,
+			want:   4,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, truncate(tc.msgs, tc.budget))
+		})
+	}
+}
+
+func TestConversationSvc_History_NoSummaryConfig(t *testing.T) {
+	repo := &memConversationRepo{
+		msgs: []domain.ConversationMessage{
+			{Id: 1, Role: domain.RoleUser, Content: "问题1", Tokens: 30},
+			{Id: 2, Role: domain.RoleAssistant, Content: "回答1", Tokens: 30},
+			{Id: 3, Role: domain.RoleUser, Content: "问题2", Tokens: 30},
+			{Id: 4, Role: domain.RoleAssistant, Content: "回答2", Tokens: 30},
+		},
+	}
+	svc := &conversationSvc{
+		repo:          repo,
+		aiSvc:         &fakeLLMService{err: fmt.Errorf("%w, biz %s", ErrBizConfigNotFound, domain.BizConversationSummary)},
+		historyTokens: 70,
+		logger:        elog.DefaultLogger,
+	}
+	msgs, err := svc.History(context.Background(), 1, "ask", "sn-1", "问题3")
+	require.NoError(t, err)
+	// 没有摘要，只是丢掉了放不下的旧消息
+	assert.Equal(t, []domain.Message{
+		{Role: domain.RoleUser, Content: "问题2"},
+		{Role: domain.RoleAssistant, Content: "回答2"},
+	}, msgs)
+	assert.False(t, repo.summarized)
+}
+
+// memConversationRepo 只实现了 History 用到的方法
+type memConversationRepo struct {
+	repository.ConversationRepository
+	msgs       []domain.ConversationMessage
+	summarized bool
+}
+
+func (r *memConversationRepo) Create(ctx context.Context, c domain.Conversation) error {
+	return nil
+}
+
+func (r *memConversationRepo) FindBySn(ctx context.Context, uid int64, sn string) (domain.Conversation, error) {
+	return domain.Conversation{Sn: sn, Uid: uid}, nil
+}
+
+func (r *memConversationRepo) FindMessages(ctx context.Context, sn string, afterId int64) ([]domain.ConversationMessage, error) {
+	return r.msgs, nil
+}
+
+func (r *memConversationRepo) UpdateSummary(ctx context.Context, sn string, summary string, msgId int64) error {
+	r.summarized = true
+	return nil
+}
+
+type fakeLLMService struct {
+	llm.Service
+	err error
+}
+
+func (s *fakeLLMService) Invoke(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+	return domain.LLMResponse{}, s.err
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/lithammer/shortuuid/v4"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrEvalRunNotFound = repository.ErrEvalRunNotFound
+	ErrNoEvalCase      = errors.New("业务没有离线评估的用例")
+)
+
+// EvalService 离线评估。
+// 管理员为业务准备好用例之后，使用指定版本的配置和模型批量调用大模型，
+// 再把回答和用例的期望做对比，从而在上线之前发现 Prompt 修改带来的退化
+type EvalService interface {
+	SaveCase(ctx context.Context, c domain.EvalCase) (int64, error)
+	DeleteCase(ctx context.Context, id int64) error
+	ListCases(ctx context.Context, biz string, offset, limit int) ([]domain.EvalCase, int64, error)
+	// Run 在后台运行，返回运行的 id，运行结束之后可以通过 Detail 查看报告
+	Run(ctx context.Context, run domain.EvalRun) (int64, error)
+	ListRuns(ctx context.Context, biz string, offset, limit int) ([]domain.EvalRun, int64, error)
+	Detail(ctx context.Context, id int64) (domain.EvalRun, []domain.EvalResult, error)
+}
+
+type evalService struct {
+	repo   repository.EvalRepository
+	llmSvc llm.Service
+	logger *elog.Component
+	// 同时调用大模型的用例数量
+	concurrency int
+	// 一次运行最长多久
+	timeout time.Duration
+	// 测试里面用来等待后台的运行结束
+	wg sync.WaitGroup
+}
+
+func NewEvalService(repo repository.EvalRepository, llmSvc llm.Service) EvalService {
+	return &evalService{
+		repo:        repo,
+		llmSvc:      llmSvc,
+		logger:      elog.DefaultLogger,
+		concurrency: 4,
+		timeout:     time.Minute * 30,
+	}
+}
+
+func (s *evalService) SaveCase(ctx context.Context, c domain.EvalCase) (int64, error) {
+	return s.repo.SaveCase(ctx, c)
+}
+
+func (s *evalService) DeleteCase(ctx context.Context, id int64) error {
+	return s.repo.DeleteCase(ctx, id)
+}
+
+func (s *evalService) ListCases(ctx context.Context, biz string, offset, limit int) ([]domain.EvalCase, int64, error) {
+	return s.repo.ListCases(ctx, biz, offset, limit)
+}
+
+func (s *evalService) Run(ctx context.Context, run domain.EvalRun) (int64, error) {
+	cases, err := s.repo.FindAllCases(ctx, run.Biz)
+	if err != nil {
+		return 0, err
+	}
+	if len(cases) == 0 {
+		return 0, fmt.Errorf("%w, biz %s", ErrNoEvalCase, run.Biz)
+	}
+	if len(run.Levels) == 0 {
+		run.Levels = s.expectedLevels(cases)
+	}
+	run.Status = domain.EvalRunStatusRunning
+	run.Id, err = s.repo.CreateRun(ctx, run)
+	if err != nil {
+		return 0, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		// 用例比较多的时候要跑很久，不能跟着请求走
+		newCtx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		s.execute(newCtx, run, cases)
+	}()
+	return run.Id, nil
+}
+
+// execute 运行所有的用例并且保存结果和报告。
+// 单个用例调用失败只会记录在结果里面，不影响别的用例
+func (s *evalService) execute(ctx context.Context, run domain.EvalRun, cases []domain.EvalCase) {
+	logger := s.logger.With(elog.Int64("runId", run.Id), elog.String("biz", run.Biz))
+	results := make([]domain.EvalResult, len(cases))
+	var eg errgroup.Group
+	eg.SetLimit(s.concurrency)
+	for i, c := range cases {
+		eg.Go(func() error {
+			results[i] = s.evaluate(ctx, run, c)
+			results[i].RunId = run.Id
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	status := domain.EvalRunStatusSuccess
+	err := s.repo.SaveResults(ctx, results)
+	if err != nil {
+		logger.Error("保存离线评估的结果失败", elog.FieldErr(err))
+		status = domain.EvalRunStatusFailed
+	}
+	report := domain.NewEvalReport(results)
+	err = s.repo.FinishRun(ctx, run.Id, status, report)
+	if err != nil {
+		logger.Error("保存离线评估的报告失败", elog.FieldErr(err))
+	}
+}
+
+func (s *evalService) evaluate(ctx context.Context, run domain.EvalRun, c domain.EvalCase) domain.EvalResult {
+	start := time.Now()
+	resp, err := s.llmSvc.Invoke(ctx, domain.LLMRequest{
+		Biz:           run.Biz,
+		Tid:           shortuuid.New(),
+		Input:         c.Input,
+		Internal:      true,
+		ConfigVersion: run.ConfigVersion,
+		Model:         run.Model,
+	})
+	latency := time.Since(start)
+	if err != nil {
+		return domain.EvalResult{
+			CaseId:         c.Id,
+			ExpectedLevel:  c.ExpectedLevel,
+			MissedKeywords: c.Keywords,
+			Tokens:         resp.Tokens,
+			Amount:         resp.Amount,
+			Latency:        latency,
+			Err:            err.Error(),
+		}
+	}
+	return domain.NewEvalResult(c, run.Levels, resp, latency)
+}
+
+// expectedLevels 用例中出现过的期望评级
+func (s *evalService) expectedLevels(cases []domain.EvalCase) []string {
+	res := make([]string, 0, 4)
+	seen := make(map[string]struct{}, 4)
+	for _, c := range cases {
+		if c.ExpectedLevel == "" {
+			continue
+		}
+		if _, ok := seen[c.ExpectedLevel]; ok {
+			continue
+		}
+		seen[c.ExpectedLevel] = struct{}{}
+		res = append(res, c.ExpectedLevel)
+	}
+	return res
+}
+
+func (s *evalService) ListRuns(ctx context.Context, biz string, offset, limit int) ([]domain.EvalRun, int64, error) {
+	return s.repo.ListRuns(ctx, biz, offset, limit)
+}
+
+func (s *evalService) Detail(ctx context.Context, id int64) (domain.EvalRun, []domain.EvalResult, error) {
+	run, err := s.repo.GetRun(ctx, id)
+	if err != nil {
+		return domain.EvalRun{}, nil, err
+	}
+	results, err := s.repo.FindResults(ctx, id)
+	return run, results, err
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/config"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalService_Run(t *testing.T) {
+	repo := &memEvalRepo{
+		cases: []domain.EvalCase{
+			{Id: 1, Biz: domain.BizCaseExamine, Input: []string{"pass"}, ExpectedLevel: "通过", Keywords: []string{"索引"}},
+			{Id: 2, Biz: domain.BizCaseExamine, Input: []string{"fail"}, ExpectedLevel: "通过"},
+			{Id: 3, Biz: domain.BizCaseExamine, Input: []string{"error"}, ExpectedLevel: "不通过"},
+		},
+	}
+	cfgRepo := &fakeConfigRepo{
+		versions: map[int64]domain.BizConfig{
+			// 线上版本，离线评估不应该用到
+			2: {Biz: domain.BizCaseExamine, Version: 2, Model: "online", Price: 100, PromptTemplate: "%s"},
+			1: {Biz: domain.BizCaseExamine, Version: 1, Model: "v1", Price: 100, PromptTemplate: "%s"},
+		},
+		current: 2,
+	}
+	// 桩平台，不会真的调用大模型
+	platform := handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+		if req.Config.Version != 1 || req.Config.Model != "glm-4" {
+			return domain.LLMResponse{}, errors.New("没有使用指定的配置")
+		}
+		switch req.Prompt() {
+		case "pass":
+			return domain.LLMResponse{Answer: "通过，索引分析得很好", Tokens: 10, Amount: 1}, nil
+		case "fail":
+			return domain.LLMResponse{Answer: "不通过", Tokens: 20, Amount: 2}, nil
+		default:
+			return domain.LLMResponse{}, errors.New("mock platform error")
+		}
+	})
+	// credit 里面没有积分服务，扣费就会 panic
+	root := handler.NewCompositionHandler([]handler.Builder{
+		config.NewBuilder(cfgRepo),
+		credit.NewHandlerBuilder(nil, nil),
+	}, platform)
+	svc := NewEvalService(repo, llm.NewLLMService(root, nil)).(*evalService)
+
+	id, err := svc.Run(context.Background(), domain.EvalRun{
+		Biz:           domain.BizCaseExamine,
+		ConfigVersion: 1,
+		Model:         "glm-4",
+	})
+	require.NoError(t, err)
+	svc.wg.Wait()
+
+	run := repo.runs[0]
+	assert.Equal(t, id, run.Id)
+	assert.Equal(t, domain.EvalRunStatusSuccess, run.Status)
+	assert.Equal(t, []string{"通过", "不通过"}, run.Levels)
+	report := run.Report
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.LevelMatched)
+	assert.Equal(t, 0.3333, report.LevelAgreement)
+	assert.Equal(t, 1.0, report.KeywordRecall)
+	assert.Equal(t, int64(30), report.Tokens)
+
+	require.Len(t, repo.results, 3)
+	slices.SortFunc(repo.results, func(a, b domain.EvalResult) int {
+		return int(a.CaseId - b.CaseId)
+	})
+	assert.Equal(t, "通过", repo.results[0].Level)
+	assert.Equal(t, "不通过", repo.results[1].Level)
+	assert.Contains(t, repo.results[2].Err, "mock platform error")
+	for _, r := range repo.results {
+		assert.Equal(t, id, r.RunId)
+	}
+
+	// 没有用例
+	_, err = svc.Run(context.Background(), domain.EvalRun{Biz: "unknown"})
+	assert.ErrorIs(t, err, ErrNoEvalCase)
+}
+
+type fakeConfigRepo struct {
+	repository.ConfigRepository
+	versions map[int64]domain.BizConfig
+	current  int64
+}
+
+func (r *fakeConfigRepo) GetConfig(ctx context.Context, biz string) (domain.BizConfig, error) {
+	return r.versions[r.current], nil
+}
+
+func (r *fakeConfigRepo) GetVersion(ctx context.Context, biz string, version int64) (domain.BizConfig, error) {
+	cfg, ok := r.versions[version]
+	if !ok {
+		return domain.BizConfig{}, errors.New("版本不存在")
+	}
+	return cfg, nil
+}
+
+// memEvalRepo 只实现了 Run 用到的方法
+type memEvalRepo struct {
+	repository.EvalRepository
+	cases   []domain.EvalCase
+	runs    []domain.EvalRun
+	results []domain.EvalResult
+}
+
+func (r *memEvalRepo) FindAllCases(ctx context.Context, biz string) ([]domain.EvalCase, error) {
+	var res []domain.EvalCase
+	for _, c := range r.cases {
+		if c.Biz == biz {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func (r *memEvalRepo) CreateRun(ctx context.Context, run domain.EvalRun) (int64, error) {
+	run.Id = int64(len(r.runs) + 1)
+	r.runs = append(r.runs, run)
+	return run.Id, nil
+}
+
+func (r *memEvalRepo) FinishRun(ctx context.Context, id int64, status domain.EvalRunStatus, report domain.EvalReport) error {
+	r.runs[id-1].Status = status
+	r.runs[id-1].Report = report
+	return nil
+}
+
+func (r *memEvalRepo) SaveResults(ctx context.Context, results []domain.EvalResult) error {
+	r.results = append(r.results, results...)
+	return nil
+}
@@ -2,46 +2,91 @@ package service
 
 import (
 	"context"
+	"strings"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/gotomicro/ego/core/elog"
 	"github.com/lithammer/shortuuid/v4"
 )
 
 type GeneralService interface {
-	// LLMAsk 通用询问ai的接口
-	LLMAsk(ctx context.Context, uid int64, biz string, input []string) (domain.LLMResponse, error)
-	Stream(ctx context.Context, uid int64, biz string, input []string) (chan domain.StreamEvent, error)
+	// LLMAsk 通用询问ai的接口。
+	// sn 为空就是单次问答，不为空的时候会带上这个会话之前的消息，并且保存本轮问答
+	LLMAsk(ctx context.Context, uid int64, biz string, sn string, input []string) (domain.LLMResponse, error)
+	Stream(ctx context.Context, uid int64, biz string, sn string, input []string) (chan domain.StreamEvent, error)
 }
 
-func NewGeneralService(aiSvc llm.Service) GeneralService {
+func NewGeneralService(aiSvc llm.Service, convSvc ConversationService) GeneralService {
 	return &generalSvc{
-		aiSvc: aiSvc,
+		aiSvc:   aiSvc,
+		convSvc: convSvc,
+		logger:  elog.DefaultLogger,
 	}
 }
 
 type generalSvc struct {
-	aiSvc llm.Service
+	aiSvc   llm.Service
+	convSvc ConversationService
+	logger  *elog.Component
 }
 
-func (g *generalSvc) Stream(ctx context.Context, uid int64, biz string, input []string) (chan domain.StreamEvent, error) {
-	tid := shortuuid.New()
-	aiReq := domain.LLMRequest{
-		Uid:   uid,
-		Tid:   tid,
-		Biz:   biz,
-		Input: input,
+func (g *generalSvc) Stream(ctx context.Context, uid int64, biz string, sn string, input []string) (chan domain.StreamEvent, error) {
+	aiReq, err := g.newReq(ctx, uid, biz, sn, input)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := g.aiSvc.Stream(ctx, aiReq)
+	if err != nil || sn == "" {
+		return ch, err
+	}
+	var answer strings.Builder
+	return handler.Relay(ctx, ch, func(evt domain.StreamEvent) {
+		answer.WriteString(evt.Content)
+		if !evt.Done || evt.Error != nil {
+			return
+		}
+		// 调用方可能已经断开了，但是完整的回答依旧要保存下来
+		err1 := g.convSvc.Append(context.WithoutCancel(ctx), sn, g.question(input), answer.String())
+		if err1 != nil {
+			g.logger.Error("保存对话消息失败",
+				elog.String("sn", sn),
+				elog.Int64("uid", uid),
+				elog.FieldErr(err1))
+		}
+	}), nil
+}
+
+func (g *generalSvc) LLMAsk(ctx context.Context, uid int64, biz string, sn string, input []string) (domain.LLMResponse, error) {
+	aiReq, err := g.newReq(ctx, uid, biz, sn, input)
+	if err != nil {
+		return domain.LLMResponse{}, err
+	}
+	resp, err := g.aiSvc.Invoke(ctx, aiReq)
+	if err != nil || sn == "" {
+		return resp, err
 	}
-	return g.aiSvc.Stream(ctx, aiReq)
+	err = g.convSvc.Append(ctx, sn, g.question(input), resp.Answer)
+	return resp, err
 }
 
-func (g *generalSvc) LLMAsk(ctx context.Context, uid int64, biz string, input []string) (domain.LLMResponse, error) {
-	tid := shortuuid.New()
+func (g *generalSvc) newReq(ctx context.Context, uid int64, biz string, sn string, input []string) (domain.LLMRequest, error) {
 	aiReq := domain.LLMRequest{
 		Uid:   uid,
-		Tid:   tid,
+		Tid:   shortuuid.New(),
 		Biz:   biz,
 		Input: input,
 	}
-	return g.aiSvc.Invoke(ctx, aiReq)
+	if sn == "" {
+		return aiReq, nil
+	}
+	history, err := g.convSvc.History(ctx, uid, biz, sn, g.question(input))
+	aiReq.History = history
+	return aiReq, err
+}
+
+// question 保存到对话里面的用户提问
+func (g *generalSvc) question(input []string) string {
+	return strings.Join(input, "\n")
 }
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"regexp"
 	"strings"
 	"sync/atomic"
 
@@ -15,8 +14,18 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-// 最简单的提取方式
-const jsonExpr = `\{(.|\n|\r)+\}`
+// scoreSchema 测评结果的格式，对应 ScoreResp
+var scoreSchema = &domain.JSONSchema{
+	Name: "jd_score",
+	Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "score": {"type": "number", "minimum": 0, "maximum": 10},
+    "summary": {"type": "array", "items": {"type": "string"}}
+  },
+  "required": ["score", "summary"]
+}`),
+}
 
 type JDService interface {
 	// Evaluate 测评
@@ -26,14 +35,12 @@ type JDService interface {
 type jdSvc struct {
 	aiSvc  llm.Service
 	logger *elog.Component
-	expr   *regexp.Regexp
 }
 
 func NewJDService(aiSvc llm.Service) JDService {
 	return &jdSvc{
 		aiSvc:  aiSvc,
 		logger: elog.DefaultLogger,
-		expr:   regexp.MustCompile(jsonExpr),
 	}
 }
 
@@ -100,32 +107,25 @@ func (j *jdSvc) Evaluate(ctx context.Context, uid int64, jd string) (domain.JD,
 func (j *jdSvc) analysisJd(ctx context.Context, uid int64, biz string, jd string) (int64, domain.JDEvaluation, error) {
 	tid := shortuuid.New()
 	aiReq := domain.LLMRequest{
-		Uid:   uid,
-		Tid:   tid,
-		Biz:   biz,
-		Input: []string{jd},
+		Uid:    uid,
+		Tid:    tid,
+		Biz:    biz,
+		Input:  []string{jd},
+		Schema: scoreSchema,
 	}
 	resp, err := j.aiSvc.Invoke(ctx, aiReq)
 	if err != nil {
 		return 0, domain.JDEvaluation{}, err
 	}
-	jsonStr := j.expr.FindString(resp.Answer)
-	var (
-		scoreResp ScoreResp
-		analysis  string
-	)
-	err = json.Unmarshal([]byte(jsonStr), &scoreResp)
+	var scoreResp ScoreResp
+	err = resp.Decode(&scoreResp)
 	if err != nil {
-		j.logger.Error("不符合预期的大模型响应",
-			elog.FieldErr(err),
-			elog.String("resp", resp.Answer))
-	} else {
-		analysis = "- " + strings.Join(scoreResp.Summary, "\n- ")
+		return 0, domain.JDEvaluation{}, err
 	}
 	return resp.Amount, domain.JDEvaluation{
 		Score: scoreResp.Score,
 		// 按照 Markdown 的写法，拼接起来
-		Analysis: analysis,
+		Analysis: "- " + strings.Join(scoreResp.Summary, "\n- "),
 	}, nil
 }
 
@@ -0,0 +1,94 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// HandlerBuilder 相同的输入直接返回缓存的回答，不再调用大模型。
+// 需要放在 config 之后，credit 之前，这样命中缓存的时候不会扣费
+type HandlerBuilder struct {
+	repo   repository.LLMCacheRepo
+	logger *elog.Component
+}
+
+func NewHandlerBuilder(repo repository.LLMCacheRepo) *HandlerBuilder {
+	return &HandlerBuilder{
+		repo:   repo,
+		logger: elog.DefaultLogger,
+	}
+}
+
+func (b *HandlerBuilder) Name() string {
+	return "cache"
+}
+
+func (b *HandlerBuilder) Next(next handler.Handler) handler.Handler {
+	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+		if !b.enabled(ctx, req) {
+			return next.Handle(ctx, req)
+		}
+		resp, err := b.repo.Get(ctx, req)
+		if err == nil {
+			// 命中缓存没有消耗任何 token
+			return domain.LLMResponse{
+				Answer:   resp.Answer,
+				Object:   resp.Object,
+				Platform: domain.PlatformCache,
+			}, nil
+		}
+		if !errors.Is(err, repository.ErrLLMResponseNotFound) {
+			b.logger.Error("查询大模型回答缓存失败",
+				elog.String("biz", req.Biz),
+				elog.String("tid", req.Tid),
+				elog.FieldErr(err))
+		}
+		resp, err = next.Handle(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		err = b.repo.Set(ctx, req, resp)
+		if err != nil {
+			b.logger.Error("缓存大模型回答失败",
+				elog.String("biz", req.Biz),
+				elog.String("tid", req.Tid),
+				elog.FieldErr(err))
+		}
+		return resp, nil
+	})
+}
+
+// enabled 业务没有配置缓存时间，或者管理员关闭了缓存，都不走缓存。
+// 多轮对话的回答依赖上下文，离线评估需要真实的耗时，也不走缓存
+func (b *HandlerBuilder) enabled(ctx context.Context, req domain.LLMRequest) bool {
+	if req.Config.CacheTTL <= 0 || len(req.History) > 0 || req.Internal {
+		return false
+	}
+	ok, err := b.repo.Enabled(ctx)
+	if err != nil {
+		b.logger.Error("查询大模型回答缓存开关失败", elog.FieldErr(err))
+		return false
+	}
+	return ok
+}
+
+var _ handler.Builder = &HandlerBuilder{}
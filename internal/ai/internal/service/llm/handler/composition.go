@@ -40,3 +40,23 @@ func NewCompositionHandler(common []Builder,
 		root: root,
 	}
 }
+
+// CompositionStreamHandler 是 CompositionHandler 的流式版本
+type CompositionStreamHandler struct {
+	root StreamHandler
+}
+
+func (c *CompositionStreamHandler) StreamHandle(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+	return c.root.StreamHandle(ctx, req)
+}
+
+func NewCompositionStreamHandler(common []StreamBuilder,
+	root StreamHandler) *CompositionStreamHandler {
+	for i := len(common) - 1; i >= 0; i-- {
+		current := common[i]
+		root = current.StreamNext(root)
+	}
+	return &CompositionStreamHandler{
+		root: root,
+	}
+}
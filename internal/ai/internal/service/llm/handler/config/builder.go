@@ -20,23 +20,26 @@ import (
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/gotomicro/ego/core/elog"
 )
 
 // HandlerBuilder 改为从数据库中读取
 type HandlerBuilder struct {
-	repo repository.ConfigRepository
+	repo   repository.ConfigRepository
+	logger *elog.Component
 }
 
 func NewBuilder(repo repository.ConfigRepository) *HandlerBuilder {
 	return &HandlerBuilder{
-		repo: repo,
+		repo:   repo,
+		logger: elog.DefaultLogger,
 	}
 }
 
 func (b *HandlerBuilder) Next(next handler.Handler) handler.Handler {
 	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
 		// 读取配置
-		cfg, err := b.repo.GetConfig(ctx, req.Biz)
+		cfg, err := b.getConfig(ctx, req)
 		if err != nil {
 			return domain.LLMResponse{}, err
 		}
@@ -45,4 +48,55 @@ func (b *HandlerBuilder) Next(next handler.Handler) handler.Handler {
 	})
 }
 
+func (b *HandlerBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	return handler.StreamHandleFunc(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+		cfg, err := b.getConfig(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		req.Config = cfg
+		return next.StreamHandle(ctx, req)
+	})
+}
+
+// getConfig 落在 A/B 实验组的用户使用实验版本的配置，
+// 请求里面指定了版本和模型的，以请求为准
+func (b *HandlerBuilder) getConfig(ctx context.Context, req domain.LLMRequest) (domain.BizConfig, error) {
+	cfg, err := b.selectConfig(ctx, req)
+	if err != nil {
+		return domain.BizConfig{}, err
+	}
+	if req.Model != "" {
+		cfg.Model = req.Model
+	}
+	return cfg, nil
+}
+
+func (b *HandlerBuilder) selectConfig(ctx context.Context, req domain.LLMRequest) (domain.BizConfig, error) {
+	if req.ConfigVersion > 0 {
+		return b.repo.GetVersion(ctx, req.Biz, req.ConfigVersion)
+	}
+	cfg, err := b.repo.GetConfig(ctx, req.Biz)
+	if err != nil {
+		return domain.BizConfig{}, err
+	}
+	exp := cfg.Experiment
+	if exp.Version == cfg.Version || !exp.Hit(req.Biz, req.Uid) {
+		return cfg, nil
+	}
+	expCfg, err := b.repo.GetVersion(ctx, req.Biz, exp.Version)
+	if err != nil {
+		// 实验版本出了问题，就使用当前版本
+		b.logger.Error("查询实验版本的配置失败",
+			elog.String("biz", req.Biz),
+			elog.Int64("version", exp.Version),
+			elog.FieldErr(err))
+		return cfg, nil
+	}
+	expCfg.Id = cfg.Id
+	expCfg.Experiment = exp
+	return expCfg, nil
+}
+
 var _ handler.Builder = &HandlerBuilder{}
+var _ handler.StreamBuilder = &HandlerBuilder{}
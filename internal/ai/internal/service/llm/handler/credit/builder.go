@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/gotomicro/ego/core/elog"
 
@@ -14,10 +16,17 @@ import (
 	uuid "github.com/lithammer/shortuuid/v4"
 )
 
+// HandlerBuilder 在调用大模型之前按照预估的费用预扣积分，
+// 调用成功之后按照实际费用结算，多扣的部分退回，调用失败则全部退回。
+// 调用失败但是已经产生了费用的，例如结构化输出修正失败，依旧按照实际费用结算
 type HandlerBuilder struct {
 	creditSvc credit.Service
 	logRepo   repository.LLMCreditLogRepo
 	logger    *elog.Component
+	// 预估费用的时候，为输出预留的 token 数量
+	reservedOutputTokens int64
+	// 流式调用最长多久，必须比预扣积分的有效期短，否则还没结算预扣的积分就被退回了
+	maxStreamDuration time.Duration
 }
 
 func (h *HandlerBuilder) Name() string {
@@ -30,71 +39,191 @@ var (
 
 func NewHandlerBuilder(creSvc credit.Service, repo repository.LLMCreditLogRepo) *HandlerBuilder {
 	return &HandlerBuilder{
-		creditSvc: creSvc,
-		logRepo:   repo,
-		logger:    elog.DefaultLogger,
+		creditSvc:            creSvc,
+		logRepo:              repo,
+		logger:               elog.DefaultLogger,
+		reservedOutputTokens: 1024,
+		// 留一分钟用来结算
+		maxStreamDuration: credit.LockedCreditsTimeout - time.Minute,
 	}
 }
 
 func (h *HandlerBuilder) Next(next handler.Handler) handler.Handler {
 	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
-		// 不需要扣除积分
-		if req.Config.Price == 0 {
+		// 不需要扣除积分，系统内部的请求也不扣
+		if req.Config.Price == 0 || req.Internal {
 			return next.Handle(ctx, req)
 		}
-		cre, err := h.creditSvc.GetCreditsByUID(ctx, req.Uid)
+		rsv, err := h.reserve(ctx, req)
 		if err != nil {
 			return domain.LLMResponse{}, err
 		}
-		// 如果剩余的积分不足就返回积分不足
-		ok := h.checkCredit(cre)
-		if !ok {
-			return domain.LLMResponse{}, fmt.Errorf("%w, 余额非正数，无法继续调用，用户 %d",
-				ErrInsufficientCredit, req.Uid)
-		}
 
 		// 调用下层服务
 		resp, err := next.Handle(ctx, req)
 		if err != nil {
+			if resp.Amount == 0 {
+				h.release(ctx, rsv)
+				return resp, err
+			}
+			// 出错了但是已经产生了费用，例如修正之后输出依旧不符合 JSON Schema
+			err1 := h.settle(ctx, rsv, resp.Amount)
+			if err1 != nil {
+				h.logger.Error("调用失败之后结算积分失败",
+					elog.String("tid", req.Tid),
+					elog.Int64("uid", req.Uid),
+					elog.Int64("amount", resp.Amount),
+					elog.FieldErr(err1))
+			}
 			return resp, err
 		}
-
-		// 扣款
-		id, err := h.logRepo.SaveCredit(ctx, h.newLog(req, resp))
+		err = h.settle(ctx, rsv, resp.Amount)
 		if err != nil {
 			return domain.LLMResponse{}, err
 		}
-		err = h.deductCredit(ctx, credit.Credit{
-			Uid: req.Uid,
-			Logs: []credit.CreditLog{
-				{
-					Key:          uuid.New(),
-					ChangeAmount: resp.Amount,
-					Uid:          req.Uid,
-					Biz:          "ai-llm",
-					BizId:        id,
-					Desc:         "ai-llm服务",
-				},
-			},
-		})
+		return resp, nil
+	})
+}
+
+func (h *HandlerBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	return handler.StreamHandleFunc(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+		if req.Config.Price == 0 || req.Internal {
+			return next.StreamHandle(ctx, req)
+		}
+		rsv, err := h.reserve(ctx, req)
 		if err != nil {
-			_, _ = h.logRepo.SaveCredit(ctx, domain.LLMCredit{
-				Id:     id,
-				Status: domain.CreditStatusFailed,
-			})
-			return domain.LLMResponse{}, err
+			return nil, err
+		}
+		// 超时之后上游会中断，并且在最后一个事件里面给出已经输出的内容的用量
+		streamCtx, cancelStream := context.WithTimeout(ctx, h.maxStreamDuration)
+		ch, err := next.StreamHandle(streamCtx, req)
+		if err != nil {
+			cancelStream()
+			h.release(ctx, rsv)
+			return nil, err
 		}
+		return handler.Relay(ctx, ch, func(evt domain.StreamEvent) {
+			if !evt.Done {
+				return
+			}
+			cancelStream()
+			// 调用方很可能已经走了，所以不能用原本的 ctx
+			newCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			// 回答被拦截，用户什么都没有拿到，不收费
+			var merr *domain.ModerationError
+			if errors.As(evt.Error, &merr) {
+				h.release(newCtx, rsv)
+				return
+			}
+			// 流被取消或者出错，也要为已经输出的内容付费
+			err1 := h.settle(newCtx, rsv, evt.Amount)
+			if err1 != nil {
+				h.logger.Error("流式调用结算积分失败",
+					elog.String("tid", req.Tid),
+					elog.Int64("uid", req.Uid),
+					elog.Int64("amount", evt.Amount),
+					elog.FieldErr(err1))
+			}
+		}), nil
+	})
+}
 
-		_, err = h.logRepo.SaveCredit(ctx, domain.LLMCredit{
-			Id:     id,
-			Status: domain.CreditStatusSuccess,
-		})
+// reservation 一次预扣
+type reservation struct {
+	// 预扣对应的积分流水
+	creditLogId int64
+	log         domain.LLMCredit
+}
 
-		return resp, err
-	})
+// estimate 按照所有消息的长度预估 token 数量，再加上为输出预留的部分
+func (h *HandlerBuilder) estimate(req domain.LLMRequest) int64 {
+	tokens := domain.EstimateMessagesTokens(req.Messages()) + h.reservedOutputTokens
+	return int64(math.Ceil(float64(tokens*req.Config.Price) / float64(1000)))
 }
 
-// TODO deductCredit 后面要求 credit 那边提供一个一次性接口，绕开 try-confirm 流程
+func (h *HandlerBuilder) reserve(ctx context.Context, req domain.LLMRequest) (reservation, error) {
+	amount := h.estimate(req)
+	cre, err := h.creditSvc.GetCreditsByUID(ctx, req.Uid)
+	if err != nil {
+		return reservation{}, err
+	}
+	if cre.TotalAmount < uint64(amount) {
+		return reservation{}, fmt.Errorf("%w, 余额不足以支付预估费用 %d，用户 %d",
+			ErrInsufficientCredit, amount, req.Uid)
+	}
+	log := domain.LLMCredit{
+		Tid:    req.Tid,
+		Uid:    req.Uid,
+		Biz:    req.Biz,
+		Amount: amount,
+		Status: domain.CreditStatusProcessing,
+	}
+	log.Id, err = h.logRepo.SaveCredit(ctx, log)
+	if err != nil {
+		return reservation{}, err
+	}
+	cid, err := h.creditSvc.TryDeductCredits(ctx, h.newCredit(log))
+	if errors.Is(err, credit.ErrCreditNotEnough) {
+		// 查询余额之后积分被别的请求用掉了
+		err = fmt.Errorf("%w, %w", ErrInsufficientCredit, err)
+	}
+	if err != nil {
+		h.saveStatus(ctx, log, domain.CreditStatusFailed)
+		return reservation{}, err
+	}
+	return reservation{creditLogId: cid, log: log}, nil
+}
+
+// release 调用失败，退回全部预扣的积分
+func (h *HandlerBuilder) release(ctx context.Context, rsv reservation) {
+	err := h.creditSvc.CancelDeductCredits(ctx, rsv.log.Uid, rsv.creditLogId)
+	if err != nil {
+		// 超时未确认的预扣积分会被定时任务释放
+		h.logger.Error("退回预扣积分失败",
+			elog.Int64("uid", rsv.log.Uid),
+			elog.Int64("creditLogId", rsv.creditLogId),
+			elog.FieldErr(err))
+	}
+	rsv.log.Amount = 0
+	h.saveStatus(ctx, rsv.log, domain.CreditStatusFailed)
+}
+
+// settle 按照实际费用结算。实际费用超过预扣的部分，在余额允许的情况下再扣一次
+func (h *HandlerBuilder) settle(ctx context.Context, rsv reservation, amount int64) error {
+	log := rsv.log
+	err := h.creditSvc.SettleDeductCredits(ctx, log.Uid, rsv.creditLogId, min(amount, log.Amount))
+	if err != nil {
+		h.saveStatus(ctx, log, domain.CreditStatusFailed)
+		return err
+	}
+	if amount > log.Amount {
+		extra := log
+		extra.Amount = amount - log.Amount
+		err = h.deductCredit(ctx, h.newCredit(extra))
+		if err != nil {
+			// 余额不够支付超出的部分，那么只收预扣的部分，保证余额不会变成负数
+			h.logger.Error("扣减超出预估的积分失败",
+				elog.Int64("uid", log.Uid),
+				elog.Int64("extra", extra.Amount),
+				elog.FieldErr(err))
+			amount = log.Amount
+		}
+	}
+	log.Amount = amount
+	return h.saveStatus(ctx, log, domain.CreditStatusSuccess)
+}
+
+func (h *HandlerBuilder) saveStatus(ctx context.Context, log domain.LLMCredit, status domain.CreditStatus) error {
+	log.Status = status
+	_, err := h.logRepo.SaveCredit(ctx, log)
+	if err != nil {
+		h.logger.Error("更新 LLM 扣费记录失败", elog.Int64("id", log.Id), elog.FieldErr(err))
+	}
+	return err
+}
+
+// deductCredit 一次性扣减积分
 func (h *HandlerBuilder) deductCredit(ctx context.Context, c credit.Credit) error {
 	id, err := h.creditSvc.TryDeductCredits(ctx, c)
 	if err != nil {
@@ -111,20 +240,18 @@ func (h *HandlerBuilder) deductCredit(ctx context.Context, c credit.Credit) erro
 	return err
 }
 
-func (h *HandlerBuilder) newLog(req domain.LLMRequest, resp domain.LLMResponse) domain.LLMCredit {
-	return domain.LLMCredit{
-		Tid:    req.Tid,
-		Uid:    req.Uid,
-		Biz:    req.Biz,
-		Tokens: resp.Tokens,
-		Amount: resp.Amount,
-		Status: domain.CreditStatusProcessing,
+func (h *HandlerBuilder) newCredit(log domain.LLMCredit) credit.Credit {
+	return credit.Credit{
+		Uid: log.Uid,
+		Logs: []credit.CreditLog{
+			{
+				Key:          uuid.New(),
+				ChangeAmount: log.Amount,
+				Uid:          log.Uid,
+				Biz:          "ai-llm",
+				BizId:        log.Id,
+				Desc:         "ai-llm服务",
+			},
+		},
 	}
 }
-
-func (h *HandlerBuilder) checkCredit(cre credit.Credit) bool {
-	// 判断积分是否满足
-	// 并不能用一次调用的最大 token 数量来算，因为要考虑用户可能最后只剩下一点点钱了，
-	// 这点钱不够最大数量，但是够一次普通调用
-	return cre.TotalAmount > 0
-}
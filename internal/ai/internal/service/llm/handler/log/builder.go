@@ -14,6 +14,7 @@ type HandlerBuilder struct {
 }
 
 var _ handler.Builder = &HandlerBuilder{}
+var _ handler.StreamBuilder = &HandlerBuilder{}
 
 func NewHandler() *HandlerBuilder {
 	return &HandlerBuilder{
@@ -43,3 +44,29 @@ func (h *HandlerBuilder) Next(next handler.Handler) handler.Handler {
 		return resp, err
 	})
 }
+
+func (h *HandlerBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	return handler.StreamHandleFunc(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+		logger := h.logger.With(elog.String("tid", req.Tid),
+			elog.Int64("uid", req.Uid),
+			elog.String("biz", req.Biz))
+		logger.Debug("流式请求 LLM")
+		ch, err := next.StreamHandle(ctx, req)
+		if err != nil {
+			logger.Error("流式请求 LLM 服务失败", elog.FieldErr(err))
+			return nil, err
+		}
+		return handler.Relay(ctx, ch, func(evt domain.StreamEvent) {
+			if !evt.Done {
+				return
+			}
+			if evt.Error != nil {
+				logger.Error("流式请求 LLM 服务中断",
+					elog.Int64("tokens", evt.Tokens),
+					elog.FieldErr(evt.Error))
+				return
+			}
+			logger.Debug("流式请求 LLM 服务响应成功", elog.Int64("tokens", evt.Tokens))
+		}), nil
+	})
+}
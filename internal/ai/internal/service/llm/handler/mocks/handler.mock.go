@@ -205,3 +205,65 @@ func (c *MockStreamHandlerStreamHandleCall) DoAndReturn(f func(context.Context,
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// MockStreamBuilder is a mock of StreamBuilder interface.
+type MockStreamBuilder struct {
+	ctrl     *gomock.Controller
+	recorder *MockStreamBuilderMockRecorder
+	isgomock struct{}
+}
+
+// MockStreamBuilderMockRecorder is the mock recorder for MockStreamBuilder.
+type MockStreamBuilderMockRecorder struct {
+	mock *MockStreamBuilder
+}
+
+// NewMockStreamBuilder creates a new mock instance.
+func NewMockStreamBuilder(ctrl *gomock.Controller) *MockStreamBuilder {
+	mock := &MockStreamBuilder{ctrl: ctrl}
+	mock.recorder = &MockStreamBuilderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStreamBuilder) EXPECT() *MockStreamBuilderMockRecorder {
+	return m.recorder
+}
+
+// StreamNext mocks base method.
+func (m *MockStreamBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamNext", next)
+	ret0, _ := ret[0].(handler.StreamHandler)
+	return ret0
+}
+
+// StreamNext indicates an expected call of StreamNext.
+func (mr *MockStreamBuilderMockRecorder) StreamNext(next any) *MockStreamBuilderStreamNextCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamNext", reflect.TypeOf((*MockStreamBuilder)(nil).StreamNext), next)
+	return &MockStreamBuilderStreamNextCall{Call: call}
+}
+
+// MockStreamBuilderStreamNextCall wrap *gomock.Call
+type MockStreamBuilderStreamNextCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStreamBuilderStreamNextCall) Return(arg0 handler.StreamHandler) *MockStreamBuilderStreamNextCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStreamBuilderStreamNextCall) Do(f func(handler.StreamHandler) handler.StreamHandler) *MockStreamBuilderStreamNextCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStreamBuilderStreamNextCall) DoAndReturn(f func(handler.StreamHandler) handler.StreamHandler) *MockStreamBuilderStreamNextCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
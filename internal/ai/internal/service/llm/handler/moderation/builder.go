@@ -0,0 +1,262 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+)
+
+// HandlerBuilder 审核用户的输入和大模型的回答。
+// 需要放在 record 之后，这样审核的结论才能记录到 LLMRecord 上。
+// 非流式调用中只审核输入，要放在 ratelimit 和 credit 之前，被拦截的请求不会占用限流的额度，也不会预扣积分；
+// 回答由 Output 审核，要放在 credit 之后，回答被拦截的时候预扣的积分会被退回。
+// 流式调用中要放在 credit 之后，回答被拦截的时候同样退回预扣的积分
+type HandlerBuilder struct {
+	filter moderation.Filter
+}
+
+func NewHandlerBuilder(filter moderation.Filter) *HandlerBuilder {
+	return &HandlerBuilder{filter: filter}
+}
+
+// Output 非流式调用中审核大模型的回答
+func (b *HandlerBuilder) Output() handler.Builder {
+	return outputBuilder{filter: b.filter}
+}
+
+func (b *HandlerBuilder) Next(next handler.Handler) handler.Handler {
+	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+		req, moderations, err := b.checkInput(ctx, req)
+		if err != nil {
+			return domain.LLMResponse{}, err
+		}
+		resp, err := next.Handle(ctx, req)
+		var merr *domain.ModerationError
+		if errors.As(err, &merr) {
+			merr.Moderations = append(moderations, merr.Moderations...)
+		}
+		if err != nil {
+			return resp, err
+		}
+		resp.Moderations = append(moderations, resp.Moderations...)
+		return resp, nil
+	})
+}
+
+type outputBuilder struct {
+	filter moderation.Filter
+}
+
+func (b outputBuilder) Next(next handler.Handler) handler.Handler {
+	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+		resp, err := next.Handle(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		d, err := b.filter.Check(ctx, resp.Answer)
+		if err != nil {
+			return domain.LLMResponse{}, err
+		}
+		if d.Action == moderation.ActionPass {
+			return resp, nil
+		}
+		moderations := []domain.Moderation{domain.NewModeration(domain.ModerationStageOutput, d)}
+		if d.Blocked() {
+			return domain.LLMResponse{}, &domain.ModerationError{Moderations: moderations}
+		}
+		resp.Answer = d.Text
+		// 结构化输出的 Object 是从 Answer 里面提取出来的，
+		// 打码只会把敏感词替换成 *，不会破坏 JSON 的结构
+		if resp.Object != nil {
+			obj, err := b.filter.Check(ctx, string(resp.Object))
+			if err != nil {
+				return domain.LLMResponse{}, err
+			}
+			resp.Object = []byte(obj.Text)
+		}
+		resp.Moderations = moderations
+		return resp, nil
+	})
+}
+
+// StreamNext 流式调用同时审核输入和回答。
+// 回答先攒起来，每攒够 streamCheckSize 个字符就审核一次到目前为止的全部回答，通过之后才输出，
+// 这样被拆到两个事件里面的敏感词也能被拦下来。已经输出的内容没办法撤回，
+// 所以正好跨过两次审核的打码词，前半截可能已经输出了
+func (b *HandlerBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	return handler.StreamHandleFunc(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+		req, moderations, err := b.checkInput(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		// 回答被拦截之后要中断上游的流
+		upstreamCtx, cancel := context.WithCancel(ctx)
+		src, err := next.StreamHandle(upstreamCtx, req)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		dst := make(chan domain.StreamEvent, 10)
+		go func() {
+			defer cancel()
+			b.relayOutput(ctx, cancel, src, dst, moderations)
+		}()
+		return dst, nil
+	})
+}
+
+// streamCheckSize 流式输出每攒够多少个字符审核一次
+const streamCheckSize = 50
+
+// streamText 流式输出中累计的一段回答
+type streamText struct {
+	text []rune
+	// 已经输出的字符数
+	sent int
+	// 最近一次打码的结论，每次审核的都是全部内容，所以只需要保留最近的一次
+	mask *domain.Moderation
+}
+
+func (t *streamText) pending() int {
+	return len(t.text) - t.sent
+}
+
+// release 审核通过或者打码之后，输出还没有输出过的部分
+func (t *streamText) release(checked string) string {
+	runes := []rune(checked)
+	// 打码不会改变字符数，这里只是以防万一
+	if len(runes) != len(t.text) {
+		runes = t.text
+	}
+	res := string(runes[t.sent:])
+	t.sent = len(runes)
+	return res
+}
+
+// relayOutput 审核并转发上游的事件。被拦截之后丢弃后面所有的内容，
+// 最后一个事件带上 ModerationError，上层据此记录审核结论并且退回预扣的积分。
+// 调用方放弃读取之后依旧会把 src 消费完，和 handler.Relay 一样
+func (b *HandlerBuilder) relayOutput(ctx context.Context, cancel context.CancelFunc,
+	src chan domain.StreamEvent, dst chan domain.StreamEvent, moderations []domain.Moderation) {
+	defer close(dst)
+	var (
+		content, reasoning streamText
+		blocked            error
+		abandoned          bool
+	)
+	for evt := range src {
+		if blocked == nil {
+			content.text = append(content.text, []rune(evt.Content)...)
+			reasoning.text = append(reasoning.text, []rune(evt.ReasoningContent)...)
+			if !evt.Done && content.pending() < streamCheckSize && reasoning.pending() < streamCheckSize {
+				continue
+			}
+			var err error
+			evt.Content, evt.ReasoningContent, err = b.checkOutput(ctx, &content, &reasoning)
+			if err != nil {
+				blocked = err
+				cancel()
+			}
+		}
+		if blocked != nil {
+			if !evt.Done {
+				continue
+			}
+			evt.Content, evt.ReasoningContent = "", ""
+			evt.Error = blocked
+		}
+		if evt.Done {
+			evt.Moderations = slices.Concat(moderations, masks(&content, &reasoning))
+			var merr *domain.ModerationError
+			if errors.As(evt.Error, &merr) {
+				merr.Moderations = slices.Concat(evt.Moderations, merr.Moderations)
+			}
+		}
+		if !abandoned {
+			select {
+			case dst <- evt:
+			case <-ctx.Done():
+				abandoned = true
+			}
+		}
+	}
+}
+
+// checkOutput 审核到目前为止的全部回答以及思考过程，返回可以输出的部分
+func (b *HandlerBuilder) checkOutput(ctx context.Context, content, reasoning *streamText) (string, string, error) {
+	texts := []*streamText{content, reasoning}
+	res := make([]string, len(texts))
+	for i, t := range texts {
+		if t.pending() == 0 {
+			continue
+		}
+		d, err := b.filter.Check(ctx, string(t.text))
+		if err != nil {
+			return "", "", fmt.Errorf("审核大模型回答失败 %w", err)
+		}
+		if d.Action == moderation.ActionPass {
+			res[i] = t.release(string(t.text))
+			continue
+		}
+		m := domain.NewModeration(domain.ModerationStageOutput, d)
+		if d.Blocked() {
+			// 拦截的结论由 relayOutput 补上输入以及打码的结论
+			return "", "", &domain.ModerationError{Moderations: []domain.Moderation{m}}
+		}
+		t.mask = &m
+		res[i] = t.release(d.Text)
+	}
+	return res[0], res[1], nil
+}
+
+// masks 回答以及思考过程中打码的结论
+func masks(texts ...*streamText) []domain.Moderation {
+	var res []domain.Moderation
+	for _, t := range texts {
+		if t.mask != nil {
+			res = append(res, *t.mask)
+		}
+	}
+	return res
+}
+
+// checkInput 逐个审核用户的输入，任何一个被拦截则整个请求被拦截，命中打码词典的输入会被替换成打码之后的
+func (b *HandlerBuilder) checkInput(ctx context.Context, req domain.LLMRequest) (domain.LLMRequest, []domain.Moderation, error) {
+	var moderations []domain.Moderation
+	input := slices.Clone(req.Input)
+	for i, in := range input {
+		d, err := b.filter.Check(ctx, in)
+		if err != nil {
+			return req, nil, fmt.Errorf("审核用户输入失败 %w", err)
+		}
+		if d.Action == moderation.ActionPass {
+			continue
+		}
+		moderations = append(moderations, domain.NewModeration(domain.ModerationStageInput, d))
+		if d.Blocked() {
+			return req, nil, &domain.ModerationError{Moderations: moderations}
+		}
+		input[i] = d.Text
+	}
+	req.Input = input
+	return req, moderations, nil
+}
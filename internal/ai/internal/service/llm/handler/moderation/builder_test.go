@@ -0,0 +1,221 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerBuilder_Next(t *testing.T) {
+	filter := moderation.NewDictFilter(moderation.Dict{
+		Block: []string{"赌博"},
+		Mask:  []string{"微信"},
+	}, nil)
+	testCases := []struct {
+		name   string
+		input  []string
+		answer string
+		object json.RawMessage
+		// 大模型是否被调用
+		called bool
+		// 大模型收到的输入
+		wantInput       []string
+		wantAnswer      string
+		wantObject      json.RawMessage
+		wantModerations []domain.Moderation
+		wantErr         error
+	}{
+		{
+			name:       "通过",
+			input:      []string{"MySQL 索引"},
+			answer:     "B+ 树",
+			called:     true,
+			wantInput:  []string{"MySQL 索引"},
+			wantAnswer: "B+ 树",
+		},
+		{
+			name:   "输入被拦截",
+			input:  []string{"MySQL", "线上赌博"},
+			called: false,
+			wantModerations: []domain.Moderation{
+				{Stage: domain.ModerationStageInput, Action: "block", Source: "dict", Hits: []string{"赌博"}},
+			},
+			wantErr: moderation.ErrBlocked,
+		},
+		{
+			name:       "输入和输出打码",
+			input:      []string{"我的微信"},
+			answer:     `{"contact": "微信"}`,
+			object:     json.RawMessage(`{"contact": "微信"}`),
+			called:     true,
+			wantInput:  []string{"我的**"},
+			wantAnswer: `{"contact": "**"}`,
+			wantObject: json.RawMessage(`{"contact": "**"}`),
+			wantModerations: []domain.Moderation{
+				{Stage: domain.ModerationStageInput, Action: "mask", Source: "dict", Hits: []string{"微信"}},
+				{Stage: domain.ModerationStageOutput, Action: "mask", Source: "dict", Hits: []string{"微信"}},
+			},
+		},
+		{
+			name:      "输出被拦截",
+			input:     []string{"MySQL"},
+			answer:    "推荐一个赌博网站",
+			called:    true,
+			wantInput: []string{"MySQL"},
+			wantModerations: []domain.Moderation{
+				{Stage: domain.ModerationStageOutput, Action: "block", Source: "dict", Hits: []string{"赌博"}},
+			},
+			wantErr: moderation.ErrBlocked,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				called bool
+				input  []string
+			)
+			builder := NewHandlerBuilder(filter)
+			hdl := builder.Next(builder.Output().Next(handler.HandleFunc(
+				func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+					called = true
+					input = req.Input
+					return domain.LLMResponse{Answer: tc.answer, Object: tc.object}, nil
+				})))
+			req := domain.LLMRequest{Input: tc.input}
+			resp, err := hdl.Handle(context.Background(), req)
+			assert.Equal(t, tc.called, called)
+			assert.Equal(t, tc.wantInput, input)
+			// 不能修改调用方的输入
+			assert.Equal(t, tc.input, req.Input)
+			assert.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				var merr *domain.ModerationError
+				require.True(t, errors.As(err, &merr))
+				assert.Equal(t, tc.wantModerations, merr.Moderations)
+				return
+			}
+			assert.Equal(t, tc.wantAnswer, resp.Answer)
+			assert.Equal(t, tc.wantObject, resp.Object)
+			assert.Equal(t, tc.wantModerations, resp.Moderations)
+		})
+	}
+}
+
+func TestHandlerBuilder_StreamNext(t *testing.T) {
+	filter := moderation.NewDictFilter(moderation.Dict{
+		Block: []string{"赌博"},
+		Mask:  []string{"微信"},
+	}, nil)
+	long := strings.Repeat("索", streamCheckSize)
+	testCases := []struct {
+		name   string
+		events []domain.StreamEvent
+		// 上游的流是否被中断
+		canceled        bool
+		wantContent     string
+		wantModerations []domain.Moderation
+		wantErr         error
+	}{
+		{
+			name: "通过",
+			events: []domain.StreamEvent{
+				{Content: "B+ "}, {Content: "树"}, {Done: true, Amount: 10},
+			},
+			wantContent: "B+ 树",
+		},
+		{
+			name: "打码",
+			events: []domain.StreamEvent{
+				{Content: "加我微"}, {Content: "信"}, {Done: true, Amount: 10},
+			},
+			wantContent: "加我**",
+			wantModerations: []domain.Moderation{
+				{Stage: domain.ModerationStageOutput, Action: "mask", Source: "dict", Hits: []string{"微信"}},
+			},
+		},
+		{
+			name: "敏感词被拆到两个事件里面",
+			events: []domain.StreamEvent{
+				{Content: "推荐一个赌"}, {Content: "博网站"}, {Done: true, Amount: 10},
+			},
+			wantModerations: []domain.Moderation{
+				{Stage: domain.ModerationStageOutput, Action: "block", Source: "dict", Hits: []string{"赌博"}},
+			},
+			wantErr: moderation.ErrBlocked,
+		},
+		{
+			name: "输出一部分之后被拦截",
+			events: []domain.StreamEvent{
+				{Content: long}, {Content: "赌博"}, {Content: "网站"}, {Done: true, Amount: 10},
+			},
+			canceled:    true,
+			wantContent: long,
+			wantModerations: []domain.Moderation{
+				{Stage: domain.ModerationStageOutput, Action: "block", Source: "dict", Hits: []string{"赌博"}},
+			},
+			wantErr: moderation.ErrBlocked,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var upstreamCtx context.Context
+			hdl := NewHandlerBuilder(filter).StreamNext(handler.StreamHandleFunc(
+				func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+					upstreamCtx = ctx
+					ch := make(chan domain.StreamEvent, len(tc.events))
+					for _, evt := range tc.events {
+						ch <- evt
+					}
+					close(ch)
+					return ch, nil
+				}))
+			ch, err := hdl.StreamHandle(context.Background(), domain.LLMRequest{Input: []string{"MySQL"}})
+			require.NoError(t, err)
+			var (
+				content strings.Builder
+				last    domain.StreamEvent
+			)
+			for evt := range ch {
+				content.WriteString(evt.Content)
+				last = evt
+			}
+			assert.Equal(t, tc.wantContent, content.String())
+			require.True(t, last.Done)
+			// 扣费要用到最后一个事件里面的用量
+			assert.Equal(t, int64(10), last.Amount)
+			if tc.canceled {
+				assert.ErrorIs(t, upstreamCtx.Err(), context.Canceled)
+			}
+			assert.ErrorIs(t, last.Error, tc.wantErr)
+			if last.Error != nil {
+				var merr *domain.ModerationError
+				require.True(t, errors.As(last.Error, &merr))
+				assert.Equal(t, tc.wantModerations, merr.Moderations)
+				return
+			}
+			assert.Equal(t, tc.wantModerations, last.Moderations)
+		})
+	}
+}
@@ -3,11 +3,15 @@ package ali_deepseek
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
-	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
 	"github.com/gotomicro/ego/core/elog"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -24,61 +28,117 @@ type Delta struct {
 }
 
 type Handler struct {
-	client     *openai.Client
-	repo       repository.LLMLogRepo
-	logger     *elog.Component
-	configRepo repository.ConfigRepository
+	client *openai.Client
+	logger *elog.Component
 }
 
-func NewHandler(apikey string, repo repository.LLMLogRepo, configRepo repository.ConfigRepository) *Handler {
+func NewHandler(apikey string) *Handler {
 	client := openai.NewClient(
 		option.WithBaseURL(baseUrl),
 		option.WithAPIKey(apikey),
 	)
 	return &Handler{
-		client:     client,
-		repo:       repo,
-		configRepo: configRepo,
-		logger:     elog.DefaultLogger,
+		client: client,
+		logger: elog.DefaultLogger,
 	}
 }
 
-func (h *Handler) StreamHandle(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
-	config, err := h.findConfig(ctx, req)
+func (h *Handler) Name() string {
+	return "ali"
+}
+
+func (h *Handler) Handle(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F(h.messages(req)),
+		Model:    openai.F(req.Config.Model),
+	}
+	if req.Config.Temperature > 0 {
+		params.Temperature = openai.F(req.Config.Temperature)
+	}
+	if req.Config.TopP > 0 {
+		params.TopP = openai.F(req.Config.TopP)
+	}
+	if req.Schema != nil {
+		// 百炼的 DeepSeek 只支持 json_object，Schema 本身放在 SystemPrompt 里面
+		params.ResponseFormat = openai.F[openai.ChatCompletionNewParamsResponseFormatUnion](
+			openai.ResponseFormatJSONObjectParam{
+				Type: openai.F(openai.ResponseFormatJSONObjectTypeJSONObject),
+			})
+	}
+	completion, err := h.client.Chat.Completions.New(ctx, params)
 	if err != nil {
-		return nil, err
+		return domain.LLMResponse{}, h.wrapErr(err)
+	}
+	tokens := completion.Usage.TotalTokens
+	amt := math.Ceil(float64(tokens*req.Config.Price) / float64(1000))
+	resp := domain.LLMResponse{
+		Tokens:   tokens,
+		Amount:   int64(amt),
+		Platform: h.Name(),
+	}
+	if len(completion.Choices) > 0 {
+		resp.Answer = completion.Choices[0].Message.Content
+	}
+	return resp, nil
+}
+
+func (h *Handler) messages(req domain.LLMRequest) []openai.ChatCompletionMessageParamUnion {
+	msgs := req.Messages()
+	res := make([]openai.ChatCompletionMessageParamUnion, 0, len(msgs))
+	for _, msg := range msgs {
+		switch msg.Role {
+		case domain.RoleSystem:
+			res = append(res, openai.SystemMessage(msg.Content))
+		case domain.RoleAssistant:
+			res = append(res, openai.AssistantMessage(msg.Content))
+		default:
+			res = append(res, openai.UserMessage(msg.Content))
+		}
+	}
+	return res
+}
+
+// wrapErr 把超时和 5xx 包装为 handler.ErrPlatformUnavailable
+func (h *Handler) wrapErr(err error) error {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%w, %w", handler.ErrPlatformUnavailable, err)
+		}
+		return err
 	}
-	req.Config = config
+	// 没有拿到响应，例如超时、网络错误
+	return fmt.Errorf("%w, %w", handler.ErrPlatformUnavailable, err)
+}
+
+// StreamHandle 配置、记录以及扣费都由 StreamBuilder 负责。
+// ctx 被取消的时候会中断上游的流，最后一个事件里面是按照已经输出的内容估算的用量
+func (h *Handler) StreamHandle(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
 	eventCh := make(chan domain.StreamEvent, 10)
 	params := openai.ChatCompletionNewParams{
-		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(req.Prompt()),
-		}),
-
-		Model: openai.F(req.Config.Model),
+		Messages: openai.F(h.messages(req)),
+		Model:    openai.F(req.Config.Model),
 		StreamOptions: openai.F(openai.ChatCompletionStreamOptionsParam{
 			IncludeUsage: openai.F(true),
 		}),
 	}
 
 	go func() {
-		newCtx, cancel := context.WithTimeout(context.Background(), time.Minute*10)
+		newCtx, cancel := context.WithTimeout(ctx, time.Minute*10)
 		defer cancel()
 		stream := h.client.Chat.Completions.NewStreaming(newCtx, params)
-		h.recv(req, eventCh, stream)
+		h.recv(newCtx, req, eventCh, stream)
 	}()
 
 	return eventCh, nil
 }
 
-func (h *Handler) findConfig(ctx context.Context, req domain.LLMRequest) (domain.BizConfig, error) {
-	return h.configRepo.GetConfig(ctx, req.Biz)
-}
-
-func (h *Handler) recv(req domain.LLMRequest, eventCh chan domain.StreamEvent,
+func (h *Handler) recv(ctx context.Context, req domain.LLMRequest, eventCh chan domain.StreamEvent,
 	stream *ssestream.Stream[openai.ChatCompletionChunk]) {
 	defer close(eventCh)
 	acc := openai.ChatCompletionAccumulator{}
+	var output strings.Builder
+	var err error
 
 	for stream.Next() {
 		chunk := stream.Current()
@@ -88,64 +148,44 @@ func (h *Handler) recv(req domain.LLMRequest, eventCh chan domain.StreamEvent,
 			// 说明没结束
 			if chunk.Choices[0].FinishReason == "" {
 				var delta Delta
-				err := json.Unmarshal([]byte(chunk.Choices[0].Delta.JSON.RawJSON()), &delta)
+				err = json.Unmarshal([]byte(chunk.Choices[0].Delta.JSON.RawJSON()), &delta)
 				if err != nil {
-					eventCh <- domain.StreamEvent{
-						Error: err,
-					}
-					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-					h.saveRecord(ctx, req, domain.RecordStatusFailed, domain.LLMResponse{})
-					cancel()
-					return
+					break
 				}
-				eventCh <- domain.StreamEvent{
+				output.WriteString(delta.ReasoningContent)
+				output.WriteString(delta.Content)
+				h.send(ctx, eventCh, domain.StreamEvent{
 					Content:          delta.Content,
 					ReasoningContent: delta.ReasoningContent,
-				}
+				})
 			}
 		}
 	}
-	eventCh <- domain.StreamEvent{
-		Done: true,
-	}
-	// 记录数据
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if stream.Err() != nil {
-		h.saveRecord(ctx, req, domain.RecordStatusFailed, domain.LLMResponse{})
-		h.logger.Error("获取deepseek 流数据失败", elog.FieldErr(stream.Err()))
-		return
-	}
-	var ans string
-	if len(acc.Choices) > 0 {
-		ans = acc.Choices[0].Message.Content
+	if err == nil {
+		err = stream.Err()
 	}
+	_ = stream.Close()
+
 	tokens := acc.Usage.TotalTokens
+	if err != nil {
+		h.logger.Error("获取deepseek 流数据失败", elog.FieldErr(err))
+		// 没有拿到最终的用量，只能估算
+		tokens = domain.EstimateMessagesTokens(req.Messages()) + domain.EstimateTokens(output.String())
+	}
 	amt := math.Ceil(float64(tokens*req.Config.Price) / float64(1000))
-
-	h.saveRecord(ctx, req, domain.RecordStatusSuccess, domain.LLMResponse{
+	// 最后一个事件一定要发出去，上层依赖它来记录和扣费
+	eventCh <- domain.StreamEvent{
+		Done:   true,
+		Error:  err,
 		Tokens: tokens,
-		Answer: ans,
 		Amount: int64(amt),
-	})
-
+	}
 }
 
-func (h *Handler) saveRecord(ctx context.Context, req domain.LLMRequest, status domain.RecordStatus, resp domain.LLMResponse) {
-	log := domain.LLMRecord{
-		Tid:            req.Tid,
-		Biz:            req.Biz,
-		Uid:            req.Uid,
-		Input:          req.Input,
-		Status:         domain.RecordStatusProcessing,
-		KnowledgeId:    req.Config.KnowledgeId,
-		PromptTemplate: req.Config.PromptTemplate,
-	}
-	log.Tokens = resp.Tokens
-	log.Status = status
-	log.Answer = resp.Answer
-	_, err1 := h.repo.SaveLog(ctx, log)
-	if err1 != nil {
-		h.logger.Error("保存 LLM 访问记录失败", elog.FieldErr(err1))
+// send 调用方放弃之后就不再发送，避免阻塞
+func (h *Handler) send(ctx context.Context, eventCh chan domain.StreamEvent, evt domain.StreamEvent) {
+	select {
+	case eventCh <- evt:
+	case <-ctx.Done():
 	}
 }
@@ -0,0 +1,265 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var ErrNoPlatform = errors.New("没有可用的大模型平台")
+
+var (
+	requestCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_platform_requests_total",
+		Help: "按照平台统计的大模型调用次数",
+	}, []string{"platform", "biz", "status"})
+	requestDuration = promauto.NewSummaryVec(prometheus.SummaryOpts{
+		Name: "llm_platform_request_duration_seconds",
+		Help: "按照平台统计的大模型调用耗时",
+		Objectives: map[float64]float64{
+			0.5:  0.05,
+			0.9:  0.01,
+			0.99: 0.001,
+		},
+	}, []string{"platform", "biz"})
+)
+
+// Platform 参与路由的大模型平台
+type Platform struct {
+	Name string
+	// 权重，按照权重分配流量。
+	// 权重为 0 的平台只会在别的平台不可用的时候才会被使用
+	Weight int
+	// 单次调用的超时时间，为 0 则不额外设置超时
+	Timeout time.Duration
+	// BizConfig.Model 到平台模型的映射，
+	// 为空说明该平台支持所有的模型，并且不需要转换
+	Models  map[string]string
+	Handler handler.Handler
+}
+
+func (p Platform) model(model string) (string, bool) {
+	if len(p.Models) == 0 {
+		return model, true
+	}
+	m, ok := p.Models[model]
+	if ok && m == "" {
+		m = model
+	}
+	return m, ok
+}
+
+type platform struct {
+	Platform
+	mu                  sync.Mutex
+	consecutiveFailures int
+	successCnt          int64
+	failureCnt          int64
+	lastErr             string
+	lastErrTime         time.Time
+	unhealthyUntil      time.Time
+}
+
+// Handler 根据 BizConfig.Model 和权重选择平台，
+// 在平台超时或者 5xx 的时候切换到下一个平台
+type Handler struct {
+	platforms []*platform
+	// 连续失败多少次之后标记为不可用
+	failureThreshold int
+	// 被标记为不可用之后，多久再试
+	cooldown time.Duration
+	// 一次请求最多尝试几个平台
+	maxAttempts int
+	logger      *elog.Component
+	now         func() time.Time
+	randIntN    func(n int) int
+}
+
+func NewHandler(platforms []Platform) *Handler {
+	return &Handler{
+		platforms: slice.Map(platforms, func(idx int, src Platform) *platform {
+			return &platform{Platform: src}
+		}),
+		failureThreshold: 3,
+		cooldown:         time.Minute,
+		maxAttempts:      2,
+		logger:           elog.DefaultLogger,
+		now:              time.Now,
+		randIntN:         rand.IntN,
+	}
+}
+
+func (h *Handler) Name() string {
+	return "router"
+}
+
+func (h *Handler) Handle(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+	candidates := h.candidates(req.Config.Model)
+	if len(candidates) == 0 {
+		return domain.LLMResponse{}, fmt.Errorf("%w, 模型 %s", ErrNoPlatform, req.Config.Model)
+	}
+	if len(candidates) > h.maxAttempts {
+		candidates = candidates[:h.maxAttempts]
+	}
+	var err error
+	for _, p := range candidates {
+		var resp domain.LLMResponse
+		resp, err = h.invoke(ctx, p, req)
+		if err == nil {
+			return resp, nil
+		}
+		// 调用方已经放弃了，或者不是平台的问题，就没必要换平台重试了
+		if ctx.Err() != nil || !h.unavailable(err) {
+			return domain.LLMResponse{}, err
+		}
+		h.logger.Warn("大模型平台不可用，尝试切换平台",
+			elog.String("platform", p.Name),
+			elog.String("biz", req.Biz),
+			elog.String("tid", req.Tid),
+			elog.FieldErr(err))
+	}
+	return domain.LLMResponse{}, err
+}
+
+func (h *Handler) invoke(ctx context.Context, p *platform, req domain.LLMRequest) (domain.LLMResponse, error) {
+	req.Config.Model, _ = p.model(req.Config.Model)
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	start := h.now()
+	resp, err := p.Handler.Handle(ctx, req)
+	requestDuration.WithLabelValues(p.Name, req.Biz).Observe(h.now().Sub(start).Seconds())
+	switch {
+	case err == nil:
+		requestCounter.WithLabelValues(p.Name, req.Biz, "success").Inc()
+		h.markSuccess(p)
+		if resp.Platform == "" {
+			resp.Platform = p.Name
+		}
+	case h.unavailable(err):
+		requestCounter.WithLabelValues(p.Name, req.Biz, "unavailable").Inc()
+		h.markFailure(p, err)
+	default:
+		requestCounter.WithLabelValues(p.Name, req.Biz, "failed").Inc()
+	}
+	return resp, err
+}
+
+func (h *Handler) unavailable(err error) bool {
+	return errors.Is(err, handler.ErrPlatformUnavailable) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// candidates 返回支持该模型的平台，第一个是按照权重选中的平台，
+// 后面是备选平台。不可用的平台排在最后，所有平台都不可用的时候依旧会尝试一下
+func (h *Handler) candidates(model string) []*platform {
+	now := h.now()
+	var healthy, unhealthy []*platform
+	for _, p := range h.platforms {
+		if _, ok := p.model(model); !ok {
+			continue
+		}
+		if p.healthy(now) {
+			healthy = append(healthy, p)
+		} else {
+			unhealthy = append(unhealthy, p)
+		}
+	}
+	sort.SliceStable(healthy, func(i, j int) bool {
+		return healthy[i].Weight > healthy[j].Weight
+	})
+	if idx := h.pick(healthy); idx > 0 {
+		primary := healthy[idx]
+		copy(healthy[1:idx+1], healthy[:idx])
+		healthy[0] = primary
+	}
+	return append(healthy, unhealthy...)
+}
+
+// pick 按照权重随机选择一个平台
+func (h *Handler) pick(platforms []*platform) int {
+	total := 0
+	for _, p := range platforms {
+		total += p.Weight
+	}
+	if total <= 0 {
+		return 0
+	}
+	r := h.randIntN(total)
+	for i, p := range platforms {
+		r -= p.Weight
+		if r < 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+func (h *Handler) markSuccess(p *platform) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.successCnt++
+	p.consecutiveFailures = 0
+	p.unhealthyUntil = time.Time{}
+}
+
+func (h *Handler) markFailure(p *platform, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := h.now()
+	p.failureCnt++
+	p.consecutiveFailures++
+	p.lastErr = err.Error()
+	p.lastErrTime = now
+	if p.consecutiveFailures >= h.failureThreshold {
+		p.unhealthyUntil = now.Add(h.cooldown)
+	}
+}
+
+// Health 返回各个平台的健康状态
+func (h *Handler) Health() []domain.PlatformHealth {
+	now := h.now()
+	return slice.Map(h.platforms, func(idx int, p *platform) domain.PlatformHealth {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		res := domain.PlatformHealth{
+			Name:                p.Name,
+			Weight:              p.Weight,
+			Healthy:             !now.Before(p.unhealthyUntil),
+			ConsecutiveFailures: p.consecutiveFailures,
+			SuccessCnt:          p.successCnt,
+			FailureCnt:          p.failureCnt,
+			LastErr:             p.lastErr,
+		}
+		if !p.lastErrTime.IsZero() {
+			res.LastErrTime = p.lastErrTime.UnixMilli()
+		}
+		if !p.unhealthyUntil.IsZero() {
+			res.UnhealthyUntil = p.unhealthyUntil.UnixMilli()
+		}
+		return res
+	})
+}
+
+// healthy 过了冷却时间之后，会放请求过去试探，
+// 如果依旧失败，连续失败次数依旧超过阈值，会再次被标记为不可用
+func (p *platform) healthy(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !now.Before(p.unhealthyUntil)
+}
+
+var _ handler.Handler = &Handler{}
@@ -0,0 +1,165 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Handle(t *testing.T) {
+	unavailable := func(name string) handler.Handler {
+		return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+			return domain.LLMResponse{}, fmt.Errorf("%w, %s 挂了", handler.ErrPlatformUnavailable, name)
+		})
+	}
+	answer := func(name string) handler.Handler {
+		return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+			return domain.LLMResponse{Answer: name + ":" + req.Config.Model}, nil
+		})
+	}
+	testCases := []struct {
+		name      string
+		platforms []Platform
+		rand      int
+		model     string
+		wantResp  domain.LLMResponse
+		wantErr   error
+	}{
+		{
+			name: "按照权重选中第二个平台",
+			platforms: []Platform{
+				{Name: "zhipu", Weight: 50, Handler: answer("zhipu")},
+				{Name: "ali", Weight: 50, Handler: answer("ali")},
+			},
+			rand:     60,
+			model:    "glm-4",
+			wantResp: domain.LLMResponse{Answer: "ali:glm-4", Platform: "ali"},
+		},
+		{
+			name: "主平台不可用，切换到兜底平台并转换模型",
+			platforms: []Platform{
+				{Name: "zhipu", Weight: 100, Handler: unavailable("zhipu")},
+				{Name: "ali", Handler: answer("ali"), Models: map[string]string{"glm-4": "deepseek-v3"}},
+			},
+			model:    "glm-4",
+			wantResp: domain.LLMResponse{Answer: "ali:deepseek-v3", Platform: "ali"},
+		},
+		{
+			name: "主平台超时，切换到兜底平台",
+			platforms: []Platform{
+				{Name: "zhipu", Weight: 100, Timeout: time.Millisecond,
+					Handler: handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+						<-ctx.Done()
+						return domain.LLMResponse{}, ctx.Err()
+					})},
+				{Name: "ali", Handler: answer("ali")},
+			},
+			model:    "glm-4",
+			wantResp: domain.LLMResponse{Answer: "ali:glm-4", Platform: "ali"},
+		},
+		{
+			name: "业务错误不切换平台",
+			platforms: []Platform{
+				{Name: "zhipu", Weight: 100, Handler: handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+					return domain.LLMResponse{}, errors.New("参数错误")
+				})},
+				{Name: "ali", Handler: answer("ali")},
+			},
+			model:   "glm-4",
+			wantErr: errors.New("参数错误"),
+		},
+		{
+			name: "没有平台支持该模型",
+			platforms: []Platform{
+				{Name: "ali", Weight: 100, Handler: answer("ali"), Models: map[string]string{"deepseek-v3": ""}},
+			},
+			model:   "glm-4",
+			wantErr: fmt.Errorf("%w, 模型 glm-4", ErrNoPlatform),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(tc.platforms)
+			h.randIntN = func(n int) int {
+				return tc.rand
+			}
+			resp, err := h.Handle(context.Background(), domain.LLMRequest{
+				Biz:    domain.BizQuestionExamine,
+				Config: domain.BizConfig{Model: tc.model},
+			})
+			if tc.wantErr != nil {
+				assert.Equal(t, tc.wantErr.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantResp, resp)
+		})
+	}
+}
+
+func TestHandler_Health(t *testing.T) {
+	calls := 0
+	h := NewHandler([]Platform{
+		{Name: "zhipu", Weight: 100, Handler: handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+			calls++
+			return domain.LLMResponse{}, handler.ErrPlatformUnavailable
+		})},
+		{Name: "ali", Handler: handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+			return domain.LLMResponse{Answer: "ok"}, nil
+		})},
+	})
+	now := time.UnixMilli(1000)
+	h.now = func() time.Time {
+		return now
+	}
+	for i := 0; i < 5; i++ {
+		resp, err := h.Handle(context.Background(), domain.LLMRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "ali", resp.Platform)
+	}
+	// 连续失败 3 次之后，就不会再把请求发给智谱了
+	assert.Equal(t, 3, calls)
+	health := h.Health()
+	assert.Equal(t, domain.PlatformHealth{
+		Name:                "zhipu",
+		Weight:              100,
+		Healthy:             false,
+		ConsecutiveFailures: 3,
+		FailureCnt:          3,
+		LastErr:             handler.ErrPlatformUnavailable.Error(),
+		LastErrTime:         1000,
+		UnhealthyUntil:      now.Add(time.Minute).UnixMilli(),
+	}, health[0])
+	assert.Equal(t, domain.PlatformHealth{
+		Name:       "ali",
+		Healthy:    true,
+		SuccessCnt: 5,
+	}, health[1])
+
+	// 冷却时间过了之后会再试一下
+	now = now.Add(time.Minute)
+	_, err := h.Handle(context.Background(), domain.LLMRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+}
@@ -2,9 +2,12 @@ package zhipu
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
 	"github.com/yankeguo/zhipu"
 )
 
@@ -33,7 +36,7 @@ func (h *Handler) Handle(ctx context.Context, req domain.LLMRequest) (domain.LLM
 	chatReq := h.buildReq(req)
 	completion, err := chatReq.Do(ctx)
 	if err != nil {
-		return domain.LLMResponse{}, err
+		return domain.LLMResponse{}, h.wrapErr(err)
 	}
 	tokens := completion.Usage.TotalTokens
 	// 现在的报价都是 N/1k token
@@ -41,8 +44,9 @@ func (h *Handler) Handle(ctx context.Context, req domain.LLMRequest) (domain.LLM
 	amt := math.Ceil(float64(tokens*req.Config.Price) / float64(1000))
 	// 金额只有具体的模型才知道怎么算
 	resp := domain.LLMResponse{
-		Tokens: tokens,
-		Amount: int64(amt),
+		Tokens:   tokens,
+		Amount:   int64(amt),
+		Platform: h.Name(),
 	}
 
 	if len(completion.Choices) > 0 {
@@ -51,21 +55,43 @@ func (h *Handler) Handle(ctx context.Context, req domain.LLMRequest) (domain.LLM
 	return resp, nil
 }
 
+// wrapErr 把智谱内部错误、网络错误以及超时包装为 handler.ErrPlatformUnavailable
+func (h *Handler) wrapErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w, %w", handler.ErrPlatformUnavailable, err)
+	}
+	var apiErr zhipu.APIErrorResponse
+	if errors.As(err, &apiErr) {
+		// 5xx 的时候响应体未必是 JSON，所以 code 可能为空
+		// 500 内部错误，1234 网络错误
+		switch apiErr.Code {
+		case "", "500", "1234":
+			return fmt.Errorf("%w, %w", handler.ErrPlatformUnavailable, err)
+		}
+		return err
+	}
+	// 其余的都是请求没有发出去或者没有收到响应
+	return fmt.Errorf("%w, %w", handler.ErrPlatformUnavailable, err)
+}
+
 func (h *Handler) buildReq(req domain.LLMRequest) *zhipu.ChatCompletionService {
 	chatReq := h.client.ChatCompletion(req.Config.Model)
 
-	if req.Config.SystemPrompt != "" {
+	// SDK 还不支持 response_format，结构化输出只能依赖 SystemPrompt 里面的 JSON Schema
+	for _, msg := range req.Messages() {
+		role := zhipu.RoleUser
+		switch msg.Role {
+		case domain.RoleSystem:
+			role = zhipu.RoleSystem
+		case domain.RoleAssistant:
+			role = zhipu.RoleAssistant
+		}
 		chatReq = chatReq.AddMessage(zhipu.ChatCompletionMessage{
-			Role:    zhipu.RoleSystem,
-			Content: req.Config.SystemPrompt,
+			Role:    role,
+			Content: msg.Content,
 		})
 	}
 
-	chatReq = chatReq.AddMessage(zhipu.ChatCompletionMessage{
-		Role:    zhipu.RoleUser,
-		Content: req.Prompt(),
-	})
-
 	if req.Config.Temperature > 0 {
 		chatReq = chatReq.SetTemperature(req.Config.Temperature)
 	}
@@ -0,0 +1,168 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/ginx/middlewares/ratelimit"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/ecodeclub/webook/internal/member"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrRateLimited = errors.New("AI 调用过于频繁")
+
+const (
+	// TierMember 会员
+	TierMember = "member"
+	// TierNormal 非会员
+	TierNormal = "normal"
+)
+
+// Rule 限流规则，限制的是单个用户在 Interval 内最多调用 Rate 次
+type Rule struct {
+	// 为空则对所有业务生效，否则每个业务单独计数
+	Biz string
+	// 为空则对所有用户生效，否则只对 TierMember 或者 TierNormal 生效
+	Tier     string
+	Interval time.Duration
+	Rate     int
+}
+
+type limiter interface {
+	Limit(ctx context.Context, key string) (bool, error)
+}
+
+type rule struct {
+	Rule
+	limiter limiter
+}
+
+// HandlerBuilder 基于 Redis 滑动窗口的限流，需要放在 credit 之前，
+// 被限流的请求不会预扣积分
+type HandlerBuilder struct {
+	rules     []rule
+	memberSvc member.Service
+	logger    *elog.Component
+	now       func() time.Time
+}
+
+func NewHandlerBuilder(cmd redis.Cmdable, memberSvc member.Service, rules []Rule) *HandlerBuilder {
+	rs := make([]rule, 0, len(rules))
+	for _, r := range rules {
+		rs = append(rs, rule{
+			Rule:    r,
+			limiter: ratelimit.NewRedisSlidingWindowLimiter(cmd, r.Interval, r.Rate),
+		})
+	}
+	return &HandlerBuilder{
+		rules:     rs,
+		memberSvc: memberSvc,
+		logger:    elog.DefaultLogger,
+		now:       time.Now,
+	}
+}
+
+func (b *HandlerBuilder) Name() string {
+	return "ratelimit"
+}
+
+func (b *HandlerBuilder) Next(next handler.Handler) handler.Handler {
+	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+		if err := b.limit(ctx, req); err != nil {
+			return domain.LLMResponse{}, err
+		}
+		return next.Handle(ctx, req)
+	})
+}
+
+func (b *HandlerBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	return handler.StreamHandleFunc(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+		if err := b.limit(ctx, req); err != nil {
+			return nil, err
+		}
+		return next.StreamHandle(ctx, req)
+	})
+}
+
+func (b *HandlerBuilder) limit(ctx context.Context, req domain.LLMRequest) error {
+	// 离线评估、对话摘要这些系统内部的请求不限流
+	if req.Internal {
+		return nil
+	}
+	tier := ""
+	for _, r := range b.rules {
+		if r.Biz != "" && r.Biz != req.Biz {
+			continue
+		}
+		if r.Tier != "" {
+			// 只有用到的时候才去查询会员信息
+			if tier == "" {
+				tier = b.tier(ctx, req.Uid)
+			}
+			if r.Tier != tier {
+				continue
+			}
+		}
+		limited, err := r.limiter.Limit(ctx, b.key(r.Rule, req.Uid))
+		if err != nil {
+			// Redis 出了问题就不限流了，后面还有积分兜底
+			b.logger.Error("AI 调用限流失败",
+				elog.String("biz", req.Biz),
+				elog.Int64("uid", req.Uid),
+				elog.FieldErr(err))
+			continue
+		}
+		if limited {
+			return fmt.Errorf("%w, uid %d, biz %s, %s 内最多 %d 次",
+				ErrRateLimited, req.Uid, req.Biz, r.Interval, r.Rate)
+		}
+	}
+	return nil
+}
+
+func (b *HandlerBuilder) tier(ctx context.Context, uid int64) string {
+	info, err := b.memberSvc.GetMembershipInfo(ctx, uid)
+	if err != nil {
+		// 查不到会员信息就按照非会员处理
+		b.logger.Warn("查询会员信息失败", elog.Int64("uid", uid), elog.FieldErr(err))
+		return TierNormal
+	}
+	if info.EndAt > b.now().UnixMilli() {
+		return TierMember
+	}
+	return TierNormal
+}
+
+func (b *HandlerBuilder) key(r Rule, uid int64) string {
+	biz := r.Biz
+	if biz == "" {
+		biz = "all"
+	}
+	tier := r.Tier
+	if tier == "" {
+		tier = "all"
+	}
+	return fmt.Sprintf("webook:ai:ratelimit:%s:%s:%d:%d", biz, tier, r.Interval.Milliseconds(), uid)
+}
+
+var _ handler.Builder = &HandlerBuilder{}
+var _ handler.StreamBuilder = &HandlerBuilder{}
@@ -0,0 +1,144 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/ecodeclub/webook/internal/member"
+	membermocks "github.com/ecodeclub/webook/internal/member/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// countLimiter 每个 key 最多允许 rate 次
+type countLimiter struct {
+	rate int
+	cnt  map[string]int
+}
+
+func (l *countLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	l.cnt[key]++
+	return l.cnt[key] > l.rate, nil
+}
+
+func TestHandlerBuilder_Next(t *testing.T) {
+	now := time.UnixMilli(10000)
+	testCases := []struct {
+		name    string
+		rules   []Rule
+		mock    func(ctrl *gomock.Controller) member.Service
+		req     domain.LLMRequest
+		calls   int
+		wantErr error
+	}{
+		{
+			name: "非会员超过业务的限制",
+			rules: []Rule{
+				{Biz: domain.BizQuestionExamine, Tier: TierNormal, Interval: time.Hour, Rate: 2},
+			},
+			mock: func(ctrl *gomock.Controller) member.Service {
+				svc := membermocks.NewMockService(ctrl)
+				svc.EXPECT().GetMembershipInfo(gomock.Any(), int64(1)).
+					Return(member.Member{Uid: 1, EndAt: 1000}, nil).Times(3)
+				return svc
+			},
+			req:     domain.LLMRequest{Biz: domain.BizQuestionExamine, Uid: 1},
+			calls:   3,
+			wantErr: ErrRateLimited,
+		},
+		{
+			name: "会员不受非会员规则的限制",
+			rules: []Rule{
+				{Biz: domain.BizQuestionExamine, Tier: TierNormal, Interval: time.Hour, Rate: 2},
+			},
+			mock: func(ctrl *gomock.Controller) member.Service {
+				svc := membermocks.NewMockService(ctrl)
+				svc.EXPECT().GetMembershipInfo(gomock.Any(), int64(1)).
+					Return(member.Member{Uid: 1, EndAt: 20000}, nil).Times(3)
+				return svc
+			},
+			req:   domain.LLMRequest{Biz: domain.BizQuestionExamine, Uid: 1},
+			calls: 3,
+		},
+		{
+			name: "别的业务不受限制，也不需要查询会员信息",
+			rules: []Rule{
+				{Biz: domain.BizQuestionExamine, Tier: TierNormal, Interval: time.Hour, Rate: 2},
+			},
+			mock: func(ctrl *gomock.Controller) member.Service {
+				return membermocks.NewMockService(ctrl)
+			},
+			req:   domain.LLMRequest{Biz: domain.BizCaseExamine, Uid: 1},
+			calls: 3,
+		},
+		{
+			name: "查询会员信息失败，按照非会员处理",
+			rules: []Rule{
+				{Tier: TierNormal, Interval: time.Hour, Rate: 1},
+			},
+			mock: func(ctrl *gomock.Controller) member.Service {
+				svc := membermocks.NewMockService(ctrl)
+				svc.EXPECT().GetMembershipInfo(gomock.Any(), int64(1)).
+					Return(member.Member{}, errors.New("mock db error")).Times(2)
+				return svc
+			},
+			req:     domain.LLMRequest{Biz: domain.BizCaseExamine, Uid: 1},
+			calls:   2,
+			wantErr: ErrRateLimited,
+		},
+		{
+			name: "对所有用户生效",
+			rules: []Rule{
+				{Interval: time.Minute, Rate: 2},
+			},
+			mock: func(ctrl *gomock.Controller) member.Service {
+				return membermocks.NewMockService(ctrl)
+			},
+			req:     domain.LLMRequest{Biz: domain.BizCaseExamine, Uid: 1},
+			calls:   3,
+			wantErr: ErrRateLimited,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			b := NewHandlerBuilder(nil, tc.mock(ctrl), nil)
+			b.now = func() time.Time {
+				return now
+			}
+			for _, r := range tc.rules {
+				b.rules = append(b.rules, rule{
+					Rule:    r,
+					limiter: &countLimiter{rate: r.Rate, cnt: map[string]int{}},
+				})
+			}
+			hdl := b.Next(handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+				return domain.LLMResponse{}, nil
+			}))
+			var err error
+			for i := 0; i < tc.calls; i++ {
+				_, err = hdl.Handle(context.Background(), tc.req)
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
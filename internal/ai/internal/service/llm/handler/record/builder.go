@@ -2,6 +2,9 @@ package record
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
 	"github.com/gotomicro/ego/core/elog"
@@ -35,6 +38,7 @@ func (h *HandlerBuilder) Next(next handler.Handler) handler.Handler {
 			Status:         domain.RecordStatusProcessing,
 			KnowledgeId:    req.Config.KnowledgeId,
 			PromptTemplate: req.Config.PromptTemplate,
+			ConfigVersion:  req.Config.Version,
 		}
 		defer func() {
 			_, err1 := h.repo.SaveLog(ctx, log)
@@ -45,12 +49,74 @@ func (h *HandlerBuilder) Next(next handler.Handler) handler.Handler {
 		resp, err := next.Handle(ctx, req)
 		if err != nil {
 			log.Status = domain.RecordStatusFailed
+			log.Moderations = h.moderations(err)
 			return domain.LLMResponse{}, err
 		}
 		log.Tokens = resp.Tokens
 		log.Amount = resp.Amount
 		log.Status = domain.RecordStatusSuccess
 		log.Answer = resp.Answer
+		log.Platform = resp.Platform
+		log.Moderations = resp.Moderations
 		return resp, err
 	})
 }
+
+func (h *HandlerBuilder) StreamNext(next handler.StreamHandler) handler.StreamHandler {
+	return handler.StreamHandleFunc(func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+		log := domain.LLMRecord{
+			Tid:            req.Tid,
+			Biz:            req.Biz,
+			Uid:            req.Uid,
+			Input:          req.Input,
+			Status:         domain.RecordStatusProcessing,
+			KnowledgeId:    req.Config.KnowledgeId,
+			PromptTemplate: req.Config.PromptTemplate,
+			ConfigVersion:  req.Config.Version,
+		}
+		ch, err := next.StreamHandle(ctx, req)
+		if err != nil {
+			log.Status = domain.RecordStatusFailed
+			log.Moderations = h.moderations(err)
+			_, err1 := h.repo.SaveLog(ctx, log)
+			if err1 != nil {
+				h.logger.Error("保存 LLM 访问记录失败", elog.FieldErr(err1))
+			}
+			return nil, err
+		}
+		var answer strings.Builder
+		return handler.Relay(ctx, ch, func(evt domain.StreamEvent) {
+			answer.WriteString(evt.Content)
+			if !evt.Done {
+				return
+			}
+			log.Tokens = evt.Tokens
+			log.Amount = evt.Amount
+			log.Answer = answer.String()
+			log.Status = domain.RecordStatusSuccess
+			log.Moderations = evt.Moderations
+			if evt.Error != nil {
+				log.Status = domain.RecordStatusFailed
+				if m := h.moderations(evt.Error); m != nil {
+					log.Moderations = m
+				}
+			}
+			// 调用方很可能已经走了，所以不能用原本的 ctx
+			newCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, err1 := h.repo.SaveLog(newCtx, log)
+			if err1 != nil {
+				h.logger.Error("保存 LLM 访问记录失败", elog.FieldErr(err1))
+			}
+		}), nil
+	})
+}
+
+// moderations 被内容审核拦截的时候，取出审核的结论
+func (h *HandlerBuilder) moderations(err error) []domain.Moderation {
+	var merr *domain.ModerationError
+	if errors.As(err, &merr) {
+		return merr.Moderations
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+)
+
+// Relay 把 src 中的事件转发到一个新的 channel 中，每个事件转发之后都会调用 onEvent。
+// 调用方放弃读取（ctx 被取消）之后依旧会把 src 消费完，
+// 这样 StreamBuilder 总是能够拿到最后一个事件，从而完成记录、扣费等收尾工作
+func Relay(ctx context.Context, src chan domain.StreamEvent,
+	onEvent func(evt domain.StreamEvent)) chan domain.StreamEvent {
+	dst := make(chan domain.StreamEvent, 10)
+	go func() {
+		defer close(dst)
+		abandoned := false
+		for evt := range src {
+			if !abandoned {
+				select {
+				case dst <- evt:
+				case <-ctx.Done():
+					abandoned = true
+				}
+			}
+			onEvent(evt)
+		}
+	}()
+	return dst
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelay(t *testing.T) {
+	t.Run("正常转发", func(t *testing.T) {
+		src := make(chan domain.StreamEvent, 3)
+		src <- domain.StreamEvent{Content: "a"}
+		src <- domain.StreamEvent{Content: "b"}
+		src <- domain.StreamEvent{Done: true, Tokens: 10}
+		close(src)
+		var seen []domain.StreamEvent
+		dst := Relay(context.Background(), src, func(evt domain.StreamEvent) {
+			seen = append(seen, evt)
+		})
+		var got []domain.StreamEvent
+		for evt := range dst {
+			got = append(got, evt)
+		}
+		assert.Equal(t, seen, got)
+		assert.Equal(t, int64(10), got[2].Tokens)
+	})
+
+	t.Run("调用方放弃之后依旧能拿到最后一个事件", func(t *testing.T) {
+		src := make(chan domain.StreamEvent)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan domain.StreamEvent, 1)
+		_ = Relay(ctx, src, func(evt domain.StreamEvent) {
+			if evt.Done {
+				done <- evt
+			}
+		})
+		cancel()
+		// 没有人读取 dst，超过缓冲区也不会阻塞
+		for i := 0; i < 20; i++ {
+			src <- domain.StreamEvent{Content: "a"}
+		}
+		src <- domain.StreamEvent{Done: true, Tokens: 20}
+		close(src)
+		select {
+		case evt := <-done:
+			assert.Equal(t, int64(20), evt.Tokens)
+		case <-time.After(time.Second):
+			t.Fatal("没有拿到最后一个事件")
+		}
+	})
+}
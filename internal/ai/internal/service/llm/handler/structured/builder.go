@@ -0,0 +1,122 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+var ErrMalformedOutput = errors.New("大模型的输出不符合 JSON Schema")
+
+const instruction = "请严格按照下面的 JSON Schema 输出一个 JSON，不要输出任何其它内容：\n%s"
+
+const repairPrompt = "下面的内容不符合 JSON Schema，原因是：%s。\n请修正之后只输出 JSON：\n%s"
+
+// HandlerBuilder 结构化输出。
+// 在 SystemPrompt 里面加上 JSON Schema，支持结构化输出的平台还会在请求里面声明。
+// 拿到回答之后提取 JSON 并校验，校验失败会让大模型修正一次，依旧失败就返回 ErrMalformedOutput。
+// 需要放在 credit 之后，这样修正的费用也会被计算进去
+type HandlerBuilder struct {
+	logger *elog.Component
+	// 最多修正几次
+	maxRepairs int
+}
+
+func NewHandlerBuilder() *HandlerBuilder {
+	return &HandlerBuilder{
+		logger:     elog.DefaultLogger,
+		maxRepairs: 1,
+	}
+}
+
+func (b *HandlerBuilder) Name() string {
+	return "structured"
+}
+
+func (b *HandlerBuilder) Next(next handler.Handler) handler.Handler {
+	return handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+		if req.Schema == nil {
+			return next.Handle(ctx, req)
+		}
+		var schema map[string]any
+		err := json.Unmarshal(req.Schema.Schema, &schema)
+		if err != nil {
+			return domain.LLMResponse{}, fmt.Errorf("JSON Schema 不合法 %w", err)
+		}
+		req.Config.SystemPrompt = b.systemPrompt(req)
+		resp, err := next.Handle(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		var tokens, amount int64
+		for i := 0; ; i++ {
+			tokens += resp.Tokens
+			amount += resp.Amount
+			resp.Tokens, resp.Amount = tokens, amount
+			var obj string
+			obj, err = Extract(resp.Answer)
+			if err == nil {
+				err = Validate(schema, []byte(obj))
+			}
+			if err == nil {
+				resp.Object = json.RawMessage(obj)
+				return resp, nil
+			}
+			if i >= b.maxRepairs {
+				break
+			}
+			b.logger.Warn("大模型的输出不符合 JSON Schema，尝试修正",
+				elog.String("biz", req.Biz),
+				elog.String("tid", req.Tid),
+				elog.FieldErr(err))
+			resp, err = next.Handle(ctx, b.repairReq(req, resp.Answer, err))
+			if err != nil {
+				return domain.LLMResponse{Tokens: tokens, Amount: amount}, err
+			}
+		}
+		return resp, fmt.Errorf("%w, biz %s, tid %s, %w", ErrMalformedOutput, req.Biz, req.Tid, err)
+	})
+}
+
+func (b *HandlerBuilder) systemPrompt(req domain.LLMRequest) string {
+	prompt := fmt.Sprintf(instruction, req.Schema.Schema)
+	if req.Config.SystemPrompt == "" {
+		return prompt
+	}
+	return req.Config.SystemPrompt + "\n" + prompt
+}
+
+// repairReq 把上一次的输出和错误原因交给大模型修正
+func (b *HandlerBuilder) repairReq(req domain.LLMRequest, answer string, err error) domain.LLMRequest {
+	cfg := req.Config
+	cfg.PromptTemplate = repairPrompt
+	return domain.LLMRequest{
+		Biz:    req.Biz,
+		Uid:    req.Uid,
+		Tid:    req.Tid,
+		Input:  []string{err.Error(), answer},
+		Config: cfg,
+		Schema: req.Schema,
+	}
+}
+
+var _ handler.Builder = &HandlerBuilder{}
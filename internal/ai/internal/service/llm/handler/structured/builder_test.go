@@ -0,0 +1,92 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structured
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerBuilder_Next(t *testing.T) {
+	schema := &domain.JSONSchema{
+		Name:   "score",
+		Schema: json.RawMessage(`{"type": "object", "required": ["score"]}`),
+	}
+	testCases := []struct {
+		name    string
+		req     domain.LLMRequest
+		answers []string
+		// 预期调用次数
+		calls      int
+		wantObject json.RawMessage
+		wantAmount int64
+		wantErr    error
+	}{
+		{
+			name:       "没有 Schema",
+			req:        domain.LLMRequest{Biz: "test"},
+			answers:    []string{"hello"},
+			calls:      1,
+			wantAmount: 1,
+		},
+		{
+			name:       "一次成功",
+			req:        domain.LLMRequest{Biz: "test", Schema: schema},
+			answers:    []string{"```json\n{\"score\": 1}\n```"},
+			calls:      1,
+			wantObject: json.RawMessage(`{"score": 1}`),
+			wantAmount: 1,
+		},
+		{
+			name:       "修正之后成功",
+			req:        domain.LLMRequest{Biz: "test", Schema: schema},
+			answers:    []string{`{"abc": 1}`, `{"score": 2}`},
+			calls:      2,
+			wantObject: json.RawMessage(`{"score": 2}`),
+			wantAmount: 2,
+		},
+		{
+			name:       "修正之后依旧失败",
+			req:        domain.LLMRequest{Biz: "test", Schema: schema},
+			answers:    []string{`{"abc": 1}`, `不会`},
+			calls:      2,
+			wantAmount: 2,
+			wantErr:    ErrMalformedOutput,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			hdl := NewHandlerBuilder().Next(handler.HandleFunc(func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
+				if tc.req.Schema != nil {
+					assert.Contains(t, req.Config.SystemPrompt, string(schema.Schema))
+				}
+				answer := tc.answers[calls]
+				calls++
+				return domain.LLMResponse{Answer: answer, Tokens: 10, Amount: 1}, nil
+			}))
+			resp, err := hdl.Handle(context.Background(), tc.req)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.calls, calls)
+			assert.Equal(t, tc.wantAmount, resp.Amount)
+			assert.Equal(t, tc.wantObject, resp.Object)
+		})
+	}
+}
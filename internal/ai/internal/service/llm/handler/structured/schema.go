@@ -0,0 +1,164 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structured
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Extract 从大模型的回答里面提取 JSON。
+// 大模型经常会在 JSON 前后加上 ```json 之类的内容，这里取第一个 { 或者 [ 到最后一个 } 或者 ] 之间的部分
+func Extract(answer string) (string, error) {
+	start := strings.IndexAny(answer, "{[")
+	if start < 0 {
+		return "", errors.New("回答中没有 JSON")
+	}
+	closing := "}"
+	if answer[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(answer, closing)
+	if end < start {
+		return "", errors.New("回答中的 JSON 不完整")
+	}
+	res := answer[start : end+1]
+	if !json.Valid([]byte(res)) {
+		return "", errors.New("回答中的 JSON 格式错误")
+	}
+	return res, nil
+}
+
+// Validate 校验 data 是否符合 schema。
+// 只支持 JSON Schema 的一个子集：type、properties、required、items、enum、minimum、maximum
+func Validate(schema map[string]any, data []byte) error {
+	var val any
+	err := json.Unmarshal(data, &val)
+	if err != nil {
+		return err
+	}
+	return validate(schema, val, "$")
+}
+
+func validate(schema map[string]any, val any, path string) error {
+	if err := validateType(schema["type"], val, path); err != nil {
+		return err
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s 的取值不在 enum 中", path)
+		}
+	}
+	switch v := val.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				key, _ := r.(string)
+				if _, ok := v[key]; !ok {
+					return fmt.Errorf("缺少必填字段 %s.%s", path, key)
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for key, prop := range props {
+			propSchema, ok := prop.(map[string]any)
+			field, exist := v[key]
+			if !ok || !exist {
+				continue
+			}
+			if err := validate(propSchema, field, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range v {
+			if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			return fmt.Errorf("%s 小于最小值 %v", path, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			return fmt.Errorf("%s 大于最大值 %v", path, maximum)
+		}
+	}
+	return nil
+}
+
+// validateType typ 可以是一个字符串，也可以是字符串数组
+func validateType(typ any, val any, path string) error {
+	switch t := typ.(type) {
+	case nil:
+		return nil
+	case string:
+		if !matchType(t, val) {
+			return fmt.Errorf("%s 的类型应该是 %s", path, t)
+		}
+		return nil
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && matchType(s, val) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s 的类型应该是 %v 之一", path, t)
+	default:
+		return fmt.Errorf("%s 的 type 定义不合法", path)
+	}
+}
+
+func matchType(typ string, val any) bool {
+	switch typ {
+	case "object":
+		_, ok := val.(map[string]any)
+		return ok
+	case "array":
+		_, ok := val.([]any)
+		return ok
+	case "string":
+		_, ok := val.(string)
+		return ok
+	case "number":
+		_, ok := val.(float64)
+		return ok
+	case "integer":
+		f, ok := val.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := val.(bool)
+		return ok
+	case "null":
+		return val == nil
+	default:
+		return false
+	}
+}
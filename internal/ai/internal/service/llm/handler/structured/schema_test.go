@@ -0,0 +1,131 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package structured
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "本身就是JSON",
+			input: `{"abc": "bcd"}`,
+			want:  `{"abc": "bcd"}`,
+		},
+		{
+			name:  "有前缀后缀",
+			input: "```json{\"abc\": \"bcd\"}```",
+			want:  `{"abc": "bcd"}`,
+		},
+		{
+			name:  "数组",
+			input: "结果如下：\n[1, 2, 3]\n以上",
+			want:  `[1, 2, 3]`,
+		},
+		{
+			name:    "没有JSON",
+			input:   "我不知道",
+			wantErr: true,
+		},
+		{
+			name:    "JSON不完整",
+			input:   `{"abc": "bcd"`,
+			wantErr: true,
+		},
+		{
+			name:    "JSON格式错误",
+			input:   `{"abc": bcd}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, err := Extract(tc.input)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, val)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var schema map[string]any
+	err := json.Unmarshal([]byte(`{
+  "type": "object",
+  "properties": {
+    "score": {"type": "number", "minimum": 0, "maximum": 10},
+    "level": {"type": "string", "enum": ["low", "high"]},
+    "summary": {"type": "array", "items": {"type": "string"}},
+    "count": {"type": ["integer", "null"]}
+  },
+  "required": ["score", "summary"]
+}`), &schema)
+	require.NoError(t, err)
+	testCases := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "符合",
+			data: `{"score": 8.5, "level": "high", "summary": ["a", "b"], "count": null}`,
+		},
+		{
+			name:    "类型不对",
+			data:    `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "缺少必填字段",
+			data:    `{"score": 8}`,
+			wantErr: true,
+		},
+		{
+			name:    "超过最大值",
+			data:    `{"score": 11, "summary": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "不在枚举中",
+			data:    `{"score": 1, "summary": [], "level": "mid"}`,
+			wantErr: true,
+		},
+		{
+			name:    "数组元素类型不对",
+			data:    `{"score": 1, "summary": ["a", 1]}`,
+			wantErr: true,
+		},
+		{
+			name:    "不是整数",
+			data:    `{"score": 1, "summary": [], "count": 1.5}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(schema, []byte(tc.data))
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
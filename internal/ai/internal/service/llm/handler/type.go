@@ -2,10 +2,15 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 )
 
+// ErrPlatformUnavailable 大模型平台暂时不可用，例如超时或者平台返回了 5xx。
+// 平台相关的 Handler 应该用它来包装这一类错误，上层可以据此切换到别的平台
+var ErrPlatformUnavailable = errors.New("大模型平台暂时不可用")
+
 type HandleFunc func(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error)
 
 func (f HandleFunc) Handle(ctx context.Context, req domain.LLMRequest) (domain.LLMResponse, error) {
@@ -25,3 +30,14 @@ type Builder interface {
 type StreamHandler interface {
 	StreamHandle(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error)
 }
+
+type StreamHandleFunc func(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error)
+
+func (f StreamHandleFunc) StreamHandle(ctx context.Context, req domain.LLMRequest) (chan domain.StreamEvent, error) {
+	return f(ctx, req)
+}
+
+// StreamBuilder 是 Builder 在流式调用中的对应物
+type StreamBuilder interface {
+	StreamNext(next StreamHandler) StreamHandler
+}
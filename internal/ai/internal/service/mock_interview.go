@@ -5,6 +5,8 @@ import (
 
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	"github.com/ecodeclub/webook/internal/pkg/pdf"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -14,14 +16,30 @@ type MockInterviewService interface {
 
 	SaveQuestion(ctx context.Context, q domain.MockInterviewQuestion) (int64, error)
 	ListQuestions(ctx context.Context, interviewID, uid int64, limit, offset int) ([]domain.MockInterviewQuestion, int64, error)
+
+	// Finish 结束面试并且生成报告，已经结束的面试直接返回之前的报告，
+	// 同一场面试同时只有一个请求能生成报告
+	Finish(ctx context.Context, uid int64, chatSN string) (domain.MockInterview, error)
+	// ExportReport 把面试报告导出为 PDF
+	ExportReport(ctx context.Context, uid int64, chatSN string) ([]byte, error)
 }
 
 type mockInterviewService struct {
-	repo repository.MockInterviewRepository
+	repo         repository.MockInterviewRepository
+	kbRepo       repository.KnowledgeBaseRepo
+	aiSvc        llm.Service
+	pdfConverter pdf.Converter
 }
 
-func NewMockInterviewService(repo repository.MockInterviewRepository) MockInterviewService {
-	return &mockInterviewService{repo: repo}
+func NewMockInterviewService(repo repository.MockInterviewRepository,
+	kbRepo repository.KnowledgeBaseRepo,
+	aiSvc llm.Service, pdfConverter pdf.Converter) MockInterviewService {
+	return &mockInterviewService{
+		repo:         repo,
+		kbRepo:       kbRepo,
+		aiSvc:        aiSvc,
+		pdfConverter: pdfConverter,
+	}
 }
 
 func (s *mockInterviewService) SaveInterview(ctx context.Context, mi domain.MockInterview) (int64, error) {
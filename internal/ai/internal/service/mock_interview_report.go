@@ -0,0 +1,301 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/lithammer/shortuuid/v4"
+)
+
+var (
+	//go:embed mock_interview_report.html
+	reportTemplate string
+
+	ErrMockInterviewNotFound    = errors.New("模拟面试不存在")
+	ErrMockInterviewNoQuestion  = errors.New("模拟面试还没有回答任何题目")
+	ErrMockInterviewNotFinished = errors.New("模拟面试还没有结束")
+	ErrMockInterviewReporting   = errors.New("模拟面试报告正在生成")
+)
+
+const (
+	// maxReportQuestions 一场面试最多统计多少道题
+	maxReportQuestions = 100
+	// reportingTimeout 超过这个时间报告还没有生成，允许重新生成
+	reportingTimeout = 5 * time.Minute
+)
+
+// reportSchema 大模型生成的报告格式，对应 reportResp
+var reportSchema = &domain.JSONSchema{
+	Name: "mock_interview_report",
+	Schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "questions": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "result": {"type": "integer", "enum": [0, 1, 2, 3]}
+        },
+        "required": ["id", "result"]
+      }
+    },
+    "strengths": {"type": "array", "items": {"type": "string"}},
+    "weaknesses": {"type": "array", "items": {"type": "string"}},
+    "recommendations": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "biz": {"type": "string", "enum": ["question", "case"]},
+          "bizId": {"type": "integer", "description": "只能是本场面试中的题目或者知识库中检索到的案例的 ID"},
+          "title": {"type": "string"},
+          "reason": {"type": "string"}
+        },
+        "required": ["biz", "bizId", "title", "reason"]
+      }
+    }
+  },
+  "required": ["questions", "strengths", "weaknesses", "recommendations"]
+}`),
+}
+
+type reportResp struct {
+	Questions []struct {
+		Id     int64 `json:"id"`
+		Result uint8 `json:"result"`
+	} `json:"questions"`
+	Strengths       []string             `json:"strengths"`
+	Weaknesses      []string             `json:"weaknesses"`
+	Recommendations []recommendationResp `json:"recommendations"`
+}
+
+type recommendationResp struct {
+	Biz    string `json:"biz"`
+	BizId  int64  `json:"bizId"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// recommendationKey 用来判断推荐的内容是否真实存在以及去重
+type recommendationKey struct {
+	biz   string
+	bizID int64
+}
+
+// reportQuestion 交给大模型评级的题目
+type reportQuestion struct {
+	Id         int64          `json:"id"`
+	Title      string         `json:"title"`
+	Answer     map[string]any `json:"answer"`
+	Evaluation map[string]any `json:"evaluation"`
+}
+
+func (s *mockInterviewService) Finish(ctx context.Context, uid int64, chatSN string) (domain.MockInterview, error) {
+	mi, err := s.findInterview(ctx, uid, chatSN)
+	if err != nil || mi.Finished() {
+		return mi, err
+	}
+	questions, err := s.repo.FindQuestions(ctx, mi.ID, uid, maxReportQuestions, 0)
+	if err != nil {
+		return domain.MockInterview{}, err
+	}
+	if len(questions) == 0 {
+		return domain.MockInterview{}, ErrMockInterviewNoQuestion
+	}
+	// 重复点击或者并发请求只有一个能调用大模型
+	err = s.repo.StartReport(ctx, mi.ID, time.Now().Add(-reportingTimeout).UnixMilli())
+	if errors.Is(err, repository.ErrReportConflict) {
+		return s.reportedInterview(ctx, uid, chatSN)
+	}
+	if err != nil {
+		return domain.MockInterview{}, err
+	}
+	report, err := s.generateReport(ctx, uid, questions)
+	if err != nil {
+		// 释放失败也没关系，超过 reportingTimeout 之后就可以重新生成
+		_ = s.repo.CancelReport(ctx, mi.ID)
+		return domain.MockInterview{}, err
+	}
+	err = s.repo.SaveReport(ctx, mi.ID, report)
+	if errors.Is(err, repository.ErrReportConflict) {
+		// 占用超时之后别的请求已经生成了报告，以保存下来的为准
+		return s.reportedInterview(ctx, uid, chatSN)
+	}
+	if err != nil {
+		return domain.MockInterview{}, err
+	}
+	mi.Report = &report
+	return mi, nil
+}
+
+// reportedInterview 别的请求已经生成或者正在生成报告，生成好了就直接返回
+func (s *mockInterviewService) reportedInterview(ctx context.Context, uid int64, chatSN string) (domain.MockInterview, error) {
+	mi, err := s.findInterview(ctx, uid, chatSN)
+	if err != nil || mi.Finished() {
+		return mi, err
+	}
+	return domain.MockInterview{}, fmt.Errorf("%w, sn %s", ErrMockInterviewReporting, chatSN)
+}
+
+// generateReport 由大模型给每道题评级，并且总结优缺点、推荐题目和案例。
+// 得分和整体等级是根据每道题的评级计算出来的，不依赖大模型
+func (s *mockInterviewService) generateReport(ctx context.Context, uid int64,
+	questions []domain.MockInterviewQuestion) (domain.MockInterviewReport, error) {
+	input := make([]reportQuestion, 0, len(questions))
+	for _, q := range questions {
+		input = append(input, reportQuestion{
+			Id:         q.ID,
+			Title:      q.Title,
+			Answer:     q.Answer,
+			Evaluation: q.Evaluation,
+		})
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return domain.MockInterviewReport{}, err
+	}
+	resp, err := s.aiSvc.Invoke(ctx, domain.LLMRequest{
+		Uid:    uid,
+		Tid:    shortuuid.New(),
+		Biz:    domain.BizMockInterviewReport,
+		Input:  []string{string(data)},
+		Schema: reportSchema,
+	})
+	if err != nil {
+		return domain.MockInterviewReport{}, err
+	}
+	var rr reportResp
+	err = resp.Decode(&rr)
+	if err != nil {
+		return domain.MockInterviewReport{}, err
+	}
+
+	results := make(map[int64]domain.InterviewResult, len(rr.Questions))
+	for _, q := range rr.Questions {
+		results[q.Id] = domain.InterviewResult(q.Result)
+	}
+	report := domain.MockInterviewReport{
+		Strengths:  rr.Strengths,
+		Weaknesses: rr.Weaknesses,
+		Ctime:      time.Now().UnixMilli(),
+	}
+	for _, q := range questions {
+		// 大模型漏掉的题目按照没有回答出来处理
+		res := results[q.ID]
+		report.Questions = append(report.Questions, domain.QuestionResult{
+			QuestionID: q.ID,
+			Biz:        q.Biz,
+			BizID:      q.BizID,
+			Title:      q.Title,
+			Result:     res,
+		})
+		// 题库里面没有达到 25K 的题目，推荐重新练习
+		if q.Biz == domain.BizQuestion && res < domain.InterviewResultIntermediate {
+			report.Recommendations = append(report.Recommendations, domain.Recommendation{
+				Biz:    domain.BizQuestion,
+				BizID:  q.BizID,
+				Title:  q.Title,
+				Reason: fmt.Sprintf("这道题只达到了 %s 的水平，建议重新练习", res.Salary()),
+			})
+		}
+	}
+	recs, err := s.validRecommendations(ctx, questions, report.Recommendations, rr.Recommendations)
+	if err != nil {
+		return domain.MockInterviewReport{}, err
+	}
+	report.Recommendations = append(report.Recommendations, recs...)
+	report.Aggregate()
+	return report, nil
+}
+
+// validRecommendations 大模型可能编造不存在的 bizId，只保留本场面试中出现过的题目，
+// 以及已经上传到知识库的案例。已经推荐过的不再重复推荐
+func (s *mockInterviewService) validRecommendations(ctx context.Context,
+	questions []domain.MockInterviewQuestion,
+	recommended []domain.Recommendation,
+	candidates []recommendationResp) ([]domain.Recommendation, error) {
+	existing := make(map[recommendationKey]struct{}, len(questions))
+	for _, q := range questions {
+		existing[recommendationKey{biz: q.Biz, bizID: q.BizID}] = struct{}{}
+	}
+	var caseIDs []int64
+	for _, c := range candidates {
+		if c.Biz == domain.BizCase {
+			caseIDs = append(caseIDs, c.BizId)
+		}
+	}
+	if len(caseIDs) > 0 {
+		found, err := s.kbRepo.FindBizIDs(ctx, domain.BizCase, caseIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			existing[recommendationKey{biz: domain.BizCase, bizID: id}] = struct{}{}
+		}
+	}
+
+	seen := make(map[recommendationKey]struct{}, len(recommended))
+	for _, r := range recommended {
+		seen[recommendationKey{biz: r.Biz, bizID: r.BizID}] = struct{}{}
+	}
+	var res []domain.Recommendation
+	for _, c := range candidates {
+		key := recommendationKey{biz: c.Biz, bizID: c.BizId}
+		if _, ok := existing[key]; !ok {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, domain.Recommendation{
+			Biz:    c.Biz,
+			BizID:  c.BizId,
+			Title:  c.Title,
+			Reason: c.Reason,
+		})
+	}
+	return res, nil
+}
+
+func (s *mockInterviewService) ExportReport(ctx context.Context, uid int64, chatSN string) ([]byte, error) {
+	mi, err := s.findInterview(ctx, uid, chatSN)
+	if err != nil {
+		return nil, err
+	}
+	if !mi.Finished() {
+		return nil, ErrMockInterviewNotFinished
+	}
+	t, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, map[string]any{
+		"Title":    mi.Title,
+		"Report":   mi.Report,
+		"Finished": time.UnixMilli(mi.Report.Ctime).Format("2006年01月02日 15:04"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.pdfConverter.ConvertHTMLToPDF(ctx, buf.String())
+}
+
+func (s *mockInterviewService) findInterview(ctx context.Context, uid int64, chatSN string) (domain.MockInterview, error) {
+	mi, err := s.repo.FindInterviewBySN(ctx, uid, chatSN)
+	if errors.Is(err, repository.ErrMockInterviewNotFound) {
+		return domain.MockInterview{}, fmt.Errorf("%w, uid %d, sn %s", ErrMockInterviewNotFound, uid, chatSN)
+	}
+	return mi, err
+}
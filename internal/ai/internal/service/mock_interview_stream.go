@@ -0,0 +1,209 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/lithammer/shortuuid/v4"
+)
+
+var ErrStreamNotFound = repository.ErrStreamNotFound
+
+// MockInterviewStreamService 模拟面试的流式对话。
+// 上游的事件由后台的 goroutine 写入 Redis，读取方从 Redis 里面读取，
+// 所以断线之后可以从任意一个事件开始重放
+type MockInterviewStreamService interface {
+	// Start 在后台发起一轮对话，返回流的 id
+	Start(ctx context.Context, input domain.MockInterviewInput) (string, error)
+	// Subscribe 读取序号大于 after 的事件，读到最后一个事件或者 ctx 被取消之后关闭 channel
+	Subscribe(ctx context.Context, uid int64, streamID string, after int64) (chan domain.StreamFrame, error)
+}
+
+type mockInterviewStreamService struct {
+	client chatv1.ServiceClient
+	repo   repository.MockInterviewStreamRepository
+	logger *elog.Component
+	// 一轮对话最长多久
+	timeout time.Duration
+	// 多久检查一次是否还有人在读
+	checkInterval time.Duration
+	// 没有新事件的时候，多久查询一次
+	pollInterval time.Duration
+}
+
+func NewMockInterviewStreamService(client chatv1.ServiceClient,
+	repo repository.MockInterviewStreamRepository) MockInterviewStreamService {
+	return &mockInterviewStreamService{
+		client:        client,
+		repo:          repo,
+		logger:        elog.DefaultLogger,
+		timeout:       time.Minute * 10,
+		checkInterval: time.Second * 5,
+		pollInterval:  time.Millisecond * 100,
+	}
+}
+
+func (s *mockInterviewStreamService) Start(ctx context.Context, input domain.MockInterviewInput) (string, error) {
+	streamID := shortuuid.New()
+	err := s.repo.Init(ctx, streamID, input.Uid)
+	if err != nil {
+		return "", err
+	}
+	// 上游的调用不能跟着请求走，否则断线之后就没有办法继续了
+	go s.relay(streamID, input)
+	return streamID, nil
+}
+
+// relay 把上游的事件写入 Redis。没有人读的时间超过一定时间，就认为被放弃了，取消上游的调用
+func (s *mockInterviewStreamService) relay(streamID string, input domain.MockInterviewInput) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	go s.watch(ctx, cancel, streamID)
+
+	logger := s.logger.With(elog.String("streamID", streamID), elog.String("chatSN", input.ChatSN))
+	stream, err := s.client.StreamV1(ctx, &chatv1.StreamV1Request{
+		ChatSn: input.ChatSN,
+		Input: &chatv1.UserInput{
+			Content:  input.Content,
+			AudioUrl: input.AudioURL,
+		},
+		InvocationConfigId: input.ConfigID,
+		Uid:                input.Uid,
+		Key:                "", // 未使用，传空字符串
+	})
+	if err != nil {
+		logger.Error("调用 StreamV1 失败", elog.FieldErr(err))
+		s.append(ctx, logger, streamID, s.errFrame(err))
+		return
+	}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.append(ctx, logger, streamID, domain.StreamFrame{Event: domain.StreamEventDone, Data: "{}"})
+			return
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Warn("模拟面试的流被放弃或者超时，取消上游调用", elog.FieldErr(err))
+			} else {
+				logger.Error("Stream 错误", elog.FieldErr(err))
+			}
+			s.append(ctx, logger, streamID, s.errFrame(err))
+			return
+		}
+		data, _ := json.Marshal(resp)
+		s.append(ctx, logger, streamID, domain.StreamFrame{Data: string(data)})
+	}
+}
+
+// watch 定期检查是否还有人在读
+func (s *mockInterviewStreamService) watch(ctx context.Context, cancel context.CancelFunc, streamID string) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			alive, err := s.repo.Alive(ctx, streamID)
+			if err != nil {
+				// Redis 出问题的时候不要轻易放弃
+				s.logger.Error("检查模拟面试的流是否被放弃失败",
+					elog.String("streamID", streamID), elog.FieldErr(err))
+				continue
+			}
+			if !alive {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *mockInterviewStreamService) append(ctx context.Context, logger *elog.Component,
+	streamID string, frame domain.StreamFrame) {
+	// 上游被取消之后依旧要把最后一个事件写进去
+	_, err := s.repo.Append(context.WithoutCancel(ctx), streamID, frame)
+	if err != nil {
+		logger.Error("缓存模拟面试的事件失败", elog.FieldErr(err))
+	}
+}
+
+func (s *mockInterviewStreamService) errFrame(err error) domain.StreamFrame {
+	data, _ := json.Marshal(map[string]string{"message": err.Error()})
+	return domain.StreamFrame{Event: domain.StreamEventError, Data: string(data)}
+}
+
+func (s *mockInterviewStreamService) Subscribe(ctx context.Context, uid int64,
+	streamID string, after int64) (chan domain.StreamFrame, error) {
+	owner, err := s.repo.Owner(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if owner != uid {
+		return nil, fmt.Errorf("%w, uid %d, streamID %s", ErrStreamNotFound, uid, streamID)
+	}
+	ch := make(chan domain.StreamFrame, 10)
+	go func() {
+		defer close(ch)
+		// 上游异常退出的时候不会有最后一个事件，所以读取方也要有超时
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+		var lastTouch time.Time
+		for {
+			if time.Since(lastTouch) >= s.checkInterval {
+				lastTouch = time.Now()
+				err1 := s.repo.Touch(ctx, streamID)
+				if err1 != nil {
+					s.logger.Error("模拟面试的流续约失败", elog.String("streamID", streamID), elog.FieldErr(err1))
+				}
+			}
+			frames, err1 := s.repo.Range(ctx, streamID, after)
+			if err1 != nil {
+				s.logger.Error("读取模拟面试的事件失败", elog.String("streamID", streamID), elog.FieldErr(err1))
+			}
+			for _, frame := range frames {
+				select {
+				case ch <- frame:
+				case <-ctx.Done():
+					return
+				}
+				after = frame.Seq
+				if frame.Terminal() {
+					return
+				}
+			}
+			if len(frames) > 0 {
+				continue
+			}
+			select {
+			case <-time.After(s.pollInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
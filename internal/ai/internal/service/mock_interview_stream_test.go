@@ -0,0 +1,195 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestMockInterviewStreamService(t *testing.T) {
+	repo := newMemStreamRepo()
+	client := &fakeChatClient{deltas: []string{"你好", "，请介绍一下自己"}}
+	svc := newTestStreamService(client, repo)
+
+	streamID, err := svc.Start(context.Background(), domain.MockInterviewInput{ChatSN: "sn", Uid: 123})
+	require.NoError(t, err)
+
+	// 从头开始读，最后一个事件是 done
+	frames := collect(t, svc, 123, streamID, 0)
+	require.Len(t, frames, 3)
+	assert.Equal(t, []int64{1, 2, 3}, []int64{frames[0].Seq, frames[1].Seq, frames[2].Seq})
+	assert.Contains(t, frames[1].Data, "请介绍一下自己")
+	assert.Equal(t, domain.StreamEventDone, frames[2].Event)
+
+	// 断线重连，从 Last-Event-ID 的下一个开始重放
+	frames = collect(t, svc, 123, streamID, 1)
+	require.Len(t, frames, 2)
+	assert.Equal(t, int64(2), frames[0].Seq)
+
+	// 别人的流
+	_, err = svc.Subscribe(context.Background(), 456, streamID, 0)
+	assert.ErrorIs(t, err, ErrStreamNotFound)
+}
+
+func TestMockInterviewStreamService_Abandoned(t *testing.T) {
+	repo := newMemStreamRepo()
+	// 上游一直不结束，直到被取消
+	client := &fakeChatClient{block: true}
+	svc := newTestStreamService(client, repo)
+
+	streamID, err := svc.Start(context.Background(), domain.MockInterviewInput{ChatSN: "sn", Uid: 123})
+	require.NoError(t, err)
+	repo.setAlive(streamID, false)
+	// 没有人读，上游会被取消，并且写入一个 error 事件
+	frames := collect(t, svc, 123, streamID, 0)
+	require.Len(t, frames, 1)
+	assert.Equal(t, domain.StreamEventError, frames[0].Event)
+}
+
+func newTestStreamService(client chatv1.ServiceClient, repo repository.MockInterviewStreamRepository) *mockInterviewStreamService {
+	return &mockInterviewStreamService{
+		client:        client,
+		repo:          repo,
+		logger:        elog.DefaultLogger,
+		timeout:       time.Second * 5,
+		checkInterval: time.Millisecond * 10,
+		pollInterval:  time.Millisecond * 10,
+	}
+}
+
+func collect(t *testing.T, svc *mockInterviewStreamService, uid int64, streamID string, after int64) []domain.StreamFrame {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	ch, err := svc.Subscribe(ctx, uid, streamID, after)
+	require.NoError(t, err)
+	var res []domain.StreamFrame
+	for frame := range ch {
+		res = append(res, frame)
+	}
+	return res
+}
+
+type fakeChatClient struct {
+	chatv1.ServiceClient
+	deltas []string
+	block  bool
+}
+
+func (c *fakeChatClient) StreamV1(ctx context.Context, in *chatv1.StreamV1Request,
+	opts ...grpc.CallOption) (grpc.ServerStreamingClient[chatv1.StreamV1Response], error) {
+	return &fakeStream{ctx: ctx, deltas: c.deltas, block: c.block}, nil
+}
+
+type fakeStream struct {
+	grpc.ServerStreamingClient[chatv1.StreamV1Response]
+	ctx    context.Context
+	deltas []string
+	block  bool
+}
+
+func (s *fakeStream) Recv() (*chatv1.StreamV1Response, error) {
+	if s.block {
+		<-s.ctx.Done()
+		return nil, s.ctx.Err()
+	}
+	if len(s.deltas) == 0 {
+		return nil, io.EOF
+	}
+	delta := s.deltas[0]
+	s.deltas = s.deltas[1:]
+	return &chatv1.StreamV1Response{
+		Event: &chatv1.StreamV1Response_Delta{Delta: &chatv1.Delta{Content: delta}},
+	}, nil
+}
+
+// memStreamRepo 内存实现，Touch 不会改变 alive
+type memStreamRepo struct {
+	mu     sync.Mutex
+	owners map[string]int64
+	frames map[string][]domain.StreamFrame
+	alive  map[string]bool
+}
+
+func newMemStreamRepo() *memStreamRepo {
+	return &memStreamRepo{
+		owners: map[string]int64{},
+		frames: map[string][]domain.StreamFrame{},
+		alive:  map[string]bool{},
+	}
+}
+
+func (r *memStreamRepo) setAlive(streamID string, alive bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alive[streamID] = alive
+}
+
+func (r *memStreamRepo) Init(ctx context.Context, streamID string, uid int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.owners[streamID] = uid
+	r.alive[streamID] = true
+	return nil
+}
+
+func (r *memStreamRepo) Owner(ctx context.Context, streamID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	uid, ok := r.owners[streamID]
+	if !ok {
+		return 0, ErrStreamNotFound
+	}
+	return uid, nil
+}
+
+func (r *memStreamRepo) Append(ctx context.Context, streamID string, frame domain.StreamFrame) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	frame.Seq = int64(len(r.frames[streamID])) + 1
+	r.frames[streamID] = append(r.frames[streamID], frame)
+	return frame.Seq, nil
+}
+
+func (r *memStreamRepo) Range(ctx context.Context, streamID string, after int64) ([]domain.StreamFrame, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	frames := r.frames[streamID]
+	if after >= int64(len(frames)) {
+		return nil, nil
+	}
+	return append([]domain.StreamFrame{}, frames[after:]...), nil
+}
+
+func (r *memStreamRepo) Touch(ctx context.Context, streamID string) error {
+	return nil
+}
+
+func (r *memStreamRepo) Alive(ctx context.Context, streamID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.alive[streamID], nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/platform/router"
+)
+
+// PlatformService 大模型平台相关的管理功能
+type PlatformService interface {
+	// Health 各个平台的健康状态
+	Health(ctx context.Context) []domain.PlatformHealth
+}
+
+type platformService struct {
+	router *router.Handler
+}
+
+func NewPlatformService(router *router.Handler) PlatformService {
+	return &platformService{
+		router: router,
+	}
+}
+
+func (s *platformService) Health(ctx context.Context) []domain.PlatformHealth {
+	return s.router.Health()
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+)
+
+// PrivacyService 导出以及删除用户的大模型调用记录、多轮对话和模拟面试
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 扣费记录是财务数据，保留下来对账，其余的都删掉
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	logRepo           repository.LLMLogRepo
+	conversationRepo  repository.ConversationRepository
+	mockInterviewRepo repository.MockInterviewRepository
+	batchSize         int
+}
+
+func NewPrivacyService(logRepo repository.LLMLogRepo,
+	conversationRepo repository.ConversationRepository,
+	mockInterviewRepo repository.MockInterviewRepository) PrivacyService {
+	return &privacyService{
+		logRepo:           logRepo,
+		conversationRepo:  conversationRepo,
+		mockInterviewRepo: mockInterviewRepo,
+		batchSize:         100,
+	}
+}
+
+func (s *privacyService) Name() string {
+	return "ai"
+}
+
+type conversationData struct {
+	Conversation domain.Conversation          `json:"conversation"`
+	Messages     []domain.ConversationMessage `json:"messages"`
+}
+
+type mockInterviewData struct {
+	Interview domain.MockInterview           `json:"interview"`
+	Questions []domain.MockInterviewQuestion `json:"questions"`
+}
+
+type aiData struct {
+	Records        []domain.LLMRecord  `json:"records"`
+	Conversations  []conversationData  `json:"conversations"`
+	MockInterviews []mockInterviewData `json:"mockInterviews"`
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	var res aiData
+	for offset := 0; ; offset += s.batchSize {
+		records, err := s.logRepo.FindLogs(ctx, uid, offset, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		res.Records = append(res.Records, records...)
+		if len(records) < s.batchSize {
+			break
+		}
+	}
+	for offset := 0; ; offset += s.batchSize {
+		conversations, err := s.conversationRepo.List(ctx, uid, offset, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range conversations {
+			msgs, err := s.conversationRepo.FindMessages(ctx, c.Sn, 0)
+			if err != nil {
+				return nil, err
+			}
+			res.Conversations = append(res.Conversations, conversationData{Conversation: c, Messages: msgs})
+		}
+		if len(conversations) < s.batchSize {
+			break
+		}
+	}
+	for offset := 0; ; offset += s.batchSize {
+		interviews, err := s.mockInterviewRepo.FindInterviews(ctx, uid, s.batchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, mi := range interviews {
+			questions, err := s.findQuestions(ctx, mi.ID, uid)
+			if err != nil {
+				return nil, err
+			}
+			res.MockInterviews = append(res.MockInterviews, mockInterviewData{Interview: mi, Questions: questions})
+		}
+		if len(interviews) < s.batchSize {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (s *privacyService) findQuestions(ctx context.Context, interviewID, uid int64) ([]domain.MockInterviewQuestion, error) {
+	var res []domain.MockInterviewQuestion
+	for offset := 0; ; offset += s.batchSize {
+		questions, err := s.mockInterviewRepo.FindQuestions(ctx, interviewID, uid, s.batchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, questions...)
+		if len(questions) < s.batchSize {
+			return res, nil
+		}
+	}
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	err := s.logRepo.DeleteLogs(ctx, uid)
+	if err != nil {
+		return err
+	}
+	err = s.conversationRepo.DeleteByUid(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return s.mockInterviewRepo.DeleteByUid(ctx, uid)
+}
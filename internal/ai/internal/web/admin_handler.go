@@ -1,20 +1,33 @@
 package web
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/errs"
 	"github.com/ecodeclub/webook/internal/ai/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type AdminHandler struct {
-	svc service.ConfigService
+	svc         service.ConfigService
+	platformSvc service.PlatformService
+	cacheSvc    service.CacheService
+	evalSvc     service.EvalService
 }
 
-func NewAdminHandler(svc service.ConfigService) *AdminHandler {
+func NewAdminHandler(svc service.ConfigService,
+	platformSvc service.PlatformService,
+	cacheSvc service.CacheService,
+	evalSvc service.EvalService) *AdminHandler {
 	return &AdminHandler{
-		svc: svc,
+		svc:         svc,
+		platformSvc: platformSvc,
+		cacheSvc:    cacheSvc,
+		evalSvc:     evalSvc,
 	}
 }
 
@@ -24,6 +37,24 @@ func (h *AdminHandler) RegisterRoutes(server *gin.Engine) {
 	admin.POST("/save", ginx.B[ConfigRequest](h.Save))
 	admin.GET("/list", ginx.W(h.List))
 	admin.POST("/detail", ginx.B[ConfigInfoReq](h.GetById))
+	admin.POST("/versions", ginx.B[ConfigInfoReq](h.Versions))
+	admin.POST("/rollback", ginx.B[ConfigVersionReq](h.Rollback))
+	admin.POST("/experiment", ginx.B[ExperimentReq](h.SetExperiment))
+
+	platform := server.Group("/ai/platform")
+	platform.GET("/health", ginx.W(h.PlatformHealth))
+
+	cache := server.Group("/ai/cache")
+	cache.GET("/status", ginx.W(h.CacheStatus))
+	cache.POST("/switch", ginx.B[CacheSwitchReq](h.CacheSwitch))
+
+	eval := server.Group("/ai/eval")
+	eval.POST("/case/save", ginx.B[EvalCaseSaveReq](h.SaveEvalCase))
+	eval.POST("/case/delete", ginx.B[EvalCaseDeleteReq](h.DeleteEvalCase))
+	eval.POST("/case/list", ginx.B[EvalListReq](h.ListEvalCases))
+	eval.POST("/run", ginx.B[EvalRunReq](h.RunEval))
+	eval.POST("/run/list", ginx.B[EvalListReq](h.ListEvalRuns))
+	eval.POST("/run/detail", ginx.B[EvalRunDetailReq](h.EvalRunDetail))
 }
 
 func (h *AdminHandler) Save(ctx *ginx.Context, req ConfigRequest) (ginx.Result, error) {
@@ -38,6 +69,7 @@ func (h *AdminHandler) Save(ctx *ginx.Context, req ConfigRequest) (ginx.Result,
 		SystemPrompt:   req.Config.SystemPrompt,
 		PromptTemplate: req.Config.PromptTemplate,
 		KnowledgeId:    req.Config.KnowledgeId,
+		CacheTTL:       time.Duration(req.Config.CacheTTL) * time.Second,
 	})
 	if err != nil {
 		return systemErrorResult, err
@@ -73,18 +105,198 @@ func (h *AdminHandler) GetById(ctx *ginx.Context, req ConfigInfoReq) (ginx.Resul
 	}, nil
 }
 
+// Versions 配置的历史版本
+func (h *AdminHandler) Versions(ctx *ginx.Context, req ConfigInfoReq) (ginx.Result, error) {
+	versions, err := h.svc.Versions(ctx, req.Id)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(versions, func(idx int, c domain.BizConfig) Config {
+			return h.domainToConfig(c)
+		}),
+	}, nil
+}
+
+// Rollback 回滚到某个历史版本
+func (h *AdminHandler) Rollback(ctx *ginx.Context, req ConfigVersionReq) (ginx.Result, error) {
+	err := h.svc.Rollback(ctx, req.Id, req.Version)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+// SetExperiment 设置 A/B 实验
+func (h *AdminHandler) SetExperiment(ctx *ginx.Context, req ExperimentReq) (ginx.Result, error) {
+	err := h.svc.SetExperiment(ctx, req.Id, domain.Experiment{
+		Version: req.Version,
+		Ratio:   req.Ratio,
+	})
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+func (h *AdminHandler) PlatformHealth(ctx *ginx.Context) (ginx.Result, error) {
+	res := h.platformSvc.Health(ctx)
+	return ginx.Result{
+		Data: slice.Map(res, func(idx int, src domain.PlatformHealth) PlatformHealth {
+			return PlatformHealth{
+				Name:                src.Name,
+				Weight:              src.Weight,
+				Healthy:             src.Healthy,
+				ConsecutiveFailures: src.ConsecutiveFailures,
+				SuccessCnt:          src.SuccessCnt,
+				FailureCnt:          src.FailureCnt,
+				LastErr:             src.LastErr,
+				LastErrTime:         src.LastErrTime,
+				UnhealthyUntil:      src.UnhealthyUntil,
+			}
+		}),
+	}, nil
+}
+
+// CacheStatus 大模型回答缓存是否打开
+func (h *AdminHandler) CacheStatus(ctx *ginx.Context) (ginx.Result, error) {
+	enabled, err := h.cacheSvc.Enabled(ctx)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: enabled,
+	}, nil
+}
+
+// CacheSwitch 打开或者关闭大模型回答缓存
+func (h *AdminHandler) CacheSwitch(ctx *ginx.Context, req CacheSwitchReq) (ginx.Result, error) {
+	err := h.cacheSvc.SetEnabled(ctx, req.Enabled)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+// SaveEvalCase 批量保存离线评估的用例，id 为 0 的是新增
+func (h *AdminHandler) SaveEvalCase(ctx *ginx.Context, req EvalCaseSaveReq) (ginx.Result, error) {
+	ids := make([]int64, 0, len(req.Cases))
+	for _, c := range req.Cases {
+		id, err := h.evalSvc.SaveCase(ctx, domain.EvalCase{
+			Id:            c.Id,
+			Biz:           req.Biz,
+			Input:         c.Input,
+			ExpectedLevel: c.ExpectedLevel,
+			Keywords:      c.Keywords,
+		})
+		if err != nil {
+			return systemErrorResult, err
+		}
+		ids = append(ids, id)
+	}
+	return ginx.Result{
+		Data: ids,
+	}, nil
+}
+
+func (h *AdminHandler) DeleteEvalCase(ctx *ginx.Context, req EvalCaseDeleteReq) (ginx.Result, error) {
+	err := h.evalSvc.DeleteCase(ctx, req.Id)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+func (h *AdminHandler) ListEvalCases(ctx *ginx.Context, req EvalListReq) (ginx.Result, error) {
+	cases, total, err := h.evalSvc.ListCases(ctx, req.Biz, req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: EvalCaseList{
+			Total: total,
+			Cases: slice.Map(cases, func(idx int, src domain.EvalCase) EvalCase {
+				return newEvalCase(src)
+			}),
+		},
+	}, nil
+}
+
+// RunEval 在后台运行离线评估，返回运行的 id
+func (h *AdminHandler) RunEval(ctx *ginx.Context, req EvalRunReq) (ginx.Result, error) {
+	id, err := h.evalSvc.Run(ctx, domain.EvalRun{
+		Biz:           req.Biz,
+		ConfigVersion: req.ConfigVersion,
+		Model:         req.Model,
+		Levels:        req.Levels,
+	})
+	switch {
+	case errors.Is(err, service.ErrNoEvalCase):
+		return ginx.Result{
+			Code: errs.EvalCaseNotFound.Code,
+			Msg:  errs.EvalCaseNotFound.Msg,
+		}, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: id,
+	}, nil
+}
+
+func (h *AdminHandler) ListEvalRuns(ctx *ginx.Context, req EvalListReq) (ginx.Result, error) {
+	runs, total, err := h.evalSvc.ListRuns(ctx, req.Biz, req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: EvalRunList{
+			Total: total,
+			Runs: slice.Map(runs, func(idx int, src domain.EvalRun) EvalRun {
+				return newEvalRun(src)
+			}),
+		},
+	}, nil
+}
+
+// EvalRunDetail 运行的报告以及每一条用例的结果
+func (h *AdminHandler) EvalRunDetail(ctx *ginx.Context, req EvalRunDetailReq) (ginx.Result, error) {
+	run, results, err := h.evalSvc.Detail(ctx, req.Id)
+	switch {
+	case errors.Is(err, service.ErrEvalRunNotFound):
+		return ginx.Result{
+			Code: errs.EvalRunNotFound.Code,
+			Msg:  errs.EvalRunNotFound.Msg,
+		}, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: EvalRunDetail{
+			Run: newEvalRun(run),
+			Results: slice.Map(results, func(idx int, src domain.EvalResult) EvalResult {
+				return newEvalResult(src)
+			}),
+		},
+	}, nil
+}
+
 func (h *AdminHandler) domainToConfig(cfg domain.BizConfig) Config {
 	return Config{
-		Id:             cfg.Id,
-		Biz:            cfg.Biz,
-		MaxInput:       cfg.MaxInput,
-		Model:          cfg.Model,
-		Price:          cfg.Price,
-		Temperature:    cfg.Temperature,
-		TopP:           cfg.TopP,
-		SystemPrompt:   cfg.SystemPrompt,
-		PromptTemplate: cfg.PromptTemplate,
-		KnowledgeId:    cfg.KnowledgeId,
-		Utime:          cfg.Utime,
+		Id:                cfg.Id,
+		Biz:               cfg.Biz,
+		MaxInput:          cfg.MaxInput,
+		Model:             cfg.Model,
+		Price:             cfg.Price,
+		Temperature:       cfg.Temperature,
+		TopP:              cfg.TopP,
+		SystemPrompt:      cfg.SystemPrompt,
+		PromptTemplate:    cfg.PromptTemplate,
+		KnowledgeId:       cfg.KnowledgeId,
+		CacheTTL:          int64(cfg.CacheTTL / time.Second),
+		Version:           cfg.Version,
+		ExperimentVersion: cfg.Experiment.Version,
+		ExperimentRatio:   cfg.Experiment.Ratio,
+		Utime:             cfg.Utime,
 	}
 }
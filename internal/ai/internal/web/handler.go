@@ -5,28 +5,33 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
-	"time"
 
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
 	"github.com/ecodeclub/webook/internal/ai/internal/errs"
 	"github.com/ecodeclub/webook/internal/ai/internal/service"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/ratelimit"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
-	"golang.org/x/net/context"
 )
 
 type Handler struct {
 	generalSvc service.GeneralService
 	jdSvc      service.JDService
+	convSvc    service.ConversationService
 }
 
-func NewHandler(generalSvc service.GeneralService, jdSvc service.JDService) *Handler {
+func NewHandler(generalSvc service.GeneralService, jdSvc service.JDService,
+	convSvc service.ConversationService) *Handler {
 	return &Handler{
 		generalSvc: generalSvc,
 		jdSvc:      jdSvc,
+		convSvc:    convSvc,
 	}
 }
 
@@ -34,18 +39,35 @@ func (h *Handler) MemberRoutes(server *gin.Engine) {
 	server.POST("/ai/ask", ginx.BS(h.LLMAsk))
 	server.POST("/ai/analysis_jd", ginx.BS(h.AnalysisJd))
 	server.POST("/ai/stream", h.Stream)
+	server.POST("/ai/conversation/list", ginx.BS(h.ConversationList))
+	server.POST("/ai/conversation/detail", ginx.BS(h.ConversationDetail))
 
 }
 
 func (h *Handler) LLMAsk(ctx *ginx.Context, req LLMRequest, sess session.Session) (ginx.Result, error) {
 	uid := sess.Claims().Uid
-	resp, err := h.generalSvc.LLMAsk(ctx, uid, req.Biz, req.Input)
+	resp, err := h.generalSvc.LLMAsk(ctx, uid, req.Biz, req.Sn, req.Input)
 	switch {
 	case errors.Is(err, credit.ErrInsufficientCredit):
 		return ginx.Result{
 			Code: errs.InsufficientCredit.Code,
 			Msg:  errs.InsufficientCredit.Msg,
 		}, nil
+	case errors.Is(err, ratelimit.ErrRateLimited):
+		return ginx.Result{
+			Code: errs.RateLimited.Code,
+			Msg:  errs.RateLimited.Msg,
+		}, nil
+	case errors.Is(err, moderation.ErrBlocked):
+		return ginx.Result{
+			Code: errs.ContentBlocked.Code,
+			Msg:  errs.ContentBlocked.Msg,
+		}, nil
+	case errors.Is(err, service.ErrConversationNotFound):
+		return ginx.Result{
+			Code: errs.ConversationNotFound.Code,
+			Msg:  errs.ConversationNotFound.Msg,
+		}, nil
 	case err == nil:
 		return ginx.Result{
 			Data: LLMResponse{
@@ -67,6 +89,21 @@ func (h *Handler) AnalysisJd(ctx *ginx.Context, req JDRequest, sess session.Sess
 			Code: errs.InsufficientCredit.Code,
 			Msg:  errs.InsufficientCredit.Msg,
 		}, nil
+	case errors.Is(err, ratelimit.ErrRateLimited):
+		return ginx.Result{
+			Code: errs.RateLimited.Code,
+			Msg:  errs.RateLimited.Msg,
+		}, nil
+	case errors.Is(err, moderation.ErrBlocked):
+		return ginx.Result{
+			Code: errs.ContentBlocked.Code,
+			Msg:  errs.ContentBlocked.Msg,
+		}, nil
+	case errors.Is(err, structured.ErrMalformedOutput):
+		return ginx.Result{
+			Code: errs.MalformedOutput.Code,
+			Msg:  errs.MalformedOutput.Msg,
+		}, nil
 	case err == nil:
 		return ginx.Result{
 			Data: JDResponse{
@@ -83,6 +120,50 @@ func (h *Handler) AnalysisJd(ctx *ginx.Context, req JDRequest, sess session.Sess
 
 }
 
+func (h *Handler) ConversationList(ctx *ginx.Context, req Page, sess session.Session) (ginx.Result, error) {
+	list, err := h.convSvc.List(ctx, sess.Claims().Uid, req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(list, func(_ int, src domain.Conversation) Conversation {
+			return h.newConversation(src)
+		}),
+	}, nil
+}
+
+func (h *Handler) ConversationDetail(ctx *ginx.Context, req ConversationReq, sess session.Session) (ginx.Result, error) {
+	conv, msgs, err := h.convSvc.Detail(ctx, sess.Claims().Uid, req.Sn)
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound):
+		return ginx.Result{
+			Code: errs.ConversationNotFound.Code,
+			Msg:  errs.ConversationNotFound.Msg,
+		}, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	res := h.newConversation(conv)
+	res.Messages = slice.Map(msgs, func(_ int, src domain.ConversationMessage) Message {
+		return Message{
+			Role:    src.Role,
+			Content: src.Content,
+			Ctime:   src.Ctime,
+		}
+	})
+	return ginx.Result{Data: res}, nil
+}
+
+func (h *Handler) newConversation(conv domain.Conversation) Conversation {
+	return Conversation{
+		Sn:    conv.Sn,
+		Biz:   conv.Biz,
+		Title: conv.Title,
+		Ctime: conv.Ctime,
+		Utime: conv.Utime,
+	}
+}
+
 func (h *Handler) Stream(ctx *gin.Context) {
 	// 设置 SSE 响应头
 	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
@@ -103,9 +184,8 @@ func (h *Handler) Stream(ctx *gin.Context) {
 		slog.Debug("绑定参数失败", slog.Any("err", err))
 		return
 	}
-	timeCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	ch, err := h.generalSvc.Stream(timeCtx, uid, req.Biz, req.Input)
-	cancel()
+	// 连接断开之后 ctx 会被取消，下游据此中断大模型的调用，并且按照已经输出的内容扣费
+	ch, err := h.generalSvc.Stream(ctx.Request.Context(), uid, req.Biz, req.Sn, req.Input)
 	if err != nil {
 		h.chatErr(ctx, err)
 		return
@@ -113,10 +193,25 @@ func (h *Handler) Stream(ctx *gin.Context) {
 	h.stream(ctx, ch)
 }
 
+// chatErr 按照非流式接口的规则把错误转换为错误码，原始的错误里面有用户 ID 等信息，只记录到日志里
 func (h *Handler) chatErr(ctx *gin.Context, err error) {
+	code := errs.SystemError
+	switch {
+	case errors.Is(err, credit.ErrInsufficientCredit):
+		code = errs.InsufficientCredit
+	case errors.Is(err, ratelimit.ErrRateLimited):
+		code = errs.RateLimited
+	case errors.Is(err, moderation.ErrBlocked):
+		code = errs.ContentBlocked
+	case errors.Is(err, service.ErrConversationNotFound):
+		code = errs.ConversationNotFound
+	default:
+		slog.Error("流式调用大模型失败", slog.Any("err", err))
+	}
 	evt := Event{
 		Type: ErrEvt,
-		Err:  err.Error(),
+		Code: code.Code,
+		Err:  code.Msg,
 	}
 	evtStr, _ := json.Marshal(evt)
 	sendEvent(ctx, string(evtStr))
@@ -147,7 +242,7 @@ func (h *Handler) stream(ctx *gin.Context, ch chan domain.StreamEvent) {
 	for {
 		select {
 		case event, ok := <-ch:
-			if !ok || event.Done {
+			if !ok {
 				// 通道关闭，发送结束事件
 				h.chatEnd(ctx)
 				return
@@ -157,6 +252,10 @@ func (h *Handler) stream(ctx *gin.Context, ch chan domain.StreamEvent) {
 				h.chatErr(ctx, event.Error)
 				return
 			}
+			if event.Done {
+				h.chatEnd(ctx)
+				return
+			}
 			h.chatMsg(ctx, event)
 		case <-ctx.Request.Context().Done():
 			return
@@ -15,17 +15,21 @@
 package web
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/ginx/session"
 	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
+	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/errs"
 	"github.com/ecodeclub/webook/internal/ai/internal/service"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
 	"github.com/gin-gonic/gin"
 	"github.com/gotomicro/ego/core/econf"
 	"github.com/gotomicro/ego/core/elog"
@@ -35,16 +39,19 @@ import (
 var _ ginx.Handler = &MockInterviewHandler{}
 
 type MockInterviewHandler struct {
-	client chatv1.ServiceClient
-	svc    service.MockInterviewService
-	logger *elog.Component
+	client    chatv1.ServiceClient
+	svc       service.MockInterviewService
+	streamSvc service.MockInterviewStreamService
+	logger    *elog.Component
 }
 
-func NewMockInterviewHandler(client chatv1.ServiceClient, svc service.MockInterviewService) *MockInterviewHandler {
+func NewMockInterviewHandler(client chatv1.ServiceClient, svc service.MockInterviewService,
+	streamSvc service.MockInterviewStreamService) *MockInterviewHandler {
 	return &MockInterviewHandler{
-		client: client,
-		svc:    svc,
-		logger: elog.DefaultLogger.With(elog.FieldComponent("MockInterviewHandler")),
+		client:    client,
+		svc:       svc,
+		streamSvc: streamSvc,
+		logger:    elog.DefaultLogger.With(elog.FieldComponent("MockInterviewHandler")),
 	}
 }
 
@@ -56,6 +63,8 @@ func (h *MockInterviewHandler) PublicRoutes(server *gin.Engine) {
 func (h *MockInterviewHandler) PrivateRoutes(server *gin.Engine) {
 	server.POST("/ai/mock_interview/create", ginx.BS(h.CreateMockInterview))
 	server.POST("/ai/mock_interview/stream", h.Stream)
+	server.POST("/ai/mock_interview/finish", ginx.BS(h.Finish))
+	server.POST("/ai/mock_interview/report/pdf", h.ExportReport)
 	// 注意：这个API是为了方便本地测试，正式前端页面不应该请求这个API，应该用正确的方法获取cos临时凭证
 	server.GET("/ai/mock_interview/cos/temp-credentials", h.GetCOSTempCredentials)
 }
@@ -75,8 +84,17 @@ func (h *MockInterviewHandler) CreateMockInterview(ctx *ginx.Context, req Create
 	return ginx.Result{Msg: "ok", Data: resp.Sn}, nil
 }
 
-func (h *MockInterviewHandler) Stream(ctx *gin.Context) {
-	// 1. 获取 session
+// Finish 结束面试并且生成报告
+func (h *MockInterviewHandler) Finish(ctx *ginx.Context, req MockInterviewReq, sess session.Session) (ginx.Result, error) {
+	mi, err := h.svc.Finish(ctx.Request.Context(), sess.Claims().Uid, req.InterviewID)
+	if err != nil {
+		return h.reportErr(err)
+	}
+	return ginx.Result{Data: h.newReport(*mi.Report)}, nil
+}
+
+// ExportReport 导出 PDF 格式的面试报告
+func (h *MockInterviewHandler) ExportReport(ctx *gin.Context) {
 	gtx := &ginx.Context{Context: ctx}
 	sess, err := session.Get(gtx)
 	if err != nil {
@@ -84,34 +102,133 @@ func (h *MockInterviewHandler) Stream(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
-	uid := sess.Claims().Uid
-
-	// 2. 解析请求参数
-	var req StreamMockInterviewReq
+	var req MockInterviewReq
 	if err := ctx.Bind(&req); err != nil {
 		h.logger.Error("绑定参数失败", elog.FieldErr(err))
-		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	data, err := h.svc.ExportReport(ctx.Request.Context(), sess.Claims().Uid, req.InterviewID)
+	if err != nil {
+		res, err := h.reportErr(err)
+		if err != nil {
+			h.logger.Error("导出模拟面试报告失败", elog.FieldErr(err))
+		}
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="report.pdf"`)
+	ctx.Data(http.StatusOK, "application/pdf", data)
+}
 
-	// 3. 构建 gRPC 请求
-	stream, err := h.client.StreamV1(gtx.Request.Context(), &chatv1.StreamV1Request{
-		ChatSn: req.InterviewID,
-		Input: &chatv1.UserInput{
+func (h *MockInterviewHandler) reportErr(err error) (ginx.Result, error) {
+	switch {
+	case errors.Is(err, service.ErrMockInterviewNotFound):
+		return ginx.Result{
+			Code: errs.MockInterviewNotFound.Code,
+			Msg:  errs.MockInterviewNotFound.Msg,
+		}, nil
+	case errors.Is(err, service.ErrMockInterviewNoQuestion):
+		return ginx.Result{
+			Code: errs.MockInterviewNoQuestion.Code,
+			Msg:  errs.MockInterviewNoQuestion.Msg,
+		}, nil
+	case errors.Is(err, service.ErrMockInterviewNotFinished):
+		return ginx.Result{
+			Code: errs.MockInterviewNotFinished.Code,
+			Msg:  errs.MockInterviewNotFinished.Msg,
+		}, nil
+	case errors.Is(err, service.ErrMockInterviewReporting):
+		return ginx.Result{
+			Code: errs.MockInterviewReporting.Code,
+			Msg:  errs.MockInterviewReporting.Msg,
+		}, nil
+	case errors.Is(err, credit.ErrInsufficientCredit):
+		return ginx.Result{
+			Code: errs.InsufficientCredit.Code,
+			Msg:  errs.InsufficientCredit.Msg,
+		}, nil
+	default:
+		return systemErrorResult, err
+	}
+}
+
+func (h *MockInterviewHandler) newReport(report domain.MockInterviewReport) MockInterviewReport {
+	return MockInterviewReport{
+		Result:     report.Result.ToUint8(),
+		Salary:     report.Result.Salary(),
+		Score:      report.Score,
+		Strengths:  report.Strengths,
+		Weaknesses: report.Weaknesses,
+		Questions: slice.Map(report.Questions, func(_ int, src domain.QuestionResult) QuestionResult {
+			return QuestionResult{
+				QuestionID: src.QuestionID,
+				Biz:        src.Biz,
+				BizID:      src.BizID,
+				Title:      src.Title,
+				Result:     src.Result.ToUint8(),
+			}
+		}),
+		Recommendations: slice.Map(report.Recommendations, func(_ int, src domain.Recommendation) Recommendation {
+			return Recommendation{
+				Biz:    src.Biz,
+				BizID:  src.BizID,
+				Title:  src.Title,
+				Reason: src.Reason,
+			}
+		}),
+		Ctime: report.Ctime,
+	}
+}
+
+// Stream 每个事件都会带上 id，格式是 streamID:seq。
+// 断线重连的时候带上 Last-Event-ID，就会从下一个事件开始重放，之后继续接收新的事件
+func (h *MockInterviewHandler) Stream(ctx *gin.Context) {
+	// 1. 获取 session
+	gtx := &ginx.Context{Context: ctx}
+	sess, err := session.Get(gtx)
+	if err != nil {
+		h.logger.Error("获取 Session 失败", elog.FieldErr(err))
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	uid := sess.Claims().Uid
+
+	// 2. 断线重连直接从 Last-Event-ID 开始读，否则发起一轮新的对话
+	streamID, after, ok := h.parseEventID(ctx.GetHeader("Last-Event-ID"))
+	if !ok {
+		var req StreamMockInterviewReq
+		if err := ctx.Bind(&req); err != nil {
+			h.logger.Error("绑定参数失败", elog.FieldErr(err))
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		streamID, err = h.streamSvc.Start(ctx.Request.Context(), domain.MockInterviewInput{
+			ChatSN:   req.InterviewID,
+			Uid:      uid,
 			Content:  req.Content,
-			AudioUrl: req.AudioURL,
-		},
-		InvocationConfigId: req.ConfigID,
-		Uid:                uid,
-		Key:                "", // 未使用，传空字符串
-	})
+			AudioURL: req.AudioURL,
+			ConfigID: req.ConfigID,
+		})
+		if err != nil {
+			h.logger.Error("发起模拟面试对话失败", elog.FieldErr(err))
+			ctx.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+	}
+	// 连接断开之后 ctx 会被取消，不再续约，上游一段时间之后会被取消
+	frames, err := h.streamSvc.Subscribe(ctx.Request.Context(), uid, streamID, after)
+	if errors.Is(err, service.ErrStreamNotFound) {
+		h.logger.Warn("模拟面试的流不存在", elog.String("streamID", streamID), elog.FieldErr(err))
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		h.logger.Error("调用 StreamV1 失败", elog.FieldErr(err))
+		h.logger.Error("读取模拟面试的流失败", elog.FieldErr(err))
 		ctx.AbortWithStatus(http.StatusInternalServerError)
 		return
 	}
 
-	// 4. 设置 SSE 响应头
+	// 3. 设置 SSE 响应头
 	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
 	ctx.Writer.Header().Set("Cache-Control", "no-cache")
 	ctx.Writer.Header().Set("Connection", "keep-alive")
@@ -123,52 +240,34 @@ func (h *MockInterviewHandler) Stream(ctx *gin.Context) {
 		return
 	}
 
-	// 5. 转发流式响应
-	deltaCount := 0
-	for {
-
-		resp, err := stream.Recv()
-
-		if errors.Is(err, io.EOF) {
-			_, err := fmt.Fprintf(ctx.Writer, "event: done\ndata: {}\n\n")
-			if err != nil {
-				h.logger.Error("发送done事件失败")
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-				return
-			}
-			flusher.Flush()
-			h.logger.Debug("Stream 完成", elog.Int("DeltaCount", deltaCount))
-			break
-		}
-
-		if err != nil {
-			h.logger.Error("Stream 错误", elog.FieldErr(err))
-			_, err = fmt.Fprintf(ctx.Writer, "event: error\ndata: {\"message\": \"%s\"}\n\n", err.Error())
-			if err != nil {
-				h.logger.Error("发送error事件失败")
-				ctx.AbortWithStatus(http.StatusInternalServerError)
-				return
-			}
-			flusher.Flush()
-			break
+	// 4. 转发事件
+	for frame := range frames {
+		var buf strings.Builder
+		buf.WriteString(fmt.Sprintf("id: %s:%d\n", streamID, frame.Seq))
+		if frame.Event != "" {
+			buf.WriteString(fmt.Sprintf("event: %s\n", frame.Event))
 		}
-
-		// 转换为 JSON 并发送
-		data, _ := json.Marshal(resp)
-		_, err = fmt.Fprintf(ctx.Writer, "data: %s\n\n", data)
+		buf.WriteString(fmt.Sprintf("data: %s\n\n", frame.Data))
+		_, err = io.WriteString(ctx.Writer, buf.String())
 		if err != nil {
-			h.logger.Error("发送data事件失败")
-			ctx.AbortWithStatus(http.StatusInternalServerError)
-			break
+			h.logger.Error("发送事件失败", elog.String("streamID", streamID), elog.FieldErr(err))
+			return
 		}
 		flusher.Flush()
+	}
+}
 
-		// 日志（Delta 事件）
-		if d := resp.GetDelta(); d != nil {
-			deltaCount++
-			h.logger.Debug("Delta 事件", elog.Int("Count", deltaCount), elog.String("Content", d.Content))
-		}
+// parseEventID 解析 streamID:seq 格式的事件 id
+func (h *MockInterviewHandler) parseEventID(id string) (string, int64, bool) {
+	idx := strings.LastIndex(id, ":")
+	if idx <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseInt(id[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
 	}
+	return id[:idx], seq, true
 }
 
 // GetCOSTempCredentials 获取腾讯云 COS 临时密钥
@@ -34,3 +34,34 @@ type COSTempCredentialsResp struct {
 	Bucket       string `json:"bucket"`
 	Region       string `json:"region"`
 }
+
+type MockInterviewReq struct {
+	InterviewID string `json:"interviewId"`
+}
+
+type MockInterviewReport struct {
+	// 0 未达到 15K，1 15K，2 25K，3 35K
+	Result          uint8            `json:"result"`
+	Salary          string           `json:"salary"`
+	Score           float64          `json:"score"`
+	Strengths       []string         `json:"strengths"`
+	Weaknesses      []string         `json:"weaknesses"`
+	Questions       []QuestionResult `json:"questions"`
+	Recommendations []Recommendation `json:"recommendations"`
+	Ctime           int64            `json:"ctime"`
+}
+
+type QuestionResult struct {
+	QuestionID int64  `json:"questionId"`
+	Biz        string `json:"biz"`
+	BizID      int64  `json:"bizId"`
+	Title      string `json:"title"`
+	Result     uint8  `json:"result"`
+}
+
+type Recommendation struct {
+	Biz    string `json:"biz"`
+	BizID  int64  `json:"bizId"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
@@ -1,8 +1,36 @@
 package web
 
+import "github.com/ecodeclub/webook/internal/ai/internal/domain"
+
 type LLMRequest struct {
 	Biz   string   `json:"biz"`
 	Input []string `json:"input"`
+	// 会话 SN，由前端生成。为空则是单次问答，不会记住上下文
+	Sn string `json:"sn"`
+}
+
+type Page struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type ConversationReq struct {
+	Sn string `json:"sn"`
+}
+
+type Conversation struct {
+	Sn       string    `json:"sn"`
+	Biz      string    `json:"biz"`
+	Title    string    `json:"title"`
+	Messages []Message `json:"messages,omitempty"`
+	Ctime    int64     `json:"ctime"`
+	Utime    int64     `json:"utime"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Ctime   int64  `json:"ctime"`
 }
 
 type LLMResponse struct {
@@ -38,17 +66,205 @@ type Config struct {
 	SystemPrompt   string  `json:"systemPrompt"`
 	PromptTemplate string  `json:"promptTemplate"`
 	KnowledgeId    string  `json:"knowledgeId"`
-	Utime          int64   `json:"utime"`
+	// 回答缓存时间，单位秒，0 表示不缓存
+	CacheTTL int64 `json:"cacheTTL"`
+	// 以下字段只读，保存的时候会被忽略
+	Version           int64 `json:"version"`
+	ExperimentVersion int64 `json:"experimentVersion"`
+	ExperimentRatio   int   `json:"experimentRatio"`
+	Utime             int64 `json:"utime"`
+}
+
+type PlatformHealth struct {
+	Name                string `json:"name"`
+	Weight              int    `json:"weight"`
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	SuccessCnt          int64  `json:"successCnt"`
+	FailureCnt          int64  `json:"failureCnt"`
+	LastErr             string `json:"lastErr"`
+	LastErrTime         int64  `json:"lastErrTime"`
+	UnhealthyUntil      int64  `json:"unhealthyUntil"`
 }
+
 type ConfigRequest struct {
 	Config Config `json:"config"`
 }
+type ConfigVersionReq struct {
+	Id      int64 `json:"id"`
+	Version int64 `json:"version"`
+}
+
+type ExperimentReq struct {
+	Id int64 `json:"id"`
+	// 实验组使用的版本
+	Version int64 `json:"version"`
+	// 实验组的流量百分比，0 表示关闭实验
+	Ratio int `json:"ratio"`
+}
+
+type CacheSwitchReq struct {
+	Enabled bool `json:"enabled"`
+}
+
 type ConfigInfoReq struct {
 	Id int64 `json:"id"`
 }
 
+type EvalCaseSaveReq struct {
+	Biz   string     `json:"biz"`
+	Cases []EvalCase `json:"cases"`
+}
+
+type EvalCase struct {
+	Id    int64    `json:"id"`
+	Input []string `json:"input"`
+	// 期望的评级，为空则不参与评级一致率的统计
+	ExpectedLevel string   `json:"expectedLevel"`
+	Keywords      []string `json:"keywords"`
+	Utime         int64    `json:"utime"`
+}
+
+func newEvalCase(c domain.EvalCase) EvalCase {
+	return EvalCase{
+		Id:            c.Id,
+		Input:         c.Input,
+		ExpectedLevel: c.ExpectedLevel,
+		Keywords:      c.Keywords,
+		Utime:         c.Utime,
+	}
+}
+
+type EvalCaseDeleteReq struct {
+	Id int64 `json:"id"`
+}
+
+type EvalListReq struct {
+	Biz    string `json:"biz"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+type EvalCaseList struct {
+	Total int64      `json:"total"`
+	Cases []EvalCase `json:"cases"`
+}
+
+type EvalRunReq struct {
+	Biz string `json:"biz"`
+	// 为 0 则使用当前版本的配置
+	ConfigVersion int64 `json:"configVersion"`
+	// 为空则使用配置中的模型
+	Model string `json:"model"`
+	// 可能出现的评级，为空则使用用例中出现过的期望评级
+	Levels []string `json:"levels"`
+}
+
+type EvalRunDetailReq struct {
+	Id int64 `json:"id"`
+}
+
+type EvalRun struct {
+	Id            int64      `json:"id"`
+	Biz           string     `json:"biz"`
+	ConfigVersion int64      `json:"configVersion"`
+	Model         string     `json:"model"`
+	Levels        []string   `json:"levels"`
+	Status        uint8      `json:"status"`
+	Report        EvalReport `json:"report"`
+	Ctime         int64      `json:"ctime"`
+	Utime         int64      `json:"utime"`
+}
+
+func newEvalRun(run domain.EvalRun) EvalRun {
+	r := run.Report
+	return EvalRun{
+		Id:            run.Id,
+		Biz:           run.Biz,
+		ConfigVersion: run.ConfigVersion,
+		Model:         run.Model,
+		Levels:        run.Levels,
+		Status:        run.Status.ToUint8(),
+		Report: EvalReport{
+			Total:          r.Total,
+			Failed:         r.Failed,
+			Leveled:        r.Leveled,
+			LevelMatched:   r.LevelMatched,
+			LevelAgreement: r.LevelAgreement,
+			Keywords:       r.Keywords,
+			HitKeywords:    r.HitKeywords,
+			KeywordRecall:  r.KeywordRecall,
+			Tokens:         r.Tokens,
+			Amount:         r.Amount,
+			AvgLatency:     r.AvgLatency.Milliseconds(),
+			P90Latency:     r.P90Latency.Milliseconds(),
+			MaxLatency:     r.MaxLatency.Milliseconds(),
+		},
+		Ctime: run.Ctime,
+		Utime: run.Utime,
+	}
+}
+
+type EvalReport struct {
+	Total          int     `json:"total"`
+	Failed         int     `json:"failed"`
+	Leveled        int     `json:"leveled"`
+	LevelMatched   int     `json:"levelMatched"`
+	LevelAgreement float64 `json:"levelAgreement"`
+	Keywords       int     `json:"keywords"`
+	HitKeywords    int     `json:"hitKeywords"`
+	KeywordRecall  float64 `json:"keywordRecall"`
+	Tokens         int64   `json:"tokens"`
+	Amount         int64   `json:"amount"`
+	// 毫秒
+	AvgLatency int64 `json:"avgLatency"`
+	P90Latency int64 `json:"p90Latency"`
+	MaxLatency int64 `json:"maxLatency"`
+}
+
+type EvalResult struct {
+	CaseId         int64    `json:"caseId"`
+	Answer         string   `json:"answer"`
+	ExpectedLevel  string   `json:"expectedLevel"`
+	Level          string   `json:"level"`
+	HitKeywords    []string `json:"hitKeywords"`
+	MissedKeywords []string `json:"missedKeywords"`
+	Tokens         int64    `json:"tokens"`
+	Amount         int64    `json:"amount"`
+	// 毫秒
+	Latency int64  `json:"latency"`
+	Err     string `json:"err"`
+}
+
+func newEvalResult(r domain.EvalResult) EvalResult {
+	return EvalResult{
+		CaseId:         r.CaseId,
+		Answer:         r.Answer,
+		ExpectedLevel:  r.ExpectedLevel,
+		Level:          r.Level,
+		HitKeywords:    r.HitKeywords,
+		MissedKeywords: r.MissedKeywords,
+		Tokens:         r.Tokens,
+		Amount:         r.Amount,
+		Latency:        r.Latency.Milliseconds(),
+		Err:            r.Err,
+	}
+}
+
+type EvalRunList struct {
+	Total int64     `json:"total"`
+	Runs  []EvalRun `json:"runs"`
+}
+
+type EvalRunDetail struct {
+	Run     EvalRun      `json:"run"`
+	Results []EvalResult `json:"results"`
+}
+
 type Event struct {
 	Type string `json:"type"` // 事件类型 msg end err
+	// 出错的时候和非流式接口一样的错误码，Err 是给用户看的错误信息
+	Code int    `json:"code,omitempty"`
 	Err  string `json:"error"`
 	Data EvtMsg `json:"data"`
 }
@@ -14,4 +14,5 @@ type Module struct {
 	AdminHandler     *AdminHandler
 	MockInterviewHdl *MockInterviewHandler
 	C                *event.KnowledgeBaseConsumer
+	PrivacySvc       PrivacyService
 }
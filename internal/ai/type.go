@@ -2,6 +2,7 @@ package ai
 
 import (
 	"github.com/ecodeclub/webook/internal/ai/internal/domain"
+	"github.com/ecodeclub/webook/internal/ai/internal/service"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/knowledge_base"
 	"github.com/ecodeclub/webook/internal/ai/internal/web"
@@ -9,9 +10,11 @@ import (
 
 type LLMRequest = domain.LLMRequest
 type LLMResponse = domain.LLMResponse
+type JSONSchema = domain.JSONSchema
 type LLMService = llm.Service
 type KnowledgeBaseService = knowledge_base.RepositoryBaseSvc
 type AdminHandler = web.AdminHandler
+type PrivacyService = service.PrivacyService
 type LLMHandler = web.Handler
 type KnowledgeBaseFile = domain.KnowledgeBaseFile
 
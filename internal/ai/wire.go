@@ -6,6 +6,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/mq-api"
 	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
 	"github.com/ecodeclub/webook/internal/ai/internal/event"
@@ -15,20 +16,36 @@ import (
 	"github.com/ecodeclub/webook/internal/ai/internal/web"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	aicache "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/config"
 	aicredit "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/log"
+	aimoderation "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/moderation"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/record"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
 
 	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/credit"
+	"github.com/ecodeclub/webook/internal/member"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+	"github.com/ecodeclub/webook/internal/pkg/pdf"
 	"github.com/ego-component/egorm"
 	"github.com/google/wire"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
-func InitModule(db *egorm.Component, creditSvc *credit.Module, q mq.MQ, grpcClient chatv1.ServiceClient) (*Module, error) {
+func InitModule(db *egorm.Component,
+	ec ecache.Cache,
+	cmd redis.Cmdable,
+	creditSvc *credit.Module,
+	memberModule *member.Module,
+	q mq.MQ,
+	grpcClient chatv1.ServiceClient,
+	filter moderation.Filter) (*Module, error) {
 	wire.Build(
 		InitAliDeepSeekHandler,
 		llm.NewLLMService,
@@ -36,11 +53,19 @@ func InitModule(db *egorm.Component, creditSvc *credit.Module, q mq.MQ, grpcClie
 		repository.NewLLMCreditLogRepo,
 		repository.NewCachedConfigRepository,
 		repository.NewMockInterviewRepository,
+		repository.NewLLMCacheRepo,
+		repository.NewConversationRepository,
+		repository.NewMockInterviewStreamRepository,
+		repository.NewEvalRepository,
 
 		InitLLMCreditLogDAO,
 		dao.NewGORMLLMLogDAO,
 		dao.NewGORMConfigDAO,
 		dao.NewMockInterviewDAO,
+		dao.NewGORMConversationDAO,
+		dao.NewGORMEvalDAO,
+		cache.NewLLMCache,
+		cache.NewMockInterviewStreamCache,
 
 		InitZhipuKnowledgeBase,
 		dao.NewKnowledgeBaseDAO,
@@ -50,22 +75,37 @@ func InitModule(db *egorm.Component, creditSvc *credit.Module, q mq.MQ, grpcClie
 		log.NewHandler,
 		record.NewHandler,
 		aicredit.NewHandlerBuilder,
+		aicache.NewHandlerBuilder,
+		InitRateLimitBuilder,
+		structured.NewHandlerBuilder,
+		aimoderation.NewHandlerBuilder,
 
-		InitCompositionHandlerUsingZhipu,
+		InitCompositionHandler,
 		InitCommonHandlers,
+		InitCompositionStreamHandler,
+		InitCommonStreamHandlers,
 		InitZhipu,
+		InitPlatformRouter,
 
 		service.NewGeneralService,
 		service.NewJDService,
 		service.NewConfigService,
 		service.NewMockInterviewService,
+		service.NewPlatformService,
+		service.NewCacheService,
+		service.NewConversationService,
+		service.NewMockInterviewStreamService,
+		service.NewEvalService,
+		service.NewPrivacyService,
 		web.NewHandler,
 		web.NewAdminHandler,
 		web.NewMockInterviewHandler,
 
 		initKnowledgeConsumer,
+		initPDFConverter,
 		wire.Struct(new(Module), "*"),
 		wire.FieldsOf(new(*credit.Module), "Svc"),
+		wire.FieldsOf(new(*member.Module), "Svc"),
 	)
 	return new(Module), nil
 }
@@ -86,6 +126,18 @@ func InitLLMCreditLogDAO(db *egorm.Component) dao.LLMCreditDAO {
 	return dao.NewLLMCreditLogDAO(db)
 }
 
+func initPDFConverter() pdf.Converter {
+	type cfg struct {
+		Endpoint string `yaml:"endpoint"`
+	}
+	var c cfg
+	err := econf.UnmarshalKey("pdf", &c)
+	if err != nil {
+		panic(err)
+	}
+	return pdf.NewChromeDPConverter(c.Endpoint)
+}
+
 func initKnowledgeConsumer(svc knowledge_base.RepositoryBaseSvc, q mq.MQ) *event.KnowledgeBaseConsumer {
 	c, err := event.NewKnowledgeBaseConsumer(svc, q)
 	if err != nil {
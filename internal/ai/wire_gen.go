@@ -10,27 +10,37 @@ import (
 	"context"
 	"sync"
 
+	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/mq-api"
 	chatv1 "github.com/ecodeclub/webook/api/proto/gen/chat/v1"
 	"github.com/ecodeclub/webook/internal/ai/internal/event"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository"
+	"github.com/ecodeclub/webook/internal/ai/internal/repository/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/ai/internal/service"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm"
+	cache2 "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/cache"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/config"
 	credit2 "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/credit"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/log"
+	moderation2 "github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/moderation"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/record"
+	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/handler/structured"
 	"github.com/ecodeclub/webook/internal/ai/internal/service/llm/knowledge_base"
 	"github.com/ecodeclub/webook/internal/ai/internal/web"
 	"github.com/ecodeclub/webook/internal/credit"
+	"github.com/ecodeclub/webook/internal/member"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+	"github.com/ecodeclub/webook/internal/pkg/pdf"
 	"github.com/ego-component/egorm"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitModule(db *gorm.DB, creditSvc *credit.Module, q mq.MQ, grpcClient chatv1.ServiceClient) (*Module, error) {
+func InitModule(db *gorm.DB, ec ecache.Cache, cmd redis.Cmdable, creditSvc *credit.Module, memberModule *member.Module, q mq.MQ, grpcClient chatv1.ServiceClient, filter moderation.Filter) (*Module, error) {
 	handlerBuilder := log.NewHandler()
 	configDAO := dao.NewGORMConfigDAO(db)
 	configRepository := repository.NewCachedConfigRepository(configDAO)
@@ -42,24 +52,47 @@ func InitModule(db *gorm.DB, creditSvc *credit.Module, q mq.MQ, grpcClient chatv
 	llmRecordDAO := dao.NewGORMLLMLogDAO(db)
 	llmLogRepo := repository.NewLLMLogRepo(llmRecordDAO)
 	recordHandlerBuilder := record.NewHandler(llmLogRepo)
-	v := InitCommonHandlers(handlerBuilder, configHandlerBuilder, creditHandlerBuilder, recordHandlerBuilder)
+	llmCache := cache.NewLLMCache(ec)
+	llmCacheRepo := repository.NewLLMCacheRepo(llmCache)
+	cacheHandlerBuilder := cache2.NewHandlerBuilder(llmCacheRepo)
+	moderationHandlerBuilder := moderation2.NewHandlerBuilder(filter)
+	service2 := memberModule.Svc
+	ratelimitHandlerBuilder := InitRateLimitBuilder(cmd, service2)
+	structuredHandlerBuilder := structured.NewHandlerBuilder()
+	v := InitCommonHandlers(handlerBuilder, configHandlerBuilder, creditHandlerBuilder, recordHandlerBuilder, cacheHandlerBuilder, moderationHandlerBuilder, ratelimitHandlerBuilder, structuredHandlerBuilder)
 	handler := InitZhipu()
-	handlerHandler := InitCompositionHandlerUsingZhipu(v, handler)
-	streamHandler := InitAliDeepSeekHandler(configRepository, llmLogRepo)
+	ali_deepseekHandler := InitAliDeepSeekHandler()
+	routerHandler := InitPlatformRouter(handler, ali_deepseekHandler)
+	handlerHandler := InitCompositionHandler(v, routerHandler)
+	v2 := InitCommonStreamHandlers(handlerBuilder, configHandlerBuilder, creditHandlerBuilder, recordHandlerBuilder, moderationHandlerBuilder, ratelimitHandlerBuilder)
+	streamHandler := InitCompositionStreamHandler(v2, ali_deepseekHandler)
 	llmService := llm.NewLLMService(handlerHandler, streamHandler)
 	knowledgeBaseDAO := dao.NewKnowledgeBaseDAO(db)
 	knowledgeBaseRepo := repository.NewKnowledgeBaseRepo(knowledgeBaseDAO)
 	repositoryBaseSvc := InitZhipuKnowledgeBase(knowledgeBaseRepo)
-	generalService := service.NewGeneralService(llmService)
+	conversationDAO := dao.NewGORMConversationDAO(db)
+	conversationRepository := repository.NewConversationRepository(conversationDAO)
+	conversationService := service.NewConversationService(conversationRepository, llmService)
+	generalService := service.NewGeneralService(llmService, conversationService)
 	jdService := service.NewJDService(llmService)
-	webHandler := web.NewHandler(generalService, jdService)
+	webHandler := web.NewHandler(generalService, jdService, conversationService)
 	configService := service.NewConfigService(configRepository)
-	adminHandler := web.NewAdminHandler(configService)
+	platformService := service.NewPlatformService(routerHandler)
+	cacheService := service.NewCacheService(llmCacheRepo)
+	evalDAO := dao.NewGORMEvalDAO(db)
+	evalRepository := repository.NewEvalRepository(evalDAO)
+	evalService := service.NewEvalService(evalRepository, llmService)
+	adminHandler := web.NewAdminHandler(configService, platformService, cacheService, evalService)
 	mockInterviewDAO := dao.NewMockInterviewDAO(db)
 	mockInterviewRepository := repository.NewMockInterviewRepository(mockInterviewDAO)
-	mockInterviewService := service.NewMockInterviewService(mockInterviewRepository)
-	mockInterviewHandler := web.NewMockInterviewHandler(grpcClient, mockInterviewService)
+	converter := initPDFConverter()
+	mockInterviewService := service.NewMockInterviewService(mockInterviewRepository, knowledgeBaseRepo, llmService, converter)
+	mockInterviewStreamCache := cache.NewMockInterviewStreamCache(cmd)
+	mockInterviewStreamRepository := repository.NewMockInterviewStreamRepository(mockInterviewStreamCache)
+	mockInterviewStreamService := service.NewMockInterviewStreamService(grpcClient, mockInterviewStreamRepository)
+	mockInterviewHandler := web.NewMockInterviewHandler(grpcClient, mockInterviewService, mockInterviewStreamService)
 	knowledgeBaseConsumer := initKnowledgeConsumer(repositoryBaseSvc, q)
+	privacyService := service.NewPrivacyService(llmLogRepo, conversationRepository, mockInterviewRepository)
 	module := &Module{
 		Svc:              llmService,
 		KnowledgeBaseSvc: repositoryBaseSvc,
@@ -67,6 +100,7 @@ func InitModule(db *gorm.DB, creditSvc *credit.Module, q mq.MQ, grpcClient chatv
 		AdminHandler:     adminHandler,
 		MockInterviewHdl: mockInterviewHandler,
 		C:                knowledgeBaseConsumer,
+		PrivacySvc:       privacyService,
 	}
 	return module, nil
 }
@@ -89,6 +123,18 @@ func InitLLMCreditLogDAO(db *egorm.Component) dao.LLMCreditDAO {
 	return dao.NewLLMCreditLogDAO(db)
 }
 
+func initPDFConverter() pdf.Converter {
+	type cfg struct {
+		Endpoint string `yaml:"endpoint"`
+	}
+	var c cfg
+	err := econf.UnmarshalKey("pdf", &c)
+	if err != nil {
+		panic(err)
+	}
+	return pdf.NewChromeDPConverter(c.Endpoint)
+}
+
 func initKnowledgeConsumer(svc knowledge_base.RepositoryBaseSvc, q mq.MQ) *event.KnowledgeBaseConsumer {
 	c, err := event.NewKnowledgeBaseConsumer(svc, q)
 	if err != nil {
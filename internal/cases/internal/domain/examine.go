@@ -1,5 +1,12 @@
 package domain
 
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
 type ExamineCaseResult struct {
 	Cid    int64
 	Result CaseResult
@@ -26,3 +33,168 @@ const (
 	// 注意案例这边只有符合或者不符合，没有级别的评判
 	ResultPassed
 )
+
+// ExamineRecord 一次测试的记录。
+// 每一次测试都会保留下来，ExamineCaseResult 只是最近一次的结果
+type ExamineRecord struct {
+	Id  int64
+	Uid int64
+	Cid int64
+	Tid string
+	// 用户输入的内容
+	Input     string
+	Result    CaseResult
+	RawResult string
+	Tokens    int64
+	Amount    int64
+	Ctime     time.Time
+}
+
+// DiffOp 对比的操作
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "equal"
+	DiffOpInsert DiffOp = "insert"
+	DiffOpDelete DiffOp = "delete"
+)
+
+// DiffLine 按行对比的结果
+type DiffLine struct {
+	Op      DiffOp
+	Content string
+}
+
+// ExamineDiff 两次测试的对比，Base 是之前的测试，Target 是之后的测试
+type ExamineDiff struct {
+	Base   ExamineRecord
+	Target ExamineRecord
+	// 用户输入的变化
+	Input []DiffLine
+	// AI 评价的变化
+	RawResult []DiffLine
+}
+
+// Improved 从没通过变成了通过
+func (d ExamineDiff) Improved() bool {
+	return d.Base.Result < d.Target.Result
+}
+
+// Regressed 从通过变成了没通过
+func (d ExamineDiff) Regressed() bool {
+	return d.Base.Result > d.Target.Result
+}
+
+// NewExamineDiff 对比两次测试，会把时间早的那一次作为 Base
+func NewExamineDiff(base, target ExamineRecord) ExamineDiff {
+	if base.Ctime.After(target.Ctime) ||
+		(base.Ctime.Equal(target.Ctime) && base.Id > target.Id) {
+		base, target = target, base
+	}
+	return ExamineDiff{
+		Base:      base,
+		Target:    target,
+		Input:     DiffLines(base.Input, target.Input),
+		RawResult: DiffLines(base.RawResult, target.RawResult),
+	}
+}
+
+// DiffLines 基于最长公共子序列按行对比
+func DiffLines(a, b string) []DiffLine {
+	as, bs := splitLines(a), splitLines(b)
+	// lcs[i][j] 是 as[i:] 和 bs[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	res := make([]DiffLine, 0, len(as)+len(bs))
+	i, j := 0, 0
+	for i < len(as) && j < len(bs) {
+		switch {
+		case as[i] == bs[j]:
+			res = append(res, DiffLine{Op: DiffOpEqual, Content: as[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			res = append(res, DiffLine{Op: DiffOpDelete, Content: as[i]})
+			i++
+		default:
+			res = append(res, DiffLine{Op: DiffOpInsert, Content: bs[j]})
+			j++
+		}
+	}
+	for ; i < len(as); i++ {
+		res = append(res, DiffLine{Op: DiffOpDelete, Content: as[i]})
+	}
+	for ; j < len(bs); j++ {
+		res = append(res, DiffLine{Op: DiffOpInsert, Content: bs[j]})
+	}
+	return res
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+// ExamineProgress 某一天结束时的掌握情况
+type ExamineProgress struct {
+	// 当天零点
+	Date time.Time
+	// 一共有多少个案例
+	Total int
+	// 测试过的案例数量
+	Examined int
+	// 最近一次测试通过的案例数量
+	Passed int
+}
+
+// NewExamineProgress 根据测试记录计算每一天的掌握情况，只有有测试记录的日子才会有数据。
+// 案例以最近一次测试的结果为准，所以 Passed 也可能会下降
+func NewExamineProgress(cids []int64, records []ExamineRecord, loc *time.Location) []ExamineProgress {
+	total := make(map[int64]struct{}, len(cids))
+	for _, cid := range cids {
+		total[cid] = struct{}{}
+	}
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(a, b ExamineRecord) int {
+		if c := a.Ctime.Compare(b.Ctime); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	latest := make(map[int64]CaseResult, len(total))
+	res := make([]ExamineProgress, 0, 8)
+	for _, r := range records {
+		if _, ok := total[r.Cid]; !ok {
+			continue
+		}
+		latest[r.Cid] = r.Result
+		y, m, d := r.Ctime.In(loc).Date()
+		date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		passed := 0
+		for _, result := range latest {
+			if result == ResultPassed {
+				passed++
+			}
+		}
+		p := ExamineProgress{Date: date, Total: len(total), Examined: len(latest), Passed: passed}
+		if n := len(res); n > 0 && res[n-1].Date.Equal(date) {
+			res[n-1] = p
+			continue
+		}
+		res = append(res, p)
+	}
+	return res
+}
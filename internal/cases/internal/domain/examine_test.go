@@ -0,0 +1,117 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		want []DiffLine
+	}{
+		{
+			name: "完全相同",
+			a:    "a\nb",
+			b:    "a\nb",
+			want: []DiffLine{
+				{Op: DiffOpEqual, Content: "a"},
+				{Op: DiffOpEqual, Content: "b"},
+			},
+		},
+		{
+			name: "从无到有",
+			a:    "",
+			b:    "a\r\nb",
+			want: []DiffLine{
+				{Op: DiffOpInsert, Content: "a"},
+				{Op: DiffOpInsert, Content: "b"},
+			},
+		},
+		{
+			name: "中间修改",
+			a:    "a\nb\nc",
+			b:    "a\nd\nc\ne",
+			want: []DiffLine{
+				{Op: DiffOpEqual, Content: "a"},
+				{Op: DiffOpDelete, Content: "b"},
+				{Op: DiffOpInsert, Content: "d"},
+				{Op: DiffOpEqual, Content: "c"},
+				{Op: DiffOpInsert, Content: "e"},
+			},
+		},
+		{
+			name: "全部删除",
+			a:    "a\nb",
+			b:    "",
+			want: []DiffLine{
+				{Op: DiffOpDelete, Content: "a"},
+				{Op: DiffOpDelete, Content: "b"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, DiffLines(tc.a, tc.b))
+		})
+	}
+}
+
+func TestNewExamineProgress(t *testing.T) {
+	loc := time.UTC
+	day := func(d, h int) time.Time {
+		return time.Date(2024, 5, d, h, 0, 0, 0, loc)
+	}
+	testCases := []struct {
+		name    string
+		cids    []int64
+		records []ExamineRecord
+		want    []ExamineProgress
+	}{
+		{
+			name: "没有测试记录",
+			cids: []int64{1, 2},
+			want: []ExamineProgress{},
+		},
+		{
+			name: "同一天的多次测试合并，以最近一次的结果为准",
+			cids: []int64{1, 2, 3},
+			records: []ExamineRecord{
+				{Id: 3, Cid: 2, Result: ResultPassed, Ctime: day(2, 10)},
+				{Id: 1, Cid: 1, Result: ResultFailed, Ctime: day(1, 10)},
+				{Id: 2, Cid: 1, Result: ResultPassed, Ctime: day(1, 11)},
+				{Id: 4, Cid: 1, Result: ResultFailed, Ctime: day(3, 9)},
+				// 不在统计范围内
+				{Id: 5, Cid: 4, Result: ResultPassed, Ctime: day(4, 9)},
+			},
+			want: []ExamineProgress{
+				{Date: day(1, 0), Total: 3, Examined: 1, Passed: 1},
+				{Date: day(2, 0), Total: 3, Examined: 2, Passed: 2},
+				{Date: day(3, 0), Total: 3, Examined: 2, Passed: 1},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, NewExamineProgress(tc.cids, tc.records, loc))
+		})
+	}
+}
@@ -4,6 +4,10 @@ var (
 	SystemError = ErrorCode{Code: 505001, Msg: "系统错误"}
 	// InsufficientCredits 这个不管说是客户端错误还是服务端错误，都有点勉强，所以随便用一个 5
 	InsufficientCredits = ErrorCode{Code: 505002, Msg: "积分不足"}
+	// ExamineRecordNotFound 测试记录不存在，或者两次测试不是同一个案例
+	ExamineRecordNotFound = ErrorCode{Code: 505003, Msg: "测试记录不存在"}
+	ContentBlocked        = ErrorCode{Code: 505004, Msg: "内容包含敏感信息"}
+	RateLimited           = ErrorCode{Code: 505005, Msg: "请求过于频繁，请稍后再试"}
 )
 
 type ErrorCode struct {
@@ -5,6 +5,7 @@ package integration
 import (
 	"context"
 	"net/http"
+	"slices"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/ecodeclub/webook/internal/ai"
 	aimocks "github.com/ecodeclub/webook/internal/ai/mocks"
 	"github.com/ecodeclub/webook/internal/cases/internal/domain"
+	"github.com/ecodeclub/webook/internal/cases/internal/errs"
 	eveMocks "github.com/ecodeclub/webook/internal/cases/internal/event/mocks"
 	"github.com/ecodeclub/webook/internal/cases/internal/integration/startup"
 	"github.com/ecodeclub/webook/internal/cases/internal/repository/dao"
@@ -47,6 +49,12 @@ func (s *ExamineHandlerTest) SetupSuite() {
 	ctrl := gomock.NewController(s.T())
 	aiSvc := aimocks.NewMockService(ctrl)
 	aiSvc.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, req ai.LLMRequest) (ai.LLMResponse, error) {
+		if slices.Contains(req.Input, "敏感内容") {
+			return ai.LLMResponse{}, ai.ErrContentBlocked
+		}
+		if slices.Contains(req.Input, "限流") {
+			return ai.LLMResponse{}, ai.ErrRateLimited
+		}
 		return ai.LLMResponse{
 			Tokens: req.Uid,
 			Amount: req.Uid,
@@ -131,6 +139,7 @@ func (s *ExamineHandlerTest) TestExamine() {
 				assert.Equal(t, dao.CaseExamineRecord{
 					Uid:       uid,
 					Cid:       1,
+					Input:     "测试一下",
 					Result:    domain.ResultPassed.ToUint8(),
 					RawResult: "通过",
 					Tokens:    uid,
@@ -199,6 +208,7 @@ func (s *ExamineHandlerTest) TestExamine() {
 				assert.Equal(t, dao.CaseExamineRecord{
 					Uid:       uid,
 					Cid:       2,
+					Input:     "测试一下",
 					Result:    domain.ResultPassed.ToUint8(),
 					RawResult: "通过",
 					Tokens:    uid,
@@ -235,6 +245,39 @@ func (s *ExamineHandlerTest) TestExamine() {
 				},
 			},
 		},
+		{
+			name:   "输入没有通过审核",
+			before: func(t *testing.T) {},
+			after: func(t *testing.T) {
+				var cnt int64
+				err := s.db.Model(&dao.CaseExamineRecord{}).Where("input = ?", "敏感内容").Count(&cnt).Error
+				require.NoError(t, err)
+				assert.Equal(t, int64(0), cnt)
+			},
+			req: web.ExamineReq{
+				Cid:   1,
+				Input: "敏感内容",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.ExamineResult]{
+				Code: errs.ContentBlocked.Code,
+				Msg:  errs.ContentBlocked.Msg,
+			},
+		},
+		{
+			name:   "触发限流",
+			before: func(t *testing.T) {},
+			after:  func(t *testing.T) {},
+			req: web.ExamineReq{
+				Cid:   1,
+				Input: "限流",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.ExamineResult]{
+				Code: errs.RateLimited.Code,
+				Msg:  errs.RateLimited.Msg,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -253,6 +296,114 @@ func (s *ExamineHandlerTest) TestExamine() {
 	}
 }
 
+func (s *ExamineHandlerTest) TestRecords() {
+	err := s.db.Create([]dao.CaseExamineRecord{
+		{Id: 1, Uid: uid, Cid: 1, Input: "第一次", Result: domain.ResultFailed.ToUint8(), RawResult: "不通过", Ctime: 1000, Utime: 1000},
+		{Id: 2, Uid: uid, Cid: 1, Input: "第二次", Result: domain.ResultPassed.ToUint8(), RawResult: "通过", Ctime: 2000, Utime: 2000},
+		{Id: 3, Uid: uid, Cid: 1, Input: "第三次", Result: domain.ResultPassed.ToUint8(), RawResult: "通过", Ctime: 3000, Utime: 3000},
+		// 别的案例
+		{Id: 4, Uid: uid, Cid: 2, Input: "别的案例", Ctime: 4000, Utime: 4000},
+		// 别人的
+		{Id: 5, Uid: uid + 1, Cid: 1, Input: "别人的", Ctime: 5000, Utime: 5000},
+	}).Error
+	require.NoError(s.T(), err)
+	req, err := http.NewRequest(http.MethodPost,
+		"/cases/examine/records", iox.NewJSONReader(web.ExamineRecordsReq{
+			Cid:   1,
+			Limit: 2,
+		}))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(s.T(), err)
+	recorder := test.NewJSONResponseRecorder[web.ExamineRecordList]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(s.T(), 200, recorder.Code)
+	assert.Equal(s.T(), test.Result[web.ExamineRecordList]{
+		Data: web.ExamineRecordList{
+			Total: 3,
+			Records: []web.ExamineRecord{
+				{Id: 3, Cid: 1, Input: "第三次", Result: domain.ResultPassed.ToUint8(), RawResult: "通过", Ctime: 3000},
+				{Id: 2, Cid: 1, Input: "第二次", Result: domain.ResultPassed.ToUint8(), RawResult: "通过", Ctime: 2000},
+			},
+		},
+	}, recorder.MustScan())
+
+	// 没有传 limit，使用默认值
+	req, err = http.NewRequest(http.MethodPost,
+		"/cases/examine/records", iox.NewJSONReader(web.ExamineRecordsReq{Cid: 1}))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(s.T(), err)
+	recorder = test.NewJSONResponseRecorder[web.ExamineRecordList]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(s.T(), 200, recorder.Code)
+	res := recorder.MustScan()
+	assert.Equal(s.T(), int64(3), res.Data.Total)
+	assert.Len(s.T(), res.Data.Records, 3)
+}
+
+func (s *ExamineHandlerTest) TestDiff() {
+	err := s.db.Create([]dao.CaseExamineRecord{
+		{Id: 1, Uid: uid, Cid: 1, Input: "第一行\n第二行", Result: domain.ResultFailed.ToUint8(), RawResult: "不通过", Ctime: 1000, Utime: 1000},
+		{Id: 2, Uid: uid, Cid: 1, Input: "第一行\n第三行", Result: domain.ResultPassed.ToUint8(), RawResult: "通过", Ctime: 2000, Utime: 2000},
+		{Id: 3, Uid: uid, Cid: 2, Input: "别的案例", Ctime: 3000, Utime: 3000},
+		{Id: 4, Uid: uid + 1, Cid: 1, Input: "别人的", Ctime: 4000, Utime: 4000},
+	}).Error
+	require.NoError(s.T(), err)
+	testCases := []struct {
+		name     string
+		req      web.ExamineDiffReq
+		wantResp test.Result[web.ExamineDiff]
+	}{
+		{
+			name: "顺序颠倒也会以之前的测试为准",
+			req:  web.ExamineDiffReq{Id1: 2, Id2: 1},
+			wantResp: test.Result[web.ExamineDiff]{
+				Data: web.ExamineDiff{
+					Base:     web.ExamineRecord{Id: 1, Cid: 1, Input: "第一行\n第二行", Result: domain.ResultFailed.ToUint8(), RawResult: "不通过", Ctime: 1000},
+					Target:   web.ExamineRecord{Id: 2, Cid: 1, Input: "第一行\n第三行", Result: domain.ResultPassed.ToUint8(), RawResult: "通过", Ctime: 2000},
+					Improved: true,
+					Input: []web.DiffLine{
+						{Op: "equal", Content: "第一行"},
+						{Op: "delete", Content: "第二行"},
+						{Op: "insert", Content: "第三行"},
+					},
+					RawResult: []web.DiffLine{
+						{Op: "delete", Content: "不通过"},
+						{Op: "insert", Content: "通过"},
+					},
+				},
+			},
+		},
+		{
+			name: "不是同一个案例",
+			req:  web.ExamineDiffReq{Id1: 1, Id2: 3},
+			wantResp: test.Result[web.ExamineDiff]{
+				Code: 505003,
+				Msg:  "测试记录不存在",
+			},
+		},
+		{
+			name: "不能查看别人的记录",
+			req:  web.ExamineDiffReq{Id1: 1, Id2: 4},
+			wantResp: test.Result[web.ExamineDiff]{
+				Code: 505003,
+				Msg:  "测试记录不存在",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		s.T().Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost,
+				"/cases/examine/diff", iox.NewJSONReader(tc.req))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[web.ExamineDiff]()
+			s.server.ServeHTTP(recorder, req)
+			require.Equal(t, 200, recorder.Code)
+			assert.Equal(t, tc.wantResp, recorder.MustScan())
+		})
+	}
+}
+
 func TestExamineHandler(t *testing.T) {
 	suite.Run(t, new(ExamineHandlerTest))
 }
@@ -2,12 +2,15 @@ package dao
 
 // CaseExamineRecord 业务层面上记录
 type CaseExamineRecord struct {
-	Id  int64
-	Uid int64
-	Cid int64
+	Id int64
+	// 按照 uid 和 cid 查询历史记录
+	Uid int64 `gorm:"index:uid_cid"`
+	Cid int64 `gorm:"index:uid_cid"`
 	// 代表这一次测试的 ID
 	// 这个主要是为了和 AI 打交道，有一个唯一凭证
-	Tid    string
+	Tid string
+	// 用户输入的内容
+	Input  string
 	Result uint8
 	// 原始的 AI 回答
 	RawResult string
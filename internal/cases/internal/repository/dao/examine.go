@@ -15,6 +15,12 @@ type ExamineDAO interface {
 	SaveResult(ctx context.Context, record CaseExamineRecord) error
 	GetResultByUidAndCid(ctx context.Context, uid int64, cid int64) (CaseResult, error)
 	GetResultByUidAndCids(ctx context.Context, uid int64, cids []int64) ([]CaseResult, error)
+	// ListRecords 按照时间倒序
+	ListRecords(ctx context.Context, uid, cid int64, offset, limit int) ([]CaseExamineRecord, error)
+	CountRecords(ctx context.Context, uid, cid int64) (int64, error)
+	GetRecordsByIds(ctx context.Context, uid int64, ids []int64) ([]CaseExamineRecord, error)
+	// GetRecordsByCids 只会返回 id、cid、result 和 ctime，用于计算掌握情况
+	GetRecordsByCids(ctx context.Context, uid int64, cids []int64) ([]CaseExamineRecord, error)
 }
 
 type GORMExamineDAO struct {
@@ -62,3 +68,37 @@ func (dao *GORMExamineDAO) GetResultByUidAndCids(ctx context.Context, uid int64,
 	err := dao.db.WithContext(ctx).Where("uid = ? AND cid IN ?", uid, cids).Find(&res).Error
 	return res, err
 }
+
+func (dao *GORMExamineDAO) ListRecords(ctx context.Context, uid, cid int64, offset, limit int) ([]CaseExamineRecord, error) {
+	var res []CaseExamineRecord
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND cid = ?", uid, cid).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMExamineDAO) CountRecords(ctx context.Context, uid, cid int64) (int64, error) {
+	var res int64
+	err := dao.db.WithContext(ctx).Model(&CaseExamineRecord{}).
+		Where("uid = ? AND cid = ?", uid, cid).
+		Count(&res).Error
+	return res, err
+}
+
+func (dao *GORMExamineDAO) GetRecordsByIds(ctx context.Context, uid int64, ids []int64) ([]CaseExamineRecord, error) {
+	var res []CaseExamineRecord
+	err := dao.db.WithContext(ctx).Where("uid = ? AND id IN ?", uid, ids).Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMExamineDAO) GetRecordsByCids(ctx context.Context, uid int64, cids []int64) ([]CaseExamineRecord, error) {
+	var res []CaseExamineRecord
+	err := dao.db.WithContext(ctx).
+		Select("id", "cid", "result", "ctime").
+		Where("uid = ? AND cid IN ?", uid, cids).
+		Order("id ASC").
+		Find(&res).Error
+	return res, err
+}
@@ -3,17 +3,22 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/ecodeclub/webook/internal/cases/internal/domain"
 	"github.com/ecodeclub/webook/internal/cases/internal/repository/dao"
 
 	"github.com/ecodeclub/ekit/slice"
+	"golang.org/x/sync/errgroup"
 )
 
 type ExamineRepository interface {
-	SaveResult(ctx context.Context, uid, cid int64, result domain.ExamineCaseResult) error
+	SaveResult(ctx context.Context, uid, cid int64, input string, result domain.ExamineCaseResult) error
 	GetResultByUidAndQid(ctx context.Context, uid int64, cid int64) (domain.CaseResult, error)
 	GetResultsByIds(ctx context.Context, uid int64, ids []int64) ([]domain.ExamineCaseResult, error)
+	ListRecords(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.ExamineRecord, int64, error)
+	GetRecordsByIds(ctx context.Context, uid int64, ids []int64) ([]domain.ExamineRecord, error)
+	GetRecordsByCids(ctx context.Context, uid int64, cids []int64) ([]domain.ExamineRecord, error)
 }
 
 var _ ExamineRepository = &CachedExamineRepository{}
@@ -40,12 +45,13 @@ func (repo *CachedExamineRepository) GetResultByUidAndQid(ctx context.Context, u
 	return domain.CaseResult(res.Result), err
 }
 
-func (repo *CachedExamineRepository) SaveResult(ctx context.Context, uid, cid int64, result domain.ExamineCaseResult) error {
+func (repo *CachedExamineRepository) SaveResult(ctx context.Context, uid, cid int64, input string, result domain.ExamineCaseResult) error {
 	// 开始记录
 	err := repo.dao.SaveResult(ctx, dao.CaseExamineRecord{
 		Uid:       uid,
 		Cid:       cid,
 		Tid:       result.Tid,
+		Input:     input,
 		Result:    result.Result.ToUint8(),
 		RawResult: result.RawResult,
 		Tokens:    result.Tokens,
@@ -54,6 +60,57 @@ func (repo *CachedExamineRepository) SaveResult(ctx context.Context, uid, cid in
 	return err
 }
 
+func (repo *CachedExamineRepository) ListRecords(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.ExamineRecord, int64, error) {
+	var (
+		eg    errgroup.Group
+		res   []dao.CaseExamineRecord
+		total int64
+	)
+	eg.Go(func() error {
+		var err error
+		res, err = repo.dao.ListRecords(ctx, uid, cid, offset, limit)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		total, err = repo.dao.CountRecords(ctx, uid, cid)
+		return err
+	})
+	err := eg.Wait()
+	return slice.Map(res, func(idx int, src dao.CaseExamineRecord) domain.ExamineRecord {
+		return repo.toRecord(src)
+	}), total, err
+}
+
+func (repo *CachedExamineRepository) GetRecordsByIds(ctx context.Context, uid int64, ids []int64) ([]domain.ExamineRecord, error) {
+	res, err := repo.dao.GetRecordsByIds(ctx, uid, ids)
+	return slice.Map(res, func(idx int, src dao.CaseExamineRecord) domain.ExamineRecord {
+		return repo.toRecord(src)
+	}), err
+}
+
+func (repo *CachedExamineRepository) GetRecordsByCids(ctx context.Context, uid int64, cids []int64) ([]domain.ExamineRecord, error) {
+	res, err := repo.dao.GetRecordsByCids(ctx, uid, cids)
+	return slice.Map(res, func(idx int, src dao.CaseExamineRecord) domain.ExamineRecord {
+		return repo.toRecord(src)
+	}), err
+}
+
+func (repo *CachedExamineRepository) toRecord(src dao.CaseExamineRecord) domain.ExamineRecord {
+	return domain.ExamineRecord{
+		Id:        src.Id,
+		Uid:       src.Uid,
+		Cid:       src.Cid,
+		Tid:       src.Tid,
+		Input:     src.Input,
+		Result:    domain.CaseResult(src.Result),
+		RawResult: src.RawResult,
+		Tokens:    src.Tokens,
+		Amount:    src.Amount,
+		Ctime:     time.UnixMilli(src.Ctime),
+	}
+}
+
 func NewCachedExamineRepository(dao dao.ExamineDAO) ExamineRepository {
 	return &CachedExamineRepository{dao: dao}
 }
@@ -16,7 +16,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/webook/internal/ai"
@@ -25,7 +28,12 @@ import (
 	"github.com/lithammer/shortuuid/v4"
 )
 
-var ErrInsufficientCredit = ai.ErrInsufficientCredit
+var (
+	ErrInsufficientCredit    = ai.ErrInsufficientCredit
+	ErrContentBlocked        = ai.ErrContentBlocked
+	ErrRateLimited           = ai.ErrRateLimited
+	ErrExamineRecordNotFound = errors.New("测试记录不存在")
+)
 
 // ExamineService 测试服务
 //
@@ -36,6 +44,12 @@ type ExamineService interface {
 	Examine(ctx context.Context, uid, cid int64, input string) (domain.ExamineCaseResult, error)
 	GetResult(ctx context.Context, uid, cid int64) (domain.CaseResult, error)
 	GetResults(ctx context.Context, uid int64, ids []int64) (map[int64]domain.ExamineCaseResult, error)
+	// Records 某个案例的所有测试记录，按照时间倒序
+	Records(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.ExamineRecord, int64, error)
+	// Diff 对比同一个案例的两次测试
+	Diff(ctx context.Context, uid, id1, id2 int64) (domain.ExamineDiff, error)
+	// Progress 这些案例按天统计的掌握情况
+	Progress(ctx context.Context, uid int64, cids []int64) ([]domain.ExamineProgress, error)
 }
 
 var _ ExamineService = &LLMExamineService{}
@@ -54,6 +68,36 @@ func (svc *LLMExamineService) GetResults(ctx context.Context, uid int64, ids []i
 	}), err
 }
 
+func (svc *LLMExamineService) Records(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.ExamineRecord, int64, error) {
+	return svc.repo.ListRecords(ctx, uid, cid, offset, limit)
+}
+
+func (svc *LLMExamineService) Diff(ctx context.Context, uid, id1, id2 int64) (domain.ExamineDiff, error) {
+	records, err := svc.repo.GetRecordsByIds(ctx, uid, []int64{id1, id2})
+	if err != nil {
+		return domain.ExamineDiff{}, err
+	}
+	// 只能看自己的记录，所以 uid 不对也是找不到
+	if id1 == id2 || len(records) != 2 {
+		return domain.ExamineDiff{}, fmt.Errorf("%w, uid %d, id1 %d, id2 %d", ErrExamineRecordNotFound, uid, id1, id2)
+	}
+	if records[0].Cid != records[1].Cid {
+		return domain.ExamineDiff{}, fmt.Errorf("%w, 两次测试不是同一个案例 id1 %d, id2 %d", ErrExamineRecordNotFound, id1, id2)
+	}
+	return domain.NewExamineDiff(records[0], records[1]), nil
+}
+
+func (svc *LLMExamineService) Progress(ctx context.Context, uid int64, cids []int64) ([]domain.ExamineProgress, error) {
+	if len(cids) == 0 {
+		return []domain.ExamineProgress{}, nil
+	}
+	records, err := svc.repo.GetRecordsByCids(ctx, uid, cids)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewExamineProgress(cids, records, time.Local), nil
+}
+
 func (svc *LLMExamineService) GetResult(ctx context.Context, uid, qid int64) (domain.CaseResult, error) {
 	return svc.repo.GetResultByUidAndQid(ctx, uid, qid)
 }
@@ -88,7 +132,7 @@ func (svc *LLMExamineService) Examine(ctx context.Context,
 		Tid:       tid,
 	}
 	// 开始记录结果
-	err = svc.repo.SaveResult(ctx, uid, cid, result)
+	err = svc.repo.SaveResult(ctx, uid, cid, input, result)
 	return result, err
 }
 
@@ -3,13 +3,21 @@ package web
 import (
 	"errors"
 
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/cases/internal/domain"
 	"github.com/ecodeclub/webook/internal/cases/internal/errs"
 	"github.com/ecodeclub/webook/internal/cases/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// defaultRecordsLimit 没有传 limit 的时候返回多少条测试记录
+	defaultRecordsLimit = 10
+	maxRecordsLimit     = 100
+)
+
 type ExamineHandler struct {
 	svc service.ExamineService
 }
@@ -23,6 +31,8 @@ func NewExamineHandler(svc service.ExamineService) *ExamineHandler {
 func (h *ExamineHandler) MemberRoutes(server *gin.Engine) {
 	g := server.Group("/cases/examine")
 	g.POST("", ginx.BS(h.Examine))
+	g.POST("/records", ginx.BS(h.Records))
+	g.POST("/diff", ginx.BS(h.Diff))
 }
 
 func (h *ExamineHandler) Examine(ctx *ginx.Context, req ExamineReq, sess session.Session) (ginx.Result, error) {
@@ -33,7 +43,16 @@ func (h *ExamineHandler) Examine(ctx *ginx.Context, req ExamineReq, sess session
 			Code: errs.InsufficientCredits.Code,
 			Msg:  errs.InsufficientCredits.Msg,
 		}, nil
-
+	case errors.Is(err, service.ErrContentBlocked):
+		return ginx.Result{
+			Code: errs.ContentBlocked.Code,
+			Msg:  errs.ContentBlocked.Msg,
+		}, nil
+	case errors.Is(err, service.ErrRateLimited):
+		return ginx.Result{
+			Code: errs.RateLimited.Code,
+			Msg:  errs.RateLimited.Msg,
+		}, nil
 	case err == nil:
 		return ginx.Result{
 			Data: newExamineResult(res),
@@ -42,3 +61,42 @@ func (h *ExamineHandler) Examine(ctx *ginx.Context, req ExamineReq, sess session
 		return systemErrorResult, err
 	}
 }
+
+// Records 某个案例的历史测试记录
+func (h *ExamineHandler) Records(ctx *ginx.Context, req ExamineRecordsReq, sess session.Session) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRecordsLimit
+	}
+	limit = min(limit, maxRecordsLimit)
+	records, total, err := h.svc.Records(ctx, sess.Claims().Uid, req.Cid, req.Offset, limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: ExamineRecordList{
+			Total: total,
+			Records: slice.Map(records, func(idx int, src domain.ExamineRecord) ExamineRecord {
+				return newExamineRecord(src)
+			}),
+		},
+	}, nil
+}
+
+// Diff 对比两次测试
+func (h *ExamineHandler) Diff(ctx *ginx.Context, req ExamineDiffReq, sess session.Session) (ginx.Result, error) {
+	diff, err := h.svc.Diff(ctx, sess.Claims().Uid, req.Id1, req.Id2)
+	switch {
+	case errors.Is(err, service.ErrExamineRecordNotFound):
+		return ginx.Result{
+			Code: errs.ExamineRecordNotFound.Code,
+			Msg:  errs.ExamineRecordNotFound.Msg,
+		}, nil
+	case err == nil:
+		return ginx.Result{
+			Data: newExamineDiff(diff),
+		}, nil
+	default:
+		return systemErrorResult, err
+	}
+}
@@ -161,6 +161,79 @@ func newExamineResult(r domain.ExamineCaseResult) ExamineResult {
 	}
 }
 
+type ExamineRecordsReq struct {
+	Cid    int64 `json:"cid"`
+	Offset int   `json:"offset,omitempty"`
+	Limit  int   `json:"limit,omitempty"`
+}
+
+type ExamineRecordList struct {
+	Records []ExamineRecord `json:"records"`
+	Total   int64           `json:"total"`
+}
+
+type ExamineRecord struct {
+	Id        int64  `json:"id"`
+	Cid       int64  `json:"cid"`
+	Input     string `json:"input"`
+	Result    uint8  `json:"result"`
+	RawResult string `json:"rawResult"`
+	Amount    int64  `json:"amount"`
+	Ctime     int64  `json:"ctime"`
+}
+
+func newExamineRecord(r domain.ExamineRecord) ExamineRecord {
+	return ExamineRecord{
+		Id:        r.Id,
+		Cid:       r.Cid,
+		Input:     r.Input,
+		Result:    r.Result.ToUint8(),
+		RawResult: r.RawResult,
+		Amount:    r.Amount,
+		Ctime:     r.Ctime.UnixMilli(),
+	}
+}
+
+type ExamineDiffReq struct {
+	Id1 int64 `json:"id1"`
+	Id2 int64 `json:"id2"`
+}
+
+type DiffLine struct {
+	// equal, insert 或者 delete
+	Op      string `json:"op"`
+	Content string `json:"content"`
+}
+
+type ExamineDiff struct {
+	// 之前的测试
+	Base ExamineRecord `json:"base"`
+	// 之后的测试
+	Target ExamineRecord `json:"target"`
+	// 从没通过变成了通过
+	Improved bool `json:"improved"`
+	// 从通过变成了没通过
+	Regressed bool       `json:"regressed"`
+	Input     []DiffLine `json:"input"`
+	RawResult []DiffLine `json:"rawResult"`
+}
+
+func newExamineDiff(d domain.ExamineDiff) ExamineDiff {
+	toLines := func(lines []domain.DiffLine) []DiffLine {
+		return slice.Map(lines, func(idx int, src domain.DiffLine) DiffLine {
+			return DiffLine{Op: string(src.Op), Content: src.Content}
+		})
+	}
+	return ExamineDiff{
+		Base:      newExamineRecord(d.Base),
+		Target:    newExamineRecord(d.Target),
+		Improved:  d.Improved(),
+		Regressed: d.Regressed(),
+		Input:     toLines(d.Input),
+		RawResult: toLines(d.RawResult),
+	}
+}
+
 type BizReq struct {
 	Biz   string `json:"biz"`
 	BizId int64  `json:"bizId"`
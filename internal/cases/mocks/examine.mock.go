@@ -3,9 +3,10 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=./examine.go -destination=../../mocks/examine.mock.go -package=quemocks -typed=true ExamineService
+//	mockgen -source=./examine.go -destination=../../mocks/examine.mock.go -package=casemocks -typed=true ExamineService
 //
-// Package quemocks is a generated GoMock package.
+
+// Package casemocks is a generated GoMock package.
 package casemocks
 
 import (
@@ -20,6 +21,7 @@ import (
 type MockExamineService struct {
 	ctrl     *gomock.Controller
 	recorder *MockExamineServiceMockRecorder
+	isgomock struct{}
 }
 
 // MockExamineServiceMockRecorder is the mock recorder for MockExamineService.
@@ -39,6 +41,45 @@ func (m *MockExamineService) EXPECT() *MockExamineServiceMockRecorder {
 	return m.recorder
 }
 
+// Diff mocks base method.
+func (m *MockExamineService) Diff(ctx context.Context, uid, id1, id2 int64) (domain.ExamineDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diff", ctx, uid, id1, id2)
+	ret0, _ := ret[0].(domain.ExamineDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Diff indicates an expected call of Diff.
+func (mr *MockExamineServiceMockRecorder) Diff(ctx, uid, id1, id2 any) *MockExamineServiceDiffCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diff", reflect.TypeOf((*MockExamineService)(nil).Diff), ctx, uid, id1, id2)
+	return &MockExamineServiceDiffCall{Call: call}
+}
+
+// MockExamineServiceDiffCall wrap *gomock.Call
+type MockExamineServiceDiffCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockExamineServiceDiffCall) Return(arg0 domain.ExamineDiff, arg1 error) *MockExamineServiceDiffCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockExamineServiceDiffCall) Do(f func(context.Context, int64, int64, int64) (domain.ExamineDiff, error)) *MockExamineServiceDiffCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockExamineServiceDiffCall) DoAndReturn(f func(context.Context, int64, int64, int64) (domain.ExamineDiff, error)) *MockExamineServiceDiffCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Examine mocks base method.
 func (m *MockExamineService) Examine(ctx context.Context, uid, cid int64, input string) (domain.ExamineCaseResult, error) {
 	m.ctrl.T.Helper()
@@ -49,31 +90,31 @@ func (m *MockExamineService) Examine(ctx context.Context, uid, cid int64, input
 }
 
 // Examine indicates an expected call of Examine.
-func (mr *MockExamineServiceMockRecorder) Examine(ctx, uid, cid, input any) *ExamineServiceExamineCall {
+func (mr *MockExamineServiceMockRecorder) Examine(ctx, uid, cid, input any) *MockExamineServiceExamineCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Examine", reflect.TypeOf((*MockExamineService)(nil).Examine), ctx, uid, cid, input)
-	return &ExamineServiceExamineCall{Call: call}
+	return &MockExamineServiceExamineCall{Call: call}
 }
 
-// ExamineServiceExamineCall wrap *gomock.Call
-type ExamineServiceExamineCall struct {
+// MockExamineServiceExamineCall wrap *gomock.Call
+type MockExamineServiceExamineCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ExamineServiceExamineCall) Return(arg0 domain.ExamineCaseResult, arg1 error) *ExamineServiceExamineCall {
+func (c *MockExamineServiceExamineCall) Return(arg0 domain.ExamineCaseResult, arg1 error) *MockExamineServiceExamineCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ExamineServiceExamineCall) Do(f func(context.Context, int64, int64, string) (domain.ExamineCaseResult, error)) *ExamineServiceExamineCall {
+func (c *MockExamineServiceExamineCall) Do(f func(context.Context, int64, int64, string) (domain.ExamineCaseResult, error)) *MockExamineServiceExamineCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ExamineServiceExamineCall) DoAndReturn(f func(context.Context, int64, int64, string) (domain.ExamineCaseResult, error)) *ExamineServiceExamineCall {
+func (c *MockExamineServiceExamineCall) DoAndReturn(f func(context.Context, int64, int64, string) (domain.ExamineCaseResult, error)) *MockExamineServiceExamineCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -88,31 +129,31 @@ func (m *MockExamineService) GetResult(ctx context.Context, uid, cid int64) (dom
 }
 
 // GetResult indicates an expected call of GetResult.
-func (mr *MockExamineServiceMockRecorder) GetResult(ctx, uid, cid any) *ExamineServiceGetResultCall {
+func (mr *MockExamineServiceMockRecorder) GetResult(ctx, uid, cid any) *MockExamineServiceGetResultCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResult", reflect.TypeOf((*MockExamineService)(nil).GetResult), ctx, uid, cid)
-	return &ExamineServiceGetResultCall{Call: call}
+	return &MockExamineServiceGetResultCall{Call: call}
 }
 
-// ExamineServiceGetResultCall wrap *gomock.Call
-type ExamineServiceGetResultCall struct {
+// MockExamineServiceGetResultCall wrap *gomock.Call
+type MockExamineServiceGetResultCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ExamineServiceGetResultCall) Return(arg0 domain.CaseResult, arg1 error) *ExamineServiceGetResultCall {
+func (c *MockExamineServiceGetResultCall) Return(arg0 domain.CaseResult, arg1 error) *MockExamineServiceGetResultCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ExamineServiceGetResultCall) Do(f func(context.Context, int64, int64) (domain.CaseResult, error)) *ExamineServiceGetResultCall {
+func (c *MockExamineServiceGetResultCall) Do(f func(context.Context, int64, int64) (domain.CaseResult, error)) *MockExamineServiceGetResultCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ExamineServiceGetResultCall) DoAndReturn(f func(context.Context, int64, int64) (domain.CaseResult, error)) *ExamineServiceGetResultCall {
+func (c *MockExamineServiceGetResultCall) DoAndReturn(f func(context.Context, int64, int64) (domain.CaseResult, error)) *MockExamineServiceGetResultCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -127,31 +168,110 @@ func (m *MockExamineService) GetResults(ctx context.Context, uid int64, ids []in
 }
 
 // GetResults indicates an expected call of GetResults.
-func (mr *MockExamineServiceMockRecorder) GetResults(ctx, uid, ids any) *ExamineServiceGetResultsCall {
+func (mr *MockExamineServiceMockRecorder) GetResults(ctx, uid, ids any) *MockExamineServiceGetResultsCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetResults", reflect.TypeOf((*MockExamineService)(nil).GetResults), ctx, uid, ids)
-	return &ExamineServiceGetResultsCall{Call: call}
+	return &MockExamineServiceGetResultsCall{Call: call}
+}
+
+// MockExamineServiceGetResultsCall wrap *gomock.Call
+type MockExamineServiceGetResultsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockExamineServiceGetResultsCall) Return(arg0 map[int64]domain.ExamineCaseResult, arg1 error) *MockExamineServiceGetResultsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockExamineServiceGetResultsCall) Do(f func(context.Context, int64, []int64) (map[int64]domain.ExamineCaseResult, error)) *MockExamineServiceGetResultsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockExamineServiceGetResultsCall) DoAndReturn(f func(context.Context, int64, []int64) (map[int64]domain.ExamineCaseResult, error)) *MockExamineServiceGetResultsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
 }
 
-// ExamineServiceGetResultsCall wrap *gomock.Call
-type ExamineServiceGetResultsCall struct {
+// Progress mocks base method.
+func (m *MockExamineService) Progress(ctx context.Context, uid int64, cids []int64) ([]domain.ExamineProgress, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Progress", ctx, uid, cids)
+	ret0, _ := ret[0].([]domain.ExamineProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Progress indicates an expected call of Progress.
+func (mr *MockExamineServiceMockRecorder) Progress(ctx, uid, cids any) *MockExamineServiceProgressCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Progress", reflect.TypeOf((*MockExamineService)(nil).Progress), ctx, uid, cids)
+	return &MockExamineServiceProgressCall{Call: call}
+}
+
+// MockExamineServiceProgressCall wrap *gomock.Call
+type MockExamineServiceProgressCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ExamineServiceGetResultsCall) Return(arg0 map[int64]domain.ExamineCaseResult, arg1 error) *ExamineServiceGetResultsCall {
+func (c *MockExamineServiceProgressCall) Return(arg0 []domain.ExamineProgress, arg1 error) *MockExamineServiceProgressCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ExamineServiceGetResultsCall) Do(f func(context.Context, int64, []int64) (map[int64]domain.ExamineCaseResult, error)) *ExamineServiceGetResultsCall {
+func (c *MockExamineServiceProgressCall) Do(f func(context.Context, int64, []int64) ([]domain.ExamineProgress, error)) *MockExamineServiceProgressCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockExamineServiceProgressCall) DoAndReturn(f func(context.Context, int64, []int64) ([]domain.ExamineProgress, error)) *MockExamineServiceProgressCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Records mocks base method.
+func (m *MockExamineService) Records(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.ExamineRecord, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Records", ctx, uid, cid, offset, limit)
+	ret0, _ := ret[0].([]domain.ExamineRecord)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Records indicates an expected call of Records.
+func (mr *MockExamineServiceMockRecorder) Records(ctx, uid, cid, offset, limit any) *MockExamineServiceRecordsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Records", reflect.TypeOf((*MockExamineService)(nil).Records), ctx, uid, cid, offset, limit)
+	return &MockExamineServiceRecordsCall{Call: call}
+}
+
+// MockExamineServiceRecordsCall wrap *gomock.Call
+type MockExamineServiceRecordsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockExamineServiceRecordsCall) Return(arg0 []domain.ExamineRecord, arg1 int64, arg2 error) *MockExamineServiceRecordsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockExamineServiceRecordsCall) Do(f func(context.Context, int64, int64, int, int) ([]domain.ExamineRecord, int64, error)) *MockExamineServiceRecordsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ExamineServiceGetResultsCall) DoAndReturn(f func(context.Context, int64, []int64) (map[int64]domain.ExamineCaseResult, error)) *ExamineServiceGetResultsCall {
+func (c *MockExamineServiceRecordsCall) DoAndReturn(f func(context.Context, int64, int64, int, int) ([]domain.ExamineRecord, int64, error)) *MockExamineServiceRecordsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -39,6 +39,7 @@ type ExamineService = service.ExamineService
 type KnowledgeBaseHandler = web.KnowledgeBaseHandler
 type ExamineResult = domain.ExamineCaseResult
 type ExamineResultEnum = domain.CaseResult
+type ExamineProgress = domain.ExamineProgress
 type Case = domain.Case
 type CaseSet = domain.CaseSet
 type AdminCaseSetHandler = web.AdminCaseSetHandler
@@ -14,12 +14,38 @@
 
 package domain
 
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// BizComment 评论在 interactive 中的 biz
+const BizComment = "comment"
+
 type User struct {
 	ID       int64
 	NickName string
 	Avatar   string
 }
 
+type CommentStatus uint8
+
+func (s CommentStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	CommentStatusUnknown CommentStatus = iota
+	// CommentStatusPending 新发表或者刚修改过的评论，审核通过之前只有作者自己能看到
+	CommentStatusPending
+	CommentStatusApproved
+	CommentStatusRejected
+	// CommentStatusHidden 被举报的次数太多，自动隐藏，等待管理员重新审核
+	CommentStatusHidden
+)
+
 type Comment struct {
 	ID int64
 	// 评论的人
@@ -30,13 +56,120 @@ type Comment struct {
 
 	// 当前评论要回复的父评论ID
 	ParentID int64
+	// 始祖评论ID，直接评论为 0
+	AncestorID int64
 
 	// 评论的具体内容
 	Content string
 
-	// 评论时间，因为评论本身是不允许修改的，所以这个时间基本上就是评论时间
+	Status CommentStatus
+
+	// 评论时间，作者只能在发表之后的一段时间内修改评论
+	Ctime int64
 	Utime int64
 
 	// 展示“始祖评论”的时候，要设置其后裔回复的总数
 	ReplyCount int64
+	LikeCnt    int64
+	// 当前用户是否点赞过
+	Liked bool
+	// 热度，只有直接评论（始祖评论）才有
+	HotScore int64
+	// 直接评论由管理员置顶，回复由始祖评论的作者置顶
+	Pinned bool
+	// 管理员审核的时候要看到待处理的举报的数量
+	ReportCount int64
+}
+
+// mentionPattern 前端选中用户之后插入 @[昵称](uid)，展示的时候渲染成链接
+var mentionPattern = regexp.MustCompile(`@\[([^\]]+)\]\((\d+)\)`)
+
+// maxMentions 一条评论最多通知多少人，避免被用来刷屏
+const maxMentions = 10
+
+// Mentions 评论里面 @ 了的用户，去重并且排除作者自己
+func (c Comment) Mentions() []int64 {
+	matches := mentionPattern.FindAllStringSubmatch(c.Content, -1)
+	res := make([]int64, 0, len(matches))
+	seen := make(map[int64]struct{}, len(matches))
+	for _, m := range matches {
+		uid, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil || uid <= 0 || uid == c.User.ID {
+			continue
+		}
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		res = append(res, uid)
+		if len(res) == maxMentions {
+			break
+		}
+	}
+	return res
+}
+
+// hotEpoch 计算热度的起点，只是为了让数字小一点
+var hotEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local).Unix()
+
+// HotScore 按照点赞数以及回复数计算热度，越新的评论起点越高。
+// 互动数量每多一个数量级，相当于晚发表了 12.5 个小时，所以老的评论会慢慢沉下去。
+// 热度只随着互动变化，不随着时间变化，所以可以存下来并且用来分页。
+// 为了方便比较以及作为游标，放大一百万倍之后取整
+func HotScore(likeCnt, replyCount, ctime int64) int64 {
+	// 回复比点赞更能说明热度
+	n := likeCnt + replyCount*2
+	order := math.Log10(math.Max(float64(n), 1))
+	seconds := float64(ctime/1000 - hotEpoch)
+	return int64(math.Round((order + seconds/45000) * 1e6))
+}
+
+// HotCursor 按照热度排序的时候，热度相同的再按照 ID 倒序排序
+type HotCursor struct {
+	HotScore int64
+	ID       int64
+}
+
+// IsZero 第一页
+func (c HotCursor) IsZero() bool {
+	return c.HotScore == 0 && c.ID == 0
+}
+
+// CommentHistory 评论被修改之前的内容
+type CommentHistory struct {
+	ID      int64
+	Content string
+	// 修改的时间
+	Ctime int64
+}
+
+type ReportReason string
+
+const (
+	ReportReasonSpam    ReportReason = "spam"
+	ReportReasonAbuse   ReportReason = "abuse"
+	ReportReasonPorn    ReportReason = "porn"
+	ReportReasonIllegal ReportReason = "illegal"
+	ReportReasonOther   ReportReason = "other"
+)
+
+func (r ReportReason) Valid() bool {
+	switch r {
+	case ReportReasonSpam, ReportReasonAbuse, ReportReasonPorn,
+		ReportReasonIllegal, ReportReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// Report 用户对评论的举报
+type Report struct {
+	ID        int64
+	CommentID int64
+	Uid       int64
+	Reason    ReportReason
+	// 用户的补充说明
+	Detail string
+	Ctime  int64
 }
@@ -0,0 +1,55 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment_Mentions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		comment Comment
+		want    []int64
+	}{
+		{
+			name:    "没有@",
+			comment: Comment{User: User{ID: 1}, Content: "@张三 这里没有uid"},
+			want:    []int64{},
+		},
+		{
+			name:    "去重并且排除作者",
+			comment: Comment{User: User{ID: 1}, Content: "@[张三](2) @[我](1) 你好 @[李四](3)@[张三](2)"},
+			want:    []int64{2, 3},
+		},
+		{
+			name:    "非法的uid",
+			comment: Comment{User: User{ID: 1}, Content: "@[张三](0) @[李四](99999999999999999999)"},
+			want:    []int64{},
+		},
+		{
+			name: "最多十个",
+			comment: Comment{User: User{ID: 1}, Content: "@[a](2)@[a](3)@[a](4)@[a](5)@[a](6)" +
+				"@[a](7)@[a](8)@[a](9)@[a](10)@[a](11)@[a](12)"},
+			want: []int64{2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.comment.Mentions())
+		})
+	}
+}
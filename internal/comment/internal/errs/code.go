@@ -15,7 +15,13 @@
 package errs
 
 var (
-	SystemError = ErrorCode{Code: 517001, Msg: "系统错误"}
+	SystemError         = ErrorCode{Code: 517001, Msg: "系统错误"}
+	ContentBlocked      = ErrorCode{Code: 517002, Msg: "评论包含敏感信息"}
+	CommentNotFound     = ErrorCode{Code: 517003, Msg: "评论不存在"}
+	EditExpired         = ErrorCode{Code: 517004, Msg: "已经超过可以修改评论的时间"}
+	DuplicateReport     = ErrorCode{Code: 517005, Msg: "你已经举报过这条评论"}
+	InvalidReportReason = ErrorCode{Code: 517006, Msg: "举报原因非法"}
+	PinForbidden        = ErrorCode{Code: 517007, Msg: "只有楼主才能置顶回复"}
 )
 
 type ErrorCode struct {
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./notification_event_producer.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./notification_event_producer.go -package=evtmocks -destination=./mocks/notification.mock.go -typed NotificationEventProducer
+//
+
+// Package evtmocks is a generated GoMock package.
+package evtmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	event "github.com/ecodeclub/webook/internal/notification/event"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationEventProducer is a mock of NotificationEventProducer interface.
+type MockNotificationEventProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationEventProducerMockRecorder
+	isgomock struct{}
+}
+
+// MockNotificationEventProducerMockRecorder is the mock recorder for MockNotificationEventProducer.
+type MockNotificationEventProducerMockRecorder struct {
+	mock *MockNotificationEventProducer
+}
+
+// NewMockNotificationEventProducer creates a new mock instance.
+func NewMockNotificationEventProducer(ctrl *gomock.Controller) *MockNotificationEventProducer {
+	mock := &MockNotificationEventProducer{ctrl: ctrl}
+	mock.recorder = &MockNotificationEventProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationEventProducer) EXPECT() *MockNotificationEventProducerMockRecorder {
+	return m.recorder
+}
+
+// Produce mocks base method.
+func (m *MockNotificationEventProducer) Produce(ctx context.Context, evt event.NotificationEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Produce", ctx, evt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Produce indicates an expected call of Produce.
+func (mr *MockNotificationEventProducerMockRecorder) Produce(ctx, evt any) *MockNotificationEventProducerProduceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockNotificationEventProducer)(nil).Produce), ctx, evt)
+	return &MockNotificationEventProducerProduceCall{Call: call}
+}
+
+// MockNotificationEventProducerProduceCall wrap *gomock.Call
+type MockNotificationEventProducerProduceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockNotificationEventProducerProduceCall) Return(arg0 error) *MockNotificationEventProducerProduceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockNotificationEventProducerProduceCall) Do(f func(context.Context, event.NotificationEvent) error) *MockNotificationEventProducerProduceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockNotificationEventProducerProduceCall) DoAndReturn(f func(context.Context, event.NotificationEvent) error) *MockNotificationEventProducerProduceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
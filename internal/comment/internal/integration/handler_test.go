@@ -28,13 +28,19 @@ import (
 	"time"
 
 	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/comment/internal/domain"
+	"github.com/ecodeclub/webook/internal/comment/internal/errs"
 	evtmocks "github.com/ecodeclub/webook/internal/comment/internal/event/mocks"
 	"github.com/ecodeclub/webook/internal/comment/internal/repository"
 	"github.com/ecodeclub/webook/internal/comment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/comment/internal/service"
 	"github.com/ecodeclub/webook/internal/comment/internal/web"
+	"github.com/ecodeclub/webook/internal/interactive"
+	intrmocks "github.com/ecodeclub/webook/internal/interactive/mocks"
 	"github.com/ecodeclub/webook/internal/notification/event"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/test"
 	testioc "github.com/ecodeclub/webook/internal/test/ioc"
 	"github.com/ecodeclub/webook/internal/user"
@@ -50,9 +56,12 @@ import (
 
 type HandlerTestSuite struct {
 	suite.Suite
-	server *egin.Component
-	db     *egorm.Component
-	dao    dao.CommentDAO
+	server    *egin.Component
+	db        *egorm.Component
+	dao       dao.CommentDAO
+	repo      repository.CommentRepository
+	reportSvc service.ReportService
+	filter    moderation.Filter
 }
 
 const (
@@ -66,6 +75,12 @@ func (s *HandlerTestSuite) SetupSuite() {
 	err := dao.InitTables(s.db)
 	s.NoError(err)
 	s.dao = dao.NewCommentGORMDAO(s.db)
+	s.repo = repository.NewCommentRepository(s.dao, dao.NewReportGORMDAO(s.db))
+	s.reportSvc = service.NewReportService(s.repo, 2)
+	s.filter = moderation.NewDictFilter(moderation.Dict{
+		Block: []string{"赌博"},
+		Mask:  []string{"微信"},
+	}, nil)
 }
 
 func (s *HandlerTestSuite) newGinServer(handler *web.Handler, uid int64) *egin.Component {
@@ -82,6 +97,8 @@ func (s *HandlerTestSuite) newGinServer(handler *web.Handler, uid int64) *egin.C
 
 func (s *HandlerTestSuite) TearDownSuite() {
 	s.NoError(s.db.Exec("TRUNCATE TABLE `comments`").Error)
+	s.NoError(s.db.Exec("TRUNCATE TABLE `comment_histories`").Error)
+	s.NoError(s.db.Exec("TRUNCATE TABLE `comment_reports`").Error)
 }
 
 // 生成唯一的业务ID，避免测试间冲突
@@ -134,8 +151,8 @@ func (s *HandlerTestSuite) TestCreateComment() {
 			assert.NotEmpty(t, event.RawContent)
 			return nil
 		}).Times(1)
-		svc := service.NewCommentService(nil, repository.NewCommentRepository(s.dao))
-		return web.NewHandler(svc, mockProducer)
+		svc := service.NewCommentService(nil, nil, s.repo, s.filter, nil)
+		return web.NewHandler(svc, s.reportSvc, mockProducer)
 	}
 
 	testCases := []struct {
@@ -177,8 +194,8 @@ func (s *HandlerTestSuite) TestCreateComment() {
 				mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event event.WechatRobotEvent) error {
 					return errors.New("fake error")
 				}).Times(1)
-				svc := service.NewCommentService(nil, repository.NewCommentRepository(s.dao))
-				return web.NewHandler(svc, mockProducer)
+				svc := service.NewCommentService(nil, nil, s.repo, s.filter, nil)
+				return web.NewHandler(svc, s.reportSvc, mockProducer)
 
 			},
 			reqFunc: func() web.CreateRequest {
@@ -276,6 +293,43 @@ func (s *HandlerTestSuite) TestCreateComment() {
 				s.NotEqual(int64(0), cmt.AncestorID.V)
 			},
 		},
+		{
+			name:           "敏感词打码",
+			newHandlerFunc: handlerFunc,
+			reqFunc: func() web.CreateRequest {
+				return web.CreateRequest{
+					Comment: web.Comment{
+						Biz:     "article",
+						BizID:   s.getUniqueBizID(),
+						Content: "有问题加我微信",
+					},
+				}
+			},
+			wantCode: 200,
+			after: func(commentID int64) {
+				var cmt dao.Comment
+				err := s.db.First(&cmt, commentID).Error
+				s.NoError(err)
+				s.Equal("有问题加我**", cmt.Content)
+			},
+		},
+		{
+			name:           "敏感词拦截",
+			newHandlerFunc: s.newHandlerWithout3rdDependency,
+			reqFunc: func() web.CreateRequest {
+				return web.CreateRequest{
+					Comment: web.Comment{
+						Biz:     "article",
+						BizID:   s.getUniqueBizID(),
+						Content: "线上赌博",
+					},
+				}
+			},
+			wantCode: 200,
+			after: func(commentID int64) {
+				s.Equal(int64(0), commentID)
+			},
+		},
 		{
 			name:           "无效ParentID回复失败",
 			newHandlerFunc: s.newHandlerWithout3rdDependency,
@@ -336,10 +390,10 @@ func (s *HandlerTestSuite) TestCreateComment() {
 	}
 }
 
-func (s *HandlerTestSuite) newHandlerWithout3rdDependency(t *testing.T, _ *gomock.Controller) *web.Handler {
+func (s *HandlerTestSuite) newHandlerWithout3rdDependency(t *testing.T, ctrl *gomock.Controller) *web.Handler {
 	t.Helper()
-	svc := service.NewCommentService(nil, repository.NewCommentRepository(s.dao))
-	return web.NewHandler(svc, nil)
+	svc := service.NewCommentService(nil, s.newIntrSvc(ctrl), s.repo, s.filter, nil)
+	return web.NewHandler(svc, s.reportSvc, nil)
 }
 
 func (s *HandlerTestSuite) TestCommentList() {
@@ -516,8 +570,16 @@ func (s *HandlerTestSuite) newHandlerWithMockUserServiceOnly(t *testing.T, ctrl
 			}
 			return users, nil
 		}).AnyTimes()
-	svc := service.NewCommentService(mockUserSvc, repository.NewCommentRepository(s.dao))
-	return web.NewHandler(svc, nil)
+	svc := service.NewCommentService(mockUserSvc, s.newIntrSvc(ctrl), s.repo, s.filter, nil)
+	return web.NewHandler(svc, s.reportSvc, nil)
+}
+
+// newIntrSvc 没有任何点赞数据
+func (s *HandlerTestSuite) newIntrSvc(ctrl *gomock.Controller) interactive.Service {
+	mockIntrSvc := intrmocks.NewMockService(ctrl)
+	mockIntrSvc.EXPECT().GetByIds(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(map[int64]interactive.Interactive{}, nil).AnyTimes()
+	return mockIntrSvc
 }
 
 func (s *HandlerTestSuite) TestGetReplies() {
@@ -688,7 +750,7 @@ func (s *HandlerTestSuite) TestDelete() {
 				_, err := s.dao.FindByID(context.Background(), id)
 				s.Error(err)
 
-				descendants, err := s.dao.FindDescendants(context.Background(), id, math.MaxInt64, 100)
+				descendants, err := s.dao.FindDescendants(context.Background(), testUID, id, math.MaxInt64, 100)
 				s.NoError(err)
 				s.Empty(descendants)
 			},
@@ -767,6 +829,547 @@ func (s *HandlerTestSuite) TestDelete() {
 	}
 }
 
+func (s *HandlerTestSuite) TestListVisibility() {
+	biz, bizID := "article", s.getUniqueBizID()
+	approvedID := s.createAncestorComment(biz, bizID)
+	pendingID := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusPending, time.Now())
+	othersPendingID := s.createCommentWithStatus(testUID2, biz, bizID, dao.CommentStatusPending, time.Now())
+	rejectedID := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusRejected, time.Now())
+	hiddenID := s.createCommentWithStatus(testUID2, biz, bizID, dao.CommentStatusHidden, time.Now())
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+	server := s.newGinServer(s.newHandlerWithMockUserServiceOnly(s.T(), ctrl), testUID)
+	httpReq, err := http.NewRequest(http.MethodPost,
+		"/comment/list", iox.NewJSONReader(web.ListRequest{Biz: biz, BizID: bizID, Limit: 10}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[web.CommentList]()
+	server.ServeHTTP(recorder, httpReq)
+	s.Equal(200, recorder.Code)
+
+	// 其他人审核中的、被拒绝的以及被隐藏的评论都看不到
+	result := recorder.MustScan().Data
+	s.Equal(2, result.Total)
+	ids := slice.Map(result.List, func(idx int, src web.Comment) int64 {
+		return src.ID
+	})
+	s.ElementsMatch([]int64{approvedID, pendingID}, ids)
+	s.NotContains(ids, othersPendingID)
+	s.NotContains(ids, rejectedID)
+	s.NotContains(ids, hiddenID)
+}
+
+func (s *HandlerTestSuite) TestEdit() {
+	t := s.T()
+
+	handlerFunc := func(t *testing.T, ctrl *gomock.Controller) *web.Handler {
+		t.Helper()
+		mockProducer := evtmocks.NewMockWechatRobotEventProducer(ctrl)
+		mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		svc := service.NewCommentService(nil, nil, s.repo, s.filter, nil)
+		return web.NewHandler(svc, s.reportSvc, mockProducer)
+	}
+
+	testCases := []struct {
+		name     string
+		before   func() int64
+		content  string
+		wantResp test.Result[any]
+		after    func(id int64)
+	}{
+		{
+			name: "修改成功_重新审核",
+			before: func() int64 {
+				return s.createCommentWithStatus(testUID, "article", s.getUniqueBizID(), dao.CommentStatusApproved, time.Now())
+			},
+			content:  "加我微信聊",
+			wantResp: test.Result[any]{Msg: "OK"},
+			after: func(id int64) {
+				found, err := s.dao.FindByID(context.Background(), id)
+				s.NoError(err)
+				s.Equal("加我**聊", found.Content)
+				s.Equal(dao.CommentStatusPending, found.Status)
+				histories, err := s.dao.FindHistories(context.Background(), id)
+				s.NoError(err)
+				s.Equal(1, len(histories))
+				s.Equal("原始评论", histories[0].Content)
+			},
+		},
+		{
+			name: "超过可以修改的时间",
+			before: func() int64 {
+				return s.createCommentWithStatus(testUID, "article", s.getUniqueBizID(), dao.CommentStatusApproved, time.Now().Add(-time.Hour))
+			},
+			content: "新的内容",
+			wantResp: test.Result[any]{
+				Code: errs.EditExpired.Code,
+				Msg:  errs.EditExpired.Msg,
+			},
+			after: func(id int64) {
+				found, err := s.dao.FindByID(context.Background(), id)
+				s.NoError(err)
+				s.Equal("原始评论", found.Content)
+			},
+		},
+		{
+			name: "不是自己的评论",
+			before: func() int64 {
+				return s.createCommentWithStatus(testUID2, "article", s.getUniqueBizID(), dao.CommentStatusApproved, time.Now())
+			},
+			content: "新的内容",
+			wantResp: test.Result[any]{
+				Code: errs.CommentNotFound.Code,
+				Msg:  errs.CommentNotFound.Msg,
+			},
+			after: func(id int64) {
+				histories, err := s.dao.FindHistories(context.Background(), id)
+				s.NoError(err)
+				s.Empty(histories)
+			},
+		},
+		{
+			name: "包含敏感信息",
+			before: func() int64 {
+				return s.createCommentWithStatus(testUID, "article", s.getUniqueBizID(), dao.CommentStatusApproved, time.Now())
+			},
+			content: "一起来赌博",
+			wantResp: test.Result[any]{
+				Code: errs.ContentBlocked.Code,
+				Msg:  errs.ContentBlocked.Msg,
+			},
+			after: func(id int64) {
+				found, err := s.dao.FindByID(context.Background(), id)
+				s.NoError(err)
+				s.Equal("原始评论", found.Content)
+				s.Equal(dao.CommentStatusApproved, found.Status)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			id := tc.before()
+			httpReq, err := http.NewRequest(http.MethodPost,
+				"/comment/edit", iox.NewJSONReader(web.EditRequest{ID: id, Content: tc.content}))
+			s.NoError(err)
+			httpReq.Header.Set("Content-Type", "application/json")
+			recorder := test.NewJSONResponseRecorder[any]()
+			server := s.newGinServer(handlerFunc(t, ctrl), testUID)
+			server.ServeHTTP(recorder, httpReq)
+
+			s.Equal(200, recorder.Code)
+			s.Equal(tc.wantResp, recorder.MustScan())
+			tc.after(id)
+		})
+	}
+}
+
+func (s *HandlerTestSuite) TestHistory() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc := service.NewCommentService(nil, nil, s.repo, s.filter, nil)
+	hdl := web.NewHandler(svc, s.reportSvc, evtmocks.NewMockWechatRobotEventProducer(ctrl))
+
+	id := s.createCommentWithStatus(testUID, "article", s.getUniqueBizID(), dao.CommentStatusApproved, time.Now())
+	// 修改之后重新进入审核
+	s.NoError(s.repo.Edit(context.Background(), id, testUID, "修改之后的评论"))
+	history := func(id, uid int64) test.Result[[]web.CommentHistory] {
+		httpReq, err := http.NewRequest(http.MethodPost,
+			"/comment/history", iox.NewJSONReader(web.HistoryRequest{ID: id}))
+		s.NoError(err)
+		httpReq.Header.Set("Content-Type", "application/json")
+		recorder := test.NewJSONResponseRecorder[[]web.CommentHistory]()
+		s.newGinServer(hdl, uid).ServeHTTP(recorder, httpReq)
+		s.Equal(200, recorder.Code)
+		return recorder.MustScan()
+	}
+	notFound := test.Result[[]web.CommentHistory]{
+		Code: errs.CommentNotFound.Code,
+		Msg:  errs.CommentNotFound.Msg,
+	}
+
+	// 作者能看到审核中的评论的修改记录
+	res := history(id, testUID)
+	s.Len(res.Data, 1)
+	s.Equal("原始评论", res.Data[0].Content)
+	// 其他人看不到
+	s.Equal(notFound, history(id, testUID2))
+	// 审核通过之后其他人也能看到
+	s.NoError(s.dao.Review(context.Background(), id, dao.CommentStatusApproved))
+	res = history(id, testUID2)
+	s.Len(res.Data, 1)
+	s.Equal("原始评论", res.Data[0].Content)
+	// 评论不存在
+	s.Equal(notFound, history(id+10000, testUID))
+}
+
+func (s *HandlerTestSuite) TestReportAndReview() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockProducer := evtmocks.NewMockWechatRobotEventProducer(ctrl)
+	// 被隐藏的时候通知管理员
+	mockProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockUserSvc := usermocks.NewMockUserService(ctrl)
+	mockUserSvc.EXPECT().BatchProfile(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	svc := service.NewCommentService(mockUserSvc, nil, s.repo, s.filter, nil)
+	hdl := web.NewHandler(svc, s.reportSvc, mockProducer)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	adminServer := egin.Load("server").Build()
+	web.NewAdminHandler(svc, s.reportSvc).PrivateRoutes(adminServer.Engine)
+
+	id := s.createAncestorComment("article", s.getUniqueBizID())
+	report := func(uid int64, reason string) test.Result[any] {
+		httpReq, err := http.NewRequest(http.MethodPost,
+			"/comment/report", iox.NewJSONReader(web.ReportRequest{ID: id, Reason: reason, Detail: "测试"}))
+		s.NoError(err)
+		httpReq.Header.Set("Content-Type", "application/json")
+		recorder := test.NewJSONResponseRecorder[any]()
+		s.newGinServer(hdl, uid).ServeHTTP(recorder, httpReq)
+		s.Equal(200, recorder.Code)
+		return recorder.MustScan()
+	}
+	status := func() uint8 {
+		found, err := s.dao.FindByID(context.Background(), id)
+		s.NoError(err)
+		return found.Status
+	}
+
+	s.Equal(errs.InvalidReportReason.Code, report(testUID, "unknown").Code)
+	s.Equal(test.Result[any]{Msg: "OK"}, report(testUID, "spam"))
+	s.Equal(errs.DuplicateReport.Code, report(testUID, "abuse").Code)
+	s.Equal(dao.CommentStatusApproved, status())
+	// 达到阈值之后隐藏
+	s.Equal(test.Result[any]{Msg: "OK"}, report(testUID2, "abuse"))
+	s.Equal(dao.CommentStatusHidden, status())
+
+	httpReq, err := http.NewRequest(http.MethodPost,
+		"/comment/review/list", iox.NewJSONReader(web.ReviewListRequest{Status: dao.CommentStatusHidden, Limit: 10}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	listRecorder := test.NewJSONResponseRecorder[web.CommentList]()
+	adminServer.ServeHTTP(listRecorder, httpReq)
+	s.Equal(200, listRecorder.Code)
+	list := listRecorder.MustScan().Data
+	s.Equal(1, list.Total)
+	s.Equal(id, list.List[0].ID)
+	s.Equal(int64(2), list.List[0].ReportCount)
+
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/report/list", iox.NewJSONReader(web.ReportsRequest{ID: id}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	reportsRecorder := test.NewJSONResponseRecorder[[]web.Report]()
+	adminServer.ServeHTTP(reportsRecorder, httpReq)
+	reports := reportsRecorder.MustScan().Data
+	s.Equal(2, len(reports))
+	s.Equal(testUID2, reports[0].Uid)
+	s.Equal("abuse", reports[0].Reason)
+
+	// 审核通过之后重新展示，之前的举报不再计数
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/review/approve", iox.NewJSONReader(web.ReviewRequest{ID: id}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[any]()
+	adminServer.ServeHTTP(recorder, httpReq)
+	s.Equal(test.Result[any]{Msg: "OK"}, recorder.MustScan())
+	s.Equal(dao.CommentStatusApproved, status())
+	s.Equal(test.Result[any]{Msg: "OK"}, report(testUID3, "porn"))
+	s.Equal(dao.CommentStatusApproved, status())
+
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/review/reject", iox.NewJSONReader(web.ReviewRequest{ID: id}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder = test.NewJSONResponseRecorder[any]()
+	adminServer.ServeHTTP(recorder, httpReq)
+	s.Equal(test.Result[any]{Msg: "OK"}, recorder.MustScan())
+	s.Equal(dao.CommentStatusRejected, status())
+
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/review/reject", iox.NewJSONReader(web.ReviewRequest{ID: -1}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder = test.NewJSONResponseRecorder[any]()
+	adminServer.ServeHTTP(recorder, httpReq)
+	s.Equal(errs.CommentNotFound.Code, recorder.MustScan().Code)
+}
+
+func (s *HandlerTestSuite) TestHotAndPin() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	hdl := s.newHandlerWithMockUserServiceOnly(t, ctrl)
+	svc := service.NewCommentService(nil, nil, s.repo, s.filter, nil)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	adminServer := egin.Load("server").Build()
+	web.NewAdminHandler(svc, s.reportSvc).PrivateRoutes(adminServer.Engine)
+
+	biz, bizID := "article", s.getUniqueBizID()
+	id1 := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusApproved, time.Now())
+	id2 := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusApproved, time.Now())
+	id3 := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusApproved, time.Now())
+	// id2 和 id3 的热度一样，按照 ID 倒序
+	for id, score := range map[int64]int64{id1: 100, id2: 200, id3: 200} {
+		s.NoError(s.dao.UpdateHotScore(context.Background(), id, score))
+	}
+
+	post := func(server *egin.Component, path string, req any) test.Result[web.CommentList] {
+		httpReq, err := http.NewRequest(http.MethodPost, path, iox.NewJSONReader(req))
+		s.NoError(err)
+		httpReq.Header.Set("Content-Type", "application/json")
+		recorder := test.NewJSONResponseRecorder[web.CommentList]()
+		server.ServeHTTP(recorder, httpReq)
+		s.Equal(200, recorder.Code)
+		return recorder.MustScan()
+	}
+	hot := func(cursor string) web.CommentList {
+		return post(s.newGinServer(hdl, testUID), "/comment/list", web.ListRequest{
+			Biz: biz, BizID: bizID, Sort: web.SortHot, Cursor: cursor, Limit: 2,
+		}).Data
+	}
+	ids := func(list web.CommentList) []int64 {
+		return slice.Map(list.List, func(idx int, src web.Comment) int64 {
+			return src.ID
+		})
+	}
+
+	page := hot("")
+	s.Equal([]int64{id3, id2}, ids(page))
+	s.Equal(3, page.Total)
+	s.Equal(fmt.Sprintf("200_%d", id2), page.Cursor)
+	page = hot(page.Cursor)
+	s.Equal([]int64{id1}, ids(page))
+
+	// 管理员置顶之后，只在第一页出现，并且不参与排序
+	res := post(adminServer, "/comment/pin", web.PinRequest{ID: id1, Pinned: true})
+	s.Equal("OK", res.Msg)
+	page = hot("")
+	s.Equal([]int64{id1, id3, id2}, ids(page))
+	s.True(page.List[0].Pinned)
+	s.Equal(fmt.Sprintf("200_%d", id2), page.Cursor)
+	page = hot(page.Cursor)
+	s.Empty(page.List)
+	// 按照时间排序也一样
+	page = post(s.newGinServer(hdl, testUID), "/comment/list", web.ListRequest{
+		Biz: biz, BizID: bizID, Limit: 10,
+	}).Data
+	s.Equal([]int64{id1, id3, id2}, ids(page))
+
+	// 只有楼主可以置顶回复
+	reply1 := s.createReplyComment(id3, id3, "回复1")
+	reply2 := s.createReplyComment(id3, id3, "回复2")
+	res = post(s.newGinServer(hdl, testUID2), "/comment/pin", web.PinRequest{ID: reply1, Pinned: true})
+	s.Equal(errs.PinForbidden.Code, res.Code)
+	res = post(s.newGinServer(hdl, testUID), "/comment/pin", web.PinRequest{ID: id3, Pinned: true})
+	s.Equal(errs.PinForbidden.Code, res.Code)
+	res = post(s.newGinServer(hdl, testUID), "/comment/pin", web.PinRequest{ID: reply1, Pinned: true})
+	s.Equal("OK", res.Msg)
+	page = post(s.newGinServer(hdl, testUID), "/comment/replies", web.RepliesRequest{
+		AncestorID: id3, Limit: 10,
+	}).Data
+	s.Equal([]int64{reply1, reply2}, ids(page))
+	s.True(page.List[0].Pinned)
+
+	// 同一个楼里面只能有一条置顶的回复
+	res = post(s.newGinServer(hdl, testUID), "/comment/pin", web.PinRequest{ID: reply2, Pinned: true})
+	s.Equal("OK", res.Msg)
+	page = post(s.newGinServer(hdl, testUID), "/comment/replies", web.RepliesRequest{
+		AncestorID: id3, Limit: 10,
+	}).Data
+	s.Equal([]int64{reply2, reply1}, ids(page))
+	s.True(page.List[0].Pinned)
+	s.False(page.List[1].Pinned)
+}
+
+func (s *HandlerTestSuite) TestLikeToggle() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	biz, bizID := "article", s.getUniqueBizID()
+	id := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusApproved, time.Now())
+
+	mockIntrSvc := intrmocks.NewMockService(ctrl)
+	mockIntrSvc.EXPECT().LikeToggle(gomock.Any(), "comment", id, testUID2).Return(nil)
+	mockIntrSvc.EXPECT().Get(gomock.Any(), "comment", id, testUID2).
+		Return(interactive.Interactive{Biz: "comment", BizId: id, LikeCnt: 3, Liked: true}, nil)
+	mockIntrSvc.EXPECT().GetByIds(gomock.Any(), "comment", testUID2, []int64{id}).
+		Return(map[int64]interactive.Interactive{
+			id: {Biz: "comment", BizId: id, LikeCnt: 3, Liked: true},
+		}, nil)
+	mockUserSvc := usermocks.NewMockUserService(ctrl)
+	mockUserSvc.EXPECT().BatchProfile(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	svc := service.NewCommentService(mockUserSvc, mockIntrSvc, s.repo, s.filter, nil)
+	server := s.newGinServer(web.NewHandler(svc, s.reportSvc, nil), testUID2)
+
+	httpReq, err := http.NewRequest(http.MethodPost,
+		"/comment/like/toggle", iox.NewJSONReader(web.LikeToggleRequest{ID: id}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[web.LikeToggleResp]()
+	server.ServeHTTP(recorder, httpReq)
+	s.Equal(200, recorder.Code)
+	s.Equal(int64(3), recorder.MustScan().Data.LikeCnt)
+
+	// 点赞数同步过来了，热度也跟着变了
+	found, err := s.dao.FindByID(context.Background(), id)
+	s.NoError(err)
+	s.Equal(int64(3), found.LikeCnt)
+	s.Greater(found.HotScore, int64(0))
+
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/list", iox.NewJSONReader(web.ListRequest{Biz: biz, BizID: bizID, Limit: 10}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	listRecorder := test.NewJSONResponseRecorder[web.CommentList]()
+	server.ServeHTTP(listRecorder, httpReq)
+	s.Equal(200, listRecorder.Code)
+	list := listRecorder.MustScan().Data
+	s.Equal(1, len(list.List))
+	s.Equal(int64(3), list.List[0].LikeCnt)
+	s.True(list.List[0].Liked)
+
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/like/toggle", iox.NewJSONReader(web.LikeToggleRequest{ID: -1}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	errRecorder := test.NewJSONResponseRecorder[any]()
+	server.ServeHTTP(errRecorder, httpReq)
+	s.Equal(errs.CommentNotFound.Code, errRecorder.MustScan().Code)
+
+	// 还在审核中的评论不能点赞
+	pending := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusPending, time.Now())
+	httpReq, err = http.NewRequest(http.MethodPost,
+		"/comment/like/toggle", iox.NewJSONReader(web.LikeToggleRequest{ID: pending}))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	errRecorder = test.NewJSONResponseRecorder[any]()
+	server.ServeHTTP(errRecorder, httpReq)
+	s.Equal(errs.CommentNotFound.Code, errRecorder.MustScan().Code)
+}
+
+func (s *HandlerTestSuite) TestBackfillHotScore() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	biz, bizID := "article", s.getUniqueBizID()
+	ctime := time.Now().Add(-time.Hour)
+	id := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusApproved, ctime)
+	s.createReplyComment(id, id, "回复1")
+	s.createReplyComment(id, id, "回复2")
+	// 上线之前的评论，热度和点赞数都是 0
+	s.NoError(s.dao.UpdateHotScore(context.Background(), id, 0))
+
+	mockIntrSvc := intrmocks.NewMockService(ctrl)
+	mockIntrSvc.EXPECT().GetByIds(gomock.Any(), "comment", int64(0), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, biz string, uid int64, ids []int64) (map[int64]interactive.Interactive, error) {
+			return map[int64]interactive.Interactive{
+				id: {Biz: "comment", BizId: id, LikeCnt: 5},
+			}, nil
+		}).AnyTimes()
+	svc := service.NewCommentService(nil, mockIntrSvc, s.repo, s.filter, nil)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	adminServer := egin.Load("server").Build()
+	web.NewAdminHandler(svc, s.reportSvc).PrivateRoutes(adminServer.Engine)
+
+	httpReq, err := http.NewRequest(http.MethodPost, "/comment/hot/backfill", iox.NewJSONReader(nil))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[web.BackfillHotScoreResp]()
+	adminServer.ServeHTTP(recorder, httpReq)
+	s.Equal(200, recorder.Code)
+	s.GreaterOrEqual(recorder.MustScan().Data.Total, int64(1))
+
+	found, err := s.dao.FindByID(context.Background(), id)
+	s.NoError(err)
+	s.Equal(int64(5), found.LikeCnt)
+	s.Equal(domain.HotScore(5, 2, found.Ctime), found.HotScore)
+}
+
+func (s *HandlerTestSuite) TestApproveNotify() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	biz, bizID := "article", s.getUniqueBizID()
+	parentID := s.createCommentWithStatus(testUID, biz, bizID, dao.CommentStatusApproved, time.Now())
+	content := fmt.Sprintf("@[用户1](%d) @[用户3](%d) @[自己](%d) 你好", testUID, testUID3, testUID2)
+	reply := dao.Comment{
+		Uid:        testUID2,
+		Biz:        biz,
+		BizID:      bizID,
+		ParentID:   sql.Null[int64]{V: parentID, Valid: true},
+		AncestorID: sql.Null[int64]{V: parentID, Valid: true},
+		Content:    content,
+		Status:     dao.CommentStatusPending,
+		Ctime:      time.Now().UnixMilli(),
+		Utime:      time.Now().UnixMilli(),
+	}
+	s.NoError(s.db.Create(&reply).Error)
+
+	mockProducer := evtmocks.NewMockNotificationEventProducer(ctrl)
+	// 楼主收到回复的通知，用户3收到 @ 的通知，楼主不会重复收到 @ 的通知
+	mockProducer.EXPECT().Produce(gomock.Any(), event.NotificationEvent{
+		Receivers: []int64{testUID},
+		Sender:    testUID2,
+		Type:      event.NotificationTypeReply,
+		Biz:       biz,
+		BizID:     bizID,
+		SourceID:  reply.ID,
+		Content:   content,
+	}).Return(nil)
+	mockProducer.EXPECT().Produce(gomock.Any(), event.NotificationEvent{
+		Receivers: []int64{testUID3},
+		Sender:    testUID2,
+		Type:      event.NotificationTypeMention,
+		Biz:       biz,
+		BizID:     bizID,
+		SourceID:  reply.ID,
+		Content:   content,
+	}).Return(errors.New("mock error"))
+	svc := service.NewCommentService(nil, nil, s.repo, s.filter, mockProducer)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	adminServer := egin.Load("server").Build()
+	web.NewAdminHandler(svc, s.reportSvc).PrivateRoutes(adminServer.Engine)
+
+	approve := func(id int64) test.Result[any] {
+		httpReq, err := http.NewRequest(http.MethodPost,
+			"/comment/review/approve", iox.NewJSONReader(web.ReviewRequest{ID: id}))
+		s.NoError(err)
+		httpReq.Header.Set("Content-Type", "application/json")
+		recorder := test.NewJSONResponseRecorder[any]()
+		adminServer.ServeHTTP(recorder, httpReq)
+		s.Equal(200, recorder.Code)
+		return recorder.MustScan()
+	}
+	// 发送通知失败不影响审核
+	s.Equal(test.Result[any]{Msg: "OK"}, approve(reply.ID))
+	// 已经审核通过的再次审核不会重复通知
+	s.Equal(test.Result[any]{Msg: "OK"}, approve(reply.ID))
+}
+
+func (s *HandlerTestSuite) createCommentWithStatus(uid int64, biz string, bizID int64, status uint8, ctime time.Time) int64 {
+	cmt := dao.Comment{
+		Uid:     uid,
+		Biz:     biz,
+		BizID:   bizID,
+		Content: "原始评论",
+		Status:  status,
+		Ctime:   ctime.UnixMilli(),
+		Utime:   ctime.UnixMilli(),
+	}
+	err := s.db.Create(&cmt).Error
+	s.NoError(err)
+	return cmt.ID
+}
+
 func TestHandler(t *testing.T) {
 	suite.Run(t, new(HandlerTestSuite))
 }
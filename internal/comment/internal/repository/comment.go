@@ -26,24 +26,65 @@ import (
 type CommentRepository interface {
 	// Create 创建直接评论（始祖评论），子评论及孙子评论
 	Create(ctx context.Context, comment domain.Comment) (int64, error)
-	// FindAncestors 查找某一业务下的所有直接评论（始祖评论）评论时间的倒序
-	FindAncestors(ctx context.Context, biz string, bizID, minID int64, limit int) ([]domain.Comment, error)
+	// FindAncestors 查找某一业务下的所有直接评论（始祖评论）评论时间的倒序，uid 是当前用户
+	FindAncestors(ctx context.Context, uid int64, biz string, bizID, minID int64, limit int) ([]domain.Comment, error)
 	// CountAncestors 统计某一业务下所有直接评论（始祖评论）的数量
-	CountAncestors(ctx context.Context, biz string, bizID int64) (int64, error)
+	CountAncestors(ctx context.Context, uid int64, biz string, bizID int64) (int64, error)
 	// FindDescendants 查找直接评论（始祖评论）所有后代即所有子评论，孙子评论，按照评论时间倒序排序（即后评论的在前面）
-	FindDescendants(ctx context.Context, ancestorID, minID int64, limit int) ([]domain.Comment, error)
+	FindDescendants(ctx context.Context, uid int64, ancestorID, minID int64, limit int) ([]domain.Comment, error)
 	// CountDescendants 统计直接评论（始祖评论）所有后代即所有子评论，孙子评论的数量
-	CountDescendants(ctx context.Context, ancestorID int64) (int64, error)
+	CountDescendants(ctx context.Context, uid int64, ancestorID int64) (int64, error)
+	// FindByID 根据评论ID查找评论
+	FindByID(ctx context.Context, id int64) (domain.Comment, error)
+	// FindHotAncestors 按照热度倒序排序，从 cursor 之后开始
+	FindHotAncestors(ctx context.Context, uid int64, biz string, bizID int64, cursor domain.HotCursor, limit int) ([]domain.Comment, error)
+	// FindPinnedAncestors 某一业务下置顶的直接评论（始祖评论）
+	FindPinnedAncestors(ctx context.Context, uid int64, biz string, bizID int64) ([]domain.Comment, error)
+	// FindPinnedDescendants 始祖评论下置顶的回复
+	FindPinnedDescendants(ctx context.Context, uid int64, ancestorID int64) ([]domain.Comment, error)
+	// Pin 置顶或者取消置顶，同一个范围内只能有一个置顶
+	Pin(ctx context.Context, id int64, pinned bool) error
+	UpdateLikeCnt(ctx context.Context, id, likeCnt int64) error
+	// RefreshHotScore 按照最新的点赞数以及回复数重新计算始祖评论的热度
+	RefreshHotScore(ctx context.Context, id int64) error
+	// ScanAncestors 按照ID升序遍历所有的始祖评论，带上审核通过的回复数量
+	ScanAncestors(ctx context.Context, minID int64, limit int) ([]domain.Comment, error)
+	// UpdateHotScore 同时更新点赞数以及热度
+	UpdateHotScore(ctx context.Context, id, likeCnt, hotScore int64) error
 	// Delete 根据ID删除评论及其后裔评论
 	Delete(ctx context.Context, id, uid int64) error
+	// FindByUID 查找用户发表的所有评论，按照评论ID升序排序
+	FindByUID(ctx context.Context, uid int64, offset, limit int) ([]domain.Comment, error)
+	// AnonymizeByUID 匿名化用户发表的所有评论
+	AnonymizeByUID(ctx context.Context, uid int64, content string) error
+
+	// Edit 修改评论的内容并且重新进入审核，保留修改之前的内容
+	Edit(ctx context.Context, id, uid int64, content string) error
+	// FindHistories 评论修改之前的内容，按照修改时间倒序排序
+	FindHistories(ctx context.Context, id int64) ([]domain.CommentHistory, error)
+	// FindByStatus 管理员审核用，带上待处理的举报的数量
+	FindByStatus(ctx context.Context, status domain.CommentStatus, offset, limit int) ([]domain.Comment, int64, error)
+	// Review 管理员审核评论，同时把评论收到的举报都标记为已处理
+	Review(ctx context.Context, id int64, status domain.CommentStatus) error
+
+	// CreateReport 返回评论是否因为这次举报被隐藏
+	CreateReport(ctx context.Context, r domain.Report, threshold int64) (bool, error)
+	// FindReports 评论收到的所有举报，按照举报时间倒序排序
+	FindReports(ctx context.Context, commentID int64) ([]domain.Report, error)
 }
 
+var (
+	ErrRecordNotFound  = dao.ErrRecordNotFound
+	ErrDuplicateReport = dao.ErrDuplicateReport
+)
+
 type commentRepository struct {
-	dao dao.CommentDAO
+	dao       dao.CommentDAO
+	reportDAO dao.ReportDAO
 }
 
-func NewCommentRepository(dao dao.CommentDAO) CommentRepository {
-	return &commentRepository{dao: dao}
+func NewCommentRepository(dao dao.CommentDAO, reportDAO dao.ReportDAO) CommentRepository {
+	return &commentRepository{dao: dao, reportDAO: reportDAO}
 }
 
 func (r *commentRepository) Create(ctx context.Context, comment domain.Comment) (int64, error) {
@@ -58,32 +99,48 @@ func (r *commentRepository) toEntity(comment domain.Comment) dao.Comment {
 		BizID:    comment.BizID,
 		ParentID: sql.Null[int64]{V: comment.ParentID, Valid: comment.ParentID != 0},
 		Content:  comment.Content,
+		Status:   comment.Status.ToUint8(),
+		HotScore: comment.HotScore,
 	}
 }
 
 func (r *commentRepository) toDomain(comment dao.Comment) domain.Comment {
-	var parentID int64
+	var parentID, ancestorID int64
 	if comment.ParentID.Valid {
 		parentID = comment.ParentID.V
 	}
+	if comment.AncestorID.Valid {
+		ancestorID = comment.AncestorID.V
+	}
 	return domain.Comment{
 		ID: comment.ID,
 		User: domain.User{
 			ID: comment.Uid,
 		},
-		Biz:      comment.Biz,
-		BizID:    comment.BizID,
-		ParentID: parentID,
-		Content:  comment.Content,
-		Utime:    comment.Utime,
+		Biz:        comment.Biz,
+		BizID:      comment.BizID,
+		ParentID:   parentID,
+		AncestorID: ancestorID,
+		Content:    comment.Content,
+		Status:     domain.CommentStatus(comment.Status),
+		LikeCnt:    comment.LikeCnt,
+		HotScore:   comment.HotScore,
+		Pinned:     comment.PinnedAt > 0,
+		Ctime:      comment.Ctime,
+		Utime:      comment.Utime,
 	}
 }
 
-func (r *commentRepository) FindAncestors(ctx context.Context, biz string, bizID, minID int64, limit int) ([]domain.Comment, error) {
-	ancestors, err := r.dao.FindAncestors(ctx, biz, bizID, minID, limit)
+func (r *commentRepository) FindAncestors(ctx context.Context, uid int64, biz string, bizID, minID int64, limit int) ([]domain.Comment, error) {
+	ancestors, err := r.dao.FindAncestors(ctx, uid, biz, bizID, minID, limit)
 	if err != nil {
 		return nil, err
 	}
+	return r.withReplyCount(ctx, ancestors)
+}
+
+// withReplyCount 始祖评论要带上回复的数量
+func (r *commentRepository) withReplyCount(ctx context.Context, ancestors []dao.Comment) ([]domain.Comment, error) {
 	ancestorIDs := make([]int64, 0, len(ancestors))
 	comments := slice.Map(ancestors, func(_ int, src dao.Comment) domain.Comment {
 		ancestorIDs = append(ancestorIDs, src.ID)
@@ -98,12 +155,12 @@ func (r *commentRepository) FindAncestors(ctx context.Context, biz string, bizID
 	return comments, err
 }
 
-func (r *commentRepository) CountAncestors(ctx context.Context, biz string, bizID int64) (int64, error) {
-	return r.dao.CountAncestors(ctx, biz, bizID)
+func (r *commentRepository) CountAncestors(ctx context.Context, uid int64, biz string, bizID int64) (int64, error) {
+	return r.dao.CountAncestors(ctx, uid, biz, bizID)
 }
 
-func (r *commentRepository) FindDescendants(ctx context.Context, ancestorID, minID int64, limit int) ([]domain.Comment, error) {
-	found, err := r.dao.FindDescendants(ctx, ancestorID, minID, limit)
+func (r *commentRepository) FindDescendants(ctx context.Context, uid int64, ancestorID, minID int64, limit int) ([]domain.Comment, error) {
+	found, err := r.dao.FindDescendants(ctx, uid, ancestorID, minID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -113,10 +170,151 @@ func (r *commentRepository) FindDescendants(ctx context.Context, ancestorID, min
 	}), nil
 }
 
-func (r *commentRepository) CountDescendants(ctx context.Context, ancestorID int64) (int64, error) {
-	return r.dao.CountDescendants(ctx, ancestorID)
+func (r *commentRepository) CountDescendants(ctx context.Context, uid int64, ancestorID int64) (int64, error) {
+	return r.dao.CountDescendants(ctx, uid, ancestorID)
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, id int64) (domain.Comment, error) {
+	c, err := r.dao.FindByID(ctx, id)
+	return r.toDomain(c), err
 }
 
 func (r *commentRepository) Delete(ctx context.Context, id, uid int64) error {
 	return r.dao.Delete(ctx, id, uid)
 }
+
+func (r *commentRepository) FindByUID(ctx context.Context, uid int64, offset, limit int) ([]domain.Comment, error) {
+	found, err := r.dao.FindByUID(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(found, func(_ int, src dao.Comment) domain.Comment {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *commentRepository) AnonymizeByUID(ctx context.Context, uid int64, content string) error {
+	return r.dao.AnonymizeByUID(ctx, uid, content)
+}
+
+func (r *commentRepository) Edit(ctx context.Context, id, uid int64, content string) error {
+	return r.dao.Edit(ctx, id, uid, content)
+}
+
+func (r *commentRepository) FindHistories(ctx context.Context, id int64) ([]domain.CommentHistory, error) {
+	found, err := r.dao.FindHistories(ctx, id)
+	return slice.Map(found, func(_ int, src dao.CommentHistory) domain.CommentHistory {
+		return domain.CommentHistory{
+			ID:      src.ID,
+			Content: src.Content,
+			Ctime:   src.Ctime,
+		}
+	}), err
+}
+
+func (r *commentRepository) FindByStatus(ctx context.Context, status domain.CommentStatus, offset, limit int) ([]domain.Comment, int64, error) {
+	found, err := r.dao.FindByStatus(ctx, status.ToUint8(), offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := r.dao.CountByStatus(ctx, status.ToUint8())
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int64, 0, len(found))
+	comments := slice.Map(found, func(_ int, src dao.Comment) domain.Comment {
+		ids = append(ids, src.ID)
+		return r.toDomain(src)
+	})
+	counts, err := r.reportDAO.BatchCountPending(ctx, ids)
+	for i := range comments {
+		comments[i].ReportCount = counts[comments[i].ID]
+	}
+	return comments, total, err
+}
+
+func (r *commentRepository) Review(ctx context.Context, id int64, status domain.CommentStatus) error {
+	return r.dao.Review(ctx, id, status.ToUint8())
+}
+
+func (r *commentRepository) CreateReport(ctx context.Context, report domain.Report, threshold int64) (bool, error) {
+	return r.reportDAO.Create(ctx, dao.CommentReport{
+		CommentID: report.CommentID,
+		Uid:       report.Uid,
+		Reason:    string(report.Reason),
+		Detail:    report.Detail,
+	}, threshold)
+}
+
+func (r *commentRepository) FindReports(ctx context.Context, commentID int64) ([]domain.Report, error) {
+	found, err := r.reportDAO.FindByCommentID(ctx, commentID)
+	return slice.Map(found, func(_ int, src dao.CommentReport) domain.Report {
+		return domain.Report{
+			ID:        src.ID,
+			CommentID: src.CommentID,
+			Uid:       src.Uid,
+			Reason:    domain.ReportReason(src.Reason),
+			Detail:    src.Detail,
+			Ctime:     src.Ctime,
+		}
+	}), err
+}
+
+func (r *commentRepository) FindHotAncestors(ctx context.Context, uid int64, biz string, bizID int64, cursor domain.HotCursor, limit int) ([]domain.Comment, error) {
+	ancestors, err := r.dao.FindHotAncestors(ctx, uid, biz, bizID, cursor.HotScore, cursor.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.withReplyCount(ctx, ancestors)
+}
+
+func (r *commentRepository) FindPinnedAncestors(ctx context.Context, uid int64, biz string, bizID int64) ([]domain.Comment, error) {
+	ancestors, err := r.dao.FindPinnedAncestors(ctx, uid, biz, bizID)
+	if err != nil {
+		return nil, err
+	}
+	return r.withReplyCount(ctx, ancestors)
+}
+
+func (r *commentRepository) FindPinnedDescendants(ctx context.Context, uid int64, ancestorID int64) ([]domain.Comment, error) {
+	found, err := r.dao.FindPinnedDescendants(ctx, uid, ancestorID)
+	return slice.Map(found, func(_ int, src dao.Comment) domain.Comment {
+		return r.toDomain(src)
+	}), err
+}
+
+func (r *commentRepository) Pin(ctx context.Context, id int64, pinned bool) error {
+	return r.dao.Pin(ctx, id, pinned)
+}
+
+func (r *commentRepository) UpdateLikeCnt(ctx context.Context, id, likeCnt int64) error {
+	return r.dao.UpdateLikeCnt(ctx, id, likeCnt)
+}
+
+func (r *commentRepository) ScanAncestors(ctx context.Context, minID int64, limit int) ([]domain.Comment, error) {
+	ancestors, err := r.dao.ScanAncestors(ctx, minID, limit)
+	if err != nil || len(ancestors) == 0 {
+		return nil, err
+	}
+	return r.withReplyCount(ctx, ancestors)
+}
+
+func (r *commentRepository) UpdateHotScore(ctx context.Context, id, likeCnt, hotScore int64) error {
+	err := r.dao.UpdateLikeCnt(ctx, id, likeCnt)
+	if err != nil {
+		return err
+	}
+	return r.dao.UpdateHotScore(ctx, id, hotScore)
+}
+
+func (r *commentRepository) RefreshHotScore(ctx context.Context, id int64) error {
+	c, err := r.dao.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	counts, err := r.dao.BatchCountDescendants(ctx, []int64{id})
+	if err != nil {
+		return err
+	}
+	return r.dao.UpdateHotScore(ctx, id, domain.HotScore(c.LikeCnt, counts[id], c.Ctime))
+}
@@ -28,6 +28,16 @@ import (
 
 var (
 	ErrInvalidParentID = errors.New("父评论ID非法")
+	ErrRecordNotFound  = gorm.ErrRecordNotFound
+)
+
+const uniqueIndexErrNo uint16 = 1062
+
+const (
+	CommentStatusPending uint8 = iota + 1
+	CommentStatusApproved
+	CommentStatusRejected
+	CommentStatusHidden
 )
 
 // Comment 表示针对某一资源的评论
@@ -49,6 +59,13 @@ type Comment struct {
 	// 外键用于级联删除后裔评论（子评论、子孙评论）
 	ParentComment *Comment `gorm:"ForeignKey:ParentID;AssociationForeignKey:ID;constraint:OnDelete:CASCADE"`
 
+	// 之前的评论都是直接发布的，所以默认是审核通过
+	Status uint8 `gorm:"type:tinyint(3);not null;default:2;index;comment:'1-待审核 2-审核通过 3-审核拒绝 4-被举报之后隐藏'"`
+
+	LikeCnt  int64 `gorm:"not null;default:0;comment:'点赞数，从 interactive 同步过来'"`
+	HotScore int64 `gorm:"not null;default:0;index;comment:'热度，只有始祖评论才有'"`
+	PinnedAt int64 `gorm:"not null;default:0;comment:'置顶的时间，0 表示没有置顶'"`
+
 	Utime int64
 	Ctime int64
 }
@@ -57,23 +74,63 @@ func (Comment) TableName() string {
 	return "comments"
 }
 
+// CommentHistory 评论被修改之前的内容
+type CommentHistory struct {
+	ID        int64  `gorm:"autoIncrement,primaryKey"`
+	CommentID int64  `gorm:"not null;index;comment:'评论ID'"`
+	Content   string `gorm:"type:text;not null;comment:'修改之前的内容'"`
+	Ctime     int64
+}
+
+func (CommentHistory) TableName() string {
+	return "comment_histories"
+}
+
+// CommentDAO 查询评论的时候，uid 是当前用户。
+// 除了审核通过的评论，用户还能看到自己还在审核中的评论，其他人看不到
 type CommentDAO interface {
 	// Create 创建直接评论（始祖评论），子评论及孙子评论
 	Create(ctx context.Context, comment Comment) (int64, error)
 	// FindAncestors 查找某一业务下的所有直接评论（始祖评论），按评论时间的倒序排序
-	FindAncestors(ctx context.Context, biz string, bizID, minID int64, limit int) ([]Comment, error)
+	FindAncestors(ctx context.Context, uid int64, biz string, bizID, minID int64, limit int) ([]Comment, error)
 	// CountAncestors 统计某一业务下所有直接评论（始祖评论）的数量
-	CountAncestors(ctx context.Context, biz string, bizID int64) (int64, error)
+	CountAncestors(ctx context.Context, uid int64, biz string, bizID int64) (int64, error)
 	// FindDescendants 查找直接评论（始祖评论）所有后代即所有子评论，孙子评论，按照评论时间倒序排序（即后评论的在前面）
-	FindDescendants(ctx context.Context, ancestorID, minID int64, limit int) ([]Comment, error)
+	FindDescendants(ctx context.Context, uid int64, ancestorID, minID int64, limit int) ([]Comment, error)
 	// CountDescendants 统计直接评论（始祖评论）所有后代即所有子评论，孙子评论的数量
-	CountDescendants(ctx context.Context, ancestorID int64) (int64, error)
-	// BatchCountDescendants 批量统计直接评论（始祖评论）所有后代即所有子评论，孙子评论的数量
+	CountDescendants(ctx context.Context, uid int64, ancestorID int64) (int64, error)
+	// BatchCountDescendants 批量统计直接评论（始祖评论）所有审核通过的后代的数量
 	BatchCountDescendants(ctx context.Context, ancestorIDs []int64) (map[int64]int64, error)
+	// FindHotAncestors 按照热度倒序排序，热度相同的按照ID倒序排序，从 (hotScore, minID) 之后开始
+	FindHotAncestors(ctx context.Context, uid int64, biz string, bizID, hotScore, minID int64, limit int) ([]Comment, error)
+	// FindPinnedAncestors 某一业务下置顶的直接评论（始祖评论）
+	FindPinnedAncestors(ctx context.Context, uid int64, biz string, bizID int64) ([]Comment, error)
+	// FindPinnedDescendants 始祖评论下置顶的回复
+	FindPinnedDescendants(ctx context.Context, uid int64, ancestorID int64) ([]Comment, error)
+	// Pin 置顶或者取消置顶。同一个业务下的直接评论，或者同一个始祖评论下的回复，只能有一个置顶
+	Pin(ctx context.Context, id int64, pinned bool) error
+	UpdateLikeCnt(ctx context.Context, id, likeCnt int64) error
+	UpdateHotScore(ctx context.Context, id, hotScore int64) error
+	// ScanAncestors 按照ID升序遍历所有的直接评论（始祖评论），不区分业务和状态，从 minID 之后开始
+	ScanAncestors(ctx context.Context, minID int64, limit int) ([]Comment, error)
 	// FindByID 根据评论ID查找评论
 	FindByID(ctx context.Context, id int64) (Comment, error)
 	// Delete 根据ID删除评论及其后裔评论
 	Delete(ctx context.Context, id, uid int64) error
+	// FindByUID 查找用户发表的所有评论，按照评论ID升序排序
+	FindByUID(ctx context.Context, uid int64, offset, limit int) ([]Comment, error)
+	// AnonymizeByUID 把用户发表的评论内容都替换成 content 并且删除修改历史，不删除评论以免其他人的回复跟着被级联删除
+	AnonymizeByUID(ctx context.Context, uid int64, content string) error
+
+	// Edit 修改评论的内容并且重新进入审核，修改之前的内容保存到 comment_histories
+	Edit(ctx context.Context, id, uid int64, content string) error
+	// FindHistories 评论修改之前的内容，按照修改时间倒序排序
+	FindHistories(ctx context.Context, id int64) ([]CommentHistory, error)
+	// FindByStatus 管理员审核用，按照评论ID升序排序，先提交的先审核
+	FindByStatus(ctx context.Context, status uint8, offset, limit int) ([]Comment, error)
+	CountByStatus(ctx context.Context, status uint8) (int64, error)
+	// Review 管理员审核评论，同时把评论收到的举报都标记为已处理
+	Review(ctx context.Context, id int64, status uint8) error
 }
 
 type commentDAO struct {
@@ -112,40 +169,48 @@ func (g *commentDAO) Create(ctx context.Context, c Comment) (int64, error) {
 	return c.ID, err
 }
 
-func (g *commentDAO) FindAncestors(ctx context.Context, biz string, bizID, minID int64, limit int) ([]Comment, error) {
+// visible 审核通过的，以及当前用户自己还在审核中的
+func (g *commentDAO) visible(db *gorm.DB, uid int64) *gorm.DB {
+	return db.Where("status = ? OR (status = ? AND uid = ?)",
+		CommentStatusApproved, CommentStatusPending, uid)
+}
+
+func (g *commentDAO) FindAncestors(ctx context.Context, uid int64, biz string, bizID, minID int64, limit int) ([]Comment, error) {
 	var res []Comment
-	err := g.db.WithContext(ctx).
+	err := g.visible(g.db.WithContext(ctx), uid).
 		Where("id < ? AND biz = ? AND biz_id = ?", minID, biz, bizID).
 		// 直接评论、根评论、始祖评论
 		Where("ancestor_id IS NULL AND parent_id IS NULL").
+		// 置顶的评论单独查询
+		Where("pinned_at = 0").
 		Order("id DESC").
 		Limit(limit).
 		Find(&res).Error
 	return res, err
 }
 
-func (g *commentDAO) CountAncestors(ctx context.Context, biz string, bizID int64) (int64, error) {
+func (g *commentDAO) CountAncestors(ctx context.Context, uid int64, biz string, bizID int64) (int64, error) {
 	var count int64
-	err := g.db.WithContext(ctx).Model(&Comment{}).
+	err := g.visible(g.db.WithContext(ctx).Model(&Comment{}), uid).
 		Where("biz = ? AND biz_id = ?", biz, bizID).
 		Where("ancestor_id IS NULL AND parent_id IS NULL").
 		Count(&count).Error
 	return count, err
 }
 
-func (g *commentDAO) FindDescendants(ctx context.Context, ancestorID, minID int64, limit int) ([]Comment, error) {
+func (g *commentDAO) FindDescendants(ctx context.Context, uid int64, ancestorID, minID int64, limit int) ([]Comment, error) {
 	var res []Comment
-	err := g.db.WithContext(ctx).
-		Where("id < ? AND ancestor_id = ?", minID, ancestorID).
+	err := g.visible(g.db.WithContext(ctx), uid).
+		Where("id < ? AND ancestor_id = ? AND pinned_at = 0", minID, ancestorID).
 		Order("id DESC").
 		Limit(limit).
 		Find(&res).Error
 	return res, err
 }
 
-func (g *commentDAO) CountDescendants(ctx context.Context, ancestorID int64) (int64, error) {
+func (g *commentDAO) CountDescendants(ctx context.Context, uid int64, ancestorID int64) (int64, error) {
 	var count int64
-	err := g.db.WithContext(ctx).Model(&Comment{}).
+	err := g.visible(g.db.WithContext(ctx).Model(&Comment{}), uid).
 		Where("ancestor_id = ?", ancestorID).
 		Count(&count).Error
 	return count, err
@@ -158,7 +223,7 @@ func (g *commentDAO) BatchCountDescendants(ctx context.Context, ancestorIDs []in
 	}
 	var results []Result
 	err := g.db.WithContext(ctx).Model(&Comment{}).Select("ancestor_id", "count(id) as count").
-		Where("ancestor_id IN ?", ancestorIDs).Group("ancestor_id").
+		Where("ancestor_id IN ? AND status = ?", ancestorIDs, CommentStatusApproved).Group("ancestor_id").
 		Find(&results).Error
 	return slice.ToMapV(results, func(element Result) (int64, int64) {
 		return element.AncestorID, element.Count
@@ -174,3 +239,186 @@ func (g *commentDAO) FindByID(ctx context.Context, id int64) (Comment, error) {
 func (g *commentDAO) Delete(ctx context.Context, id, uid int64) error {
 	return g.db.WithContext(ctx).Where("id = ? AND uid = ?", id, uid).Delete(&Comment{}).Error
 }
+
+func (g *commentDAO) FindByUID(ctx context.Context, uid int64, offset, limit int) ([]Comment, error) {
+	var res []Comment
+	err := g.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id ASC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (g *commentDAO) AnonymizeByUID(ctx context.Context, uid int64, content string) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 修改之前的内容也要一起删掉
+		err := tx.Where("comment_id IN (?)", tx.Model(&Comment{}).Select("id").Where("uid = ?", uid)).
+			Delete(&CommentHistory{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Comment{}).Where("uid = ?", uid).
+			Updates(map[string]any{
+				"content": content,
+				"utime":   time.Now().UnixMilli(),
+			}).Error
+	})
+}
+
+func (g *commentDAO) Edit(ctx context.Context, id, uid int64, content string) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var c Comment
+		err := tx.Where("id = ? AND uid = ?", id, uid).First(&c).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		err = tx.Create(&CommentHistory{
+			CommentID: id,
+			Content:   c.Content,
+			Ctime:     now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Comment{}).Where("id = ?", id).
+			Updates(map[string]any{
+				"content": content,
+				"status":  CommentStatusPending,
+				"utime":   now,
+			}).Error
+	})
+}
+
+func (g *commentDAO) FindHistories(ctx context.Context, id int64) ([]CommentHistory, error) {
+	var res []CommentHistory
+	err := g.db.WithContext(ctx).Where("comment_id = ?", id).
+		Order("id DESC").Find(&res).Error
+	return res, err
+}
+
+func (g *commentDAO) FindByStatus(ctx context.Context, status uint8, offset, limit int) ([]Comment, error) {
+	var res []Comment
+	err := g.db.WithContext(ctx).Where("status = ?", status).
+		Order("id ASC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (g *commentDAO) CountByStatus(ctx context.Context, status uint8) (int64, error) {
+	var count int64
+	err := g.db.WithContext(ctx).Model(&Comment{}).
+		Where("status = ?", status).Count(&count).Error
+	return count, err
+}
+
+func (g *commentDAO) Review(ctx context.Context, id int64, status uint8) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		res := tx.Model(&Comment{}).Where("id = ?", id).
+			Updates(map[string]any{
+				"status": status,
+				"utime":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+		// 审核通过之后重新计算举报次数，不然再来一个举报就又被隐藏了
+		return tx.Model(&CommentReport{}).
+			Where("comment_id = ? AND status = ?", id, ReportStatusPending).
+			Updates(map[string]any{
+				"status": ReportStatusHandled,
+				"utime":  now,
+			}).Error
+	})
+}
+
+func (g *commentDAO) FindHotAncestors(ctx context.Context, uid int64, biz string, bizID, hotScore, minID int64, limit int) ([]Comment, error) {
+	var res []Comment
+	err := g.visible(g.db.WithContext(ctx), uid).
+		Where("biz = ? AND biz_id = ?", biz, bizID).
+		Where("ancestor_id IS NULL AND parent_id IS NULL AND pinned_at = 0").
+		Where("hot_score < ? OR (hot_score = ? AND id < ?)", hotScore, hotScore, minID).
+		Order("hot_score DESC, id DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (g *commentDAO) FindPinnedAncestors(ctx context.Context, uid int64, biz string, bizID int64) ([]Comment, error) {
+	var res []Comment
+	err := g.visible(g.db.WithContext(ctx), uid).
+		Where("biz = ? AND biz_id = ?", biz, bizID).
+		Where("ancestor_id IS NULL AND parent_id IS NULL AND pinned_at > 0").
+		Order("pinned_at DESC").
+		Find(&res).Error
+	return res, err
+}
+
+func (g *commentDAO) FindPinnedDescendants(ctx context.Context, uid int64, ancestorID int64) ([]Comment, error) {
+	var res []Comment
+	err := g.visible(g.db.WithContext(ctx), uid).
+		Where("ancestor_id = ? AND pinned_at > 0", ancestorID).
+		Order("pinned_at DESC").
+		Find(&res).Error
+	return res, err
+}
+
+func (g *commentDAO) Pin(ctx context.Context, id int64, pinned bool) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var c Comment
+		err := tx.Where("id = ?", id).First(&c).Error
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		if !pinned {
+			return tx.Model(&Comment{}).Where("id = ?", id).
+				Updates(map[string]any{
+					"pinned_at": 0,
+					"utime":     now,
+				}).Error
+		}
+		// 先取消同一个范围内之前的置顶
+		scope := tx.Model(&Comment{}).Where("pinned_at > 0")
+		if c.AncestorID.Valid {
+			scope = scope.Where("ancestor_id = ?", c.AncestorID.V)
+		} else {
+			scope = scope.Where("biz = ? AND biz_id = ? AND ancestor_id IS NULL", c.Biz, c.BizID)
+		}
+		err = scope.Updates(map[string]any{
+			"pinned_at": 0,
+			"utime":     now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Comment{}).Where("id = ?", id).
+			Updates(map[string]any{
+				"pinned_at": now,
+				"utime":     now,
+			}).Error
+	})
+}
+
+func (g *commentDAO) UpdateLikeCnt(ctx context.Context, id, likeCnt int64) error {
+	return g.db.WithContext(ctx).Model(&Comment{}).Where("id = ?", id).
+		Update("like_cnt", likeCnt).Error
+}
+
+func (g *commentDAO) UpdateHotScore(ctx context.Context, id, hotScore int64) error {
+	return g.db.WithContext(ctx).Model(&Comment{}).Where("id = ?", id).
+		Update("hot_score", hotScore).Error
+}
+
+func (g *commentDAO) ScanAncestors(ctx context.Context, minID int64, limit int) ([]Comment, error) {
+	var res []Comment
+	err := g.db.WithContext(ctx).
+		Where("id > ? AND ancestor_id IS NULL AND parent_id IS NULL", minID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
@@ -17,5 +17,5 @@ package dao
 import "github.com/ego-component/egorm"
 
 func InitTables(db *egorm.Component) error {
-	return db.AutoMigrate(&Comment{})
+	return db.AutoMigrate(&Comment{}, &CommentHistory{}, &CommentReport{})
 }
@@ -0,0 +1,124 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ego-component/egorm"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var ErrDuplicateReport = errors.New("重复举报")
+
+const (
+	ReportStatusPending uint8 = iota + 1
+	ReportStatusHandled
+)
+
+// CommentReport 用户对评论的举报，每个用户对同一条评论只能举报一次
+type CommentReport struct {
+	ID        int64  `gorm:"autoIncrement,primaryKey"`
+	CommentID int64  `gorm:"not null;uniqueIndex:uniq_comment_uid,priority:1;comment:'被举报的评论'"`
+	Uid       int64  `gorm:"not null;uniqueIndex:uniq_comment_uid,priority:2;comment:'举报人'"`
+	Reason    string `gorm:"type:varchar(32);not null;comment:'举报原因'"`
+	Detail    string `gorm:"type:varchar(512);comment:'补充说明'"`
+	Status    uint8  `gorm:"type:tinyint(3);not null;comment:'1-待处理 2-已处理'"`
+	Ctime     int64
+	Utime     int64
+}
+
+func (CommentReport) TableName() string {
+	return "comment_reports"
+}
+
+type ReportDAO interface {
+	// Create 保存举报，待处理的举报达到 threshold 之后隐藏审核通过的评论，返回评论是否因此被隐藏
+	Create(ctx context.Context, r CommentReport, threshold int64) (bool, error)
+	// FindByCommentID 评论收到的所有举报，按照举报时间倒序排序
+	FindByCommentID(ctx context.Context, commentID int64) ([]CommentReport, error)
+	// BatchCountPending 批量统计评论收到的待处理的举报的数量
+	BatchCountPending(ctx context.Context, commentIDs []int64) (map[int64]int64, error)
+}
+
+type reportDAO struct {
+	db *egorm.Component
+}
+
+func NewReportGORMDAO(db *egorm.Component) ReportDAO {
+	return &reportDAO{db: db}
+}
+
+func (g *reportDAO) Create(ctx context.Context, r CommentReport, threshold int64) (bool, error) {
+	now := time.Now().UnixMilli()
+	r.Ctime, r.Utime = now, now
+	r.Status = ReportStatusPending
+	hidden := false
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", r.CommentID).First(&Comment{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&r).Error
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == uniqueIndexErrNo {
+			return ErrDuplicateReport
+		}
+		if err != nil {
+			return err
+		}
+		var cnt int64
+		err = tx.Model(&CommentReport{}).
+			Where("comment_id = ? AND status = ?", r.CommentID, ReportStatusPending).
+			Count(&cnt).Error
+		if err != nil || cnt < threshold {
+			return err
+		}
+		res := tx.Model(&Comment{}).
+			Where("id = ? AND status = ?", r.CommentID, CommentStatusApproved).
+			Updates(map[string]any{
+				"status": CommentStatusHidden,
+				"utime":  now,
+			})
+		hidden = res.RowsAffected > 0
+		return res.Error
+	})
+	return hidden, err
+}
+
+func (g *reportDAO) FindByCommentID(ctx context.Context, commentID int64) ([]CommentReport, error) {
+	var res []CommentReport
+	err := g.db.WithContext(ctx).Where("comment_id = ?", commentID).
+		Order("id DESC").Find(&res).Error
+	return res, err
+}
+
+func (g *reportDAO) BatchCountPending(ctx context.Context, commentIDs []int64) (map[int64]int64, error) {
+	type Result struct {
+		CommentID int64
+		Count     int64
+	}
+	var results []Result
+	err := g.db.WithContext(ctx).Model(&CommentReport{}).Select("comment_id", "count(id) as count").
+		Where("comment_id IN ? AND status = ?", commentIDs, ReportStatusPending).Group("comment_id").
+		Find(&results).Error
+	return slice.ToMapV(results, func(element Result) (int64, int64) {
+		return element.CommentID, element.Count
+	}), err
+}
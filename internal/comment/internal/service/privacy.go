@@ -0,0 +1,67 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/comment/internal/domain"
+	"github.com/ecodeclub/webook/internal/comment/internal/repository"
+)
+
+// erasedContent 注销账号之后，评论展示的内容
+const erasedContent = "该评论已随账号注销删除"
+
+// PrivacyService 导出以及匿名化用户发表的评论
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 评论下面可能有其他人的回复，所以只清空内容，不删除评论
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	repo      repository.CommentRepository
+	batchSize int
+}
+
+func NewPrivacyService(repo repository.CommentRepository) PrivacyService {
+	return &privacyService{
+		repo:      repo,
+		batchSize: 200,
+	}
+}
+
+func (s *privacyService) Name() string {
+	return "comment"
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	res := make([]domain.Comment, 0, s.batchSize)
+	for offset := 0; ; offset += s.batchSize {
+		comments, err := s.repo.FindByUID(ctx, uid, offset, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, comments...)
+		if len(comments) < s.batchSize {
+			return res, nil
+		}
+	}
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	return s.repo.AnonymizeByUID(ctx, uid, erasedContent)
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ecodeclub/webook/internal/comment/internal/domain"
+	"github.com/ecodeclub/webook/internal/comment/internal/repository"
+)
+
+var (
+	ErrDuplicateReport     = repository.ErrDuplicateReport
+	ErrInvalidReportReason = errors.New("举报原因非法")
+)
+
+// ReportService 举报评论。同一条评论被举报的次数达到阈值之后自动隐藏，等待管理员重新审核
+type ReportService interface {
+	// Report 返回评论是否因为这次举报被隐藏
+	Report(ctx context.Context, r domain.Report) (bool, error)
+	// Reports 评论收到的所有举报，按照举报时间倒序排序
+	Reports(ctx context.Context, commentID int64) ([]domain.Report, error)
+}
+
+type reportService struct {
+	repo      repository.CommentRepository
+	threshold int64
+}
+
+func NewReportService(repo repository.CommentRepository, threshold int64) ReportService {
+	return &reportService{repo: repo, threshold: threshold}
+}
+
+func (s *reportService) Report(ctx context.Context, r domain.Report) (bool, error) {
+	if !r.Reason.Valid() {
+		return false, fmt.Errorf("%w, reason %s", ErrInvalidReportReason, r.Reason)
+	}
+	hidden, err := s.repo.CreateReport(ctx, r, s.threshold)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return false, fmt.Errorf("%w, id %d", ErrCommentNotFound, r.CommentID)
+	}
+	return hidden, err
+}
+
+func (s *reportService) Reports(ctx context.Context, commentID int64) ([]domain.Report, error) {
+	return s.repo.FindReports(ctx, commentID)
+}
@@ -16,61 +16,148 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
+	"time"
 
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/webook/internal/comment/internal/domain"
+	"github.com/ecodeclub/webook/internal/comment/internal/event"
 	"github.com/ecodeclub/webook/internal/comment/internal/repository"
+	"github.com/ecodeclub/webook/internal/interactive"
+	notificationevt "github.com/ecodeclub/webook/internal/notification/event"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/user"
+	"github.com/gotomicro/ego/core/elog"
 	"golang.org/x/sync/errgroup"
 )
 
 type CommentService interface {
 	// Create  创建直接评论（始祖评论），子评论及孙子评论
 	Create(ctx context.Context, comment domain.Comment) (int64, error)
-	// List 查找某一业务下的所有直接评论（始祖评论），按评论时间的倒序排序
-	List(ctx context.Context, biz string, bizID, minID int64, limit int) ([]domain.Comment, int64, error)
+	// List 查找某一业务下的所有直接评论（始祖评论），按评论时间的倒序排序。
+	// uid 是当前用户，除了审核通过的评论，还能看到自己还在审核中的评论
+	List(ctx context.Context, uid int64, biz string, bizID, minID int64, limit int) ([]domain.Comment, int64, error)
 	// Replies 查找直接评论（始祖评论）所有后代即所有子评论，孙子评论，按照评论时间倒序排序（即后评论的在前面）
-	Replies(ctx context.Context, ancestorID, minID int64, limit int) ([]domain.Comment, int64, error)
+	Replies(ctx context.Context, uid int64, ancestorID, minID int64, limit int) ([]domain.Comment, int64, error)
+	// Hot 按照热度倒序排序的直接评论（始祖评论），第一页的 cursor 为零值。置顶的评论只在第一页返回
+	Hot(ctx context.Context, uid int64, biz string, bizID int64, cursor domain.HotCursor, limit int) ([]domain.Comment, int64, error)
+	// LikeToggle 点赞或者取消点赞，返回最新的点赞数
+	LikeToggle(ctx context.Context, id, uid int64) (int64, error)
+	// PinReply 始祖评论的作者置顶或者取消置顶其下的回复
+	PinReply(ctx context.Context, id, uid int64, pinned bool) error
+	// Pin 管理员置顶或者取消置顶任意评论
+	Pin(ctx context.Context, id int64, pinned bool) error
+	// BackfillHotScore 按照互动服务里的点赞数以及回复数重新计算所有始祖评论的热度，
+	// 返回处理了多少条评论。用于上线热度排序之前的历史评论
+	BackfillHotScore(ctx context.Context) (int64, error)
 	// Delete 根据ID删除评论及其后裔评论
 	Delete(ctx context.Context, id, uid int64) error
+	// Edit 作者在发表之后的一段时间内可以修改评论，修改之后重新审核
+	Edit(ctx context.Context, id, uid int64, content string) error
+	// Histories 评论修改之前的内容，按照修改时间倒序排序。
+	// 作者总能看到，其他人只能看到审核通过的评论的修改记录
+	Histories(ctx context.Context, id, uid int64) ([]domain.CommentHistory, error)
+
+	// ListForReview 管理员审核用，status 是待审核或者被举报之后隐藏
+	ListForReview(ctx context.Context, status domain.CommentStatus, offset, limit int) ([]domain.Comment, int64, error)
+	Approve(ctx context.Context, id int64) error
+	Reject(ctx context.Context, id int64) error
 }
 
+var (
+	// ErrContentBlocked 评论没有通过内容审核
+	ErrContentBlocked  = moderation.ErrBlocked
+	ErrCommentNotFound = errors.New("评论不存在")
+	ErrEditExpired     = errors.New("已经超过可以修改评论的时间")
+	ErrPinForbidden    = errors.New("只有始祖评论的作者才能置顶回复")
+)
+
 type commentService struct {
 	userSvc user.UserService
+	intrSvc interactive.Service
 	repo    repository.CommentRepository
+	filter  moderation.Filter
+	// 回复以及 @ 的站内信
+	producer event.NotificationEventProducer
+	logger   *elog.Component
+	// 发表之后多久以内可以修改
+	editWindow time.Duration
 }
 
-func NewCommentService(userSvc user.UserService, repo repository.CommentRepository) CommentService {
-	return &commentService{userSvc: userSvc, repo: repo}
+func NewCommentService(userSvc user.UserService,
+	intrSvc interactive.Service,
+	repo repository.CommentRepository,
+	filter moderation.Filter,
+	producer event.NotificationEventProducer) CommentService {
+	return &commentService{
+		userSvc:    userSvc,
+		intrSvc:    intrSvc,
+		repo:       repo,
+		filter:     filter,
+		producer:   producer,
+		logger:     elog.DefaultLogger.With(elog.FieldComponentName("comment.Service")),
+		editWindow: time.Minute * 10,
+	}
 }
 
 func (s *commentService) Create(ctx context.Context, comment domain.Comment) (int64, error) {
+	content, err := s.check(ctx, comment.Content)
+	if err != nil {
+		return 0, err
+	}
+	comment.Content = content
+	comment.Status = domain.CommentStatusPending
+	if comment.ParentID == 0 {
+		comment.HotScore = domain.HotScore(0, 0, time.Now().UnixMilli())
+	}
 	return s.repo.Create(ctx, comment)
 }
 
-func (s *commentService) List(ctx context.Context, biz string, bizID, minID int64, limit int) ([]domain.Comment, int64, error) {
+// check 内容审核，命中打码词典的评论保存打码之后的内容
+func (s *commentService) check(ctx context.Context, content string) (string, error) {
+	d, err := s.filter.Check(ctx, content)
+	if err != nil {
+		return "", err
+	}
+	if d.Blocked() {
+		return "", fmt.Errorf("%w, 命中 %v", ErrContentBlocked, d.Hits)
+	}
+	return d.Text, nil
+}
+
+func (s *commentService) List(ctx context.Context, uid int64, biz string, bizID, minID int64, limit int) ([]domain.Comment, int64, error) {
 	var (
 		eg       errgroup.Group
 		comments []domain.Comment
 		total    int64
 	)
 
-	if minID <= 0 {
+	firstPage := minID <= 0
+	if firstPage {
 		minID = math.MaxInt64
 	}
 
 	eg.Go(func() error {
 		var err error
-		comments, err = s.repo.FindAncestors(ctx, biz, bizID, minID, limit)
+		comments, err = s.repo.FindAncestors(ctx, uid, biz, bizID, minID, limit)
 		if err != nil {
 			return err
 		}
+		if firstPage {
+			comments, err = s.withPinnedAncestors(ctx, uid, biz, bizID, comments)
+			if err != nil {
+				return err
+			}
+		}
+		s.setLikes(ctx, uid, comments)
 		return s.setUserInfo(ctx, comments)
 	})
 
 	eg.Go(func() error {
 		var err error
-		total, err = s.repo.CountAncestors(ctx, biz, bizID)
+		total, err = s.repo.CountAncestors(ctx, uid, biz, bizID)
 		return err
 	})
 
@@ -111,29 +198,38 @@ func (s *commentService) setUserInfo(ctx context.Context, comments []domain.Comm
 	return nil
 }
 
-func (s *commentService) Replies(ctx context.Context, ancestorID, minID int64, limit int) ([]domain.Comment, int64, error) {
+func (s *commentService) Replies(ctx context.Context, uid int64, ancestorID, minID int64, limit int) ([]domain.Comment, int64, error) {
 	var (
 		eg      errgroup.Group
 		replies []domain.Comment
 		total   int64
 	)
 
-	if minID <= 0 {
+	firstPage := minID <= 0
+	if firstPage {
 		minID = math.MaxInt64
 	}
 
 	eg.Go(func() error {
 		var err error
-		replies, err = s.repo.FindDescendants(ctx, ancestorID, minID, limit)
+		replies, err = s.repo.FindDescendants(ctx, uid, ancestorID, minID, limit)
 		if err != nil {
 			return err
 		}
+		if firstPage {
+			pinned, err := s.repo.FindPinnedDescendants(ctx, uid, ancestorID)
+			if err != nil {
+				return err
+			}
+			replies = append(pinned, replies...)
+		}
+		s.setLikes(ctx, uid, replies)
 		return s.setUserInfo(ctx, replies)
 	})
 
 	eg.Go(func() error {
 		var err error
-		total, err = s.repo.CountDescendants(ctx, ancestorID)
+		total, err = s.repo.CountDescendants(ctx, uid, ancestorID)
 		return err
 	})
 
@@ -141,5 +237,308 @@ func (s *commentService) Replies(ctx context.Context, ancestorID, minID int64, l
 }
 
 func (s *commentService) Delete(ctx context.Context, id, uid int64) error {
-	return s.repo.Delete(ctx, id, uid)
+	c, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = s.repo.Delete(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+	s.refreshAncestorHotScore(ctx, c)
+	return nil
+}
+
+// refreshAncestorHotScore 回复的数量变了，始祖评论的热度跟着变。
+// 评论本身已经处理成功了，热度没有更新也问题不大，所以只记录日志
+func (s *commentService) refreshAncestorHotScore(ctx context.Context, c domain.Comment) {
+	if c.AncestorID == 0 {
+		return
+	}
+	err := s.repo.RefreshHotScore(ctx, c.AncestorID)
+	if err != nil {
+		s.logger.Error("更新始祖评论的热度失败",
+			elog.Int64("id", c.AncestorID),
+			elog.FieldErr(err))
+	}
+}
+
+func (s *commentService) Edit(ctx context.Context, id, uid int64, content string) error {
+	c, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrRecordNotFound) || (err == nil && c.User.ID != uid) {
+		return fmt.Errorf("%w, id %d, uid %d", ErrCommentNotFound, id, uid)
+	}
+	if err != nil {
+		return err
+	}
+	if time.Since(time.UnixMilli(c.Ctime)) > s.editWindow {
+		return fmt.Errorf("%w, id %d", ErrEditExpired, id)
+	}
+	content, err = s.check(ctx, content)
+	if err != nil {
+		return err
+	}
+	return s.repo.Edit(ctx, id, uid, content)
+}
+
+func (s *commentService) Histories(ctx context.Context, id, uid int64) ([]domain.CommentHistory, error) {
+	c, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 审核中或者被拒绝、被隐藏的评论，修改之前的内容同样不能给别人看
+	if c.User.ID != uid && c.Status != domain.CommentStatusApproved {
+		return nil, fmt.Errorf("%w, id %d, uid %d", ErrCommentNotFound, id, uid)
+	}
+	return s.repo.FindHistories(ctx, id)
+}
+
+func (s *commentService) ListForReview(ctx context.Context, status domain.CommentStatus, offset, limit int) ([]domain.Comment, int64, error) {
+	comments, total, err := s.repo.FindByStatus(ctx, status, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, total, s.setUserInfo(ctx, comments)
+}
+
+func (s *commentService) Approve(ctx context.Context, id int64) error {
+	return s.review(ctx, id, domain.CommentStatusApproved)
+}
+
+func (s *commentService) Reject(ctx context.Context, id int64) error {
+	return s.review(ctx, id, domain.CommentStatusRejected)
+}
+
+func (s *commentService) review(ctx context.Context, id int64, status domain.CommentStatus) error {
+	c, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Review(ctx, id, status)
+	if err != nil {
+		return err
+	}
+	// 只有审核通过的回复才算热度
+	s.refreshAncestorHotScore(ctx, c)
+	if status == domain.CommentStatusApproved && c.Status == domain.CommentStatusPending {
+		s.notify(ctx, c)
+	}
+	return nil
+}
+
+// notify 评论第一次审核通过之后通知被回复以及被 @ 的人。
+// 修改过的评论重新审核通过的时候不再通知，避免重复打扰。
+// 评论已经审核通过了，通知失败也只记录日志
+func (s *commentService) notify(ctx context.Context, c domain.Comment) {
+	histories, err := s.repo.FindHistories(ctx, c.ID)
+	if err != nil {
+		s.logger.Error("查询评论的修改记录失败", elog.Int64("id", c.ID), elog.FieldErr(err))
+		return
+	}
+	if len(histories) > 0 {
+		return
+	}
+	evt := notificationevt.NotificationEvent{
+		Sender:   c.User.ID,
+		Biz:      c.Biz,
+		BizID:    c.BizID,
+		SourceID: c.ID,
+		Content:  c.Content,
+	}
+	var replied int64
+	if c.ParentID > 0 {
+		parent, err := s.repo.FindByID(ctx, c.ParentID)
+		if err != nil {
+			s.logger.Error("查询父评论失败", elog.Int64("id", c.ParentID), elog.FieldErr(err))
+		} else if parent.User.ID != c.User.ID {
+			replied = parent.User.ID
+			evt.Type = notificationevt.NotificationTypeReply
+			evt.Receivers = []int64{replied}
+			s.produce(ctx, evt)
+		}
+	}
+	// 被回复的人已经收到回复的通知了
+	mentions := slice.FilterDelete(c.Mentions(), func(_ int, src int64) bool {
+		return src == replied
+	})
+	if len(mentions) > 0 {
+		evt.Type = notificationevt.NotificationTypeMention
+		evt.Receivers = mentions
+		s.produce(ctx, evt)
+	}
+}
+
+func (s *commentService) produce(ctx context.Context, evt notificationevt.NotificationEvent) {
+	err := s.producer.Produce(ctx, evt)
+	if err != nil {
+		s.logger.Error("发送站内信事件失败", elog.Any("event", evt), elog.FieldErr(err))
+	}
+}
+
+func (s *commentService) find(ctx context.Context, id int64) (domain.Comment, error) {
+	c, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return domain.Comment{}, fmt.Errorf("%w, id %d", ErrCommentNotFound, id)
+	}
+	return c, err
+}
+
+func (s *commentService) Hot(ctx context.Context, uid int64, biz string, bizID int64, cursor domain.HotCursor, limit int) ([]domain.Comment, int64, error) {
+	var (
+		eg       errgroup.Group
+		comments []domain.Comment
+		total    int64
+	)
+
+	firstPage := cursor.IsZero()
+	if firstPage {
+		cursor = domain.HotCursor{HotScore: math.MaxInt64, ID: math.MaxInt64}
+	}
+
+	eg.Go(func() error {
+		var err error
+		comments, err = s.repo.FindHotAncestors(ctx, uid, biz, bizID, cursor, limit)
+		if err != nil {
+			return err
+		}
+		if firstPage {
+			comments, err = s.withPinnedAncestors(ctx, uid, biz, bizID, comments)
+			if err != nil {
+				return err
+			}
+		}
+		s.setLikes(ctx, uid, comments)
+		return s.setUserInfo(ctx, comments)
+	})
+
+	eg.Go(func() error {
+		var err error
+		total, err = s.repo.CountAncestors(ctx, uid, biz, bizID)
+		return err
+	})
+
+	return comments, total, eg.Wait()
+}
+
+// withPinnedAncestors 置顶的评论放在第一页的最前面
+func (s *commentService) withPinnedAncestors(ctx context.Context, uid int64, biz string, bizID int64, comments []domain.Comment) ([]domain.Comment, error) {
+	pinned, err := s.repo.FindPinnedAncestors(ctx, uid, biz, bizID)
+	if err != nil {
+		return nil, err
+	}
+	return append(pinned, comments...), nil
+}
+
+// setLikes 点赞数据查询不到也不影响展示评论，所以只记录日志
+func (s *commentService) setLikes(ctx context.Context, uid int64, comments []domain.Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	ids := slice.Map(comments, func(_ int, src domain.Comment) int64 {
+		return src.ID
+	})
+	intrs, err := s.intrSvc.GetByIds(ctx, domain.BizComment, uid, ids)
+	if err != nil {
+		s.logger.Error("查询评论的点赞数据失败",
+			elog.Any("ids", ids),
+			elog.FieldErr(err))
+		return
+	}
+	for i := range comments {
+		if intr, ok := intrs[comments[i].ID]; ok {
+			comments[i].LikeCnt = int64(intr.LikeCnt)
+			comments[i].Liked = intr.Liked
+		}
+	}
+}
+
+func (s *commentService) LikeToggle(ctx context.Context, id, uid int64) (int64, error) {
+	c, err := s.find(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	// 审核中或者被拒绝、被隐藏的评论别人看不到，也就不能点赞
+	if c.Status != domain.CommentStatusApproved {
+		return 0, fmt.Errorf("%w, id %d", ErrCommentNotFound, id)
+	}
+	err = s.intrSvc.LikeToggle(ctx, domain.BizComment, id, uid)
+	if err != nil {
+		return 0, err
+	}
+	intr, err := s.intrSvc.Get(ctx, domain.BizComment, id, uid)
+	if err != nil {
+		return 0, err
+	}
+	// 同步一份点赞数，按照热度排序的时候要用
+	likeCnt := int64(intr.LikeCnt)
+	err = s.repo.UpdateLikeCnt(ctx, id, likeCnt)
+	if err != nil {
+		return 0, err
+	}
+	if c.AncestorID == 0 {
+		err = s.repo.RefreshHotScore(ctx, id)
+	}
+	return likeCnt, err
+}
+
+func (s *commentService) BackfillHotScore(ctx context.Context) (int64, error) {
+	const batchSize = 100
+	var (
+		minID int64
+		total int64
+	)
+	for {
+		ancestors, err := s.repo.ScanAncestors(ctx, minID, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(ancestors) == 0 {
+			return total, nil
+		}
+		ids := slice.Map(ancestors, func(_ int, src domain.Comment) int64 {
+			return src.ID
+		})
+		intrs, err := s.intrSvc.GetByIds(ctx, domain.BizComment, 0, ids)
+		if err != nil {
+			return total, err
+		}
+		for _, c := range ancestors {
+			likeCnt := int64(intrs[c.ID].LikeCnt)
+			err = s.repo.UpdateHotScore(ctx, c.ID, likeCnt, domain.HotScore(likeCnt, c.ReplyCount, c.Ctime))
+			if err != nil {
+				return total, err
+			}
+			total++
+		}
+		minID = ancestors[len(ancestors)-1].ID
+	}
+}
+
+func (s *commentService) PinReply(ctx context.Context, id, uid int64, pinned bool) error {
+	c, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.AncestorID == 0 {
+		return fmt.Errorf("%w, 不是回复, id %d", ErrPinForbidden, id)
+	}
+	ancestor, err := s.find(ctx, c.AncestorID)
+	if err != nil {
+		return err
+	}
+	if ancestor.User.ID != uid {
+		return fmt.Errorf("%w, id %d, uid %d", ErrPinForbidden, id, uid)
+	}
+	return s.repo.Pin(ctx, id, pinned)
+}
+
+func (s *commentService) Pin(ctx context.Context, id int64, pinned bool) error {
+	_, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Pin(ctx, id, pinned)
 }
@@ -0,0 +1,118 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/comment/internal/domain"
+	"github.com/ecodeclub/webook/internal/comment/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理员审核评论
+type AdminHandler struct {
+	svc       service.CommentService
+	reportSvc service.ReportService
+}
+
+func NewAdminHandler(svc service.CommentService, reportSvc service.ReportService) *AdminHandler {
+	return &AdminHandler{
+		svc:       svc,
+		reportSvc: reportSvc,
+	}
+}
+
+func (h *AdminHandler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/comment")
+	// 待审核或者被举报之后隐藏的评论，先提交的在前面
+	g.POST("/review/list", ginx.BS[ReviewListRequest](h.ReviewList))
+	// 审核通过，被隐藏的评论会重新展示，之前的举报都标记为已处理
+	g.POST("/review/approve", ginx.BS[ReviewRequest](h.Approve))
+	g.POST("/review/reject", ginx.BS[ReviewRequest](h.Reject))
+	// 评论收到的所有举报
+	g.POST("/report/list", ginx.BS[ReportsRequest](h.Reports))
+	// 管理员可以置顶任意评论
+	g.POST("/pin", ginx.BS[PinRequest](h.Pin))
+	// 回填历史评论的点赞数以及热度
+	g.POST("/hot/backfill", ginx.S(h.BackfillHotScore))
+}
+
+func (h *AdminHandler) ReviewList(ctx *ginx.Context, req ReviewListRequest, _ session.Session) (ginx.Result, error) {
+	comments, total, err := h.svc.ListForReview(ctx.Request.Context(), domain.CommentStatus(req.Status), req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: CommentList{
+			List: slice.Map(comments, func(_ int, src domain.Comment) Comment {
+				return toVO(src)
+			}),
+			Total: int(total),
+		},
+	}, nil
+}
+
+func (h *AdminHandler) Approve(ctx *ginx.Context, req ReviewRequest, _ session.Session) (ginx.Result, error) {
+	return h.reviewResult(h.svc.Approve(ctx.Request.Context(), req.ID))
+}
+
+func (h *AdminHandler) Reject(ctx *ginx.Context, req ReviewRequest, _ session.Session) (ginx.Result, error) {
+	return h.reviewResult(h.svc.Reject(ctx.Request.Context(), req.ID))
+}
+
+func (h *AdminHandler) Pin(ctx *ginx.Context, req PinRequest, _ session.Session) (ginx.Result, error) {
+	return h.reviewResult(h.svc.Pin(ctx.Request.Context(), req.ID, req.Pinned))
+}
+
+func (h *AdminHandler) reviewResult(err error) (ginx.Result, error) {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return commentNotFoundResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	default:
+		return ginx.Result{Msg: "OK"}, nil
+	}
+}
+
+func (h *AdminHandler) Reports(ctx *ginx.Context, req ReportsRequest, _ session.Session) (ginx.Result, error) {
+	reports, err := h.reportSvc.Reports(ctx.Request.Context(), req.ID)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(reports, func(_ int, src domain.Report) Report {
+			return Report{
+				ID:     src.ID,
+				Uid:    src.Uid,
+				Reason: string(src.Reason),
+				Detail: src.Detail,
+				Ctime:  src.Ctime,
+			}
+		}),
+	}, nil
+}
+
+func (h *AdminHandler) BackfillHotScore(ctx *ginx.Context, _ session.Session) (ginx.Result, error) {
+	total, err := h.svc.BackfillHotScore(ctx.Request.Context())
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: BackfillHotScoreResp{Total: total}}, nil
+}
@@ -32,19 +32,22 @@ import (
 var _ ginx.Handler = &Handler{}
 
 type Handler struct {
-	svc      service.CommentService
-	producer event.WechatRobotEventProducer
-	logger   *elog.Component
+	svc       service.CommentService
+	reportSvc service.ReportService
+	producer  event.WechatRobotEventProducer
+	logger    *elog.Component
 }
 
 func NewHandler(
 	svc service.CommentService,
+	reportSvc service.ReportService,
 	producer event.WechatRobotEventProducer,
 ) *Handler {
 	return &Handler{
-		svc:      svc,
-		producer: producer,
-		logger:   elog.DefaultLogger.With(elog.FieldComponentName("comment.Handler")),
+		svc:       svc,
+		reportSvc: reportSvc,
+		producer:  producer,
+		logger:    elog.DefaultLogger.With(elog.FieldComponentName("comment.Handler")),
 	}
 }
 
@@ -61,6 +64,13 @@ func (h *Handler) MemberRoutes(server *gin.Engine) {
 	// 获得某个直接（始祖）评论的所有子评论，孙子评论，按照评论ID的倒序排序
 	group.POST("/replies", ginx.BS[RepliesRequest](h.Replies))
 	group.POST("/delete", ginx.BS[DeleteRequest](h.Delete))
+	// 发表之后的一段时间内可以修改，修改之后重新审核
+	group.POST("/edit", ginx.BS[EditRequest](h.Edit))
+	group.POST("/history", ginx.BS[HistoryRequest](h.History))
+	group.POST("/report", ginx.BS[ReportRequest](h.Report))
+	group.POST("/like/toggle", ginx.BS[LikeToggleRequest](h.LikeToggle))
+	// 始祖评论的作者置顶回复
+	group.POST("/pin", ginx.BS[PinRequest](h.Pin))
 }
 
 func (h *Handler) Create(ctx *ginx.Context, req CreateRequest, sess session.Session) (ginx.Result, error) {
@@ -79,16 +89,28 @@ func (h *Handler) Create(ctx *ginx.Context, req CreateRequest, sess session.Sess
 			Content:  req.Comment.Content,
 			Utime:    req.Comment.Utime,
 		})
-	if err != nil {
+	switch {
+	case errors.Is(err, service.ErrContentBlocked):
+		return contentBlockedResult, nil
+	case err != nil:
 		return systemErrorResult, err
 	}
+	h.notifyReview(ctx, fmt.Sprintf("用户%d刚刚对biz=%q,bizID=%d发表了评论，等待审核：%q",
+		uid,
+		req.Comment.Biz,
+		req.Comment.BizID,
+		req.Comment.Content))
+	// 返回评论 ID
+	return ginx.Result{
+		Data: id,
+	}, nil
+}
+
+// notifyReview 通知管理员审核
+func (h *Handler) notifyReview(ctx *ginx.Context, content string) {
 	evt := notificationevt.WechatRobotEvent{
-		Robot: "adminRobot",
-		RawContent: fmt.Sprintf("用户%d刚刚对biz=%q,bizID=%d发表了评论：%q",
-			uid,
-			req.Comment.Biz,
-			req.Comment.BizID,
-			req.Comment.Content),
+		Robot:      "adminRobot",
+		RawContent: content,
 	}
 	if er := h.producer.Produce(ctx.Request.Context(), evt); er != nil {
 		h.logger.Error("发送企业微信群通知失败",
@@ -96,28 +118,52 @@ func (h *Handler) Create(ctx *ginx.Context, req CreateRequest, sess session.Sess
 			elog.Any("event", evt),
 		)
 	}
-	// 返回评论 ID
-	return ginx.Result{
-		Data: id,
-	}, nil
 }
 
-func (h *Handler) List(ctx *ginx.Context, req ListRequest, _ session.Session) (ginx.Result, error) {
-	ancestors, total, err := h.svc.List(ctx.Request.Context(), req.Biz, req.BizID, req.MinID, req.Limit)
+func (h *Handler) List(ctx *ginx.Context, req ListRequest, sess session.Session) (ginx.Result, error) {
+	if req.Sort == SortHot {
+		return h.hot(ctx, req, sess)
+	}
+	ancestors, total, err := h.svc.List(ctx.Request.Context(), sess.Claims().Uid, req.Biz, req.BizID, req.MinID, req.Limit)
 	if err != nil {
 		return systemErrorResult, fmt.Errorf("查找%q业务的%d资源的直接评论（始祖评论）失败: %w", req.Biz, req.BizID, err)
 	}
 	return ginx.Result{
 		Data: CommentList{
 			List: slice.Map(ancestors, func(_ int, src domain.Comment) Comment {
-				return h.toVO(src)
+				return toVO(src)
 			}),
 			Total: int(total),
 		},
 	}, nil
 }
 
-func (h *Handler) toVO(c domain.Comment) Comment {
+func (h *Handler) hot(ctx *ginx.Context, req ListRequest, sess session.Session) (ginx.Result, error) {
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	ancestors, total, err := h.svc.Hot(ctx.Request.Context(), sess.Claims().Uid, req.Biz, req.BizID, cursor, req.Limit)
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("按照热度查找%q业务的%d资源的直接评论（始祖评论）失败: %w", req.Biz, req.BizID, err)
+	}
+	res := CommentList{
+		List: slice.Map(ancestors, func(_ int, src domain.Comment) Comment {
+			return toVO(src)
+		}),
+		Total: int(total),
+	}
+	// 置顶的评论不参与排序，用最后一条未置顶的评论作为下一页的起点
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if !ancestors[i].Pinned {
+			res.Cursor = encodeCursor(domain.HotCursor{HotScore: ancestors[i].HotScore, ID: ancestors[i].ID})
+			break
+		}
+	}
+	return ginx.Result{Data: res}, nil
+}
+
+func toVO(c domain.Comment) Comment {
 	return Comment{
 		ID: c.ID,
 		User: User{
@@ -125,24 +171,30 @@ func (h *Handler) toVO(c domain.Comment) Comment {
 			Nickname: c.User.NickName,
 			Avatar:   c.User.Avatar,
 		},
-		Biz:        c.Biz,
-		BizID:      c.BizID,
-		ParentID:   c.ParentID,
-		Content:    c.Content,
-		Utime:      c.Utime,
-		ReplyCount: c.ReplyCount,
+		Biz:         c.Biz,
+		BizID:       c.BizID,
+		ParentID:    c.ParentID,
+		Content:     c.Content,
+		Status:      c.Status.ToUint8(),
+		Ctime:       c.Ctime,
+		Utime:       c.Utime,
+		ReplyCount:  c.ReplyCount,
+		ReportCount: c.ReportCount,
+		LikeCnt:     c.LikeCnt,
+		Liked:       c.Liked,
+		Pinned:      c.Pinned,
 	}
 }
 
-func (h *Handler) Replies(ctx *ginx.Context, req RepliesRequest, _ session.Session) (ginx.Result, error) {
-	descendants, total, err := h.svc.Replies(ctx.Request.Context(), req.AncestorID, req.MinID, req.Limit)
+func (h *Handler) Replies(ctx *ginx.Context, req RepliesRequest, sess session.Session) (ginx.Result, error) {
+	descendants, total, err := h.svc.Replies(ctx.Request.Context(), sess.Claims().Uid, req.AncestorID, req.MinID, req.Limit)
 	if err != nil {
 		return systemErrorResult, fmt.Errorf("查找评论ID=%d的后裔评论失败: %w", req.AncestorID, err)
 	}
 	return ginx.Result{
 		Data: CommentList{
 			List: slice.Map(descendants, func(_ int, src domain.Comment) Comment {
-				return h.toVO(src)
+				return toVO(src)
 			}),
 			Total: int(total),
 		},
@@ -158,3 +210,90 @@ func (h *Handler) Delete(ctx *ginx.Context, req DeleteRequest, sess session.Sess
 		Msg: "OK",
 	}, nil
 }
+
+func (h *Handler) Edit(ctx *ginx.Context, req EditRequest, sess session.Session) (ginx.Result, error) {
+	if req.Content == "" {
+		return systemErrorResult, errors.New("评论内容不能为空")
+	}
+	uid := sess.Claims().Uid
+	err := h.svc.Edit(ctx.Request.Context(), req.ID, uid, req.Content)
+	switch {
+	case errors.Is(err, service.ErrContentBlocked):
+		return contentBlockedResult, nil
+	case errors.Is(err, service.ErrCommentNotFound):
+		return commentNotFoundResult, nil
+	case errors.Is(err, service.ErrEditExpired):
+		return editExpiredResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	h.notifyReview(ctx, fmt.Sprintf("用户%d刚刚修改了评论%d，等待审核：%q", uid, req.ID, req.Content))
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *Handler) History(ctx *ginx.Context, req HistoryRequest, sess session.Session) (ginx.Result, error) {
+	histories, err := h.svc.Histories(ctx.Request.Context(), req.ID, sess.Claims().Uid)
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return commentNotFoundResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(histories, func(_ int, src domain.CommentHistory) CommentHistory {
+			return CommentHistory{
+				ID:      src.ID,
+				Content: src.Content,
+				Ctime:   src.Ctime,
+			}
+		}),
+	}, nil
+}
+
+func (h *Handler) Report(ctx *ginx.Context, req ReportRequest, sess session.Session) (ginx.Result, error) {
+	uid := sess.Claims().Uid
+	hidden, err := h.reportSvc.Report(ctx.Request.Context(), domain.Report{
+		CommentID: req.ID,
+		Uid:       uid,
+		Reason:    domain.ReportReason(req.Reason),
+		Detail:    req.Detail,
+	})
+	switch {
+	case errors.Is(err, service.ErrInvalidReportReason):
+		return invalidReportReasonResult, nil
+	case errors.Is(err, service.ErrCommentNotFound):
+		return commentNotFoundResult, nil
+	case errors.Is(err, service.ErrDuplicateReport):
+		return duplicateReportResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	if hidden {
+		h.notifyReview(ctx, fmt.Sprintf("评论%d被举报的次数太多，已经自动隐藏，等待重新审核", req.ID))
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *Handler) LikeToggle(ctx *ginx.Context, req LikeToggleRequest, sess session.Session) (ginx.Result, error) {
+	likeCnt, err := h.svc.LikeToggle(ctx.Request.Context(), req.ID, sess.Claims().Uid)
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return commentNotFoundResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: LikeToggleResp{LikeCnt: likeCnt}}, nil
+}
+
+func (h *Handler) Pin(ctx *ginx.Context, req PinRequest, sess session.Session) (ginx.Result, error) {
+	err := h.svc.PinReply(ctx.Request.Context(), req.ID, sess.Claims().Uid, req.Pinned)
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return commentNotFoundResult, nil
+	case errors.Is(err, service.ErrPinForbidden):
+		return pinForbiddenResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
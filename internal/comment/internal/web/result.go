@@ -24,4 +24,28 @@ var (
 		Code: errs.SystemError.Code,
 		Msg:  errs.SystemError.Msg,
 	}
+	contentBlockedResult = ginx.Result{
+		Code: errs.ContentBlocked.Code,
+		Msg:  errs.ContentBlocked.Msg,
+	}
+	commentNotFoundResult = ginx.Result{
+		Code: errs.CommentNotFound.Code,
+		Msg:  errs.CommentNotFound.Msg,
+	}
+	editExpiredResult = ginx.Result{
+		Code: errs.EditExpired.Code,
+		Msg:  errs.EditExpired.Msg,
+	}
+	duplicateReportResult = ginx.Result{
+		Code: errs.DuplicateReport.Code,
+		Msg:  errs.DuplicateReport.Msg,
+	}
+	invalidReportReasonResult = ginx.Result{
+		Code: errs.InvalidReportReason.Code,
+		Msg:  errs.InvalidReportReason.Msg,
+	}
+	pinForbiddenResult = ginx.Result{
+		Code: errs.PinForbidden.Code,
+		Msg:  errs.PinForbidden.Msg,
+	}
 )
@@ -14,7 +14,13 @@
 
 package web
 
-import "github.com/ecodeclub/ginx"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ecodeclub/webook/internal/comment/internal/domain"
+)
 
 type CreateRequest struct {
 	Comment Comment `json:"comment"`
@@ -45,21 +51,38 @@ type Comment struct {
 	// 评论的具体内容
 	Content string `json:"content"`
 
+	// 1-审核中 2-审核通过 3-审核拒绝 4-被举报之后隐藏
+	Status uint8 `json:"status"`
+
+	Ctime int64 `json:"ctime"`
 	Utime int64 `json:"utime"`
 
 	// 当前评论的回复总数
 	ReplyCount int64 `json:"replyCount"`
+	// 待处理的举报的数量，只有管理员审核的时候才有
+	ReportCount int64 `json:"reportCount,omitempty"`
+
+	LikeCnt int64 `json:"likeCnt"`
+	// 当前用户是否点赞过
+	Liked  bool `json:"liked"`
+	Pinned bool `json:"pinned"`
 }
 
 type ListRequest struct {
 	Biz   string `json:"biz"`
 	BizID int64  `json:"bizId"`
 
-	// 上一页最小的评论ID，如果是第一页就传0
+	// 排序方式，默认按照时间倒序，hot-按照热度倒序
+	Sort string `json:"sort"`
+	// 上一页最小的评论ID，如果是第一页就传0，按照时间排序的时候使用
 	MinID int64 `json:"minId"`
-	Limit int   `json:"limit"`
+	// 上一页返回的 cursor，如果是第一页就传空字符串，按照热度排序的时候使用
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
 }
 
+const SortHot = "hot"
+
 type RepliesRequest struct {
 	// 直接评论、根评论、始祖评论 ID
 	AncestorID int64 `json:"ancestorId"`
@@ -68,8 +91,104 @@ type RepliesRequest struct {
 	Limit int   `json:"limit"`
 }
 
-type CommentList ginx.DataList[Comment]
+type CommentList struct {
+	List  []Comment `json:"list"`
+	Total int       `json:"total"`
+	// 下一页的 cursor，只有按照热度排序的时候才有
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// encodeCursor 热度会重复，所以 cursor 由热度和 ID 组成，格式是 热度_ID
+func encodeCursor(c domain.HotCursor) string {
+	return fmt.Sprintf("%d_%d", c.HotScore, c.ID)
+}
+
+func decodeCursor(cursor string) (domain.HotCursor, error) {
+	if cursor == "" {
+		return domain.HotCursor{}, nil
+	}
+	score, id, ok := strings.Cut(cursor, "_")
+	if !ok {
+		return domain.HotCursor{}, fmt.Errorf("cursor 格式错误 %q", cursor)
+	}
+	hotScore, err := strconv.ParseInt(score, 10, 64)
+	if err != nil {
+		return domain.HotCursor{}, fmt.Errorf("cursor 格式错误 %q: %w", cursor, err)
+	}
+	cid, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return domain.HotCursor{}, fmt.Errorf("cursor 格式错误 %q: %w", cursor, err)
+	}
+	return domain.HotCursor{HotScore: hotScore, ID: cid}, nil
+}
 
 type DeleteRequest struct {
 	ID int64 `json:"id"`
 }
+
+type EditRequest struct {
+	ID      int64  `json:"id"`
+	Content string `json:"content"`
+}
+
+type HistoryRequest struct {
+	ID int64 `json:"id"`
+}
+
+type CommentHistory struct {
+	ID int64 `json:"id"`
+	// 修改之前的内容
+	Content string `json:"content"`
+	// 修改的时间
+	Ctime int64 `json:"ctime"`
+}
+
+type ReportRequest struct {
+	ID int64 `json:"id"`
+	// spam-垃圾广告 abuse-辱骂攻击 porn-色情低俗 illegal-违法违规 other-其他
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+type Report struct {
+	ID     int64  `json:"id"`
+	Uid    int64  `json:"uid"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+	Ctime  int64  `json:"ctime"`
+}
+
+type ReviewListRequest struct {
+	// 1-待审核 4-被举报之后隐藏
+	Status uint8 `json:"status"`
+	Offset int   `json:"offset"`
+	Limit  int   `json:"limit"`
+}
+
+type ReviewRequest struct {
+	ID int64 `json:"id"`
+}
+
+type ReportsRequest struct {
+	// 评论的ID
+	ID int64 `json:"id"`
+}
+
+type LikeToggleRequest struct {
+	ID int64 `json:"id"`
+}
+
+type LikeToggleResp struct {
+	LikeCnt int64 `json:"likeCnt"`
+}
+
+type BackfillHotScoreResp struct {
+	// 处理了多少条始祖评论
+	Total int64 `json:"total"`
+}
+
+type PinRequest struct {
+	ID int64 `json:"id"`
+	// true-置顶 false-取消置顶
+	Pinned bool `json:"pinned"`
+}
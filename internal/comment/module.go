@@ -1,8 +1,15 @@
 package comment
 
-import "github.com/ecodeclub/webook/internal/comment/internal/web"
+import (
+	"github.com/ecodeclub/webook/internal/comment/internal/service"
+	"github.com/ecodeclub/webook/internal/comment/internal/web"
+)
 
 type Module struct {
-	Hdl *Handler
+	Hdl        *Handler
+	AdminHdl   *AdminHandler
+	PrivacySvc PrivacyService
 }
 type Handler = web.Handler
+type AdminHandler = web.AdminHandler
+type PrivacyService = service.PrivacyService
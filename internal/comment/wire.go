@@ -25,6 +25,8 @@ import (
 	"github.com/ecodeclub/webook/internal/comment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/comment/internal/service"
 	"github.com/ecodeclub/webook/internal/comment/internal/web"
+	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/user"
 	"github.com/ego-component/egorm"
 	"github.com/google/wire"
@@ -33,14 +35,22 @@ import (
 func InitModule(
 	db *egorm.Component,
 	q mq.MQ,
-	userModule *user.Module) (*Module, error) {
+	userModule *user.Module,
+	intrModule *interactive.Module,
+	filter moderation.Filter) (*Module, error) {
 	wire.Build(
 		initCommentDAO,
+		dao.NewReportGORMDAO,
 		repository.NewCommentRepository,
 		service.NewCommentService,
+		initReportService,
+		service.NewPrivacyService,
 		event.NewQYWeChatEventProducer,
+		event.NewNotificationEventProducer,
 		web.NewHandler,
+		web.NewAdminHandler,
 		wire.FieldsOf(new(*user.Module), "Svc"),
+		wire.FieldsOf(new(*interactive.Module), "Svc"),
 		wire.Struct(new(Module), "*"),
 	)
 	return new(Module), nil
@@ -58,3 +68,8 @@ func initCommentDAO(db *egorm.Component) (dao.CommentDAO, error) {
 	}
 	return dao.NewCommentGORMDAO(db), nil
 }
+
+// initReportService 被举报五次之后自动隐藏
+func initReportService(repo repository.CommentRepository) service.ReportService {
+	return service.NewReportService(repo, 5)
+}
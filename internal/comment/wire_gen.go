@@ -15,6 +15,8 @@ import (
 	"github.com/ecodeclub/webook/internal/comment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/comment/internal/service"
 	"github.com/ecodeclub/webook/internal/comment/internal/web"
+	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/user"
 	"github.com/ego-component/egorm"
 	"gorm.io/gorm"
@@ -22,21 +24,32 @@ import (
 
 // Injectors from wire.go:
 
-func InitModule(db *gorm.DB, q mq.MQ, userModule *user.Module) (*Module, error) {
+func InitModule(db *gorm.DB, q mq.MQ, userModule *user.Module, intrModule *interactive.Module, filter moderation.Filter) (*Module, error) {
 	userService := userModule.Svc
+	serviceService := intrModule.Svc
 	commentDAO, err := initCommentDAO(db)
 	if err != nil {
 		return nil, err
 	}
-	commentRepository := repository.NewCommentRepository(commentDAO)
-	commentService := service.NewCommentService(userService, commentRepository)
+	reportDAO := dao.NewReportGORMDAO(db)
+	commentRepository := repository.NewCommentRepository(commentDAO, reportDAO)
+	notificationEventProducer, err := event.NewNotificationEventProducer(q)
+	if err != nil {
+		return nil, err
+	}
+	commentService := service.NewCommentService(userService, serviceService, commentRepository, filter, notificationEventProducer)
+	reportService := initReportService(commentRepository)
 	wechatRobotEventProducer, err := event.NewQYWeChatEventProducer(q)
 	if err != nil {
 		return nil, err
 	}
-	handler := web.NewHandler(commentService, wechatRobotEventProducer)
+	handler := web.NewHandler(commentService, reportService, wechatRobotEventProducer)
+	adminHandler := web.NewAdminHandler(commentService, reportService)
+	privacyService := service.NewPrivacyService(commentRepository)
 	module := &Module{
-		Hdl: handler,
+		Hdl:        handler,
+		AdminHdl:   adminHandler,
+		PrivacySvc: privacyService,
 	}
 	return module, nil
 }
@@ -55,3 +68,8 @@ func initCommentDAO(db *egorm.Component) (dao.CommentDAO, error) {
 	}
 	return dao.NewCommentGORMDAO(db), nil
 }
+
+// initReportService 被举报五次之后自动隐藏
+func initReportService(repo repository.CommentRepository) service.ReportService {
+	return service.NewReportService(repo, 5)
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+import "slices"
+
+// Type 优惠券类型
+type Type uint8
+
+func (t Type) ToUint8() uint8 {
+	return uint8(t)
+}
+
+func (t Type) Valid() bool {
+	return t >= TypeFixed && t <= TypeThreshold
+}
+
+const (
+	// TypeFixed 立减, 直接减去 Amount
+	TypeFixed Type = 1
+	// TypePercentage 折扣, 按照 Rate 打折, MaxDiscount 大于 0 时为优惠上限
+	TypePercentage Type = 2
+	// TypeThreshold 满减, 适用商品的金额满 Threshold 之后减去 Amount
+	TypeThreshold Type = 3
+)
+
+// ScopeType 优惠券的适用范围
+type ScopeType uint8
+
+func (s ScopeType) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	// ScopeTypeAll 全部商品
+	ScopeTypeAll ScopeType = 1
+	// ScopeTypeSKU 指定商品, Values 为 SKU 的 SN
+	ScopeTypeSKU ScopeType = 2
+	// ScopeTypeCategory 指定分类, Values 为 SPU 的 Category0 或者 Category1, 例如 member
+	ScopeTypeCategory ScopeType = 3
+)
+
+type Scope struct {
+	Type   ScopeType
+	Values []string
+}
+
+func (s Scope) Contains(item Item) bool {
+	switch s.Type {
+	case ScopeTypeSKU:
+		return slices.Contains(s.Values, item.SKUSN)
+	case ScopeTypeCategory:
+		return slices.Contains(s.Values, item.Category0) ||
+			slices.Contains(s.Values, item.Category1)
+	default:
+		return true
+	}
+}
+
+// Rule 优惠规则, 金额的单位都是分
+type Rule struct {
+	Type Type
+	// Threshold 使用门槛, 适用商品的金额要达到这个值, 0 表示没有门槛
+	Threshold int64
+	// Amount 立减和满减的优惠金额
+	Amount int64
+	// Rate 折扣率, 85 表示八五折
+	Rate int64
+	// MaxDiscount 折扣券的优惠上限, 0 表示不限制
+	MaxDiscount int64
+	Scope       Scope
+}
+
+type TemplateStatus uint8
+
+func (s TemplateStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	TemplateStatusEnabled  TemplateStatus = 1
+	TemplateStatusDisabled TemplateStatus = 2
+)
+
+const (
+	// ActivityRegistration 注册之后发给新用户
+	ActivityRegistration = "registration"
+	// ActivityInvitation 邀请注册成功之后发给邀请人
+	ActivityInvitation = "invitation"
+)
+
+// Template 优惠券模板, 发放的时候把规则复制到用户的优惠券上,
+// 之后修改模板不会影响已经发出去的优惠券
+type Template struct {
+	ID   int64
+	Name string
+	Desc string
+	Rule Rule
+	// Activity 自动发放的活动, 为空表示只能由管理员手动发放
+	Activity string
+	// ValidDays 领取之后的有效天数
+	ValidDays int64
+	Status    TemplateStatus
+	Ctime     int64
+	Utime     int64
+}
+
+type Status uint8
+
+func (s Status) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	StatusUnused Status = 1
+	// StatusLocked 下单的时候锁定, 支付成功之后变为已使用, 取消或者超时之后释放
+	StatusLocked Status = 2
+	StatusUsed   Status = 3
+	// StatusExpired 未使用并且已经过期, 只用于查询, 不会落库
+	StatusExpired Status = 4
+)
+
+// Coupon 用户领取的优惠券
+type Coupon struct {
+	ID         int64
+	Uid        int64
+	TemplateID int64
+	Name       string
+	Desc       string
+	Rule       Rule
+	Status     Status
+	// OrderSN 锁定或者使用这张优惠券的订单
+	OrderSN  string
+	ExpireAt int64
+	Ctime    int64
+	Utime    int64
+}
+
+// Available 未使用并且还没有过期, now 为毫秒时间戳
+func (c Coupon) Available(now int64) bool {
+	return c.Status == StatusUnused && c.ExpireAt > now
+}
+
+// Item 参与计算优惠的订单项
+type Item struct {
+	SKUSN     string
+	Category0 string
+	Category1 string
+	// Amount 这一项的原始总价, 也就是单价乘以数量
+	Amount int64
+}
+
+// Discount 使用某一张优惠券之后的优惠明细
+type Discount struct {
+	Coupon Coupon
+	// Amount 总共优惠的金额
+	Amount int64
+	// Items 每一个订单项分摊到的优惠, 和传入的 Item 一一对应
+	Items []int64
+}
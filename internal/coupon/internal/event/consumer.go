@@ -0,0 +1,75 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/coupon/internal/service"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+type CouponEventConsumer struct {
+	consumer mq.Consumer
+	svc      service.Service
+	logger   *elog.Component
+}
+
+func NewCouponEventConsumer(q mq.MQ, svc service.Service) (*CouponEventConsumer, error) {
+	groupID := "coupon"
+	consumer, err := q.Consumer(CouponEventName, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return &CouponEventConsumer{
+		consumer: consumer,
+		svc:      svc,
+		logger:   elog.DefaultLogger.With(elog.FieldComponent("coupon.consumer")),
+	}, nil
+}
+
+func (c *CouponEventConsumer) Start(ctx context.Context) {
+	go func() {
+		for {
+			err := c.Consume(ctx)
+			if err != nil {
+				c.logger.Error("消费优惠券发放事件失败", elog.FieldErr(err))
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (c *CouponEventConsumer) Consume(ctx context.Context) error {
+	msg, err := c.consumer.Consume(ctx)
+	if err != nil {
+		return fmt.Errorf("获取消息失败: %w", err)
+	}
+	var evt CouponEvent
+	err = json.Unmarshal(msg.Value, &evt)
+	if err != nil {
+		return fmt.Errorf("解析消息失败: %w", err)
+	}
+	err = c.svc.IssueByActivity(ctx, evt.Uid, evt.Activity, evt.Key)
+	if err != nil {
+		return fmt.Errorf("发放优惠券失败, uid: %d, activity: %s: %w", evt.Uid, evt.Activity, err)
+	}
+	return nil
+}
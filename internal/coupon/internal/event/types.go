@@ -0,0 +1,24 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+const CouponEventName = "coupon_issue_events"
+
+// CouponEvent 营销活动触发的优惠券发放, 发放活动关联的所有优惠券
+type CouponEvent struct {
+	Key      string `json:"key"`
+	Uid      int64  `json:"uid"`
+	Activity string `json:"activity"` // registration, invitation
+}
@@ -0,0 +1,323 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/ekit/sqlx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/coupon"
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/ecodeclub/webook/internal/coupon/internal/errs"
+	"github.com/ecodeclub/webook/internal/coupon/internal/event"
+	"github.com/ecodeclub/webook/internal/coupon/internal/integration/startup"
+	"github.com/ecodeclub/webook/internal/coupon/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/coupon/internal/web"
+	"github.com/ecodeclub/webook/internal/test"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ego-component/egorm"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/server/egin"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const uid = int64(123)
+
+type ModuleTestSuite struct {
+	suite.Suite
+	server      *egin.Component
+	adminServer *egin.Component
+	db          *egorm.Component
+	dao         dao.CouponDAO
+	svc         coupon.Service
+	producer    mq.Producer
+}
+
+func (s *ModuleTestSuite) SetupSuite() {
+	module, err := startup.InitModule()
+	require.NoError(s.T(), err)
+	s.svc = module.Svc
+
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	server := egin.Load("server").Build()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("_session", session.NewMemorySession(session.Claims{Uid: uid}))
+	})
+	module.Hdl.PrivateRoutes(server.Engine)
+	s.server = server
+
+	adminServer := egin.Load("server").Build()
+	module.AdminHdl.PrivateRoutes(adminServer.Engine)
+	s.adminServer = adminServer
+
+	s.db = testioc.InitDB()
+	s.dao = dao.NewCouponGORMDAO(s.db)
+	s.producer, err = testioc.InitMQ().Producer(event.CouponEventName)
+	require.NoError(s.T(), err)
+}
+
+func (s *ModuleTestSuite) TearDownTest() {
+	s.NoError(s.db.Exec("TRUNCATE TABLE `coupon_templates`").Error)
+	s.NoError(s.db.Exec("TRUNCATE TABLE `coupons`").Error)
+}
+
+func (s *ModuleTestSuite) TestAdmin_Template() {
+	// 满减金额不能大于门槛
+	res := post[int64](s, s.adminServer, "/coupon/template/save", web.Template{
+		Name: "满100减100",
+		Rule: web.Rule{
+			Type:      domain.TypeThreshold.ToUint8(),
+			Threshold: 10000,
+			Amount:    10000,
+			ScopeType: domain.ScopeTypeAll.ToUint8(),
+		},
+		ValidDays: 7,
+		Status:    domain.TemplateStatusEnabled.ToUint8(),
+	})
+	s.Equal(errs.TemplateInvalid.Code, res.Code)
+
+	tmpl := web.Template{
+		Name: "会员八折券",
+		Desc: "只能用于购买会员",
+		Rule: web.Rule{
+			Type:        domain.TypePercentage.ToUint8(),
+			Rate:        80,
+			MaxDiscount: 5000,
+			ScopeType:   domain.ScopeTypeCategory.ToUint8(),
+			ScopeValues: []string{"member"},
+		},
+		Activity:  domain.ActivityRegistration,
+		ValidDays: 7,
+		Status:    domain.TemplateStatusEnabled.ToUint8(),
+	}
+	res = post[int64](s, s.adminServer, "/coupon/template/save", tmpl)
+	s.Equal(0, res.Code)
+	tmpl.ID = res.Data
+
+	list := post[web.ListTemplatesResp](s, s.adminServer, "/coupon/template/list", web.ListTemplatesReq{Limit: 10})
+	s.Equal(0, list.Code)
+	s.Equal(int64(1), list.Data.Total)
+	actual := list.Data.Templates[0]
+	s.True(actual.Ctime > 0)
+	actual.Ctime, actual.Utime = 0, 0
+	s.Equal(tmpl, actual)
+
+	// 手动发放, 同一个 key 只发放一次
+	issue := web.IssueReq{Uid: uid, TemplateID: tmpl.ID, Key: "compensation-1"}
+	s.Equal(0, post[any](s, s.adminServer, "/coupon/issue", issue).Code)
+	s.Equal(0, post[any](s, s.adminServer, "/coupon/issue", issue).Code)
+	cnt, err := s.dao.CountCouponsByUid(context.Background(), uid, 0, time.Now().UnixMilli())
+	s.NoError(err)
+	s.Equal(int64(1), cnt)
+}
+
+func (s *ModuleTestSuite) TestConsume() {
+	ctx := context.Background()
+	_, err := s.dao.SaveTemplate(ctx, s.newTemplate(domain.ActivityRegistration, domain.TemplateStatusEnabled))
+	s.NoError(err)
+	_, err = s.dao.SaveTemplate(ctx, s.newTemplate(domain.ActivityRegistration, domain.TemplateStatusDisabled))
+	s.NoError(err)
+	_, err = s.dao.SaveTemplate(ctx, s.newTemplate(domain.ActivityInvitation, domain.TemplateStatusEnabled))
+	s.NoError(err)
+
+	evt := event.CouponEvent{
+		Key:      "user-registration-123",
+		Uid:      uid,
+		Activity: domain.ActivityRegistration,
+	}
+	// 重复投递也只发放一次
+	s.produce(evt)
+	s.produce(evt)
+
+	s.Eventually(func() bool {
+		cnt, err := s.dao.CountCouponsByUid(ctx, uid, 0, time.Now().UnixMilli())
+		return err == nil && cnt == 1
+	}, time.Second*10, time.Millisecond*100)
+	// 等待第二条消息也被消费
+	time.Sleep(time.Second)
+	cs, err := s.dao.FindCouponsByUid(ctx, uid, 0, time.Now().UnixMilli(), 0, 10)
+	s.NoError(err)
+	s.Len(cs, 1)
+	s.Equal(domain.StatusUnused.ToUint8(), cs[0].Status)
+	s.Equal(domain.TypeFixed.ToUint8(), cs[0].Type)
+	s.True(cs[0].ExpireAt > time.Now().Add(6*24*time.Hour).UnixMilli())
+}
+
+func (s *ModuleTestSuite) TestLockConsumeRelease() {
+	ctx := context.Background()
+	err := s.dao.CreateCoupons(ctx, []dao.Coupon{
+		s.newCoupon(1, domain.StatusUnused, time.Now().Add(time.Hour).UnixMilli()),
+		// 过期的
+		s.newCoupon(2, domain.StatusUnused, time.Now().Add(-time.Hour).UnixMilli()),
+	})
+	s.NoError(err)
+	cs, err := s.dao.FindCouponsByUid(ctx, uid, 0, time.Now().UnixMilli(), 0, 10)
+	s.NoError(err)
+	s.Len(cs, 2)
+	expired, available := cs[0], cs[1]
+
+	items := []domain.Item{
+		{SKUSN: "member-sku", Category0: "product", Category1: "member", Amount: 9900},
+		{SKUSN: "project-sku", Category0: "product", Category1: "project", Amount: 19900},
+	}
+	usable, err := s.svc.FindUsableCoupons(ctx, uid, items)
+	s.NoError(err)
+	s.Len(usable, 1)
+	s.Equal(available.Id, usable[0].Coupon.ID)
+	s.Equal(int64(1000), usable[0].Amount)
+
+	_, err = s.svc.Lock(ctx, uid, expired.Id, "order-sn-1", items)
+	s.ErrorIs(err, coupon.ErrCouponUnavailable)
+	// 别人的优惠券
+	_, err = s.svc.Lock(ctx, uid+1, available.Id, "order-sn-1", items)
+	s.ErrorIs(err, coupon.ErrCouponUnavailable)
+
+	d, err := s.svc.Lock(ctx, uid, available.Id, "order-sn-1", items)
+	s.NoError(err)
+	s.Equal(int64(1000), d.Amount)
+	s.Equal([]int64{332, 668}, d.Items)
+	// 已经被锁定的不能再用
+	_, err = s.svc.Lock(ctx, uid, available.Id, "order-sn-2", items)
+	s.ErrorIs(err, coupon.ErrCouponUnavailable)
+
+	// 订单取消之后可以再次使用
+	s.NoError(s.svc.Release(ctx, "order-sn-1"))
+	c, err := s.dao.FindCouponByID(ctx, available.Id)
+	s.NoError(err)
+	s.Equal(domain.StatusUnused.ToUint8(), c.Status)
+	s.Equal("", c.OrderSn)
+
+	_, err = s.svc.Lock(ctx, uid, available.Id, "order-sn-2", items)
+	s.NoError(err)
+	// 其他订单不影响
+	s.NoError(s.svc.Consume(ctx, "order-sn-1"))
+	s.NoError(s.svc.Consume(ctx, "order-sn-2"))
+	c, err = s.dao.FindCouponByID(ctx, available.Id)
+	s.NoError(err)
+	s.Equal(domain.StatusUsed.ToUint8(), c.Status)
+	s.Equal("order-sn-2", c.OrderSn)
+	// 已经使用的不会被释放
+	s.NoError(s.svc.Release(ctx, "order-sn-2"))
+	c, err = s.dao.FindCouponByID(ctx, available.Id)
+	s.NoError(err)
+	s.Equal(domain.StatusUsed.ToUint8(), c.Status)
+}
+
+func (s *ModuleTestSuite) TestHandler_List() {
+	now := time.Now()
+	err := s.dao.CreateCoupons(context.Background(), []dao.Coupon{
+		s.newCoupon(1, domain.StatusUnused, now.Add(time.Hour).UnixMilli()),
+		s.newCoupon(2, domain.StatusUnused, now.Add(-time.Hour).UnixMilli()),
+		s.newCoupon(3, domain.StatusUsed, now.Add(time.Hour).UnixMilli()),
+	})
+	s.NoError(err)
+
+	testCases := []struct {
+		name   string
+		status domain.Status
+		want   []uint8
+	}{
+		{
+			name: "全部",
+			want: []uint8{domain.StatusUsed.ToUint8(), domain.StatusExpired.ToUint8(), domain.StatusUnused.ToUint8()},
+		},
+		{
+			name:   "未使用",
+			status: domain.StatusUnused,
+			want:   []uint8{domain.StatusUnused.ToUint8()},
+		},
+		{
+			name:   "已过期",
+			status: domain.StatusExpired,
+			want:   []uint8{domain.StatusExpired.ToUint8()},
+		},
+		{
+			name:   "已使用",
+			status: domain.StatusUsed,
+			want:   []uint8{domain.StatusUsed.ToUint8()},
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			res := post[web.ListCouponsResp](s, s.server, "/coupon/list",
+				web.ListCouponsReq{Status: tc.status.ToUint8(), Limit: 10})
+			s.Equal(0, res.Code)
+			s.Equal(int64(len(tc.want)), res.Data.Total)
+			statuses := make([]uint8, 0, len(res.Data.Coupons))
+			for _, c := range res.Data.Coupons {
+				statuses = append(statuses, c.Status)
+			}
+			s.Equal(tc.want, statuses)
+		})
+	}
+}
+
+func (s *ModuleTestSuite) newTemplate(activity string, status domain.TemplateStatus) dao.Template {
+	return dao.Template{
+		Name:      "立减10元",
+		Type:      domain.TypeFixed.ToUint8(),
+		Amount:    1000,
+		ScopeType: domain.ScopeTypeAll.ToUint8(),
+		Activity:  activity,
+		ValidDays: 7,
+		Status:    status.ToUint8(),
+	}
+}
+
+func (s *ModuleTestSuite) newCoupon(templateID int64, status domain.Status, expireAt int64) dao.Coupon {
+	return dao.Coupon{
+		Uid:         uid,
+		TemplateId:  templateID,
+		BizKey:      "test",
+		Name:        "立减10元",
+		Type:        domain.TypeFixed.ToUint8(),
+		Amount:      1000,
+		ScopeType:   domain.ScopeTypeAll.ToUint8(),
+		ScopeValues: sqlx.JsonColumn[[]string]{},
+		Status:      status.ToUint8(),
+		ExpireAt:    expireAt,
+	}
+}
+
+func (s *ModuleTestSuite) produce(evt event.CouponEvent) {
+	val, err := json.Marshal(evt)
+	s.NoError(err)
+	_, err = s.producer.Produce(context.Background(), &mq.Message{Value: val})
+	s.NoError(err)
+}
+
+func post[T any](s *ModuleTestSuite, server *egin.Component, path string, req any) test.Result[T] {
+	httpReq, err := http.NewRequest(http.MethodPost, path, iox.NewJSONReader(req))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[T]()
+	server.ServeHTTP(recorder, httpReq)
+	s.Equal(http.StatusOK, recorder.Code)
+	return recorder.MustScan()
+}
+
+func TestModule(t *testing.T) {
+	suite.Run(t, new(ModuleTestSuite))
+}
@@ -0,0 +1,235 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ekit/sqlx"
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/ecodeclub/webook/internal/coupon/internal/repository/dao"
+)
+
+var (
+	ErrRecordNotFound    = dao.ErrRecordNotFound
+	ErrCouponUnavailable = dao.ErrCouponUnavailable
+)
+
+type CouponRepository interface {
+	SaveTemplate(ctx context.Context, t domain.Template) (int64, error)
+	FindTemplateByID(ctx context.Context, id int64) (domain.Template, error)
+	ListTemplates(ctx context.Context, offset, limit int) ([]domain.Template, error)
+	TotalTemplates(ctx context.Context) (int64, error)
+	FindEnabledTemplatesByActivity(ctx context.Context, activity string) ([]domain.Template, error)
+
+	CreateCoupons(ctx context.Context, key string, cs []domain.Coupon) error
+	FindCouponByID(ctx context.Context, id int64) (domain.Coupon, error)
+	FindCouponsByUid(ctx context.Context, uid int64, status domain.Status, now int64, offset, limit int) ([]domain.Coupon, error)
+	TotalCouponsByUid(ctx context.Context, uid int64, status domain.Status, now int64) (int64, error)
+	FindAvailableCoupons(ctx context.Context, uid int64, now int64) ([]domain.Coupon, error)
+	LockCoupon(ctx context.Context, id, uid int64, orderSN string, now int64) error
+	ConsumeCoupons(ctx context.Context, orderSN string) error
+	ReleaseCoupons(ctx context.Context, orderSNs []string) error
+}
+
+type couponRepository struct {
+	dao dao.CouponDAO
+}
+
+func NewCouponRepository(d dao.CouponDAO) CouponRepository {
+	return &couponRepository{dao: d}
+}
+
+func (r *couponRepository) SaveTemplate(ctx context.Context, t domain.Template) (int64, error) {
+	return r.dao.SaveTemplate(ctx, r.toTemplateEntity(t))
+}
+
+func (r *couponRepository) FindTemplateByID(ctx context.Context, id int64) (domain.Template, error) {
+	t, err := r.dao.FindTemplateByID(ctx, id)
+	if err != nil {
+		return domain.Template{}, err
+	}
+	return r.toTemplateDomain(t), nil
+}
+
+func (r *couponRepository) ListTemplates(ctx context.Context, offset, limit int) ([]domain.Template, error) {
+	ts, err := r.dao.ListTemplates(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(ts, func(idx int, src dao.Template) domain.Template {
+		return r.toTemplateDomain(src)
+	}), nil
+}
+
+func (r *couponRepository) TotalTemplates(ctx context.Context) (int64, error) {
+	return r.dao.CountTemplates(ctx)
+}
+
+func (r *couponRepository) FindEnabledTemplatesByActivity(ctx context.Context, activity string) ([]domain.Template, error) {
+	ts, err := r.dao.FindEnabledTemplatesByActivity(ctx, activity)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(ts, func(idx int, src dao.Template) domain.Template {
+		return r.toTemplateDomain(src)
+	}), nil
+}
+
+func (r *couponRepository) CreateCoupons(ctx context.Context, key string, cs []domain.Coupon) error {
+	return r.dao.CreateCoupons(ctx, slice.Map(cs, func(idx int, src domain.Coupon) dao.Coupon {
+		return r.toCouponEntity(key, src)
+	}))
+}
+
+func (r *couponRepository) FindCouponByID(ctx context.Context, id int64) (domain.Coupon, error) {
+	c, err := r.dao.FindCouponByID(ctx, id)
+	if err != nil {
+		return domain.Coupon{}, err
+	}
+	return r.toCouponDomain(c), nil
+}
+
+func (r *couponRepository) FindCouponsByUid(ctx context.Context, uid int64, status domain.Status, now int64, offset, limit int) ([]domain.Coupon, error) {
+	cs, err := r.dao.FindCouponsByUid(ctx, uid, status.ToUint8(), now, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(cs, func(idx int, src dao.Coupon) domain.Coupon {
+		return r.toCouponDomain(src)
+	}), nil
+}
+
+func (r *couponRepository) TotalCouponsByUid(ctx context.Context, uid int64, status domain.Status, now int64) (int64, error) {
+	return r.dao.CountCouponsByUid(ctx, uid, status.ToUint8(), now)
+}
+
+func (r *couponRepository) FindAvailableCoupons(ctx context.Context, uid int64, now int64) ([]domain.Coupon, error) {
+	cs, err := r.dao.FindAvailableCoupons(ctx, uid, now)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(cs, func(idx int, src dao.Coupon) domain.Coupon {
+		return r.toCouponDomain(src)
+	}), nil
+}
+
+func (r *couponRepository) LockCoupon(ctx context.Context, id, uid int64, orderSN string, now int64) error {
+	return r.dao.LockCoupon(ctx, id, uid, orderSN, now)
+}
+
+func (r *couponRepository) ConsumeCoupons(ctx context.Context, orderSN string) error {
+	return r.dao.ConsumeCoupons(ctx, orderSN)
+}
+
+func (r *couponRepository) ReleaseCoupons(ctx context.Context, orderSNs []string) error {
+	return r.dao.ReleaseCoupons(ctx, orderSNs)
+}
+
+func (r *couponRepository) toTemplateEntity(t domain.Template) dao.Template {
+	return dao.Template{
+		Id:          t.ID,
+		Name:        t.Name,
+		Description: t.Desc,
+		Type:        t.Rule.Type.ToUint8(),
+		Threshold:   t.Rule.Threshold,
+		Amount:      t.Rule.Amount,
+		Rate:        t.Rule.Rate,
+		MaxDiscount: t.Rule.MaxDiscount,
+		ScopeType:   t.Rule.Scope.Type.ToUint8(),
+		ScopeValues: sqlx.JsonColumn[[]string]{
+			Valid: len(t.Rule.Scope.Values) > 0,
+			Val:   t.Rule.Scope.Values,
+		},
+		Activity:  t.Activity,
+		ValidDays: t.ValidDays,
+		Status:    t.Status.ToUint8(),
+	}
+}
+
+func (r *couponRepository) toTemplateDomain(t dao.Template) domain.Template {
+	return domain.Template{
+		ID:   t.Id,
+		Name: t.Name,
+		Desc: t.Description,
+		Rule: domain.Rule{
+			Type:        domain.Type(t.Type),
+			Threshold:   t.Threshold,
+			Amount:      t.Amount,
+			Rate:        t.Rate,
+			MaxDiscount: t.MaxDiscount,
+			Scope: domain.Scope{
+				Type:   domain.ScopeType(t.ScopeType),
+				Values: t.ScopeValues.Val,
+			},
+		},
+		Activity:  t.Activity,
+		ValidDays: t.ValidDays,
+		Status:    domain.TemplateStatus(t.Status),
+		Ctime:     t.Ctime,
+		Utime:     t.Utime,
+	}
+}
+
+func (r *couponRepository) toCouponEntity(key string, c domain.Coupon) dao.Coupon {
+	return dao.Coupon{
+		Id:          c.ID,
+		Uid:         c.Uid,
+		TemplateId:  c.TemplateID,
+		BizKey:      key,
+		Name:        c.Name,
+		Description: c.Desc,
+		Type:        c.Rule.Type.ToUint8(),
+		Threshold:   c.Rule.Threshold,
+		Amount:      c.Rule.Amount,
+		Rate:        c.Rule.Rate,
+		MaxDiscount: c.Rule.MaxDiscount,
+		ScopeType:   c.Rule.Scope.Type.ToUint8(),
+		ScopeValues: sqlx.JsonColumn[[]string]{
+			Valid: len(c.Rule.Scope.Values) > 0,
+			Val:   c.Rule.Scope.Values,
+		},
+		Status:   c.Status.ToUint8(),
+		OrderSn:  c.OrderSN,
+		ExpireAt: c.ExpireAt,
+	}
+}
+
+func (r *couponRepository) toCouponDomain(c dao.Coupon) domain.Coupon {
+	return domain.Coupon{
+		ID:         c.Id,
+		Uid:        c.Uid,
+		TemplateID: c.TemplateId,
+		Name:       c.Name,
+		Desc:       c.Description,
+		Rule: domain.Rule{
+			Type:        domain.Type(c.Type),
+			Threshold:   c.Threshold,
+			Amount:      c.Amount,
+			Rate:        c.Rate,
+			MaxDiscount: c.MaxDiscount,
+			Scope: domain.Scope{
+				Type:   domain.ScopeType(c.ScopeType),
+				Values: c.ScopeValues.Val,
+			},
+		},
+		Status:   domain.Status(c.Status),
+		OrderSN:  c.OrderSn,
+		ExpireAt: c.ExpireAt,
+		Ctime:    c.Ctime,
+		Utime:    c.Utime,
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/ekit/sqlx"
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRecordNotFound    = gorm.ErrRecordNotFound
+	ErrCouponUnavailable = errors.New("优惠券不可用")
+)
+
+type CouponDAO interface {
+	SaveTemplate(ctx context.Context, t Template) (int64, error)
+	FindTemplateByID(ctx context.Context, id int64) (Template, error)
+	ListTemplates(ctx context.Context, offset, limit int) ([]Template, error)
+	CountTemplates(ctx context.Context) (int64, error)
+	FindEnabledTemplatesByActivity(ctx context.Context, activity string) ([]Template, error)
+
+	// CreateCoupons 同一个用户、同一个模板、同一个 BizKey 只会发放一次, 消息重复投递也没关系
+	CreateCoupons(ctx context.Context, cs []Coupon) error
+	FindCouponByID(ctx context.Context, id int64) (Coupon, error)
+	// FindCouponsByUid status 为 0 时查询全部, 未使用和已过期根据 now 区分
+	FindCouponsByUid(ctx context.Context, uid int64, status uint8, now int64, offset, limit int) ([]Coupon, error)
+	CountCouponsByUid(ctx context.Context, uid int64, status uint8, now int64) (int64, error)
+	// FindAvailableCoupons 查找用户所有未使用并且没有过期的优惠券
+	FindAvailableCoupons(ctx context.Context, uid int64, now int64) ([]Coupon, error)
+	// LockCoupon 只有未使用并且没有过期的优惠券可以被锁定, 否则返回 ErrCouponUnavailable
+	LockCoupon(ctx context.Context, id, uid int64, orderSN string, now int64) error
+	// ConsumeCoupons 把订单锁定的优惠券标记为已使用
+	ConsumeCoupons(ctx context.Context, orderSN string) error
+	// ReleaseCoupons 把订单锁定的优惠券恢复为未使用
+	ReleaseCoupons(ctx context.Context, orderSNs []string) error
+}
+
+type CouponGORMDAO struct {
+	db *egorm.Component
+}
+
+func NewCouponGORMDAO(db *egorm.Component) CouponDAO {
+	return &CouponGORMDAO{db: db}
+}
+
+func (g *CouponGORMDAO) SaveTemplate(ctx context.Context, t Template) (int64, error) {
+	now := time.Now().UnixMilli()
+	t.Ctime, t.Utime = now, now
+	err := g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "description", "type", "threshold", "amount", "rate",
+			"max_discount", "scope_type", "scope_values", "activity",
+			"valid_days", "status", "utime",
+		}),
+	}).Create(&t).Error
+	return t.Id, err
+}
+
+func (g *CouponGORMDAO) FindTemplateByID(ctx context.Context, id int64) (Template, error) {
+	var res Template
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) ListTemplates(ctx context.Context, offset, limit int) ([]Template, error) {
+	var res []Template
+	err := g.db.WithContext(ctx).Order("id DESC").Offset(offset).Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) CountTemplates(ctx context.Context) (int64, error) {
+	var res int64
+	err := g.db.WithContext(ctx).Model(&Template{}).Count(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) FindEnabledTemplatesByActivity(ctx context.Context, activity string) ([]Template, error) {
+	var res []Template
+	err := g.db.WithContext(ctx).
+		Where("activity = ? AND status = ?", activity, domain.TemplateStatusEnabled.ToUint8()).
+		Find(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) CreateCoupons(ctx context.Context, cs []Coupon) error {
+	if len(cs) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range cs {
+		cs[i].Ctime, cs[i].Utime = now, now
+	}
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&cs).Error
+}
+
+func (g *CouponGORMDAO) FindCouponByID(ctx context.Context, id int64) (Coupon, error) {
+	var res Coupon
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) FindCouponsByUid(ctx context.Context, uid int64, status uint8, now int64, offset, limit int) ([]Coupon, error) {
+	var res []Coupon
+	err := g.whereUid(ctx, uid, status, now).
+		Order("id DESC").Offset(offset).Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) CountCouponsByUid(ctx context.Context, uid int64, status uint8, now int64) (int64, error) {
+	var res int64
+	err := g.whereUid(ctx, uid, status, now).Count(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) whereUid(ctx context.Context, uid int64, status uint8, now int64) *gorm.DB {
+	query := g.db.WithContext(ctx).Model(&Coupon{}).Where("uid = ?", uid)
+	switch domain.Status(status) {
+	case domain.StatusUnused:
+		query = query.Where("status = ? AND expire_at > ?", status, now)
+	case domain.StatusExpired:
+		query = query.Where("status = ? AND expire_at <= ?", domain.StatusUnused.ToUint8(), now)
+	case domain.StatusLocked, domain.StatusUsed:
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
+
+func (g *CouponGORMDAO) FindAvailableCoupons(ctx context.Context, uid int64, now int64) ([]Coupon, error) {
+	var res []Coupon
+	err := g.whereUid(ctx, uid, domain.StatusUnused.ToUint8(), now).
+		Order("expire_at ASC").Find(&res).Error
+	return res, err
+}
+
+func (g *CouponGORMDAO) LockCoupon(ctx context.Context, id, uid int64, orderSN string, now int64) error {
+	res := g.db.WithContext(ctx).Model(&Coupon{}).
+		Where("id = ? AND uid = ? AND status = ? AND expire_at > ?", id, uid, domain.StatusUnused.ToUint8(), now).
+		Updates(map[string]any{
+			"status":   domain.StatusLocked.ToUint8(),
+			"order_sn": orderSN,
+			"utime":    time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrCouponUnavailable)
+	}
+	return nil
+}
+
+func (g *CouponGORMDAO) ConsumeCoupons(ctx context.Context, orderSN string) error {
+	return g.db.WithContext(ctx).Model(&Coupon{}).
+		Where("order_sn = ? AND status = ?", orderSN, domain.StatusLocked.ToUint8()).
+		Updates(map[string]any{
+			"status": domain.StatusUsed.ToUint8(),
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+func (g *CouponGORMDAO) ReleaseCoupons(ctx context.Context, orderSNs []string) error {
+	if len(orderSNs) == 0 {
+		return nil
+	}
+	return g.db.WithContext(ctx).Model(&Coupon{}).
+		Where("order_sn IN ? AND status = ?", orderSNs, domain.StatusLocked.ToUint8()).
+		Updates(map[string]any{
+			"status":   domain.StatusUnused.ToUint8(),
+			"order_sn": "",
+			"utime":    time.Now().UnixMilli(),
+		}).Error
+}
+
+// Template 优惠券模板
+type Template struct {
+	Id          int64                     `gorm:"primaryKey;autoIncrement;comment:优惠券模板自增ID"`
+	Name        string                    `gorm:"type:varchar(255);not null;comment:优惠券名称"`
+	Description string                    `gorm:"type:varchar(1024);not null;default:'';comment:使用说明"`
+	Type        uint8                     `gorm:"type:tinyint unsigned;not null;comment:优惠券类型 1=立减 2=折扣 3=满减"`
+	Threshold   int64                     `gorm:"not null;default:0;comment:使用门槛,单位为分, 0表示没有门槛"`
+	Amount      int64                     `gorm:"not null;default:0;comment:立减和满减的优惠金额,单位为分"`
+	Rate        int64                     `gorm:"not null;default:0;comment:折扣率, 85表示八五折"`
+	MaxDiscount int64                     `gorm:"not null;default:0;comment:折扣券的优惠上限,单位为分, 0表示不限制"`
+	ScopeType   uint8                     `gorm:"type:tinyint unsigned;not null;default:1;comment:适用范围 1=全部商品 2=指定SKU 3=指定分类"`
+	ScopeValues sqlx.JsonColumn[[]string] `gorm:"type:json;comment:适用的SKU SN或者分类"`
+	Activity    string                    `gorm:"type:varchar(64);not null;default:'';index:idx_activity;comment:自动发放的活动 registration/invitation, 空表示手动发放"`
+	ValidDays   int64                     `gorm:"not null;comment:领取之后的有效天数"`
+	Status      uint8                     `gorm:"type:tinyint unsigned;not null;default:1;comment:状态 1=启用 2=停用"`
+	Ctime       int64
+	Utime       int64
+}
+
+func (Template) TableName() string {
+	return "coupon_templates"
+}
+
+// Coupon 用户的优惠券, 发放的时候复制模板的规则
+type Coupon struct {
+	Id          int64                     `gorm:"primaryKey;autoIncrement;comment:优惠券自增ID"`
+	Uid         int64                     `gorm:"not null;uniqueIndex:uniq_uid_template_biz_key;index:idx_uid_status;comment:优惠券的拥有者"`
+	TemplateId  int64                     `gorm:"not null;uniqueIndex:uniq_uid_template_biz_key;comment:优惠券模板ID"`
+	BizKey      string                    `gorm:"type:varchar(255);not null;uniqueIndex:uniq_uid_template_biz_key;comment:发放的业务标识,用于去重"`
+	Name        string                    `gorm:"type:varchar(255);not null;comment:优惠券名称"`
+	Description string                    `gorm:"type:varchar(1024);not null;default:'';comment:使用说明"`
+	Type        uint8                     `gorm:"type:tinyint unsigned;not null;comment:优惠券类型 1=立减 2=折扣 3=满减"`
+	Threshold   int64                     `gorm:"not null;default:0;comment:使用门槛,单位为分, 0表示没有门槛"`
+	Amount      int64                     `gorm:"not null;default:0;comment:立减和满减的优惠金额,单位为分"`
+	Rate        int64                     `gorm:"not null;default:0;comment:折扣率, 85表示八五折"`
+	MaxDiscount int64                     `gorm:"not null;default:0;comment:折扣券的优惠上限,单位为分, 0表示不限制"`
+	ScopeType   uint8                     `gorm:"type:tinyint unsigned;not null;default:1;comment:适用范围 1=全部商品 2=指定SKU 3=指定分类"`
+	ScopeValues sqlx.JsonColumn[[]string] `gorm:"type:json;comment:适用的SKU SN或者分类"`
+	Status      uint8                     `gorm:"type:tinyint unsigned;not null;default:1;index:idx_uid_status;comment:状态 1=未使用 2=已锁定 3=已使用"`
+	OrderSn     string                    `gorm:"type:varchar(255);not null;default:'';index:idx_order_sn;comment:锁定或者使用这张优惠券的订单"`
+	ExpireAt    int64                     `gorm:"not null;comment:过期时间"`
+	Ctime       int64
+	Utime       int64
+}
+
+func (Coupon) TableName() string {
+	return "coupons"
+}
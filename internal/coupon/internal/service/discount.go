@@ -0,0 +1,122 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+)
+
+var errNotApplicable = errors.New("不满足优惠券的使用条件")
+
+// calculate 计算优惠金额以及每一个订单项分摊到的优惠。
+// 只有适用范围内的商品参与计算, 优惠按照金额比例分摊到这些商品上,
+// 除不尽的部分按照余数从大到小分配。优惠之后订单至少还要支付 1 分钱。
+func calculate(rule domain.Rule, items []domain.Item) (int64, []int64, error) {
+	var total, eligible int64
+	for _, item := range items {
+		total += item.Amount
+		if rule.Scope.Contains(item) {
+			eligible += item.Amount
+		}
+	}
+	if eligible == 0 || eligible < rule.Threshold {
+		return 0, nil, fmt.Errorf("%w, 适用金额: %d, 门槛: %d", errNotApplicable, eligible, rule.Threshold)
+	}
+
+	var amt int64
+	switch rule.Type {
+	case domain.TypeFixed, domain.TypeThreshold:
+		amt = rule.Amount
+	case domain.TypePercentage:
+		amt = eligible * (100 - rule.Rate) / 100
+		if rule.MaxDiscount > 0 {
+			amt = min(amt, rule.MaxDiscount)
+		}
+	default:
+		return 0, nil, fmt.Errorf("未知的优惠券类型 %d", rule.Type)
+	}
+	amt = min(amt, eligible, total-1)
+	if amt <= 0 {
+		return 0, nil, fmt.Errorf("%w, 优惠金额为 0", errNotApplicable)
+	}
+	return amt, allocate(rule.Scope, amt, eligible, items), nil
+}
+
+func allocate(scope domain.Scope, amt, eligible int64, items []domain.Item) []int64 {
+	type remainder struct {
+		idx int
+		val int64
+	}
+	res := make([]int64, len(items))
+	rems := make([]remainder, 0, len(items))
+	left := amt
+	for i, item := range items {
+		if !scope.Contains(item) {
+			continue
+		}
+		res[i] = amt * item.Amount / eligible
+		left -= res[i]
+		rems = append(rems, remainder{idx: i, val: amt * item.Amount % eligible})
+	}
+	// 剩下的金额一定小于有余数的项数, 每项最多再分 1 分钱, 不会超过这一项的金额
+	sort.SliceStable(rems, func(i, j int) bool {
+		return rems[i].val > rems[j].val
+	})
+	for i := 0; left > 0; i++ {
+		res[rems[i].idx]++
+		left--
+	}
+	return res
+}
+
+func validateRule(rule domain.Rule) error {
+	if !rule.Type.Valid() {
+		return fmt.Errorf("未知的优惠券类型 %d", rule.Type)
+	}
+	if rule.Threshold < 0 {
+		return errors.New("使用门槛不能为负数")
+	}
+	switch rule.Type {
+	case domain.TypeFixed:
+		if rule.Amount <= 0 {
+			return errors.New("立减金额必须大于 0")
+		}
+	case domain.TypeThreshold:
+		if rule.Threshold <= 0 || rule.Amount <= 0 || rule.Amount >= rule.Threshold {
+			return errors.New("满减的门槛和金额必须大于 0, 并且金额要小于门槛")
+		}
+	case domain.TypePercentage:
+		if rule.Rate <= 0 || rule.Rate >= 100 {
+			return errors.New("折扣率必须在 1 到 99 之间")
+		}
+		if rule.MaxDiscount < 0 {
+			return errors.New("优惠上限不能为负数")
+		}
+	}
+	switch rule.Scope.Type {
+	case domain.ScopeTypeAll:
+	case domain.ScopeTypeSKU, domain.ScopeTypeCategory:
+		if len(rule.Scope.Values) == 0 {
+			return errors.New("没有指定适用的商品或者分类")
+		}
+	default:
+		return fmt.Errorf("未知的适用范围 %d", rule.Scope.Type)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculate(t *testing.T) {
+	items := []domain.Item{
+		{SKUSN: "member-sku", Category0: "product", Category1: "member", Amount: 9900},
+		{SKUSN: "project-sku", Category0: "product", Category1: "project", Amount: 19900},
+		{SKUSN: "code-sku", Category0: "code", Category1: "member", Amount: 100},
+	}
+	testCases := []struct {
+		name      string
+		rule      domain.Rule
+		items     []domain.Item
+		wantAmt   int64
+		wantItems []int64
+		wantErr   error
+	}{
+		{
+			name: "立减按照金额比例分摊",
+			rule: domain.Rule{
+				Type:   domain.TypeFixed,
+				Amount: 1000,
+				Scope:  domain.Scope{Type: domain.ScopeTypeAll},
+			},
+			items:     items,
+			wantAmt:   1000,
+			wantItems: []int64{331, 666, 3},
+		},
+		{
+			name: "立减金额超过订单金额至少支付1分钱",
+			rule: domain.Rule{
+				Type:   domain.TypeFixed,
+				Amount: 100000,
+				Scope:  domain.Scope{Type: domain.ScopeTypeAll},
+			},
+			items:     items,
+			wantAmt:   29899,
+			wantItems: []int64{9900, 19899, 100},
+		},
+		{
+			name: "满减达到门槛",
+			rule: domain.Rule{
+				Type:      domain.TypeThreshold,
+				Threshold: 19900,
+				Amount:    2000,
+				Scope:     domain.Scope{Type: domain.ScopeTypeSKU, Values: []string{"project-sku"}},
+			},
+			items:     items,
+			wantAmt:   2000,
+			wantItems: []int64{0, 2000, 0},
+		},
+		{
+			name: "满减没有达到门槛",
+			rule: domain.Rule{
+				Type:      domain.TypeThreshold,
+				Threshold: 10000,
+				Amount:    2000,
+				Scope:     domain.Scope{Type: domain.ScopeTypeSKU, Values: []string{"member-sku"}},
+			},
+			items:   items,
+			wantErr: errNotApplicable,
+		},
+		{
+			name: "折扣只计算适用分类的商品",
+			rule: domain.Rule{
+				Type:  domain.TypePercentage,
+				Rate:  80,
+				Scope: domain.Scope{Type: domain.ScopeTypeCategory, Values: []string{"member"}},
+			},
+			items:     items,
+			wantAmt:   2000,
+			wantItems: []int64{1980, 0, 20},
+		},
+		{
+			name: "折扣有优惠上限",
+			rule: domain.Rule{
+				Type:        domain.TypePercentage,
+				Rate:        50,
+				MaxDiscount: 5000,
+				Scope:       domain.Scope{Type: domain.ScopeTypeAll},
+			},
+			items:     items,
+			wantAmt:   5000,
+			wantItems: []int64{1655, 3328, 17},
+		},
+		{
+			name: "没有适用的商品",
+			rule: domain.Rule{
+				Type:   domain.TypeFixed,
+				Amount: 1000,
+				Scope:  domain.Scope{Type: domain.ScopeTypeCategory, Values: []string{"credit"}},
+			},
+			items:   items,
+			wantErr: errNotApplicable,
+		},
+		{
+			name: "订单只有1分钱",
+			rule: domain.Rule{
+				Type:   domain.TypeFixed,
+				Amount: 1000,
+				Scope:  domain.Scope{Type: domain.ScopeTypeAll},
+			},
+			items:   []domain.Item{{SKUSN: "member-sku", Amount: 1}},
+			wantErr: errNotApplicable,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amt, allocated, err := calculate(tc.rule, tc.items)
+			require.ErrorIs(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantAmt, amt)
+			assert.Equal(t, tc.wantItems, allocated)
+			var sum int64
+			for _, a := range allocated {
+				sum += a
+			}
+			assert.Equal(t, amt, sum)
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    domain.Rule
+		wantErr bool
+	}{
+		{
+			name: "合法的满减",
+			rule: domain.Rule{
+				Type:      domain.TypeThreshold,
+				Threshold: 10000,
+				Amount:    1000,
+				Scope:     domain.Scope{Type: domain.ScopeTypeAll},
+			},
+		},
+		{
+			name: "满减金额大于门槛",
+			rule: domain.Rule{
+				Type:      domain.TypeThreshold,
+				Threshold: 1000,
+				Amount:    1000,
+				Scope:     domain.Scope{Type: domain.ScopeTypeAll},
+			},
+			wantErr: true,
+		},
+		{
+			name: "折扣率非法",
+			rule: domain.Rule{
+				Type:  domain.TypePercentage,
+				Rate:  100,
+				Scope: domain.Scope{Type: domain.ScopeTypeAll},
+			},
+			wantErr: true,
+		},
+		{
+			name: "指定分类但是没有分类",
+			rule: domain.Rule{
+				Type:   domain.TypeFixed,
+				Amount: 1000,
+				Scope:  domain.Scope{Type: domain.ScopeTypeCategory},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "未知类型",
+			rule:    domain.Rule{Type: 9, Scope: domain.Scope{Type: domain.ScopeTypeAll}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRule(tc.rule)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/ecodeclub/webook/internal/coupon/internal/repository"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrRecordNotFound    = repository.ErrRecordNotFound
+	ErrCouponUnavailable = repository.ErrCouponUnavailable
+	ErrInvalidTemplate   = errors.New("优惠券模板不合法")
+)
+
+//go:generate mockgen -source=./service.go -package=couponmocks -destination=../../mocks/coupon.mock.go -typed Service
+type Service interface {
+	// SaveTemplate 新增或者修改优惠券模板, 修改不会影响已经发出去的优惠券 admin调用
+	SaveTemplate(ctx context.Context, t domain.Template) (int64, error)
+	// ListTemplates 分页查找优惠券模板 admin调用
+	ListTemplates(ctx context.Context, offset, limit int) ([]domain.Template, int64, error)
+	// Issue 按照模板给用户发放一张优惠券, 同一个 key 只会发放一次 admin调用
+	Issue(ctx context.Context, uid, templateID int64, key string) error
+	// IssueByActivity 发放活动关联的所有优惠券, 同一个 key 只会发放一次 event调用
+	IssueByActivity(ctx context.Context, uid int64, activity string, key string) error
+	// FindUserCoupons 分页查找用户的优惠券, status 为零值时查找全部 web调用
+	FindUserCoupons(ctx context.Context, uid int64, status domain.Status, offset, limit int) ([]domain.Coupon, int64, error)
+
+	// FindUsableCoupons 查找这些商品可以使用的优惠券, 优惠多的在前面 order调用
+	FindUsableCoupons(ctx context.Context, uid int64, items []domain.Item) ([]domain.Discount, error)
+	// Lock 下单时锁定优惠券并计算优惠, 优惠券不可用或者不满足使用条件时返回 ErrCouponUnavailable order调用
+	Lock(ctx context.Context, uid, couponID int64, orderSN string, items []domain.Item) (domain.Discount, error)
+	// Consume 订单支付成功, 锁定的优惠券标记为已使用, 订单没有使用优惠券时什么也不做 order调用
+	Consume(ctx context.Context, orderSN string) error
+	// Release 订单取消、超时关闭或者支付失败, 释放锁定的优惠券 order调用
+	Release(ctx context.Context, orderSNs ...string) error
+}
+
+type service struct {
+	repo repository.CouponRepository
+}
+
+func NewService(repo repository.CouponRepository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) SaveTemplate(ctx context.Context, t domain.Template) (int64, error) {
+	if err := validateRule(t.Rule); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+	if t.ValidDays <= 0 {
+		return 0, fmt.Errorf("%w: 有效天数必须大于 0", ErrInvalidTemplate)
+	}
+	return s.repo.SaveTemplate(ctx, t)
+}
+
+func (s *service) ListTemplates(ctx context.Context, offset, limit int) ([]domain.Template, int64, error) {
+	var (
+		eg    errgroup.Group
+		ts    []domain.Template
+		total int64
+	)
+	eg.Go(func() error {
+		var err error
+		ts, err = s.repo.ListTemplates(ctx, offset, limit)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		total, err = s.repo.TotalTemplates(ctx)
+		return err
+	})
+	return ts, total, eg.Wait()
+}
+
+func (s *service) Issue(ctx context.Context, uid, templateID int64, key string) error {
+	t, err := s.repo.FindTemplateByID(ctx, templateID)
+	if err != nil {
+		return err
+	}
+	if t.Status != domain.TemplateStatusEnabled {
+		return fmt.Errorf("%w: 模板已停用, id: %d", ErrInvalidTemplate, templateID)
+	}
+	return s.repo.CreateCoupons(ctx, key, []domain.Coupon{s.newCoupon(uid, t)})
+}
+
+func (s *service) IssueByActivity(ctx context.Context, uid int64, activity string, key string) error {
+	ts, err := s.repo.FindEnabledTemplatesByActivity(ctx, activity)
+	if err != nil {
+		return err
+	}
+	return s.repo.CreateCoupons(ctx, key, slice.Map(ts, func(idx int, src domain.Template) domain.Coupon {
+		return s.newCoupon(uid, src)
+	}))
+}
+
+func (s *service) newCoupon(uid int64, t domain.Template) domain.Coupon {
+	return domain.Coupon{
+		Uid:        uid,
+		TemplateID: t.ID,
+		Name:       t.Name,
+		Desc:       t.Desc,
+		Rule:       t.Rule,
+		Status:     domain.StatusUnused,
+		ExpireAt:   time.Now().Add(time.Duration(t.ValidDays) * 24 * time.Hour).UnixMilli(),
+	}
+}
+
+func (s *service) FindUserCoupons(ctx context.Context, uid int64, status domain.Status, offset, limit int) ([]domain.Coupon, int64, error) {
+	var (
+		eg    errgroup.Group
+		cs    []domain.Coupon
+		total int64
+		now   = time.Now().UnixMilli()
+	)
+	eg.Go(func() error {
+		var err error
+		cs, err = s.repo.FindCouponsByUid(ctx, uid, status, now, offset, limit)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		total, err = s.repo.TotalCouponsByUid(ctx, uid, status, now)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, 0, err
+	}
+	for i := range cs {
+		if cs[i].Status == domain.StatusUnused && cs[i].ExpireAt <= now {
+			cs[i].Status = domain.StatusExpired
+		}
+	}
+	return cs, total, nil
+}
+
+func (s *service) FindUsableCoupons(ctx context.Context, uid int64, items []domain.Item) ([]domain.Discount, error) {
+	cs, err := s.repo.FindAvailableCoupons(ctx, uid, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Discount, 0, len(cs))
+	for _, c := range cs {
+		amt, allocated, er := calculate(c.Rule, items)
+		if er != nil {
+			continue
+		}
+		res = append(res, domain.Discount{Coupon: c, Amount: amt, Items: allocated})
+	}
+	// 优惠相同的时候, 先过期的在前面
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Amount > res[j].Amount
+	})
+	return res, nil
+}
+
+func (s *service) Lock(ctx context.Context, uid, couponID int64, orderSN string, items []domain.Item) (domain.Discount, error) {
+	now := time.Now().UnixMilli()
+	c, err := s.repo.FindCouponByID(ctx, couponID)
+	if errors.Is(err, ErrRecordNotFound) {
+		return domain.Discount{}, fmt.Errorf("%w: 优惠券不存在, id: %d", ErrCouponUnavailable, couponID)
+	}
+	if err != nil {
+		return domain.Discount{}, err
+	}
+	if c.Uid != uid || !c.Available(now) {
+		return domain.Discount{}, fmt.Errorf("%w: uid: %d, id: %d, status: %d", ErrCouponUnavailable, uid, couponID, c.Status.ToUint8())
+	}
+	amt, allocated, err := calculate(c.Rule, items)
+	if err != nil {
+		return domain.Discount{}, fmt.Errorf("%w: %w", ErrCouponUnavailable, err)
+	}
+	err = s.repo.LockCoupon(ctx, couponID, uid, orderSN, now)
+	if err != nil {
+		return domain.Discount{}, err
+	}
+	c.Status, c.OrderSN = domain.StatusLocked, orderSN
+	return domain.Discount{Coupon: c, Amount: amt, Items: allocated}, nil
+}
+
+func (s *service) Consume(ctx context.Context, orderSN string) error {
+	return s.repo.ConsumeCoupons(ctx, orderSN)
+}
+
+func (s *service) Release(ctx context.Context, orderSNs ...string) error {
+	return s.repo.ReleaseCoupons(ctx, orderSNs)
+}
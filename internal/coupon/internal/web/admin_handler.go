@@ -0,0 +1,82 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/ecodeclub/webook/internal/coupon/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	svc service.Service
+}
+
+func NewAdminHandler(svc service.Service) *AdminHandler {
+	return &AdminHandler{svc: svc}
+}
+
+func (h *AdminHandler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/coupon")
+	g.POST("/template/save", ginx.B[Template](h.SaveTemplate))
+	g.POST("/template/list", ginx.B[ListTemplatesReq](h.ListTemplates))
+	g.POST("/issue", ginx.B[IssueReq](h.Issue))
+}
+
+func (h *AdminHandler) SaveTemplate(ctx *ginx.Context, req Template) (ginx.Result, error) {
+	id, err := h.svc.SaveTemplate(ctx.Request.Context(), req.toDomain())
+	if errors.Is(err, service.ErrInvalidTemplate) {
+		return templateInvalidResult, err
+	}
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: id}, nil
+}
+
+func (h *AdminHandler) ListTemplates(ctx *ginx.Context, req ListTemplatesReq) (ginx.Result, error) {
+	ts, total, err := h.svc.ListTemplates(ctx.Request.Context(), req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: ListTemplatesResp{
+			Total: total,
+			Templates: slice.Map(ts, func(idx int, src domain.Template) Template {
+				return newTemplate(src)
+			}),
+		},
+	}, nil
+}
+
+// Issue 手动发放优惠券, 例如客服补偿
+func (h *AdminHandler) Issue(ctx *ginx.Context, req IssueReq) (ginx.Result, error) {
+	if req.Key == "" {
+		return systemErrorResult, fmt.Errorf("发放优惠券的 key 为空, uid: %d", req.Uid)
+	}
+	err := h.svc.Issue(ctx.Request.Context(), req.Uid, req.TemplateID, req.Key)
+	if errors.Is(err, service.ErrInvalidTemplate) {
+		return templateInvalidResult, err
+	}
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
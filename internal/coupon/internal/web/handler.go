@@ -0,0 +1,56 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	"github.com/ecodeclub/webook/internal/coupon/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc service.Service
+}
+
+func NewHandler(svc service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) PublicRoutes(server *gin.Engine) {}
+
+func (h *Handler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/coupon")
+	g.POST("/list", ginx.BS[ListCouponsReq](h.List))
+}
+
+// List 我的优惠券, 下单时可用的优惠券走订单预览
+func (h *Handler) List(ctx *ginx.Context, req ListCouponsReq, sess session.Session) (ginx.Result, error) {
+	cs, total, err := h.svc.FindUserCoupons(ctx.Request.Context(), sess.Claims().Uid,
+		domain.Status(req.Status), req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: ListCouponsResp{
+			Total: total,
+			Coupons: slice.Map(cs, func(idx int, src domain.Coupon) Coupon {
+				return newCoupon(src)
+			}),
+		},
+	}, nil
+}
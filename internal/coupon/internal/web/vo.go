@@ -0,0 +1,143 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import "github.com/ecodeclub/webook/internal/coupon/internal/domain"
+
+// ListCouponsReq 分页查询我的优惠券, Status 为 0 时查询全部
+type ListCouponsReq struct {
+	Status uint8 `json:"status,omitempty"` // 1 未使用, 2 已锁定, 3 已使用, 4 已过期
+	Offset int   `json:"offset,omitempty"`
+	Limit  int   `json:"limit,omitempty"`
+}
+
+type ListCouponsResp struct {
+	Total   int64    `json:"total,omitempty"`
+	Coupons []Coupon `json:"coupons,omitempty"`
+}
+
+type Coupon struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Desc     string `json:"desc"`
+	Rule     Rule   `json:"rule"`
+	Status   uint8  `json:"status"`
+	ExpireAt int64  `json:"expireAt"`
+	Ctime    int64  `json:"ctime"`
+}
+
+// Rule 金额的单位都是分
+type Rule struct {
+	Type        uint8    `json:"type"` // 1 立减, 2 折扣, 3 满减
+	Threshold   int64    `json:"threshold,omitempty"`
+	Amount      int64    `json:"amount,omitempty"`
+	Rate        int64    `json:"rate,omitempty"` // 85 表示八五折
+	MaxDiscount int64    `json:"maxDiscount,omitempty"`
+	ScopeType   uint8    `json:"scopeType"` // 1 全部商品, 2 指定SKU, 3 指定分类
+	ScopeValues []string `json:"scopeValues,omitempty"`
+}
+
+func newCoupon(c domain.Coupon) Coupon {
+	return Coupon{
+		ID:       c.ID,
+		Name:     c.Name,
+		Desc:     c.Desc,
+		Rule:     newRule(c.Rule),
+		Status:   c.Status.ToUint8(),
+		ExpireAt: c.ExpireAt,
+		Ctime:    c.Ctime,
+	}
+}
+
+func newRule(r domain.Rule) Rule {
+	return Rule{
+		Type:        r.Type.ToUint8(),
+		Threshold:   r.Threshold,
+		Amount:      r.Amount,
+		Rate:        r.Rate,
+		MaxDiscount: r.MaxDiscount,
+		ScopeType:   r.Scope.Type.ToUint8(),
+		ScopeValues: r.Scope.Values,
+	}
+}
+
+func (r Rule) toDomain() domain.Rule {
+	return domain.Rule{
+		Type:        domain.Type(r.Type),
+		Threshold:   r.Threshold,
+		Amount:      r.Amount,
+		Rate:        r.Rate,
+		MaxDiscount: r.MaxDiscount,
+		Scope: domain.Scope{
+			Type:   domain.ScopeType(r.ScopeType),
+			Values: r.ScopeValues,
+		},
+	}
+}
+
+type Template struct {
+	ID        int64  `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Desc      string `json:"desc"`
+	Rule      Rule   `json:"rule"`
+	Activity  string `json:"activity,omitempty"` // registration 注册, invitation 邀请, 为空表示手动发放
+	ValidDays int64  `json:"validDays"`
+	Status    uint8  `json:"status"` // 1 启用, 2 停用
+	Ctime     int64  `json:"ctime,omitempty"`
+	Utime     int64  `json:"utime,omitempty"`
+}
+
+func newTemplate(t domain.Template) Template {
+	return Template{
+		ID:        t.ID,
+		Name:      t.Name,
+		Desc:      t.Desc,
+		Rule:      newRule(t.Rule),
+		Activity:  t.Activity,
+		ValidDays: t.ValidDays,
+		Status:    t.Status.ToUint8(),
+		Ctime:     t.Ctime,
+		Utime:     t.Utime,
+	}
+}
+
+func (t Template) toDomain() domain.Template {
+	return domain.Template{
+		ID:        t.ID,
+		Name:      t.Name,
+		Desc:      t.Desc,
+		Rule:      t.Rule.toDomain(),
+		Activity:  t.Activity,
+		ValidDays: t.ValidDays,
+		Status:    domain.TemplateStatus(t.Status),
+	}
+}
+
+type ListTemplatesReq struct {
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+type ListTemplatesResp struct {
+	Total     int64      `json:"total,omitempty"`
+	Templates []Template `json:"templates,omitempty"`
+}
+
+// IssueReq 手动给用户发放优惠券, Key 相同的请求只会发放一次
+type IssueReq struct {
+	Uid        int64  `json:"uid"`
+	TemplateID int64  `json:"templateId"`
+	Key        string `json:"key"`
+}
@@ -0,0 +1,396 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./service.go -package=couponmocks -destination=../../mocks/coupon.mock.go -typed Service
+//
+
+// Package couponmocks is a generated GoMock package.
+package couponmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/ecodeclub/webook/internal/coupon/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockService) Consume(ctx context.Context, orderSN string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, orderSN)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockServiceMockRecorder) Consume(ctx, orderSN any) *MockServiceConsumeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockService)(nil).Consume), ctx, orderSN)
+	return &MockServiceConsumeCall{Call: call}
+}
+
+// MockServiceConsumeCall wrap *gomock.Call
+type MockServiceConsumeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceConsumeCall) Return(arg0 error) *MockServiceConsumeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceConsumeCall) Do(f func(context.Context, string) error) *MockServiceConsumeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceConsumeCall) DoAndReturn(f func(context.Context, string) error) *MockServiceConsumeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindUsableCoupons mocks base method.
+func (m *MockService) FindUsableCoupons(ctx context.Context, uid int64, items []domain.Item) ([]domain.Discount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsableCoupons", ctx, uid, items)
+	ret0, _ := ret[0].([]domain.Discount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsableCoupons indicates an expected call of FindUsableCoupons.
+func (mr *MockServiceMockRecorder) FindUsableCoupons(ctx, uid, items any) *MockServiceFindUsableCouponsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsableCoupons", reflect.TypeOf((*MockService)(nil).FindUsableCoupons), ctx, uid, items)
+	return &MockServiceFindUsableCouponsCall{Call: call}
+}
+
+// MockServiceFindUsableCouponsCall wrap *gomock.Call
+type MockServiceFindUsableCouponsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindUsableCouponsCall) Return(arg0 []domain.Discount, arg1 error) *MockServiceFindUsableCouponsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindUsableCouponsCall) Do(f func(context.Context, int64, []domain.Item) ([]domain.Discount, error)) *MockServiceFindUsableCouponsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindUsableCouponsCall) DoAndReturn(f func(context.Context, int64, []domain.Item) ([]domain.Discount, error)) *MockServiceFindUsableCouponsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindUserCoupons mocks base method.
+func (m *MockService) FindUserCoupons(ctx context.Context, uid int64, status domain.Status, offset, limit int) ([]domain.Coupon, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUserCoupons", ctx, uid, status, offset, limit)
+	ret0, _ := ret[0].([]domain.Coupon)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindUserCoupons indicates an expected call of FindUserCoupons.
+func (mr *MockServiceMockRecorder) FindUserCoupons(ctx, uid, status, offset, limit any) *MockServiceFindUserCouponsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserCoupons", reflect.TypeOf((*MockService)(nil).FindUserCoupons), ctx, uid, status, offset, limit)
+	return &MockServiceFindUserCouponsCall{Call: call}
+}
+
+// MockServiceFindUserCouponsCall wrap *gomock.Call
+type MockServiceFindUserCouponsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindUserCouponsCall) Return(arg0 []domain.Coupon, arg1 int64, arg2 error) *MockServiceFindUserCouponsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindUserCouponsCall) Do(f func(context.Context, int64, domain.Status, int, int) ([]domain.Coupon, int64, error)) *MockServiceFindUserCouponsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindUserCouponsCall) DoAndReturn(f func(context.Context, int64, domain.Status, int, int) ([]domain.Coupon, int64, error)) *MockServiceFindUserCouponsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Issue mocks base method.
+func (m *MockService) Issue(ctx context.Context, uid, templateID int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", ctx, uid, templateID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockServiceMockRecorder) Issue(ctx, uid, templateID, key any) *MockServiceIssueCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockService)(nil).Issue), ctx, uid, templateID, key)
+	return &MockServiceIssueCall{Call: call}
+}
+
+// MockServiceIssueCall wrap *gomock.Call
+type MockServiceIssueCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceIssueCall) Return(arg0 error) *MockServiceIssueCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceIssueCall) Do(f func(context.Context, int64, int64, string) error) *MockServiceIssueCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceIssueCall) DoAndReturn(f func(context.Context, int64, int64, string) error) *MockServiceIssueCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// IssueByActivity mocks base method.
+func (m *MockService) IssueByActivity(ctx context.Context, uid int64, activity, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueByActivity", ctx, uid, activity, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IssueByActivity indicates an expected call of IssueByActivity.
+func (mr *MockServiceMockRecorder) IssueByActivity(ctx, uid, activity, key any) *MockServiceIssueByActivityCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueByActivity", reflect.TypeOf((*MockService)(nil).IssueByActivity), ctx, uid, activity, key)
+	return &MockServiceIssueByActivityCall{Call: call}
+}
+
+// MockServiceIssueByActivityCall wrap *gomock.Call
+type MockServiceIssueByActivityCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceIssueByActivityCall) Return(arg0 error) *MockServiceIssueByActivityCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceIssueByActivityCall) Do(f func(context.Context, int64, string, string) error) *MockServiceIssueByActivityCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceIssueByActivityCall) DoAndReturn(f func(context.Context, int64, string, string) error) *MockServiceIssueByActivityCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ListTemplates mocks base method.
+func (m *MockService) ListTemplates(ctx context.Context, offset, limit int) ([]domain.Template, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTemplates", ctx, offset, limit)
+	ret0, _ := ret[0].([]domain.Template)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListTemplates indicates an expected call of ListTemplates.
+func (mr *MockServiceMockRecorder) ListTemplates(ctx, offset, limit any) *MockServiceListTemplatesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTemplates", reflect.TypeOf((*MockService)(nil).ListTemplates), ctx, offset, limit)
+	return &MockServiceListTemplatesCall{Call: call}
+}
+
+// MockServiceListTemplatesCall wrap *gomock.Call
+type MockServiceListTemplatesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceListTemplatesCall) Return(arg0 []domain.Template, arg1 int64, arg2 error) *MockServiceListTemplatesCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceListTemplatesCall) Do(f func(context.Context, int, int) ([]domain.Template, int64, error)) *MockServiceListTemplatesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceListTemplatesCall) DoAndReturn(f func(context.Context, int, int) ([]domain.Template, int64, error)) *MockServiceListTemplatesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Lock mocks base method.
+func (m *MockService) Lock(ctx context.Context, uid, couponID int64, orderSN string, items []domain.Item) (domain.Discount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, uid, couponID, orderSN, items)
+	ret0, _ := ret[0].(domain.Discount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockServiceMockRecorder) Lock(ctx, uid, couponID, orderSN, items any) *MockServiceLockCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockService)(nil).Lock), ctx, uid, couponID, orderSN, items)
+	return &MockServiceLockCall{Call: call}
+}
+
+// MockServiceLockCall wrap *gomock.Call
+type MockServiceLockCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceLockCall) Return(arg0 domain.Discount, arg1 error) *MockServiceLockCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceLockCall) Do(f func(context.Context, int64, int64, string, []domain.Item) (domain.Discount, error)) *MockServiceLockCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceLockCall) DoAndReturn(f func(context.Context, int64, int64, string, []domain.Item) (domain.Discount, error)) *MockServiceLockCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Release mocks base method.
+func (m *MockService) Release(ctx context.Context, orderSNs ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range orderSNs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Release", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockServiceMockRecorder) Release(ctx any, orderSNs ...any) *MockServiceReleaseCall {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, orderSNs...)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockService)(nil).Release), varargs...)
+	return &MockServiceReleaseCall{Call: call}
+}
+
+// MockServiceReleaseCall wrap *gomock.Call
+type MockServiceReleaseCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceReleaseCall) Return(arg0 error) *MockServiceReleaseCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceReleaseCall) Do(f func(context.Context, ...string) error) *MockServiceReleaseCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceReleaseCall) DoAndReturn(f func(context.Context, ...string) error) *MockServiceReleaseCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SaveTemplate mocks base method.
+func (m *MockService) SaveTemplate(ctx context.Context, t domain.Template) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTemplate", ctx, t)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveTemplate indicates an expected call of SaveTemplate.
+func (mr *MockServiceMockRecorder) SaveTemplate(ctx, t any) *MockServiceSaveTemplateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTemplate", reflect.TypeOf((*MockService)(nil).SaveTemplate), ctx, t)
+	return &MockServiceSaveTemplateCall{Call: call}
+}
+
+// MockServiceSaveTemplateCall wrap *gomock.Call
+type MockServiceSaveTemplateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceSaveTemplateCall) Return(arg0 int64, arg1 error) *MockServiceSaveTemplateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceSaveTemplateCall) Do(f func(context.Context, domain.Template) (int64, error)) *MockServiceSaveTemplateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceSaveTemplateCall) DoAndReturn(f func(context.Context, domain.Template) (int64, error)) *MockServiceSaveTemplateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//go:build wireinject
+
+package coupon
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/coupon/internal/event"
+	"github.com/ecodeclub/webook/internal/coupon/internal/repository"
+	"github.com/ecodeclub/webook/internal/coupon/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/coupon/internal/service"
+	"github.com/ecodeclub/webook/internal/coupon/internal/web"
+	"github.com/ego-component/egorm"
+	"github.com/google/wire"
+)
+
+func InitModule(db *egorm.Component, q mq.MQ) (*Module, error) {
+	wire.Build(
+		InitService,
+		web.NewHandler,
+		web.NewAdminHandler,
+		initConsumer,
+		wire.Struct(new(Module), "*"),
+	)
+	return new(Module), nil
+}
+
+var (
+	once = &sync.Once{}
+	svc  service.Service
+)
+
+// InitService 订单模块的测试也会用到
+func InitService(db *egorm.Component) Service {
+	once.Do(func() {
+		err := dao.InitTables(db)
+		if err != nil {
+			panic(err)
+		}
+		couponDAO := dao.NewCouponGORMDAO(db)
+		svc = service.NewService(repository.NewCouponRepository(couponDAO))
+	})
+	return svc
+}
+
+func initConsumer(q mq.MQ, svc service.Service) *event.CouponEventConsumer {
+	consumer, err := event.NewCouponEventConsumer(q, svc)
+	if err != nil {
+		panic(err)
+	}
+	consumer.Start(context.Background())
+	return consumer
+}
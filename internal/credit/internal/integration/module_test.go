@@ -1514,6 +1514,113 @@ func (s *ModuleTestSuite) TestService_CancelDeductCredits_Concurrent() {
 	}, c.Logs)
 }
 
+func (s *ModuleTestSuite) TestService_SettleDeductCredits() {
+	t := s.T()
+
+	prepare := func(t *testing.T, uid int64) int64 {
+		t.Helper()
+		err := s.svc.AddCredits(context.Background(), domain.Credit{
+			Uid: uid,
+			Logs: []domain.CreditLog{
+				{
+					Key:          fmt.Sprintf("key-%d-1", uid),
+					ChangeAmount: 100,
+					Biz:          "user",
+					BizId:        1,
+					Desc:         "注册",
+				},
+			},
+		})
+		require.NoError(t, err)
+		// 预扣
+		tid, err := s.svc.TryDeductCredits(context.Background(), domain.Credit{
+			Uid: uid,
+			Logs: []domain.CreditLog{
+				{
+					Key:          fmt.Sprintf("key-%d-2", uid),
+					ChangeAmount: 50,
+					Biz:          "ai-llm",
+					BizId:        9,
+					Desc:         "ai-llm服务",
+				},
+			},
+		})
+		require.NoError(t, err)
+		return tid
+	}
+
+	testCases := []struct {
+		name           string
+		uid            int64
+		amount         int64
+		wantTotal      uint64
+		wantChange     int64
+		errRequireFunc require.ErrorAssertionFunc
+	}{
+		{
+			name:           "结算成功_实际金额小于预扣金额_退回多余部分",
+			uid:            9101,
+			amount:         20,
+			wantTotal:      80,
+			wantChange:     -20,
+			errRequireFunc: require.NoError,
+		},
+		{
+			name:           "结算成功_实际金额超过预扣金额_只扣预扣金额",
+			uid:            9102,
+			amount:         70,
+			wantTotal:      50,
+			wantChange:     -50,
+			errRequireFunc: require.NoError,
+		},
+		{
+			name:           "结算成功_实际金额为0_全部退回",
+			uid:            9103,
+			amount:         0,
+			wantTotal:      100,
+			wantChange:     0,
+			errRequireFunc: require.NoError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tid := prepare(t, tc.uid)
+			err := s.svc.SettleDeductCredits(context.Background(), tc.uid, tid, tc.amount)
+			tc.errRequireFunc(t, err)
+			// 重复结算返回第一次的结果
+			require.NoError(t, s.svc.SettleDeductCredits(context.Background(), tc.uid, tid, tc.amount))
+
+			c, err := s.svc.GetCreditsByUID(context.Background(), tc.uid)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantTotal, c.TotalAmount)
+			require.Equal(t, uint64(0), c.LockedTotalAmount)
+			s.requireCreditLogs(t, []domain.CreditLog{
+				{
+					Key:          fmt.Sprintf("key-%d-2", tc.uid),
+					Uid:          tc.uid,
+					ChangeAmount: tc.wantChange,
+					Biz:          "ai-llm",
+					BizId:        9,
+					Desc:         "ai-llm服务",
+				},
+				{
+					Key:          fmt.Sprintf("key-%d-1", tc.uid),
+					Uid:          tc.uid,
+					ChangeAmount: 100,
+					Biz:          "user",
+					BizId:        1,
+					Desc:         "注册",
+				},
+			}, c.Logs)
+		})
+	}
+
+	err := s.svc.SettleDeductCredits(context.Background(), 9101, 1, -1)
+	require.ErrorIs(t, err, service.ErrInvalidCreditLog)
+}
+
 func (s *ModuleTestSuite) TestService_GetCreditsByUID() {
 	t := s.T()
 
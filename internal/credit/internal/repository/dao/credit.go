@@ -41,6 +41,7 @@ type CreditDAO interface {
 	CreateCreditLockLog(ctx context.Context, l CreditLog) (int64, error)
 	ConfirmCreditLockLog(ctx context.Context, uid, tid int64) error
 	CancelCreditLockLog(ctx context.Context, uid, tid int64) error
+	SettleCreditLockLog(ctx context.Context, uid, tid int64, amount uint64) error
 	FindExpiredLockedCreditLogs(ctx context.Context, offset int, limit int, ctime int64) ([]CreditLog, error)
 	TotalExpiredLockedCreditLogs(ctx context.Context, ctime int64) (int64, error)
 }
@@ -251,6 +252,84 @@ func (g *creditDAO) CancelCreditLockLog(ctx context.Context, uid, tid int64) err
 	}
 }
 
+// SettleCreditLockLog 按照实际金额确认预扣积分，多出来的部分退回。
+// amount 超过预扣金额的时候只确认预扣金额，超出的部分需要调用方另外扣减
+func (g *creditDAO) SettleCreditLockLog(ctx context.Context, uid, tid int64, amount uint64) error {
+	for {
+		err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return g.settleCreditLockLog(tx, uid, tid, amount)
+		})
+		if errors.Is(err, ErrUpdateCreditConflict) {
+			continue
+		}
+		return err
+	}
+}
+
+func (g *creditDAO) settleCreditLockLog(tx *gorm.DB, uid, tid int64, amount uint64) error {
+	now := time.Now().UnixMilli()
+
+	var c Credit
+	if err := tx.First(&c, "uid = ?", uid).Error; err != nil {
+		return err
+	}
+
+	var cl CreditLog
+	if err := tx.Where("uid = ? AND id = ?", uid, tid).First(&cl).Error; err != nil {
+		return err
+	}
+
+	if cl.Status == CreditLogStatusActive {
+		// 重复处理相同请求,返回第一次处理的结果
+		return nil
+	}
+
+	if cl.Status != CreditLogStatusLocked {
+		return fmt.Errorf("%w: 已被修改为%d", ErrInvalidLockedCreditLogStatus, cl.Status)
+	}
+
+	locked := uint64(0 - cl.CreditChange)
+	amount = min(amount, locked)
+	refund := locked - amount
+
+	version := c.Version
+	c.TotalCredits += refund
+	c.LockedTotalCredits -= locked
+	c.Version += 1
+	c.Utime = now
+
+	res := tx.Model(&CreditLog{}).
+		Where("uid = ? AND id = ? AND status = ?", uid, tid, CreditLogStatusLocked).
+		Updates(map[string]any{
+			"CreditChange":  0 - int64(amount),
+			"CreditBalance": c.TotalCredits,
+			"Status":        CreditLogStatusActive,
+			"Utime":         now,
+		})
+	if err := res.Error; err != nil {
+		return fmt.Errorf("更新积分流水记录失败: %w", err)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrUpdateCreditConflict)
+	}
+
+	res = tx.Model(&Credit{}).
+		Where("uid = ? AND Version = ?", uid, version).
+		Updates(map[string]any{
+			"TotalCredits":       c.TotalCredits,
+			"LockedTotalCredits": c.LockedTotalCredits, // 更新后可能为0
+			"Utime":              c.Utime,
+			"Version":            c.Version,
+		})
+	if err := res.Error; err != nil {
+		return fmt.Errorf("更新积分主记录失败: %w", err)
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrUpdateCreditConflict)
+	}
+	return nil
+}
+
 func (g *creditDAO) updateCreditLockLog(tx *gorm.DB, uid, tid int64, srcStatus, dstStatus uint8,
 	totalCreditsIncreaseAmountFunc func(cl CreditLog) uint64) error {
 	// 更新
@@ -34,6 +34,7 @@ type CreditRepository interface {
 	TryDeductCredits(ctx context.Context, credit domain.Credit) (int64, error)
 	ConfirmDeductCredits(ctx context.Context, uid, tid int64) error
 	CancelDeductCredits(ctx context.Context, uid, tid int64) error
+	SettleDeductCredits(ctx context.Context, uid, tid int64, amount uint64) error
 	FindExpiredLockedCreditLogs(ctx context.Context, offset int, limit int, ctime int64) ([]domain.CreditLog, error)
 	TotalExpiredLockedCreditLogs(ctx context.Context, ctime int64) (int64, error)
 }
@@ -111,6 +112,10 @@ func (r *creditRepository) CancelDeductCredits(ctx context.Context, uid, tid int
 	return r.dao.CancelCreditLockLog(ctx, uid, tid)
 }
 
+func (r *creditRepository) SettleDeductCredits(ctx context.Context, uid, tid int64, amount uint64) error {
+	return r.dao.SettleCreditLockLog(ctx, uid, tid, amount)
+}
+
 func (r *creditRepository) FindExpiredLockedCreditLogs(ctx context.Context, offset int, limit int, ctime int64) ([]domain.CreditLog, error) {
 	cs, err := r.dao.FindExpiredLockedCreditLogs(ctx, offset, limit, ctime)
 	return r.toDomainCreditLog(cs), err
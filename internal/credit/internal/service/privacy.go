@@ -0,0 +1,47 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+)
+
+// PrivacyService 导出用户的积分以及积分流水
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 积分余额和流水都保留，对账要用
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	svc Service
+}
+
+func NewPrivacyService(svc Service) PrivacyService {
+	return &privacyService{svc: svc}
+}
+
+func (s *privacyService) Name() string {
+	return "credit"
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	return s.svc.GetCreditsByUID(ctx, uid)
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	return nil
+}
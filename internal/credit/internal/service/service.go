@@ -38,6 +38,9 @@ type Service interface {
 	TryDeductCredits(ctx context.Context, credit domain.Credit) (id int64, err error)
 	ConfirmDeductCredits(ctx context.Context, uid, tid int64) error
 	CancelDeductCredits(ctx context.Context, uid, tid int64) error
+	// SettleDeductCredits 按照实际金额 amount 确认预扣积分，多余的部分退回。
+	// amount 超过预扣金额的时候只会扣掉预扣的金额
+	SettleDeductCredits(ctx context.Context, uid, tid int64, amount int64) error
 	FindExpiredLockedCreditLogs(ctx context.Context, offset int, limit int, ctime int64) ([]domain.CreditLog, int64, error)
 }
 
@@ -83,6 +86,13 @@ func (s *service) CancelDeductCredits(ctx context.Context, uid, tid int64) error
 	return s.repo.CancelDeductCredits(ctx, uid, tid)
 }
 
+func (s *service) SettleDeductCredits(ctx context.Context, uid, tid int64, amount int64) error {
+	if amount < 0 {
+		return fmt.Errorf("%w: 扣减金额不能为负数", ErrInvalidCreditLog)
+	}
+	return s.repo.SettleDeductCredits(ctx, uid, tid, uint64(amount))
+}
+
 func (s *service) FindExpiredLockedCreditLogs(ctx context.Context, offset int, limit int, ctime int64) ([]domain.CreditLog, int64, error) {
 	var (
 		eg    errgroup.Group
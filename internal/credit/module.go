@@ -15,13 +15,26 @@
 package credit
 
 import (
+	"time"
+
 	"github.com/ecodeclub/webook/internal/credit/internal/event"
+	"github.com/ecodeclub/webook/internal/credit/internal/service"
 	"github.com/ecodeclub/webook/internal/credit/internal/web"
 )
 
+var (
+	ErrDuplicatedCreditLog = service.ErrDuplicatedCreditLog
+	ErrCreditNotEnough     = service.ErrCreditNotEnough
+)
+
+// LockedCreditsTimeout 预扣的积分超过这个时间还没有确认或者取消，会被定时任务退回。
+// 预扣积分的一方要在这之前完成结算
+const LockedCreditsTimeout = 30 * time.Minute
+
 type Module struct {
 	Hdl                          *web.Handler
 	Svc                          Service
 	c                            *event.CreditIncreaseConsumer
 	CloseTimeoutLockedCreditsJob *CloseTimeoutLockedCreditsJob
+	PrivacySvc                   PrivacyService
 }
@@ -19,6 +19,7 @@ package credit
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/mq-api"
@@ -39,6 +40,7 @@ type (
 	Service                      = service.Service
 	Handler                      = web.Handler
 	CloseTimeoutLockedCreditsJob = job.CloseTimeoutLockedCreditsJob
+	PrivacyService               = service.PrivacyService
 )
 
 func InitModule(db *egorm.Component, q mq.MQ, e ecache.Cache) (*Module, error) {
@@ -48,6 +50,7 @@ func InitModule(db *egorm.Component, q mq.MQ, e ecache.Cache) (*Module, error) {
 		InitHandler,
 		initCreditConsumer,
 		initCloseTimeoutLockedCreditsJob,
+		service.NewPrivacyService,
 	)
 	return new(Module), nil
 }
@@ -81,7 +84,7 @@ func initCreditConsumer(svc service.Service, q mq.MQ) *event.CreditIncreaseConsu
 }
 
 func initCloseTimeoutLockedCreditsJob(svc service.Service) *CloseTimeoutLockedCreditsJob {
-	minutes := int64(30)
+	minutes := int64(LockedCreditsTimeout / time.Minute)
 	seconds := int64(10)
 	limit := 100
 	return job.NewCloseTimeoutLockedCreditsJob(svc, minutes, seconds, limit)
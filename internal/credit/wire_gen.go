@@ -9,6 +9,7 @@ package credit
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/mq-api"
@@ -26,15 +27,17 @@ import (
 // Injectors from wire.go:
 
 func InitModule(db *gorm.DB, q mq.MQ, e ecache.Cache) (*Module, error) {
-	service := InitService(db)
-	handler := InitHandler(service)
-	creditIncreaseConsumer := initCreditConsumer(service, q)
-	closeTimeoutLockedCreditsJob := initCloseTimeoutLockedCreditsJob(service)
+	serviceService := InitService(db)
+	handler := InitHandler(serviceService)
+	creditIncreaseConsumer := initCreditConsumer(serviceService, q)
+	closeTimeoutLockedCreditsJob := initCloseTimeoutLockedCreditsJob(serviceService)
+	privacyService := service.NewPrivacyService(serviceService)
 	module := &Module{
 		Hdl:                          handler,
-		Svc:                          service,
+		Svc:                          serviceService,
 		c:                            creditIncreaseConsumer,
 		CloseTimeoutLockedCreditsJob: closeTimeoutLockedCreditsJob,
+		PrivacySvc:                   privacyService,
 	}
 	return module, nil
 }
@@ -47,6 +50,7 @@ type (
 	Service                      = service.Service
 	Handler                      = web.Handler
 	CloseTimeoutLockedCreditsJob = job.CloseTimeoutLockedCreditsJob
+	PrivacyService               = service.PrivacyService
 )
 
 var (
@@ -78,7 +82,7 @@ func initCreditConsumer(svc2 service.Service, q mq.MQ) *event.CreditIncreaseCons
 }
 
 func initCloseTimeoutLockedCreditsJob(svc2 service.Service) *CloseTimeoutLockedCreditsJob {
-	minutes := int64(30)
+	minutes := int64(LockedCreditsTimeout / time.Minute)
 	seconds := int64(10)
 	limit := 100
 	return job.NewCloseTimeoutLockedCreditsJob(svc2, minutes, seconds, limit)
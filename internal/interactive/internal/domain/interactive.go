@@ -40,3 +40,12 @@ type CollectionRecord struct {
 	Question    int64
 	QuestionSet int64
 }
+
+// UserBiz 用户点赞或者收藏过的资源
+type UserBiz struct {
+	Biz   string
+	BizId int64
+	// 收藏夹 ID，点赞记录没有收藏夹
+	Cid   int64
+	Ctime int64
+}
@@ -58,6 +58,15 @@ type InteractiveDAO interface {
 	MoveCollection(ctx context.Context, biz string, bizid, uid, collectionId int64) error
 	// 减少计数
 	DecrCollectCount(ctx context.Context, biz string, bizid int64) error
+
+	// FindCollectionsByUid 用户所有的收藏夹
+	FindCollectionsByUid(ctx context.Context, uid int64) ([]Collection, error)
+	// FindUserCollectsByUid 用户所有的收藏记录
+	FindUserCollectsByUid(ctx context.Context, uid int64) ([]UserCollectionBiz, error)
+	// FindUserLikesByUid 用户所有的点赞记录
+	FindUserLikesByUid(ctx context.Context, uid int64) ([]UserLikeBiz, error)
+	// DeleteByUid 删除用户所有的点赞、收藏以及收藏夹，同时扣减对应的计数
+	DeleteByUid(ctx context.Context, uid int64) error
 }
 
 type GORMInteractiveDAO struct {
@@ -305,6 +314,52 @@ func (g *GORMInteractiveDAO) deleteLikeInfo(tx *gorm.DB, biz string, id int64, u
 		}).Error
 }
 
+func (g *GORMInteractiveDAO) FindCollectionsByUid(ctx context.Context, uid int64) ([]Collection, error) {
+	var res []Collection
+	err := g.db.WithContext(ctx).Where("uid = ?", uid).Order("id ASC").Find(&res).Error
+	return res, err
+}
+
+func (g *GORMInteractiveDAO) FindUserCollectsByUid(ctx context.Context, uid int64) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	err := g.db.WithContext(ctx).Where("uid = ?", uid).Order("id ASC").Find(&res).Error
+	return res, err
+}
+
+func (g *GORMInteractiveDAO) FindUserLikesByUid(ctx context.Context, uid int64) ([]UserLikeBiz, error) {
+	var res []UserLikeBiz
+	err := g.db.WithContext(ctx).Where("uid = ?", uid).Order("id ASC").Find(&res).Error
+	return res, err
+}
+
+func (g *GORMInteractiveDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var likes []UserLikeBiz
+		err := tx.Where("uid = ?", uid).Find(&likes).Error
+		if err != nil {
+			return err
+		}
+		for _, l := range likes {
+			err = g.deleteLikeInfo(tx, l.Biz, l.BizId, uid)
+			if err != nil {
+				return err
+			}
+		}
+		var collects []UserCollectionBiz
+		err = tx.Where("uid = ?", uid).Find(&collects).Error
+		if err != nil {
+			return err
+		}
+		for _, c := range collects {
+			err = g.deleteCollectionInfo(tx, c.Biz, c.BizId, uid)
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("uid = ?", uid).Delete(&Collection{}).Error
+	})
+}
+
 func (g *GORMInteractiveDAO) IncrViewCnt(ctx context.Context, biz string, bizId int64) error {
 	now := time.Now().UnixMilli()
 	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
@@ -58,6 +58,15 @@ type InteractiveRepository interface {
 	CollectionInfo(ctx context.Context, uid, collectionId int64, biz string, offset, limit int) ([]domain.CollectionRecord, int, error)
 	// MoveCollection 转移收藏夹
 	MoveCollection(ctx context.Context, biz string, bizId, uid, collectionId int64) error
+
+	// FindCollectionsByUid 用户所有的收藏夹，导出个人数据的时候使用
+	FindCollectionsByUid(ctx context.Context, uid int64) ([]domain.Collection, error)
+	// FindUserCollects 用户所有的收藏
+	FindUserCollects(ctx context.Context, uid int64) ([]domain.UserBiz, error)
+	// FindUserLikes 用户所有的点赞
+	FindUserLikes(ctx context.Context, uid int64) ([]domain.UserBiz, error)
+	// DeleteByUid 删除用户所有的点赞、收藏以及收藏夹
+	DeleteByUid(ctx context.Context, uid int64) error
 }
 
 type interactiveRepository struct {
@@ -234,6 +243,31 @@ func (i *interactiveRepository) GetByIds(ctx context.Context, biz string, uid in
 	return list, nil
 }
 
+func (i *interactiveRepository) FindCollectionsByUid(ctx context.Context, uid int64) ([]domain.Collection, error) {
+	clist, err := i.interactiveDao.FindCollectionsByUid(ctx, uid)
+	return slice.Map(clist, func(idx int, src dao.Collection) domain.Collection {
+		return i.collectionToDomain(src)
+	}), err
+}
+
+func (i *interactiveRepository) FindUserCollects(ctx context.Context, uid int64) ([]domain.UserBiz, error) {
+	collects, err := i.interactiveDao.FindUserCollectsByUid(ctx, uid)
+	return slice.Map(collects, func(idx int, src dao.UserCollectionBiz) domain.UserBiz {
+		return domain.UserBiz{Biz: src.Biz, BizId: src.BizId, Cid: src.Cid, Ctime: src.Ctime}
+	}), err
+}
+
+func (i *interactiveRepository) FindUserLikes(ctx context.Context, uid int64) ([]domain.UserBiz, error) {
+	likes, err := i.interactiveDao.FindUserLikesByUid(ctx, uid)
+	return slice.Map(likes, func(idx int, src dao.UserLikeBiz) domain.UserBiz {
+		return domain.UserBiz{Biz: src.Biz, BizId: src.BizId, Ctime: src.Ctime}
+	}), err
+}
+
+func (i *interactiveRepository) DeleteByUid(ctx context.Context, uid int64) error {
+	return i.interactiveDao.DeleteByUid(ctx, uid)
+}
+
 func NewCachedInteractiveRepository(interactiveDao dao.InteractiveDAO) InteractiveRepository {
 	return &interactiveRepository{
 		interactiveDao: interactiveDao,
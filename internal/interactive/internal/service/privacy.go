@@ -0,0 +1,68 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/interactive/internal/domain"
+	"github.com/ecodeclub/webook/internal/interactive/internal/repository"
+)
+
+// PrivacyService 导出以及删除用户的收藏夹、收藏和点赞
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 删除之后会扣减对应资源的收藏数和点赞数
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	repo repository.InteractiveRepository
+}
+
+func NewPrivacyService(repo repository.InteractiveRepository) PrivacyService {
+	return &privacyService{repo: repo}
+}
+
+func (s *privacyService) Name() string {
+	return "interactive"
+}
+
+type interactiveData struct {
+	Collections []domain.Collection `json:"collections"`
+	Collects    []domain.UserBiz    `json:"collects"`
+	Likes       []domain.UserBiz    `json:"likes"`
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	collections, err := s.repo.FindCollectionsByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	collects, err := s.repo.FindUserCollects(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	likes, err := s.repo.FindUserLikes(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return interactiveData{Collections: collections, Collects: collects, Likes: likes}, nil
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	return s.repo.DeleteByUid(ctx, uid)
+}
@@ -20,4 +20,6 @@ type Module struct {
 	Svc Service
 	c   *event.Consumer
 	Hdl *Handler
+
+	PrivacySvc PrivacyService
 }
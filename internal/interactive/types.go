@@ -24,6 +24,8 @@ type Handler = web.Handler
 
 type Service = service.Service
 
+type PrivacyService = service.PrivacyService
+
 type Interactive = domain.Interactive
 
 type CollectionRecord = domain.CollectionRecord
@@ -41,6 +41,7 @@ func InitModule(db *egorm.Component, q mq.MQ) (*Module, error) {
 		InitTablesOnce,
 		repository.NewCachedInteractiveRepository,
 		service.NewService,
+		service.NewPrivacyService,
 		initConsumer,
 		web.NewHandler,
 		wire.Struct(new(Module), "*"),
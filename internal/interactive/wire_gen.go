@@ -29,10 +29,12 @@ func InitModule(db *gorm.DB, q mq.MQ) (*Module, error) {
 	serviceService := service.NewService(interactiveRepository)
 	consumer := initConsumer(serviceService, q)
 	handler := web.NewHandler(serviceService)
+	privacyService := service.NewPrivacyService(interactiveRepository)
 	module := &Module{
-		Svc: serviceService,
-		c:   consumer,
-		Hdl: handler,
+		Svc:        serviceService,
+		c:          consumer,
+		Hdl:        handler,
+		PrivacySvc: privacyService,
 	}
 	return module, nil
 }
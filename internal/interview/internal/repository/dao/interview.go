@@ -76,6 +76,9 @@ type InterviewDAO interface {
 	CountJourneyByUID(ctx context.Context, uid int64) (int64, error)
 
 	FindRoundsByJidAndUid(ctx context.Context, jid, uid int64) ([]InterviewRound, error)
+
+	// DeleteByUID 删除用户所有的面试历程以及轮次
+	DeleteByUID(ctx context.Context, uid int64) error
 }
 
 // GORMInterviewDAO 是 InterviewDAO 的GORM实现
@@ -179,3 +182,12 @@ func (g *GORMInterviewDAO) FindRoundsByJidAndUid(ctx context.Context, jid, uid i
 	err := g.db.WithContext(ctx).Where("jid = ? AND uid = ?", jid, uid).Order("round_number ASC").Find(&rounds).Error
 	return rounds, err
 }
+
+func (g *GORMInterviewDAO) DeleteByUID(ctx context.Context, uid int64) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("uid = ?", uid).Delete(&InterviewRound{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", uid).Delete(&InterviewJourney{}).Error
+	})
+}
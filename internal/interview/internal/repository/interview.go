@@ -35,6 +35,8 @@ type InterviewRepository interface {
 	CountJourneyByUID(ctx context.Context, uid int64) (int64, error)
 	// FindRoundsByJidAndUid 根据Journey ID和uid查找全部面试轮次
 	FindRoundsByJidAndUid(ctx context.Context, jid, uid int64) ([]domain.InterviewRound, error)
+	// DeleteByUID 删除一个用户的所有面试历程，包括轮次
+	DeleteByUID(ctx context.Context, uid int64) error
 }
 
 // interviewRepository 重构后的实现，聚合了两个DAO和DB实例用于事务
@@ -157,3 +159,7 @@ func (r *interviewRepository) FindRoundsByJidAndUid(ctx context.Context, jid, ui
 		return r.toRoundDomain(src)
 	}), nil
 }
+
+func (r *interviewRepository) DeleteByUID(ctx context.Context, uid int64) error {
+	return r.dao.DeleteByUID(ctx, uid)
+}
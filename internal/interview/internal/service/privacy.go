@@ -0,0 +1,70 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/interview/internal/domain"
+	"github.com/ecodeclub/webook/internal/interview/internal/repository"
+)
+
+// PrivacyService 导出以及删除用户的面试历程
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	repo      repository.InterviewRepository
+	batchSize int
+}
+
+func NewPrivacyService(repo repository.InterviewRepository) PrivacyService {
+	return &privacyService{
+		repo:      repo,
+		batchSize: 100,
+	}
+}
+
+func (s *privacyService) Name() string {
+	return "interview"
+}
+
+// Export 返回所有的面试历程，每个面试历程都带上轮次
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	res := make([]domain.InterviewJourney, 0, s.batchSize)
+	for offset := 0; ; offset += s.batchSize {
+		journeys, err := s.repo.FindJourneysByUID(ctx, uid, offset, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range journeys {
+			j.Rounds, err = s.repo.FindRoundsByJidAndUid(ctx, j.ID, uid)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, j)
+		}
+		if len(journeys) < s.batchSize {
+			return res, nil
+		}
+	}
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	return s.repo.DeleteByUID(ctx, uid)
+}
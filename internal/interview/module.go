@@ -17,4 +17,5 @@ package interview
 type Module struct {
 	JourneyHdl *JourneyHandler
 	OfferHdl   *OfferHandler
+	PrivacySvc PrivacyService
 }
@@ -36,6 +36,7 @@ import (
 type (
 	JourneyHandler = web.InterviewJourneyHandler
 	OfferHandler   = web.OfferHandler
+	PrivacyService = service.PrivacyService
 )
 
 func InitModule(db *egorm.Component) (*Module, error) {
@@ -43,6 +44,7 @@ func InitModule(db *egorm.Component) (*Module, error) {
 		initDAO,
 		repository.NewInterviewRepository,
 		service.NewInterviewService,
+		service.NewPrivacyService,
 		web.NewInterviewJourneyHandler,
 		initOfferHdl,
 		wire.Struct(new(Module), "*"),
@@ -28,9 +28,11 @@ func InitModule(db *gorm.DB) (*Module, error) {
 	interviewService := service.NewInterviewService(interviewRepository)
 	interviewJourneyHandler := web.NewInterviewJourneyHandler(interviewService)
 	offerHandler := initOfferHdl()
+	privacyService := service.NewPrivacyService(interviewRepository)
 	module := &Module{
 		JourneyHdl: interviewJourneyHandler,
 		OfferHdl:   offerHandler,
+		PrivacySvc: privacyService,
 	}
 	return module, nil
 }
@@ -40,6 +42,7 @@ func InitModule(db *gorm.DB) (*Module, error) {
 type (
 	JourneyHandler = web.InterviewJourneyHandler
 	OfferHandler   = web.OfferHandler
+	PrivacyService = service.PrivacyService
 )
 
 var initOnce sync.Once
@@ -19,6 +19,11 @@ type OrderCompletedActivity struct {
 	BuyerID int64
 }
 
+type OrderRefundedActivity struct {
+	OrderSN string
+	BuyerID int64
+}
+
 type UserRegistrationActivity struct {
 	Uid            int64
 	InvitationCode string
@@ -17,18 +17,21 @@ package event
 const (
 	MemberUpdateEventName     = "member_update_events"
 	OrderEventName            = "order_events"
+	OrderRefundEventName      = "order_refund_events"
 	CreditEventName           = "credit_increase_events"
 	PermissionEventName       = "permission_events"
 	UserRegistrationEventName = "user_registration_events"
+	CouponEventName           = "coupon_issue_events"
 )
 
 type MemberEvent struct {
 	Key    string `json:"key"`
-	Uid    int64  `json:"uid"`    // 用户A      用户C
-	Days   uint64 `json:"days"`   // 31天会员   366天会员
-	Biz    string `json:"biz"`    // user      order  对应的包名
-	BizId  int64  `json:"biz_id"` // user_id=A order_id
-	Action string `json:"action"` // 首次注册   购买会员
+	Uid    int64  `json:"uid"`              // 用户A      用户C
+	Days   uint64 `json:"days"`             // 31天会员   366天会员
+	Biz    string `json:"biz"`              // user      order  对应的包名
+	BizId  int64  `json:"biz_id"`           // user_id=A order_id
+	Action string `json:"action"`           // 首次注册   购买会员
+	Refund bool   `json:"refund,omitempty"` // 订单退款时为true, 表示扣减会员天数
 }
 
 type OrderEvent struct {
@@ -79,7 +82,15 @@ type PermissionEvent struct {
 	Uid    int64   `json:"uid"`
 	Biz    string  `json:"biz"` // project,interview
 	BizIds []int64 `json:"biz_ids"`
-	Action string  `json:"action"` // 购买项目商品, 兑换项目商品
+	Action string  `json:"action"`           // 购买项目商品, 兑换项目商品
+	Revoke bool    `json:"revoke,omitempty"` // 订单退款时为true, 表示收回权限
+}
+
+// CouponEvent 发放活动关联的所有优惠券
+type CouponEvent struct {
+	Key      string `json:"key"`
+	Uid      int64  `json:"uid"`
+	Activity string `json:"activity"` // registration 注册, invitation 邀请
 }
 
 type UserRegistrationEvent struct {
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./coupon_event_producer.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./coupon_event_producer.go -package=evtmocks -destination=../mocks/coupon.mock.go -typed CouponEventProducer
+//
+
+// Package evtmocks is a generated GoMock package.
+package evtmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	event "github.com/ecodeclub/webook/internal/marketing/internal/event"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCouponEventProducer is a mock of CouponEventProducer interface.
+type MockCouponEventProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockCouponEventProducerMockRecorder
+	isgomock struct{}
+}
+
+// MockCouponEventProducerMockRecorder is the mock recorder for MockCouponEventProducer.
+type MockCouponEventProducerMockRecorder struct {
+	mock *MockCouponEventProducer
+}
+
+// NewMockCouponEventProducer creates a new mock instance.
+func NewMockCouponEventProducer(ctrl *gomock.Controller) *MockCouponEventProducer {
+	mock := &MockCouponEventProducer{ctrl: ctrl}
+	mock.recorder = &MockCouponEventProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCouponEventProducer) EXPECT() *MockCouponEventProducerMockRecorder {
+	return m.recorder
+}
+
+// Produce mocks base method.
+func (m *MockCouponEventProducer) Produce(ctx context.Context, evt event.CouponEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Produce", ctx, evt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Produce indicates an expected call of Produce.
+func (mr *MockCouponEventProducerMockRecorder) Produce(ctx, evt any) *MockCouponEventProducerProduceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockCouponEventProducer)(nil).Produce), ctx, evt)
+	return &MockCouponEventProducerProduceCall{Call: call}
+}
+
+// MockCouponEventProducerProduceCall wrap *gomock.Call
+type MockCouponEventProducerProduceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockCouponEventProducerProduceCall) Return(arg0 error) *MockCouponEventProducerProduceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockCouponEventProducerProduceCall) Do(f func(context.Context, event.CouponEvent) error) *MockCouponEventProducerProduceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockCouponEventProducerProduceCall) DoAndReturn(f func(context.Context, event.CouponEvent) error) *MockCouponEventProducerProduceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
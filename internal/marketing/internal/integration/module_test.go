@@ -201,7 +201,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 				eventKeyGenerator := func() string {
 					return fmt.Sprintf("event-key-%s", evt.OrderSN)
 				}
-				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, memberEventProducer, nil, nil, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, memberEventProducer, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-member",
@@ -295,7 +295,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 				eventKeyGenerator := func() string {
 					return fmt.Sprintf("event-key-%s", evt.OrderSN)
 				}
-				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, memberEventProducer, nil, nil, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, memberEventProducer, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-member-2",
@@ -362,7 +362,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 					}, nil).Times(2)
 
 				return service.NewService(s.repo, mockOrderSvc, nil, s.getRedemptionCodeGenerator(sequencenumber.NewGenerator()),
-					nil, nil, nil, nil, nil)
+					nil, nil, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-code-member-1",
@@ -450,7 +450,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 					}, nil).Times(2)
 
 				return service.NewService(s.repo, mockOrderSvc, nil, s.getRedemptionCodeGenerator(sequencenumber.NewGenerator()),
-					nil, nil, nil, nil, nil)
+					nil, nil, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-code-member-2",
@@ -547,7 +547,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 				eventKeyGenerator := func() string {
 					return fmt.Sprintf("event-key-%s", evt.OrderSN)
 				}
-				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, nil, nil, permissionEventProducer, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, nil, nil, permissionEventProducer, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-project-101",
@@ -639,7 +639,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 				eventKeyGenerator := func() string {
 					return fmt.Sprintf("event-key-%s", evt.OrderSN)
 				}
-				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, nil, nil, permissionEventProducer, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, eventKeyGenerator, nil, nil, permissionEventProducer, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-project-102",
@@ -701,7 +701,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 					}, nil).Times(2)
 
 				return service.NewService(s.repo, mockOrderSvc, nil, s.getRedemptionCodeGenerator(sequencenumber.NewGenerator()),
-					nil, nil, nil, nil, nil)
+					nil, nil, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-code-project-103",
@@ -789,7 +789,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 					}, nil).Times(2)
 
 				return service.NewService(s.repo, mockOrderSvc, nil, s.getRedemptionCodeGenerator(sequencenumber.NewGenerator()),
-					nil, nil, nil, nil, nil)
+					nil, nil, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-code-project-104",
@@ -868,7 +868,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 					}, nil).Times(2)
 				qyWechatEventProducer := evtmocks.NewMockWechatRobotEventProducer(ctrl)
 				qyWechatEventProducer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil).Times(2)
-				return service.NewService(nil, mockOrderSvc, nil, nil, nil, nil, nil, nil, qyWechatEventProducer)
+				return service.NewService(nil, mockOrderSvc, nil, nil, nil, nil, nil, nil, qyWechatEventProducer, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-service-101",
@@ -947,7 +947,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 				mockMQ.EXPECT().Producer(event.CreditEventName).Return(mockProducer, nil)
 				creditProducer, err := producer.NewCreditEventProducer(mockMQ)
 				assert.NoError(t, err)
-				return service.NewService(nil, mockOrderSvc, nil, nil, nil, nil, creditProducer, nil, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, nil, nil, creditProducer, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-service-102",
@@ -983,7 +983,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 				mockOrderSvc := ordermocks.NewMockService(ctrl)
 				memberEventProducer, err := producer.NewMemberEventProducer(q)
 				require.NoError(t, err)
-				return service.NewService(nil, mockOrderSvc, nil, nil, nil, memberEventProducer, nil, nil, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, nil, memberEventProducer, nil, nil, nil, nil)
 			},
 			evt: event.OrderEvent{
 				OrderSN: "OrderSN-marketing-other",
@@ -1023,6 +1023,181 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeOrderEvent() {
 	}
 }
 
+func (s *ModuleTestSuite) TestConsumer_ConsumeOrderRefundEvent() {
+	t := s.T()
+
+	testCases := []struct {
+		name           string
+		newMQFunc      func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent) mq.MQ
+		newSvcFunc     func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent, q mq.MQ) service.Service
+		evt            event.OrderEvent
+		errRequireFunc require.ErrorAssertionFunc
+	}{
+		{
+			name: "消费订单退款消息成功_扣减会员天数",
+			newMQFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent) mq.MQ {
+				t.Helper()
+
+				mockMQ := mocks.NewMockMQ(ctrl)
+				mockConsumer := mocks.NewMockConsumer(ctrl)
+				mockConsumer.EXPECT().Consume(gomock.Any()).Return(s.newOrderEventMessage(t, evt), nil)
+
+				mockProducer := mocks.NewMockProducer(ctrl)
+				memberEvent := s.newMemberEventMessage(t, event.MemberEvent{
+					Key:    fmt.Sprintf("refund-%s", evt.OrderSN),
+					Uid:    evt.BuyerID,
+					Days:   14,
+					Biz:    "order",
+					BizId:  11,
+					Action: "退款会员商品",
+					Refund: true,
+				})
+				mockProducer.EXPECT().Produce(gomock.Any(), memberEvent).Return(&mq.ProducerResult{}, nil)
+
+				mockMQ.EXPECT().Consumer(event.OrderRefundEventName, gomock.Any()).Return(mockConsumer, nil)
+				mockMQ.EXPECT().Producer(event.MemberUpdateEventName).Return(mockProducer, nil)
+				return mockMQ
+			},
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent, q mq.MQ) service.Service {
+				t.Helper()
+
+				mockOrderSvc := ordermocks.NewMockService(ctrl)
+				mockOrderSvc.EXPECT().
+					FindUserVisibleOrderByUIDAndSN(gomock.Any(), evt.BuyerID, evt.OrderSN).
+					Return(order.Order{
+						ID:      11,
+						SN:      evt.OrderSN,
+						BuyerID: evt.BuyerID,
+						Status:  order.StatusRefunded,
+						Items: []order.Item{
+							{
+								SPU: order.SPU{ID: 1, Category0: "product", Category1: "member"},
+								SKU: order.SKU{ID: 1, Attrs: `{"days":7}`, Quantity: 2},
+							},
+						},
+					}, nil)
+
+				memberEventProducer, err := producer.NewMemberEventProducer(q)
+				require.NoError(t, err)
+				return service.NewService(nil, mockOrderSvc, nil, nil, nil, memberEventProducer, nil, nil, nil, nil)
+			},
+			evt: event.OrderEvent{
+				OrderSN: "OrderSN-marketing-refund-member",
+				BuyerID: 223456,
+				SPUs:    []event.SPU{{ID: 1, Category0: "product", Category1: "member"}},
+			},
+			errRequireFunc: require.NoError,
+		},
+		{
+			name: "消费订单退款消息成功_收回项目权限",
+			newMQFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent) mq.MQ {
+				t.Helper()
+
+				mockMQ := mocks.NewMockMQ(ctrl)
+				mockConsumer := mocks.NewMockConsumer(ctrl)
+				mockConsumer.EXPECT().Consume(gomock.Any()).Return(s.newOrderEventMessage(t, evt), nil)
+
+				mockProducer := mocks.NewMockProducer(ctrl)
+				permissionEvent := s.newPermissionEventMessage(t, event.PermissionEvent{
+					Uid:    evt.BuyerID,
+					Biz:    "project",
+					BizIds: []int64{5},
+					Action: "退款项目商品",
+					Revoke: true,
+				})
+				mockProducer.EXPECT().Produce(gomock.Any(), permissionEvent).Return(&mq.ProducerResult{}, nil)
+
+				mockMQ.EXPECT().Consumer(event.OrderRefundEventName, gomock.Any()).Return(mockConsumer, nil)
+				mockMQ.EXPECT().Producer(event.PermissionEventName).Return(mockProducer, nil)
+				return mockMQ
+			},
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent, q mq.MQ) service.Service {
+				t.Helper()
+
+				mockOrderSvc := ordermocks.NewMockService(ctrl)
+				mockOrderSvc.EXPECT().
+					FindUserVisibleOrderByUIDAndSN(gomock.Any(), evt.BuyerID, evt.OrderSN).
+					Return(order.Order{
+						ID:      12,
+						SN:      evt.OrderSN,
+						BuyerID: evt.BuyerID,
+						Status:  order.StatusRefunded,
+						Items: []order.Item{
+							{
+								SPU: order.SPU{ID: 2, Category0: "product", Category1: "project"},
+								SKU: order.SKU{ID: 2, Attrs: `{"projectId":5}`, Quantity: 1},
+							},
+						},
+					}, nil)
+
+				permissionEventProducer, err := producer.NewPermissionEventProducer(q)
+				require.NoError(t, err)
+				return service.NewService(nil, mockOrderSvc, nil, nil, nil, nil, nil, permissionEventProducer, nil, nil)
+			},
+			evt: event.OrderEvent{
+				OrderSN: "OrderSN-marketing-refund-project",
+				BuyerID: 223457,
+				SPUs:    []event.SPU{{ID: 2, Category0: "product", Category1: "project"}},
+			},
+			errRequireFunc: require.NoError,
+		},
+		{
+			name: "消费订单退款消息成功_忽略不支持回收的商品",
+			newMQFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent) mq.MQ {
+				t.Helper()
+
+				mockMQ := mocks.NewMockMQ(ctrl)
+				mockConsumer := mocks.NewMockConsumer(ctrl)
+				mockConsumer.EXPECT().Consume(gomock.Any()).Return(s.newOrderEventMessage(t, evt), nil)
+				mockMQ.EXPECT().Consumer(event.OrderRefundEventName, gomock.Any()).Return(mockConsumer, nil)
+				return mockMQ
+			},
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.OrderEvent, q mq.MQ) service.Service {
+				t.Helper()
+
+				mockOrderSvc := ordermocks.NewMockService(ctrl)
+				mockOrderSvc.EXPECT().
+					FindUserVisibleOrderByUIDAndSN(gomock.Any(), evt.BuyerID, evt.OrderSN).
+					Return(order.Order{
+						ID:      13,
+						SN:      evt.OrderSN,
+						BuyerID: evt.BuyerID,
+						Status:  order.StatusRefunded,
+						Items: []order.Item{
+							{
+								SPU: order.SPU{ID: 3, Category0: "product", Category1: "credit"},
+								SKU: order.SKU{ID: 3, Attrs: `{"credit":100}`, Quantity: 1},
+							},
+						},
+					}, nil)
+				return service.NewService(nil, mockOrderSvc, nil, nil, nil, nil, nil, nil, nil, nil)
+			},
+			evt: event.OrderEvent{
+				OrderSN: "OrderSN-marketing-refund-credit",
+				BuyerID: 223458,
+				SPUs:    []event.SPU{{ID: 3, Category0: "product", Category1: "credit"}},
+			},
+			errRequireFunc: require.NoError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			q := tc.newMQFunc(t, ctrl, tc.evt)
+			svc := tc.newSvcFunc(t, ctrl, tc.evt, q)
+			c, err := consumer.NewOrderRefundEventConsumer(svc, q)
+			require.NoError(t, err)
+
+			err = c.Consume(context.Background())
+			tc.errRequireFunc(t, err)
+		})
+	}
+}
+
 func (s *ModuleTestSuite) assertRedemptionCodeEqual(t *testing.T, expected []domain.RedemptionCode, actual []domain.RedemptionCode) {
 	for i, c := range actual {
 		assert.NotZero(t, c.ID)
@@ -1088,9 +1263,16 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 					Action: "注册福利",
 				})
 				mockProducer.EXPECT().Produce(gomock.Any(), memberEvent).Return(&mq.ProducerResult{}, nil).Times(2)
+				registrationCouponEvent := s.newCouponEventMessage(t, event.CouponEvent{
+					Key:      fmt.Sprintf("user-registration-%d", evt.Uid),
+					Uid:      evt.Uid,
+					Activity: "registration",
+				})
+				mockProducer.EXPECT().Produce(gomock.Any(), registrationCouponEvent).Return(&mq.ProducerResult{}, nil).Times(2)
 
 				mockMQ.EXPECT().Consumer(gomock.Any(), gomock.Any()).Return(mockConsumer, nil)
 				mockMQ.EXPECT().Producer(event.MemberUpdateEventName).Return(mockProducer, nil)
+				mockMQ.EXPECT().Producer(event.CouponEventName).Return(mockProducer, nil)
 				return mockMQ
 			},
 			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.UserRegistrationEvent, q mq.MQ) service.Service {
@@ -1099,9 +1281,12 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 				memberEventProducer, err := producer.NewMemberEventProducer(q)
 				require.NoError(t, err)
 
+				couponEventProducer, err := producer.NewCouponEventProducer(q)
+				require.NoError(t, err)
+
 				repo := repository.NewRepository(dao.NewGORMMarketingDAO(s.db), cache.NewInvitationCodeECache(testioc.InitCache(), time.Minute*10))
 
-				return service.NewService(repo, nil, nil, nil, nil, memberEventProducer, nil, nil, nil)
+				return service.NewService(repo, nil, nil, nil, nil, memberEventProducer, nil, nil, nil, couponEventProducer)
 			},
 			evt: event.UserRegistrationEvent{
 				Uid: testID,
@@ -1128,6 +1313,12 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 					Action: "注册福利",
 				})
 				mockProducer.EXPECT().Produce(gomock.Any(), memberEvent).Return(&mq.ProducerResult{}, nil).Times(2)
+				registrationCouponEvent := s.newCouponEventMessage(t, event.CouponEvent{
+					Key:      fmt.Sprintf("user-registration-%d", evt.Uid),
+					Uid:      evt.Uid,
+					Activity: "registration",
+				})
+				mockProducer.EXPECT().Produce(gomock.Any(), registrationCouponEvent).Return(&mq.ProducerResult{}, nil).Times(2)
 
 				inviterId := int64(345691)
 				creditsAwarded := uint64(500)
@@ -1140,9 +1331,16 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 					Action: "邀请奖励",
 				})
 				mockProducer.EXPECT().Produce(gomock.Any(), creditEvent).Return(&mq.ProducerResult{}, nil).Times(2)
+				invitationCouponEvent := s.newCouponEventMessage(t, event.CouponEvent{
+					Key:      fmt.Sprintf("inviteeId-%d", evt.Uid),
+					Uid:      inviterId,
+					Activity: "invitation",
+				})
+				mockProducer.EXPECT().Produce(gomock.Any(), invitationCouponEvent).Return(&mq.ProducerResult{}, nil).Times(2)
 
 				mockMQ.EXPECT().Consumer(gomock.Any(), gomock.Any()).Return(mockConsumer, nil)
 				mockMQ.EXPECT().Producer(event.MemberUpdateEventName).Return(mockProducer, nil)
+				mockMQ.EXPECT().Producer(event.CouponEventName).Return(mockProducer, nil)
 				mockMQ.EXPECT().Producer(event.CreditEventName).Return(mockProducer, nil)
 				return mockMQ
 			},
@@ -1155,6 +1353,9 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 				creditEventProducer, err := producer.NewCreditEventProducer(q)
 				require.NoError(t, err)
 
+				couponEventProducer, err := producer.NewCouponEventProducer(q)
+				require.NoError(t, err)
+
 				repo := repository.NewRepository(dao.NewGORMMarketingDAO(s.db), cache.NewInvitationCodeECache(testioc.InitCache(), time.Minute*10))
 
 				expectedCode := domain.InvitationCode{
@@ -1164,7 +1365,7 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 				_, err = repo.CreateInvitationCode(context.Background(), expectedCode)
 				require.NoError(t, err)
 
-				return service.NewService(repo, nil, nil, nil, nil, memberEventProducer, creditEventProducer, nil, nil)
+				return service.NewService(repo, nil, nil, nil, nil, memberEventProducer, creditEventProducer, nil, nil, couponEventProducer)
 			},
 			evt: event.UserRegistrationEvent{
 				Uid:            testID,
@@ -1210,9 +1411,16 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 					Action: "注册福利",
 				})
 				mockProducer.EXPECT().Produce(gomock.Any(), memberEvent).Return(&mq.ProducerResult{}, nil).Times(2)
+				registrationCouponEvent := s.newCouponEventMessage(t, event.CouponEvent{
+					Key:      fmt.Sprintf("user-registration-%d", evt.Uid),
+					Uid:      evt.Uid,
+					Activity: "registration",
+				})
+				mockProducer.EXPECT().Produce(gomock.Any(), registrationCouponEvent).Return(&mq.ProducerResult{}, nil).Times(2)
 
 				mockMQ.EXPECT().Consumer(gomock.Any(), gomock.Any()).Return(mockConsumer, nil)
 				mockMQ.EXPECT().Producer(event.MemberUpdateEventName).Return(mockProducer, nil)
+				mockMQ.EXPECT().Producer(event.CouponEventName).Return(mockProducer, nil)
 				mockMQ.EXPECT().Producer(event.CreditEventName).Return(mockProducer, nil)
 				return mockMQ
 			},
@@ -1225,9 +1433,12 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeUserRegistrationEvent() {
 				creditEventProducer, err := producer.NewCreditEventProducer(q)
 				require.NoError(t, err)
 
+				couponEventProducer, err := producer.NewCouponEventProducer(q)
+				require.NoError(t, err)
+
 				repo := repository.NewRepository(dao.NewGORMMarketingDAO(s.db), cache.NewInvitationCodeECache(testioc.InitCache(), time.Minute*10))
 
-				return service.NewService(repo, nil, nil, nil, nil, memberEventProducer, creditEventProducer, nil, nil)
+				return service.NewService(repo, nil, nil, nil, nil, memberEventProducer, creditEventProducer, nil, nil, couponEventProducer)
 			},
 			evt: event.UserRegistrationEvent{
 				Uid:            testID,
@@ -1276,6 +1487,12 @@ func (s *ModuleTestSuite) newCreditEventMessage(t *testing.T, evt event.CreditIn
 	return &mq.Message{Value: marshal}
 }
 
+func (s *ModuleTestSuite) newCouponEventMessage(t *testing.T, evt event.CouponEvent) *mq.Message {
+	marshal, err := json.Marshal(evt)
+	require.NoError(t, err)
+	return &mq.Message{Value: marshal}
+}
+
 func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 	t := s.T()
 
@@ -1344,7 +1561,7 @@ func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 				memberEventProducer, err := producer.NewMemberEventProducer(q)
 				require.NoError(t, err)
 
-				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil)
+				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 
@@ -1416,7 +1633,7 @@ func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 				memberEventProducer, err := producer.NewMemberEventProducer(q)
 				require.NoError(t, err)
 
-				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil)
+				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 
@@ -1486,7 +1703,7 @@ func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 				permissionEventProducer, err := producer.NewPermissionEventProducer(q)
 				require.NoError(t, err)
 
-				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, nil, nil, permissionEventProducer, nil)
+				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, nil, nil, permissionEventProducer, nil, nil)
 				return web.NewHandler(svc)
 			},
 
@@ -1555,7 +1772,7 @@ func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 				permissionEventProducer, err := producer.NewPermissionEventProducer(q)
 				require.NoError(t, err)
 
-				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, nil, nil, permissionEventProducer, nil)
+				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, nil, nil, permissionEventProducer, nil, nil)
 				return web.NewHandler(svc)
 			},
 
@@ -1619,7 +1836,7 @@ func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 				memberEventProducer, err := producer.NewMemberEventProducer(q)
 				require.NoError(t, err)
 
-				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil)
+				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 
@@ -1666,7 +1883,7 @@ func (s *ModuleTestSuite) TestHandler_RedeemRedemptionCode() {
 				memberEventProducer, err := producer.NewMemberEventProducer(q)
 				require.NoError(t, err)
 
-				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil)
+				svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 
@@ -1729,7 +1946,7 @@ func (s *ModuleTestSuite) TestHandler_ListRedemptionCode() {
 				t.Helper()
 
 				redemptionCodeGenerator := s.getRedemptionCodeGenerator(sequencenumber.NewGenerator())
-				svc := service.NewService(s.repo, nil, nil, redemptionCodeGenerator, nil, nil, nil, nil, nil)
+				svc := service.NewService(s.repo, nil, nil, redemptionCodeGenerator, nil, nil, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 			req: web.ListRedemptionCodesReq{
@@ -1827,7 +2044,7 @@ func (s *ModuleTestSuite) TestHandler_GenerateInvitationCode() {
 				codeGenerator := func(id int64) string {
 					return fmt.Sprintf("invitation-code-1-%d", id)
 				}
-				svc := service.NewService(repo, nil, nil, codeGenerator, nil, nil, nil, nil, nil)
+				svc := service.NewService(repo, nil, nil, codeGenerator, nil, nil, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 			wantCode: 200,
@@ -1858,7 +2075,7 @@ func (s *ModuleTestSuite) TestHandler_GenerateInvitationCode() {
 				codeGenerator := func(id int64) string {
 					return fmt.Sprintf("invitation-code-3-%d", id)
 				}
-				svc := service.NewService(repo, nil, nil, codeGenerator, nil, nil, nil, nil, nil)
+				svc := service.NewService(repo, nil, nil, codeGenerator, nil, nil, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 			wantCode: 200,
@@ -1891,7 +2108,7 @@ func (s *ModuleTestSuite) TestHandler_GenerateInvitationCode() {
 				codeGenerator := func(id int64) string {
 					return fmt.Sprintf("invitation-code-5-%d", id)
 				}
-				svc := service.NewService(repo, nil, nil, codeGenerator, nil, nil, nil, nil, nil)
+				svc := service.NewService(repo, nil, nil, codeGenerator, nil, nil, nil, nil, nil, nil)
 				return web.NewHandler(svc)
 			},
 			wantCode: 200,
@@ -1955,7 +2172,7 @@ func (s *ModuleTestSuite) TestService_RedeemRedemptionCode() {
 	memberEventProducer, err := producer.NewMemberEventProducer(mockMQ)
 	require.NoError(t, err)
 
-	svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil)
+	svc := service.NewService(s.repo, mockOrderSvc, mockProductSvc, nil, nil, memberEventProducer, nil, nil, nil, nil)
 
 	var wg sync.WaitGroup
 	n := 100
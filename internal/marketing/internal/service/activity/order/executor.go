@@ -41,8 +41,14 @@ func NewOrderActivityExecutor(
 ) *ActivityExecutor {
 
 	registry := NewHandlerRegistry()
-	registry.RegisterOrderHandler("product", "member", handler.NewProductMemberHandler(memberEventProducer, creditEventProducer))
-	registry.RegisterOrderHandler("product", "project", handler.NewProductProjectHandler(permissionEventProducer, creditEventProducer))
+	productMemberHandler := handler.NewProductMemberHandler(memberEventProducer, creditEventProducer)
+	registry.RegisterOrderHandler("product", "member", productMemberHandler)
+	registry.RegisterRefundHandler("product", "member", productMemberHandler)
+
+	productProjectHandler := handler.NewProductProjectHandler(permissionEventProducer, creditEventProducer)
+	registry.RegisterOrderHandler("product", "project", productProjectHandler)
+	registry.RegisterRefundHandler("product", "project", productProjectHandler)
+
 	registry.RegisterOrderHandler("product", "service", handler.NewProductServiceHandler(qywechatEventProducer))
 	registry.RegisterOrderHandler("product", "credit", handler.NewProductCreditHandler(creditEventProducer))
 
@@ -86,6 +92,33 @@ func (s *ActivityExecutor) Execute(ctx context.Context, act domain.OrderComplete
 	return nil
 }
 
+// Refund 订单退款后回收权益,订单模块只允许会员和项目商品申请退款
+func (s *ActivityExecutor) Refund(ctx context.Context, act domain.OrderRefundedActivity) error {
+	o, err := s.orderSvc.FindUserVisibleOrderByUIDAndSN(ctx, act.BuyerID, act.OrderSN)
+	if err != nil {
+		return err
+	}
+
+	categorizedItems := NewCategorizedItems()
+	for _, item := range o.Items {
+		categorizedItems.AddItem(SPUCategory(item.SPU.Category0), SPUCategory(item.SPU.Category1), item)
+	}
+
+	for category0, category1Set := range categorizedItems.CategoriesAndTypes() {
+		for category1 := range category1Set {
+			h, ok := s.handlerRegistry.GetRefundHandler(category0, category1)
+			if !ok {
+				continue
+			}
+			items := categorizedItems.GetItems(category0, category1)
+			if er := h.Refund(ctx, handler.OrderInfo{Order: o, Items: items}); er != nil {
+				return fmt.Errorf("回收category0=%s, category1=%s商品权益失败: %w", category0, category1, er)
+			}
+		}
+	}
+	return nil
+}
+
 func (s *ActivityExecutor) Redeem(ctx context.Context, redeemerID int64, r domain.RedemptionCode) error {
 	h, ok := s.handlerRegistry.GetRedeemerHandler(SPUCategory(r.Type))
 	if !ok {
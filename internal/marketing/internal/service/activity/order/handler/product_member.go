@@ -22,7 +22,10 @@ import (
 	"github.com/ecodeclub/webook/internal/marketing/internal/event/producer"
 )
 
-var _ OrderHandler = (*ProductMemberHandler)(nil)
+var (
+	_ OrderHandler  = (*ProductMemberHandler)(nil)
+	_ RefundHandler = (*ProductMemberHandler)(nil)
+)
 
 type ProductMemberHandler struct {
 	memberEventProducer producer.MemberEventProducer
@@ -34,6 +37,38 @@ func NewProductMemberHandler(memberEventProducer producer.MemberEventProducer, c
 }
 
 func (h *ProductMemberHandler) Handle(ctx context.Context, info OrderInfo) error {
+	days, err := h.days(info)
+	if err != nil {
+		return err
+	}
+	return h.memberEventProducer.Produce(ctx, event.MemberEvent{
+		Key:    info.Order.SN,
+		Uid:    info.Order.BuyerID,
+		Days:   days,
+		Biz:    Biz,
+		BizId:  info.Order.ID,
+		Action: "购买会员商品",
+	})
+}
+
+// Refund 扣减购买时开通的会员天数
+func (h *ProductMemberHandler) Refund(ctx context.Context, info OrderInfo) error {
+	days, err := h.days(info)
+	if err != nil {
+		return err
+	}
+	return h.memberEventProducer.Produce(ctx, event.MemberEvent{
+		Key:    fmt.Sprintf("refund-%s", info.Order.SN),
+		Uid:    info.Order.BuyerID,
+		Days:   days,
+		Biz:    Biz,
+		BizId:  info.Order.ID,
+		Action: "退款会员商品",
+		Refund: true,
+	})
+}
+
+func (h *ProductMemberHandler) days(info OrderInfo) (uint64, error) {
 	type Attrs struct {
 		Days uint64 `json:"days,omitempty"`
 	}
@@ -42,17 +77,10 @@ func (h *ProductMemberHandler) Handle(ctx context.Context, info OrderInfo) error
 		var attrs Attrs
 		err := item.SKU.UnmarshalAttrs(&attrs)
 		if err != nil {
-			return fmt.Errorf("解析会员商品属性失败: %w, oid: %d, skuid:%d, attrs: %s",
+			return 0, fmt.Errorf("解析会员商品属性失败: %w, oid: %d, skuid:%d, attrs: %s",
 				err, info.Order.ID, item.SKU.ID, item.SKU.Attrs)
 		}
 		days += attrs.Days * uint64(item.SKU.Quantity)
 	}
-	return h.memberEventProducer.Produce(ctx, event.MemberEvent{
-		Key:    info.Order.SN,
-		Uid:    info.Order.BuyerID,
-		Days:   days,
-		Biz:    Biz,
-		BizId:  info.Order.ID,
-		Action: "购买会员商品",
-	})
+	return days, nil
 }
@@ -21,7 +21,10 @@ import (
 	"github.com/ecodeclub/webook/internal/marketing/internal/event/producer"
 )
 
-var _ OrderHandler = (*ProductProjectHandler)(nil)
+var (
+	_ OrderHandler  = (*ProductProjectHandler)(nil)
+	_ RefundHandler = (*ProductProjectHandler)(nil)
+)
 
 type ProductProjectHandler struct {
 	permissionEventProducer producer.PermissionEventProducer
@@ -33,6 +36,34 @@ func NewProductProjectHandler(permissionEventProducer producer.PermissionEventPr
 }
 
 func (h *ProductProjectHandler) Handle(ctx context.Context, info OrderInfo) error {
+	ids, err := h.projectIDs(info)
+	if err != nil {
+		return err
+	}
+	return h.permissionEventProducer.Produce(ctx, event.PermissionEvent{
+		Uid:    info.Order.BuyerID,
+		Biz:    "project",
+		BizIds: ids,
+		Action: "购买项目商品",
+	})
+}
+
+// Refund 收回购买时授予的项目权限
+func (h *ProductProjectHandler) Refund(ctx context.Context, info OrderInfo) error {
+	ids, err := h.projectIDs(info)
+	if err != nil {
+		return err
+	}
+	return h.permissionEventProducer.Produce(ctx, event.PermissionEvent{
+		Uid:    info.Order.BuyerID,
+		Biz:    "project",
+		BizIds: ids,
+		Action: "退款项目商品",
+		Revoke: true,
+	})
+}
+
+func (h *ProductProjectHandler) projectIDs(info OrderInfo) ([]int64, error) {
 	ids := make([]int64, 0, len(info.Items))
 	type Attrs struct {
 		ProjectId int64 `json:"projectId"`
@@ -41,14 +72,9 @@ func (h *ProductProjectHandler) Handle(ctx context.Context, info OrderInfo) erro
 		var attrs Attrs
 		err := item.SKU.UnmarshalAttrs(&attrs)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		ids = append(ids, attrs.ProjectId)
 	}
-	return h.permissionEventProducer.Produce(ctx, event.PermissionEvent{
-		Uid:    info.Order.BuyerID,
-		Biz:    "project",
-		BizIds: ids,
-		Action: "购买项目商品",
-	})
+	return ids, nil
 }
@@ -30,6 +30,11 @@ type (
 		Handle(ctx context.Context, info OrderInfo) error
 	}
 
+	// RefundHandler 订单退款后回收对应商品的权益
+	RefundHandler interface {
+		Refund(ctx context.Context, info OrderInfo) error
+	}
+
 	RedeemInfo struct {
 		RedeemerID int64
 		Code       domain.RedemptionCode
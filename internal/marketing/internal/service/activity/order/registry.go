@@ -21,12 +21,14 @@ import (
 type HandlerRegistry struct {
 	orderHandlers    map[SPUCategory]map[SPUCategory]handler.OrderHandler
 	redeemerHandlers map[SPUCategory]handler.RedeemerHandler
+	refundHandlers   map[SPUCategory]map[SPUCategory]handler.RefundHandler
 }
 
 func NewHandlerRegistry() *HandlerRegistry {
 	return &HandlerRegistry{
 		orderHandlers:    make(map[SPUCategory]map[SPUCategory]handler.OrderHandler),
 		redeemerHandlers: make(map[SPUCategory]handler.RedeemerHandler),
+		refundHandlers:   make(map[SPUCategory]map[SPUCategory]handler.RefundHandler),
 	}
 }
 
@@ -53,3 +55,18 @@ func (r *HandlerRegistry) GetRedeemerHandler(category1 SPUCategory) (handler.Red
 	h, ok := r.redeemerHandlers[category1]
 	return h, ok
 }
+
+func (r *HandlerRegistry) RegisterRefundHandler(category0 SPUCategory, category1 SPUCategory, h handler.RefundHandler) {
+	if r.refundHandlers[category0] == nil {
+		r.refundHandlers[category0] = make(map[SPUCategory]handler.RefundHandler)
+	}
+	r.refundHandlers[category0][category1] = h
+}
+
+func (r *HandlerRegistry) GetRefundHandler(category0 SPUCategory, category1 SPUCategory) (handler.RefundHandler, bool) {
+	if category1Set, ok := r.refundHandlers[category0]; ok {
+		h, ok := category1Set[category1]
+		return h, ok
+	}
+	return nil, false
+}
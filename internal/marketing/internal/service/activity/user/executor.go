@@ -30,6 +30,7 @@ type ActivityExecutor struct {
 	repo                repository.MarketingRepository
 	memberEventProducer producer.MemberEventProducer
 	creditEventProducer producer.CreditEventProducer
+	couponEventProducer producer.CouponEventProducer
 	logger              *elog.Component
 	creditsAwarded      uint64
 }
@@ -38,12 +39,14 @@ func NewActivityExecutor(
 	repo repository.MarketingRepository,
 	memberEventProducer producer.MemberEventProducer,
 	creditEventProducer producer.CreditEventProducer,
+	couponEventProducer producer.CouponEventProducer,
 	creditsAwarded uint64,
 ) *ActivityExecutor {
 	return &ActivityExecutor{
 		repo:                repo,
 		memberEventProducer: memberEventProducer,
 		creditEventProducer: creditEventProducer,
+		couponEventProducer: couponEventProducer,
 		logger:              elog.DefaultLogger,
 		creditsAwarded:      creditsAwarded,
 	}
@@ -68,6 +71,14 @@ func (s *ActivityExecutor) awardRegistrationBonus(ctx context.Context, act domai
 	if err != nil {
 		return fmt.Errorf("为注册者发放注册福利失败: %w", err)
 	}
+	err = s.couponEventProducer.Produce(ctx, event.CouponEvent{
+		Key:      fmt.Sprintf("user-registration-%d", act.Uid),
+		Uid:      act.Uid,
+		Activity: "registration",
+	})
+	if err != nil {
+		return fmt.Errorf("为注册者发放注册优惠券失败: %w", err)
+	}
 	return nil
 }
 
@@ -95,7 +106,7 @@ func (s *ActivityExecutor) awardInvitationBonus(ctx context.Context, act domain.
 		return fmt.Errorf("创建邀请记录失败: %w", err)
 	}
 
-	return s.creditEventProducer.Produce(ctx, event.CreditIncreaseEvent{
+	err = s.creditEventProducer.Produce(ctx, event.CreditIncreaseEvent{
 		Key:    fmt.Sprintf("inviteeId-%d", act.Uid),
 		Uid:    c.Uid,
 		Amount: s.creditsAwarded,
@@ -103,4 +114,12 @@ func (s *ActivityExecutor) awardInvitationBonus(ctx context.Context, act domain.
 		BizId:  act.Uid,
 		Action: "邀请奖励",
 	})
+	if err != nil {
+		return fmt.Errorf("为邀请者发放邀请奖励失败: %w", err)
+	}
+	return s.couponEventProducer.Produce(ctx, event.CouponEvent{
+		Key:      fmt.Sprintf("inviteeId-%d", act.Uid),
+		Uid:      c.Uid,
+		Activity: "invitation",
+	})
 }
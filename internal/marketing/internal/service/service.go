@@ -34,6 +34,7 @@ var (
 
 type Service interface {
 	ExecuteOrderCompletedActivity(ctx context.Context, act domain.OrderCompletedActivity) error
+	ExecuteOrderRefundedActivity(ctx context.Context, act domain.OrderRefundedActivity) error
 	ExecuteUserRegistrationActivity(ctx context.Context, act domain.UserRegistrationActivity) error
 	RedeemRedemptionCode(ctx context.Context, uid int64, code string) error
 	ListRedemptionCodes(ctx context.Context, uid int64, offset, list int) ([]domain.RedemptionCode, int64, error)
@@ -60,6 +61,7 @@ func NewService(
 	creditEventProducer producer.CreditEventProducer,
 	permissionEventProducer producer.PermissionEventProducer,
 	qywechatEventProducer producer.WechatRobotEventProducer,
+	couponEventProducer producer.CouponEventProducer,
 ) Service {
 
 	return &service{
@@ -68,7 +70,7 @@ func NewService(
 		eventKeyGenerator:       eventKeyGenerator,
 		invitationCodeGenerator: codeGenerator,
 		orderActivityExecutor:   orderexe.NewOrderActivityExecutor(repo, orderSvc, codeGenerator, memberEventProducer, creditEventProducer, permissionEventProducer, qywechatEventProducer),
-		userActivityExecutor:    user.NewActivityExecutor(repo, memberEventProducer, creditEventProducer, 500),
+		userActivityExecutor:    user.NewActivityExecutor(repo, memberEventProducer, creditEventProducer, couponEventProducer, 500),
 	}
 }
 
@@ -76,6 +78,10 @@ func (s *service) ExecuteOrderCompletedActivity(ctx context.Context, act domain.
 	return s.orderActivityExecutor.Execute(ctx, act)
 }
 
+func (s *service) ExecuteOrderRefundedActivity(ctx context.Context, act domain.OrderRefundedActivity) error {
+	return s.orderActivityExecutor.Refund(ctx, act)
+}
+
 func (s *service) ExecuteUserRegistrationActivity(ctx context.Context, act domain.UserRegistrationActivity) error {
 	return s.userActivityExecutor.Execute(ctx, act)
 }
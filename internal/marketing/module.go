@@ -17,8 +17,9 @@ package marketing
 import "github.com/ecodeclub/webook/internal/marketing/internal/event/consumer"
 
 type Module struct {
-	AdminHdl      *AdminHandler
-	Hdl           *Handler
-	orderConsumer *consumer.OrderEventConsumer
-	userConsumer  *consumer.UserRegistrationEventConsumer
+	AdminHdl            *AdminHandler
+	Hdl                 *Handler
+	orderConsumer       *consumer.OrderEventConsumer
+	orderRefundConsumer *consumer.OrderRefundEventConsumer
+	userConsumer        *consumer.UserRegistrationEventConsumer
 }
@@ -63,11 +63,13 @@ func InitModule(db *egorm.Component, q mq.MQ, c ecache.Cache, om *order.Module,
 		producer.NewCreditEventProducer,
 		producer.NewPermissionEventProducer,
 		producer.NewQYWeChatEventProducer,
+		producer.NewCouponEventProducer,
 		service.NewService,
 		web.NewHandler,
 		service.NewAdminService,
 		web.NewAdminHandler,
 		newOrderEventConsumer,
+		newOrderRefundEventConsumer,
 		newUserEventConsumer,
 		wire.Struct(new(Module), "*"),
 	)
@@ -94,6 +96,14 @@ func newOrderEventConsumer(svc service.Service, q mq.MQ) (*consumer.OrderEventCo
 	return res, err
 }
 
+func newOrderRefundEventConsumer(svc service.Service, q mq.MQ) (*consumer.OrderRefundEventConsumer, error) {
+	res, err := consumer.NewOrderRefundEventConsumer(svc, q)
+	if err == nil {
+		res.Start(context.Background())
+	}
+	return res, err
+}
+
 func newUserEventConsumer(svc service.Service, q mq.MQ) (*consumer.UserRegistrationEventConsumer, error) {
 	res, err := consumer.NewUserRegistrationEventConsumer(svc, q)
 	if err == nil {
@@ -57,21 +57,30 @@ func InitModule(db *gorm.DB, q mq.MQ, c ecache.Cache, om *order.Module, pm *prod
 	if err != nil {
 		return nil, err
 	}
-	service3 := service.NewService(marketingRepository, service2, serviceService, v, v2, memberEventProducer, creditEventProducer, permissionEventProducer, wechatRobotEventProducer)
+	couponEventProducer, err := producer.NewCouponEventProducer(q)
+	if err != nil {
+		return nil, err
+	}
+	service3 := service.NewService(marketingRepository, service2, serviceService, v, v2, memberEventProducer, creditEventProducer, permissionEventProducer, wechatRobotEventProducer, couponEventProducer)
 	handler := web.NewHandler(service3)
 	orderEventConsumer, err := newOrderEventConsumer(service3, q)
 	if err != nil {
 		return nil, err
 	}
+	orderRefundEventConsumer, err := newOrderRefundEventConsumer(service3, q)
+	if err != nil {
+		return nil, err
+	}
 	userRegistrationEventConsumer, err := newUserEventConsumer(service3, q)
 	if err != nil {
 		return nil, err
 	}
 	module := &Module{
-		AdminHdl:      adminHandler,
-		Hdl:           handler,
-		orderConsumer: orderEventConsumer,
-		userConsumer:  userRegistrationEventConsumer,
+		AdminHdl:            adminHandler,
+		Hdl:                 handler,
+		orderConsumer:       orderEventConsumer,
+		orderRefundConsumer: orderRefundEventConsumer,
+		userConsumer:        userRegistrationEventConsumer,
 	}
 	return module, nil
 }
@@ -104,6 +113,14 @@ func newOrderEventConsumer(svc service.Service, q mq.MQ) (*consumer.OrderEventCo
 	return res, err
 }
 
+func newOrderRefundEventConsumer(svc service.Service, q mq.MQ) (*consumer.OrderRefundEventConsumer, error) {
+	res, err := consumer.NewOrderRefundEventConsumer(svc, q)
+	if err == nil {
+		res.Start(context.Background())
+	}
+	return res, err
+}
+
 func newUserEventConsumer(svc service.Service, q mq.MQ) (*consumer.UserRegistrationEventConsumer, error) {
 	res, err := consumer.NewUserRegistrationEventConsumer(svc, q)
 	if err == nil {
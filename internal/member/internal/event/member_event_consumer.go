@@ -68,7 +68,7 @@ func (c *MemberEventConsumer) Consume(ctx context.Context) error {
 		return fmt.Errorf("解析消息失败: %w", err)
 	}
 
-	err = c.svc.ActivateMembership(ctx, domain.Member{
+	member := domain.Member{
 		Uid: evt.Uid,
 		Records: []domain.MemberRecord{
 			{
@@ -79,7 +79,12 @@ func (c *MemberEventConsumer) Consume(ctx context.Context) error {
 				Desc:  evt.Action,
 			},
 		},
-	})
+	}
+	if evt.Refund {
+		err = c.svc.ShortenMembership(ctx, member)
+	} else {
+		err = c.svc.ActivateMembership(ctx, member)
+	}
 
 	if errors.Is(err, service.ErrDuplicatedMemberRecord) {
 		c.logger.Warn("重复消费",
@@ -90,7 +95,7 @@ func (c *MemberEventConsumer) Consume(ctx context.Context) error {
 		return nil
 	}
 	if err != nil {
-		c.logger.Error("开通/重新激活/续约会员失败", elog.Any("MemberEvent", evt))
+		c.logger.Error("开通/重新激活/续约/退款扣减会员失败", elog.Any("MemberEvent", evt))
 	}
 	return err
 }
@@ -257,6 +257,119 @@ func (s *ModuleTestSuite) TestConsumer_ConsumeMemberEvent() {
 			},
 			errRequireFunc: require.NoError,
 		},
+		{
+			name: "退款扣减会员成功_已有会员",
+			before: func(t *testing.T, producer mq.Producer, message *mq.Message) {
+				t.Helper()
+
+				err := s.svc.ActivateMembership(context.Background(), domain.Member{
+					Uid: 20004,
+					Records: []domain.MemberRecord{
+						{
+							Key:   "member-key-20004-1",
+							Days:  31,
+							Biz:   "user",
+							BizId: 20004,
+							Desc:  "首次注册",
+						},
+					},
+				})
+				require.NoError(t, err)
+				err = s.svc.ActivateMembership(context.Background(), domain.Member{
+					Uid: 20004,
+					Records: []domain.MemberRecord{
+						{
+							Key:   "member-key-20004-2",
+							Days:  365,
+							Biz:   "order",
+							BizId: 4,
+							Desc:  "购买年会员",
+						},
+					},
+				})
+				require.NoError(t, err)
+
+				_, err = producer.Produce(context.Background(), message)
+				require.NoError(t, err)
+
+				// 模拟重试
+				_, err = producer.Produce(context.Background(), message)
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T, uid int64) {
+				t.Helper()
+
+				info, err := s.svc.GetMembershipInfo(context.Background(), uid)
+				require.NoError(t, err)
+
+				nowDate := time.Now().UTC()
+				startAt := time.Date(nowDate.Year(), nowDate.Month(), nowDate.Day(), 23, 59, 59, 0, time.UTC).UnixMilli()
+				require.Len(t, info.Records, 3)
+				require.Equal(t, domain.MemberRecord{
+					Key:   "refund-member-key-20004-2",
+					Days:  365,
+					Biz:   "order",
+					BizId: 4,
+					Desc:  "退款年会员",
+				}, info.Records[0])
+				require.Equal(t, uint64(31), uint64(time.Duration(info.EndAt-startAt)*time.Millisecond/(time.Hour*24)))
+			},
+			evt: event.MemberEvent{
+				Key:    "refund-member-key-20004-2",
+				Uid:    20004,
+				Days:   365,
+				Biz:    "order",
+				BizId:  4,
+				Action: "退款年会员",
+				Refund: true,
+			},
+			errRequireFunc: require.NoError,
+		},
+		{
+			name: "退款扣减会员成功_扣减后会员直接到期",
+			before: func(t *testing.T, producer mq.Producer, message *mq.Message) {
+				t.Helper()
+
+				err := s.svc.ActivateMembership(context.Background(), domain.Member{
+					Uid: 20005,
+					Records: []domain.MemberRecord{
+						{
+							Key:   "member-key-20005-1",
+							Days:  31,
+							Biz:   "order",
+							BizId: 5,
+							Desc:  "购买月会员",
+						},
+					},
+				})
+				require.NoError(t, err)
+
+				_, err = producer.Produce(context.Background(), message)
+				require.NoError(t, err)
+
+				// 模拟重试
+				_, err = producer.Produce(context.Background(), message)
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T, uid int64) {
+				t.Helper()
+
+				info, err := s.svc.GetMembershipInfo(context.Background(), uid)
+				require.NoError(t, err)
+				require.True(t, info.EndAt <= time.Now().UnixMilli())
+				require.Len(t, info.Records, 2)
+			},
+			evt: event.MemberEvent{
+				Key:    "refund-member-key-20005-1",
+				Uid:    20005,
+				Days:   62,
+				Biz:    "order",
+				BizId:  5,
+				Action: "退款月会员",
+				Refund: true,
+			},
+			errRequireFunc: require.NoError,
+		},
 	}
 
 	consumer, err := event.NewMemberEventConsumer(s.svc, s.mq)
@@ -35,6 +35,7 @@ type MemberDAO interface {
 	FindMemberByUID(ctx context.Context, uid int64) (Member, error)
 	FindMemberRecordsByUID(ctx context.Context, uid int64) ([]MemberRecord, error)
 	Upsert(ctx context.Context, d Member, r MemberRecord) error
+	Shorten(ctx context.Context, d Member, r MemberRecord) error
 }
 
 type memberGROMDAO struct {
@@ -136,6 +137,50 @@ func (g *memberGROMDAO) upsert(tx *egorm.Component, d Member, r MemberRecord) er
 	return nil
 }
 
+// Shorten 扣减会员天数,扣减后早于当前时间的直接以当前时间结束
+func (g *memberGROMDAO) Shorten(ctx context.Context, d Member, r MemberRecord) error {
+	for {
+		err := g.db.WithContext(ctx).Transaction(func(tx *egorm.Component) error {
+			return g.shorten(tx, d, r)
+		})
+		if errors.Is(err, ErrUpdateMemberConflict) {
+			continue
+		}
+		return err
+	}
+}
+
+func (g *memberGROMDAO) shorten(tx *egorm.Component, d Member, r MemberRecord) error {
+	var member Member
+	if err := tx.First(&member, "uid = ?", d.Uid).Error; err != nil {
+		return err
+	}
+	now := time.Now().UTC().UnixMilli()
+	endAt := time.UnixMilli(member.EndAt).Add(-time.Hour * 24 * time.Duration(r.Days)).UnixMilli()
+	member.EndAt = max(endAt, now)
+	member.Version += 1
+	member.Utime = now
+	res := tx.Model(&Member{}).
+		Where("uid = ? AND version = ?", member.Uid, member.Version-1).Updates(&member)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		// case: version被其他并发事务更新 通知上层重试
+		return fmt.Errorf("%w", ErrUpdateMemberConflict)
+	}
+	// 创建会员记录,Key重复说明是重复消息,整个事务回滚
+	r.Uid = d.Uid
+	r.Ctime, r.Utime = now, now
+	if err := tx.Create(&r).Error; err != nil {
+		if g.isMySQLUniqueIndexError(err) {
+			return fmt.Errorf("%w", ErrDuplicatedMemberRecord)
+		}
+		return err
+	}
+	return nil
+}
+
 func (g *memberGROMDAO) endAt(startAt time.Time, days uint64) int64 {
 	return startAt.Add(time.Hour * 24 * time.Duration(days)).UnixMilli()
 }
@@ -29,6 +29,7 @@ var (
 type MemberRepository interface {
 	FindByUID(ctx context.Context, uid int64) (domain.Member, error)
 	Upsert(ctx context.Context, member domain.Member) error
+	Shorten(ctx context.Context, member domain.Member) error
 }
 
 func NewMemberRepository(d dao.MemberDAO) MemberRepository {
@@ -74,6 +75,11 @@ func (m *memberRepository) Upsert(ctx context.Context, member domain.Member) err
 	return m.dao.Upsert(ctx, d, r)
 }
 
+func (m *memberRepository) Shorten(ctx context.Context, member domain.Member) error {
+	d, r := m.toEntity(member)
+	return m.dao.Shorten(ctx, d, r)
+}
+
 func (m *memberRepository) toEntity(d domain.Member) (dao.Member, dao.MemberRecord) {
 	member := dao.Member{
 		Uid:   d.Uid,
@@ -29,6 +29,8 @@ var (
 type Service interface {
 	GetMembershipInfo(ctx context.Context, uid int64) (domain.Member, error)
 	ActivateMembership(ctx context.Context, member domain.Member) error
+	// ShortenMembership 扣减会员天数,比如会员商品退款
+	ShortenMembership(ctx context.Context, member domain.Member) error
 }
 
 type service struct {
@@ -46,3 +48,7 @@ func (s *service) GetMembershipInfo(ctx context.Context, uid int64) (domain.Memb
 func (s *service) ActivateMembership(ctx context.Context, member domain.Member) error {
 	return s.repo.Upsert(ctx, member)
 }
+
+func (s *service) ShortenMembership(ctx context.Context, member domain.Member) error {
+	return s.repo.Shorten(ctx, member)
+}
@@ -5,6 +5,7 @@
 //
 //	mockgen -source=./service.go -package=membermocks --destination=../../mocks/member.mock.go -typed Service
 //
+
 // Package membermocks is a generated GoMock package.
 package membermocks
 
@@ -20,6 +21,7 @@ import (
 type MockService struct {
 	ctrl     *gomock.Controller
 	recorder *MockServiceMockRecorder
+	isgomock struct{}
 }
 
 // MockServiceMockRecorder is the mock recorder for MockService.
@@ -48,31 +50,31 @@ func (m *MockService) ActivateMembership(ctx context.Context, member domain.Memb
 }
 
 // ActivateMembership indicates an expected call of ActivateMembership.
-func (mr *MockServiceMockRecorder) ActivateMembership(ctx, member any) *ServiceActivateMembershipCall {
+func (mr *MockServiceMockRecorder) ActivateMembership(ctx, member any) *MockServiceActivateMembershipCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateMembership", reflect.TypeOf((*MockService)(nil).ActivateMembership), ctx, member)
-	return &ServiceActivateMembershipCall{Call: call}
+	return &MockServiceActivateMembershipCall{Call: call}
 }
 
-// ServiceActivateMembershipCall wrap *gomock.Call
-type ServiceActivateMembershipCall struct {
+// MockServiceActivateMembershipCall wrap *gomock.Call
+type MockServiceActivateMembershipCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ServiceActivateMembershipCall) Return(arg0 error) *ServiceActivateMembershipCall {
+func (c *MockServiceActivateMembershipCall) Return(arg0 error) *MockServiceActivateMembershipCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ServiceActivateMembershipCall) Do(f func(context.Context, domain.Member) error) *ServiceActivateMembershipCall {
+func (c *MockServiceActivateMembershipCall) Do(f func(context.Context, domain.Member) error) *MockServiceActivateMembershipCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ServiceActivateMembershipCall) DoAndReturn(f func(context.Context, domain.Member) error) *ServiceActivateMembershipCall {
+func (c *MockServiceActivateMembershipCall) DoAndReturn(f func(context.Context, domain.Member) error) *MockServiceActivateMembershipCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -87,31 +89,69 @@ func (m *MockService) GetMembershipInfo(ctx context.Context, uid int64) (domain.
 }
 
 // GetMembershipInfo indicates an expected call of GetMembershipInfo.
-func (mr *MockServiceMockRecorder) GetMembershipInfo(ctx, uid any) *ServiceGetMembershipInfoCall {
+func (mr *MockServiceMockRecorder) GetMembershipInfo(ctx, uid any) *MockServiceGetMembershipInfoCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembershipInfo", reflect.TypeOf((*MockService)(nil).GetMembershipInfo), ctx, uid)
-	return &ServiceGetMembershipInfoCall{Call: call}
+	return &MockServiceGetMembershipInfoCall{Call: call}
 }
 
-// ServiceGetMembershipInfoCall wrap *gomock.Call
-type ServiceGetMembershipInfoCall struct {
+// MockServiceGetMembershipInfoCall wrap *gomock.Call
+type MockServiceGetMembershipInfoCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ServiceGetMembershipInfoCall) Return(arg0 domain.Member, arg1 error) *ServiceGetMembershipInfoCall {
+func (c *MockServiceGetMembershipInfoCall) Return(arg0 domain.Member, arg1 error) *MockServiceGetMembershipInfoCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ServiceGetMembershipInfoCall) Do(f func(context.Context, int64) (domain.Member, error)) *ServiceGetMembershipInfoCall {
+func (c *MockServiceGetMembershipInfoCall) Do(f func(context.Context, int64) (domain.Member, error)) *MockServiceGetMembershipInfoCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceGetMembershipInfoCall) DoAndReturn(f func(context.Context, int64) (domain.Member, error)) *MockServiceGetMembershipInfoCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ShortenMembership mocks base method.
+func (m *MockService) ShortenMembership(ctx context.Context, member domain.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShortenMembership", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ShortenMembership indicates an expected call of ShortenMembership.
+func (mr *MockServiceMockRecorder) ShortenMembership(ctx, member any) *MockServiceShortenMembershipCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShortenMembership", reflect.TypeOf((*MockService)(nil).ShortenMembership), ctx, member)
+	return &MockServiceShortenMembershipCall{Call: call}
+}
+
+// MockServiceShortenMembershipCall wrap *gomock.Call
+type MockServiceShortenMembershipCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceShortenMembershipCall) Return(arg0 error) *MockServiceShortenMembershipCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceShortenMembershipCall) Do(f func(context.Context, domain.Member) error) *MockServiceShortenMembershipCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ServiceGetMembershipInfoCall) DoAndReturn(f func(context.Context, int64) (domain.Member, error)) *ServiceGetMembershipInfoCall {
+func (c *MockServiceShortenMembershipCall) DoAndReturn(f func(context.Context, domain.Member) error) *MockServiceShortenMembershipCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -0,0 +1,39 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package event
+
+const (
+	NotificationEventName = "notification_events"
+)
+
+const (
+	NotificationTypeReply   = "reply"
+	NotificationTypeMention = "mention"
+)
+
+// NotificationEvent 站内信，例如评论被回复或者被 @ 了
+type NotificationEvent struct {
+	// 接收人
+	Receivers []int64 `json:"receivers"`
+	// 触发通知的人
+	Sender int64 `json:"sender"`
+	// reply-回复 mention-@
+	Type string `json:"type"`
+	// 评论针对的资源
+	Biz   string `json:"biz"`
+	BizID int64  `json:"bizId"`
+	// 评论的 ID
+	SourceID int64  `json:"sourceId"`
+	Content  string `json:"content"`
+}
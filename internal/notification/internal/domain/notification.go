@@ -0,0 +1,72 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package domain
+
+type Type string
+
+const (
+	TypeReply   Type = "reply"
+	TypeMention Type = "mention"
+)
+
+func (t Type) Valid() bool {
+	return t == TypeReply || t == TypeMention
+}
+
+type Notification struct {
+	ID int64
+	// 接收人
+	Uid  int64
+	Type Type
+	// 触发通知的人
+	Sender Sender
+	// 评论针对的资源
+	Biz   string
+	BizID int64
+	// 评论的 ID
+	SourceID int64
+	// 评论内容的摘要
+	Content string
+	Read    bool
+	Ctime   int64
+}
+
+type Sender struct {
+	ID       int64
+	Nickname string
+	Avatar   string
+}
+
+// Preference 通知设置，没有设置过的时候全部开启
+type Preference struct {
+	Uid     int64
+	Reply   bool
+	Mention bool
+}
+
+func DefaultPreference(uid int64) Preference {
+	return Preference{Uid: uid, Reply: true, Mention: true}
+}
+
+// Allow 用户是否愿意接收这种通知
+func (p Preference) Allow(typ Type) bool {
+	switch typ {
+	case TypeReply:
+		return p.Reply
+	case TypeMention:
+		return p.Mention
+	default:
+		return false
+	}
+}
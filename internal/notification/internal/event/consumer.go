@@ -0,0 +1,91 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/notification/event"
+	"github.com/ecodeclub/webook/internal/notification/internal/domain"
+	"github.com/ecodeclub/webook/internal/notification/internal/service"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// Consumer 把评论模块发过来的事件保存到接收人的收件箱
+type Consumer struct {
+	consumer mq.Consumer
+	svc      service.Service
+	logger   *elog.Component
+}
+
+func NewConsumer(q mq.MQ, svc service.Service) (*Consumer, error) {
+	groupID := "notification.inbox"
+	consumer, err := q.Consumer(event.NotificationEventName, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return &Consumer{
+		consumer: consumer,
+		svc:      svc,
+		logger:   elog.DefaultLogger.With(elog.FieldComponent("notification.inbox.consumer")),
+	}, nil
+}
+
+func (c *Consumer) Start(ctx context.Context) {
+	go func() {
+		for {
+			err := c.Consume(ctx)
+			if err != nil {
+				c.logger.Error("消费站内信事件失败", elog.FieldErr(err))
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
+
+func (c *Consumer) Consume(ctx context.Context) error {
+	msg, err := c.consumer.Consume(ctx)
+	if err != nil {
+		return fmt.Errorf("获取消息失败: %w", err)
+	}
+	var evt event.NotificationEvent
+	err = json.Unmarshal(msg.Value, &evt)
+	if err != nil {
+		return fmt.Errorf("解析消息失败: %w", err)
+	}
+	typ := domain.Type(evt.Type)
+	if !typ.Valid() {
+		return fmt.Errorf("未知的站内信类型 %q", evt.Type)
+	}
+	for _, uid := range evt.Receivers {
+		err = c.svc.Notify(ctx, domain.Notification{
+			Uid:      uid,
+			Type:     typ,
+			Sender:   domain.Sender{ID: evt.Sender},
+			Biz:      evt.Biz,
+			BizID:    evt.BizID,
+			SourceID: evt.SourceID,
+			Content:  evt.Content,
+		})
+		if err != nil {
+			return fmt.Errorf("保存站内信失败, uid %d: %w", uid, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/notification/event"
+	"github.com/ecodeclub/webook/internal/notification/internal/integration/startup"
+	"github.com/ecodeclub/webook/internal/notification/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/notification/internal/web"
+	"github.com/ecodeclub/webook/internal/test"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ecodeclub/webook/internal/user"
+	usermocks "github.com/ecodeclub/webook/internal/user/mocks"
+	"github.com/ego-component/egorm"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/server/egin"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	uid    = int64(123)
+	uid2   = int64(234)
+	sender = int64(345)
+)
+
+type HandlerTestSuite struct {
+	suite.Suite
+	server   *egin.Component
+	db       *egorm.Component
+	dao      dao.NotificationDAO
+	producer mq.Producer
+}
+
+func (s *HandlerTestSuite) SetupSuite() {
+	ctrl := gomock.NewController(s.T())
+	userSvc := usermocks.NewMockUserService(ctrl)
+	userSvc.EXPECT().BatchProfile(gomock.Any(), gomock.Any()).
+		Return([]user.User{{Id: sender, Nickname: "发送人", Avatar: "avatar"}}, nil).AnyTimes()
+	module, err := startup.InitModule(&user.Module{Svc: userSvc})
+	require.NoError(s.T(), err)
+
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	server := egin.Load("server").Build()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("_session", session.NewMemorySession(session.Claims{Uid: uid}))
+	})
+	module.Hdl.PrivateRoutes(server.Engine)
+	s.server = server
+	s.db = testioc.InitDB()
+	s.dao = dao.NewGORMNotificationDAO(s.db)
+	s.producer, err = testioc.InitMQ().Producer(event.NotificationEventName)
+	require.NoError(s.T(), err)
+}
+
+func (s *HandlerTestSuite) TearDownTest() {
+	s.NoError(s.db.Exec("TRUNCATE TABLE `notifications`").Error)
+	s.NoError(s.db.Exec("TRUNCATE TABLE `notification_preferences`").Error)
+}
+
+func (s *HandlerTestSuite) TestConsume() {
+	// uid2 关闭了回复的通知
+	err := s.dao.SavePreference(context.Background(), dao.Preference{Uid: uid2, Reply: false, Mention: true})
+	s.NoError(err)
+
+	evt := event.NotificationEvent{
+		Receivers: []int64{uid, uid2, sender},
+		Sender:    sender,
+		Type:      event.NotificationTypeReply,
+		Biz:       "case",
+		BizID:     1,
+		SourceID:  10,
+		Content:   "回复的内容",
+	}
+	// 重复投递也只保存一次
+	s.produce(evt)
+	s.produce(evt)
+	evt.Type = event.NotificationTypeMention
+	evt.Receivers = []int64{uid2}
+	s.produce(evt)
+
+	s.Eventually(func() bool {
+		cnt, err := s.dao.CountByUid(context.Background(), uid2)
+		return err == nil && cnt == 1
+	}, time.Second*10, time.Millisecond*100)
+
+	ns, err := s.dao.FindByUid(context.Background(), uid, 0, 10)
+	s.NoError(err)
+	s.Len(ns, 1)
+	s.Equal(event.NotificationTypeReply, ns[0].Type)
+	ns, err = s.dao.FindByUid(context.Background(), uid2, 0, 10)
+	s.NoError(err)
+	s.Len(ns, 1)
+	s.Equal(event.NotificationTypeMention, ns[0].Type)
+	// 自己触发的不通知
+	cnt, err := s.dao.CountByUid(context.Background(), sender)
+	s.NoError(err)
+	s.Equal(int64(0), cnt)
+}
+
+func (s *HandlerTestSuite) produce(evt event.NotificationEvent) {
+	val, err := json.Marshal(evt)
+	s.NoError(err)
+	_, err = s.producer.Produce(context.Background(), &mq.Message{Value: val})
+	s.NoError(err)
+}
+
+func (s *HandlerTestSuite) TestInbox() {
+	for i := int64(1); i <= 3; i++ {
+		err := s.dao.Create(context.Background(), dao.Notification{
+			Uid:      uid,
+			SourceId: i,
+			Type:     event.NotificationTypeReply,
+			Sender:   sender,
+			Biz:      "case",
+			BizId:    1,
+			Content:  "回复的内容",
+		})
+		s.NoError(err)
+	}
+	// 别人的通知
+	err := s.dao.Create(context.Background(), dao.Notification{
+		Uid: uid2, SourceId: 1, Type: event.NotificationTypeReply, Sender: sender,
+	})
+	s.NoError(err)
+
+	list := post[web.NotificationList](s, "/notification/list", web.ListReq{Offset: 0, Limit: 2}).Data
+	s.Equal(int64(3), list.Total)
+	s.Len(list.List, 2)
+	s.Equal(int64(3), list.List[0].SourceID)
+	s.Equal(web.Sender{ID: sender, Nickname: "发送人", Avatar: "avatar"}, list.List[0].Sender)
+	s.False(list.List[0].Read)
+	s.Equal(int64(3), post[int64](s, "/notification/unread/count", nil).Data)
+
+	// 不能修改别人的通知
+	ns, err := s.dao.FindByUid(context.Background(), uid2, 0, 1)
+	s.NoError(err)
+	post[any](s, "/notification/read", web.ReadReq{IDs: []int64{list.List[0].ID, ns[0].Id}})
+	s.Equal(int64(2), post[int64](s, "/notification/unread/count", nil).Data)
+	cnt, err := s.dao.CountUnread(context.Background(), uid2)
+	s.NoError(err)
+	s.Equal(int64(1), cnt)
+	list = post[web.NotificationList](s, "/notification/list", web.ListReq{Offset: 0, Limit: 1}).Data
+	s.True(list.List[0].Read)
+
+	post[any](s, "/notification/read/all", nil)
+	s.Equal(int64(0), post[int64](s, "/notification/unread/count", nil).Data)
+}
+
+func (s *HandlerTestSuite) TestPreference() {
+	// 默认全部开启
+	s.Equal(web.Preference{Reply: true, Mention: true},
+		post[web.Preference](s, "/notification/preference", nil).Data)
+	post[any](s, "/notification/preference/save", web.Preference{Reply: false, Mention: true})
+	s.Equal(web.Preference{Reply: false, Mention: true},
+		post[web.Preference](s, "/notification/preference", nil).Data)
+	post[any](s, "/notification/preference/save", web.Preference{Reply: true, Mention: false})
+	s.Equal(web.Preference{Reply: true, Mention: false},
+		post[web.Preference](s, "/notification/preference", nil).Data)
+}
+
+func post[T any](s *HandlerTestSuite, path string, req any) test.Result[T] {
+	httpReq, err := http.NewRequest(http.MethodPost, path, iox.NewJSONReader(req))
+	s.NoError(err)
+	httpReq.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[T]()
+	s.server.ServeHTTP(recorder, httpReq)
+	s.Equal(http.StatusOK, recorder.Code)
+	res := recorder.MustScan()
+	s.Equal(0, res.Code)
+	return res
+}
+
+func TestHandler(t *testing.T) {
+	suite.Run(t, new(HandlerTestSuite))
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrRecordNotFound = gorm.ErrRecordNotFound
+
+type NotificationDAO interface {
+	// Create 同一条评论给同一个人的同一种通知只会保存一次，消息重复投递也没关系
+	Create(ctx context.Context, n Notification) error
+	// FindByUid 按照 ID 倒序排序，新的在前面
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]Notification, error)
+	CountByUid(ctx context.Context, uid int64) (int64, error)
+	CountUnread(ctx context.Context, uid int64) (int64, error)
+	// MarkRead 只会修改属于 uid 的通知，ids 为空的时候全部标记为已读
+	MarkRead(ctx context.Context, uid int64, ids []int64) error
+	// DeleteByUid 删除用户收到的以及由用户触发的通知
+	DeleteByUid(ctx context.Context, uid int64) error
+
+	FindPreference(ctx context.Context, uid int64) (Preference, error)
+	SavePreference(ctx context.Context, p Preference) error
+}
+
+type GORMNotificationDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMNotificationDAO(db *egorm.Component) NotificationDAO {
+	return &GORMNotificationDAO{db: db}
+}
+
+func (dao *GORMNotificationDAO) Create(ctx context.Context, n Notification) error {
+	now := time.Now().UnixMilli()
+	n.Ctime = now
+	n.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&n).Error
+}
+
+func (dao *GORMNotificationDAO) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]Notification, error) {
+	var res []Notification
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMNotificationDAO) CountByUid(ctx context.Context, uid int64) (int64, error) {
+	var res int64
+	err := dao.db.WithContext(ctx).Model(&Notification{}).
+		Where("uid = ?", uid).Count(&res).Error
+	return res, err
+}
+
+func (dao *GORMNotificationDAO) CountUnread(ctx context.Context, uid int64) (int64, error) {
+	var res int64
+	err := dao.db.WithContext(ctx).Model(&Notification{}).
+		Where("uid = ? AND read_at = 0", uid).Count(&res).Error
+	return res, err
+}
+
+func (dao *GORMNotificationDAO) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	now := time.Now().UnixMilli()
+	query := dao.db.WithContext(ctx).Model(&Notification{}).
+		Where("uid = ? AND read_at = 0", uid)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query.Updates(map[string]any{
+		"read_at": now,
+		"utime":   now,
+	}).Error
+}
+
+func (dao *GORMNotificationDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("uid = ? OR sender = ?", uid, uid).Delete(&Notification{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", uid).Delete(&Preference{}).Error
+	})
+}
+
+func (dao *GORMNotificationDAO) FindPreference(ctx context.Context, uid int64) (Preference, error) {
+	var res Preference
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMNotificationDAO) SavePreference(ctx context.Context, p Preference) error {
+	now := time.Now().UnixMilli()
+	p.Ctime = now
+	p.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"reply", "mention", "utime"}),
+	}).Create(&p).Error
+}
+
+// Notification 站内信
+type Notification struct {
+	Id       int64  `gorm:"primaryKey;autoIncrement"`
+	Uid      int64  `gorm:"uniqueIndex:uniq_uid_source_type;index:idx_uid_read_at;comment:接收人"`
+	SourceId int64  `gorm:"uniqueIndex:uniq_uid_source_type;comment:评论的ID"`
+	Type     string `gorm:"type:varchar(32);uniqueIndex:uniq_uid_source_type;comment:reply-回复 mention-@"`
+	Sender   int64  `gorm:"index;comment:触发通知的人"`
+	Biz      string `gorm:"type:varchar(256);comment:评论针对的资源"`
+	BizId    int64
+	Content  string `gorm:"type:varchar(1024);comment:评论内容的摘要"`
+	ReadAt   int64  `gorm:"not null;default:0;index:idx_uid_read_at;comment:已读的时间，0 表示未读"`
+	Ctime    int64
+	Utime    int64
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// Preference 用户的通知设置
+type Preference struct {
+	Id      int64 `gorm:"primaryKey;autoIncrement"`
+	Uid     int64 `gorm:"uniqueIndex"`
+	Reply   bool  `gorm:"comment:是否接收回复的通知"`
+	Mention bool  `gorm:"comment:是否接收被 @ 的通知"`
+	Ctime   int64
+	Utime   int64
+}
+
+func (Preference) TableName() string {
+	return "notification_preferences"
+}
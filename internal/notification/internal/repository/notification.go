@@ -0,0 +1,117 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/notification/internal/domain"
+	"github.com/ecodeclub/webook/internal/notification/internal/repository/dao"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, n domain.Notification) error
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.Notification, error)
+	CountByUid(ctx context.Context, uid int64) (int64, error)
+	CountUnread(ctx context.Context, uid int64) (int64, error)
+	MarkRead(ctx context.Context, uid int64, ids []int64) error
+	DeleteByUid(ctx context.Context, uid int64) error
+
+	// FindPreference 没有设置过的时候返回默认设置
+	FindPreference(ctx context.Context, uid int64) (domain.Preference, error)
+	SavePreference(ctx context.Context, p domain.Preference) error
+}
+
+type notificationRepository struct {
+	dao dao.NotificationDAO
+}
+
+func NewNotificationRepository(d dao.NotificationDAO) NotificationRepository {
+	return &notificationRepository{dao: d}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, n domain.Notification) error {
+	return r.dao.Create(ctx, dao.Notification{
+		Uid:      n.Uid,
+		SourceId: n.SourceID,
+		Type:     string(n.Type),
+		Sender:   n.Sender.ID,
+		Biz:      n.Biz,
+		BizId:    n.BizID,
+		Content:  n.Content,
+	})
+}
+
+func (r *notificationRepository) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.Notification, error) {
+	res, err := r.dao.FindByUid(ctx, uid, offset, limit)
+	return slice.Map(res, func(_ int, src dao.Notification) domain.Notification {
+		return r.toDomain(src)
+	}), err
+}
+
+func (r *notificationRepository) CountByUid(ctx context.Context, uid int64) (int64, error) {
+	return r.dao.CountByUid(ctx, uid)
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, uid int64) (int64, error) {
+	return r.dao.CountUnread(ctx, uid)
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	return r.dao.MarkRead(ctx, uid, ids)
+}
+
+func (r *notificationRepository) DeleteByUid(ctx context.Context, uid int64) error {
+	return r.dao.DeleteByUid(ctx, uid)
+}
+
+func (r *notificationRepository) FindPreference(ctx context.Context, uid int64) (domain.Preference, error) {
+	p, err := r.dao.FindPreference(ctx, uid)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return domain.DefaultPreference(uid), nil
+	}
+	if err != nil {
+		return domain.Preference{}, err
+	}
+	return domain.Preference{
+		Uid:     p.Uid,
+		Reply:   p.Reply,
+		Mention: p.Mention,
+	}, nil
+}
+
+func (r *notificationRepository) SavePreference(ctx context.Context, p domain.Preference) error {
+	return r.dao.SavePreference(ctx, dao.Preference{
+		Uid:     p.Uid,
+		Reply:   p.Reply,
+		Mention: p.Mention,
+	})
+}
+
+func (r *notificationRepository) toDomain(n dao.Notification) domain.Notification {
+	return domain.Notification{
+		ID:       n.Id,
+		Uid:      n.Uid,
+		Type:     domain.Type(n.Type),
+		Sender:   domain.Sender{ID: n.Sender},
+		Biz:      n.Biz,
+		BizID:    n.BizId,
+		SourceID: n.SourceId,
+		Content:  n.Content,
+		Read:     n.ReadAt > 0,
+		Ctime:    n.Ctime,
+	}
+}
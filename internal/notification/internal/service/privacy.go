@@ -0,0 +1,74 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/notification/internal/domain"
+	"github.com/ecodeclub/webook/internal/notification/internal/repository"
+)
+
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 同时删除这个用户触发的、在别人收件箱里面的通知
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	repo      repository.NotificationRepository
+	batchSize int
+}
+
+func NewPrivacyService(repo repository.NotificationRepository) PrivacyService {
+	return &privacyService{
+		repo:      repo,
+		batchSize: 200,
+	}
+}
+
+func (s *privacyService) Name() string {
+	return "notification"
+}
+
+type notificationData struct {
+	Notifications []domain.Notification `json:"notifications"`
+	Preference    domain.Preference     `json:"preference"`
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	p, err := s.repo.FindPreference(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	res := notificationData{
+		Notifications: make([]domain.Notification, 0, s.batchSize),
+		Preference:    p,
+	}
+	for offset := 0; ; offset += s.batchSize {
+		ns, err := s.repo.FindByUid(ctx, uid, offset, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		res.Notifications = append(res.Notifications, ns...)
+		if len(ns) < s.batchSize {
+			return res, nil
+		}
+	}
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	return s.repo.DeleteByUid(ctx, uid)
+}
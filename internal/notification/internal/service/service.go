@@ -0,0 +1,126 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/notification/internal/domain"
+	"github.com/ecodeclub/webook/internal/notification/internal/repository"
+	"github.com/ecodeclub/webook/internal/user"
+	"golang.org/x/sync/errgroup"
+)
+
+// contentLimit 通知里面只保存评论内容的摘要
+const contentLimit = 100
+
+type Service interface {
+	// Notify 按照接收人的通知设置决定是否保存，自己触发的通知直接忽略
+	Notify(ctx context.Context, n domain.Notification) error
+	// List 按照时间倒序排序，返回通知以及总数
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.Notification, int64, error)
+	UnreadCount(ctx context.Context, uid int64) (int64, error)
+	// MarkRead ids 为空的时候全部标记为已读
+	MarkRead(ctx context.Context, uid int64, ids []int64) error
+	Preference(ctx context.Context, uid int64) (domain.Preference, error)
+	SavePreference(ctx context.Context, p domain.Preference) error
+}
+
+type service struct {
+	repo    repository.NotificationRepository
+	userSvc user.UserService
+}
+
+func NewService(repo repository.NotificationRepository, userSvc user.UserService) Service {
+	return &service{repo: repo, userSvc: userSvc}
+}
+
+func (s *service) Notify(ctx context.Context, n domain.Notification) error {
+	if n.Uid == n.Sender.ID {
+		return nil
+	}
+	p, err := s.repo.FindPreference(ctx, n.Uid)
+	if err != nil {
+		return err
+	}
+	if !p.Allow(n.Type) {
+		return nil
+	}
+	if runes := []rune(n.Content); len(runes) > contentLimit {
+		n.Content = string(runes[:contentLimit]) + "..."
+	}
+	return s.repo.Create(ctx, n)
+}
+
+func (s *service) List(ctx context.Context, uid int64, offset, limit int) ([]domain.Notification, int64, error) {
+	var (
+		eg    errgroup.Group
+		res   []domain.Notification
+		total int64
+	)
+	eg.Go(func() error {
+		var err error
+		res, err = s.repo.FindByUid(ctx, uid, offset, limit)
+		if err != nil {
+			return err
+		}
+		return s.setSenders(ctx, res)
+	})
+	eg.Go(func() error {
+		var err error
+		total, err = s.repo.CountByUid(ctx, uid)
+		return err
+	})
+	return res, total, eg.Wait()
+}
+
+func (s *service) setSenders(ctx context.Context, ns []domain.Notification) error {
+	if len(ns) == 0 {
+		return nil
+	}
+	uids := slice.Map(ns, func(_ int, src domain.Notification) int64 {
+		return src.Sender.ID
+	})
+	users, err := s.userSvc.BatchProfile(ctx, uids)
+	if err != nil {
+		return err
+	}
+	userMap := make(map[int64]user.User, len(users))
+	for _, u := range users {
+		userMap[u.Id] = u
+	}
+	for i := range ns {
+		u := userMap[ns[i].Sender.ID]
+		ns[i].Sender.Nickname = u.Nickname
+		ns[i].Sender.Avatar = u.Avatar
+	}
+	return nil
+}
+
+func (s *service) UnreadCount(ctx context.Context, uid int64) (int64, error) {
+	return s.repo.CountUnread(ctx, uid)
+}
+
+func (s *service) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	return s.repo.MarkRead(ctx, uid, ids)
+}
+
+func (s *service) Preference(ctx context.Context, uid int64) (domain.Preference, error) {
+	return s.repo.FindPreference(ctx, uid)
+}
+
+func (s *service) SavePreference(ctx context.Context, p domain.Preference) error {
+	return s.repo.SavePreference(ctx, p)
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/notification/internal/domain"
+	"github.com/ecodeclub/webook/internal/notification/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc service.Service
+}
+
+func NewHandler(svc service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) PublicRoutes(server *gin.Engine) {}
+
+func (h *Handler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/notification")
+	g.POST("/list", ginx.BS[ListReq](h.List))
+	g.POST("/unread/count", ginx.S(h.UnreadCount))
+	g.POST("/read", ginx.BS[ReadReq](h.Read))
+	g.POST("/read/all", ginx.S(h.ReadAll))
+	g.POST("/preference", ginx.S(h.Preference))
+	g.POST("/preference/save", ginx.BS[Preference](h.SavePreference))
+}
+
+func (h *Handler) List(ctx *ginx.Context, req ListReq, sess session.Session) (ginx.Result, error) {
+	ns, total, err := h.svc.List(ctx, sess.Claims().Uid, req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: NotificationList{
+			List: slice.Map(ns, func(_ int, src domain.Notification) Notification {
+				return newNotification(src)
+			}),
+			Total: total,
+		},
+	}, nil
+}
+
+func (h *Handler) UnreadCount(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	cnt, err := h.svc.UnreadCount(ctx, sess.Claims().Uid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: cnt}, nil
+}
+
+func (h *Handler) Read(ctx *ginx.Context, req ReadReq, sess session.Session) (ginx.Result, error) {
+	// 不传 ID 的时候不做任何事情，全部已读要走 /read/all
+	if len(req.IDs) == 0 {
+		return ginx.Result{}, nil
+	}
+	err := h.svc.MarkRead(ctx, sess.Claims().Uid, req.IDs)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+func (h *Handler) ReadAll(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	err := h.svc.MarkRead(ctx, sess.Claims().Uid, nil)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+func (h *Handler) Preference(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	p, err := h.svc.Preference(ctx, sess.Claims().Uid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: Preference{Reply: p.Reply, Mention: p.Mention}}, nil
+}
+
+func (h *Handler) SavePreference(ctx *ginx.Context, req Preference, sess session.Session) (ginx.Result, error) {
+	err := h.svc.SavePreference(ctx, domain.Preference{
+		Uid:     sess.Claims().Uid,
+		Reply:   req.Reply,
+		Mention: req.Mention,
+	})
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+func newNotification(n domain.Notification) Notification {
+	return Notification{
+		ID:   n.ID,
+		Type: string(n.Type),
+		Sender: Sender{
+			ID:       n.Sender.ID,
+			Nickname: n.Sender.Nickname,
+			Avatar:   n.Sender.Avatar,
+		},
+		Biz:      n.Biz,
+		BizID:    n.BizID,
+		SourceID: n.SourceID,
+		Content:  n.Content,
+		Read:     n.Read,
+		Ctime:    n.Ctime,
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package web
+
+type ListReq struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type Sender struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+type Notification struct {
+	ID int64 `json:"id"`
+	// reply-回复 mention-@
+	Type   string `json:"type"`
+	Sender Sender `json:"sender"`
+	// 评论针对的资源
+	Biz   string `json:"biz"`
+	BizID int64  `json:"bizId"`
+	// 评论的 ID
+	SourceID int64  `json:"sourceId"`
+	Content  string `json:"content"`
+	Read     bool   `json:"read"`
+	Ctime    int64  `json:"ctime"`
+}
+
+type NotificationList struct {
+	List  []Notification `json:"list"`
+	Total int64          `json:"total"`
+}
+
+type ReadReq struct {
+	IDs []int64 `json:"ids"`
+}
+
+type Preference struct {
+	// 是否接收回复的通知
+	Reply bool `json:"reply"`
+	// 是否接收被 @ 的通知
+	Mention bool `json:"mention"`
+}
@@ -30,6 +30,8 @@ const (
 	StatusFailed        OrderStatus = 4
 	StatusCanceled      OrderStatus = 5
 	StatusTimeoutClosed OrderStatus = 6
+	// StatusRefunded 已退款,退款审核通过后由“支付成功”转入
+	StatusRefunded OrderStatus = 7
 )
 
 type Order struct {
@@ -38,11 +40,15 @@ type Order struct {
 	BuyerID          int64
 	Payment          Payment
 	OriginalTotalAmt int64
+	CouponID         int64 // 使用的优惠券, 0 表示没有使用
+	DiscountAmt      int64 // 优惠券优惠的金额, RealTotalAmt = OriginalTotalAmt - DiscountAmt
 	RealTotalAmt     int64
 	Status           OrderStatus
-	Items            []OrderItem
-	Ctime            int64
-	Utime            int64
+	// AutoRenew 自动续费订单, 由 subscription 模块按照扣款结果完成或者关闭, 不参与超时关闭
+	AutoRenew bool
+	Items     []OrderItem
+	Ctime     int64
+	Utime     int64
 }
 
 type Payment struct {
@@ -53,6 +59,8 @@ type Payment struct {
 type OrderItem struct {
 	SPU SPU
 	SKU SKU
+	// DiscountAmt 这一项分摊到的优惠金额, 也就是 SKU.RealPrice * SKU.Quantity 还要减去的部分
+	DiscountAmt int64
 }
 
 type SPU struct {
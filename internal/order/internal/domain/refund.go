@@ -0,0 +1,48 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type RefundStatus uint8
+
+func (s RefundStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	// RefundStatusPending 待审核
+	RefundStatusPending RefundStatus = 1
+	// RefundStatusRejected 已驳回,用户可以重新申请
+	RefundStatusRejected RefundStatus = 2
+	// RefundStatusSucceeded 已退款
+	RefundStatusSucceeded RefundStatus = 3
+	// RefundStatusRefunding 退款中,审核通过后占用申请再调用支付渠道退款,此时不能再驳回
+	RefundStatusRefunding RefundStatus = 4
+)
+
+// Refund 退款申请,目前只支持整单全额退款
+type Refund struct {
+	ID           int64
+	OrderID      int64
+	OrderSN      string
+	BuyerID      int64
+	Amount       int64
+	Reason       string
+	RejectReason string
+	Status       RefundStatus
+	// EventSent 退款事件是否已发送,未发送的由定时任务补发
+	EventSent bool
+	Ctime     int64
+	Utime     int64
+}
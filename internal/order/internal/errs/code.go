@@ -16,6 +16,12 @@ package errs
 
 var (
 	SystemError = ErrorCode{Code: 506001, Msg: "系统错误"}
+
+	OrderNotRefundable   = ErrorCode{Code: 406001, Msg: "订单不满足退款条件"}
+	RefundDuplicated     = ErrorCode{Code: 406002, Msg: "退款申请已存在"}
+	RefundNotFound       = ErrorCode{Code: 406003, Msg: "退款申请不存在"}
+	RefundStatusConflict = ErrorCode{Code: 406004, Msg: "退款申请已被处理"}
+	CouponUnavailable    = ErrorCode{Code: 406005, Msg: "优惠券不可用"}
 )
 
 type ErrorCode struct {
@@ -15,8 +15,9 @@
 package event
 
 const (
-	paymentEventName = "payment_events"
-	orderEventName   = "order_events"
+	paymentEventName     = "payment_events"
+	orderEventName       = "order_events"
+	orderRefundEventName = "order_refund_events"
 )
 
 type PaymentEvent struct {
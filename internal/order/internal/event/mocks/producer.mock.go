@@ -3,8 +3,9 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=./producer.go -package=evtmocks -destination=./mocks/producer.mock.go -typed OrderEventProducer
+//	mockgen -source=./producer.go -package=evtmocks -destination=./mocks/producer.mock.go -typed OrderEventProducer,OrderRefundEventProducer
 //
+
 // Package evtmocks is a generated GoMock package.
 package evtmocks
 
@@ -20,6 +21,7 @@ import (
 type MockOrderEventProducer struct {
 	ctrl     *gomock.Controller
 	recorder *MockOrderEventProducerMockRecorder
+	isgomock struct{}
 }
 
 // MockOrderEventProducerMockRecorder is the mock recorder for MockOrderEventProducer.
@@ -48,31 +50,93 @@ func (m *MockOrderEventProducer) Produce(ctx context.Context, evt event.OrderEve
 }
 
 // Produce indicates an expected call of Produce.
-func (mr *MockOrderEventProducerMockRecorder) Produce(ctx, evt any) *OrderEventProducerProduceCall {
+func (mr *MockOrderEventProducerMockRecorder) Produce(ctx, evt any) *MockOrderEventProducerProduceCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockOrderEventProducer)(nil).Produce), ctx, evt)
-	return &OrderEventProducerProduceCall{Call: call}
+	return &MockOrderEventProducerProduceCall{Call: call}
+}
+
+// MockOrderEventProducerProduceCall wrap *gomock.Call
+type MockOrderEventProducerProduceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockOrderEventProducerProduceCall) Return(arg0 error) *MockOrderEventProducerProduceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockOrderEventProducerProduceCall) Do(f func(context.Context, event.OrderEvent) error) *MockOrderEventProducerProduceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockOrderEventProducerProduceCall) DoAndReturn(f func(context.Context, event.OrderEvent) error) *MockOrderEventProducerProduceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockOrderRefundEventProducer is a mock of OrderRefundEventProducer interface.
+type MockOrderRefundEventProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderRefundEventProducerMockRecorder
+	isgomock struct{}
+}
+
+// MockOrderRefundEventProducerMockRecorder is the mock recorder for MockOrderRefundEventProducer.
+type MockOrderRefundEventProducerMockRecorder struct {
+	mock *MockOrderRefundEventProducer
+}
+
+// NewMockOrderRefundEventProducer creates a new mock instance.
+func NewMockOrderRefundEventProducer(ctrl *gomock.Controller) *MockOrderRefundEventProducer {
+	mock := &MockOrderRefundEventProducer{ctrl: ctrl}
+	mock.recorder = &MockOrderRefundEventProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderRefundEventProducer) EXPECT() *MockOrderRefundEventProducerMockRecorder {
+	return m.recorder
+}
+
+// Produce mocks base method.
+func (m *MockOrderRefundEventProducer) Produce(ctx context.Context, evt event.OrderEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Produce", ctx, evt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Produce indicates an expected call of Produce.
+func (mr *MockOrderRefundEventProducerMockRecorder) Produce(ctx, evt any) *MockOrderRefundEventProducerProduceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockOrderRefundEventProducer)(nil).Produce), ctx, evt)
+	return &MockOrderRefundEventProducerProduceCall{Call: call}
 }
 
-// OrderEventProducerProduceCall wrap *gomock.Call
-type OrderEventProducerProduceCall struct {
+// MockOrderRefundEventProducerProduceCall wrap *gomock.Call
+type MockOrderRefundEventProducerProduceCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *OrderEventProducerProduceCall) Return(arg0 error) *OrderEventProducerProduceCall {
+func (c *MockOrderRefundEventProducerProduceCall) Return(arg0 error) *MockOrderRefundEventProducerProduceCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *OrderEventProducerProduceCall) Do(f func(context.Context, event.OrderEvent) error) *OrderEventProducerProduceCall {
+func (c *MockOrderRefundEventProducerProduceCall) Do(f func(context.Context, event.OrderEvent) error) *MockOrderRefundEventProducerProduceCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *OrderEventProducerProduceCall) DoAndReturn(f func(context.Context, event.OrderEvent) error) *OrderEventProducerProduceCall {
+func (c *MockOrderRefundEventProducerProduceCall) DoAndReturn(f func(context.Context, event.OrderEvent) error) *MockOrderRefundEventProducerProduceCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
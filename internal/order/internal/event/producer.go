@@ -17,11 +17,13 @@ package event
 import (
 	"context"
 
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ecodeclub/webook/internal/pkg/mqx"
 )
 
-//go:generate mockgen -source=./producer.go -package=evtmocks -destination=./mocks/producer.mock.go -typed OrderEventProducer
+//go:generate mockgen -source=./producer.go -package=evtmocks -destination=./mocks/producer.mock.go -typed OrderEventProducer,OrderRefundEventProducer
 type OrderEventProducer interface {
 	Produce(ctx context.Context, evt OrderEvent) error
 }
@@ -29,3 +31,27 @@ type OrderEventProducer interface {
 func NewOrderEventProducer(q mq.MQ) (OrderEventProducer, error) {
 	return mqx.NewGeneralProducer[OrderEvent](q, orderEventName)
 }
+
+// OrderRefundEventProducer 订单退款完成后发送, 消息体与 OrderEvent 一致
+type OrderRefundEventProducer interface {
+	Produce(ctx context.Context, evt OrderEvent) error
+}
+
+func NewOrderRefundEventProducer(q mq.MQ) (OrderRefundEventProducer, error) {
+	return mqx.NewGeneralProducer[OrderEvent](q, orderRefundEventName)
+}
+
+// NewOrderRefundEvent 根据已退款的订单构造退款事件
+func NewOrderRefundEvent(order domain.Order) OrderEvent {
+	return OrderEvent{
+		OrderSN: order.SN,
+		BuyerID: order.BuyerID,
+		SPUs: slice.Map(order.Items, func(idx int, src domain.OrderItem) SPU {
+			return SPU{
+				ID:        src.SPU.ID,
+				Category0: src.SPU.Category0,
+				Category1: src.SPU.Category1,
+			}
+		}),
+	}
+}
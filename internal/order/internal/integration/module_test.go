@@ -19,15 +19,18 @@ package integration
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/ekit/iox"
 	"github.com/ecodeclub/ekit/sqlx"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	creditmocks "github.com/ecodeclub/webook/internal/credit/mocks"
 	"github.com/ecodeclub/webook/internal/order"
@@ -54,6 +57,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 const (
@@ -78,7 +82,7 @@ func (s *OrderModuleTestSuite) SetupSuite() {
 	err := dao.InitTables(s.db)
 	require.NoError(s.T(), err)
 	s.dao = dao.NewOrderGORMDAO(s.db)
-	s.svc = order.InitService(s.db)
+	s.svc = order.InitService(s.db, coupon.InitService(s.db))
 	s.cache = testioc.InitCache()
 }
 
@@ -87,6 +91,8 @@ func (s *OrderModuleTestSuite) TearDownSuite() {
 	require.NoError(s.T(), err)
 	err = s.db.Exec("DROP TABLE `order_items`").Error
 	require.NoError(s.T(), err)
+	err = s.db.Exec("DROP TABLE `order_refunds`").Error
+	require.NoError(s.T(), err)
 }
 
 func (s *OrderModuleTestSuite) TearDownTest() {
@@ -94,6 +100,8 @@ func (s *OrderModuleTestSuite) TearDownTest() {
 	require.NoError(s.T(), err)
 	err = s.db.Exec("TRUNCATE TABLE `order_items`").Error
 	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE TABLE `order_refunds`").Error
+	require.NoError(s.T(), err)
 }
 
 func (s *OrderModuleTestSuite) newGinServer(handler *web.Handler) *egin.Component {
@@ -143,7 +151,7 @@ func (s *OrderModuleTestSuite) TestAdminHandler_List() {
 			name: "获取成功",
 			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
 				t.Helper()
-				return web.NewAdminHandler(s.svc)
+				return web.NewAdminHandler(s.svc, nil, nil)
 			},
 			req: web.ListOrdersReq{
 				Limit:  2,
@@ -2773,6 +2781,39 @@ func (s *OrderModuleTestSuite) TestJob_CloseTimeoutOrders() {
 				}
 			},
 		},
+		{
+			name: "不关闭自动续费订单",
+			before: func(t *testing.T) {
+				t.Helper()
+				id := int64(400)
+				orderEntity := dao.Order{
+					Id:               id,
+					SN:               fmt.Sprintf("OrderSN-close-%d", id),
+					BuyerId:          id,
+					OriginalTotalAmt: 100,
+					RealTotalAmt:     100,
+					AutoRenew:        true,
+				}
+				items := []dao.OrderItem{
+					s.newOrderItemDAO(0, 1),
+				}
+				_, err := s.dao.CreateOrder(context.Background(), orderEntity, items)
+				require.NoError(s.T(), err)
+			},
+			getJobFunc: func(t *testing.T) *job.CloseTimeoutOrdersJob {
+				t.Helper()
+				return job.NewCloseTimeoutOrdersJob(s.svc, 0, 0, 10)
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				// 扣款结果还没有回来, 保持未支付等待续费模块处理
+				orderEntity, err := s.dao.FindOrderByUIDAndSNAndStatus(context.Background(), 400, "OrderSN-close-400",
+					domain.StatusInit.ToUint8())
+				require.NoError(t, err)
+				assert.Equal(t, domain.StatusInit.ToUint8(), orderEntity.Status)
+				assert.True(t, orderEntity.AutoRenew)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2786,3 +2827,586 @@ func (s *OrderModuleTestSuite) TestJob_CloseTimeoutOrders() {
 		})
 	}
 }
+
+func (s *OrderModuleTestSuite) TestHandler_ApplyRefund() {
+	t := s.T()
+	testCases := []struct {
+		name string
+
+		before   func(t *testing.T)
+		after    func(t *testing.T)
+		req      web.ApplyRefundReq
+		wantCode int
+		wantResp test.Result[web.Refund]
+	}{
+		{
+			name: "申请退款成功",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createRefundableOrder(t, 61, domain.StatusSuccess)
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				r, err := s.dao.FindRefundByUIDAndOrderSN(context.Background(), testUID, "orderSN-refund-61")
+				require.NoError(t, err)
+				assert.Equal(t, "不想学了", r.Reason)
+				assert.Equal(t, domain.RefundStatusPending.ToUint8(), r.Status)
+			},
+			req: web.ApplyRefundReq{
+				SN:     "orderSN-refund-61",
+				Reason: "不想学了",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.Refund]{
+				Data: web.Refund{
+					OrderSN: "orderSN-refund-61",
+					BuyerID: testUID,
+					Amount:  9900,
+					Reason:  "不想学了",
+					Status:  domain.RefundStatusPending.ToUint8(),
+				},
+			},
+		},
+		{
+			name: "驳回后再次申请退款成功",
+			before: func(t *testing.T) {
+				t.Helper()
+				oid := s.createRefundableOrder(t, 62, domain.StatusSuccess)
+				id, err := s.dao.CreateRefund(context.Background(), dao.OrderRefund{
+					OrderId: oid,
+					OrderSn: "orderSN-refund-62",
+					BuyerId: testUID,
+					Amount:  9900,
+					Reason:  "买错了",
+				})
+				require.NoError(t, err)
+				require.NoError(t, s.dao.SetRefundRejected(context.Background(), id, "理由不充分"))
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				r, err := s.dao.FindRefundByUIDAndOrderSN(context.Background(), testUID, "orderSN-refund-62")
+				require.NoError(t, err)
+				assert.Equal(t, "确实买错了", r.Reason)
+				assert.Empty(t, r.RejectReason)
+				assert.Equal(t, domain.RefundStatusPending.ToUint8(), r.Status)
+			},
+			req: web.ApplyRefundReq{
+				SN:     "orderSN-refund-62",
+				Reason: "确实买错了",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.Refund]{
+				Data: web.Refund{
+					OrderSN: "orderSN-refund-62",
+					BuyerID: testUID,
+					Amount:  9900,
+					Reason:  "确实买错了",
+					Status:  domain.RefundStatusPending.ToUint8(),
+				},
+			},
+		},
+		{
+			name: "订单未支付",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createRefundableOrder(t, 63, domain.StatusProcessing)
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				_, err := s.dao.FindRefundByUIDAndOrderSN(context.Background(), testUID, "orderSN-refund-63")
+				require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			},
+			req: web.ApplyRefundReq{
+				SN: "orderSN-refund-63",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.Refund]{
+				Code: errs.OrderNotRefundable.Code,
+				Msg:  errs.OrderNotRefundable.Msg,
+			},
+		},
+		{
+			name: "超过退款期限",
+			before: func(t *testing.T) {
+				t.Helper()
+				oid := s.createRefundableOrder(t, 64, domain.StatusSuccess)
+				utime := time.Now().Add(-8 * 24 * time.Hour).UnixMilli()
+				require.NoError(t, s.db.Model(&dao.Order{}).Where("id = ?", oid).Update("utime", utime).Error)
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				_, err := s.dao.FindRefundByUIDAndOrderSN(context.Background(), testUID, "orderSN-refund-64")
+				require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			},
+			req: web.ApplyRefundReq{
+				SN: "orderSN-refund-64",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.Refund]{
+				Code: errs.OrderNotRefundable.Code,
+				Msg:  errs.OrderNotRefundable.Msg,
+			},
+		},
+		{
+			name: "积分商品不能退款",
+			before: func(t *testing.T) {
+				t.Helper()
+				item := s.newOrderItemDAO(66, 66)
+				item.SPUCategory0, item.SPUCategory1 = "product", "credit"
+				_, err := s.dao.CreateOrder(context.Background(), dao.Order{
+					Id:               66,
+					SN:               "orderSN-refund-66",
+					BuyerId:          testUID,
+					PaymentId:        sqlx.NewNullInt64(66),
+					PaymentSn:        sqlx.NewNullString("paymentSN-refund-66"),
+					OriginalTotalAmt: 9900,
+					RealTotalAmt:     9900,
+					Status:           domain.StatusSuccess.ToUint8(),
+				}, []dao.OrderItem{item})
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				_, err := s.dao.FindRefundByUIDAndOrderSN(context.Background(), testUID, "orderSN-refund-66")
+				require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			},
+			req: web.ApplyRefundReq{
+				SN: "orderSN-refund-66",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.Refund]{
+				Code: errs.OrderNotRefundable.Code,
+				Msg:  errs.OrderNotRefundable.Msg,
+			},
+		},
+		{
+			name: "重复申请退款",
+			before: func(t *testing.T) {
+				t.Helper()
+				oid := s.createRefundableOrder(t, 65, domain.StatusSuccess)
+				_, err := s.dao.CreateRefund(context.Background(), dao.OrderRefund{
+					OrderId: oid,
+					OrderSn: "orderSN-refund-65",
+					BuyerId: testUID,
+					Amount:  9900,
+					Reason:  "买错了",
+				})
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				t.Helper()
+				r, err := s.dao.FindRefundByUIDAndOrderSN(context.Background(), testUID, "orderSN-refund-65")
+				require.NoError(t, err)
+				assert.Equal(t, "买错了", r.Reason)
+			},
+			req: web.ApplyRefundReq{
+				SN:     "orderSN-refund-65",
+				Reason: "再申请一次",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.Refund]{
+				Code: errs.RefundDuplicated.Code,
+				Msg:  errs.RefundDuplicated.Msg,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/order/refund/apply", iox.NewJSONReader(tc.req))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[web.Refund]()
+			server := s.newGinServer(s.emptyHandler(t, ctrl))
+			server.ServeHTTP(recorder, req)
+			require.Equal(t, tc.wantCode, recorder.Code)
+
+			resp := recorder.MustScan()
+			if tc.wantResp.Data.ID != 0 || resp.Data.ID != 0 {
+				assert.NotZero(t, resp.Data.ID)
+				assert.NotZero(t, resp.Data.Ctime)
+				assert.NotZero(t, resp.Data.Utime)
+				resp.Data.ID, resp.Data.Ctime, resp.Data.Utime = 0, 0, 0
+			}
+			assert.Equal(t, tc.wantResp, resp)
+			tc.after(t)
+		})
+	}
+}
+
+func (s *OrderModuleTestSuite) TestAdminHandler_ApproveRefund() {
+	t := s.T()
+	testCases := []struct {
+		name string
+
+		before         func(t *testing.T) int64
+		newHandlerFunc func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler
+		after          func(t *testing.T, id int64)
+		wantCode       int
+		wantResp       test.Result[any]
+	}{
+		{
+			name: "审核通过_退款成功",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createPendingRefund(t, 71)
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				paymentSvc := paymentmocks.NewMockService(ctrl)
+				paymentSvc.EXPECT().Refund(gomock.Any(), "orderSN-refund-71").Return(payment.Payment{
+					OrderSN: "orderSN-refund-71",
+					Status:  payment.StatusRefund,
+				}, nil)
+				producer := evtmocks.NewMockOrderRefundEventProducer(ctrl)
+				producer.EXPECT().Produce(gomock.Any(), event.OrderEvent{
+					OrderSN: "orderSN-refund-71",
+					BuyerID: testUID,
+					SPUs: []event.SPU{
+						{ID: 71, Category0: "product", Category1: "member"},
+					},
+				}).Return(nil)
+				return web.NewAdminHandler(s.svc, paymentSvc, producer)
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusSucceeded, domain.StatusRefunded)
+				s.requireRefundEventSent(t, id, true)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Msg: "OK",
+			},
+		},
+		{
+			name: "审核通过_发送事件失败不影响退款",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createPendingRefund(t, 72)
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				paymentSvc := paymentmocks.NewMockService(ctrl)
+				paymentSvc.EXPECT().Refund(gomock.Any(), "orderSN-refund-72").Return(payment.Payment{}, nil)
+				producer := evtmocks.NewMockOrderRefundEventProducer(ctrl)
+				producer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(errors.New("mock: 发送事件失败"))
+				return web.NewAdminHandler(s.svc, paymentSvc, producer)
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusSucceeded, domain.StatusRefunded)
+				// 等待定时任务补发
+				s.requireRefundEventSent(t, id, false)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Msg: "OK",
+			},
+		},
+		{
+			name: "支付退款失败_申请保持退款中",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createPendingRefund(t, 73)
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				paymentSvc := paymentmocks.NewMockService(ctrl)
+				paymentSvc.EXPECT().Refund(gomock.Any(), "orderSN-refund-73").Return(payment.Payment{}, errors.New("mock: 微信退款失败"))
+				return web.NewAdminHandler(s.svc, paymentSvc, evtmocks.NewMockOrderRefundEventProducer(ctrl))
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusRefunding, domain.StatusSuccess)
+			},
+			wantCode: 500,
+			wantResp: test.Result[any]{
+				Code: errs.SystemError.Code,
+				Msg:  errs.SystemError.Msg,
+			},
+		},
+		{
+			name: "退款中_其他审核正在退款",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				id := s.createPendingRefund(t, 75)
+				require.NoError(t, s.dao.SetRefundRefunding(context.Background(), id, 0))
+				return id
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				return web.NewAdminHandler(s.svc, paymentmocks.NewMockService(ctrl), evtmocks.NewMockOrderRefundEventProducer(ctrl))
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusRefunding, domain.StatusSuccess)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Code: errs.RefundStatusConflict.Code,
+				Msg:  errs.RefundStatusConflict.Msg,
+			},
+		},
+		{
+			name: "退款中_超时后重试退款成功",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				id := s.createPendingRefund(t, 76)
+				require.NoError(t, s.dao.SetRefundRefunding(context.Background(), id, 0))
+				require.NoError(t, s.db.Model(&dao.OrderRefund{}).Where("id = ?", id).
+					Update("utime", time.Now().Add(-time.Hour).UnixMilli()).Error)
+				return id
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				paymentSvc := paymentmocks.NewMockService(ctrl)
+				paymentSvc.EXPECT().Refund(gomock.Any(), "orderSN-refund-76").Return(payment.Payment{}, nil)
+				producer := evtmocks.NewMockOrderRefundEventProducer(ctrl)
+				producer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil)
+				return web.NewAdminHandler(s.svc, paymentSvc, producer)
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusSucceeded, domain.StatusRefunded)
+				s.requireRefundEventSent(t, id, true)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Msg: "OK",
+			},
+		},
+		{
+			name: "退款申请已驳回",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				id := s.createPendingRefund(t, 74)
+				require.NoError(t, s.dao.SetRefundRejected(context.Background(), id, "理由不充分"))
+				return id
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				return web.NewAdminHandler(s.svc, paymentmocks.NewMockService(ctrl), evtmocks.NewMockOrderRefundEventProducer(ctrl))
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusRejected, domain.StatusSuccess)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Code: errs.RefundStatusConflict.Code,
+				Msg:  errs.RefundStatusConflict.Msg,
+			},
+		},
+		{
+			name: "退款申请不存在",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return 10000
+			},
+			newHandlerFunc: func(t *testing.T, ctrl *gomock.Controller) *web.AdminHandler {
+				t.Helper()
+				return web.NewAdminHandler(s.svc, paymentmocks.NewMockService(ctrl), evtmocks.NewMockOrderRefundEventProducer(ctrl))
+			},
+			after:    func(t *testing.T, id int64) {},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Code: errs.RefundNotFound.Code,
+				Msg:  errs.RefundNotFound.Msg,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			id := tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/order/refund/approve", iox.NewJSONReader(web.RefundIDReq{ID: id}))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[any]()
+			server := s.newGinServerForAdmin(tc.newHandlerFunc(t, ctrl))
+			server.ServeHTTP(recorder, req)
+			require.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantResp, recorder.MustScan())
+			tc.after(t, id)
+		})
+	}
+}
+
+func (s *OrderModuleTestSuite) TestAdminHandler_RejectRefund() {
+	t := s.T()
+	testCases := []struct {
+		name string
+
+		before   func(t *testing.T) int64
+		after    func(t *testing.T, id int64)
+		wantCode int
+		wantResp test.Result[any]
+	}{
+		{
+			name: "驳回成功",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createPendingRefund(t, 81)
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusRejected, domain.StatusSuccess)
+				r, err := s.dao.FindRefundByID(context.Background(), id)
+				require.NoError(t, err)
+				assert.Equal(t, "已超过学习进度", r.RejectReason)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Msg: "OK",
+			},
+		},
+		{
+			name: "已退款的申请不能驳回",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				id := s.createPendingRefund(t, 82)
+				require.NoError(t, s.dao.SetRefundRefunding(context.Background(), id, 0))
+				require.NoError(t, s.dao.SetRefundSucceeded(context.Background(), id))
+				return id
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusSucceeded, domain.StatusRefunded)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Code: errs.RefundStatusConflict.Code,
+				Msg:  errs.RefundStatusConflict.Msg,
+			},
+		},
+		{
+			name: "退款中的申请不能驳回",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				id := s.createPendingRefund(t, 83)
+				require.NoError(t, s.dao.SetRefundRefunding(context.Background(), id, 0))
+				return id
+			},
+			after: func(t *testing.T, id int64) {
+				t.Helper()
+				s.requireRefundStatus(t, id, domain.RefundStatusRefunding, domain.StatusSuccess)
+			},
+			wantCode: 200,
+			wantResp: test.Result[any]{
+				Code: errs.RefundStatusConflict.Code,
+				Msg:  errs.RefundStatusConflict.Msg,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			id := tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/order/refund/reject", iox.NewJSONReader(web.RejectRefundReq{ID: id, Reason: "已超过学习进度"}))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[any]()
+			server := s.newGinServerForAdmin(web.NewAdminHandler(s.svc, nil, nil))
+			server.ServeHTTP(recorder, req)
+			require.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantResp, recorder.MustScan())
+			tc.after(t, id)
+		})
+	}
+}
+
+func (s *OrderModuleTestSuite) TestJob_SendOrderRefundEvents() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	unsent := s.createPendingRefund(t, 91)
+	sent := s.createPendingRefund(t, 92)
+	for _, id := range []int64{unsent, sent} {
+		require.NoError(t, s.dao.SetRefundRefunding(context.Background(), id, 0))
+		require.NoError(t, s.dao.SetRefundSucceeded(context.Background(), id))
+	}
+	require.NoError(t, s.dao.SetRefundEventSent(context.Background(), sent))
+
+	producer := evtmocks.NewMockOrderRefundEventProducer(ctrl)
+	producer.EXPECT().Produce(gomock.Any(), event.OrderEvent{
+		OrderSN: "orderSN-refund-91",
+		BuyerID: testUID,
+		SPUs: []event.SPU{
+			{ID: 91, Category0: "product", Category1: "member"},
+		},
+	}).Return(nil)
+
+	j := job.NewSendOrderRefundEventsJob(s.svc, producer, 0, 1)
+	require.NoError(t, j.Run(context.Background()))
+	s.requireRefundEventSent(t, unsent, true)
+	s.requireRefundEventSent(t, sent, true)
+}
+
+func (s *OrderModuleTestSuite) createRefundableOrder(t *testing.T, id int64, status domain.OrderStatus) int64 {
+	t.Helper()
+	oid, err := s.dao.CreateOrder(context.Background(), dao.Order{
+		Id:               id,
+		SN:               fmt.Sprintf("orderSN-refund-%d", id),
+		BuyerId:          testUID,
+		PaymentId:        sqlx.NewNullInt64(id),
+		PaymentSn:        sqlx.NewNullString(fmt.Sprintf("paymentSN-refund-%d", id)),
+		OriginalTotalAmt: 9900,
+		RealTotalAmt:     9900,
+		Status:           status.ToUint8(),
+	}, []dao.OrderItem{
+		s.newRefundableOrderItemDAO(id, id),
+	})
+	require.NoError(t, err)
+	return oid
+}
+
+func (s *OrderModuleTestSuite) newRefundableOrderItemDAO(oid, id int64) dao.OrderItem {
+	item := s.newOrderItemDAO(oid, id)
+	item.SPUCategory0 = "product"
+	return item
+}
+
+func (s *OrderModuleTestSuite) createPendingRefund(t *testing.T, id int64) int64 {
+	t.Helper()
+	oid := s.createRefundableOrder(t, id, domain.StatusSuccess)
+	rid, err := s.dao.CreateRefund(context.Background(), dao.OrderRefund{
+		OrderId: oid,
+		OrderSn: fmt.Sprintf("orderSN-refund-%d", id),
+		BuyerId: testUID,
+		Amount:  9900,
+		Reason:  "不想学了",
+	})
+	require.NoError(t, err)
+	return rid
+}
+
+func (s *OrderModuleTestSuite) requireRefundStatus(t *testing.T, id int64, refundStatus domain.RefundStatus, orderStatus domain.OrderStatus) {
+	t.Helper()
+	r, err := s.dao.FindRefundByID(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, refundStatus.ToUint8(), r.Status)
+	var o dao.Order
+	require.NoError(t, s.db.Where("id = ?", r.OrderId).First(&o).Error)
+	require.Equal(t, orderStatus.ToUint8(), o.Status)
+}
+
+func (s *OrderModuleTestSuite) requireRefundEventSent(t *testing.T, id int64, sent bool) {
+	t.Helper()
+	r, err := s.dao.FindRefundByID(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, sent, r.EventSent)
+}
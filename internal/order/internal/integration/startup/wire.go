@@ -17,8 +17,10 @@
 package startup
 
 import (
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order"
+	"github.com/ecodeclub/webook/internal/order/internal/event"
 	"github.com/ecodeclub/webook/internal/order/internal/web"
 	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/ecodeclub/webook/internal/product"
@@ -33,9 +35,13 @@ type Module struct {
 
 func InitModule(pm *payment.Module, ppm *product.Module, cm *credit.Module) (*Module, error) {
 	wire.Build(testioc.BaseSet,
+		coupon.InitService,
+		wire.Struct(new(coupon.Module), "Svc"),
 		order.InitService,
 		order.InitHandler,
 		web.NewAdminHandler,
+		wire.FieldsOf(new(*payment.Module), "Svc"),
+		event.NewOrderRefundEventProducer,
 		wire.Struct(new(Module), "*"),
 	)
 
@@ -7,8 +7,10 @@
 package startup
 
 import (
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order"
+	"github.com/ecodeclub/webook/internal/order/internal/event"
 	"github.com/ecodeclub/webook/internal/order/internal/web"
 	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/ecodeclub/webook/internal/product"
@@ -20,14 +22,24 @@ import (
 func InitModule(pm *payment.Module, ppm *product.Module, cm *credit.Module) (*Module, error) {
 	cache := testioc.InitCache()
 	db := testioc.InitDB()
-	service := order.InitService(db)
-	handler := order.InitHandler(cache, service, pm, ppm, cm)
-	adminHandler := web.NewAdminHandler(service)
-	module := &Module{
+	service := coupon.InitService(db)
+	serviceService := order.InitService(db, service)
+	module := &coupon.Module{
+		Svc: service,
+	}
+	handler := order.InitHandler(cache, serviceService, pm, ppm, cm, module)
+	service2 := pm.Svc
+	mq := testioc.InitMQ()
+	orderRefundEventProducer, err := event.NewOrderRefundEventProducer(mq)
+	if err != nil {
+		return nil, err
+	}
+	adminHandler := web.NewAdminHandler(serviceService, service2, orderRefundEventProducer)
+	startupModule := &Module{
 		Handler:      handler,
 		AdminHandler: adminHandler,
 	}
-	return module, nil
+	return startupModule, nil
 }
 
 // wire.go:
@@ -0,0 +1,76 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/order/internal/event"
+	"github.com/ecodeclub/webook/internal/order/internal/service"
+	"github.com/gotomicro/ego/task/ecron"
+)
+
+var _ ecron.NamedJob = (*SendOrderRefundEventsJob)(nil)
+
+// SendOrderRefundEventsJob 补发已退款但是退款事件发送失败的订单,保证权益最终会被回收
+type SendOrderRefundEventsJob struct {
+	svc      service.Service
+	producer event.OrderRefundEventProducer
+	// delay 退款完成后等待审核接口自行发送的时间
+	delay time.Duration
+	limit int
+}
+
+func NewSendOrderRefundEventsJob(svc service.Service, producer event.OrderRefundEventProducer, delay time.Duration, limit int) *SendOrderRefundEventsJob {
+	return &SendOrderRefundEventsJob{
+		svc:      svc,
+		producer: producer,
+		delay:    delay,
+		limit:    limit,
+	}
+}
+
+func (s *SendOrderRefundEventsJob) Name() string {
+	return "SendOrderRefundEventsJob"
+}
+
+func (s *SendOrderRefundEventsJob) Run(ctx context.Context) error {
+	utime := time.Now().Add(-s.delay).UnixMilli()
+	for {
+		refunds, err := s.svc.FindEventUnsentRefunds(ctx, utime, s.limit)
+		if err != nil {
+			return fmt.Errorf("查找退款事件未发送的退款申请失败: %w", err)
+		}
+		for _, r := range refunds {
+			order, err := s.svc.FindUserVisibleOrderByUIDAndSN(ctx, r.BuyerID, r.OrderSN)
+			if err != nil {
+				return fmt.Errorf("查找已退款订单失败: %w, sn: %s", err, r.OrderSN)
+			}
+			err = s.producer.Produce(ctx, event.NewOrderRefundEvent(order))
+			if err != nil {
+				return fmt.Errorf("补发订单退款事件失败: %w, sn: %s", err, r.OrderSN)
+			}
+			err = s.svc.SetRefundEventSent(ctx, r.ID)
+			if err != nil {
+				return fmt.Errorf("标记订单退款事件已发送失败: %w, refundID: %d", err, r.ID)
+			}
+		}
+		if len(refunds) < s.limit {
+			return nil
+		}
+	}
+}
@@ -17,12 +17,21 @@ package dao
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ecodeclub/ekit/sqlx"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ego-component/egorm"
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDuplicatedRefund     = errors.New("退款申请重复")
+	ErrRefundStatusConflict = errors.New("退款申请状态已变更")
 )
 
 type OrderDAO interface {
@@ -37,10 +46,25 @@ type OrderDAO interface {
 	FindTimeoutOrders(ctx context.Context, offset, limit int, ctime int64) ([]Order, error)
 	CountTimeoutOrders(ctx context.Context, ctime int64) (int64, error)
 	SetOrdersTimeoutClosed(ctx context.Context, orderIDs []int64, ctime int64) error
+	// FindCouponOrderSNs 查找使用了优惠券并且处于 status 的订单
+	FindCouponOrderSNs(ctx context.Context, orderIDs []int64, status uint8) ([]string, error)
 
 	FindOrders(ctx context.Context, offset, limit int) ([]Order, error)
 	CountOrders(ctx context.Context) (int64, error)
 	FindItemsByOrderIDs(ctx context.Context, oids []int64) (map[int64][]OrderItem, error)
+
+	CreateRefund(ctx context.Context, r OrderRefund) (int64, error)
+	FindRefundByID(ctx context.Context, id int64) (OrderRefund, error)
+	FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (OrderRefund, error)
+	FindRefunds(ctx context.Context, status uint8, offset, limit int) ([]OrderRefund, error)
+	CountRefunds(ctx context.Context, status uint8) (int64, error)
+	SetRefundRejected(ctx context.Context, id int64, reason string) error
+	// SetRefundRefunding 占用退款申请, 待审核或者占用已超过 utime 的退款中申请才能占用成功
+	SetRefundRefunding(ctx context.Context, id int64, utime int64) error
+	SetRefundSucceeded(ctx context.Context, id int64) error
+	// FindEventUnsentRefunds 查找已退款但退款事件未发送, 并且更新时间早于 utime 的退款申请
+	FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]OrderRefund, error)
+	SetRefundEventSent(ctx context.Context, id int64) error
 }
 
 func NewOrderGORMDAO(db *egorm.Component) OrderDAO {
@@ -167,7 +191,7 @@ func (g *gormOrderDAO) SetOrderStatus(ctx context.Context, uid int64, orderSN st
 func (g *gormOrderDAO) FindTimeoutOrders(ctx context.Context, offset, limit int, ctime int64) ([]Order, error) {
 	var res []Order
 	err := g.db.WithContext(ctx).Offset(offset).Limit(limit).Order("ctime DESC").
-		Where("status <= ? AND Ctime <= ?", domain.StatusProcessing.ToUint8(), ctime).
+		Where("status <= ? AND Ctime <= ? AND auto_renew = ?", domain.StatusProcessing.ToUint8(), ctime, false).
 		Find(&res).Error
 	return res, err
 }
@@ -175,7 +199,7 @@ func (g *gormOrderDAO) FindTimeoutOrders(ctx context.Context, offset, limit int,
 func (g *gormOrderDAO) CountTimeoutOrders(ctx context.Context, ctime int64) (int64, error) {
 	var res int64
 	err := g.db.WithContext(ctx).Model(&Order{}).
-		Where("status <= ? AND Ctime <= ?", domain.StatusProcessing.ToUint8(), ctime).
+		Where("status <= ? AND Ctime <= ? AND auto_renew = ?", domain.StatusProcessing.ToUint8(), ctime, false).
 		Select("COUNT(id)").Count(&res).Error
 	return res, err
 }
@@ -183,13 +207,175 @@ func (g *gormOrderDAO) CountTimeoutOrders(ctx context.Context, ctime int64) (int
 func (g *gormOrderDAO) SetOrdersTimeoutClosed(ctx context.Context, orderIDs []int64, ctime int64) error {
 	timestamp := time.Now().UnixMilli()
 	return g.db.WithContext(ctx).Model(&Order{}).
-		Where("status <= ? AND Ctime <= ? AND id IN ?", domain.StatusProcessing.ToUint8(), ctime, orderIDs).
+		Where("status <= ? AND Ctime <= ? AND auto_renew = ? AND id IN ?", domain.StatusProcessing.ToUint8(), ctime, false, orderIDs).
 		Updates(map[string]any{
 			"status": domain.StatusTimeoutClosed.ToUint8(),
 			"utime":  timestamp,
 		}).Error
 }
 
+func (g *gormOrderDAO) FindCouponOrderSNs(ctx context.Context, orderIDs []int64, status uint8) ([]string, error) {
+	var res []string
+	err := g.db.WithContext(ctx).Model(&Order{}).
+		Where("id IN ? AND status = ? AND coupon_id > 0", orderIDs, status).
+		Pluck("sn", &res).Error
+	return res, err
+}
+
+// CreateRefund 创建退款申请,每个订单只有一条退款申请,被驳回后再次申请会重置为待审核
+func (g *gormOrderDAO) CreateRefund(ctx context.Context, r OrderRefund) (int64, error) {
+	now := time.Now().UnixMilli()
+	r.Status = domain.RefundStatusPending.ToUint8()
+	r.Ctime, r.Utime = now, now
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old OrderRefund
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", r.OrderId).First(&old).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Create(&r).Error
+			if g.isMySQLUniqueIndexError(err) {
+				return fmt.Errorf("%w", ErrDuplicatedRefund)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if old.Status != domain.RefundStatusRejected.ToUint8() {
+			return fmt.Errorf("%w", ErrDuplicatedRefund)
+		}
+		r.Id = old.Id
+		return tx.Model(&OrderRefund{}).Where("id = ?", old.Id).
+			Updates(map[string]any{
+				"reason":        r.Reason,
+				"reject_reason": "",
+				"status":        r.Status,
+				"utime":         now,
+			}).Error
+	})
+	return r.Id, err
+}
+
+func (g *gormOrderDAO) isMySQLUniqueIndexError(err error) bool {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		const uniqueIndexErrNo uint16 = 1062
+		if me.Number == uniqueIndexErrNo {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *gormOrderDAO) FindRefundByID(ctx context.Context, id int64) (OrderRefund, error) {
+	var res OrderRefund
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (g *gormOrderDAO) FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (OrderRefund, error) {
+	var res OrderRefund
+	err := g.db.WithContext(ctx).Where("buyer_id = ? AND order_sn = ?", uid, orderSN).First(&res).Error
+	return res, err
+}
+
+func (g *gormOrderDAO) FindRefunds(ctx context.Context, status uint8, offset, limit int) ([]OrderRefund, error) {
+	var res []OrderRefund
+	query := g.db.WithContext(ctx).Model(&OrderRefund{})
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("utime DESC, id DESC").Offset(offset).Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (g *gormOrderDAO) CountRefunds(ctx context.Context, status uint8) (int64, error) {
+	var res int64
+	query := g.db.WithContext(ctx).Model(&OrderRefund{})
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Count(&res).Error
+	return res, err
+}
+
+func (g *gormOrderDAO) SetRefundRejected(ctx context.Context, id int64, reason string) error {
+	res := g.db.WithContext(ctx).Model(&OrderRefund{}).
+		Where("id = ? AND status = ?", id, domain.RefundStatusPending.ToUint8()).
+		Updates(map[string]any{
+			"reject_reason": reason,
+			"status":        domain.RefundStatusRejected.ToUint8(),
+			"utime":         time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrRefundStatusConflict)
+	}
+	return nil
+}
+
+func (g *gormOrderDAO) SetRefundRefunding(ctx context.Context, id int64, utime int64) error {
+	res := g.db.WithContext(ctx).Model(&OrderRefund{}).
+		Where("id = ? AND (status = ? OR (status = ? AND utime <= ?))", id,
+			domain.RefundStatusPending.ToUint8(), domain.RefundStatusRefunding.ToUint8(), utime).
+		Updates(map[string]any{
+			"status": domain.RefundStatusRefunding.ToUint8(),
+			"utime":  time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrRefundStatusConflict)
+	}
+	return nil
+}
+
+// SetRefundSucceeded 退款申请由“退款中”标记为“已退款”,同时订单由“支付成功”转为“已退款”
+func (g *gormOrderDAO) SetRefundSucceeded(ctx context.Context, id int64) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		res := tx.Model(&OrderRefund{}).
+			Where("id = ? AND status = ?", id, domain.RefundStatusRefunding.ToUint8()).
+			Updates(map[string]any{
+				"status": domain.RefundStatusSucceeded.ToUint8(),
+				"utime":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w", ErrRefundStatusConflict)
+		}
+		var r OrderRefund
+		if err := tx.Where("id = ?", id).First(&r).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Order{}).
+			Where("id = ? AND status = ?", r.OrderId, domain.StatusSuccess.ToUint8()).
+			Updates(map[string]any{
+				"status": domain.StatusRefunded.ToUint8(),
+				"utime":  now,
+			}).Error
+	})
+}
+
+func (g *gormOrderDAO) FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]OrderRefund, error) {
+	var res []OrderRefund
+	err := g.db.WithContext(ctx).
+		Where("status = ? AND event_sent = ? AND utime <= ?", domain.RefundStatusSucceeded.ToUint8(), false, utime).
+		Order("id ASC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (g *gormOrderDAO) SetRefundEventSent(ctx context.Context, id int64) error {
+	return g.db.WithContext(ctx).Model(&OrderRefund{}).
+		Where("id = ?", id).
+		Update("event_sent", true).Error
+}
+
 type Order struct {
 	Id               int64          `gorm:"primaryKey;autoIncrement;comment:订单自增ID"`
 	SN               string         `gorm:"type:varchar(255);not null;uniqueIndex:uniq_order_sn;comment:订单序列号"`
@@ -197,8 +383,11 @@ type Order struct {
 	PaymentId        sql.NullInt64  `gorm:"uniqueIndex:uniq_payment_id;comment:支付自增ID,冗余允许为NULL"`
 	PaymentSn        sql.NullString `gorm:"type:varchar(255);uniqueIndex:uniq_payment_sn;comment:支付序列号,冗余允许为NULL"`
 	OriginalTotalAmt int64          `gorm:"not null;comment:原始总价;单位为分, 999表示9.99元"`
+	CouponId         int64          `gorm:"not null;default:0;comment:使用的优惠券ID, 0表示没有使用"`
+	DiscountAmt      int64          `gorm:"not null;default:0;comment:优惠券优惠的金额;单位为分, 999表示9.99元"`
 	RealTotalAmt     int64          `gorm:"not null;comment:实付总价;单位为分, 999表示9.99元"`
-	Status           uint8          `gorm:"type:tinyint unsigned;not null;default:1;index:idx_order_status;comment:订单状态 1=未支付 2=处理中 3=支付成功(用户支付完成) 4=支付失败 5=已取消(用户主动取消) 6=已过期(订单超时关闭)"`
+	Status           uint8          `gorm:"type:tinyint unsigned;not null;default:1;index:idx_order_status;comment:订单状态 1=未支付 2=处理中 3=支付成功(用户支付完成) 4=支付失败 5=已取消(用户主动取消) 6=已过期(订单超时关闭) 7=已退款"`
+	AutoRenew        bool           `gorm:"not null;default:false;comment:是否为自动续费订单,扣款结果由续费模块处理,不参与超时关闭"`
 	Ctime            int64
 	Utime            int64
 }
@@ -218,6 +407,22 @@ type OrderItem struct {
 	SKUOriginalPrice int64          `gorm:"column:sku_original_price;not null;comment:商品原始单价;单位为分, 999表示9.99元"`
 	SKURealPrice     int64          `gorm:"column:sku_real_price;not null;comment:商品实付单价;单位为分, 999表示9.99元"`
 	Quantity         int64          `gorm:"not null;comment:购买数量"`
+	DiscountAmt      int64          `gorm:"not null;default:0;comment:分摊到的优惠金额;单位为分, 999表示9.99元"`
 	Ctime            int64
 	Utime            int64
 }
+
+// OrderRefund 退款申请表
+type OrderRefund struct {
+	Id           int64  `gorm:"primaryKey;autoIncrement;comment:退款申请自增ID"`
+	OrderId      int64  `gorm:"not null;uniqueIndex:uniq_order_id;comment:订单自增ID"`
+	OrderSn      string `gorm:"type:varchar(255);not null;index:idx_order_sn;comment:订单序列号"`
+	BuyerId      int64  `gorm:"not null;index:idx_buyer_id;comment:购买者ID"`
+	Amount       int64  `gorm:"not null;comment:退款金额,即订单实付总价;单位为分, 999表示9.99元"`
+	Reason       string `gorm:"type:varchar(512);not null;comment:退款原因"`
+	RejectReason string `gorm:"type:varchar(512);not null;default:'';comment:驳回原因"`
+	Status       uint8  `gorm:"type:tinyint unsigned;not null;default:1;index:idx_refund_status;comment:退款状态 1=待审核 2=已驳回 3=已退款 4=退款中"`
+	EventSent    bool   `gorm:"not null;default:false;comment:退款事件是否已发送,未发送的由定时任务补发"`
+	Ctime        int64
+	Utime        int64
+}
@@ -24,6 +24,13 @@ import (
 	"github.com/ecodeclub/ekit/sqlx"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ecodeclub/webook/internal/order/internal/repository/dao"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicatedRefund     = dao.ErrDuplicatedRefund
+	ErrRefundStatusConflict = dao.ErrRefundStatusConflict
+	ErrRecordNotFound       = gorm.ErrRecordNotFound
 )
 
 type OrderRepository interface {
@@ -38,8 +45,20 @@ type OrderRepository interface {
 	FindTimeoutOrders(ctx context.Context, offset, limit int, ctime int64) ([]domain.Order, error)
 	TotalTimeoutOrders(ctx context.Context, ctime int64) (int64, error)
 	CloseTimeoutOrders(ctx context.Context, orderIDs []int64, ctime int64) error
+	FindCouponOrderSNs(ctx context.Context, orderIDs []int64, status domain.OrderStatus) ([]string, error)
 
 	FindOrders(ctx context.Context, offset, limit int) (int64, []domain.Order, error)
+
+	CreateRefund(ctx context.Context, r domain.Refund) (domain.Refund, error)
+	FindRefundByID(ctx context.Context, id int64) (domain.Refund, error)
+	FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (domain.Refund, error)
+	FindRefunds(ctx context.Context, status domain.RefundStatus, offset, limit int) ([]domain.Refund, error)
+	TotalRefunds(ctx context.Context, status domain.RefundStatus) (int64, error)
+	RejectRefund(ctx context.Context, id int64, reason string) error
+	StartRefund(ctx context.Context, id int64, utime int64) error
+	SucceedRefund(ctx context.Context, id int64) error
+	FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]domain.Refund, error)
+	SetRefundEventSent(ctx context.Context, id int64) error
 }
 
 func NewRepository(d dao.OrderDAO) OrderRepository {
@@ -100,8 +119,11 @@ func (o *orderRepository) toOrderEntity(order domain.Order) dao.Order {
 		PaymentId:        sqlx.NewNullInt64(order.Payment.ID),
 		PaymentSn:        sqlx.NewNullString(order.Payment.SN),
 		OriginalTotalAmt: order.OriginalTotalAmt,
+		CouponId:         order.CouponID,
+		DiscountAmt:      order.DiscountAmt,
 		RealTotalAmt:     order.RealTotalAmt,
 		Status:           order.Status.ToUint8(),
+		AutoRenew:        order.AutoRenew,
 	}
 }
 
@@ -120,6 +142,7 @@ func (o *orderRepository) toOrderItemEntities(orderItems []domain.OrderItem) []d
 			SKUOriginalPrice: src.SKU.OriginalPrice,
 			SKURealPrice:     src.SKU.RealPrice,
 			Quantity:         src.SKU.Quantity,
+			DiscountAmt:      src.DiscountAmt,
 		}
 	})
 }
@@ -156,8 +179,11 @@ func (o *orderRepository) toOrderDomain(order dao.Order, orderItems []dao.OrderI
 			SN: order.PaymentSn.String,
 		},
 		OriginalTotalAmt: order.OriginalTotalAmt,
+		CouponID:         order.CouponId,
+		DiscountAmt:      order.DiscountAmt,
 		RealTotalAmt:     order.RealTotalAmt,
 		Status:           domain.OrderStatus(order.Status),
+		AutoRenew:        order.AutoRenew,
 		Items: slice.Map(orderItems, func(idx int, src dao.OrderItem) domain.OrderItem {
 			return domain.OrderItem{
 				SPU: domain.SPU{
@@ -176,6 +202,7 @@ func (o *orderRepository) toOrderDomain(order dao.Order, orderItems []dao.OrderI
 					RealPrice:     src.SKURealPrice,
 					Quantity:      src.Quantity,
 				},
+				DiscountAmt: src.DiscountAmt,
 			}
 		}),
 		Ctime: order.Ctime,
@@ -246,3 +273,100 @@ func (o *orderRepository) TotalTimeoutOrders(ctx context.Context, ctime int64) (
 func (o *orderRepository) CloseTimeoutOrders(ctx context.Context, orderIDs []int64, ctime int64) error {
 	return o.dao.SetOrdersTimeoutClosed(ctx, orderIDs, ctime)
 }
+
+func (o *orderRepository) FindCouponOrderSNs(ctx context.Context, orderIDs []int64, status domain.OrderStatus) ([]string, error) {
+	return o.dao.FindCouponOrderSNs(ctx, orderIDs, status.ToUint8())
+}
+
+func (o *orderRepository) CreateRefund(ctx context.Context, r domain.Refund) (domain.Refund, error) {
+	id, err := o.dao.CreateRefund(ctx, o.toRefundEntity(r))
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	return o.FindRefundByID(ctx, id)
+}
+
+func (o *orderRepository) FindRefundByID(ctx context.Context, id int64) (domain.Refund, error) {
+	r, err := o.dao.FindRefundByID(ctx, id)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	return o.toRefundDomain(r), nil
+}
+
+func (o *orderRepository) FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (domain.Refund, error) {
+	r, err := o.dao.FindRefundByUIDAndOrderSN(ctx, uid, orderSN)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	return o.toRefundDomain(r), nil
+}
+
+func (o *orderRepository) FindRefunds(ctx context.Context, status domain.RefundStatus, offset, limit int) ([]domain.Refund, error) {
+	rs, err := o.dao.FindRefunds(ctx, status.ToUint8(), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(rs, func(idx int, src dao.OrderRefund) domain.Refund {
+		return o.toRefundDomain(src)
+	}), nil
+}
+
+func (o *orderRepository) TotalRefunds(ctx context.Context, status domain.RefundStatus) (int64, error) {
+	return o.dao.CountRefunds(ctx, status.ToUint8())
+}
+
+func (o *orderRepository) RejectRefund(ctx context.Context, id int64, reason string) error {
+	return o.dao.SetRefundRejected(ctx, id, reason)
+}
+
+func (o *orderRepository) StartRefund(ctx context.Context, id int64, utime int64) error {
+	return o.dao.SetRefundRefunding(ctx, id, utime)
+}
+
+func (o *orderRepository) SucceedRefund(ctx context.Context, id int64) error {
+	return o.dao.SetRefundSucceeded(ctx, id)
+}
+
+func (o *orderRepository) FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]domain.Refund, error) {
+	rs, err := o.dao.FindEventUnsentRefunds(ctx, utime, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(rs, func(idx int, src dao.OrderRefund) domain.Refund {
+		return o.toRefundDomain(src)
+	}), nil
+}
+
+func (o *orderRepository) SetRefundEventSent(ctx context.Context, id int64) error {
+	return o.dao.SetRefundEventSent(ctx, id)
+}
+
+func (o *orderRepository) toRefundEntity(r domain.Refund) dao.OrderRefund {
+	return dao.OrderRefund{
+		Id:           r.ID,
+		OrderId:      r.OrderID,
+		OrderSn:      r.OrderSN,
+		BuyerId:      r.BuyerID,
+		Amount:       r.Amount,
+		Reason:       r.Reason,
+		RejectReason: r.RejectReason,
+		Status:       r.Status.ToUint8(),
+	}
+}
+
+func (o *orderRepository) toRefundDomain(r dao.OrderRefund) domain.Refund {
+	return domain.Refund{
+		ID:           r.Id,
+		OrderID:      r.OrderId,
+		OrderSN:      r.OrderSn,
+		BuyerID:      r.BuyerId,
+		Amount:       r.Amount,
+		Reason:       r.Reason,
+		RejectReason: r.RejectReason,
+		Status:       domain.RefundStatus(r.Status),
+		EventSent:    r.EventSent,
+		Ctime:        r.Ctime,
+		Utime:        r.Utime,
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/order/internal/domain"
+)
+
+// PrivacyService 导出用户的订单
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 不删除订单，退款和售后还要用到
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	svc       Service
+	batchSize int
+}
+
+func NewPrivacyService(svc Service) PrivacyService {
+	return &privacyService{
+		svc:       svc,
+		batchSize: 100,
+	}
+}
+
+func (s *privacyService) Name() string {
+	return "order"
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	res := make([]domain.Order, 0, s.batchSize)
+	for offset := 0; ; offset += s.batchSize {
+		orders, _, err := s.svc.FindUserVisibleOrdersByUID(ctx, uid, offset, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, orders...)
+		if len(orders) < s.batchSize {
+			return res, nil
+		}
+	}
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	return nil
+}
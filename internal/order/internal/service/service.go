@@ -16,12 +16,42 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ecodeclub/webook/internal/order/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
 	"golang.org/x/sync/errgroup"
 )
 
+var (
+	ErrOrderNotRefundable   = errors.New("订单不可退款")
+	ErrDuplicatedRefund     = repository.ErrDuplicatedRefund
+	ErrRefundStatusConflict = repository.ErrRefundStatusConflict
+	ErrRecordNotFound       = repository.ErrRecordNotFound
+)
+
+// refundableCategories 退款后权益可以回收的商品分类
+// 积分可能已经被消耗, 兑换码可能已经被兑换, 这些商品都不支持退款
+var refundableCategories = map[string][]string{
+	"product": {"member", "project"},
+}
+
+func isRefundableCategory(category0, category1 string) bool {
+	return slices.Contains(refundableCategories[category0], category1)
+}
+
+const (
+	// refundWindow 支付成功后可以申请退款的期限
+	refundWindow = 7 * 24 * time.Hour
+	// refundingTimeout 退款中的申请超过这个时间仍未完成, 允许再次审核通过重试退款
+	refundingTimeout = 5 * time.Minute
+)
+
 //go:generate mockgen -source=./service.go -package=ordermocks -destination=../../mocks/order.mock.go -typed Service
 type Service interface {
 	// CreateOrder 创建订单 web调用
@@ -32,25 +62,50 @@ type Service interface {
 	FindUserVisibleOrderByUIDAndSN(ctx context.Context, uid int64, orderSN string) (domain.Order, error)
 	// FindUserVisibleOrdersByUID 分页查找用户订单 web调用
 	FindUserVisibleOrdersByUID(ctx context.Context, uid int64, offset, limit int) ([]domain.Order, int64, error)
-	// CancelOrder 取消订单 web 调用
+	// CancelOrder 取消订单并释放锁定的优惠券 web 调用
 	CancelOrder(ctx context.Context, uid, oid int64) error
-	// SucceedOrder 订单支付失败 event调用
+	// SucceedOrder 订单支付成功,锁定的优惠券标记为已使用 event调用
 	SucceedOrder(ctx context.Context, uid int64, orderSN string) error
-	// FailOrder 订单支付失败 event调用
+	// FailOrder 订单支付失败并释放锁定的优惠券 event调用
 	FailOrder(ctx context.Context, uid int64, orderSN string) error
 	// FindTimeoutOrders 查询过期订单 job调用
 	FindTimeoutOrders(ctx context.Context, offset, limit int, ctime int64) ([]domain.Order, int64, error)
-	// CloseTimeoutOrders 关闭过期订单 job调用
+	// CloseTimeoutOrders 关闭过期订单并释放锁定的优惠券 job调用
 	CloseTimeoutOrders(ctx context.Context, orderIDs []int64, ctime int64) error
 	FindOrders(ctx context.Context, offset, limit int) (int64, []domain.Order, error)
+
+	// ApplyRefund 申请退款 web调用
+	ApplyRefund(ctx context.Context, uid int64, orderSN string, reason string) (domain.Refund, error)
+	// FindRefundByUIDAndOrderSN 查找订单的退款申请 web调用
+	FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (domain.Refund, error)
+	// FindRefunds 分页查找退款申请, status为零值时查找全部 admin调用
+	FindRefunds(ctx context.Context, status domain.RefundStatus, offset, limit int) ([]domain.Refund, int64, error)
+	// FindRefundByID 查找退款申请 admin调用
+	FindRefundByID(ctx context.Context, id int64) (domain.Refund, error)
+	// RejectRefund 驳回退款申请 admin调用
+	RejectRefund(ctx context.Context, id int64, reason string) error
+	// StartRefund 审核通过后占用退款申请,占用成功后才能调用支付渠道退款 admin调用
+	StartRefund(ctx context.Context, id int64) error
+	// SucceedRefund 退款完成,退款申请及订单均标记为“已退款” admin调用
+	SucceedRefund(ctx context.Context, id int64) error
+	// FindEventUnsentRefunds 查找退款事件未发送的退款申请 job调用
+	FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]domain.Refund, error)
+	// SetRefundEventSent 退款事件发送成功 admin/job调用
+	SetRefundEventSent(ctx context.Context, id int64) error
 }
 
-func NewService(repo repository.OrderRepository) Service {
-	return &service{repo: repo}
+func NewService(repo repository.OrderRepository, couponSvc coupon.Service) Service {
+	return &service{
+		repo:      repo,
+		couponSvc: couponSvc,
+		logger:    elog.DefaultLogger,
+	}
 }
 
 type service struct {
-	repo repository.OrderRepository
+	repo      repository.OrderRepository
+	couponSvc coupon.Service
+	logger    *elog.Component
 }
 
 func (s *service) FindOrders(ctx context.Context, offset, limit int) (int64, []domain.Order, error) {
@@ -90,15 +145,50 @@ func (s *service) FindUserVisibleOrdersByUID(ctx context.Context, uid int64, off
 }
 
 func (s *service) CancelOrder(ctx context.Context, uid, oid int64) error {
-	return s.repo.CancelOrder(ctx, uid, oid)
+	err := s.repo.CancelOrder(ctx, uid, oid)
+	if err != nil {
+		return err
+	}
+	s.releaseCoupons(ctx, []int64{oid}, domain.StatusCanceled)
+	return nil
 }
 
 func (s *service) SucceedOrder(ctx context.Context, uid int64, orderSN string) error {
 	// 已收到用户付款,不管订单状态为什么一律标记为“已完成”
-	return s.repo.SucceedOrder(ctx, uid, orderSN)
+	err := s.repo.SucceedOrder(ctx, uid, orderSN)
+	if err != nil {
+		return err
+	}
+	// 订单已经完成,优惠券没能标记为已使用也不影响订单,只记录下来人工处理
+	err = s.couponSvc.Consume(ctx, orderSN)
+	if err != nil {
+		s.logger.Error("标记优惠券为已使用失败", elog.FieldErr(err), elog.String("orderSN", orderSN))
+	}
+	return nil
 }
+
 func (s *service) FailOrder(ctx context.Context, uid int64, orderSN string) error {
-	return s.repo.FailOrder(ctx, uid, orderSN)
+	err := s.repo.FailOrder(ctx, uid, orderSN)
+	if err != nil {
+		return err
+	}
+	err = s.couponSvc.Release(ctx, orderSN)
+	if err != nil {
+		s.logger.Error("释放优惠券失败", elog.FieldErr(err), elog.String("orderSN", orderSN))
+	}
+	return nil
+}
+
+// releaseCoupons 只释放确实已经转入 status 的订单所锁定的优惠券,
+// 避免和支付成功并发的时候把已经支付的订单的优惠券释放掉
+func (s *service) releaseCoupons(ctx context.Context, orderIDs []int64, status domain.OrderStatus) {
+	sns, err := s.repo.FindCouponOrderSNs(ctx, orderIDs, status)
+	if err == nil && len(sns) > 0 {
+		err = s.couponSvc.Release(ctx, sns...)
+	}
+	if err != nil {
+		s.logger.Error("释放优惠券失败", elog.FieldErr(err), elog.Any("orderIDs", orderIDs))
+	}
 }
 
 func (s *service) FindTimeoutOrders(ctx context.Context, offset, limit int, ctime int64) ([]domain.Order, int64, error) {
@@ -122,5 +212,85 @@ func (s *service) FindTimeoutOrders(ctx context.Context, offset, limit int, ctim
 }
 
 func (s *service) CloseTimeoutOrders(ctx context.Context, orderIDs []int64, ctime int64) error {
-	return s.repo.CloseTimeoutOrders(ctx, orderIDs, ctime)
+	err := s.repo.CloseTimeoutOrders(ctx, orderIDs, ctime)
+	if err != nil {
+		return err
+	}
+	s.releaseCoupons(ctx, orderIDs, domain.StatusTimeoutClosed)
+	return nil
+}
+
+func (s *service) ApplyRefund(ctx context.Context, uid int64, orderSN string, reason string) (domain.Refund, error) {
+	order, err := s.repo.FindUserVisibleOrderByUIDAndSN(ctx, uid, orderSN)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	if order.Status != domain.StatusSuccess {
+		return domain.Refund{}, fmt.Errorf("%w: 订单状态非法, status: %d", ErrOrderNotRefundable, order.Status.ToUint8())
+	}
+	// 订单支付成功后不会再有其他更新,所以 Utime 就是支付成功的时间
+	if time.Since(time.UnixMilli(order.Utime)) > refundWindow {
+		return domain.Refund{}, fmt.Errorf("%w: 超过退款期限, sn: %s", ErrOrderNotRefundable, orderSN)
+	}
+	for _, item := range order.Items {
+		if !isRefundableCategory(item.SPU.Category0, item.SPU.Category1) {
+			return domain.Refund{}, fmt.Errorf("%w: 商品权益无法回收, sn: %s, category0: %s, category1: %s",
+				ErrOrderNotRefundable, orderSN, item.SPU.Category0, item.SPU.Category1)
+		}
+	}
+	return s.repo.CreateRefund(ctx, domain.Refund{
+		OrderID: order.ID,
+		OrderSN: order.SN,
+		BuyerID: order.BuyerID,
+		Amount:  order.RealTotalAmt,
+		Reason:  reason,
+	})
+}
+
+func (s *service) FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (domain.Refund, error) {
+	return s.repo.FindRefundByUIDAndOrderSN(ctx, uid, orderSN)
+}
+
+func (s *service) FindRefunds(ctx context.Context, status domain.RefundStatus, offset, limit int) ([]domain.Refund, int64, error) {
+	var (
+		eg    errgroup.Group
+		rs    []domain.Refund
+		total int64
+	)
+	eg.Go(func() error {
+		var err error
+		rs, err = s.repo.FindRefunds(ctx, status, offset, limit)
+		return err
+	})
+
+	eg.Go(func() error {
+		var err error
+		total, err = s.repo.TotalRefunds(ctx, status)
+		return err
+	})
+	return rs, total, eg.Wait()
+}
+
+func (s *service) FindRefundByID(ctx context.Context, id int64) (domain.Refund, error) {
+	return s.repo.FindRefundByID(ctx, id)
+}
+
+func (s *service) RejectRefund(ctx context.Context, id int64, reason string) error {
+	return s.repo.RejectRefund(ctx, id, reason)
+}
+
+func (s *service) StartRefund(ctx context.Context, id int64) error {
+	return s.repo.StartRefund(ctx, id, time.Now().Add(-refundingTimeout).UnixMilli())
+}
+
+func (s *service) SucceedRefund(ctx context.Context, id int64) error {
+	return s.repo.SucceedRefund(ctx, id)
+}
+
+func (s *service) FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]domain.Refund, error) {
+	return s.repo.FindEventUnsentRefunds(ctx, utime, limit)
+}
+
+func (s *service) SetRefundEventSent(ctx context.Context, id int64) error {
+	return s.repo.SetRefundEventSent(ctx, id)
 }
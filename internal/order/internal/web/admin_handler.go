@@ -1,24 +1,40 @@
 package web
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
+	"github.com/ecodeclub/webook/internal/order/internal/event"
 	"github.com/ecodeclub/webook/internal/order/internal/service"
+	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/elog"
 )
 
 type AdminHandler struct {
-	svc service.Service
+	svc                 service.Service
+	paymentSvc          payment.Service
+	refundEventProducer event.OrderRefundEventProducer
+	logger              *elog.Component
 }
 
 func (h *AdminHandler) PrivateRoutes(server *gin.Engine) {
 	g := server.Group("/order")
 	g.POST("/list", ginx.B[ListOrdersReq](h.List))
+	g.POST("/refund/list", ginx.B[ListRefundsReq](h.ListRefunds))
+	g.POST("/refund/approve", ginx.B[RefundIDReq](h.ApproveRefund))
+	g.POST("/refund/reject", ginx.B[RejectRefundReq](h.RejectRefund))
 }
-func NewAdminHandler(svc service.Service) *AdminHandler {
+func NewAdminHandler(svc service.Service, paymentSvc payment.Service, refundEventProducer event.OrderRefundEventProducer) *AdminHandler {
 	return &AdminHandler{
-		svc: svc,
+		svc:                 svc,
+		paymentSvc:          paymentSvc,
+		refundEventProducer: refundEventProducer,
+		logger:              elog.DefaultLogger,
 	}
 }
 
@@ -36,3 +52,93 @@ func (h *AdminHandler) List(ctx *ginx.Context, req ListOrdersReq) (ginx.Result,
 		},
 	}, nil
 }
+
+// ListRefunds 分页查询退款申请
+func (h *AdminHandler) ListRefunds(ctx *ginx.Context, req ListRefundsReq) (ginx.Result, error) {
+	list, count, err := h.svc.FindRefunds(ctx, domain.RefundStatus(req.Status), req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: ListRefundsResp{
+			Total: count,
+			Refunds: slice.Map(list, func(idx int, src domain.Refund) Refund {
+				return toRefundVO(src)
+			}),
+		},
+	}, nil
+}
+
+// ApproveRefund 审核通过退款申请,原路退款后将订单标记为“已退款”并发送退款事件回收权益
+func (h *AdminHandler) ApproveRefund(ctx *ginx.Context, req RefundIDReq) (ginx.Result, error) {
+	r, err := h.svc.FindRefundByID(ctx.Request.Context(), req.ID)
+	if errors.Is(err, service.ErrRecordNotFound) {
+		return refundNotFoundResult, nil
+	}
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("查找退款申请失败: %w, id: %d", err, req.ID)
+	}
+
+	// 先占用退款申请,占用成功后不能再被驳回,也不会被并发审核重复退款
+	err = h.svc.StartRefund(ctx.Request.Context(), r.ID)
+	if errors.Is(err, service.ErrRefundStatusConflict) {
+		return refundStatusConflictResult, nil
+	}
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("占用退款申请失败: %w, id: %d", err, r.ID)
+	}
+
+	// 退款失败时申请停留在“退款中”,超时后可以再次审核通过重试,支付渠道退款是幂等的
+	_, err = h.paymentSvc.Refund(ctx.Request.Context(), r.OrderSN)
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("退款失败: %w, sn: %s", err, r.OrderSN)
+	}
+
+	err = h.svc.SucceedRefund(ctx.Request.Context(), r.ID)
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("更新退款申请状态失败: %w, id: %d", err, r.ID)
+	}
+
+	h.sendOrderRefundEvent(ctx.Request.Context(), r)
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+// sendOrderRefundEvent 发送失败的退款事件由 SendOrderRefundEventsJob 补发
+func (h *AdminHandler) sendOrderRefundEvent(ctx context.Context, r domain.Refund) {
+	order, err := h.svc.FindUserVisibleOrderByUIDAndSN(ctx, r.BuyerID, r.OrderSN)
+	if err != nil {
+		h.logger.Warn("查找已退款订单失败,等待补发'订单退款事件'",
+			elog.FieldErr(err),
+			elog.String("orderSN", r.OrderSN),
+		)
+		return
+	}
+	evt := event.NewOrderRefundEvent(order)
+	err = h.refundEventProducer.Produce(ctx, evt)
+	if err != nil {
+		h.logger.Warn("发送'订单退款事件'失败,等待补发",
+			elog.FieldErr(err),
+			elog.Any("event", evt),
+		)
+		return
+	}
+	err = h.svc.SetRefundEventSent(ctx, r.ID)
+	if err != nil {
+		h.logger.Warn("标记'订单退款事件'已发送失败",
+			elog.FieldErr(err),
+			elog.Int64("refundID", r.ID),
+		)
+	}
+}
+
+// RejectRefund 驳回退款申请
+func (h *AdminHandler) RejectRefund(ctx *ginx.Context, req RejectRefundReq) (ginx.Result, error) {
+	err := h.svc.RejectRefund(ctx.Request.Context(), req.ID, req.Reason)
+	if errors.Is(err, service.ErrRefundStatusConflict) {
+		return refundStatusConflictResult, nil
+	}
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("驳回退款申请失败: %w, id: %d", err, req.ID)
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
@@ -16,12 +16,14 @@ package web
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ecodeclub/webook/internal/order/internal/service"
@@ -29,6 +31,7 @@ import (
 	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
 	"github.com/ecodeclub/webook/internal/product"
 	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/elog"
 )
 
 var _ ginx.Handler = &Handler{}
@@ -38,12 +41,14 @@ type Handler struct {
 	paymentSvc  payment.Service
 	productSvc  product.Service
 	creditSvc   credit.Service
+	couponSvc   coupon.Service
 	snGenerator *sequencenumber.Generator
 	cache       ecache.Cache
+	logger      *elog.Component
 }
 
-func NewHandler(svc service.Service, paymentSvc payment.Service, productSvc product.Service, creditSvc credit.Service, snGenerator *sequencenumber.Generator, cache ecache.Cache) *Handler {
-	return &Handler{svc: svc, paymentSvc: paymentSvc, productSvc: productSvc, creditSvc: creditSvc, snGenerator: snGenerator, cache: cache}
+func NewHandler(svc service.Service, paymentSvc payment.Service, productSvc product.Service, creditSvc credit.Service, couponSvc coupon.Service, snGenerator *sequencenumber.Generator, cache ecache.Cache) *Handler {
+	return &Handler{svc: svc, paymentSvc: paymentSvc, productSvc: productSvc, creditSvc: creditSvc, couponSvc: couponSvc, snGenerator: snGenerator, cache: cache, logger: elog.DefaultLogger}
 }
 
 func (h *Handler) PrivateRoutes(server *gin.Engine) {
@@ -54,6 +59,8 @@ func (h *Handler) PrivateRoutes(server *gin.Engine) {
 	g.POST("/list", ginx.BS[ListOrdersReq](h.ListOrders))
 	g.POST("/detail", ginx.BS[OrderSNReq](h.RetrieveOrderDetail))
 	g.POST("/cancel", ginx.BS[OrderSNReq](h.CancelOrder))
+	g.POST("/refund/apply", ginx.BS[ApplyRefundReq](h.ApplyRefund))
+	g.POST("/refund/detail", ginx.BS[OrderSNReq](h.RetrieveRefundDetail))
 }
 
 func (h *Handler) PublicRoutes(_ *gin.Engine) {}
@@ -61,12 +68,32 @@ func (h *Handler) PublicRoutes(_ *gin.Engine) {}
 // PreviewOrder 获取订单预览信息, 此时订单尚未创建
 func (h *Handler) PreviewOrder(ctx *ginx.Context, req PreviewOrderReq, sess session.Session) (ginx.Result, error) {
 
+	uid := sess.Claims().Uid
 	orderItems, originalTotalPrice, realTotalPrice, err := h.getDomainOrderItems(ctx, req.SKUs)
 	if err != nil {
 		return systemErrorResult, fmt.Errorf("获取预览订单项失败: %w", err)
 	}
+	order := domain.Order{
+		OriginalTotalAmt: originalTotalPrice,
+		RealTotalAmt:     realTotalPrice,
+		Items:            orderItems,
+	}
 
-	c, err := h.creditSvc.GetCreditsByUID(ctx.Request.Context(), sess.Claims().Uid)
+	discounts, err := h.couponSvc.FindUsableCoupons(ctx.Request.Context(), uid, h.toCouponItems(orderItems))
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("获取可用优惠券失败: %w", err)
+	}
+	if req.CouponID > 0 {
+		d, ok := slice.Find(discounts, func(src coupon.Discount) bool {
+			return src.Coupon.ID == req.CouponID
+		})
+		if !ok {
+			return couponUnavailableResult, fmt.Errorf("%w, uid: %d, couponID: %d", coupon.ErrCouponUnavailable, uid, req.CouponID)
+		}
+		h.applyDiscount(&order, d)
+	}
+
+	c, err := h.creditSvc.GetCreditsByUID(ctx.Request.Context(), uid)
 	if err != nil {
 		return systemErrorResult, fmt.Errorf("获取用户积分失败: %w", err)
 	}
@@ -77,27 +104,47 @@ func (h *Handler) PreviewOrder(ctx *ginx.Context, req PreviewOrderReq, sess sess
 		items = append(items, PaymentItem{Type: int64(pc.Type)})
 	}
 
+	vo := toOrderVO(order)
+	vo.Payment.Items = items
 	return ginx.Result{
 		Data: PreviewOrderResp{
-			Order: Order{
-				Payment: Payment{
-					Items: items,
-				},
-				OriginalTotalAmt: originalTotalPrice,
-				RealTotalAmt:     realTotalPrice,
-				Items: slice.Map(orderItems, func(idx int, src domain.OrderItem) OrderItem {
-					return OrderItem{
-						SPU: toSPUVO(src.SPU),
-						SKU: toSKUVO(src.SKU),
-					}
-				}),
-			},
+			Order:   vo,
 			Credits: c.TotalAmount,
 			Policy:  "请注意: 虚拟商品、一旦支付成功不退、不换,请谨慎操作",
+			Coupons: slice.Map(discounts, func(idx int, src coupon.Discount) Coupon {
+				return Coupon{
+					ID:          src.Coupon.ID,
+					Name:        src.Coupon.Name,
+					Desc:        src.Coupon.Desc,
+					DiscountAmt: src.Amount,
+					ExpireAt:    src.Coupon.ExpireAt,
+				}
+			}),
 		},
 	}, nil
 }
 
+func (h *Handler) toCouponItems(items []domain.OrderItem) []coupon.Item {
+	return slice.Map(items, func(idx int, src domain.OrderItem) coupon.Item {
+		return coupon.Item{
+			SKUSN:     src.SKU.SN,
+			Category0: src.SPU.Category0,
+			Category1: src.SPU.Category1,
+			Amount:    src.SKU.RealPrice * src.SKU.Quantity,
+		}
+	})
+}
+
+// applyDiscount 把优惠券的优惠记到订单上, 优惠明细和订单项一一对应
+func (h *Handler) applyDiscount(order *domain.Order, d coupon.Discount) {
+	order.CouponID = d.Coupon.ID
+	order.DiscountAmt = d.Amount
+	order.RealTotalAmt -= d.Amount
+	for i := range order.Items {
+		order.Items[i].DiscountAmt = d.Items[i]
+	}
+}
+
 func toSPUVO(spu domain.SPU) SPU {
 	return SPU{Category0: spu.Category0, Category1: spu.Category1}
 }
@@ -109,7 +156,7 @@ func toSKUVO(sku domain.SKU) SKU {
 		Name:          sku.Name,
 		Desc:          sku.Description,
 		OriginalPrice: sku.OriginalPrice,
-		RealPrice:     sku.RealPrice, // 引入优惠券时, 需要获取用户的优惠信息,动态计算
+		RealPrice:     sku.RealPrice, // 优惠券的优惠记在订单项的 DiscountAmt 上
 		Quantity:      sku.Quantity,
 	}
 }
@@ -122,7 +169,10 @@ func (h *Handler) CreateOrder(ctx *ginx.Context, req CreateOrderReq, sess sessio
 	}
 
 	uid := sess.Claims().Uid
-	order, err := h.createOrder(ctx, req.SKUs, uid)
+	order, err := h.createOrder(ctx, req.SKUs, req.CouponID, uid)
+	if errors.Is(err, coupon.ErrCouponUnavailable) {
+		return couponUnavailableResult, fmt.Errorf("创建订单失败: %w, uid: %d", err, uid)
+	}
 	if err != nil {
 		return systemErrorResult, fmt.Errorf("创建订单失败: %w, uid: %d", err, uid)
 	}
@@ -166,6 +216,10 @@ func (h *Handler) buildCreateOrderResp(order domain.Order, r payment.Record) Cre
 			SignType:  jsAPI.SignType,
 			PaySign:   jsAPI.PaySign,
 		}
+	} else if r.Channel == payment.ChannelTypeAlipay {
+		ret.AlipayQRCode = r.AlipayQRCode
+	} else if r.Channel == payment.ChannelTypeAlipayWap {
+		ret.AlipayWapURL = r.AlipayWapURL
 	}
 	return ret
 }
@@ -192,7 +246,9 @@ func (h *Handler) createOrderRequestKey(requestID string) string {
 	return fmt.Sprintf("order:create:%s", requestID)
 }
 
-func (h *Handler) createOrder(ctx context.Context, skus []SKU, buyerID int64) (domain.Order, error) {
+// createOrder 使用了优惠券的时候先锁定优惠券再创建订单,
+// 订单创建成功之后的失败由超时关闭订单的时候释放优惠券
+func (h *Handler) createOrder(ctx context.Context, skus []SKU, couponID, buyerID int64) (domain.Order, error) {
 	orderItems, originalTotalAmt, realTotalAmt, err := h.getDomainOrderItems(ctx, skus)
 	if err != nil {
 		return domain.Order{}, err
@@ -203,13 +259,30 @@ func (h *Handler) createOrder(ctx context.Context, skus []SKU, buyerID int64) (d
 		return domain.Order{}, fmt.Errorf("生成订单序列号失败")
 	}
 
-	return h.svc.CreateOrder(ctx, domain.Order{
+	order := domain.Order{
 		SN:               orderSN,
 		BuyerID:          buyerID,
 		OriginalTotalAmt: originalTotalAmt,
 		RealTotalAmt:     realTotalAmt,
 		Items:            orderItems,
-	})
+	}
+	if couponID == 0 {
+		return h.svc.CreateOrder(ctx, order)
+	}
+
+	d, err := h.couponSvc.Lock(ctx, buyerID, couponID, orderSN, h.toCouponItems(orderItems))
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("锁定优惠券失败: %w", err)
+	}
+	h.applyDiscount(&order, d)
+	order, err = h.svc.CreateOrder(ctx, order)
+	if err != nil {
+		if er := h.couponSvc.Release(ctx, orderSN); er != nil {
+			h.logger.Error("释放优惠券失败", elog.FieldErr(er), elog.String("orderSN", orderSN))
+		}
+		return domain.Order{}, err
+	}
+	return order, nil
 }
 
 func (h *Handler) getDomainOrderItems(ctx context.Context, skus []SKU) ([]domain.OrderItem, int64, int64, error) {
@@ -247,7 +320,7 @@ func (h *Handler) getDomainOrderItems(ctx context.Context, skus []SKU) ([]domain
 				Name:          productSKU.Name,
 				Description:   productSKU.Desc,
 				OriginalPrice: productSKU.Price,
-				RealPrice:     productSKU.Price, // 引入优惠券时,需要重新计算
+				RealPrice:     productSKU.Price,
 				Quantity:      sku.Quantity,
 			},
 		}
@@ -264,9 +337,11 @@ func (h *Handler) createPayment(ctx context.Context, order domain.Order, payment
 	records := make([]payment.Record, 0, len(paymentChannels))
 	realTotalAmt := int64(0)
 	channelsSet := map[payment.ChannelType]struct{}{
-		payment.ChannelTypeCredit:   {},
-		payment.ChannelTypeWechat:   {},
-		payment.ChannelTypeWechatJS: {},
+		payment.ChannelTypeCredit:    {},
+		payment.ChannelTypeWechat:    {},
+		payment.ChannelTypeWechatJS:  {},
+		payment.ChannelTypeAlipay:    {},
+		payment.ChannelTypeAlipayWap: {},
 	}
 	for _, pc := range paymentChannels {
 		if _, ok := channelsSet[payment.ChannelType(pc.Type)]; !ok {
@@ -297,7 +372,7 @@ func (h *Handler) processPaymentForOrder(ctx context.Context, pmtID int64) (paym
 		return payment.Record{}, fmt.Errorf("执行支付失败: %w, pmtID: %d", err, pmtID)
 	}
 	r, _ := slice.Find(p.Records, func(r payment.Record) bool {
-		return payment.ChannelTypeWechat == r.Channel || payment.ChannelTypeWechatJS == r.Channel
+		return payment.ChannelTypeCredit != r.Channel
 	})
 	return r, nil
 }
@@ -345,12 +420,15 @@ func toOrderVO(order domain.Order) Order {
 		SN:               order.SN,
 		Payment:          Payment{SN: order.Payment.SN},
 		OriginalTotalAmt: order.OriginalTotalAmt,
+		CouponID:         order.CouponID,
+		DiscountAmt:      order.DiscountAmt,
 		RealTotalAmt:     order.RealTotalAmt,
 		Status:           order.Status.ToUint8(),
 		Items: slice.Map(order.Items, func(idx int, src domain.OrderItem) OrderItem {
 			return OrderItem{
-				SPU: toSPUVO(src.SPU),
-				SKU: toSKUVO(src.SKU),
+				SPU:         toSPUVO(src.SPU),
+				SKU:         toSKUVO(src.SKU),
+				DiscountAmt: src.DiscountAmt,
 			}
 		}),
 		Ctime: order.Ctime,
@@ -402,3 +480,48 @@ func (h *Handler) CancelOrder(ctx *ginx.Context, req OrderSNReq, sess session.Se
 	}
 	return ginx.Result{Msg: "OK"}, nil
 }
+
+// ApplyRefund 申请退款, 等待管理员审核
+func (h *Handler) ApplyRefund(ctx *ginx.Context, req ApplyRefundReq, sess session.Session) (ginx.Result, error) {
+	uid := sess.Claims().Uid
+	r, err := h.svc.ApplyRefund(ctx.Request.Context(), uid, req.SN, req.Reason)
+	switch {
+	case errors.Is(err, service.ErrOrderNotRefundable):
+		return orderNotRefundableResult, nil
+	case errors.Is(err, service.ErrDuplicatedRefund):
+		return refundDuplicatedResult, nil
+	case err != nil:
+		return systemErrorResult, fmt.Errorf("申请退款失败: %w, uid: %d, sn: %s", err, uid, req.SN)
+	}
+	return ginx.Result{
+		Data: toRefundVO(r),
+	}, nil
+}
+
+// RetrieveRefundDetail 查看订单的退款申请
+func (h *Handler) RetrieveRefundDetail(ctx *ginx.Context, req OrderSNReq, sess session.Session) (ginx.Result, error) {
+	r, err := h.svc.FindRefundByUIDAndOrderSN(ctx.Request.Context(), sess.Claims().Uid, req.SN)
+	switch {
+	case errors.Is(err, service.ErrRecordNotFound):
+		return refundNotFoundResult, nil
+	case err != nil:
+		return systemErrorResult, fmt.Errorf("查找退款申请失败: %w", err)
+	}
+	return ginx.Result{
+		Data: toRefundVO(r),
+	}, nil
+}
+
+func toRefundVO(r domain.Refund) Refund {
+	return Refund{
+		ID:           r.ID,
+		OrderSN:      r.OrderSN,
+		BuyerID:      r.BuyerID,
+		Amount:       r.Amount,
+		Reason:       r.Reason,
+		RejectReason: r.RejectReason,
+		Status:       r.Status.ToUint8(),
+		Ctime:        r.Ctime,
+		Utime:        r.Utime,
+	}
+}
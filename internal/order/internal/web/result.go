@@ -24,4 +24,24 @@ var (
 		Code: errs.SystemError.Code,
 		Msg:  errs.SystemError.Msg,
 	}
+	orderNotRefundableResult = ginx.Result{
+		Code: errs.OrderNotRefundable.Code,
+		Msg:  errs.OrderNotRefundable.Msg,
+	}
+	refundDuplicatedResult = ginx.Result{
+		Code: errs.RefundDuplicated.Code,
+		Msg:  errs.RefundDuplicated.Msg,
+	}
+	refundNotFoundResult = ginx.Result{
+		Code: errs.RefundNotFound.Code,
+		Msg:  errs.RefundNotFound.Msg,
+	}
+	refundStatusConflictResult = ginx.Result{
+		Code: errs.RefundStatusConflict.Code,
+		Msg:  errs.RefundStatusConflict.Msg,
+	}
+	couponUnavailableResult = ginx.Result{
+		Code: errs.CouponUnavailable.Code,
+		Msg:  errs.CouponUnavailable.Msg,
+	}
 )
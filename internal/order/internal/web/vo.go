@@ -16,13 +16,23 @@ package web
 
 // PreviewOrderReq 预览订单请求
 type PreviewOrderReq struct {
-	SKUs []SKU `json:"skus"` // 商品信息
+	SKUs     []SKU `json:"skus"`               // 商品信息
+	CouponID int64 `json:"couponId,omitempty"` // 选中的优惠券, 0 表示不使用
 }
 
 type PreviewOrderResp struct {
-	Order   Order  `json:"order"`   // 预览oder, 包含支持的渠道, 和要购买的SKU
-	Credits uint64 `json:"credits"` // 积分总数
-	Policy  string `json:"policy"`  // 政策信息
+	Order   Order    `json:"order"`             // 预览oder, 包含支持的渠道, 和要购买的SKU
+	Credits uint64   `json:"credits"`           // 积分总数
+	Policy  string   `json:"policy"`            // 政策信息
+	Coupons []Coupon `json:"coupons,omitempty"` // 可以使用的优惠券, 优惠多的在前面
+}
+
+type Coupon struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Desc        string `json:"desc"`
+	DiscountAmt int64  `json:"discountAmt"` // 用在这个订单上可以优惠的金额
+	ExpireAt    int64  `json:"expireAt"`
 }
 
 type SKU struct {
@@ -41,21 +51,24 @@ type SPU struct {
 }
 
 type PaymentItem struct {
-	Type   int64 `json:"type"` // 1 积分, 2微信
+	Type   int64 `json:"type"` // 1 积分, 2微信, 3微信小程序, 4支付宝, 5支付宝H5
 	Amount int64 `json:"amount,omitempty"`
 }
 
 // CreateOrderReq 创建订单请求
 type CreateOrderReq struct {
-	RequestID    string        `json:"requestID"`    // 请求去重,防止订单重复提交
-	SKUs         []SKU         `json:"skus"`         // 商品信息
-	PaymentItems []PaymentItem `json:"paymentItems"` // 支付通道
+	RequestID    string        `json:"requestID"`          // 请求去重,防止订单重复提交
+	SKUs         []SKU         `json:"skus"`               // 商品信息
+	CouponID     int64         `json:"couponId,omitempty"` // 使用的优惠券, 0 表示不使用
+	PaymentItems []PaymentItem `json:"paymentItems"`       // 支付通道, 金额之和等于优惠之后的实付总价
 }
 
 type CreateOrderResp struct {
 	SN            string      `json:"sn"`
 	WechatCodeURL string      `json:"wechatCodeURL,omitempty"`
 	WechatJsAPI   WechatJsAPI `json:"wechatJsAPI,omitempty"`
+	AlipayQRCode  string      `json:"alipayQRCode,omitempty"` // 支付宝PC扫码支付的二维码内容
+	AlipayWapURL  string      `json:"alipayWapURL,omitempty"` // 支付宝H5支付的收银台链接, 前端直接跳转
 }
 
 // WechatJsAPI 代表微信小程序支付的东西
@@ -105,6 +118,8 @@ type Order struct {
 	SN               string      `json:"sn"`
 	Payment          Payment     `json:"payment"`
 	OriginalTotalAmt int64       `json:"originalTotalAmt"`
+	CouponID         int64       `json:"couponId,omitempty"`
+	DiscountAmt      int64       `json:"discountAmt,omitempty"` // 优惠券优惠的金额
 	RealTotalAmt     int64       `json:"realTotalAmt"`
 	Status           uint8       `json:"status"`
 	Items            []OrderItem `json:"items"`
@@ -118,6 +133,48 @@ type Payment struct {
 }
 
 type OrderItem struct {
-	SKU SKU `json:"sku"`
-	SPU SPU `json:"spu"`
+	SKU         SKU   `json:"sku"`
+	SPU         SPU   `json:"spu"`
+	DiscountAmt int64 `json:"discountAmt,omitempty"` // 这一项分摊到的优惠
+}
+
+// ApplyRefundReq 申请退款
+type ApplyRefundReq struct {
+	SN     string `json:"sn"`
+	Reason string `json:"reason"`
+}
+
+type Refund struct {
+	ID           int64  `json:"id"`
+	OrderSN      string `json:"orderSN"`
+	BuyerID      int64  `json:"buyerID,omitempty"`
+	Amount       int64  `json:"amount"`
+	Reason       string `json:"reason"`
+	RejectReason string `json:"rejectReason,omitempty"`
+	Status       uint8  `json:"status"` // 1 待审核, 2 已驳回, 3 已退款
+	Ctime        int64  `json:"ctime"`
+	Utime        int64  `json:"utime"`
+}
+
+// ListRefundsReq 分页查询退款申请, Status 为 0 时查询全部
+type ListRefundsReq struct {
+	Status uint8 `json:"status,omitempty"`
+	Offset int   `json:"offset,omitempty"`
+	Limit  int   `json:"limit,omitempty"`
+}
+
+type ListRefundsResp struct {
+	Total   int64    `json:"total,omitempty"`
+	Refunds []Refund `json:"refunds,omitempty"`
+}
+
+// RefundIDReq 审核通过退款申请
+type RefundIDReq struct {
+	ID int64 `json:"id"`
+}
+
+// RejectRefundReq 驳回退款申请
+type RejectRefundReq struct {
+	ID     int64  `json:"id"`
+	Reason string `json:"reason"`
 }
@@ -41,6 +41,45 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// ApplyRefund mocks base method.
+func (m *MockService) ApplyRefund(ctx context.Context, uid int64, orderSN, reason string) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyRefund", ctx, uid, orderSN, reason)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyRefund indicates an expected call of ApplyRefund.
+func (mr *MockServiceMockRecorder) ApplyRefund(ctx, uid, orderSN, reason any) *MockServiceApplyRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyRefund", reflect.TypeOf((*MockService)(nil).ApplyRefund), ctx, uid, orderSN, reason)
+	return &MockServiceApplyRefundCall{Call: call}
+}
+
+// MockServiceApplyRefundCall wrap *gomock.Call
+type MockServiceApplyRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceApplyRefundCall) Return(arg0 domain.Refund, arg1 error) *MockServiceApplyRefundCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceApplyRefundCall) Do(f func(context.Context, int64, string, string) (domain.Refund, error)) *MockServiceApplyRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceApplyRefundCall) DoAndReturn(f func(context.Context, int64, string, string) (domain.Refund, error)) *MockServiceApplyRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CancelOrder mocks base method.
 func (m *MockService) CancelOrder(ctx context.Context, uid, oid int64) error {
 	m.ctrl.T.Helper()
@@ -194,6 +233,45 @@ func (c *MockServiceFailOrderCall) DoAndReturn(f func(context.Context, int64, st
 	return c
 }
 
+// FindEventUnsentRefunds mocks base method.
+func (m *MockService) FindEventUnsentRefunds(ctx context.Context, utime int64, limit int) ([]domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEventUnsentRefunds", ctx, utime, limit)
+	ret0, _ := ret[0].([]domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEventUnsentRefunds indicates an expected call of FindEventUnsentRefunds.
+func (mr *MockServiceMockRecorder) FindEventUnsentRefunds(ctx, utime, limit any) *MockServiceFindEventUnsentRefundsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEventUnsentRefunds", reflect.TypeOf((*MockService)(nil).FindEventUnsentRefunds), ctx, utime, limit)
+	return &MockServiceFindEventUnsentRefundsCall{Call: call}
+}
+
+// MockServiceFindEventUnsentRefundsCall wrap *gomock.Call
+type MockServiceFindEventUnsentRefundsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindEventUnsentRefundsCall) Return(arg0 []domain.Refund, arg1 error) *MockServiceFindEventUnsentRefundsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindEventUnsentRefundsCall) Do(f func(context.Context, int64, int) ([]domain.Refund, error)) *MockServiceFindEventUnsentRefundsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindEventUnsentRefundsCall) DoAndReturn(f func(context.Context, int64, int) ([]domain.Refund, error)) *MockServiceFindEventUnsentRefundsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // FindOrders mocks base method.
 func (m *MockService) FindOrders(ctx context.Context, offset, limit int) (int64, []domain.Order, error) {
 	m.ctrl.T.Helper()
@@ -234,6 +312,124 @@ func (c *MockServiceFindOrdersCall) DoAndReturn(f func(context.Context, int, int
 	return c
 }
 
+// FindRefundByID mocks base method.
+func (m *MockService) FindRefundByID(ctx context.Context, id int64) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRefundByID", ctx, id)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRefundByID indicates an expected call of FindRefundByID.
+func (mr *MockServiceMockRecorder) FindRefundByID(ctx, id any) *MockServiceFindRefundByIDCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRefundByID", reflect.TypeOf((*MockService)(nil).FindRefundByID), ctx, id)
+	return &MockServiceFindRefundByIDCall{Call: call}
+}
+
+// MockServiceFindRefundByIDCall wrap *gomock.Call
+type MockServiceFindRefundByIDCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindRefundByIDCall) Return(arg0 domain.Refund, arg1 error) *MockServiceFindRefundByIDCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindRefundByIDCall) Do(f func(context.Context, int64) (domain.Refund, error)) *MockServiceFindRefundByIDCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindRefundByIDCall) DoAndReturn(f func(context.Context, int64) (domain.Refund, error)) *MockServiceFindRefundByIDCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindRefundByUIDAndOrderSN mocks base method.
+func (m *MockService) FindRefundByUIDAndOrderSN(ctx context.Context, uid int64, orderSN string) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRefundByUIDAndOrderSN", ctx, uid, orderSN)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRefundByUIDAndOrderSN indicates an expected call of FindRefundByUIDAndOrderSN.
+func (mr *MockServiceMockRecorder) FindRefundByUIDAndOrderSN(ctx, uid, orderSN any) *MockServiceFindRefundByUIDAndOrderSNCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRefundByUIDAndOrderSN", reflect.TypeOf((*MockService)(nil).FindRefundByUIDAndOrderSN), ctx, uid, orderSN)
+	return &MockServiceFindRefundByUIDAndOrderSNCall{Call: call}
+}
+
+// MockServiceFindRefundByUIDAndOrderSNCall wrap *gomock.Call
+type MockServiceFindRefundByUIDAndOrderSNCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindRefundByUIDAndOrderSNCall) Return(arg0 domain.Refund, arg1 error) *MockServiceFindRefundByUIDAndOrderSNCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindRefundByUIDAndOrderSNCall) Do(f func(context.Context, int64, string) (domain.Refund, error)) *MockServiceFindRefundByUIDAndOrderSNCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindRefundByUIDAndOrderSNCall) DoAndReturn(f func(context.Context, int64, string) (domain.Refund, error)) *MockServiceFindRefundByUIDAndOrderSNCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindRefunds mocks base method.
+func (m *MockService) FindRefunds(ctx context.Context, status domain.RefundStatus, offset, limit int) ([]domain.Refund, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRefunds", ctx, status, offset, limit)
+	ret0, _ := ret[0].([]domain.Refund)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindRefunds indicates an expected call of FindRefunds.
+func (mr *MockServiceMockRecorder) FindRefunds(ctx, status, offset, limit any) *MockServiceFindRefundsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRefunds", reflect.TypeOf((*MockService)(nil).FindRefunds), ctx, status, offset, limit)
+	return &MockServiceFindRefundsCall{Call: call}
+}
+
+// MockServiceFindRefundsCall wrap *gomock.Call
+type MockServiceFindRefundsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindRefundsCall) Return(arg0 []domain.Refund, arg1 int64, arg2 error) *MockServiceFindRefundsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindRefundsCall) Do(f func(context.Context, domain.RefundStatus, int, int) ([]domain.Refund, int64, error)) *MockServiceFindRefundsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindRefundsCall) DoAndReturn(f func(context.Context, domain.RefundStatus, int, int) ([]domain.Refund, int64, error)) *MockServiceFindRefundsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // FindTimeoutOrders mocks base method.
 func (m *MockService) FindTimeoutOrders(ctx context.Context, offset, limit int, ctime int64) ([]domain.Order, int64, error) {
 	m.ctrl.T.Helper()
@@ -353,6 +549,120 @@ func (c *MockServiceFindUserVisibleOrdersByUIDCall) DoAndReturn(f func(context.C
 	return c
 }
 
+// RejectRefund mocks base method.
+func (m *MockService) RejectRefund(ctx context.Context, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRefund", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectRefund indicates an expected call of RejectRefund.
+func (mr *MockServiceMockRecorder) RejectRefund(ctx, id, reason any) *MockServiceRejectRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRefund", reflect.TypeOf((*MockService)(nil).RejectRefund), ctx, id, reason)
+	return &MockServiceRejectRefundCall{Call: call}
+}
+
+// MockServiceRejectRefundCall wrap *gomock.Call
+type MockServiceRejectRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceRejectRefundCall) Return(arg0 error) *MockServiceRejectRefundCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceRejectRefundCall) Do(f func(context.Context, int64, string) error) *MockServiceRejectRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceRejectRefundCall) DoAndReturn(f func(context.Context, int64, string) error) *MockServiceRejectRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SetRefundEventSent mocks base method.
+func (m *MockService) SetRefundEventSent(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRefundEventSent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRefundEventSent indicates an expected call of SetRefundEventSent.
+func (mr *MockServiceMockRecorder) SetRefundEventSent(ctx, id any) *MockServiceSetRefundEventSentCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRefundEventSent", reflect.TypeOf((*MockService)(nil).SetRefundEventSent), ctx, id)
+	return &MockServiceSetRefundEventSentCall{Call: call}
+}
+
+// MockServiceSetRefundEventSentCall wrap *gomock.Call
+type MockServiceSetRefundEventSentCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceSetRefundEventSentCall) Return(arg0 error) *MockServiceSetRefundEventSentCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceSetRefundEventSentCall) Do(f func(context.Context, int64) error) *MockServiceSetRefundEventSentCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceSetRefundEventSentCall) DoAndReturn(f func(context.Context, int64) error) *MockServiceSetRefundEventSentCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// StartRefund mocks base method.
+func (m *MockService) StartRefund(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartRefund", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartRefund indicates an expected call of StartRefund.
+func (mr *MockServiceMockRecorder) StartRefund(ctx, id any) *MockServiceStartRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartRefund", reflect.TypeOf((*MockService)(nil).StartRefund), ctx, id)
+	return &MockServiceStartRefundCall{Call: call}
+}
+
+// MockServiceStartRefundCall wrap *gomock.Call
+type MockServiceStartRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceStartRefundCall) Return(arg0 error) *MockServiceStartRefundCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceStartRefundCall) Do(f func(context.Context, int64) error) *MockServiceStartRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceStartRefundCall) DoAndReturn(f func(context.Context, int64) error) *MockServiceStartRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SucceedOrder mocks base method.
 func (m *MockService) SucceedOrder(ctx context.Context, uid int64, orderSN string) error {
 	m.ctrl.T.Helper()
@@ -391,6 +701,44 @@ func (c *MockServiceSucceedOrderCall) DoAndReturn(f func(context.Context, int64,
 	return c
 }
 
+// SucceedRefund mocks base method.
+func (m *MockService) SucceedRefund(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SucceedRefund", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SucceedRefund indicates an expected call of SucceedRefund.
+func (mr *MockServiceMockRecorder) SucceedRefund(ctx, id any) *MockServiceSucceedRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SucceedRefund", reflect.TypeOf((*MockService)(nil).SucceedRefund), ctx, id)
+	return &MockServiceSucceedRefundCall{Call: call}
+}
+
+// MockServiceSucceedRefundCall wrap *gomock.Call
+type MockServiceSucceedRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceSucceedRefundCall) Return(arg0 error) *MockServiceSucceedRefundCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceSucceedRefundCall) Do(f func(context.Context, int64) error) *MockServiceSucceedRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceSucceedRefundCall) DoAndReturn(f func(context.Context, int64) error) *MockServiceSucceedRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // UpdateUnpaidOrderPaymentInfo mocks base method.
 func (m *MockService) UpdateUnpaidOrderPaymentInfo(ctx context.Context, uid, oid, pid int64, psn string) error {
 	m.ctrl.T.Helper()
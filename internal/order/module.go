@@ -19,9 +19,11 @@ import (
 )
 
 type Module struct {
-	Hdl                   *Handler
-	AdminHandler          *AdminHandler
-	c                     *event.PaymentConsumer
-	Svc                   Service
-	CloseTimeoutOrdersJob *CloseTimeoutOrdersJob
+	Hdl                      *Handler
+	AdminHandler             *AdminHandler
+	c                        *event.PaymentConsumer
+	Svc                      Service
+	CloseTimeoutOrdersJob    *CloseTimeoutOrdersJob
+	SendOrderRefundEventsJob *SendOrderRefundEventsJob
+	PrivacySvc               PrivacyService
 }
@@ -19,9 +19,11 @@ package order
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ecodeclub/webook/internal/order/internal/event"
@@ -39,16 +41,18 @@ import (
 )
 
 type (
-	Handler               = web.Handler
-	AdminHandler          = web.AdminHandler
-	Service               = service.Service
-	CloseTimeoutOrdersJob = job.CloseTimeoutOrdersJob
-	Order                 = domain.Order
-	Item                  = domain.OrderItem
-	SPU                   = domain.SPU
-	SKU                   = domain.SKU
-	Status                = domain.OrderStatus
-	Payment               = domain.Payment
+	Handler                  = web.Handler
+	AdminHandler             = web.AdminHandler
+	Service                  = service.Service
+	CloseTimeoutOrdersJob    = job.CloseTimeoutOrdersJob
+	SendOrderRefundEventsJob = job.SendOrderRefundEventsJob
+	PrivacyService           = service.PrivacyService
+	Order                    = domain.Order
+	Item                     = domain.OrderItem
+	SPU                      = domain.SPU
+	SKU                      = domain.SKU
+	Status                   = domain.OrderStatus
+	Payment                  = domain.Payment
 )
 
 const (
@@ -56,26 +60,33 @@ const (
 	StatusProcessing = domain.StatusProcessing
 	StatusSuccess    = domain.StatusSuccess
 	StatusFailed     = domain.StatusFailed
+	StatusRefunded   = domain.StatusRefunded
 )
 
-func InitModule(db *egorm.Component, cache ecache.Cache, q mq.MQ, pm *payment.Module, ppm *product.Module, cm *credit.Module) (*Module, error) {
+func InitModule(db *egorm.Component, cache ecache.Cache, q mq.MQ, pm *payment.Module, ppm *product.Module, cm *credit.Module, cpm *coupon.Module) (*Module, error) {
 	wire.Build(
 		wire.Struct(new(Module), "*"),
+		wire.FieldsOf(new(*coupon.Module), "Svc"),
 		InitService,
 		InitHandler,
 		web.NewAdminHandler,
+		wire.FieldsOf(new(*payment.Module), "Svc"),
 		event.NewOrderEventProducer,
+		event.NewOrderRefundEventProducer,
 		initCompleteOrderConsumer,
 		initCloseExpiredOrdersJob,
+		initSendOrderRefundEventsJob,
+		service.NewPrivacyService,
 	)
 	return new(Module), nil
 }
 
-func InitHandler(cache ecache.Cache, svc service.Service, pm *payment.Module, ppm *product.Module, cm *credit.Module) *Handler {
+func InitHandler(cache ecache.Cache, svc service.Service, pm *payment.Module, ppm *product.Module, cm *credit.Module, cpm *coupon.Module) *Handler {
 	wire.Build(
 		wire.FieldsOf(new(*payment.Module), "Svc"),
 		wire.FieldsOf(new(*product.Module), "Svc"),
 		wire.FieldsOf(new(*credit.Module), "Svc"),
+		wire.FieldsOf(new(*coupon.Module), "Svc"),
 		sequencenumber.NewGenerator,
 		web.NewHandler)
 	return new(Handler)
@@ -86,12 +97,12 @@ var (
 	svc  service.Service
 )
 
-func InitService(db *gorm.DB) service.Service {
+func InitService(db *gorm.DB, couponSvc coupon.Service) service.Service {
 	once.Do(func() {
 		_ = dao.InitTables(db)
 		orderDAO := dao.NewOrderGORMDAO(db)
 		orderRepository := repository.NewRepository(orderDAO)
-		svc = service.NewService(orderRepository)
+		svc = service.NewService(orderRepository, couponSvc)
 	})
 	return svc
 }
@@ -111,3 +122,9 @@ func initCloseExpiredOrdersJob(svc service.Service) *CloseTimeoutOrdersJob {
 	limit := 100
 	return job.NewCloseTimeoutOrdersJob(svc, minutes, seconds, limit)
 }
+
+func initSendOrderRefundEventsJob(svc service.Service, p event.OrderRefundEventProducer) *SendOrderRefundEventsJob {
+	delay := time.Minute
+	limit := 100
+	return job.NewSendOrderRefundEventsJob(svc, p, delay, limit)
+}
@@ -9,9 +9,11 @@ package order
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order/internal/domain"
 	"github.com/ecodeclub/webook/internal/order/internal/event"
@@ -28,48 +30,61 @@ import (
 
 // Injectors from wire.go:
 
-func InitModule(db *gorm.DB, cache ecache.Cache, q mq.MQ, pm *payment.Module, ppm *product.Module, cm *credit.Module) (*Module, error) {
-	service := InitService(db)
-	handler := InitHandler(cache, service, pm, ppm, cm)
-	adminHandler := web.NewAdminHandler(service)
+func InitModule(db *gorm.DB, cache ecache.Cache, q mq.MQ, pm *payment.Module, ppm *product.Module, cm *credit.Module, cpm *coupon.Module) (*Module, error) {
+	serviceService := cpm.Svc
+	service2 := InitService(db, serviceService)
+	handler := InitHandler(cache, service2, pm, ppm, cm, cpm)
+	service3 := pm.Svc
+	orderRefundEventProducer, err := event.NewOrderRefundEventProducer(q)
+	if err != nil {
+		return nil, err
+	}
+	adminHandler := web.NewAdminHandler(service2, service3, orderRefundEventProducer)
 	orderEventProducer, err := event.NewOrderEventProducer(q)
 	if err != nil {
 		return nil, err
 	}
-	paymentConsumer := initCompleteOrderConsumer(service, orderEventProducer, q)
-	closeTimeoutOrdersJob := initCloseExpiredOrdersJob(service)
+	paymentConsumer := initCompleteOrderConsumer(service2, orderEventProducer, q)
+	closeTimeoutOrdersJob := initCloseExpiredOrdersJob(service2)
+	sendOrderRefundEventsJob := initSendOrderRefundEventsJob(service2, orderRefundEventProducer)
+	privacyService := service.NewPrivacyService(service2)
 	module := &Module{
-		Hdl:                   handler,
-		AdminHandler:          adminHandler,
-		c:                     paymentConsumer,
-		Svc:                   service,
-		CloseTimeoutOrdersJob: closeTimeoutOrdersJob,
+		Hdl:                      handler,
+		AdminHandler:             adminHandler,
+		c:                        paymentConsumer,
+		Svc:                      service2,
+		CloseTimeoutOrdersJob:    closeTimeoutOrdersJob,
+		SendOrderRefundEventsJob: sendOrderRefundEventsJob,
+		PrivacySvc:               privacyService,
 	}
 	return module, nil
 }
 
-func InitHandler(cache ecache.Cache, svc2 service.Service, pm *payment.Module, ppm *product.Module, cm *credit.Module) *web.Handler {
+func InitHandler(cache ecache.Cache, svc2 service.Service, pm *payment.Module, ppm *product.Module, cm *credit.Module, cpm *coupon.Module) *web.Handler {
 	serviceService := pm.Svc
 	service2 := ppm.Svc
 	service3 := cm.Svc
+	service4 := cpm.Svc
 	generator := sequencenumber.NewGenerator()
-	handler := web.NewHandler(svc2, serviceService, service2, service3, generator, cache)
+	handler := web.NewHandler(svc2, serviceService, service2, service3, service4, generator, cache)
 	return handler
 }
 
 // wire.go:
 
 type (
-	Handler               = web.Handler
-	AdminHandler          = web.AdminHandler
-	Service               = service.Service
-	CloseTimeoutOrdersJob = job.CloseTimeoutOrdersJob
-	Order                 = domain.Order
-	Item                  = domain.OrderItem
-	SPU                   = domain.SPU
-	SKU                   = domain.SKU
-	Status                = domain.OrderStatus
-	Payment               = domain.Payment
+	Handler                  = web.Handler
+	AdminHandler             = web.AdminHandler
+	Service                  = service.Service
+	CloseTimeoutOrdersJob    = job.CloseTimeoutOrdersJob
+	SendOrderRefundEventsJob = job.SendOrderRefundEventsJob
+	PrivacyService           = service.PrivacyService
+	Order                    = domain.Order
+	Item                     = domain.OrderItem
+	SPU                      = domain.SPU
+	SKU                      = domain.SKU
+	Status                   = domain.OrderStatus
+	Payment                  = domain.Payment
 )
 
 const (
@@ -77,6 +92,7 @@ const (
 	StatusProcessing = domain.StatusProcessing
 	StatusSuccess    = domain.StatusSuccess
 	StatusFailed     = domain.StatusFailed
+	StatusRefunded   = domain.StatusRefunded
 )
 
 var (
@@ -84,12 +100,12 @@ var (
 	svc  service.Service
 )
 
-func InitService(db *gorm.DB) service.Service {
+func InitService(db *gorm.DB, couponSvc coupon.Service) service.Service {
 	once.Do(func() {
 		_ = dao.InitTables(db)
 		orderDAO := dao.NewOrderGORMDAO(db)
 		orderRepository := repository.NewRepository(orderDAO)
-		svc = service.NewService(orderRepository)
+		svc = service.NewService(orderRepository, couponSvc)
 	})
 	return svc
 }
@@ -109,3 +125,9 @@ func initCloseExpiredOrdersJob(svc2 service.Service) *CloseTimeoutOrdersJob {
 	limit := 100
 	return job.NewCloseTimeoutOrdersJob(svc2, minutes, seconds, limit)
 }
+
+func initSendOrderRefundEventsJob(svc service.Service, p event.OrderRefundEventProducer) *SendOrderRefundEventsJob {
+	delay := time.Minute
+	limit := 100
+	return job.NewSendOrderRefundEventsJob(svc, p, delay, limit)
+}
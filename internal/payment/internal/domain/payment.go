@@ -24,6 +24,13 @@ const (
 	ChannelTypeCredit   ChannelType = 1
 	ChannelTypeWechat   ChannelType = 2
 	ChannelTypeWechatJS ChannelType = 3
+	// ChannelTypeAlipay 支付宝PC端扫码支付
+	ChannelTypeAlipay ChannelType = 4
+	// ChannelTypeAlipayWap 支付宝手机网站支付
+	ChannelTypeAlipayWap ChannelType = 5
+	// ChannelTypeWechatPapay 微信委托代扣, 会员自动续费扣款成功后由 subscription 模块记录,
+	// 和 Native 支付使用同一个商户号, 退款和对账都按照微信支付处理
+	ChannelTypeWechatPapay ChannelType = 6
 )
 
 type PaymentStatus uint8
@@ -84,6 +91,10 @@ type PaymentRecord struct {
 	WechatCodeURL string
 	// JSAPI 支付方式使用
 	WechatJsAPIResp WechatJsAPIPrepayResponse
+	// 支付宝PC端扫码支付使用, 二维码内容
+	AlipayQRCode string
+	// 支付宝手机网站支付使用, 跳转收银台的链接
+	AlipayWapURL string
 }
 
 type WechatJsAPIPrepayResponse struct {
@@ -18,8 +18,13 @@ package integration
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -35,7 +40,10 @@ import (
 	evtmocks "github.com/ecodeclub/webook/internal/payment/internal/event/mocks"
 	startup "github.com/ecodeclub/webook/internal/payment/internal/integration/setup"
 	"github.com/ecodeclub/webook/internal/payment/internal/job"
+	"github.com/ecodeclub/webook/internal/payment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay/alipaytest"
 	wechatmocks "github.com/ecodeclub/webook/internal/payment/internal/service/wechat/mocks"
 	paymentmocks "github.com/ecodeclub/webook/internal/payment/mocks"
 	testioc "github.com/ecodeclub/webook/internal/test/ioc"
@@ -46,6 +54,7 @@ import (
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/jsapi"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/native"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
 	"go.uber.org/mock/gomock"
 )
 
@@ -428,6 +437,8 @@ func (s *PaymentModuleTestSuite) TestService_GetPaymentChannels() {
 		{Type: domain.ChannelTypeCredit, Desc: "积分"},
 		{Type: domain.ChannelTypeWechat, Desc: "微信"},
 		{Type: domain.ChannelTypeWechatJS, Desc: "微信小程序"},
+		{Type: domain.ChannelTypeAlipay, Desc: "支付宝"},
+		{Type: domain.ChannelTypeAlipayWap, Desc: "支付宝H5"},
 	}, channels)
 }
 
@@ -435,7 +446,7 @@ func (s *PaymentModuleTestSuite) newCreditPaymentService(
 	p event.PaymentEventProducer,
 	userSvc user.UserService,
 	svc credit.Service) payment.Service {
-	return startup.InitService(p, &credit.Module{Svc: svc}, &user.Module{Svc: userSvc}, nil, nil)
+	return startup.InitService(p, &credit.Module{Svc: svc}, &user.Module{Svc: userSvc}, nil, nil, nil, nil)
 }
 
 func (s *PaymentModuleTestSuite) TestService_PayByID() {
@@ -730,7 +741,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 					CodeUrl: &codeURL,
 				}, &core.APIResult{}, nil)
 
-				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPI, nil)
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPI, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, expected payment.Payment) {
@@ -783,7 +794,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 				mockNativeAPI := wechatmocks.NewMockNativeAPIService(ctrl)
 				mockNativeAPI.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(&native.PrepayResponse{}, &core.APIResult{}, errors.New("mock: 获取二维码失败"))
 
-				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPI, nil)
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPI, nil, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after:          func(t *testing.T, svc service.Service, expected payment.Payment) {},
@@ -886,7 +897,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 					},
 				}, nil).AnyTimes()
 				return startup.InitService(nil, &credit.Module{},
-					&user.Module{Svc: mockUserSvc}, nil, mockJSAPI)
+					&user.Module{Svc: mockUserSvc}, nil, mockJSAPI, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, expected payment.Payment) {
@@ -957,7 +968,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 				return startup.InitService(nil, &credit.Module{},
 					&user.Module{
 						Svc: mockUserSvc,
-					}, nil, mockJSAPI)
+					}, nil, mockJSAPI, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after:          func(t *testing.T, svc service.Service, expected payment.Payment) {},
@@ -1055,7 +1066,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 					CodeUrl: &codeURL,
 				}, &core.APIResult{}, nil)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPI, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPI, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, expected payment.Payment) {
@@ -1199,7 +1210,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 				mockNativeAPI := wechatmocks.NewMockNativeAPIService(ctrl)
 				mockNativeAPI.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(&native.PrepayResponse{}, &core.APIResult{}, errors.New("mock: 获取二维码失败"))
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPI, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPI, nil, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after:          func(t *testing.T, svc service.Service, expected payment.Payment) {},
@@ -1295,7 +1306,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 					},
 				}, nil).AnyTimes()
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{Svc: mockUserSvc}, nil, mockJSAPI)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{Svc: mockUserSvc}, nil, mockJSAPI, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, expected payment.Payment) {
@@ -1454,7 +1465,7 @@ func (s *PaymentModuleTestSuite) TestService_PayByID() {
 					},
 				}, nil).AnyTimes()
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{Svc: mockUserSvc}, nil, mockJSAPI)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{Svc: mockUserSvc}, nil, mockJSAPI, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after:          func(t *testing.T, svc service.Service, expected payment.Payment) {},
@@ -1721,7 +1732,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				resp := &native.PrepayResponse{CodeUrl: core.String("wechat_code_url_300003")}
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
-				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -1780,7 +1791,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				resp := &native.PrepayResponse{CodeUrl: core.String("wechat_code_url_300004")}
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
-				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2179,7 +2190,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 					},
 				}, nil).AnyTimes()
 
-				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{Svc: mockUserSvc}, mockNativeAPIService, nil)
+				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{Svc: mockUserSvc}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2268,7 +2279,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
 
-				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2356,7 +2367,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
 
-				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2445,7 +2456,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
 
-				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2522,7 +2533,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2599,7 +2610,7 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.Error,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -2648,14 +2659,14 @@ func (s *PaymentModuleTestSuite) TestService_HandleWechatCallback() {
 }
 
 func (s *PaymentModuleTestSuite) newWechatNativePayment(mockProducer *evtmocks.MockPaymentEventProducer, mockNativeAPIService *wechatmocks.MockNativeAPIService) payment.Service {
-	return startup.InitService(mockProducer, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil)
+	return startup.InitService(mockProducer, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 }
 
 func (s *PaymentModuleTestSuite) newWechatJSAPIPayment(
 	mockProducer *evtmocks.MockPaymentEventProducer,
 	mockUsrSvc user.UserService,
 	mockJSAPIService *wechatmocks.MockJSAPIService) payment.Service {
-	return startup.InitService(mockProducer, &credit.Module{}, &user.Module{Svc: mockUsrSvc}, nil, mockJSAPIService)
+	return startup.InitService(mockProducer, &credit.Module{}, &user.Module{Svc: mockUsrSvc}, nil, mockJSAPIService, nil, nil)
 }
 
 func (s *PaymentModuleTestSuite) TestService_FindTimeoutPayments() {
@@ -2770,7 +2781,7 @@ func (s *PaymentModuleTestSuite) TestService_FindTimeoutPayments() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil).Times(5)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			offset:         0,
 			limit:          2,
@@ -2935,7 +2946,7 @@ func (s *PaymentModuleTestSuite) TestService_CloseTimeoutPayment() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil).Times(6)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -3177,7 +3188,7 @@ func (s *PaymentModuleTestSuite) TestService_SyncWechatInfo() {
 				}
 				mockNativeAPIService.EXPECT().QueryOrderByOutTradeNo(gomock.Any(), req).Return(txn, result, nil)
 
-				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -3797,7 +3808,7 @@ func (s *PaymentModuleTestSuite) TestService_SyncWechatInfo() {
 				}
 				mockNativeAPIService.EXPECT().QueryOrderByOutTradeNo(gomock.Any(), req).Return(txn, result, nil)
 
-				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditService}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditService}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -3889,7 +3900,7 @@ func (s *PaymentModuleTestSuite) TestService_SyncWechatInfo() {
 				}
 				mockNativeAPIService.EXPECT().QueryOrderByOutTradeNo(gomock.Any(), req).Return(txn, result, nil)
 
-				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditService}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(mockProducer, &credit.Module{Svc: mockCreditService}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -3974,7 +3985,7 @@ func (s *PaymentModuleTestSuite) TestService_SyncWechatInfo() {
 				}
 				mockNativeAPIService.EXPECT().QueryOrderByOutTradeNo(gomock.Any(), req).Return(txn, result, nil)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditService}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditService}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -4526,7 +4537,7 @@ func (s *PaymentModuleTestSuite) TestService_SetPaymentStatusPaidFailed() {
 				result := &core.APIResult{}
 				mockNativeAPIService.EXPECT().Prepay(gomock.Any(), gomock.Any()).Return(resp, result, nil).Times(6)
 
-				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, mockNativeAPIService, nil, nil, nil)
 			},
 			errRequireFunc: require.NoError,
 			after: func(t *testing.T, svc service.Service, pmtID int64) {
@@ -4575,3 +4586,440 @@ func (s *PaymentModuleTestSuite) TestService_SetPaymentStatusPaidFailed() {
 		s.TearDownTest()
 	}
 }
+
+func (s *PaymentModuleTestSuite) TestService_Refund() {
+	t := s.T()
+
+	testCases := []struct {
+		name           string
+		before         func(t *testing.T)
+		orderSN        string
+		newSvcFunc     func(t *testing.T, ctrl *gomock.Controller) service.Service
+		errRequireFunc require.ErrorAssertionFunc
+		after          func(t *testing.T, svc service.Service)
+	}{
+		{
+			name: "退款成功_仅积分支付",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500001, domain.PaymentStatusPaidSuccess, domain.ChannelTypeCredit)
+			},
+			orderSN: "order-refund-500001",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				mockCreditSvc := creditmocks.NewMockService(ctrl)
+				mockCreditSvc.EXPECT().AddCredits(gomock.Any(), credit.Credit{
+					Uid: 500001,
+					Logs: []credit.CreditLog{
+						{
+							Key:          "refund-order-refund-500001",
+							ChangeAmount: 1000,
+							Biz:          "order",
+							BizId:        500001,
+							Desc:         "订单退款",
+						},
+					},
+				}).Return(nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, nil, nil, nil, nil)
+			},
+			errRequireFunc: require.NoError,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500001, domain.PaymentStatusRefund)
+			},
+		},
+		{
+			name: "退款成功_积分和微信Native混合支付",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500002, domain.PaymentStatusPaidSuccess, domain.ChannelTypeCredit, domain.ChannelTypeWechat)
+			},
+			orderSN: "order-refund-500002",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				mockCreditSvc := creditmocks.NewMockService(ctrl)
+				mockCreditSvc.EXPECT().AddCredits(gomock.Any(), gomock.Any()).Return(nil)
+
+				mockRefundAPI := wechatmocks.NewMockRefundAPIService(ctrl)
+				mockRefundAPI.EXPECT().Create(gomock.Any(), refunddomestic.CreateRequest{
+					OutTradeNo:  core.String("order-refund-500002"),
+					OutRefundNo: core.String("order-refund-500002"),
+					Reason:      core.String("订单退款"),
+					Amount: &refunddomestic.AmountReq{
+						Refund:   core.Int64(1000),
+						Total:    core.Int64(1000),
+						Currency: core.String("CNY"),
+					},
+				}).Return(&refunddomestic.Refund{
+					Status: refunddomestic.STATUS_PROCESSING.Ptr(),
+				}, &core.APIResult{}, nil)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, nil, nil, mockRefundAPI, nil)
+			},
+			errRequireFunc: require.NoError,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500002, domain.PaymentStatusRefund)
+			},
+		},
+		{
+			name: "退款成功_积分已返还",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500003, domain.PaymentStatusPaidSuccess, domain.ChannelTypeCredit)
+			},
+			orderSN: "order-refund-500003",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				mockCreditSvc := creditmocks.NewMockService(ctrl)
+				mockCreditSvc.EXPECT().AddCredits(gomock.Any(), gomock.Any()).Return(credit.ErrDuplicatedCreditLog)
+				return startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, nil, nil, nil, nil)
+			},
+			errRequireFunc: require.NoError,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500003, domain.PaymentStatusRefund)
+			},
+		},
+		{
+			name: "重复退款",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500004, domain.PaymentStatusRefund, domain.ChannelTypeWechat)
+			},
+			orderSN: "order-refund-500004",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, nil, nil, nil, nil)
+			},
+			errRequireFunc: require.NoError,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500004, domain.PaymentStatusRefund)
+			},
+		},
+		{
+			name: "退款失败_微信退款失败",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500005, domain.PaymentStatusPaidSuccess, domain.ChannelTypeWechatJS)
+			},
+			orderSN: "order-refund-500005",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				mockRefundAPI := wechatmocks.NewMockRefundAPIService(ctrl)
+				mockRefundAPI.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, nil, errors.New("mock: 微信退款失败"))
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, nil, nil, mockRefundAPI, nil)
+			},
+			errRequireFunc: require.Error,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500005, domain.PaymentStatusPaidSuccess)
+			},
+		},
+		{
+			name: "退款成功_微信委托代扣",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500007, domain.PaymentStatusPaidSuccess, domain.ChannelTypeWechatPapay)
+			},
+			orderSN: "order-refund-500007",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				// 委托代扣的订单通过 Native 支付的退款接口原路退款
+				mockRefundAPI := wechatmocks.NewMockRefundAPIService(ctrl)
+				mockRefundAPI.EXPECT().Create(gomock.Any(), refunddomestic.CreateRequest{
+					OutTradeNo:  core.String("order-refund-500007"),
+					OutRefundNo: core.String("order-refund-500007"),
+					Reason:      core.String("订单退款"),
+					Amount: &refunddomestic.AmountReq{
+						Refund:   core.Int64(1000),
+						Total:    core.Int64(1000),
+						Currency: core.String("CNY"),
+					},
+				}).Return(&refunddomestic.Refund{
+					Status: refunddomestic.STATUS_PROCESSING.Ptr(),
+				}, &core.APIResult{}, nil)
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, nil, nil, mockRefundAPI, nil)
+			},
+			errRequireFunc: require.NoError,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500007, domain.PaymentStatusRefund)
+			},
+		},
+		{
+			name: "退款失败_支付未成功",
+			before: func(t *testing.T) {
+				t.Helper()
+				s.createPayment(t, 500006, domain.PaymentStatusProcessing, domain.ChannelTypeWechat)
+			},
+			orderSN: "order-refund-500006",
+			newSvcFunc: func(t *testing.T, ctrl *gomock.Controller) service.Service {
+				t.Helper()
+				return startup.InitService(nil, &credit.Module{}, &user.Module{}, nil, nil, nil, nil)
+			},
+			errRequireFunc: require.Error,
+			after: func(t *testing.T, svc service.Service) {
+				t.Helper()
+				s.requirePaymentStatus(t, svc, 500006, domain.PaymentStatusProcessing)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tc.before(t)
+			svc := tc.newSvcFunc(t, ctrl)
+			_, err := svc.Refund(context.Background(), tc.orderSN)
+			tc.errRequireFunc(t, err)
+			tc.after(t, svc)
+		})
+	}
+}
+
+func (s *PaymentModuleTestSuite) TestService_FindPaidPayments() {
+	t := s.T()
+	s.createPayment(t, 510001, domain.PaymentStatusPaidSuccess, domain.ChannelTypeWechat)
+	s.createPayment(t, 510002, domain.PaymentStatusRefund, domain.ChannelTypeCredit, domain.ChannelTypeWechatJS)
+	s.createPayment(t, 510003, domain.PaymentStatusUnpaid, domain.ChannelTypeWechat)
+	s.createPayment(t, 510004, domain.PaymentStatusPaidFailed, domain.ChannelTypeWechat)
+
+	svc := startup.InitService(nil, &credit.Module{}, &user.Module{}, nil, nil, nil, nil)
+	now := time.Now()
+	stime, etime := now.Add(-time.Hour).UnixMilli(), now.Add(time.Hour).UnixMilli()
+
+	pmts, err := svc.FindPaidPayments(context.Background(), 0, 1, stime, etime)
+	require.NoError(t, err)
+	require.Len(t, pmts, 1)
+	require.Equal(t, int64(510001), pmts[0].ID)
+	require.Len(t, pmts[0].Records, 1)
+
+	pmts, err = svc.FindPaidPayments(context.Background(), 1, 10, stime, etime)
+	require.NoError(t, err)
+	require.Len(t, pmts, 1)
+	require.Equal(t, int64(510002), pmts[0].ID)
+	require.Len(t, pmts[0].Records, 2)
+
+	// 不在时间范围内
+	pmts, err = svc.FindPaidPayments(context.Background(), 0, 10, etime, etime+1)
+	require.NoError(t, err)
+	require.Empty(t, pmts)
+
+	pmt, err := svc.FindPaymentByOrderSN(context.Background(), "order-refund-510003")
+	require.NoError(t, err)
+	require.Equal(t, int64(510003), pmt.ID)
+
+	_, err = svc.FindPaymentByOrderSN(context.Background(), "order-refund-not-exist")
+	require.ErrorIs(t, err, service.ErrRecordNotFound)
+}
+
+// createPayment 创建 id 对应的支付记录, 每个渠道金额均为 1000
+func (s *PaymentModuleTestSuite) createPayment(t *testing.T, id int64, status domain.PaymentStatus, channels ...domain.ChannelType) {
+	t.Helper()
+	records := slice.Map(channels, func(idx int, src domain.ChannelType) dao.PaymentRecord {
+		return dao.PaymentRecord{
+			PaymentNO3rd: sql.NullString{String: fmt.Sprintf("payment-no-3rd-%d-%d", id, src), Valid: true},
+			Description:  "月会员 * 1",
+			Channel:      src.ToUnit8(),
+			Amount:       1000,
+			PaidAt:       time.Now().UnixMilli(),
+			Status:       status.ToUint8(),
+		}
+	})
+	_, _, err := startup.InitDAO(s.db).FindOrCreate(context.Background(), dao.Payment{
+		Id:               id,
+		SN:               fmt.Sprintf("payment-refund-%d", id),
+		PayerId:          id,
+		OrderId:          id,
+		OrderSn:          sql.NullString{String: fmt.Sprintf("order-refund-%d", id), Valid: true},
+		OrderDescription: "月会员 * 1",
+		TotalAmount:      int64(1000 * len(channels)),
+		PaidAt:           time.Now().UnixMilli(),
+		Status:           status.ToUint8(),
+	}, records)
+	require.NoError(t, err)
+}
+
+func (s *PaymentModuleTestSuite) requirePaymentStatus(t *testing.T, svc service.Service, id int64, status domain.PaymentStatus) {
+	t.Helper()
+	actual, err := svc.FindPaymentByID(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, status, actual.Status)
+	for _, r := range actual.Records {
+		require.Equal(t, status, r.Status)
+	}
+}
+
+func (s *PaymentModuleTestSuite) TestService_Alipay() {
+	t := s.T()
+
+	testCases := []struct {
+		name string
+		pmt  domain.Payment
+		run  func(t *testing.T, ctrl *gomock.Controller, gateway *alipaytest.Gateway, client *alipay.Client, pmt domain.Payment)
+	}{
+		{
+			name: "积分和支付宝PC扫码混合支付_支付成功后退款",
+			pmt:  s.newAlipayPayment(600001, domain.ChannelTypeAlipay),
+			run: func(t *testing.T, ctrl *gomock.Controller, gateway *alipaytest.Gateway, client *alipay.Client, pmt domain.Payment) {
+				t.Helper()
+				mockProducer := evtmocks.NewMockPaymentEventProducer(ctrl)
+				mockProducer.EXPECT().Produce(gomock.Any(), event.PaymentEvent{
+					OrderSN: pmt.OrderSN,
+					PayerID: pmt.PayerID,
+					Status:  domain.PaymentStatusPaidSuccess.ToUint8(),
+				}).Return(nil)
+				mockCreditSvc := creditmocks.NewMockService(ctrl)
+				mockCreditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(int64(61), nil)
+				mockCreditSvc.EXPECT().ConfirmDeductCredits(gomock.Any(), pmt.PayerID, int64(61)).Return(nil)
+				mockCreditSvc.EXPECT().AddCredits(gomock.Any(), gomock.Any()).Return(nil)
+				svc := startup.InitService(mockProducer, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, nil, nil, nil, client)
+
+				p, err := svc.CreatePayment(context.Background(), pmt)
+				require.NoError(t, err)
+				p, err = svc.PayByID(context.Background(), p.ID)
+				require.NoError(t, err)
+				r, ok := slice.Find(p.Records, func(src domain.PaymentRecord) bool {
+					return src.Channel == domain.ChannelTypeAlipay
+				})
+				require.True(t, ok)
+				require.NotEmpty(t, r.AlipayQRCode)
+				s.requirePaymentStatus(t, svc, p.ID, domain.PaymentStatusProcessing)
+
+				// 用户扫码付款, 支付宝异步通知
+				require.NoError(t, gateway.Pay(pmt.OrderSN))
+				n := s.parseAlipayNotification(t, gateway, client, pmt.OrderSN)
+				require.NoError(t, svc.HandleAlipayCallback(context.Background(), n))
+				s.requirePaymentStatus(t, svc, p.ID, domain.PaymentStatusPaidSuccess)
+				actual, err := svc.FindPaymentByID(context.Background(), p.ID)
+				require.NoError(t, err)
+				trade, _ := gateway.Trade(pmt.OrderSN)
+				r, _ = slice.Find(actual.Records, func(src domain.PaymentRecord) bool {
+					return src.Channel == domain.ChannelTypeAlipay
+				})
+				require.Equal(t, trade.TradeNo, r.PaymentNO3rd)
+
+				// 退款, 之后的退款通知被忽略
+				_, err = svc.Refund(context.Background(), pmt.OrderSN)
+				require.NoError(t, err)
+				trade, _ = gateway.Trade(pmt.OrderSN)
+				require.True(t, trade.Refunded)
+				require.Equal(t, "99.00", trade.RefundAmount)
+				refundNotification := s.parseAlipayNotification(t, gateway, client, pmt.OrderSN)
+				require.ErrorIs(t, svc.HandleAlipayCallback(context.Background(), refundNotification), service.ErrIgnoredPaymentStatus)
+				s.requirePaymentStatus(t, svc, p.ID, domain.PaymentStatusRefund)
+
+				// 退款之后重复投递的支付成功通知不能覆盖“已退款”, 也不会再次发送支付成功事件
+				require.NoError(t, svc.HandleAlipayCallback(context.Background(), n))
+				s.requirePaymentStatus(t, svc, p.ID, domain.PaymentStatusRefund)
+			},
+		},
+		{
+			name: "支付宝通知金额与支付记录不一致",
+			pmt:  s.newAlipayPayment(600003, domain.ChannelTypeAlipay),
+			run: func(t *testing.T, ctrl *gomock.Controller, gateway *alipaytest.Gateway, client *alipay.Client, pmt domain.Payment) {
+				t.Helper()
+				mockCreditSvc := creditmocks.NewMockService(ctrl)
+				mockCreditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(int64(63), nil)
+				svc := startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, nil, nil, nil, client)
+
+				p, err := svc.CreatePayment(context.Background(), pmt)
+				require.NoError(t, err)
+				p, err = svc.PayByID(context.Background(), p.ID)
+				require.NoError(t, err)
+
+				require.NoError(t, gateway.Pay(pmt.OrderSN))
+				n := s.parseAlipayNotification(t, gateway, client, pmt.OrderSN)
+				n.TotalAmount = "0.01"
+				require.Error(t, svc.HandleAlipayCallback(context.Background(), n))
+				s.requirePaymentStatus(t, svc, p.ID, domain.PaymentStatusProcessing)
+			},
+		},
+		{
+			name: "积分和支付宝H5混合支付_超时未支付关闭交易",
+			pmt:  s.newAlipayPayment(600002, domain.ChannelTypeAlipayWap),
+			run: func(t *testing.T, ctrl *gomock.Controller, gateway *alipaytest.Gateway, client *alipay.Client, pmt domain.Payment) {
+				t.Helper()
+				mockCreditSvc := creditmocks.NewMockService(ctrl)
+				mockCreditSvc.EXPECT().TryDeductCredits(gomock.Any(), gomock.Any()).Return(int64(62), nil)
+				mockCreditSvc.EXPECT().CancelDeductCredits(gomock.Any(), pmt.PayerID, int64(62)).Return(nil)
+				svc := startup.InitService(nil, &credit.Module{Svc: mockCreditSvc}, &user.Module{}, nil, nil, nil, client)
+
+				p, err := svc.CreatePayment(context.Background(), pmt)
+				require.NoError(t, err)
+				p, err = svc.PayByID(context.Background(), p.ID)
+				require.NoError(t, err)
+				r, ok := slice.Find(p.Records, func(src domain.PaymentRecord) bool {
+					return src.Channel == domain.ChannelTypeAlipayWap
+				})
+				require.True(t, ok)
+				require.NotEmpty(t, r.AlipayWapURL)
+
+				// 用户打开了收银台但没有付款
+				resp, err := http.Get(r.AlipayWapURL)
+				require.NoError(t, err)
+				_ = resp.Body.Close()
+
+				p, err = svc.FindPaymentByID(context.Background(), p.ID)
+				require.NoError(t, err)
+				require.NoError(t, svc.SyncWechatInfo(context.Background(), p))
+				s.requirePaymentStatus(t, svc, p.ID, domain.PaymentStatusTimeoutClosed)
+				trade, _ := gateway.Trade(pmt.OrderSN)
+				require.Equal(t, alipaytest.TradeStatusClosed, trade.Status)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+			require.NoError(t, err)
+			gateway, err := alipaytest.NewGateway("MockAlipayAppID", &appKey.PublicKey)
+			require.NoError(t, err)
+			server := httptest.NewServer(gateway)
+			defer server.Close()
+			client := alipay.NewClient("MockAlipayAppID", alipaytest.SellerID, appKey, gateway.PublicKey(), server.URL)
+
+			tc.run(t, ctrl, gateway, client, tc.pmt)
+		})
+	}
+}
+
+func (s *PaymentModuleTestSuite) newAlipayPayment(id int64, channel domain.ChannelType) domain.Payment {
+	return domain.Payment{
+		OrderID:          id,
+		OrderSN:          fmt.Sprintf("order-alipay-%d", id),
+		PayerID:          id,
+		OrderDescription: "年会员 * 1",
+		TotalAmount:      10000,
+		Records: []domain.PaymentRecord{
+			{
+				Description: "年会员 * 1",
+				Channel:     domain.ChannelTypeCredit,
+				Amount:      100,
+			},
+			{
+				Description: "年会员 * 1",
+				Channel:     channel,
+				Amount:      9900,
+			},
+		},
+	}
+}
+
+func (s *PaymentModuleTestSuite) parseAlipayNotification(t *testing.T, gateway *alipaytest.Gateway, client *alipay.Client, orderSN string) alipay.Notification {
+	t.Helper()
+	req, err := gateway.NotifyRequest("/api/interview/pay/alipay/callback", orderSN)
+	require.NoError(t, err)
+	n, err := client.ParseNotification(req)
+	require.NoError(t, err)
+	return n
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/repository"
 	"github.com/ecodeclub/webook/internal/payment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/ecodeclub/webook/internal/payment/ioc"
 	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
@@ -52,21 +53,31 @@ func InitService(p event.PaymentEventProducer,
 	cm *credit.Module,
 	um *user.Module,
 	native wechat.NativeAPIService,
-	js wechat.JSAPIService) payment.Service {
+	js wechat.JSAPIService,
+	refund wechat.RefundAPIService,
+	ali alipay.APIService) payment.Service {
 	wire.Build(
 		serviceSet,
 		ioc.InitWechatNativePaymentService,
 		ioc.InitWechatJSAPIPaymentService,
+		initAlipayConfig,
+		ioc.InitAlipayPCPaymentService,
+		ioc.InitAlipayWapPaymentService,
 		newPaymentServices,
 		service.NewService,
 	)
 	return nil
 }
 
-func newPaymentServices(n *wechat.NativePaymentService, j *wechat.JSAPIPaymentService) map[payment.ChannelType]service.PaymentService {
+func newPaymentServices(n *wechat.NativePaymentService,
+	j *wechat.JSAPIPaymentService,
+	ap *alipay.PCPaymentService,
+	aw *alipay.WapPaymentService) map[payment.ChannelType]service.PaymentService {
 	return map[payment.ChannelType]service.PaymentService{
-		payment.ChannelTypeWechat:   n,
-		payment.ChannelTypeWechatJS: j,
+		payment.ChannelTypeWechat:    n,
+		payment.ChannelTypeWechatJS:  j,
+		payment.ChannelTypeAlipay:    ap,
+		payment.ChannelTypeAlipayWap: aw,
 	}
 }
 
@@ -90,3 +101,11 @@ func initWechatConfig() ioc.WechatConfig {
 		PaymentNotifyURL: "MockPaymentNotifyURL",
 	}
 }
+
+func initAlipayConfig() ioc.AlipayConfig {
+	return ioc.AlipayConfig{
+		AppID:            "MockAlipayAppID",
+		PaymentNotifyURL: "MockAlipayNotifyURL",
+		PaymentReturnURL: "MockAlipayReturnURL",
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/repository"
 	"github.com/ecodeclub/webook/internal/payment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/ecodeclub/webook/internal/payment/ioc"
 	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
@@ -26,12 +27,15 @@ import (
 
 // Injectors from wire.go:
 
-func InitService(p event.PaymentEventProducer, cm *credit.Module, um *user.Module, native wechat.NativeAPIService, js wechat.JSAPIService) service.Service {
+func InitService(p event.PaymentEventProducer, cm *credit.Module, um *user.Module, native wechat.NativeAPIService, js wechat.JSAPIService, refund wechat.RefundAPIService, ali alipay.APIService) service.Service {
 	wechatConfig := initWechatConfig()
-	nativePaymentService := ioc.InitWechatNativePaymentService(native, wechatConfig)
+	nativePaymentService := ioc.InitWechatNativePaymentService(native, refund, wechatConfig)
 	userService := um.Svc
-	jsapiPaymentService := ioc.InitWechatJSAPIPaymentService(js, userService, wechatConfig)
-	v := newPaymentServices(nativePaymentService, jsapiPaymentService)
+	jsapiPaymentService := ioc.InitWechatJSAPIPaymentService(js, refund, userService, wechatConfig)
+	alipayConfig := initAlipayConfig()
+	pcPaymentService := ioc.InitAlipayPCPaymentService(ali, alipayConfig)
+	wapPaymentService := ioc.InitAlipayWapPaymentService(ali, alipayConfig)
+	v := newPaymentServices(nativePaymentService, jsapiPaymentService, pcPaymentService, wapPaymentService)
 	serviceService := cm.Svc
 	generator := sequencenumber.NewGenerator()
 	db := testioc.InitDB()
@@ -47,8 +51,11 @@ var serviceSet = wire.NewSet(
 	initWechatConfig, wire.FieldsOf(new(*credit.Module), "Svc"), wire.FieldsOf(new(*user.Module), "Svc"), sequencenumber.NewGenerator, testioc.BaseSet, InitDAO, repository.NewPaymentRepository,
 )
 
-func newPaymentServices(n *wechat.NativePaymentService, j *wechat.JSAPIPaymentService) map[payment.ChannelType]service.PaymentService {
-	return map[payment.ChannelType]service.PaymentService{payment.ChannelTypeWechat: n, payment.ChannelTypeWechatJS: j}
+func newPaymentServices(n *wechat.NativePaymentService,
+	j *wechat.JSAPIPaymentService,
+	ap *alipay.PCPaymentService,
+	aw *alipay.WapPaymentService) map[payment.ChannelType]service.PaymentService {
+	return map[payment.ChannelType]service.PaymentService{payment.ChannelTypeWechat: n, payment.ChannelTypeWechatJS: j, payment.ChannelTypeAlipay: ap, payment.ChannelTypeAlipayWap: aw}
 }
 
 var (
@@ -71,3 +78,11 @@ func initWechatConfig() ioc.WechatConfig {
 		PaymentNotifyURL: "MockPaymentNotifyURL",
 	}
 }
+
+func initAlipayConfig() ioc.AlipayConfig {
+	return ioc.AlipayConfig{
+		AppID:            "MockAlipayAppID",
+		PaymentNotifyURL: "MockAlipayNotifyURL",
+		PaymentReturnURL: "MockAlipayReturnURL",
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 var _ ecron.NamedJob = (*SyncWechatOrderJob)(nil)
 
+// SyncWechatOrderJob 同步超时未完成的第三方支付, 除微信外也负责支付宝
 type SyncWechatOrderJob struct {
 	svc     service.Service
 	minutes int64
@@ -62,10 +63,10 @@ func (s *SyncWechatOrderJob) Run(ctx context.Context) error {
 
 		for _, pmt := range payments {
 			_, ok := slice.Find(pmt.Records, func(src domain.PaymentRecord) bool {
-				return src.Channel == domain.ChannelTypeWechat || src.Channel == domain.ChannelTypeWechatJS
+				return src.Channel != domain.ChannelTypeCredit
 			})
 			if !ok {
-				// 非微信支付渠道支付,直接关闭
+				// 仅积分支付,直接关闭
 				err = s.svc.CloseTimeoutPayment(ctx, pmt)
 				if err != nil {
 					s.l.Error("关闭超时支付失败",
@@ -78,7 +79,7 @@ func (s *SyncWechatOrderJob) Run(ctx context.Context) error {
 			}
 			err = s.svc.SyncWechatInfo(ctx, pmt)
 			if err != nil {
-				s.l.Error("同步微信支付信息失败",
+				s.l.Error("同步第三方支付信息失败",
 					elog.FieldErr(err),
 					elog.String("OutTradeNo", pmt.OrderSN),
 				)
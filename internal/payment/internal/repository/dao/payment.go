@@ -26,6 +26,8 @@ import (
 	"gorm.io/gorm"
 )
 
+var ErrRecordNotFound = gorm.ErrRecordNotFound
+
 type PaymentDAO interface {
 	FindOrCreate(ctx context.Context, pmt Payment, records []PaymentRecord) (Payment, []PaymentRecord, error)
 	FindPaymentByID(ctx context.Context, pmtID int64) (Payment, []PaymentRecord, error)
@@ -33,6 +35,7 @@ type PaymentDAO interface {
 	FindPaymentByOrderSN(ctx context.Context, orderSN string) (Payment, []PaymentRecord, error)
 	FindTimeoutPayments(ctx context.Context, offset int, limit int, ctime int64) ([]Payment, error)
 	CountTimeoutPayments(ctx context.Context, ctime int64) (int64, error)
+	FindPaidPayments(ctx context.Context, offset int, limit int, stime, etime int64) ([]Payment, error)
 }
 
 type PaymentGORMDAO struct {
@@ -131,6 +134,16 @@ func (g *PaymentGORMDAO) CountTimeoutPayments(ctx context.Context, ctime int64)
 	return res, err
 }
 
+// FindPaidPayments 查找支付时间在 [stime, etime) 内的已支付记录, 包含之后退款的
+func (g *PaymentGORMDAO) FindPaidPayments(ctx context.Context, offset int, limit int, stime, etime int64) ([]Payment, error) {
+	var res []Payment
+	err := g.db.WithContext(ctx).
+		Where("status IN ? AND paid_at >= ? AND paid_at < ?",
+			[]uint8{domain.PaymentStatusPaidSuccess.ToUint8(), domain.PaymentStatusRefund.ToUint8()}, stime, etime).
+		Order("id").Offset(offset).Limit(limit).Find(&res).Error
+	return res, err
+}
+
 type Payment struct {
 	Id               int64          `gorm:"primaryKey;autoIncrement;comment:支付自增ID"`
 	SN               string         `gorm:"type:varchar(255);not null;uniqueIndex:uniq_payment_sn;comment:支付序列号"`
@@ -139,7 +152,7 @@ type Payment struct {
 	OrderSn          sql.NullString `gorm:"type:varchar(255);uniqueIndex:uniq_order_sn;comment:订单序列号,冗余允许为NULL"`
 	OrderDescription string         `gorm:"type:varchar(255);not null;comment:订单简要描述"`
 	TotalAmount      int64          `gorm:"not null;comment:支付总金额, 多种支付方式支付金额的总和"`
-	PaidAt           int64          `gorm:"comment:支付时间"`
+	PaidAt           int64          `gorm:"index:idx_paid_at;comment:支付时间"`
 	Status           uint8          `gorm:"type:tinyint unsigned;not null;default:1;comment:支付状态 1=未支付 2=已支付 3=已失败"`
 	Ctime            int64
 	Utime            int64
@@ -150,7 +163,7 @@ type PaymentRecord struct {
 	PaymentId    int64          `gorm:"not null;uniqueIndex:unq_idx_payment_id_channel;comment:支付自增ID"`
 	PaymentNO3rd sql.NullString `gorm:"column:payment_no_3rd;type:varchar(255);uniqueIndex:uniq_payment_no_3rd;comment:支付单号, 支付渠道的事务ID"`
 	Description  string         `gorm:"type:varchar(255);not null;comment:本次支付的简要描述"`
-	Channel      uint8          `gorm:"type:tinyint unsigned;not null;default:1;uniqueIndex:unq_idx_payment_id_channel;comment:支付渠道 1=积分, 2=微信"`
+	Channel      uint8          `gorm:"type:tinyint unsigned;not null;default:1;uniqueIndex:unq_idx_payment_id_channel;comment:支付渠道 1=积分, 2=微信, 3=微信小程序, 4=支付宝, 5=支付宝H5"`
 	Amount       int64          `gorm:"not null;comment:支付金额"`
 	PaidAt       int64          `gorm:"comment:支付时间"`
 	Status       uint8          `gorm:"type:tinyint unsigned;not null;default:1;comment:支付状态 1=未支付 2=处理中 3=支付成功 4=支付失败"`
@@ -23,6 +23,8 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/repository/dao"
 )
 
+var ErrRecordNotFound = dao.ErrRecordNotFound
+
 type PaymentRepository interface {
 	CreatePayment(ctx context.Context, payment domain.Payment) (domain.Payment, error)
 	FindPaymentByID(ctx context.Context, pmtID int64) (domain.Payment, error)
@@ -30,6 +32,7 @@ type PaymentRepository interface {
 	FindPaymentByOrderSN(ctx context.Context, orderSN string) (domain.Payment, error)
 	FindTimeoutPayments(ctx context.Context, offset int, limit int, ctime int64) ([]domain.Payment, error)
 	TotalTimeoutPayments(ctx context.Context, ctime int64) (int64, error)
+	FindPaidPayments(ctx context.Context, offset int, limit int, stime, etime int64) ([]domain.Payment, error)
 }
 
 func NewPaymentRepository(d dao.PaymentDAO) PaymentRepository {
@@ -137,3 +140,19 @@ func (p *paymentRepository) FindTimeoutPayments(ctx context.Context, offset int,
 func (p *paymentRepository) TotalTimeoutPayments(ctx context.Context, ctime int64) (int64, error) {
 	return p.dao.CountTimeoutPayments(ctx, ctime)
 }
+
+func (p *paymentRepository) FindPaidPayments(ctx context.Context, offset int, limit int, stime, etime int64) ([]domain.Payment, error) {
+	pmts, err := p.dao.FindPaidPayments(ctx, offset, limit, stime, etime)
+	if err != nil {
+		return nil, err
+	}
+	pp := make([]domain.Payment, 0, len(pmts))
+	for _, pmt := range pmts {
+		pmtDomain, err1 := p.FindPaymentByID(ctx, pmt.Id)
+		if err1 != nil {
+			return nil, err1
+		}
+		pp = append(pp, pmtDomain)
+	}
+	return pp, nil
+}
@@ -0,0 +1,359 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alipay_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/payment/internal/domain"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay/alipaytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAppID = "2021000000000001"
+
+type testEnv struct {
+	gateway *alipaytest.Gateway
+	server  *httptest.Server
+	client  *alipay.Client
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	gateway, err := alipaytest.NewGateway(testAppID, &appKey.PublicKey)
+	require.NoError(t, err)
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return &testEnv{
+		gateway: gateway,
+		server:  server,
+		client:  alipay.NewClient(testAppID, alipaytest.SellerID, appKey, gateway.PublicKey(), server.URL),
+	}
+}
+
+func newPayment(orderSN string, channel domain.ChannelType, amount int64) domain.Payment {
+	return domain.Payment{
+		OrderSN:          orderSN,
+		OrderDescription: "面窝吧",
+		TotalAmount:      amount + 100,
+		Records: []domain.PaymentRecord{
+			{Channel: domain.ChannelTypeCredit, Amount: 100},
+			{Channel: channel, Amount: amount},
+		},
+	}
+}
+
+func TestPCPaymentService(t *testing.T) {
+	env := newTestEnv(t)
+	svc := alipay.NewPCPaymentService(env.client, "https://webook.test/alipay/notify")
+	ctx := context.Background()
+
+	resp, err := svc.Prepay(ctx, newPayment("OrderSN-PC-1", domain.ChannelTypeAlipay, 9900))
+	require.NoError(t, err)
+	trade, ok := env.gateway.Trade("OrderSN-PC-1")
+	require.True(t, ok)
+	assert.Equal(t, "https://qr.alipay.com/"+trade.TradeNo, resp)
+	assert.Equal(t, "99.00", trade.TotalAmount)
+
+	_, err = svc.Prepay(ctx, newPayment("OrderSN-PC-2", domain.ChannelTypeWechat, 9900))
+	assert.Error(t, err)
+
+	// 用户付款后同步
+	require.NoError(t, env.gateway.Pay("OrderSN-PC-1"))
+	pmt, err := svc.QueryOrderBySN(ctx, "OrderSN-PC-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PaymentStatusPaidSuccess, pmt.Status)
+	assert.NotZero(t, pmt.PaidAt)
+	assert.Equal(t, trade.TradeNo, pmt.Records[0].PaymentNO3rd)
+	assert.Equal(t, domain.ChannelTypeAlipay, pmt.Records[0].Channel)
+
+	// 全额退款, 重复退款不会报错
+	paid := newPayment("OrderSN-PC-1", domain.ChannelTypeAlipay, 9900)
+	require.NoError(t, svc.Refund(ctx, paid))
+	require.NoError(t, svc.Refund(ctx, paid))
+	trade, _ = env.gateway.Trade("OrderSN-PC-1")
+	assert.True(t, trade.Refunded)
+	assert.Equal(t, "99.00", trade.RefundAmount)
+	assert.Equal(t, alipaytest.TradeStatusClosed, trade.Status)
+}
+
+func TestPaymentService_QueryOrderBySN(t *testing.T) {
+	env := newTestEnv(t)
+	svc := alipay.NewPCPaymentService(env.client, "")
+	ctx := context.Background()
+
+	testCases := []struct {
+		name            string
+		before          func(t *testing.T, orderSN string)
+		orderSN         string
+		wantStatus      domain.PaymentStatus
+		wantTradeStatus string
+	}{
+		{
+			name:    "交易不存在_视为超时",
+			before:  func(t *testing.T, orderSN string) {},
+			orderSN: "OrderSN-Query-1",
+
+			wantStatus: domain.PaymentStatusTimeoutClosed,
+		},
+		{
+			name: "等待付款_关闭交易并视为超时",
+			before: func(t *testing.T, orderSN string) {
+				_, err := svc.Prepay(ctx, newPayment(orderSN, domain.ChannelTypeAlipay, 1))
+				require.NoError(t, err)
+			},
+			orderSN:         "OrderSN-Query-2",
+			wantStatus:      domain.PaymentStatusTimeoutClosed,
+			wantTradeStatus: alipaytest.TradeStatusClosed,
+		},
+		{
+			name: "交易已关闭_支付失败",
+			before: func(t *testing.T, orderSN string) {
+				_, err := svc.Prepay(ctx, newPayment(orderSN, domain.ChannelTypeAlipay, 1))
+				require.NoError(t, err)
+				require.NoError(t, svc.Close(ctx, orderSN))
+			},
+			orderSN:         "OrderSN-Query-3",
+			wantStatus:      domain.PaymentStatusPaidFailed,
+			wantTradeStatus: alipaytest.TradeStatusClosed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.before(t, tc.orderSN)
+			pmt, err := svc.QueryOrderBySN(ctx, tc.orderSN)
+			require.NoError(t, err)
+			assert.Equal(t, tc.orderSN, pmt.OrderSN)
+			assert.Equal(t, tc.wantStatus, pmt.Status)
+			trade, _ := env.gateway.Trade(tc.orderSN)
+			assert.Equal(t, tc.wantTradeStatus, trade.Status)
+		})
+	}
+}
+
+func TestWapPaymentService_Prepay(t *testing.T) {
+	env := newTestEnv(t)
+	svc := alipay.NewWapPaymentService(env.client, "https://webook.test/alipay/notify", "https://webook.test/order")
+
+	resp, err := svc.Prepay(context.Background(), newPayment("OrderSN-Wap-1", domain.ChannelTypeAlipayWap, 1234))
+	require.NoError(t, err)
+	payURL, ok := resp.(string)
+	require.True(t, ok)
+	u, err := url.Parse(payURL)
+	require.NoError(t, err)
+	assert.Equal(t, "alipay.trade.wap.pay", u.Query().Get("method"))
+	assert.Equal(t, "https://webook.test/order", u.Query().Get("return_url"))
+
+	// 收银台链接由网关验签, 打开后创建交易
+	r, err := http.Get(payURL)
+	require.NoError(t, err)
+	_ = r.Body.Close()
+	trade, ok := env.gateway.Trade("OrderSN-Wap-1")
+	require.True(t, ok)
+	assert.Equal(t, "12.34", trade.TotalAmount)
+	assert.Equal(t, alipaytest.TradeStatusWaitBuyerPay, trade.Status)
+}
+
+func TestClient_ParseNotification(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	_, err := env.client.TradePrecreate(ctx, alipay.TradePrecreateRequest{
+		OutTradeNo:  "OrderSN-Notify-1",
+		TotalAmount: "0.01",
+		Subject:     "面窝吧",
+	})
+	require.NoError(t, err)
+	require.NoError(t, env.gateway.Pay("OrderSN-Notify-1"))
+	trade, _ := env.gateway.Trade("OrderSN-Notify-1")
+
+	testCases := []struct {
+		name    string
+		newReq  func(t *testing.T) *http.Request
+		client  *alipay.Client
+		want    alipay.Notification
+		wantErr bool
+	}{
+		{
+			name: "验签成功",
+			newReq: func(t *testing.T) *http.Request {
+				req, err := env.gateway.NotifyRequest(env.server.URL, "OrderSN-Notify-1")
+				require.NoError(t, err)
+				return req
+			},
+			client: env.client,
+			want: alipay.Notification{
+				AppID:       testAppID,
+				SellerID:    alipaytest.SellerID,
+				TradeNo:     trade.TradeNo,
+				OutTradeNo:  "OrderSN-Notify-1",
+				TradeStatus: alipaytest.TradeStatusSuccess,
+				TotalAmount: "0.01",
+			},
+		},
+		{
+			name: "内容被篡改",
+			newReq: func(t *testing.T) *http.Request {
+				req, err := env.gateway.NotifyRequest(env.server.URL, "OrderSN-Notify-1")
+				require.NoError(t, err)
+				require.NoError(t, req.ParseForm())
+				req.PostForm.Set("total_amount", "999.00")
+				return req
+			},
+			client:  env.client,
+			wantErr: true,
+		},
+		{
+			name: "应用ID不匹配",
+			newReq: func(t *testing.T) *http.Request {
+				req, err := env.gateway.NotifyRequest(env.server.URL, "OrderSN-Notify-1")
+				require.NoError(t, err)
+				return req
+			},
+			client: func() *alipay.Client {
+				key, err := rsa.GenerateKey(rand.Reader, 2048)
+				require.NoError(t, err)
+				return alipay.NewClient("other-app", alipaytest.SellerID, key, env.gateway.PublicKey(), env.server.URL)
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "卖家ID不匹配",
+			newReq: func(t *testing.T) *http.Request {
+				req, err := env.gateway.NotifyRequest(env.server.URL, "OrderSN-Notify-1")
+				require.NoError(t, err)
+				return req
+			},
+			client: func() *alipay.Client {
+				key, err := rsa.GenerateKey(rand.Reader, 2048)
+				require.NoError(t, err)
+				return alipay.NewClient(testAppID, "2088000000000002", key, env.gateway.PublicKey(), env.server.URL)
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := tc.client.ParseNotification(tc.newReq(t))
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, n.NotifyID)
+			n.NotifyID = ""
+			assert.Equal(t, tc.want, n)
+		})
+	}
+}
+
+func TestClient_VerifyResponse(t *testing.T) {
+	env := newTestEnv(t)
+	appKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// 网关不认识这个应用的公钥, 请求验签失败
+	client := alipay.NewClient(testAppID, alipaytest.SellerID, appKey, env.gateway.PublicKey(), env.server.URL)
+	_, err = client.TradeQuery(context.Background(), "OrderSN-Verify-1")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, alipay.ErrTradeNotExist)
+
+	// 密钥正确时拿到的是业务错误
+	_, err = env.client.TradeQuery(context.Background(), "OrderSN-Verify-1")
+	require.ErrorIs(t, err, alipay.ErrTradeNotExist)
+
+	// 支付宝公钥不对, 响应验签失败
+	client = alipay.NewClient(testAppID, alipaytest.SellerID, appKey, &otherKey.PublicKey, env.server.URL)
+	_, err = client.TradeQuery(context.Background(), "OrderSN-Verify-1")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, alipay.ErrTradeNotExist)
+}
+
+func TestParseKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	for _, data := range [][]byte{
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+		[]byte(base64.StdEncoding.EncodeToString(pkcs8)),
+	} {
+		k, err := alipay.ParsePrivateKey(data)
+		require.NoError(t, err)
+		assert.True(t, key.Equal(k))
+	}
+	for _, data := range [][]byte{
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}),
+		[]byte(base64.StdEncoding.EncodeToString(pub) + "\n"),
+	} {
+		k, err := alipay.ParsePublicKey(data)
+		require.NoError(t, err)
+		assert.True(t, key.PublicKey.Equal(k))
+	}
+	_, err = alipay.ParsePrivateKey([]byte("abc"))
+	assert.Error(t, err)
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "0.01", alipay.FormatAmount(1))
+	assert.Equal(t, "99.00", alipay.FormatAmount(9900))
+	assert.Equal(t, "12.34", alipay.FormatAmount(1234))
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		amount  string
+		want    int64
+		wantErr bool
+	}{
+		{amount: "99.00", want: 9900},
+		{amount: "0.01", want: 1},
+		{amount: "12.3", want: 1230},
+		{amount: "100", want: 10000},
+		{amount: "1.234", wantErr: true},
+		{amount: "-1.00", wantErr: true},
+		{amount: ".50", wantErr: true},
+		{amount: "abc", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.amount, func(t *testing.T) {
+			got, err := alipay.ParseAmount(tc.amount)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alipaytest 提供本地的支付宝假网关, 用于测试支付宝支付渠道
+package alipaytest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
+)
+
+// SellerID 假网关的商户ID, 会放在异步通知里面
+const SellerID = "2088000000000001"
+
+const (
+	TradeStatusWaitBuyerPay = "WAIT_BUYER_PAY"
+	TradeStatusClosed       = "TRADE_CLOSED"
+	TradeStatusSuccess      = "TRADE_SUCCESS"
+)
+
+type Trade struct {
+	OutTradeNo   string
+	TradeNo      string
+	TotalAmount  string
+	Status       string
+	RefundAmount string
+	OutRequestNo string
+	// Refunded 是否退过款, 用于构造退款通知
+	Refunded bool
+}
+
+// Gateway 模拟支付宝网关
+// 校验应用的请求签名, 用自己的私钥签名响应和异步通知, 交易数据保存在内存中
+type Gateway struct {
+	appID        string
+	appPublicKey *rsa.PublicKey
+	privateKey   *rsa.PrivateKey
+
+	mu     sync.Mutex
+	seq    int
+	trades map[string]*Trade
+}
+
+func NewGateway(appID string, appPublicKey *rsa.PublicKey) (*Gateway, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Gateway{
+		appID:        appID,
+		appPublicKey: appPublicKey,
+		privateKey:   key,
+		trades:       make(map[string]*Trade),
+	}, nil
+}
+
+// PublicKey 支付宝公钥, 客户端用来验签
+func (g *Gateway) PublicKey() *rsa.PublicKey {
+	return &g.privateKey.PublicKey
+}
+
+// Trade 查看交易的当前状态
+func (g *Gateway) Trade(outTradeNo string) (Trade, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t, ok := g.trades[outTradeNo]
+	if !ok {
+		return Trade{}, false
+	}
+	return *t, true
+}
+
+// Pay 模拟用户完成付款
+func (g *Gateway) Pay(outTradeNo string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t, ok := g.trades[outTradeNo]
+	if !ok || t.Status != TradeStatusWaitBuyerPay {
+		return fmt.Errorf("交易不存在或不可支付: %s", outTradeNo)
+	}
+	t.Status = TradeStatusSuccess
+	return nil
+}
+
+// NotifyRequest 按交易的当前状态构造一个签过名的异步通知请求
+func (g *Gateway) NotifyRequest(notifyURL, outTradeNo string) (*http.Request, error) {
+	t, ok := g.Trade(outTradeNo)
+	if !ok {
+		return nil, fmt.Errorf("交易不存在: %s", outTradeNo)
+	}
+	values := url.Values{}
+	values.Set("notify_time", alipay.FormatTime(time.Now()))
+	values.Set("notify_type", "trade_status_sync")
+	values.Set("notify_id", fmt.Sprintf("notify-%s-%d", t.TradeNo, time.Now().UnixNano()))
+	values.Set("app_id", g.appID)
+	values.Set("seller_id", SellerID)
+	values.Set("charset", "utf-8")
+	values.Set("version", "1.0")
+	values.Set("trade_no", t.TradeNo)
+	values.Set("out_trade_no", t.OutTradeNo)
+	values.Set("trade_status", t.Status)
+	values.Set("total_amount", t.TotalAmount)
+	if t.Refunded {
+		values.Set("refund_fee", t.RefundAmount)
+		values.Set("gmt_refund", alipay.FormatTime(time.Now()))
+	}
+	sign, err := alipay.Sign(g.privateKey, alipay.SignContent(values))
+	if err != nil {
+		return nil, err
+	}
+	values.Set("sign_type", "RSA2")
+	values.Set("sign", sign)
+
+	req, err := http.NewRequest(http.MethodPost, notifyURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
+	return req, nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	values := r.Form
+	method := values.Get("method")
+	if err := g.verifyRequest(values); err != nil {
+		g.writeResponse(w, "error_response", "40002", "Invalid Arguments", "isv.invalid-signature", err.Error(), nil)
+		return
+	}
+
+	var biz map[string]string
+	if err := json.Unmarshal([]byte(values.Get("biz_content")), &biz); err != nil {
+		g.writeResponse(w, g.responseKey(method), "40004", "Business Failed", "ACQ.INVALID_PARAMETER", err.Error(), nil)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch method {
+	case "alipay.trade.precreate":
+		t := g.createTrade(biz)
+		g.writeSuccess(w, method, map[string]any{
+			"out_trade_no": t.OutTradeNo,
+			"qr_code":      "https://qr.alipay.com/" + t.TradeNo,
+		})
+	case "alipay.trade.wap.pay":
+		// 浏览器跳转到收银台, 此时才创建交易
+		g.createTrade(biz)
+		_, _ = w.Write([]byte("支付宝收银台"))
+	case "alipay.trade.query":
+		t, ok := g.trades[biz["out_trade_no"]]
+		if !ok {
+			g.writeTradeNotExist(w, method)
+			return
+		}
+		g.writeSuccess(w, method, map[string]any{
+			"trade_no":     t.TradeNo,
+			"out_trade_no": t.OutTradeNo,
+			"trade_status": t.Status,
+			"total_amount": t.TotalAmount,
+		})
+	case "alipay.trade.close":
+		t, ok := g.trades[biz["out_trade_no"]]
+		if !ok {
+			g.writeTradeNotExist(w, method)
+			return
+		}
+		if t.Status != TradeStatusWaitBuyerPay {
+			g.writeResponse(w, g.responseKey(method), "40004", "Business Failed", "ACQ.TRADE_STATUS_ERROR", "交易状态不合法", nil)
+			return
+		}
+		t.Status = TradeStatusClosed
+		g.writeSuccess(w, method, map[string]any{
+			"trade_no":     t.TradeNo,
+			"out_trade_no": t.OutTradeNo,
+		})
+	case "alipay.trade.refund":
+		g.refund(w, method, biz)
+	default:
+		g.writeResponse(w, "error_response", "40004", "Business Failed", "isv.invalid-method", method, nil)
+	}
+}
+
+func (g *Gateway) refund(w http.ResponseWriter, method string, biz map[string]string) {
+	t, ok := g.trades[biz["out_trade_no"]]
+	if !ok {
+		g.writeTradeNotExist(w, method)
+		return
+	}
+	fundChange := "Y"
+	switch {
+	case t.Refunded && t.OutRequestNo == biz["out_request_no"]:
+		// 同一个退款请求号重复请求, 不会重复退款
+		fundChange = "N"
+	case t.Status != TradeStatusSuccess:
+		g.writeResponse(w, g.responseKey(method), "40004", "Business Failed", "ACQ.TRADE_STATUS_ERROR", "交易状态不合法", nil)
+		return
+	case biz["refund_amount"] != t.TotalAmount:
+		g.writeResponse(w, g.responseKey(method), "40004", "Business Failed", "ACQ.REFUND_AMT_NOT_EQUAL_TOTAL", "退款金额超限", nil)
+		return
+	default:
+		t.Refunded = true
+		t.RefundAmount = biz["refund_amount"]
+		t.OutRequestNo = biz["out_request_no"]
+		t.Status = TradeStatusClosed
+	}
+	g.writeSuccess(w, method, map[string]any{
+		"trade_no":     t.TradeNo,
+		"out_trade_no": t.OutTradeNo,
+		"refund_fee":   t.RefundAmount,
+		"fund_change":  fundChange,
+	})
+}
+
+func (g *Gateway) createTrade(biz map[string]string) *Trade {
+	if t, ok := g.trades[biz["out_trade_no"]]; ok {
+		return t
+	}
+	g.seq++
+	t := &Trade{
+		OutTradeNo:  biz["out_trade_no"],
+		TradeNo:     fmt.Sprintf("2024%012d", g.seq),
+		TotalAmount: biz["total_amount"],
+		Status:      TradeStatusWaitBuyerPay,
+	}
+	g.trades[t.OutTradeNo] = t
+	return t
+}
+
+func (g *Gateway) verifyRequest(values url.Values) error {
+	if values.Get("app_id") != g.appID {
+		return fmt.Errorf("应用ID不匹配: %s", values.Get("app_id"))
+	}
+	return alipay.Verify(g.appPublicKey, alipay.SignContent(values, "sign"), values.Get("sign"))
+}
+
+func (g *Gateway) responseKey(method string) string {
+	return strings.ReplaceAll(method, ".", "_") + "_response"
+}
+
+func (g *Gateway) writeTradeNotExist(w http.ResponseWriter, method string) {
+	g.writeResponse(w, g.responseKey(method), "40004", "Business Failed", "ACQ.TRADE_NOT_EXIST", "交易不存在", nil)
+}
+
+func (g *Gateway) writeSuccess(w http.ResponseWriter, method string, data map[string]any) {
+	g.writeResponse(w, g.responseKey(method), "10000", "Success", "", "", data)
+}
+
+func (g *Gateway) writeResponse(w http.ResponseWriter, key, code, msg, subCode, subMsg string, data map[string]any) {
+	if data == nil {
+		data = make(map[string]any)
+	}
+	data["code"] = code
+	data["msg"] = msg
+	if subCode != "" {
+		data["sub_code"] = subCode
+		data["sub_msg"] = subMsg
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sign, err := alipay.Sign(g.privateKey, string(content))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	signJSON, _ := json.Marshal(sign)
+	keyJSON, _ := json.Marshal(key)
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	_, _ = fmt.Fprintf(w, `{%s:%s,"sign":%s}`, keyJSON, content, signJSON)
+}
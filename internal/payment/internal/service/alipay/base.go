@@ -0,0 +1,163 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alipay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/payment/internal/domain"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+var (
+	// TradeStatus2PaymentStatus 支付宝 PC 扫码和 H5 共用
+	TradeStatus2PaymentStatus = map[string]domain.PaymentStatus{
+		"WAIT_BUYER_PAY": domain.PaymentStatusUnpaid,      // 交易创建,等待买家付款
+		"TRADE_CLOSED":   domain.PaymentStatusPaidFailed,  // 未付款交易超时关闭,或支付完成后全额退款
+		"TRADE_SUCCESS":  domain.PaymentStatusPaidSuccess, // 交易支付成功
+		"TRADE_FINISHED": domain.PaymentStatusPaidSuccess, // 交易结束,不可退款
+	}
+	errUnknownTradeStatus = errors.New("未知的支付宝交易状态")
+)
+
+//go:generate mockgen -source=./base.go -package=alipaymocks -destination=./mocks/alipay.mock.go -typed APIService
+type APIService interface {
+	TradePrecreate(ctx context.Context, req TradePrecreateRequest) (TradePrecreateResponse, error)
+	TradeWapPay(ctx context.Context, req TradeWapPayRequest) (string, error)
+	TradeQuery(ctx context.Context, outTradeNo string) (TradeQueryResponse, error)
+	TradeClose(ctx context.Context, outTradeNo string) error
+	TradeRefund(ctx context.Context, req TradeRefundRequest) (TradeRefundResponse, error)
+}
+
+type basePaymentService struct {
+	l   *elog.Component
+	svc APIService
+
+	name domain.ChannelType
+	desc string
+
+	notifyURL string
+}
+
+func (b *basePaymentService) Name() domain.ChannelType {
+	return b.name
+}
+
+func (b *basePaymentService) Desc() string {
+	return b.desc
+}
+
+func (b *basePaymentService) findRecord(pmt domain.Payment) (domain.PaymentRecord, error) {
+	r, ok := slice.Find(pmt.Records, func(src domain.PaymentRecord) bool {
+		return src.Channel == b.name
+	})
+	if !ok || r.Amount == 0 {
+		return domain.PaymentRecord{}, fmt.Errorf("缺少支付宝支付金额信息")
+	}
+	return r, nil
+}
+
+func (b *basePaymentService) timeExpire() string {
+	return FormatTime(time.Now().Add(time.Minute * 30))
+}
+
+// QueryOrderBySN 同步信息 定时任务调用此方法同步状态信息
+func (b *basePaymentService) QueryOrderBySN(ctx context.Context, orderSN string) (domain.Payment, error) {
+	resp, err := b.svc.TradeQuery(ctx, orderSN)
+	if errors.Is(err, ErrTradeNotExist) {
+		// 用户从未扫码或打开支付页面,支付宝那边没有交易
+		return b.convertToPaymentDomain(orderSN, "", domain.PaymentStatusTimeoutClosed), nil
+	}
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	status, err := GetPaymentStatus(resp.TradeStatus)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if status != domain.PaymentStatusPaidSuccess && status != domain.PaymentStatusPaidFailed {
+		// 主动同步时不再忽略,而是直接标记为超时
+		// 标记之前先关闭支付宝交易,防止用户之后仍能付款
+		err = b.Close(ctx, orderSN)
+		if err != nil {
+			return domain.Payment{}, err
+		}
+		status = domain.PaymentStatusTimeoutClosed
+	}
+	return b.convertToPaymentDomain(resp.OutTradeNo, resp.TradeNo, status), nil
+}
+
+// Close 关闭交易, 交易不存在视为关闭成功
+func (b *basePaymentService) Close(ctx context.Context, orderSN string) error {
+	err := b.svc.TradeClose(ctx, orderSN)
+	if err != nil && !errors.Is(err, ErrTradeNotExist) {
+		return fmt.Errorf("关闭支付宝交易失败: %w", err)
+	}
+	return nil
+}
+
+// Refund 全额退款
+// 退款请求号直接使用订单SN,一笔订单只退一次,重复请求时支付宝不会重复退款
+func (b *basePaymentService) Refund(ctx context.Context, pmt domain.Payment) error {
+	r, err := b.findRecord(pmt)
+	if err != nil {
+		return err
+	}
+	_, err = b.svc.TradeRefund(ctx, TradeRefundRequest{
+		OutTradeNo:   pmt.OrderSN,
+		RefundAmount: FormatAmount(r.Amount),
+		OutRequestNo: pmt.OrderSN,
+		RefundReason: "订单退款",
+	})
+	if err != nil {
+		return fmt.Errorf("支付宝退款失败: %w", err)
+	}
+	return nil
+}
+
+func (b *basePaymentService) convertToPaymentDomain(orderSN, tradeNo string, status domain.PaymentStatus) domain.Payment {
+	// 更新支付主记录+支付宝渠道支付记录两条数据的状态
+	var paidAt int64
+	if status == domain.PaymentStatusPaidSuccess {
+		paidAt = time.Now().UnixMilli()
+	}
+	return domain.Payment{
+		OrderSN: orderSN,
+		PaidAt:  paidAt,
+		Status:  status,
+		Records: []domain.PaymentRecord{
+			{
+				PaymentNO3rd: tradeNo,
+				Channel:      b.name,
+				PaidAt:       paidAt,
+				Status:       status,
+			},
+		},
+	}
+}
+
+func GetPaymentStatus(tradeStatus string) (domain.PaymentStatus, error) {
+	status, ok := TradeStatus2PaymentStatus[tradeStatus]
+	if !ok {
+		return 0, fmt.Errorf("%w, %s", errUnknownTradeStatus, tradeStatus)
+	}
+	return status, nil
+}
@@ -0,0 +1,397 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alipay
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultGatewayURL = "https://openapi.alipay.com/gateway.do"
+
+	successCode          = "10000"
+	tradeNotExistSubCode = "ACQ.TRADE_NOT_EXIST"
+	timeLayout           = "2006-01-02 15:04:05"
+)
+
+var (
+	ErrTradeNotExist  = errors.New("支付宝交易不存在")
+	errInvalidSign    = errors.New("支付宝签名校验失败")
+	errInvalidAppID   = errors.New("支付宝应用ID不匹配")
+	errInvalidSeller  = errors.New("支付宝卖家ID不匹配")
+	errInvalidAmount  = errors.New("非法的支付宝金额")
+	errInvalidKeyData = errors.New("非法的RSA密钥")
+
+	// 支付宝接口统一使用北京时间
+	beijing = time.FixedZone("CST", 8*3600)
+)
+
+// Client 支付宝开放平台网关客户端, 只实现了用到的几个交易接口
+// 请求及响应均使用 RSA2(SHA256WithRSA) 签名
+type Client struct {
+	appID           string
+	sellerID        string
+	privateKey      *rsa.PrivateKey
+	alipayPublicKey *rsa.PublicKey
+	gatewayURL      string
+	httpClient      *http.Client
+}
+
+func NewClient(appID string, sellerID string, privateKey *rsa.PrivateKey, alipayPublicKey *rsa.PublicKey, gatewayURL string) *Client {
+	if gatewayURL == "" {
+		gatewayURL = DefaultGatewayURL
+	}
+	return &Client{
+		appID:           appID,
+		sellerID:        sellerID,
+		privateKey:      privateKey,
+		alipayPublicKey: alipayPublicKey,
+		gatewayURL:      gatewayURL,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type TradePrecreateRequest struct {
+	OutTradeNo  string `json:"out_trade_no"`
+	TotalAmount string `json:"total_amount"`
+	Subject     string `json:"subject"`
+	// TimeExpire 绝对超时时间, 格式为 yyyy-MM-dd HH:mm:ss
+	TimeExpire string `json:"time_expire,omitempty"`
+	NotifyURL  string `json:"-"`
+}
+
+type TradePrecreateResponse struct {
+	OutTradeNo string `json:"out_trade_no"`
+	// QRCode 二维码内容, 前端据此生成二维码供用户扫码
+	QRCode string `json:"qr_code"`
+}
+
+type TradeWapPayRequest struct {
+	OutTradeNo  string `json:"out_trade_no"`
+	TotalAmount string `json:"total_amount"`
+	Subject     string `json:"subject"`
+	ProductCode string `json:"product_code"`
+	TimeExpire  string `json:"time_expire,omitempty"`
+	NotifyURL   string `json:"-"`
+	ReturnURL   string `json:"-"`
+}
+
+type TradeQueryResponse struct {
+	TradeNo     string `json:"trade_no"`
+	OutTradeNo  string `json:"out_trade_no"`
+	TradeStatus string `json:"trade_status"`
+	TotalAmount string `json:"total_amount"`
+}
+
+type TradeRefundRequest struct {
+	OutTradeNo   string `json:"out_trade_no"`
+	RefundAmount string `json:"refund_amount"`
+	// OutRequestNo 退款请求号, 同一笔交易多次退款时用于区分, 重复请求时幂等
+	OutRequestNo string `json:"out_request_no"`
+	RefundReason string `json:"refund_reason,omitempty"`
+}
+
+type TradeRefundResponse struct {
+	TradeNo    string `json:"trade_no"`
+	OutTradeNo string `json:"out_trade_no"`
+	RefundFee  string `json:"refund_fee"`
+	// FundChange 本次退款是否发生了资金变化, 重复请求时为 N
+	FundChange string `json:"fund_change"`
+}
+
+type commonResponse struct {
+	Code    string `json:"code"`
+	Msg     string `json:"msg"`
+	SubCode string `json:"sub_code"`
+	SubMsg  string `json:"sub_msg"`
+}
+
+// TradePrecreate 统一收单线下交易预创建, 即PC端扫码支付
+func (c *Client) TradePrecreate(ctx context.Context, req TradePrecreateRequest) (TradePrecreateResponse, error) {
+	var res TradePrecreateResponse
+	err := c.do(ctx, "alipay.trade.precreate", req.NotifyURL, req, &res)
+	return res, err
+}
+
+// TradeWapPay 手机网站支付, 只在本地签名生成跳转链接, 不访问网关
+func (c *Client) TradeWapPay(_ context.Context, req TradeWapPayRequest) (string, error) {
+	values, err := c.buildParams("alipay.trade.wap.pay", req.NotifyURL, req)
+	if err != nil {
+		return "", err
+	}
+	if req.ReturnURL != "" {
+		values.Set("return_url", req.ReturnURL)
+	}
+	if err = c.sign(values); err != nil {
+		return "", err
+	}
+	return c.gatewayURL + "?" + values.Encode(), nil
+}
+
+// TradeQuery 统一收单线下交易查询
+func (c *Client) TradeQuery(ctx context.Context, outTradeNo string) (TradeQueryResponse, error) {
+	var res TradeQueryResponse
+	err := c.do(ctx, "alipay.trade.query", "", map[string]string{"out_trade_no": outTradeNo}, &res)
+	return res, err
+}
+
+// TradeClose 统一收单交易关闭
+func (c *Client) TradeClose(ctx context.Context, outTradeNo string) error {
+	var res commonResponse
+	return c.do(ctx, "alipay.trade.close", "", map[string]string{"out_trade_no": outTradeNo}, &res)
+}
+
+// TradeRefund 统一收单交易退款
+func (c *Client) TradeRefund(ctx context.Context, req TradeRefundRequest) (TradeRefundResponse, error) {
+	var res TradeRefundResponse
+	err := c.do(ctx, "alipay.trade.refund", "", req, &res)
+	return res, err
+}
+
+func (c *Client) do(ctx context.Context, method, notifyURL string, bizContent any, res any) error {
+	values, err := c.buildParams(method, notifyURL, bizContent)
+	if err != nil {
+		return err
+	}
+	if err = c.sign(values); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.gatewayURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求支付宝网关失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// 响应形如 {"alipay_trade_query_response": {...}, "sign": "..."}
+	// 签名针对的是响应节点的原始内容, 所以这里用 RawMessage 保留原始字节
+	var raw map[string]json.RawMessage
+	if err = json.Unmarshal(body, &raw); err != nil {
+		return fmt.Errorf("解析支付宝响应失败: %w, body: %s", err, body)
+	}
+	content, ok := raw[strings.ReplaceAll(method, ".", "_")+"_response"]
+	if !ok {
+		// 网关层面的错误,比如应用ID不存在
+		content = raw["error_response"]
+	}
+	var common commonResponse
+	if err = json.Unmarshal(content, &common); err != nil {
+		return fmt.Errorf("解析支付宝响应失败: %w, body: %s", err, body)
+	}
+
+	var sign string
+	_ = json.Unmarshal(raw["sign"], &sign)
+	// 失败时支付宝可能不返回签名, 成功的响应必须验签
+	if sign != "" || common.Code == successCode {
+		if err = c.verify(content, sign); err != nil {
+			return err
+		}
+	}
+
+	if common.Code != successCode {
+		if common.SubCode == tradeNotExistSubCode {
+			return fmt.Errorf("%w: %s", ErrTradeNotExist, common.SubMsg)
+		}
+		return fmt.Errorf("调用支付宝接口%s失败, code: %s, msg: %s, sub_code: %s, sub_msg: %s",
+			method, common.Code, common.Msg, common.SubCode, common.SubMsg)
+	}
+	return json.Unmarshal(content, res)
+}
+
+func (c *Client) buildParams(method, notifyURL string, bizContent any) (url.Values, error) {
+	biz, err := json.Marshal(bizContent)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("app_id", c.appID)
+	values.Set("method", method)
+	values.Set("format", "JSON")
+	values.Set("charset", "utf-8")
+	values.Set("sign_type", "RSA2")
+	values.Set("timestamp", time.Now().In(beijing).Format(timeLayout))
+	values.Set("version", "1.0")
+	values.Set("biz_content", string(biz))
+	if notifyURL != "" {
+		values.Set("notify_url", notifyURL)
+	}
+	return values, nil
+}
+
+func (c *Client) sign(values url.Values) error {
+	sign, err := Sign(c.privateKey, SignContent(values, "sign"))
+	if err != nil {
+		return fmt.Errorf("支付宝请求签名失败: %w", err)
+	}
+	values.Set("sign", sign)
+	return nil
+}
+
+func (c *Client) verify(content []byte, sign string) error {
+	return Verify(c.alipayPublicKey, string(content), sign)
+}
+
+// SignContent 待签名字符串, 参数按key升序排列, 忽略空值和 excludes 中的参数
+func SignContent(values url.Values, excludes ...string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if values.Get(k) == "" || slices.Contains(excludes, k) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(values.Get(k))
+	}
+	return sb.String()
+}
+
+// Sign 使用 SHA256WithRSA 签名并进行 base64 编码
+func Sign(key *rsa.PrivateKey, content string) (string, error) {
+	h := sha256.Sum256([]byte(content))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify 校验 SHA256WithRSA 签名
+func Verify(key *rsa.PublicKey, content, sign string) error {
+	sig, err := base64.StdEncoding.DecodeString(sign)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidSign, err)
+	}
+	h := sha256.Sum256([]byte(content))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, h[:], sig); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidSign, err)
+	}
+	return nil
+}
+
+// ParsePrivateKey 解析应用私钥, 支持 PEM 格式及支付宝密钥工具生成的 base64 格式, PKCS1 和 PKCS8 均可
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	der, err := decodeKeyData(data)
+	if err != nil {
+		return nil, err
+	}
+	if key, err1 := x509.ParsePKCS1PrivateKey(der); err1 == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidKeyData, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: 不是RSA私钥", errInvalidKeyData)
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey 解析支付宝公钥, 支持 PEM 格式及 base64 格式
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	der, err := decodeKeyData(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidKeyData, err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: 不是RSA公钥", errInvalidKeyData)
+	}
+	return rsaKey, nil
+}
+
+func decodeKeyData(data []byte) ([]byte, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		return block.Bytes, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidKeyData, err)
+	}
+	return der, nil
+}
+
+// FormatAmount 将以分为单位的金额转换为支付宝要求的以元为单位的字符串
+func FormatAmount(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+// ParseAmount 将支付宝的金额(元, 最多两位小数)转换为分
+func ParseAmount(amount string) (int64, error) {
+	yuan, cents, found := strings.Cut(amount, ".")
+	if !found {
+		cents = "00"
+	}
+	if yuan == "" || len(cents) == 0 || len(cents) > 2 {
+		return 0, fmt.Errorf("%w: %s", errInvalidAmount, amount)
+	}
+	if len(cents) == 1 {
+		cents += "0"
+	}
+	y, err := strconv.ParseUint(yuan, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errInvalidAmount, amount)
+	}
+	c, err := strconv.ParseUint(cents, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errInvalidAmount, amount)
+	}
+	return int64(y)*100 + int64(c), nil
+}
+
+// FormatTime 转换为支付宝要求的时间格式
+func FormatTime(t time.Time) string {
+	return t.In(beijing).Format(timeLayout)
+}
@@ -0,0 +1,236 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./base.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./base.go -package=alipaymocks -destination=./mocks/alipay.mock.go -typed APIService
+//
+
+// Package alipaymocks is a generated GoMock package.
+package alipaymocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	alipay "github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIService is a mock of APIService interface.
+type MockAPIService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIServiceMockRecorder is the mock recorder for MockAPIService.
+type MockAPIServiceMockRecorder struct {
+	mock *MockAPIService
+}
+
+// NewMockAPIService creates a new mock instance.
+func NewMockAPIService(ctrl *gomock.Controller) *MockAPIService {
+	mock := &MockAPIService{ctrl: ctrl}
+	mock.recorder = &MockAPIServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIService) EXPECT() *MockAPIServiceMockRecorder {
+	return m.recorder
+}
+
+// TradeClose mocks base method.
+func (m *MockAPIService) TradeClose(ctx context.Context, outTradeNo string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradeClose", ctx, outTradeNo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TradeClose indicates an expected call of TradeClose.
+func (mr *MockAPIServiceMockRecorder) TradeClose(ctx, outTradeNo any) *MockAPIServiceTradeCloseCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradeClose", reflect.TypeOf((*MockAPIService)(nil).TradeClose), ctx, outTradeNo)
+	return &MockAPIServiceTradeCloseCall{Call: call}
+}
+
+// MockAPIServiceTradeCloseCall wrap *gomock.Call
+type MockAPIServiceTradeCloseCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAPIServiceTradeCloseCall) Return(arg0 error) *MockAPIServiceTradeCloseCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAPIServiceTradeCloseCall) Do(f func(context.Context, string) error) *MockAPIServiceTradeCloseCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAPIServiceTradeCloseCall) DoAndReturn(f func(context.Context, string) error) *MockAPIServiceTradeCloseCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// TradePrecreate mocks base method.
+func (m *MockAPIService) TradePrecreate(ctx context.Context, req alipay.TradePrecreateRequest) (alipay.TradePrecreateResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradePrecreate", ctx, req)
+	ret0, _ := ret[0].(alipay.TradePrecreateResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TradePrecreate indicates an expected call of TradePrecreate.
+func (mr *MockAPIServiceMockRecorder) TradePrecreate(ctx, req any) *MockAPIServiceTradePrecreateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradePrecreate", reflect.TypeOf((*MockAPIService)(nil).TradePrecreate), ctx, req)
+	return &MockAPIServiceTradePrecreateCall{Call: call}
+}
+
+// MockAPIServiceTradePrecreateCall wrap *gomock.Call
+type MockAPIServiceTradePrecreateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAPIServiceTradePrecreateCall) Return(arg0 alipay.TradePrecreateResponse, arg1 error) *MockAPIServiceTradePrecreateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAPIServiceTradePrecreateCall) Do(f func(context.Context, alipay.TradePrecreateRequest) (alipay.TradePrecreateResponse, error)) *MockAPIServiceTradePrecreateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAPIServiceTradePrecreateCall) DoAndReturn(f func(context.Context, alipay.TradePrecreateRequest) (alipay.TradePrecreateResponse, error)) *MockAPIServiceTradePrecreateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// TradeQuery mocks base method.
+func (m *MockAPIService) TradeQuery(ctx context.Context, outTradeNo string) (alipay.TradeQueryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradeQuery", ctx, outTradeNo)
+	ret0, _ := ret[0].(alipay.TradeQueryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TradeQuery indicates an expected call of TradeQuery.
+func (mr *MockAPIServiceMockRecorder) TradeQuery(ctx, outTradeNo any) *MockAPIServiceTradeQueryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradeQuery", reflect.TypeOf((*MockAPIService)(nil).TradeQuery), ctx, outTradeNo)
+	return &MockAPIServiceTradeQueryCall{Call: call}
+}
+
+// MockAPIServiceTradeQueryCall wrap *gomock.Call
+type MockAPIServiceTradeQueryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAPIServiceTradeQueryCall) Return(arg0 alipay.TradeQueryResponse, arg1 error) *MockAPIServiceTradeQueryCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAPIServiceTradeQueryCall) Do(f func(context.Context, string) (alipay.TradeQueryResponse, error)) *MockAPIServiceTradeQueryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAPIServiceTradeQueryCall) DoAndReturn(f func(context.Context, string) (alipay.TradeQueryResponse, error)) *MockAPIServiceTradeQueryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// TradeRefund mocks base method.
+func (m *MockAPIService) TradeRefund(ctx context.Context, req alipay.TradeRefundRequest) (alipay.TradeRefundResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradeRefund", ctx, req)
+	ret0, _ := ret[0].(alipay.TradeRefundResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TradeRefund indicates an expected call of TradeRefund.
+func (mr *MockAPIServiceMockRecorder) TradeRefund(ctx, req any) *MockAPIServiceTradeRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradeRefund", reflect.TypeOf((*MockAPIService)(nil).TradeRefund), ctx, req)
+	return &MockAPIServiceTradeRefundCall{Call: call}
+}
+
+// MockAPIServiceTradeRefundCall wrap *gomock.Call
+type MockAPIServiceTradeRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAPIServiceTradeRefundCall) Return(arg0 alipay.TradeRefundResponse, arg1 error) *MockAPIServiceTradeRefundCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAPIServiceTradeRefundCall) Do(f func(context.Context, alipay.TradeRefundRequest) (alipay.TradeRefundResponse, error)) *MockAPIServiceTradeRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAPIServiceTradeRefundCall) DoAndReturn(f func(context.Context, alipay.TradeRefundRequest) (alipay.TradeRefundResponse, error)) *MockAPIServiceTradeRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// TradeWapPay mocks base method.
+func (m *MockAPIService) TradeWapPay(ctx context.Context, req alipay.TradeWapPayRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradeWapPay", ctx, req)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TradeWapPay indicates an expected call of TradeWapPay.
+func (mr *MockAPIServiceMockRecorder) TradeWapPay(ctx, req any) *MockAPIServiceTradeWapPayCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradeWapPay", reflect.TypeOf((*MockAPIService)(nil).TradeWapPay), ctx, req)
+	return &MockAPIServiceTradeWapPayCall{Call: call}
+}
+
+// MockAPIServiceTradeWapPayCall wrap *gomock.Call
+type MockAPIServiceTradeWapPayCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockAPIServiceTradeWapPayCall) Return(arg0 string, arg1 error) *MockAPIServiceTradeWapPayCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockAPIServiceTradeWapPayCall) Do(f func(context.Context, alipay.TradeWapPayRequest) (string, error)) *MockAPIServiceTradeWapPayCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockAPIServiceTradeWapPayCall) DoAndReturn(f func(context.Context, alipay.TradeWapPayRequest) (string, error)) *MockAPIServiceTradeWapPayCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
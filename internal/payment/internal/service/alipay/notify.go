@@ -0,0 +1,65 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alipay
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// NotifyHandler 解析并验签支付宝异步通知
+type NotifyHandler interface {
+	ParseNotification(req *http.Request) (Notification, error)
+}
+
+// Notification 支付宝异步通知中用到的字段
+type Notification struct {
+	NotifyID    string
+	AppID       string
+	SellerID    string
+	TradeNo     string
+	OutTradeNo  string
+	TradeStatus string
+	TotalAmount string
+	// GmtRefund 退款时间, 只有退款引起的通知才有
+	GmtRefund string
+}
+
+// ParseNotification 异步通知以表单形式 POST 过来, 除 sign 和 sign_type 外的参数均参与签名
+func (c *Client) ParseNotification(req *http.Request) (Notification, error) {
+	if err := req.ParseForm(); err != nil {
+		return Notification{}, fmt.Errorf("解析支付宝通知失败: %w", err)
+	}
+	values := req.PostForm
+	if err := Verify(c.alipayPublicKey, SignContent(values, "sign", "sign_type"), values.Get("sign")); err != nil {
+		return Notification{}, err
+	}
+	if values.Get("app_id") != c.appID {
+		return Notification{}, fmt.Errorf("%w: %s", errInvalidAppID, values.Get("app_id"))
+	}
+	if values.Get("seller_id") != c.sellerID {
+		return Notification{}, fmt.Errorf("%w: %s", errInvalidSeller, values.Get("seller_id"))
+	}
+	return Notification{
+		NotifyID:    values.Get("notify_id"),
+		AppID:       values.Get("app_id"),
+		SellerID:    values.Get("seller_id"),
+		TradeNo:     values.Get("trade_no"),
+		OutTradeNo:  values.Get("out_trade_no"),
+		TradeStatus: values.Get("trade_status"),
+		TotalAmount: values.Get("total_amount"),
+		GmtRefund:   values.Get("gmt_refund"),
+	}, nil
+}
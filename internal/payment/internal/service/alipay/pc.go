@@ -0,0 +1,59 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alipay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ecodeclub/webook/internal/payment/internal/domain"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// PCPaymentService PC端扫码支付, 预支付返回二维码内容
+type PCPaymentService struct {
+	basePaymentService
+}
+
+func NewPCPaymentService(svc APIService, notifyURL string) *PCPaymentService {
+	return &PCPaymentService{
+		basePaymentService: basePaymentService{
+			l:         elog.DefaultLogger,
+			svc:       svc,
+			name:      domain.ChannelTypeAlipay,
+			desc:      "支付宝",
+			notifyURL: notifyURL,
+		},
+	}
+}
+
+func (p *PCPaymentService) Prepay(ctx context.Context, pmt domain.Payment) (any, error) {
+	r, err := p.findRecord(pmt)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.svc.TradePrecreate(ctx, TradePrecreateRequest{
+		OutTradeNo:  pmt.OrderSN,
+		TotalAmount: FormatAmount(r.Amount),
+		Subject:     pmt.OrderDescription,
+		TimeExpire:  p.timeExpire(),
+		NotifyURL:   p.notifyURL,
+	})
+	if err != nil {
+		return "", fmt.Errorf("支付宝预支付失败: %w", err)
+	}
+	return resp.QRCode, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alipay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ecodeclub/webook/internal/payment/internal/domain"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// WapPaymentService 手机网站(H5)支付, 预支付返回跳转到支付宝收银台的链接
+type WapPaymentService struct {
+	basePaymentService
+	returnURL string
+}
+
+func NewWapPaymentService(svc APIService, notifyURL, returnURL string) *WapPaymentService {
+	return &WapPaymentService{
+		basePaymentService: basePaymentService{
+			l:         elog.DefaultLogger,
+			svc:       svc,
+			name:      domain.ChannelTypeAlipayWap,
+			desc:      "支付宝H5",
+			notifyURL: notifyURL,
+		},
+		returnURL: returnURL,
+	}
+}
+
+func (w *WapPaymentService) Prepay(ctx context.Context, pmt domain.Payment) (any, error) {
+	r, err := w.findRecord(pmt)
+	if err != nil {
+		return "", err
+	}
+
+	payURL, err := w.svc.TradeWapPay(ctx, TradeWapPayRequest{
+		OutTradeNo:  pmt.OrderSN,
+		TotalAmount: FormatAmount(r.Amount),
+		Subject:     pmt.OrderDescription,
+		ProductCode: "QUICK_WAP_WAY",
+		TimeExpire:  w.timeExpire(),
+		NotifyURL:   w.notifyURL,
+		ReturnURL:   w.returnURL,
+	})
+	if err != nil {
+		return "", fmt.Errorf("支付宝预支付失败: %w", err)
+	}
+	return payURL, nil
+}
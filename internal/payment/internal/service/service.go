@@ -28,6 +28,7 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/domain"
 	"github.com/ecodeclub/webook/internal/payment/internal/event"
 	"github.com/ecodeclub/webook/internal/payment/internal/repository"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
 	"github.com/gotomicro/ego/core/elog"
@@ -37,7 +38,10 @@ import (
 
 var (
 	errInvalidCombinationPayment = errors.New("非法组合支付")
-	errIgnoredPaymentStatus      = errors.New("忽略的支付状态")
+	ErrIgnoredPaymentStatus      = errors.New("忽略的支付状态")
+	errPaymentNotRefundable      = errors.New("支付不可退款")
+	errNotificationMismatch      = errors.New("第三方支付通知与支付记录不一致")
+	ErrRecordNotFound            = repository.ErrRecordNotFound
 )
 
 //go:generate mockgen -source=service.go -package=paymentmocks -destination=../../mocks/payment.mock.go -typed Service
@@ -48,27 +52,39 @@ type Service interface {
 	PayByID(ctx context.Context, pmtID int64) (domain.Payment, error)
 	// HandleWechatCallback 处理微信回调请求 web调用
 	HandleWechatCallback(ctx context.Context, txn *payments.Transaction) error
+	// HandleAlipayCallback 处理支付宝异步通知 web调用
+	HandleAlipayCallback(ctx context.Context, n alipay.Notification) error
 	// FindTimeoutPayments 查找过期支付记录 —— 支付主记录+微信支付记录, job调用
 	FindTimeoutPayments(ctx context.Context, offset int, limit int, ctime int64) ([]domain.Payment, int64, error)
 	// CloseTimeoutPayment 通过支付ID关闭超时支付, job调用
 	CloseTimeoutPayment(ctx context.Context, pmt domain.Payment) error
-	// SyncWechatInfo 同步与微信对账 job调用
+	// SyncWechatInfo 同步与微信、支付宝等第三方支付对账 job调用
 	SyncWechatInfo(ctx context.Context, pmt domain.Payment) error
 
 	// HandleCreditCallback 处理积分支付的回调 recon模块使用
 	HandleCreditCallback(ctx context.Context, pmt domain.Payment) error
 	// SetPaymentStatusPaidFailed 将支付标记为失败并发送相应事件 recon模块使用
 	SetPaymentStatusPaidFailed(ctx context.Context, pmt *domain.Payment) error
+	// Refund 按订单SN全额退款,第三方支付原路退回,积分支付返还积分 order模块调用
+	Refund(ctx context.Context, orderSN string) (domain.Payment, error)
+
+	// FindPaymentByOrderSN 根据订单SN查找支付记录 recon模块使用
+	FindPaymentByOrderSN(ctx context.Context, orderSN string) (domain.Payment, error)
+	// FindPaidPayments 查找支付时间在 [stime, etime) 内的已支付记录,包含之后退款的 recon模块使用
+	FindPaidPayments(ctx context.Context, offset int, limit int, stime, etime int64) ([]domain.Payment, error)
 }
 
-// PaymentService 封装底层不同支付方式，当前有Native支付和JSAPI支付
+// PaymentService 封装底层不同支付方式，当前有微信Native支付、JSAPI支付及支付宝PC扫码、H5支付
 type PaymentService interface {
 	Name() domain.ChannelType
 	Desc() string
 	// Prepay 预支付 Native支付方式返回CodeUrl string，JSAPI支付方式返回PrepayId
+	// 支付宝PC扫码返回二维码内容 string, H5支付返回收银台链接 string
 	Prepay(ctx context.Context, pmt domain.Payment) (any, error)
 	// QueryOrderBySN 同步信息 定时任务调用此方法同步状态信息
 	QueryOrderBySN(ctx context.Context, orderSN string) (domain.Payment, error)
+	// Refund 全额退款 退款审核通过后调用
+	Refund(ctx context.Context, pmt domain.Payment) error
 }
 
 func NewService(paymentSvcs map[domain.ChannelType]PaymentService,
@@ -98,6 +114,7 @@ type service struct {
 
 // CreatePayment 创建支付记录 内部不做校验
 // 订单ID、订单SN、订单描述、支付金额、支付者ID、支付渠道记录不能为零值
+// 同一个订单只会创建一次, 自动续费扣款成功后 subscription 模块也用它直接记录已支付的委托代扣
 func (s *service) CreatePayment(ctx context.Context, pmt domain.Payment) (domain.Payment, error) {
 	sn, err := s.snGenerator.Generate(pmt.PayerID)
 	if err != nil {
@@ -149,8 +166,9 @@ func (s *service) executePayment(ctx context.Context, pmt *domain.Payment) error
 		case domain.ChannelTypeCredit:
 			// 仅积分支付，直接支付
 			return s.payByCredit(ctx, pmt)
-		case domain.ChannelTypeWechat, domain.ChannelTypeWechatJS:
-			// 仅微信预支付
+		case domain.ChannelTypeWechat, domain.ChannelTypeWechatJS,
+			domain.ChannelTypeAlipay, domain.ChannelTypeAlipayWap:
+			// 仅第三方预支付
 			return s.prepay(ctx, pmt, channels[0])
 		}
 	case 2:
@@ -255,17 +273,27 @@ func (s *service) prepay(ctx context.Context, pmt *domain.Payment, channel domai
 	idx := slice.IndexFunc(pmt.Records, func(src domain.PaymentRecord) bool {
 		return src.Channel == thirdPartyPayment.Name()
 	})
-	if thirdPartyPayment.Name() == domain.ChannelTypeWechat {
-		pmt.Records[idx].WechatCodeURL = resp.(string)
-	} else if thirdPartyPayment.Name() == domain.ChannelTypeWechatJS {
-		pmt.Records[idx].WechatJsAPIResp = resp.(domain.WechatJsAPIPrepayResponse)
-	}
+	s.setPrepayResp(&pmt.Records[idx], resp)
 
 	pmt.Status = domain.PaymentStatusProcessing
 	pmt.Records[idx].Status = domain.PaymentStatusProcessing
 	return s.repo.UpdatePayment(ctx, *pmt)
 }
 
+// setPrepayResp 按支付渠道设置预支付的返回结果
+func (s *service) setPrepayResp(r *domain.PaymentRecord, resp any) {
+	switch r.Channel {
+	case domain.ChannelTypeWechat:
+		r.WechatCodeURL = resp.(string)
+	case domain.ChannelTypeWechatJS:
+		r.WechatJsAPIResp = resp.(domain.WechatJsAPIPrepayResponse)
+	case domain.ChannelTypeAlipay:
+		r.AlipayQRCode = resp.(string)
+	case domain.ChannelTypeAlipayWap:
+		r.AlipayWapURL = resp.(string)
+	}
+}
+
 func (s *service) prepayByCreditAnd3rdPayment(ctx context.Context, pmt *domain.Payment, channel domain.ChannelType) error {
 
 	creditIdx, tid, err := s.getCreditIndexAndDeductID(ctx, pmt)
@@ -286,11 +314,7 @@ func (s *service) prepayByCreditAnd3rdPayment(ctx context.Context, pmt *domain.P
 	})
 
 	pmt.Status = domain.PaymentStatusProcessing
-	if thirdPartyPayment.Name() == domain.ChannelTypeWechat {
-		pmt.Records[channelIdx].WechatCodeURL = resp.(string)
-	} else if thirdPartyPayment.Name() == domain.ChannelTypeWechatJS {
-		pmt.Records[channelIdx].WechatJsAPIResp = resp.(domain.WechatJsAPIPrepayResponse)
-	}
+	s.setPrepayResp(&pmt.Records[channelIdx], resp)
 	pmt.Records[channelIdx].Status = domain.PaymentStatusProcessing
 	pmt.Records[creditIdx].PaymentNO3rd = strconv.FormatInt(tid, 10)
 	pmt.Records[creditIdx].Status = domain.PaymentStatusProcessing
@@ -315,14 +339,81 @@ func (s *service) HandleWechatCallback(ctx context.Context, txn *payments.Transa
 	if err1 != nil {
 		return fmt.Errorf("微信回调中携带的订单SN不存在：%w", err1)
 	}
+	return s.handle3rdPaymentCallback(ctx, pmt, *txn.TransactionId, status)
+}
+
+func (s *service) HandleAlipayCallback(ctx context.Context, n alipay.Notification) error {
+	status, err := s.getAlipayPaymentStatus(n)
+	if err != nil {
+		return err
+	}
 
+	pmt, err1 := s.repo.FindPaymentByOrderSN(ctx, n.OutTradeNo)
+	if err1 != nil {
+		return fmt.Errorf("支付宝通知中携带的订单SN不存在：%w", err1)
+	}
+	err = s.checkAlipayAmount(pmt, n)
+	if err != nil {
+		return err
+	}
+	return s.handle3rdPaymentCallback(ctx, pmt, n.TradeNo, status)
+}
+
+// checkAlipayAmount 支付宝要求校验通知中的 total_amount 与商户订单的实际金额一致
+func (s *service) checkAlipayAmount(pmt domain.Payment, n alipay.Notification) error {
+	r, ok := slice.Find(pmt.Records, func(src domain.PaymentRecord) bool {
+		return src.Channel == domain.ChannelTypeAlipay || src.Channel == domain.ChannelTypeAlipayWap
+	})
+	if !ok {
+		return fmt.Errorf("%w, 缺少支付宝支付记录, orderSN: %s", errNotificationMismatch, pmt.OrderSN)
+	}
+	amount, err := alipay.ParseAmount(n.TotalAmount)
+	if err != nil {
+		return err
+	}
+	if amount != r.Amount {
+		return fmt.Errorf("%w, orderSN: %s, total_amount: %s, 期望: %d", errNotificationMismatch, pmt.OrderSN, n.TotalAmount, r.Amount)
+	}
+	return nil
+}
+
+func (s *service) getAlipayPaymentStatus(n alipay.Notification) (domain.PaymentStatus, error) {
+	if n.GmtRefund != "" {
+		// 退款也会触发异步通知,全额退款后交易状态为 TRADE_CLOSED, 不能当作支付失败处理
+		return 0, fmt.Errorf("%w, 支付宝退款通知", ErrIgnoredPaymentStatus)
+	}
+	status, err := alipay.GetPaymentStatus(n.TradeStatus)
+	if err != nil {
+		return 0, err
+	}
+	if status != domain.PaymentStatusPaidSuccess && status != domain.PaymentStatusPaidFailed {
+		s.l.Warn("忽略的支付宝通知状态",
+			elog.String("TradeStatus", n.TradeStatus),
+			elog.Any("PaymentStatus", status),
+		)
+		return 0, fmt.Errorf("%w, %d", ErrIgnoredPaymentStatus, status.ToUint8())
+	}
+	return status, nil
+}
+
+// handle3rdPaymentCallback 更新第三方支付渠道记录并发送支付事件, 微信和支付宝共用
+func (s *service) handle3rdPaymentCallback(ctx context.Context, pmt domain.Payment, paymentNO3rd string, status domain.PaymentStatus) error {
+	if pmt.Status != domain.PaymentStatusUnpaid && pmt.Status != domain.PaymentStatusProcessing {
+		// 重复通知, 或者已经退款之后又收到了支付成功的通知, 都不能覆盖终态
+		s.l.Warn("忽略已是终态的支付记录的通知",
+			elog.String("orderSN", pmt.OrderSN),
+			elog.Any("PaymentStatus", pmt.Status),
+			elog.Any("NotifyStatus", status),
+		)
+		return nil
+	}
 	pmt.PaidAt = s.getPaymentPaidAt(status)
 	pmt.Status = status
 	for i, r := range pmt.Records {
-		if r.Channel == domain.ChannelTypeWechat || r.Channel == domain.ChannelTypeWechatJS {
+		if r.Channel != domain.ChannelTypeCredit {
 			pmt.Records[i] = domain.PaymentRecord{
 				PaymentID:       r.PaymentID,
-				PaymentNO3rd:    *txn.TransactionId,
+				PaymentNO3rd:    paymentNO3rd,
 				Description:     r.Description,
 				Channel:         r.Channel,
 				Amount:          r.Amount,
@@ -330,17 +421,19 @@ func (s *service) HandleWechatCallback(ctx context.Context, txn *payments.Transa
 				Status:          pmt.Status,
 				WechatCodeURL:   r.WechatCodeURL,
 				WechatJsAPIResp: r.WechatJsAPIResp,
+				AlipayQRCode:    r.AlipayQRCode,
+				AlipayWapURL:    r.AlipayWapURL,
 			}
 		}
 	}
 
-	err = s.repo.UpdatePayment(ctx, pmt)
+	err := s.repo.UpdatePayment(ctx, pmt)
 	if err != nil {
 		// 这里有一个小问题，就是如果超时了的话，你都不知道更新成功了没
 		return err
 	}
 
-	// 支付主记录和微信支付渠道记录更新后就直接发送消息
+	// 支付主记录和第三方支付渠道记录更新后就直接发送消息
 	_ = s.sendPaymentEvent(ctx, &pmt)
 
 	return s.HandleCreditCallback(ctx, pmt)
@@ -367,7 +460,7 @@ func (s *service) getPaymentStatus(tradeState string) (domain.PaymentStatus, err
 			elog.String("TradeState", tradeState),
 			elog.Any("PaymentStatus", status),
 		)
-		return 0, fmt.Errorf("%w, %d", errIgnoredPaymentStatus, status.ToUint8())
+		return 0, fmt.Errorf("%w, %d", ErrIgnoredPaymentStatus, status.ToUint8())
 	}
 	return status, nil
 }
@@ -491,7 +584,7 @@ func (s *service) SyncWechatInfo(ctx context.Context, pmt domain.Payment) error
 		return err
 	}
 
-	// 支付主记录和微信支付渠道支付成功/支付失败后,就发送消息
+	// 支付主记录和第三方支付渠道支付成功/支付失败后,就发送消息
 	p.PayerID = pmt.PayerID
 	_ = s.sendPaymentEvent(ctx, &p)
 
@@ -513,3 +606,82 @@ func (s *service) SetPaymentStatusPaidFailed(ctx context.Context, pmt *domain.Pa
 	}
 	return s.repo.UpdatePayment(ctx, *pmt)
 }
+
+func (s *service) Refund(ctx context.Context, orderSN string) (domain.Payment, error) {
+	pmt, err := s.repo.FindPaymentByOrderSN(ctx, orderSN)
+	if err != nil {
+		return domain.Payment{}, fmt.Errorf("查找支付记录失败: %w, orderSN: %s", err, orderSN)
+	}
+	if pmt.Status == domain.PaymentStatusRefund {
+		// 重复退款直接返回
+		return pmt, nil
+	}
+	if pmt.Status != domain.PaymentStatusPaidSuccess {
+		return domain.Payment{}, fmt.Errorf("%w, orderSN: %s, status: %d", errPaymentNotRefundable, orderSN, pmt.Status.ToUint8())
+	}
+
+	// 任意一个渠道退款失败都直接返回,支付状态保持不变以便重试
+	// 微信以订单SN作为退款单号,积分以固定Key返还,重试是幂等的
+	for i := 0; i < len(pmt.Records); i++ {
+		record := &pmt.Records[i]
+		if record.Channel == domain.ChannelTypeCredit {
+			err = s.refundByCredit(ctx, pmt, *record)
+		} else {
+			err = s.refundBy3rdPayment(ctx, pmt, record.Channel)
+		}
+		if err != nil {
+			return domain.Payment{}, err
+		}
+		record.Status = domain.PaymentStatusRefund
+	}
+
+	pmt.Status = domain.PaymentStatusRefund
+	err = s.repo.UpdatePayment(ctx, pmt)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	return pmt, nil
+}
+
+func (s *service) refundByCredit(ctx context.Context, pmt domain.Payment, r domain.PaymentRecord) error {
+	err := s.creditSvc.AddCredits(ctx, credit.Credit{
+		Uid: pmt.PayerID,
+		Logs: []credit.CreditLog{
+			{
+				Key:          fmt.Sprintf("refund-%s", pmt.OrderSN),
+				ChangeAmount: r.Amount,
+				Biz:          "order",
+				BizId:        pmt.OrderID,
+				Desc:         "订单退款",
+			},
+		},
+	})
+	if errors.Is(err, credit.ErrDuplicatedCreditLog) {
+		// 上次退款时积分已经返还
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("返还积分失败: %w, orderSN: %s", err, pmt.OrderSN)
+	}
+	return nil
+}
+
+func (s *service) refundBy3rdPayment(ctx context.Context, pmt domain.Payment, channel domain.ChannelType) error {
+	if channel == domain.ChannelTypeWechatPapay {
+		// 委托代扣的订单和 Native 支付的订单一样按照订单SN原路退款
+		channel = domain.ChannelTypeWechat
+	}
+	thirdPartyPayment, ok := s.thirdPartyPayments[channel]
+	if !ok {
+		return fmt.Errorf("未知支付渠道: %d", channel.ToUnit8())
+	}
+	return thirdPartyPayment.Refund(ctx, pmt)
+}
+
+func (s *service) FindPaymentByOrderSN(ctx context.Context, orderSN string) (domain.Payment, error) {
+	return s.repo.FindPaymentByOrderSN(ctx, orderSN)
+}
+
+func (s *service) FindPaidPayments(ctx context.Context, offset int, limit int, stime, etime int64) ([]domain.Payment, error) {
+	return s.repo.FindPaidPayments(ctx, offset, limit, stime, etime)
+}
@@ -44,6 +44,8 @@ type basePaymentService struct {
 	name domain.ChannelType
 	desc string
 
+	refundSvc RefundAPIService
+
 	appID     string
 	mchID     string
 	notifyURL string
@@ -0,0 +1,93 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wechat
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/auth/validators"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/consts"
+)
+
+// noStatementErrCode 当天没有交易时微信返回的错误码, 视为空账单
+const noStatementErrCode = "NO_STATEMENT_EXIST"
+
+// TradeBillDownloader 下载微信支付交易账单, 供对账使用
+// 账单覆盖同一商户号下的 Native 和 JSAPI 支付
+type TradeBillDownloader struct {
+	cli *core.Client
+	// downloadCli 下载账单文件的响应没有微信支付签名, 不能验签
+	downloadCli *core.Client
+}
+
+func NewTradeBillDownloader(cli *core.Client) *TradeBillDownloader {
+	return &TradeBillDownloader{
+		cli:         cli,
+		downloadCli: core.NewClientWithValidator(cli, &validators.NullValidator{}),
+	}
+}
+
+type tradeBill struct {
+	HashType    string `json:"hash_type"`
+	HashValue   string `json:"hash_value"`
+	DownloadURL string `json:"download_url"`
+}
+
+// DownloadTradeBill 下载 billDate 当天的全部交易账单, 返回未压缩的 CSV 原文
+// 当天没有交易时返回空内容
+func (d *TradeBillDownloader) DownloadTradeBill(ctx context.Context, billDate time.Time) ([]byte, error) {
+	query := url.Values{}
+	query.Set("bill_date", billDate.Format(time.DateOnly))
+	query.Set("bill_type", "ALL")
+	result, err := d.cli.Get(ctx, consts.WechatPayAPIServer+"/v3/bill/tradebill?"+query.Encode())
+	var apiErr *core.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == noStatementErrCode {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("申请微信交易账单失败: %w", err)
+	}
+	var bill tradeBill
+	if err = core.UnMarshalResponse(result.Response, &bill); err != nil {
+		return nil, fmt.Errorf("解析微信交易账单申请结果失败: %w", err)
+	}
+
+	result, err = d.downloadCli.Get(ctx, bill.DownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("下载微信交易账单失败: %w", err)
+	}
+	defer result.Response.Body.Close()
+	data, err := io.ReadAll(result.Response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取微信交易账单失败: %w", err)
+	}
+
+	if strings.EqualFold(bill.HashType, "SHA1") {
+		sum := sha1.Sum(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), bill.HashValue) {
+			return nil, fmt.Errorf("微信交易账单摘要不匹配, 日期: %s", billDate.Format(time.DateOnly))
+		}
+	}
+	return data, nil
+}
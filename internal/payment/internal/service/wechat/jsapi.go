@@ -42,6 +42,7 @@ type JSAPIPaymentService struct {
 }
 
 func NewJSAPIPaymentService(svc JSAPIService,
+	refundSvc RefundAPIService,
 	userSvc user.UserService,
 	appid, mchid, notifyURL string) *JSAPIPaymentService {
 	return &JSAPIPaymentService{
@@ -51,6 +52,7 @@ func NewJSAPIPaymentService(svc JSAPIService,
 			l:         elog.DefaultLogger,
 			name:      domain.ChannelTypeWechatJS,
 			desc:      "微信小程序",
+			refundSvc: refundSvc,
 			appID:     appid,
 			mchID:     mchid,
 			notifyURL: notifyURL,
@@ -115,6 +117,11 @@ func (n *JSAPIPaymentService) Prepay(ctx context.Context, pmt domain.Payment) (a
 	}, nil
 }
 
+// Refund 全额退款
+func (n *JSAPIPaymentService) Refund(ctx context.Context, pmt domain.Payment) error {
+	return n.refund(ctx, pmt)
+}
+
 // QueryOrderBySN 同步信息 定时任务调用此方法同步状态信息
 func (n *JSAPIPaymentService) QueryOrderBySN(ctx context.Context, orderSN string) (domain.Payment, error) {
 	txn, _, err := n.svc.QueryOrderByOutTradeNo(ctx, jsapi.QueryOrderByOutTradeNoRequest{
@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./refund.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./refund.go -package=wechatmocks -destination=./mocks/refund.mock.go -typed RefundAPIService
+//
+
+// Package wechatmocks is a generated GoMock package.
+package wechatmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	core "github.com/wechatpay-apiv3/wechatpay-go/core"
+	refunddomestic "github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRefundAPIService is a mock of RefundAPIService interface.
+type MockRefundAPIService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundAPIServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockRefundAPIServiceMockRecorder is the mock recorder for MockRefundAPIService.
+type MockRefundAPIServiceMockRecorder struct {
+	mock *MockRefundAPIService
+}
+
+// NewMockRefundAPIService creates a new mock instance.
+func NewMockRefundAPIService(ctrl *gomock.Controller) *MockRefundAPIService {
+	mock := &MockRefundAPIService{ctrl: ctrl}
+	mock.recorder = &MockRefundAPIServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefundAPIService) EXPECT() *MockRefundAPIServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRefundAPIService) Create(ctx context.Context, req refunddomestic.CreateRequest) (*refunddomestic.Refund, *core.APIResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, req)
+	ret0, _ := ret[0].(*refunddomestic.Refund)
+	ret1, _ := ret[1].(*core.APIResult)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRefundAPIServiceMockRecorder) Create(ctx, req any) *MockRefundAPIServiceCreateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRefundAPIService)(nil).Create), ctx, req)
+	return &MockRefundAPIServiceCreateCall{Call: call}
+}
+
+// MockRefundAPIServiceCreateCall wrap *gomock.Call
+type MockRefundAPIServiceCreateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockRefundAPIServiceCreateCall) Return(resp *refunddomestic.Refund, result *core.APIResult, err error) *MockRefundAPIServiceCreateCall {
+	c.Call = c.Call.Return(resp, result, err)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockRefundAPIServiceCreateCall) Do(f func(context.Context, refunddomestic.CreateRequest) (*refunddomestic.Refund, *core.APIResult, error)) *MockRefundAPIServiceCreateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockRefundAPIServiceCreateCall) DoAndReturn(f func(context.Context, refunddomestic.CreateRequest) (*refunddomestic.Refund, *core.APIResult, error)) *MockRefundAPIServiceCreateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -38,13 +38,14 @@ type NativePaymentService struct {
 	basePaymentService
 }
 
-func NewNativePaymentService(svc NativeAPIService, appid, mchid, notifyURL string) *NativePaymentService {
+func NewNativePaymentService(svc NativeAPIService, refundSvc RefundAPIService, appid, mchid, notifyURL string) *NativePaymentService {
 	return &NativePaymentService{
 		svc: svc,
 		basePaymentService: basePaymentService{
 			l:         elog.DefaultLogger,
 			name:      domain.ChannelTypeWechat,
 			desc:      "微信",
+			refundSvc: refundSvc,
 			appID:     appid,
 			mchID:     mchid,
 			notifyURL: notifyURL,
@@ -90,6 +91,11 @@ func (n *NativePaymentService) Prepay(ctx context.Context, pmt domain.Payment) (
 	return *resp.CodeUrl, nil
 }
 
+// Refund 全额退款
+func (n *NativePaymentService) Refund(ctx context.Context, pmt domain.Payment) error {
+	return n.refund(ctx, pmt)
+}
+
 // QueryOrderBySN 同步信息 定时任务调用此方法同步状态信息
 func (n *NativePaymentService) QueryOrderBySN(ctx context.Context, orderSN string) (domain.Payment, error) {
 	txn, _, err := n.svc.QueryOrderByOutTradeNo(ctx, native.QueryOrderByOutTradeNoRequest{
@@ -0,0 +1,62 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wechat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/payment/internal/domain"
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
+)
+
+//go:generate mockgen -source=./refund.go -package=wechatmocks -destination=./mocks/refund.mock.go -typed RefundAPIService
+type RefundAPIService interface {
+	Create(ctx context.Context, req refunddomestic.CreateRequest) (resp *refunddomestic.Refund, result *core.APIResult, err error)
+}
+
+// refund 申请全额退款 native 和 jsapi 共用, 委托代扣的订单也通过 native 退款
+// 退款单号直接使用订单SN,一笔订单只退一次,重复申请时微信会返回同一笔退款单,天然幂等
+func (b *basePaymentService) refund(ctx context.Context, pmt domain.Payment) error {
+	r, ok := slice.Find(pmt.Records, func(src domain.PaymentRecord) bool {
+		return src.Channel == b.name || src.Channel == domain.ChannelTypeWechatPapay
+	})
+	if !ok || r.Amount == 0 {
+		return fmt.Errorf("缺少微信支付金额信息")
+	}
+
+	resp, _, err := b.refundSvc.Create(ctx, refunddomestic.CreateRequest{
+		OutTradeNo:  core.String(pmt.OrderSN),
+		OutRefundNo: core.String(pmt.OrderSN),
+		Reason:      core.String("订单退款"),
+		Amount: &refunddomestic.AmountReq{
+			Refund:   core.Int64(r.Amount),
+			Total:    core.Int64(r.Amount),
+			Currency: core.String("CNY"),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("微信退款失败: %w", err)
+	}
+
+	// 退款受理后通常为 PROCESSING, 资金由微信异步原路退回, 只有关闭和异常才视为失败
+	if resp.Status != nil &&
+		(*resp.Status == refunddomestic.STATUS_CLOSED || *resp.Status == refunddomestic.STATUS_ABNORMAL) {
+		return fmt.Errorf("微信退款失败, 退款状态: %s", *resp.Status)
+	}
+	return nil
+}
@@ -15,8 +15,12 @@
 package web
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/gin-gonic/gin"
 	"github.com/gotomicro/ego/core/elog"
@@ -26,16 +30,18 @@ import (
 var _ ginx.Handler = &Handler{}
 
 type Handler struct {
-	handler wechat.NotifyHandler
-	svc     service.Service
-	l       *elog.Component
+	handler       wechat.NotifyHandler
+	alipayHandler alipay.NotifyHandler
+	svc           service.Service
+	l             *elog.Component
 }
 
-func NewHandler(handler wechat.NotifyHandler, svc service.Service) *Handler {
+func NewHandler(handler wechat.NotifyHandler, alipayHandler alipay.NotifyHandler, svc service.Service) *Handler {
 	return &Handler{
-		handler: handler,
-		svc:     svc,
-		l:       elog.DefaultLogger}
+		handler:       handler,
+		alipayHandler: alipayHandler,
+		svc:           svc,
+		l:             elog.DefaultLogger}
 }
 
 func (h *Handler) PrivateRoutes(_ *gin.Engine) {
@@ -44,6 +50,8 @@ func (h *Handler) PrivateRoutes(_ *gin.Engine) {
 
 func (h *Handler) PublicRoutes(server *gin.Engine) {
 	server.Any("/api/interview/pay/callback", ginx.W(h.HandleWechatCallback))
+	// 支付宝要求响应纯文本 success, 所以不走 ginx 的包装
+	server.POST("/api/interview/pay/alipay/callback", h.HandleAlipayCallback)
 	// 测试环境专用
 	server.Any("/pay/mock_cb", ginx.B[payments.Transaction](h.MockWechatCallback))
 }
@@ -57,3 +65,24 @@ func (h *Handler) HandleWechatCallback(ctx *ginx.Context) (ginx.Result, error) {
 	err = h.svc.HandleWechatCallback(ctx, transaction)
 	return ginx.Result{}, err
 }
+
+// HandleAlipayCallback 处理支付宝异步通知, 响应 success 之外的内容支付宝都会重试
+func (h *Handler) HandleAlipayCallback(ctx *gin.Context) {
+	n, err := h.alipayHandler.ParseNotification(ctx.Request)
+	if err != nil {
+		h.l.Error("解析支付宝通知失败", elog.FieldErr(err))
+		ctx.String(http.StatusBadRequest, "fail")
+		return
+	}
+	err = h.svc.HandleAlipayCallback(ctx.Request.Context(), n)
+	if err != nil && !errors.Is(err, service.ErrIgnoredPaymentStatus) {
+		h.l.Error("处理支付宝通知失败",
+			elog.FieldErr(err),
+			elog.String("out_trade_no", n.OutTradeNo),
+			elog.String("notify_id", n.NotifyID),
+		)
+		ctx.String(http.StatusInternalServerError, "fail")
+		return
+	}
+	ctx.String(http.StatusOK, "success")
+}
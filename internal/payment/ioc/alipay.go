@@ -0,0 +1,76 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ioc
+
+import (
+	"os"
+
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
+	"github.com/gotomicro/ego/core/econf"
+)
+
+func InitAlipayClient(cfg AlipayConfig) *alipay.Client {
+	// 应用私钥和支付宝公钥都需要你自己准备, 我没有上传
+	privateKeyData, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		panic(err)
+	}
+	privateKey, err := alipay.ParsePrivateKey(privateKeyData)
+	if err != nil {
+		panic(err)
+	}
+	publicKeyData, err := os.ReadFile(cfg.AlipayPublicKeyPath)
+	if err != nil {
+		panic(err)
+	}
+	publicKey, err := alipay.ParsePublicKey(publicKeyData)
+	if err != nil {
+		panic(err)
+	}
+	return alipay.NewClient(cfg.AppID, cfg.SellerID, privateKey, publicKey, cfg.GatewayURL)
+}
+
+func InitAlipayPCPaymentService(svc alipay.APIService, cfg AlipayConfig) *alipay.PCPaymentService {
+	return alipay.NewPCPaymentService(svc, cfg.PaymentNotifyURL)
+}
+
+func InitAlipayWapPaymentService(svc alipay.APIService, cfg AlipayConfig) *alipay.WapPaymentService {
+	return alipay.NewWapPaymentService(svc, cfg.PaymentNotifyURL, cfg.PaymentReturnURL)
+}
+
+func InitAlipayConfig() AlipayConfig {
+	var cfg AlipayConfig
+	err := econf.UnmarshalKey("alipay.payment", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+type AlipayConfig struct {
+	AppID string
+	// 商户的支付宝账号ID(PID), 异步通知中的 seller_id 必须与之一致
+	SellerID string
+	// 应用私钥, 用于请求签名
+	PrivateKeyPath string
+	// 支付宝公钥, 用于响应及异步通知验签
+	AlipayPublicKeyPath string
+	// 网关地址, 为空时使用支付宝正式环境, 测试时可以指向沙箱或本地的假网关
+	GatewayURL string
+
+	PaymentNotifyURL string
+	// H5支付完成后跳转回来的页面
+	PaymentReturnURL string
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/wechatpay-apiv3/wechatpay-go/core/option"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/jsapi"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/native"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
 	"github.com/wechatpay-apiv3/wechatpay-go/utils"
 )
 
@@ -61,8 +62,8 @@ func InitNativeApiService(cli *core.Client) *native.NativeApiService {
 	}
 }
 
-func InitWechatNativePaymentService(native wechat.NativeAPIService, cfg WechatConfig) *wechat.NativePaymentService {
-	return wechat.NewNativePaymentService(native, cfg.AppID, cfg.MchID, cfg.PaymentNotifyURL)
+func InitWechatNativePaymentService(native wechat.NativeAPIService, refund wechat.RefundAPIService, cfg WechatConfig) *wechat.NativePaymentService {
+	return wechat.NewNativePaymentService(native, refund, cfg.AppID, cfg.MchID, cfg.PaymentNotifyURL)
 }
 
 func InitJSApiService(cli *core.Client) *jsapi.JsapiApiService {
@@ -72,9 +73,16 @@ func InitJSApiService(cli *core.Client) *jsapi.JsapiApiService {
 }
 
 func InitWechatJSAPIPaymentService(js wechat.JSAPIService,
+	refund wechat.RefundAPIService,
 	usr user.UserService,
 	cfg WechatConfig) *wechat.JSAPIPaymentService {
-	return wechat.NewJSAPIPaymentService(js, usr, cfg.AppID, cfg.MchID, cfg.PaymentNotifyURL)
+	return wechat.NewJSAPIPaymentService(js, refund, usr, cfg.AppID, cfg.MchID, cfg.PaymentNotifyURL)
+}
+
+func InitRefundApiService(cli *core.Client) *refunddomestic.RefundsApiService {
+	return &refunddomestic.RefundsApiService{
+		Client: cli,
+	}
 }
 
 func InitWechatNotifyHandler(cfg WechatConfig) *notify.Handler {
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	domain "github.com/ecodeclub/webook/internal/payment/internal/domain"
+	alipay "github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	payments "github.com/wechatpay-apiv3/wechatpay-go/services/payments"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -119,6 +120,45 @@ func (c *MockServiceCreatePaymentCall) DoAndReturn(f func(context.Context, domai
 	return c
 }
 
+// FindPaidPayments mocks base method.
+func (m *MockService) FindPaidPayments(ctx context.Context, offset, limit int, stime, etime int64) ([]domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPaidPayments", ctx, offset, limit, stime, etime)
+	ret0, _ := ret[0].([]domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPaidPayments indicates an expected call of FindPaidPayments.
+func (mr *MockServiceMockRecorder) FindPaidPayments(ctx, offset, limit, stime, etime any) *MockServiceFindPaidPaymentsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPaidPayments", reflect.TypeOf((*MockService)(nil).FindPaidPayments), ctx, offset, limit, stime, etime)
+	return &MockServiceFindPaidPaymentsCall{Call: call}
+}
+
+// MockServiceFindPaidPaymentsCall wrap *gomock.Call
+type MockServiceFindPaidPaymentsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindPaidPaymentsCall) Return(arg0 []domain.Payment, arg1 error) *MockServiceFindPaidPaymentsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindPaidPaymentsCall) Do(f func(context.Context, int, int, int64, int64) ([]domain.Payment, error)) *MockServiceFindPaidPaymentsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindPaidPaymentsCall) DoAndReturn(f func(context.Context, int, int, int64, int64) ([]domain.Payment, error)) *MockServiceFindPaidPaymentsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // FindPaymentByID mocks base method.
 func (m *MockService) FindPaymentByID(ctx context.Context, pmtID int64) (domain.Payment, error) {
 	m.ctrl.T.Helper()
@@ -158,6 +198,45 @@ func (c *MockServiceFindPaymentByIDCall) DoAndReturn(f func(context.Context, int
 	return c
 }
 
+// FindPaymentByOrderSN mocks base method.
+func (m *MockService) FindPaymentByOrderSN(ctx context.Context, orderSN string) (domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPaymentByOrderSN", ctx, orderSN)
+	ret0, _ := ret[0].(domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPaymentByOrderSN indicates an expected call of FindPaymentByOrderSN.
+func (mr *MockServiceMockRecorder) FindPaymentByOrderSN(ctx, orderSN any) *MockServiceFindPaymentByOrderSNCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPaymentByOrderSN", reflect.TypeOf((*MockService)(nil).FindPaymentByOrderSN), ctx, orderSN)
+	return &MockServiceFindPaymentByOrderSNCall{Call: call}
+}
+
+// MockServiceFindPaymentByOrderSNCall wrap *gomock.Call
+type MockServiceFindPaymentByOrderSNCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindPaymentByOrderSNCall) Return(arg0 domain.Payment, arg1 error) *MockServiceFindPaymentByOrderSNCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindPaymentByOrderSNCall) Do(f func(context.Context, string) (domain.Payment, error)) *MockServiceFindPaymentByOrderSNCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindPaymentByOrderSNCall) DoAndReturn(f func(context.Context, string) (domain.Payment, error)) *MockServiceFindPaymentByOrderSNCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // FindTimeoutPayments mocks base method.
 func (m *MockService) FindTimeoutPayments(ctx context.Context, offset, limit int, ctime int64) ([]domain.Payment, int64, error) {
 	m.ctrl.T.Helper()
@@ -236,6 +315,44 @@ func (c *MockServiceGetPaymentChannelsCall) DoAndReturn(f func(context.Context)
 	return c
 }
 
+// HandleAlipayCallback mocks base method.
+func (m *MockService) HandleAlipayCallback(ctx context.Context, n alipay.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleAlipayCallback", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleAlipayCallback indicates an expected call of HandleAlipayCallback.
+func (mr *MockServiceMockRecorder) HandleAlipayCallback(ctx, n any) *MockServiceHandleAlipayCallbackCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleAlipayCallback", reflect.TypeOf((*MockService)(nil).HandleAlipayCallback), ctx, n)
+	return &MockServiceHandleAlipayCallbackCall{Call: call}
+}
+
+// MockServiceHandleAlipayCallbackCall wrap *gomock.Call
+type MockServiceHandleAlipayCallbackCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceHandleAlipayCallbackCall) Return(arg0 error) *MockServiceHandleAlipayCallbackCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceHandleAlipayCallbackCall) Do(f func(context.Context, alipay.Notification) error) *MockServiceHandleAlipayCallbackCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceHandleAlipayCallbackCall) DoAndReturn(f func(context.Context, alipay.Notification) error) *MockServiceHandleAlipayCallbackCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // HandleCreditCallback mocks base method.
 func (m *MockService) HandleCreditCallback(ctx context.Context, pmt domain.Payment) error {
 	m.ctrl.T.Helper()
@@ -351,6 +468,45 @@ func (c *MockServicePayByIDCall) DoAndReturn(f func(context.Context, int64) (dom
 	return c
 }
 
+// Refund mocks base method.
+func (m *MockService) Refund(ctx context.Context, orderSN string) (domain.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, orderSN)
+	ret0, _ := ret[0].(domain.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockServiceMockRecorder) Refund(ctx, orderSN any) *MockServiceRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockService)(nil).Refund), ctx, orderSN)
+	return &MockServiceRefundCall{Call: call}
+}
+
+// MockServiceRefundCall wrap *gomock.Call
+type MockServiceRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceRefundCall) Return(arg0 domain.Payment, arg1 error) *MockServiceRefundCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceRefundCall) Do(f func(context.Context, string) (domain.Payment, error)) *MockServiceRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceRefundCall) DoAndReturn(f func(context.Context, string) (domain.Payment, error)) *MockServiceRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SetPaymentStatusPaidFailed mocks base method.
 func (m *MockService) SetPaymentStatusPaidFailed(ctx context.Context, pmt *domain.Payment) error {
 	m.ctrl.T.Helper()
@@ -451,41 +607,40 @@ func (m *MockPaymentService) EXPECT() *MockPaymentServiceMockRecorder {
 	return m.recorder
 }
 
-// ConvertCallbackTransactionToDomain mocks base method.
-func (m *MockPaymentService) ConvertCallbackTransactionToDomain(txn *payments.Transaction) (domain.Payment, error) {
+// Desc mocks base method.
+func (m *MockPaymentService) Desc() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ConvertCallbackTransactionToDomain", txn)
-	ret0, _ := ret[0].(domain.Payment)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Desc")
+	ret0, _ := ret[0].(string)
+	return ret0
 }
 
-// ConvertCallbackTransactionToDomain indicates an expected call of ConvertCallbackTransactionToDomain.
-func (mr *MockPaymentServiceMockRecorder) ConvertCallbackTransactionToDomain(txn any) *MockPaymentServiceConvertCallbackTransactionToDomainCall {
+// Desc indicates an expected call of Desc.
+func (mr *MockPaymentServiceMockRecorder) Desc() *MockPaymentServiceDescCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertCallbackTransactionToDomain", reflect.TypeOf((*MockPaymentService)(nil).ConvertCallbackTransactionToDomain), txn)
-	return &MockPaymentServiceConvertCallbackTransactionToDomainCall{Call: call}
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Desc", reflect.TypeOf((*MockPaymentService)(nil).Desc))
+	return &MockPaymentServiceDescCall{Call: call}
 }
 
-// MockPaymentServiceConvertCallbackTransactionToDomainCall wrap *gomock.Call
-type MockPaymentServiceConvertCallbackTransactionToDomainCall struct {
+// MockPaymentServiceDescCall wrap *gomock.Call
+type MockPaymentServiceDescCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockPaymentServiceConvertCallbackTransactionToDomainCall) Return(arg0 domain.Payment, arg1 error) *MockPaymentServiceConvertCallbackTransactionToDomainCall {
-	c.Call = c.Call.Return(arg0, arg1)
+func (c *MockPaymentServiceDescCall) Return(arg0 string) *MockPaymentServiceDescCall {
+	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockPaymentServiceConvertCallbackTransactionToDomainCall) Do(f func(*payments.Transaction) (domain.Payment, error)) *MockPaymentServiceConvertCallbackTransactionToDomainCall {
+func (c *MockPaymentServiceDescCall) Do(f func() string) *MockPaymentServiceDescCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockPaymentServiceConvertCallbackTransactionToDomainCall) DoAndReturn(f func(*payments.Transaction) (domain.Payment, error)) *MockPaymentServiceConvertCallbackTransactionToDomainCall {
+func (c *MockPaymentServiceDescCall) DoAndReturn(f func() string) *MockPaymentServiceDescCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -529,10 +684,10 @@ func (c *MockPaymentServiceNameCall) DoAndReturn(f func() domain.ChannelType) *M
 }
 
 // Prepay mocks base method.
-func (m *MockPaymentService) Prepay(ctx context.Context, pmt domain.Payment) (string, error) {
+func (m *MockPaymentService) Prepay(ctx context.Context, pmt domain.Payment) (any, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Prepay", ctx, pmt)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(any)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -550,19 +705,19 @@ type MockPaymentServicePrepayCall struct {
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockPaymentServicePrepayCall) Return(arg0 string, arg1 error) *MockPaymentServicePrepayCall {
+func (c *MockPaymentServicePrepayCall) Return(arg0 any, arg1 error) *MockPaymentServicePrepayCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockPaymentServicePrepayCall) Do(f func(context.Context, domain.Payment) (string, error)) *MockPaymentServicePrepayCall {
+func (c *MockPaymentServicePrepayCall) Do(f func(context.Context, domain.Payment) (any, error)) *MockPaymentServicePrepayCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockPaymentServicePrepayCall) DoAndReturn(f func(context.Context, domain.Payment) (string, error)) *MockPaymentServicePrepayCall {
+func (c *MockPaymentServicePrepayCall) DoAndReturn(f func(context.Context, domain.Payment) (any, error)) *MockPaymentServicePrepayCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -605,3 +760,41 @@ func (c *MockPaymentServiceQueryOrderBySNCall) DoAndReturn(f func(context.Contex
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// Refund mocks base method.
+func (m *MockPaymentService) Refund(ctx context.Context, pmt domain.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, pmt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentServiceMockRecorder) Refund(ctx, pmt any) *MockPaymentServiceRefundCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentService)(nil).Refund), ctx, pmt)
+	return &MockPaymentServiceRefundCall{Call: call}
+}
+
+// MockPaymentServiceRefundCall wrap *gomock.Call
+type MockPaymentServiceRefundCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockPaymentServiceRefundCall) Return(arg0 error) *MockPaymentServiceRefundCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockPaymentServiceRefundCall) Do(f func(context.Context, domain.Payment) error) *MockPaymentServiceRefundCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockPaymentServiceRefundCall) DoAndReturn(f func(context.Context, domain.Payment) error) *MockPaymentServiceRefundCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
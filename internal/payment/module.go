@@ -18,6 +18,7 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/domain"
 	"github.com/ecodeclub/webook/internal/payment/internal/job"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/ecodeclub/webook/internal/payment/internal/web"
 )
 
@@ -30,21 +31,31 @@ type (
 	ChannelType               = domain.ChannelType
 	Service                   = service.Service
 	SyncWechatOrderJob        = job.SyncWechatOrderJob
+	PaymentStatus             = domain.PaymentStatus
+	WechatBillDownloader      = wechat.TradeBillDownloader
 )
 
 const (
-	ChannelTypeCredit   = domain.ChannelTypeCredit
-	ChannelTypeWechat   = domain.ChannelTypeWechat
-	ChannelTypeWechatJS = domain.ChannelTypeWechatJS
+	ChannelTypeCredit      = domain.ChannelTypeCredit
+	ChannelTypeWechat      = domain.ChannelTypeWechat
+	ChannelTypeWechatJS    = domain.ChannelTypeWechatJS
+	ChannelTypeAlipay      = domain.ChannelTypeAlipay
+	ChannelTypeAlipayWap   = domain.ChannelTypeAlipayWap
+	ChannelTypeWechatPapay = domain.ChannelTypeWechatPapay
 
 	StatusUnpaid      = domain.PaymentStatusUnpaid
 	StatusProcessing  = domain.PaymentStatusProcessing
 	StatusPaidSuccess = domain.PaymentStatusPaidSuccess
 	StatusPaidFailed  = domain.PaymentStatusPaidFailed
+	StatusRefund      = domain.PaymentStatusRefund
 )
 
+var ErrRecordNotFound = service.ErrRecordNotFound
+
 type Module struct {
 	Hdl                *Handler
 	Svc                Service
 	SyncWechatOrderJob *SyncWechatOrderJob
+	// WechatBillDownloader 下载微信交易账单 recon模块对账使用
+	WechatBillDownloader *WechatBillDownloader
 }
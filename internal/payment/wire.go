@@ -29,6 +29,7 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/repository"
 	"github.com/ecodeclub/webook/internal/payment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/ecodeclub/webook/internal/payment/internal/web"
 	"github.com/ecodeclub/webook/internal/payment/ioc"
@@ -38,6 +39,7 @@ import (
 	"github.com/wechatpay-apiv3/wechatpay-go/core/notify"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/jsapi"
 	"github.com/wechatpay-apiv3/wechatpay-go/services/payments/native"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/refunddomestic"
 	"gorm.io/gorm"
 )
 
@@ -53,6 +55,9 @@ func InitModule(db *egorm.Component,
 		// 构建Svc
 		// 构造NativePaymentService
 		ioc.InitWechatClient,
+		// 构造退款API, Native和JSAPI共用
+		ioc.InitRefundApiService,
+		wire.Bind(new(wechat.RefundAPIService), new(*refunddomestic.RefundsApiService)),
 		ioc.InitNativeApiService,
 		wire.Bind(new(wechat.NativeAPIService), new(*native.NativeApiService)),
 		ioc.InitWechatNativePaymentService,
@@ -60,6 +65,12 @@ func InitModule(db *egorm.Component,
 		ioc.InitJSApiService,
 		wire.Bind(new(wechat.JSAPIService), new(*jsapi.JsapiApiService)),
 		ioc.InitWechatJSAPIPaymentService,
+		// 构造支付宝PC扫码及H5支付, 共用一个客户端
+		ioc.InitAlipayConfig,
+		ioc.InitAlipayClient,
+		wire.Bind(new(alipay.APIService), new(*alipay.Client)),
+		ioc.InitAlipayPCPaymentService,
+		ioc.InitAlipayWapPaymentService,
 		newPaymentServices,
 
 		wire.FieldsOf(new(*user.Module), "Svc"),
@@ -73,21 +84,30 @@ func InitModule(db *egorm.Component,
 		// 构建Hdl
 		ioc.InitWechatNotifyHandler,
 		wire.Bind(new(wechat.NotifyHandler), new(*notify.Handler)),
+		wire.Bind(new(alipay.NotifyHandler), new(*alipay.Client)),
 
 		web.NewHandler,
 
 		// 构建SyncWechatOrderJob
 		initSyncWechatOrderJob,
 
+		// 构建WechatBillDownloader
+		wechat.NewTradeBillDownloader,
+
 		wire.Struct(new(Module), "*"),
 	)
 	return new(Module), nil
 }
 
-func newPaymentServices(n *wechat.NativePaymentService, j *wechat.JSAPIPaymentService) map[ChannelType]service.PaymentService {
+func newPaymentServices(n *wechat.NativePaymentService,
+	j *wechat.JSAPIPaymentService,
+	ap *alipay.PCPaymentService,
+	aw *alipay.WapPaymentService) map[ChannelType]service.PaymentService {
 	return map[ChannelType]service.PaymentService{
-		ChannelTypeWechat:   n,
-		ChannelTypeWechatJS: j,
+		ChannelTypeWechat:    n,
+		ChannelTypeWechatJS:  j,
+		ChannelTypeAlipay:    ap,
+		ChannelTypeAlipayWap: aw,
 	}
 }
 
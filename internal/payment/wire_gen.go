@@ -17,6 +17,7 @@ import (
 	"github.com/ecodeclub/webook/internal/payment/internal/repository"
 	"github.com/ecodeclub/webook/internal/payment/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/payment/internal/service"
+	"github.com/ecodeclub/webook/internal/payment/internal/service/alipay"
 	"github.com/ecodeclub/webook/internal/payment/internal/service/wechat"
 	"github.com/ecodeclub/webook/internal/payment/internal/web"
 	"github.com/ecodeclub/webook/internal/payment/ioc"
@@ -30,13 +31,18 @@ import (
 func InitModule(db *gorm.DB, mq2 mq.MQ, c ecache.Cache, um *user.Module, cm *credit.Module) (*Module, error) {
 	wechatConfig := ioc.InitWechatConfig()
 	handler := ioc.InitWechatNotifyHandler(wechatConfig)
-	client := ioc.InitWechatClient(wechatConfig)
-	nativeApiService := ioc.InitNativeApiService(client)
-	nativePaymentService := ioc.InitWechatNativePaymentService(nativeApiService, wechatConfig)
-	jsapiApiService := ioc.InitJSApiService(client)
+	alipayConfig := ioc.InitAlipayConfig()
+	client := ioc.InitAlipayClient(alipayConfig)
+	coreClient := ioc.InitWechatClient(wechatConfig)
+	nativeApiService := ioc.InitNativeApiService(coreClient)
+	refundsApiService := ioc.InitRefundApiService(coreClient)
+	nativePaymentService := ioc.InitWechatNativePaymentService(nativeApiService, refundsApiService, wechatConfig)
+	jsapiApiService := ioc.InitJSApiService(coreClient)
 	userService := um.Svc
-	jsapiPaymentService := ioc.InitWechatJSAPIPaymentService(jsapiApiService, userService, wechatConfig)
-	v := newPaymentServices(nativePaymentService, jsapiPaymentService)
+	jsapiPaymentService := ioc.InitWechatJSAPIPaymentService(jsapiApiService, refundsApiService, userService, wechatConfig)
+	pcPaymentService := ioc.InitAlipayPCPaymentService(client, alipayConfig)
+	wapPaymentService := ioc.InitAlipayWapPaymentService(client, alipayConfig)
+	v := newPaymentServices(nativePaymentService, jsapiPaymentService, pcPaymentService, wapPaymentService)
 	serviceService := cm.Svc
 	generator := sequencenumber.NewGenerator()
 	daoPaymentDAO := initDAO(db)
@@ -46,22 +52,29 @@ func InitModule(db *gorm.DB, mq2 mq.MQ, c ecache.Cache, um *user.Module, cm *cre
 		return nil, err
 	}
 	service2 := service.NewService(v, serviceService, generator, paymentRepository, paymentEventProducer)
-	webHandler := web.NewHandler(handler, service2)
+	webHandler := web.NewHandler(handler, client, service2)
 	syncWechatOrderJob := initSyncWechatOrderJob(service2)
+	tradeBillDownloader := wechat.NewTradeBillDownloader(coreClient)
 	module := &Module{
-		Hdl:                webHandler,
-		Svc:                service2,
-		SyncWechatOrderJob: syncWechatOrderJob,
+		Hdl:                  webHandler,
+		Svc:                  service2,
+		SyncWechatOrderJob:   syncWechatOrderJob,
+		WechatBillDownloader: tradeBillDownloader,
 	}
 	return module, nil
 }
 
 // wire.go:
 
-func newPaymentServices(n *wechat.NativePaymentService, j *wechat.JSAPIPaymentService) map[ChannelType]service.PaymentService {
+func newPaymentServices(n *wechat.NativePaymentService,
+	j *wechat.JSAPIPaymentService,
+	ap *alipay.PCPaymentService,
+	aw *alipay.WapPaymentService) map[ChannelType]service.PaymentService {
 	return map[ChannelType]service.PaymentService{
-		ChannelTypeWechat:   n,
-		ChannelTypeWechatJS: j,
+		ChannelTypeWechat:    n,
+		ChannelTypeWechatJS:  j,
+		ChannelTypeAlipay:    ap,
+		ChannelTypeAlipayWap: aw,
 	}
 }
 
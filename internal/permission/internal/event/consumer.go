@@ -68,5 +68,8 @@ func (c *PermissionEventConsumer) Consume(ctx context.Context) error {
 		return err
 	}
 
+	if evt.Revoke {
+		return c.svc.RevokePersonalPermission(ctx, evt.toDomain())
+	}
 	return c.svc.CreatePersonalPermission(ctx, evt.toDomain())
 }
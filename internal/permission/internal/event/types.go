@@ -26,7 +26,8 @@ type PermissionEvent struct {
 	Uid    int64   `json:"uid"`
 	Biz    string  `json:"biz"` // project,interview
 	BizIds []int64 `json:"biz_ids"`
-	Action string  `json:"action"` // 购买项目商品, 兑换项目商品
+	Action string  `json:"action"`           // 购买项目商品, 兑换项目商品
+	Revoke bool    `json:"revoke,omitempty"` // 订单退款时为true, 表示收回权限
 }
 
 func (p PermissionEvent) toDomain() []domain.Permission {
@@ -242,6 +242,64 @@ func (s *ModuleTestSuite) TestConsumer_ConsumePermissionEvent() {
 				}, permissions)
 			},
 		},
+		{
+			name: "消费权限消息成功_退款收回权限",
+			before: func(t *testing.T) {
+				t.Helper()
+				uid := int64(44981)
+				err := s.repo.CreatePersonalPermission(context.Background(), []domain.Permission{
+					{
+						Uid:   uid,
+						Biz:   "project",
+						BizID: 31,
+						Desc:  "购买项目商品",
+					},
+					{
+						Uid:   uid,
+						Biz:   "project",
+						BizID: 32,
+						Desc:  "购买项目商品",
+					},
+				})
+				require.NoError(t, err)
+			},
+			newConsumerFunc: func(t *testing.T, ctrl *gomock.Controller, evt event.PermissionEvent) *event.PermissionEventConsumer {
+				t.Helper()
+
+				mockMQ := mocks.NewMockMQ(ctrl)
+
+				mockConsumer := mocks.NewMockConsumer(ctrl)
+				mockConsumer.EXPECT().Consume(gomock.Any()).Return(s.newPermissionEventMessage(t, evt), nil).Times(2)
+
+				mockMQ.EXPECT().Consumer(gomock.Any(), gomock.Any()).Return(mockConsumer, nil)
+
+				c, err := event.NewPermissionEventConsumer(service.NewPermissionService(s.repo), mockMQ)
+				require.NoError(t, err)
+				return c
+			},
+			evt: event.PermissionEvent{
+				Uid:    44981,
+				Biz:    "project",
+				BizIds: []int64{31},
+				Action: "退款项目商品",
+				Revoke: true,
+			},
+			errRequireFunc: require.NoError,
+			after: func(t *testing.T, evt event.PermissionEvent) {
+				t.Helper()
+				uid := int64(44981)
+				permissions, err := s.repo.FindPersonalPermissions(context.Background(), uid)
+				require.NoError(t, err)
+				require.ElementsMatch(t, []domain.Permission{
+					{
+						Uid:   uid,
+						Biz:   "project",
+						BizID: 32,
+						Desc:  "购买项目商品",
+					},
+				}, permissions)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -23,6 +23,7 @@ import (
 
 type PermissionDAO interface {
 	CreatePersonalPermission(ctx context.Context, ps []PersonalPermission) error
+	DeletePersonalPermission(ctx context.Context, ps []PersonalPermission) error
 	CountPersonalPermission(ctx context.Context, p PersonalPermission) (int64, error)
 	FindPersonalPermissions(ctx context.Context, uid int64) ([]PersonalPermission, error)
 }
@@ -48,6 +49,18 @@ func (g *gormPermissionDAO) CreatePersonalPermission(ctx context.Context, ps []P
 	})
 }
 
+func (g *gormPermissionDAO) DeletePersonalPermission(ctx context.Context, ps []PersonalPermission) error {
+	return g.db.WithContext(ctx).Transaction(func(tx *egorm.Component) error {
+		for _, p := range ps {
+			if err := tx.Where("uid = ? AND biz = ? AND biz_id = ?", p.Uid, p.Biz, p.BizId).
+				Delete(&PersonalPermission{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (g *gormPermissionDAO) CountPersonalPermission(ctx context.Context, p PersonalPermission) (int64, error) {
 	var count int64
 	result := g.db.WithContext(ctx).Model(&PersonalPermission{}).
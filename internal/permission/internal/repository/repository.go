@@ -24,6 +24,7 @@ import (
 
 type PermissionRepository interface {
 	CreatePersonalPermission(ctx context.Context, ps []domain.Permission) error
+	DeletePersonalPermission(ctx context.Context, ps []domain.Permission) error
 	HasPersonalPermission(ctx context.Context, p domain.Permission) (bool, error)
 	FindPersonalPermissions(ctx context.Context, uid int64) ([]domain.Permission, error)
 }
@@ -43,6 +44,13 @@ func (r *permissionRepository) CreatePersonalPermission(ctx context.Context, ps
 	return r.dao.CreatePersonalPermission(ctx, entities)
 }
 
+func (r *permissionRepository) DeletePersonalPermission(ctx context.Context, ps []domain.Permission) error {
+	entities := slice.Map(ps, func(idx int, src domain.Permission) dao.PersonalPermission {
+		return r.toEntity(src)
+	})
+	return r.dao.DeletePersonalPermission(ctx, entities)
+}
+
 func (r *permissionRepository) HasPersonalPermission(ctx context.Context, perm domain.Permission) (bool, error) {
 	count, err := r.dao.CountPersonalPermission(ctx, r.toEntity(perm))
 	return count > 0, err
@@ -24,6 +24,8 @@ import (
 //go:generate mockgen -source=service.go -package=permissionmocks -destination=../../mocks/permission.mock.go -typed Service
 type Service interface {
 	CreatePersonalPermission(ctx context.Context, ps []domain.Permission) error
+	// RevokePersonalPermission 收回个人权限,比如项目商品退款
+	RevokePersonalPermission(ctx context.Context, ps []domain.Permission) error
 	HasPermission(ctx context.Context, p domain.Permission) (bool, error)
 	FindPersonalPermissions(ctx context.Context, uid int64) (map[string][]domain.Permission, error)
 }
@@ -40,6 +42,10 @@ func (s *permissionService) CreatePersonalPermission(ctx context.Context, ps []d
 	return s.repo.CreatePersonalPermission(ctx, ps)
 }
 
+func (s *permissionService) RevokePersonalPermission(ctx context.Context, ps []domain.Permission) error {
+	return s.repo.DeletePersonalPermission(ctx, ps)
+}
+
 func (s *permissionService) HasPermission(ctx context.Context, p domain.Permission) (bool, error) {
 	return s.repo.HasPersonalPermission(ctx, p)
 }
@@ -5,6 +5,7 @@
 //
 //	mockgen -source=service.go -package=permissionmocks -destination=../../mocks/permission.mock.go -typed Service
 //
+
 // Package permissionmocks is a generated GoMock package.
 package permissionmocks
 
@@ -20,6 +21,7 @@ import (
 type MockService struct {
 	ctrl     *gomock.Controller
 	recorder *MockServiceMockRecorder
+	isgomock struct{}
 }
 
 // MockServiceMockRecorder is the mock recorder for MockService.
@@ -48,31 +50,31 @@ func (m *MockService) CreatePersonalPermission(ctx context.Context, ps []domain.
 }
 
 // CreatePersonalPermission indicates an expected call of CreatePersonalPermission.
-func (mr *MockServiceMockRecorder) CreatePersonalPermission(ctx, ps any) *ServiceCreatePersonalPermissionCall {
+func (mr *MockServiceMockRecorder) CreatePersonalPermission(ctx, ps any) *MockServiceCreatePersonalPermissionCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePersonalPermission", reflect.TypeOf((*MockService)(nil).CreatePersonalPermission), ctx, ps)
-	return &ServiceCreatePersonalPermissionCall{Call: call}
+	return &MockServiceCreatePersonalPermissionCall{Call: call}
 }
 
-// ServiceCreatePersonalPermissionCall wrap *gomock.Call
-type ServiceCreatePersonalPermissionCall struct {
+// MockServiceCreatePersonalPermissionCall wrap *gomock.Call
+type MockServiceCreatePersonalPermissionCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ServiceCreatePersonalPermissionCall) Return(arg0 error) *ServiceCreatePersonalPermissionCall {
+func (c *MockServiceCreatePersonalPermissionCall) Return(arg0 error) *MockServiceCreatePersonalPermissionCall {
 	c.Call = c.Call.Return(arg0)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ServiceCreatePersonalPermissionCall) Do(f func(context.Context, []domain.Permission) error) *ServiceCreatePersonalPermissionCall {
+func (c *MockServiceCreatePersonalPermissionCall) Do(f func(context.Context, []domain.Permission) error) *MockServiceCreatePersonalPermissionCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ServiceCreatePersonalPermissionCall) DoAndReturn(f func(context.Context, []domain.Permission) error) *ServiceCreatePersonalPermissionCall {
+func (c *MockServiceCreatePersonalPermissionCall) DoAndReturn(f func(context.Context, []domain.Permission) error) *MockServiceCreatePersonalPermissionCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -87,31 +89,31 @@ func (m *MockService) FindPersonalPermissions(ctx context.Context, uid int64) (m
 }
 
 // FindPersonalPermissions indicates an expected call of FindPersonalPermissions.
-func (mr *MockServiceMockRecorder) FindPersonalPermissions(ctx, uid any) *ServiceFindPersonalPermissionsCall {
+func (mr *MockServiceMockRecorder) FindPersonalPermissions(ctx, uid any) *MockServiceFindPersonalPermissionsCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPersonalPermissions", reflect.TypeOf((*MockService)(nil).FindPersonalPermissions), ctx, uid)
-	return &ServiceFindPersonalPermissionsCall{Call: call}
+	return &MockServiceFindPersonalPermissionsCall{Call: call}
 }
 
-// ServiceFindPersonalPermissionsCall wrap *gomock.Call
-type ServiceFindPersonalPermissionsCall struct {
+// MockServiceFindPersonalPermissionsCall wrap *gomock.Call
+type MockServiceFindPersonalPermissionsCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ServiceFindPersonalPermissionsCall) Return(arg0 map[string][]domain.Permission, arg1 error) *ServiceFindPersonalPermissionsCall {
+func (c *MockServiceFindPersonalPermissionsCall) Return(arg0 map[string][]domain.Permission, arg1 error) *MockServiceFindPersonalPermissionsCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ServiceFindPersonalPermissionsCall) Do(f func(context.Context, int64) (map[string][]domain.Permission, error)) *ServiceFindPersonalPermissionsCall {
+func (c *MockServiceFindPersonalPermissionsCall) Do(f func(context.Context, int64) (map[string][]domain.Permission, error)) *MockServiceFindPersonalPermissionsCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ServiceFindPersonalPermissionsCall) DoAndReturn(f func(context.Context, int64) (map[string][]domain.Permission, error)) *ServiceFindPersonalPermissionsCall {
+func (c *MockServiceFindPersonalPermissionsCall) DoAndReturn(f func(context.Context, int64) (map[string][]domain.Permission, error)) *MockServiceFindPersonalPermissionsCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -126,31 +128,69 @@ func (m *MockService) HasPermission(ctx context.Context, p domain.Permission) (b
 }
 
 // HasPermission indicates an expected call of HasPermission.
-func (mr *MockServiceMockRecorder) HasPermission(ctx, p any) *ServiceHasPermissionCall {
+func (mr *MockServiceMockRecorder) HasPermission(ctx, p any) *MockServiceHasPermissionCall {
 	mr.mock.ctrl.T.Helper()
 	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPermission", reflect.TypeOf((*MockService)(nil).HasPermission), ctx, p)
-	return &ServiceHasPermissionCall{Call: call}
+	return &MockServiceHasPermissionCall{Call: call}
 }
 
-// ServiceHasPermissionCall wrap *gomock.Call
-type ServiceHasPermissionCall struct {
+// MockServiceHasPermissionCall wrap *gomock.Call
+type MockServiceHasPermissionCall struct {
 	*gomock.Call
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *ServiceHasPermissionCall) Return(arg0 bool, arg1 error) *ServiceHasPermissionCall {
+func (c *MockServiceHasPermissionCall) Return(arg0 bool, arg1 error) *MockServiceHasPermissionCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *ServiceHasPermissionCall) Do(f func(context.Context, domain.Permission) (bool, error)) *ServiceHasPermissionCall {
+func (c *MockServiceHasPermissionCall) Do(f func(context.Context, domain.Permission) (bool, error)) *MockServiceHasPermissionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceHasPermissionCall) DoAndReturn(f func(context.Context, domain.Permission) (bool, error)) *MockServiceHasPermissionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// RevokePersonalPermission mocks base method.
+func (m *MockService) RevokePersonalPermission(ctx context.Context, ps []domain.Permission) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokePersonalPermission", ctx, ps)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokePersonalPermission indicates an expected call of RevokePersonalPermission.
+func (mr *MockServiceMockRecorder) RevokePersonalPermission(ctx, ps any) *MockServiceRevokePersonalPermissionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokePersonalPermission", reflect.TypeOf((*MockService)(nil).RevokePersonalPermission), ctx, ps)
+	return &MockServiceRevokePersonalPermissionCall{Call: call}
+}
+
+// MockServiceRevokePersonalPermissionCall wrap *gomock.Call
+type MockServiceRevokePersonalPermissionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceRevokePersonalPermissionCall) Return(arg0 error) *MockServiceRevokePersonalPermissionCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceRevokePersonalPermissionCall) Do(f func(context.Context, []domain.Permission) error) *MockServiceRevokePersonalPermissionCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *ServiceHasPermissionCall) DoAndReturn(f func(context.Context, domain.Permission) (bool, error)) *ServiceHasPermissionCall {
+func (c *MockServiceRevokePersonalPermissionCall) DoAndReturn(f func(context.Context, []domain.Permission) error) *MockServiceRevokePersonalPermissionCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
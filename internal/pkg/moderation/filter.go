@@ -0,0 +1,136 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// ErrBlocked 内容没有通过审核，调用方可以用 errors.Is 判断
+var ErrBlocked = errors.New("内容包含敏感信息")
+
+type Action string
+
+const (
+	ActionPass  Action = "pass"
+	ActionMask  Action = "mask"
+	ActionBlock Action = "block"
+)
+
+const (
+	SourceDict     = "dict"
+	SourceProvider = "provider"
+)
+
+// Decision 审核的结论
+type Decision struct {
+	Action Action
+	// 哪里给出的结论，SourceDict 或者 SourceProvider，通过的时候为空
+	Source string
+	// 命中的敏感词，或者审核服务给出的标签
+	Hits []string
+	// 打码之后的文本，只有 ActionMask 的时候和原文不同
+	Text string
+}
+
+func (d Decision) Blocked() bool {
+	return d.Action == ActionBlock
+}
+
+// Err 被拦截的时候返回 ErrBlocked，否则返回 nil
+func (d Decision) Err() error {
+	if d.Blocked() {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// Filter 内容审核，评论、面经以及 AI 的输入输出都可以使用
+//
+//go:generate mockgen -source=./filter.go -destination=./mocks/filter.mock.go -package=moderationmocks -typed=true Filter
+type Filter interface {
+	Check(ctx context.Context, text string) (Decision, error)
+}
+
+// Dict 本地的敏感词词典
+type Dict struct {
+	// 命中之后直接拦截
+	Block []string `yaml:"block"`
+	// 命中之后打码
+	Mask []string `yaml:"mask"`
+}
+
+// ProviderResult 第三方审核服务的结论
+type ProviderResult struct {
+	Blocked bool
+	Labels  []string
+}
+
+// Provider 第三方的审核服务，例如云厂商的内容安全接口
+type Provider interface {
+	Moderate(ctx context.Context, text string) (ProviderResult, error)
+}
+
+// DictFilter 先用本地的词典过滤，再交给第三方审核服务。
+// 第三方审核服务是可选的，调用失败的时候以本地词典的结论为准
+type DictFilter struct {
+	block    *Matcher
+	mask     *Matcher
+	provider Provider
+	logger   *elog.Component
+}
+
+// NewDictFilter provider 可以为 nil
+func NewDictFilter(dict Dict, provider Provider) *DictFilter {
+	return &DictFilter{
+		block:    NewMatcher(dict.Block),
+		mask:     NewMatcher(dict.Mask),
+		provider: provider,
+		logger:   elog.DefaultLogger,
+	}
+}
+
+func (f *DictFilter) Check(ctx context.Context, text string) (Decision, error) {
+	if text == "" {
+		return Decision{Action: ActionPass}, nil
+	}
+	if hits := f.block.FindAll(text); len(hits) > 0 {
+		return Decision{Action: ActionBlock, Source: SourceDict, Hits: f.words(hits), Text: text}, nil
+	}
+	res := Decision{Action: ActionPass, Text: text}
+	if hits := f.mask.FindAll(text); len(hits) > 0 {
+		res = Decision{Action: ActionMask, Source: SourceDict, Hits: f.words(hits), Text: Mask(text, hits)}
+	}
+	if f.provider == nil {
+		return res, nil
+	}
+	pr, err := f.provider.Moderate(ctx, res.Text)
+	if err != nil {
+		if ctx.Err() != nil {
+			return Decision{}, err
+		}
+		f.logger.Error("调用内容审核服务失败，使用本地词典的结论", elog.FieldErr(err))
+		return res, nil
+	}
+	if pr.Blocked {
+		return Decision{Action: ActionBlock, Source: SourceProvider, Hits: pr.Labels, Text: text}, nil
+	}
+	return res, nil
+}
+
+// words 去重之后的敏感词
+func (f *DictFilter) words(hits []Hit) []string {
+	words := make([]string, 0, len(hits))
+	seen := make(map[string]struct{}, len(hits))
+	for _, h := range hits {
+		if _, ok := seen[h.Word]; ok {
+			continue
+		}
+		seen[h.Word] = struct{}{}
+		words = append(words, h.Word)
+	}
+	return words
+}
+
+var _ Filter = &DictFilter{}
@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	res ProviderResult
+	err error
+	// 收到的文本
+	text string
+}
+
+func (p *stubProvider) Moderate(ctx context.Context, text string) (ProviderResult, error) {
+	p.text = text
+	return p.res, p.err
+}
+
+func TestDictFilter_Check(t *testing.T) {
+	dict := Dict{
+		Block: []string{"赌博"},
+		Mask:  []string{"微信", "加我"},
+	}
+	testCases := []struct {
+		name     string
+		provider *stubProvider
+		text     string
+		want     Decision
+		// 交给第三方审核服务的文本
+		wantProviderText string
+	}{
+		{
+			name: "通过",
+			text: "MySQL 的索引",
+			want: Decision{Action: ActionPass, Text: "MySQL 的索引"},
+		},
+		{
+			name: "拦截",
+			text: "线上赌博，加我微信",
+			want: Decision{Action: ActionBlock, Source: SourceDict, Hits: []string{"赌博"}, Text: "线上赌博，加我微信"},
+		},
+		{
+			name:     "打码之后交给第三方审核",
+			provider: &stubProvider{},
+			text:     "加我微信聊，微信号 abc",
+			want: Decision{
+				Action: ActionMask, Source: SourceDict,
+				Hits: []string{"加我", "微信"},
+				Text: "****聊，**号 abc",
+			},
+			wantProviderText: "****聊，**号 abc",
+		},
+		{
+			name:             "第三方审核拦截",
+			provider:         &stubProvider{res: ProviderResult{Blocked: true, Labels: []string{"politics"}}},
+			text:             "某些内容",
+			want:             Decision{Action: ActionBlock, Source: SourceProvider, Hits: []string{"politics"}, Text: "某些内容"},
+			wantProviderText: "某些内容",
+		},
+		{
+			name:             "第三方审核失败，以本地词典为准",
+			provider:         &stubProvider{err: errors.New("mock error")},
+			text:             "某些内容",
+			want:             Decision{Action: ActionPass, Text: "某些内容"},
+			wantProviderText: "某些内容",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var provider Provider
+			if tc.provider != nil {
+				provider = tc.provider
+			}
+			f := NewDictFilter(dict, provider)
+			res, err := f.Check(context.Background(), tc.text)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+			if tc.provider != nil {
+				assert.Equal(t, tc.wantProviderText, tc.provider.text)
+			}
+			assert.Equal(t, tc.want.Blocked(), errors.Is(res.Err(), ErrBlocked))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package moderation
+
+import (
+	"unicode"
+)
+
+// Hit 文本中命中的一个敏感词，Start 和 End 是 rune 的下标，左闭右开
+type Hit struct {
+	Word  string
+	Start int
+	End   int
+}
+
+type node struct {
+	children map[rune]*node
+	fail     *node
+	// 以这个节点结尾的敏感词，包括通过 fail 指针可以到达的
+	outputs []string
+}
+
+// Matcher 基于 Aho-Corasick 自动机的多模式匹配，一遍扫描就能找出所有的敏感词。
+// 匹配的时候忽略大小写。构造之后是只读的，可以并发使用
+type Matcher struct {
+	root *node
+}
+
+func NewMatcher(words []string) *Matcher {
+	root := &node{children: map[rune]*node{}}
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		cur := root
+		for _, r := range w {
+			r = unicode.ToLower(r)
+			next, ok := cur.children[r]
+			if !ok {
+				next = &node{children: map[rune]*node{}}
+				cur.children[r] = next
+			}
+			cur = next
+		}
+		cur.outputs = append(cur.outputs, w)
+	}
+	// 按照层次遍历构造 fail 指针
+	queue := make([]*node, 0, len(root.children))
+	for _, child := range root.children {
+		child.fail = root
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range cur.children {
+			fail := cur.fail
+			for fail != nil && fail.children[r] == nil {
+				fail = fail.fail
+			}
+			if fail == nil {
+				child.fail = root
+			} else {
+				child.fail = fail.children[r]
+			}
+			child.outputs = append(child.outputs, child.fail.outputs...)
+			queue = append(queue, child)
+		}
+	}
+	return &Matcher{root: root}
+}
+
+// FindAll 找出文本中所有的敏感词，允许重叠
+func (m *Matcher) FindAll(text string) []Hit {
+	var hits []Hit
+	cur := m.root
+	i := 0
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		for cur != m.root && cur.children[r] == nil {
+			cur = cur.fail
+		}
+		if next, ok := cur.children[r]; ok {
+			cur = next
+		}
+		for _, w := range cur.outputs {
+			end := i + 1
+			hits = append(hits, Hit{Word: w, Start: end - len([]rune(w)), End: end})
+		}
+		i++
+	}
+	return hits
+}
+
+// Mask 把命中的部分替换成 *
+func Mask(text string, hits []Hit) string {
+	if len(hits) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, h := range hits {
+		for i := h.Start; i < h.End; i++ {
+			runes[i] = '*'
+		}
+	}
+	return string(runes)
+}
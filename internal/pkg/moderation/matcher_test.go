@@ -0,0 +1,65 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_FindAll(t *testing.T) {
+	testCases := []struct {
+		name  string
+		words []string
+		text  string
+		want  []Hit
+	}{
+		{
+			name:  "没有命中",
+			words: []string{"赌博", "代考"},
+			text:  "今天面试了 MySQL",
+		},
+		{
+			name:  "多个敏感词",
+			words: []string{"赌博", "代考"},
+			text:  "提供代考和赌博服务",
+			want: []Hit{
+				{Word: "代考", Start: 2, End: 4},
+				{Word: "赌博", Start: 5, End: 7},
+			},
+		},
+		{
+			name:  "重叠以及依赖 fail 指针的匹配",
+			words: []string{"he", "she", "his", "hers"},
+			text:  "ushers",
+			want: []Hit{
+				{Word: "she", Start: 1, End: 4},
+				{Word: "he", Start: 2, End: 4},
+				{Word: "hers", Start: 2, End: 6},
+			},
+		},
+		{
+			name:  "忽略大小写",
+			words: []string{"VPN"},
+			text:  "免费vpn",
+			want: []Hit{
+				{Word: "VPN", Start: 2, End: 5},
+			},
+		},
+		{
+			name: "空词典",
+			text: "任何内容",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMatcher(tc.words)
+			assert.ElementsMatch(t, tc.want, m.FindAll(tc.text))
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	m := NewMatcher([]string{"代考", "考试"})
+	text := "代考试卷"
+	assert.Equal(t, "***卷", Mask(text, m.FindAll(text)))
+}
@@ -0,0 +1,144 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./filter.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./filter.go -destination=./mocks/filter.mock.go -package=moderationmocks -typed=true Filter
+//
+
+// Package moderationmocks is a generated GoMock package.
+package moderationmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	moderation "github.com/ecodeclub/webook/internal/pkg/moderation"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFilter is a mock of Filter interface.
+type MockFilter struct {
+	ctrl     *gomock.Controller
+	recorder *MockFilterMockRecorder
+	isgomock struct{}
+}
+
+// MockFilterMockRecorder is the mock recorder for MockFilter.
+type MockFilterMockRecorder struct {
+	mock *MockFilter
+}
+
+// NewMockFilter creates a new mock instance.
+func NewMockFilter(ctrl *gomock.Controller) *MockFilter {
+	mock := &MockFilter{ctrl: ctrl}
+	mock.recorder = &MockFilterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFilter) EXPECT() *MockFilterMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockFilter) Check(ctx context.Context, text string) (moderation.Decision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, text)
+	ret0, _ := ret[0].(moderation.Decision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockFilterMockRecorder) Check(ctx, text any) *MockFilterCheckCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockFilter)(nil).Check), ctx, text)
+	return &MockFilterCheckCall{Call: call}
+}
+
+// MockFilterCheckCall wrap *gomock.Call
+type MockFilterCheckCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockFilterCheckCall) Return(arg0 moderation.Decision, arg1 error) *MockFilterCheckCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockFilterCheckCall) Do(f func(context.Context, string) (moderation.Decision, error)) *MockFilterCheckCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockFilterCheckCall) DoAndReturn(f func(context.Context, string) (moderation.Decision, error)) *MockFilterCheckCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockProvider is a mock of Provider interface.
+type MockProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockProviderMockRecorder is the mock recorder for MockProvider.
+type MockProviderMockRecorder struct {
+	mock *MockProvider
+}
+
+// NewMockProvider creates a new mock instance.
+func NewMockProvider(ctrl *gomock.Controller) *MockProvider {
+	mock := &MockProvider{ctrl: ctrl}
+	mock.recorder = &MockProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProvider) EXPECT() *MockProviderMockRecorder {
+	return m.recorder
+}
+
+// Moderate mocks base method.
+func (m *MockProvider) Moderate(ctx context.Context, text string) (moderation.ProviderResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Moderate", ctx, text)
+	ret0, _ := ret[0].(moderation.ProviderResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Moderate indicates an expected call of Moderate.
+func (mr *MockProviderMockRecorder) Moderate(ctx, text any) *MockProviderModerateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Moderate", reflect.TypeOf((*MockProvider)(nil).Moderate), ctx, text)
+	return &MockProviderModerateCall{Call: call}
+}
+
+// MockProviderModerateCall wrap *gomock.Call
+type MockProviderModerateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockProviderModerateCall) Return(arg0 moderation.ProviderResult, arg1 error) *MockProviderModerateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockProviderModerateCall) Do(f func(context.Context, string) (moderation.ProviderResult, error)) *MockProviderModerateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockProviderModerateCall) DoAndReturn(f func(context.Context, string) (moderation.ProviderResult, error)) *MockProviderModerateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
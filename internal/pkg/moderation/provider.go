@@ -0,0 +1,61 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPProvider 通过 HTTP 接口调用第三方审核服务。
+// 请求体是 {"text": "..."}，响应体是 {"blocked": true, "labels": ["..."]}，
+// 对接具体云厂商的时候，由网关负责转换格式
+type HTTPProvider struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func NewHTTPProvider(endpoint, token string) *HTTPProvider {
+	return &HTTPProvider{
+		endpoint: endpoint,
+		token:    token,
+		client:   &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+func (p *HTTPProvider) Moderate(ctx context.Context, text string) (ProviderResult, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return ProviderResult{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return ProviderResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return ProviderResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ProviderResult{}, fmt.Errorf("内容审核服务返回了 %d", resp.StatusCode)
+	}
+	var res struct {
+		Blocked bool     `json:"blocked"`
+		Labels  []string `json:"labels"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return ProviderResult{}, err
+	}
+	return ProviderResult{Blocked: res.Blocked, Labels: res.Labels}, nil
+}
+
+var _ Provider = &HTTPProvider{}
@@ -0,0 +1,66 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type ExportStatus uint8
+
+func (s ExportStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	ExportStatusUnknown ExportStatus = iota
+	ExportStatusProcessing
+	ExportStatusSuccess
+	ExportStatusFailed
+)
+
+// Export 一次个人数据的导出。每个用户只保留最近一次的导出
+type Export struct {
+	Id     int64
+	Uid    int64
+	Status ExportStatus
+	// 压缩包的大小，单位是字节
+	Size  int64
+	Ctime int64
+	Utime int64
+}
+
+type DeletionStatus uint8
+
+func (s DeletionStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	DeletionStatusUnknown DeletionStatus = iota
+	// DeletionStatusPending 冷静期内，用户可以撤销
+	DeletionStatusPending
+	// DeletionStatusExecuting 正在清除各个模块的数据
+	DeletionStatusExecuting
+	DeletionStatusDone
+	DeletionStatusCancelled
+)
+
+// Deletion 注销账号的申请。冷静期过了之后才会真的清除数据
+type Deletion struct {
+	Id     int64
+	Uid    int64
+	Status DeletionStatus
+	// 冷静期结束的时间，毫秒
+	ExecuteAt int64
+	Ctime     int64
+	Utime     int64
+}
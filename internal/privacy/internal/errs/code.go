@@ -0,0 +1,27 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+var (
+	ExportInProgress = ErrorCode{Code: 421001, Msg: "正在导出个人数据，请稍后再试"}
+	ExportNotFound   = ErrorCode{Code: 421002, Msg: "导出文件不存在或者已经过期，请重新导出"}
+	DeletionNotFound = ErrorCode{Code: 421003, Msg: "没有可以撤销的注销申请"}
+	SystemError      = ErrorCode{Code: 521001, Msg: "系统错误"}
+)
+
+type ErrorCode struct {
+	Code int
+	Msg  string
+}
@@ -0,0 +1,274 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/privacy/internal/domain"
+	"github.com/ecodeclub/webook/internal/privacy/internal/errs"
+	"github.com/ecodeclub/webook/internal/privacy/internal/job"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/privacy/internal/service"
+	"github.com/ecodeclub/webook/internal/privacy/internal/web"
+	"github.com/ecodeclub/webook/internal/test"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ego-component/egorm"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/server/egin"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const uid = 123
+
+type fakeHook struct {
+	name   string
+	data   any
+	err    error
+	erased []int64
+}
+
+func (f *fakeHook) Name() string {
+	return f.name
+}
+
+func (f *fakeHook) Export(ctx context.Context, uid int64) (any, error) {
+	return f.data, f.err
+}
+
+func (f *fakeHook) Erase(ctx context.Context, uid int64) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.erased = append(f.erased, uid)
+	return nil
+}
+
+type PrivacyTestSuite struct {
+	suite.Suite
+	db     *egorm.Component
+	server *egin.Component
+	hooks  []*fakeHook
+	job    *job.ExecuteDeletionJob
+}
+
+func (s *PrivacyTestSuite) SetupSuite() {
+	s.db = testioc.InitDB()
+	require.NoError(s.T(), dao.InitTables(s.db))
+}
+
+func (s *PrivacyTestSuite) SetupTest() {
+	s.hooks = []*fakeHook{
+		{name: "resume", data: map[string]any{"projects": []string{"webook"}}},
+		{name: "user", data: map[string]any{"nickname": "大明"}},
+	}
+	hooks := make([]service.Hook, 0, len(s.hooks))
+	for _, h := range s.hooks {
+		hooks = append(hooks, h)
+	}
+	exportRepo := repository.NewExportRepository(dao.NewGORMExportDAO(s.db))
+	deletionRepo := repository.NewDeletionRepository(dao.NewGORMDeletionDAO(s.db))
+	exportSvc := service.NewExportService(exportRepo, hooks)
+	deletionSvc := service.NewDeletionService(deletionRepo, exportRepo, hooks, time.Hour)
+	s.job = job.NewExecuteDeletionJob(deletionSvc, 10)
+
+	hdl := web.NewHandler(exportSvc, deletionSvc)
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	server := egin.Load("server").Build()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("_session", session.NewMemorySession(session.Claims{
+			Uid: uid,
+		}))
+	})
+	hdl.PrivateRoutes(server.Engine)
+	s.server = server
+}
+
+func (s *PrivacyTestSuite) TearDownTest() {
+	s.NoError(s.db.Exec("TRUNCATE TABLE `privacy_exports`").Error)
+	s.NoError(s.db.Exec("TRUNCATE TABLE `privacy_deletions`").Error)
+}
+
+func (s *PrivacyTestSuite) TestExport() {
+	t := s.T()
+	id := post[int64](s, "/privacy/export/apply", nil).Data
+	require.True(t, id > 0)
+
+	var latest web.Export
+	require.Eventually(t, func() bool {
+		latest = post[web.Export](s, "/privacy/export/latest", nil).Data
+		return latest.Status != domain.ExportStatusProcessing.ToUint8()
+	}, time.Second*5, time.Millisecond*100)
+	s.Equal(id, latest.Id)
+	s.Equal(domain.ExportStatusSuccess.ToUint8(), latest.Status)
+	s.True(latest.Size > 0)
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, "/privacy/export/download", iox.NewJSONReader(web.ExportReq{Id: id}))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	s.server.ServeHTTP(recorder, req)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal("application/zip", recorder.Header().Get("Content-Type"))
+
+	data := recorder.Body.Bytes()
+	s.Equal(latest.Size, int64(len(data)))
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := make(map[string]string, len(r.File))
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[f.Name] = string(content)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s.Equal([]string{"resume.json", "user.json"}, names)
+	s.Contains(files["user.json"], "大明")
+
+	// 再次导出，之前的会被删掉
+	newId := post[int64](s, "/privacy/export/apply", nil).Data
+	s.NotEqual(id, newId)
+	require.Eventually(t, func() bool {
+		latest = post[web.Export](s, "/privacy/export/latest", nil).Data
+		return latest.Status != domain.ExportStatusProcessing.ToUint8()
+	}, time.Second*5, time.Millisecond*100)
+	res := post[any](s, "/privacy/export/download", web.ExportReq{Id: id})
+	s.Equal(errs.ExportNotFound.Code, res.Code)
+}
+
+func (s *PrivacyTestSuite) TestExportFailed() {
+	t := s.T()
+	s.hooks[0].err = errors.New("mock error")
+	id := post[int64](s, "/privacy/export/apply", nil).Data
+	var latest web.Export
+	require.Eventually(t, func() bool {
+		latest = post[web.Export](s, "/privacy/export/latest", nil).Data
+		return latest.Status != domain.ExportStatusProcessing.ToUint8()
+	}, time.Second*5, time.Millisecond*100)
+	s.Equal(id, latest.Id)
+	s.Equal(domain.ExportStatusFailed.ToUint8(), latest.Status)
+
+	res := post[any](s, "/privacy/export/download", web.ExportReq{Id: id})
+	s.Equal(errs.ExportNotFound.Code, res.Code)
+}
+
+func (s *PrivacyTestSuite) TestExportInProgress() {
+	now := time.Now().UnixMilli()
+	s.NoError(s.db.Create(&dao.Export{
+		Uid:    uid,
+		Status: domain.ExportStatusProcessing.ToUint8(),
+		Ctime:  now,
+		Utime:  now,
+	}).Error)
+	res := post[any](s, "/privacy/export/apply", nil)
+	s.Equal(errs.ExportInProgress.Code, res.Code)
+}
+
+func (s *PrivacyTestSuite) TestDeletion() {
+	t := s.T()
+	d := post[web.Deletion](s, "/privacy/deletion/apply", nil).Data
+	s.Equal(domain.DeletionStatusPending.ToUint8(), d.Status)
+	s.True(d.ExecuteAt > time.Now().UnixMilli())
+
+	// 重复申请返回之前的
+	again := post[web.Deletion](s, "/privacy/deletion/apply", nil).Data
+	s.Equal(d.Id, again.Id)
+
+	status := post[web.Deletion](s, "/privacy/deletion/status", nil).Data
+	s.Equal(d.Id, status.Id)
+
+	// 冷静期内不会执行
+	require.NoError(t, s.job.Run(context.Background()))
+	s.Empty(s.hooks[1].erased)
+
+	res := post[any](s, "/privacy/deletion/cancel", nil)
+	s.Equal(0, res.Code)
+	status = post[web.Deletion](s, "/privacy/deletion/status", nil).Data
+	s.Equal(domain.DeletionStatusUnknown.ToUint8(), status.Status)
+	res = post[any](s, "/privacy/deletion/cancel", nil)
+	s.Equal(errs.DeletionNotFound.Code, res.Code)
+
+	// 重新申请，模拟冷静期结束
+	d = post[web.Deletion](s, "/privacy/deletion/apply", nil).Data
+	require.NoError(t, s.db.Model(&dao.Deletion{}).Where("id = ?", d.Id).
+		Update("execute_at", time.Now().Add(-time.Minute).UnixMilli()).Error)
+
+	// 第一次失败，停在执行中
+	s.hooks[0].err = errors.New("mock error")
+	require.NoError(t, s.job.Run(context.Background()))
+	s.Empty(s.hooks[1].erased)
+	s.Equal(domain.DeletionStatusExecuting.ToUint8(), s.findDeletion(d.Id).Status)
+	// 执行中的不能撤销
+	res = post[any](s, "/privacy/deletion/cancel", nil)
+	s.Equal(errs.DeletionNotFound.Code, res.Code)
+
+	// 还没到重试的时间
+	s.hooks[0].err = nil
+	require.NoError(t, s.job.Run(context.Background()))
+	s.Empty(s.hooks[0].erased)
+
+	require.NoError(t, s.db.Model(&dao.Deletion{}).Where("id = ?", d.Id).
+		Update("utime", time.Now().Add(-time.Hour).UnixMilli()).Error)
+	require.NoError(t, s.job.Run(context.Background()))
+	s.Equal([]int64{uid}, s.hooks[0].erased)
+	s.Equal([]int64{uid}, s.hooks[1].erased)
+	s.Equal(domain.DeletionStatusDone.ToUint8(), s.findDeletion(d.Id).Status)
+}
+
+func (s *PrivacyTestSuite) findDeletion(id int64) dao.Deletion {
+	var d dao.Deletion
+	require.NoError(s.T(), s.db.Where("id = ?", id).First(&d).Error)
+	return d
+}
+
+func post[T any](s *PrivacyTestSuite, path string, body any) test.Result[T] {
+	if body == nil {
+		body = map[string]any{}
+	}
+	req, err := http.NewRequest(http.MethodPost, path, iox.NewJSONReader(body))
+	require.NoError(s.T(), err)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := test.NewJSONResponseRecorder[T]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(s.T(), http.StatusOK, recorder.Code)
+	return recorder.MustScan()
+}
+
+func TestPrivacy(t *testing.T) {
+	suite.Run(t, new(PrivacyTestSuite))
+}
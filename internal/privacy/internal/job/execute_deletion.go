@@ -0,0 +1,54 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ecodeclub/webook/internal/privacy/internal/service"
+	"github.com/gotomicro/ego/task/ecron"
+)
+
+var _ ecron.NamedJob = (*ExecuteDeletionJob)(nil)
+
+// ExecuteDeletionJob 清除冷静期已经结束的账号的数据
+type ExecuteDeletionJob struct {
+	svc   service.DeletionService
+	limit int
+}
+
+func NewExecuteDeletionJob(svc service.DeletionService, limit int) *ExecuteDeletionJob {
+	return &ExecuteDeletionJob{
+		svc:   svc,
+		limit: limit,
+	}
+}
+
+func (e *ExecuteDeletionJob) Name() string {
+	return "ExecuteDeletionJob"
+}
+
+func (e *ExecuteDeletionJob) Run(ctx context.Context) error {
+	for {
+		cnt, err := e.svc.ExecuteDue(ctx, e.limit)
+		if err != nil {
+			return fmt.Errorf("注销账号失败: %w", err)
+		}
+		if cnt < e.limit {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ego-component/egorm"
+)
+
+type DeletionDAO interface {
+	Create(ctx context.Context, d Deletion) (int64, error)
+	// FindByStatus 用户最近一次处于 status 状态的申请
+	FindByStatus(ctx context.Context, uid int64, status uint8) (Deletion, error)
+	// UpdateStatus 只有当前状态是 from 的时候才会更新，返回是否更新成功
+	UpdateStatus(ctx context.Context, id int64, from, to uint8) (bool, error)
+	// FindDue 冷静期已经结束的申请，以及 utime 之前就开始执行但是一直没有结束的申请
+	FindDue(ctx context.Context, executeAt, utime int64, limit int) ([]Deletion, error)
+}
+
+type GORMDeletionDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMDeletionDAO(db *egorm.Component) DeletionDAO {
+	return &GORMDeletionDAO{db: db}
+}
+
+func (dao *GORMDeletionDAO) Create(ctx context.Context, d Deletion) (int64, error) {
+	now := time.Now().UnixMilli()
+	d.Ctime = now
+	d.Utime = now
+	err := dao.db.WithContext(ctx).Create(&d).Error
+	return d.Id, err
+}
+
+func (dao *GORMDeletionDAO) FindByStatus(ctx context.Context, uid int64, status uint8) (Deletion, error) {
+	var res Deletion
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND status = ?", uid, status).
+		Order("id DESC").First(&res).Error
+	return res, err
+}
+
+func (dao *GORMDeletionDAO) UpdateStatus(ctx context.Context, id int64, from, to uint8) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&Deletion{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{
+			"status": to,
+			"utime":  time.Now().UnixMilli(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (dao *GORMDeletionDAO) FindDue(ctx context.Context, executeAt, utime int64, limit int) ([]Deletion, error) {
+	var res []Deletion
+	err := dao.db.WithContext(ctx).
+		Where("(status = ? AND execute_at <= ?) OR (status = ? AND utime <= ?)",
+			DeletionStatusPending, executeAt, DeletionStatusExecuting, utime).
+		Order("id ASC").Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+const (
+	DeletionStatusPending   uint8 = 1
+	DeletionStatusExecuting uint8 = 2
+)
+
+// Deletion 注销账号的申请
+type Deletion struct {
+	Id        int64 `gorm:"primaryKey;autoIncrement"`
+	Uid       int64 `gorm:"index:idx_uid_status,priority:1"`
+	Status    uint8 `gorm:"type:tinyint(3);index:idx_uid_status,priority:2;index:idx_status_execute_at,priority:1;comment:1-冷静期 2-执行中 3-已注销 4-已撤销"`
+	ExecuteAt int64 `gorm:"index:idx_status_execute_at,priority:2;comment:冷静期结束的时间"`
+	Ctime     int64
+	Utime     int64
+}
+
+func (Deletion) TableName() string {
+	return "privacy_deletions"
+}
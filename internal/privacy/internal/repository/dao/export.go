@@ -0,0 +1,117 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ego-component/egorm"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordNotFound   = gorm.ErrRecordNotFound
+	ErrExportInProgress = errors.New("正在导出个人数据")
+)
+
+type ExportDAO interface {
+	// Create 创建导出任务，同时删掉这个用户之前的导出。
+	// 每个用户只有一条导出记录，还有创建时间晚于 staleBefore 的导出中的任务时返回 ErrExportInProgress
+	Create(ctx context.Context, e Export, staleBefore int64) (int64, error)
+	Finish(ctx context.Context, id int64, status uint8, data []byte) error
+	// FindLatest 不包含压缩包的内容
+	FindLatest(ctx context.Context, uid int64) (Export, error)
+	FindWithData(ctx context.Context, uid, id int64) (Export, error)
+	DeleteByUid(ctx context.Context, uid int64) error
+}
+
+type GORMExportDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMExportDAO(db *egorm.Component) ExportDAO {
+	return &GORMExportDAO{db: db}
+}
+
+func (d *GORMExportDAO) Create(ctx context.Context, e Export, staleBefore int64) (int64, error) {
+	now := time.Now().UnixMilli()
+	e.Ctime = now
+	e.Utime = now
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 正在导出的不删，插入的时候就会违反唯一索引
+		err := tx.Where("uid = ? AND (status <> ? OR ctime <= ?)", e.Uid, ExportStatusProcessing, staleBefore).
+			Delete(&Export{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&e).Error
+	})
+	var me *mysql.MySQLError
+	const uniqueIndexErrNo uint16 = 1062
+	if errors.As(err, &me) && me.Number == uniqueIndexErrNo {
+		return 0, fmt.Errorf("%w, uid %d", ErrExportInProgress, e.Uid)
+	}
+	return e.Id, err
+}
+
+func (d *GORMExportDAO) Finish(ctx context.Context, id int64, status uint8, data []byte) error {
+	return d.db.WithContext(ctx).Model(&Export{}).Where("id = ?", id).
+		Updates(map[string]any{
+			"status": status,
+			"data":   data,
+			"size":   len(data),
+			"utime":  time.Now().UnixMilli(),
+		}).Error
+}
+
+func (d *GORMExportDAO) FindLatest(ctx context.Context, uid int64) (Export, error) {
+	var res Export
+	err := d.db.WithContext(ctx).Omit("data").
+		Where("uid = ?", uid).Order("id DESC").
+		First(&res).Error
+	return res, err
+}
+
+func (d *GORMExportDAO) FindWithData(ctx context.Context, uid, id int64) (Export, error) {
+	var res Export
+	err := d.db.WithContext(ctx).Where("id = ? AND uid = ?", id, uid).First(&res).Error
+	return res, err
+}
+
+func (d *GORMExportDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return d.db.WithContext(ctx).Where("uid = ?", uid).Delete(&Export{}).Error
+}
+
+// ExportStatusProcessing 导出中，和 domain.ExportStatusProcessing 一致
+const ExportStatusProcessing uint8 = 1
+
+// Export 个人数据导出任务，压缩包直接放在数据库里面
+type Export struct {
+	Id     int64  `gorm:"primaryKey;autoIncrement"`
+	Uid    int64  `gorm:"not null;uniqueIndex:unq_uid"`
+	Status uint8  `gorm:"type:tinyint(3);comment:1-导出中 2-成功 3-失败"`
+	Size   int64  `gorm:"comment:压缩包大小，字节"`
+	Data   []byte `gorm:"type:longblob;comment:ZIP 压缩包"`
+	Ctime  int64
+	Utime  int64
+}
+
+func (Export) TableName() string {
+	return "privacy_exports"
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/privacy/internal/domain"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository/dao"
+)
+
+type DeletionRepository interface {
+	Create(ctx context.Context, d domain.Deletion) (int64, error)
+	FindByStatus(ctx context.Context, uid int64, status domain.DeletionStatus) (domain.Deletion, error)
+	// UpdateStatus 当前状态不是 from 的时候返回 false
+	UpdateStatus(ctx context.Context, id int64, from, to domain.DeletionStatus) (bool, error)
+	FindDue(ctx context.Context, executeAt, utime int64, limit int) ([]domain.Deletion, error)
+}
+
+type deletionRepository struct {
+	dao dao.DeletionDAO
+}
+
+func NewDeletionRepository(d dao.DeletionDAO) DeletionRepository {
+	return &deletionRepository{dao: d}
+}
+
+func (r *deletionRepository) Create(ctx context.Context, d domain.Deletion) (int64, error) {
+	return r.dao.Create(ctx, dao.Deletion{
+		Uid:       d.Uid,
+		Status:    d.Status.ToUint8(),
+		ExecuteAt: d.ExecuteAt,
+	})
+}
+
+func (r *deletionRepository) FindByStatus(ctx context.Context, uid int64, status domain.DeletionStatus) (domain.Deletion, error) {
+	d, err := r.dao.FindByStatus(ctx, uid, status.ToUint8())
+	return r.toDomain(d), err
+}
+
+func (r *deletionRepository) UpdateStatus(ctx context.Context, id int64, from, to domain.DeletionStatus) (bool, error) {
+	return r.dao.UpdateStatus(ctx, id, from.ToUint8(), to.ToUint8())
+}
+
+func (r *deletionRepository) FindDue(ctx context.Context, executeAt, utime int64, limit int) ([]domain.Deletion, error) {
+	list, err := r.dao.FindDue(ctx, executeAt, utime, limit)
+	return slice.Map(list, func(idx int, src dao.Deletion) domain.Deletion {
+		return r.toDomain(src)
+	}), err
+}
+
+func (r *deletionRepository) toDomain(d dao.Deletion) domain.Deletion {
+	return domain.Deletion{
+		Id:        d.Id,
+		Uid:       d.Uid,
+		Status:    domain.DeletionStatus(d.Status),
+		ExecuteAt: d.ExecuteAt,
+		Ctime:     d.Ctime,
+		Utime:     d.Utime,
+	}
+}
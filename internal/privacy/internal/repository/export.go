@@ -0,0 +1,81 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/privacy/internal/domain"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository/dao"
+)
+
+var (
+	ErrRecordNotFound   = dao.ErrRecordNotFound
+	ErrExportInProgress = dao.ErrExportInProgress
+)
+
+type ExportRepository interface {
+	// Create 还有创建时间晚于 staleBefore 的导出中的任务时返回 ErrExportInProgress
+	Create(ctx context.Context, e domain.Export, staleBefore int64) (int64, error)
+	Finish(ctx context.Context, id int64, status domain.ExportStatus, data []byte) error
+	FindLatest(ctx context.Context, uid int64) (domain.Export, error)
+	// FindData 返回导出任务以及压缩包
+	FindData(ctx context.Context, uid, id int64) (domain.Export, []byte, error)
+	DeleteByUid(ctx context.Context, uid int64) error
+}
+
+type exportRepository struct {
+	dao dao.ExportDAO
+}
+
+func NewExportRepository(d dao.ExportDAO) ExportRepository {
+	return &exportRepository{dao: d}
+}
+
+func (r *exportRepository) Create(ctx context.Context, e domain.Export, staleBefore int64) (int64, error) {
+	return r.dao.Create(ctx, dao.Export{
+		Uid:    e.Uid,
+		Status: e.Status.ToUint8(),
+	}, staleBefore)
+}
+
+func (r *exportRepository) Finish(ctx context.Context, id int64, status domain.ExportStatus, data []byte) error {
+	return r.dao.Finish(ctx, id, status.ToUint8(), data)
+}
+
+func (r *exportRepository) FindLatest(ctx context.Context, uid int64) (domain.Export, error) {
+	e, err := r.dao.FindLatest(ctx, uid)
+	return r.toDomain(e), err
+}
+
+func (r *exportRepository) FindData(ctx context.Context, uid, id int64) (domain.Export, []byte, error) {
+	e, err := r.dao.FindWithData(ctx, uid, id)
+	return r.toDomain(e), e.Data, err
+}
+
+func (r *exportRepository) DeleteByUid(ctx context.Context, uid int64) error {
+	return r.dao.DeleteByUid(ctx, uid)
+}
+
+func (r *exportRepository) toDomain(e dao.Export) domain.Export {
+	return domain.Export{
+		Id:     e.Id,
+		Uid:    e.Uid,
+		Status: domain.ExportStatus(e.Status),
+		Size:   e.Size,
+		Ctime:  e.Ctime,
+		Utime:  e.Utime,
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/privacy/internal/domain"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+var ErrDeletionNotFound = errors.New("没有处于冷静期的注销申请")
+
+// DeletionService 注销账号。
+// 申请之后有一段冷静期，冷静期内可以撤销；冷静期结束之后由定时任务调用各个模块清除数据
+type DeletionService interface {
+	// Apply 申请注销账号，已经在冷静期内的话返回之前的申请
+	Apply(ctx context.Context, uid int64) (domain.Deletion, error)
+	// Cancel 撤销冷静期内的申请，已经开始清除数据的不能撤销
+	Cancel(ctx context.Context, uid int64) error
+	// Pending 冷静期内的申请，没有的时候状态为 DeletionStatusUnknown
+	Pending(ctx context.Context, uid int64) (domain.Deletion, error)
+	// ExecuteDue 清除冷静期已经结束的账号的数据，返回这一批处理了多少个申请
+	ExecuteDue(ctx context.Context, limit int) (int, error)
+}
+
+type deletionService struct {
+	repo       repository.DeletionRepository
+	exportRepo repository.ExportRepository
+	hooks      []Hook
+	logger     *elog.Component
+	coolingOff time.Duration
+	// 清除数据失败之后，隔多久再重试
+	retryInterval time.Duration
+}
+
+func NewDeletionService(repo repository.DeletionRepository,
+	exportRepo repository.ExportRepository,
+	hooks []Hook, coolingOff time.Duration) DeletionService {
+	return &deletionService{
+		repo:          repo,
+		exportRepo:    exportRepo,
+		hooks:         hooks,
+		logger:        elog.DefaultLogger,
+		coolingOff:    coolingOff,
+		retryInterval: time.Minute * 10,
+	}
+}
+
+func (s *deletionService) Apply(ctx context.Context, uid int64) (domain.Deletion, error) {
+	d, err := s.Pending(ctx, uid)
+	if err != nil || d.Status == domain.DeletionStatusPending {
+		return d, err
+	}
+	d = domain.Deletion{
+		Uid:       uid,
+		Status:    domain.DeletionStatusPending,
+		ExecuteAt: time.Now().Add(s.coolingOff).UnixMilli(),
+	}
+	d.Id, err = s.repo.Create(ctx, d)
+	return d, err
+}
+
+func (s *deletionService) Cancel(ctx context.Context, uid int64) error {
+	d, err := s.Pending(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if d.Status == domain.DeletionStatusPending {
+		ok, err := s.repo.UpdateStatus(ctx, d.Id, domain.DeletionStatusPending, domain.DeletionStatusCancelled)
+		if err != nil || ok {
+			return err
+		}
+	}
+	return fmt.Errorf("%w, uid %d", ErrDeletionNotFound, uid)
+}
+
+func (s *deletionService) Pending(ctx context.Context, uid int64) (domain.Deletion, error) {
+	d, err := s.repo.FindByStatus(ctx, uid, domain.DeletionStatusPending)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return domain.Deletion{}, nil
+	}
+	return d, err
+}
+
+func (s *deletionService) ExecuteDue(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	list, err := s.repo.FindDue(ctx, now.UnixMilli(), now.Add(-s.retryInterval).UnixMilli(), limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range list {
+		// 抢占这个申请，同时刷新更新时间，失败的话过了 retryInterval 之后再重试
+		ok, err := s.repo.UpdateStatus(ctx, d.Id, d.Status, domain.DeletionStatusExecuting)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			// 刚刚被用户撤销了，或者被别的实例抢走了
+			continue
+		}
+		err = s.execute(ctx, d.Uid)
+		if err != nil {
+			s.logger.Error("注销账号失败",
+				elog.Int64("deletionId", d.Id),
+				elog.Int64("uid", d.Uid),
+				elog.FieldErr(err))
+			continue
+		}
+		_, err = s.repo.UpdateStatus(ctx, d.Id, domain.DeletionStatusExecuting, domain.DeletionStatusDone)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(list), nil
+}
+
+// execute 按照顺序调用各个模块，只要有一个失败了就停下来，下次整个重试
+func (s *deletionService) execute(ctx context.Context, uid int64) error {
+	for _, h := range s.hooks {
+		err := h.Erase(ctx, uid)
+		if err != nil {
+			return fmt.Errorf("清除 %s 模块的数据失败: %w", h.Name(), err)
+		}
+	}
+	return s.exportRepo.DeleteByUid(ctx, uid)
+}
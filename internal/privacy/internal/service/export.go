@@ -0,0 +1,153 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/privacy/internal/domain"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+var (
+	ErrExportInProgress = repository.ErrExportInProgress
+	ErrExportNotFound   = errors.New("导出文件不存在或者已经过期")
+)
+
+// ExportService 把用户在各个模块中的数据打包成 ZIP 压缩包，每个模块一个 JSON 文件
+type ExportService interface {
+	// Apply 在后台打包，返回导出任务的 id。之前的导出会被删掉
+	Apply(ctx context.Context, uid int64) (int64, error)
+	// Latest 最近一次导出，没有导出过的时候状态为 ExportStatusUnknown
+	Latest(ctx context.Context, uid int64) (domain.Export, error)
+	// Download 返回压缩包，还没有导出成功或者已经过期返回 ErrExportNotFound
+	Download(ctx context.Context, uid, id int64) ([]byte, error)
+}
+
+type exportService struct {
+	repo   repository.ExportRepository
+	hooks  []Hook
+	logger *elog.Component
+	// 超过这个时间还没有导出完就认为失败了，可以重新导出
+	timeout time.Duration
+	// 导出成功之后多久以内可以下载
+	expiration time.Duration
+	// 测试里面用来等待后台的导出结束
+	wg sync.WaitGroup
+}
+
+func NewExportService(repo repository.ExportRepository, hooks []Hook) ExportService {
+	return &exportService{
+		repo:       repo,
+		hooks:      hooks,
+		logger:     elog.DefaultLogger,
+		timeout:    time.Minute * 10,
+		expiration: time.Hour * 24 * 7,
+	}
+}
+
+func (s *exportService) Apply(ctx context.Context, uid int64) (int64, error) {
+	// 超时的导出任务和 Latest 一样当作失败，可以重新导出
+	staleBefore := time.Now().Add(-s.timeout).UnixMilli()
+	id, err := s.repo.Create(ctx, domain.Export{Uid: uid, Status: domain.ExportStatusProcessing}, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		// 模块比较多，数据量也可能比较大，不能跟着请求走
+		newCtx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		s.export(newCtx, id, uid)
+	}()
+	return id, nil
+}
+
+func (s *exportService) export(ctx context.Context, id, uid int64) {
+	logger := s.logger.With(elog.Int64("exportId", id), elog.Int64("uid", uid))
+	status := domain.ExportStatusSuccess
+	data, err := s.pack(ctx, uid)
+	if err != nil {
+		logger.Error("导出个人数据失败", elog.FieldErr(err))
+		status, data = domain.ExportStatusFailed, nil
+	}
+	err = s.repo.Finish(ctx, id, status, data)
+	if err != nil {
+		logger.Error("保存导出的个人数据失败", elog.FieldErr(err))
+	}
+}
+
+func (s *exportService) pack(ctx context.Context, uid int64) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, h := range s.hooks {
+		val, err := h.Export(ctx, uid)
+		if err != nil {
+			return nil, fmt.Errorf("导出 %s 模块的数据失败: %w", h.Name(), err)
+		}
+		f, err := w.Create(h.Name() + ".json")
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(val)
+		if err != nil {
+			return nil, fmt.Errorf("序列化 %s 模块的数据失败: %w", h.Name(), err)
+		}
+	}
+	err := w.Close()
+	return buf.Bytes(), err
+}
+
+func (s *exportService) Latest(ctx context.Context, uid int64) (domain.Export, error) {
+	e, err := s.repo.FindLatest(ctx, uid)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return domain.Export{}, nil
+	}
+	if err != nil {
+		return domain.Export{}, err
+	}
+	// 导出的过程中服务重启了，状态就一直是导出中
+	if e.Status == domain.ExportStatusProcessing &&
+		time.Since(time.UnixMilli(e.Ctime)) > s.timeout {
+		e.Status = domain.ExportStatusFailed
+	}
+	return e, nil
+}
+
+func (s *exportService) Download(ctx context.Context, uid, id int64) ([]byte, error) {
+	e, data, err := s.repo.FindData(ctx, uid, id)
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w, id %d", ErrExportNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.Status != domain.ExportStatusSuccess ||
+		time.Since(time.UnixMilli(e.Utime)) > s.expiration {
+		return nil, fmt.Errorf("%w, id %d, status %d", ErrExportNotFound, id, e.Status)
+	}
+	return data, nil
+}
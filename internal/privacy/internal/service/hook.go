@@ -0,0 +1,29 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import "context"
+
+// Hook 各个模块接入个人数据导出以及注销账号的扩展点。
+// 业务模块暴露实现了这几个方法的服务，由 ioc 组装之后交给 privacy 模块，
+// 所以 privacy 模块不依赖任何业务模块
+type Hook interface {
+	// Name 模块的名字，同时也是压缩包中的文件名
+	Name() string
+	// Export 返回值会被序列化成 JSON
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 删除或者匿名化用户在模块中的数据。失败之后会整个重试，所以必须是幂等的
+	Erase(ctx context.Context, uid int64) error
+}
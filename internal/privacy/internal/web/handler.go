@@ -0,0 +1,132 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/privacy/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+type Handler struct {
+	exportSvc   service.ExportService
+	deletionSvc service.DeletionService
+	logger      *elog.Component
+}
+
+func NewHandler(exportSvc service.ExportService, deletionSvc service.DeletionService) *Handler {
+	return &Handler{
+		exportSvc:   exportSvc,
+		deletionSvc: deletionSvc,
+		logger:      elog.DefaultLogger,
+	}
+}
+
+func (h *Handler) PublicRoutes(server *gin.Engine) {}
+
+func (h *Handler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/privacy")
+	// 导出个人数据，在后台打包，前端轮询 latest 接口
+	g.POST("/export/apply", ginx.S(h.ApplyExport))
+	g.POST("/export/latest", ginx.S(h.LatestExport))
+	// 下载 ZIP 压缩包
+	g.POST("/export/download", h.Download)
+
+	// 注销账号
+	g.POST("/deletion/apply", ginx.S(h.ApplyDeletion))
+	// 冷静期内撤销
+	g.POST("/deletion/cancel", ginx.S(h.CancelDeletion))
+	g.POST("/deletion/status", ginx.S(h.DeletionStatus))
+}
+
+func (h *Handler) ApplyExport(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	id, err := h.exportSvc.Apply(ctx, sess.Claims().Uid)
+	switch {
+	case errors.Is(err, service.ErrExportInProgress):
+		return exportInProgressResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	default:
+		return ginx.Result{Data: id}, nil
+	}
+}
+
+func (h *Handler) LatestExport(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	e, err := h.exportSvc.Latest(ctx, sess.Claims().Uid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: newExport(e)}, nil
+}
+
+func (h *Handler) Download(ctx *gin.Context) {
+	sess, err := session.Get(&ginx.Context{Context: ctx})
+	if err != nil {
+		h.logger.Error("获取 Session 失败", elog.FieldErr(err))
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	var req ExportReq
+	if err := ctx.Bind(&req); err != nil {
+		h.logger.Error("绑定参数失败", elog.FieldErr(err))
+		return
+	}
+	data, err := h.exportSvc.Download(ctx.Request.Context(), sess.Claims().Uid, req.Id)
+	if err != nil {
+		res := systemErrorResult
+		if errors.Is(err, service.ErrExportNotFound) {
+			res = exportNotFoundResult
+		} else {
+			h.logger.Error("下载导出的个人数据失败", elog.FieldErr(err))
+		}
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="webook-data.zip"`)
+	ctx.Data(http.StatusOK, "application/zip", data)
+}
+
+func (h *Handler) ApplyDeletion(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	d, err := h.deletionSvc.Apply(ctx, sess.Claims().Uid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: newDeletion(d)}, nil
+}
+
+func (h *Handler) CancelDeletion(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	err := h.deletionSvc.Cancel(ctx, sess.Claims().Uid)
+	switch {
+	case errors.Is(err, service.ErrDeletionNotFound):
+		return deletionNotFoundResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	default:
+		return ginx.Result{}, nil
+	}
+}
+
+func (h *Handler) DeletionStatus(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	d, err := h.deletionSvc.Pending(ctx, sess.Claims().Uid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: newDeletion(d)}, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import "github.com/ecodeclub/webook/internal/privacy/internal/domain"
+
+type ExportReq struct {
+	Id int64 `json:"id" form:"id"`
+}
+
+type Export struct {
+	Id int64 `json:"id"`
+	// 0-没有导出过 1-导出中 2-成功 3-失败
+	Status uint8 `json:"status"`
+	Size   int64 `json:"size"`
+	Ctime  int64 `json:"ctime"`
+	Utime  int64 `json:"utime"`
+}
+
+func newExport(e domain.Export) Export {
+	return Export{
+		Id:     e.Id,
+		Status: e.Status.ToUint8(),
+		Size:   e.Size,
+		Ctime:  e.Ctime,
+		Utime:  e.Utime,
+	}
+}
+
+type Deletion struct {
+	Id int64 `json:"id"`
+	// 0-没有申请 1-冷静期内
+	Status uint8 `json:"status"`
+	// 冷静期结束的时间，到了之后就会清除数据
+	ExecuteAt int64 `json:"executeAt"`
+	Ctime     int64 `json:"ctime"`
+}
+
+func newDeletion(d domain.Deletion) Deletion {
+	return Deletion{
+		Id:        d.Id,
+		Status:    d.Status.ToUint8(),
+		ExecuteAt: d.ExecuteAt,
+		Ctime:     d.Ctime,
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wireinject
+
+package privacy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/privacy/internal/job"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository"
+	"github.com/ecodeclub/webook/internal/privacy/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/privacy/internal/service"
+	"github.com/ecodeclub/webook/internal/privacy/internal/web"
+	"github.com/ego-component/egorm"
+	"github.com/google/wire"
+)
+
+// InitModule hooks 由 ioc 从各个业务模块组装，注销账号的时候按照顺序调用
+func InitModule(db *egorm.Component, hooks []Hook) (*Module, error) {
+	wire.Build(
+		initExportDAO,
+		dao.NewGORMDeletionDAO,
+		repository.NewExportRepository,
+		repository.NewDeletionRepository,
+		service.NewExportService,
+		initDeletionService,
+		web.NewHandler,
+		initExecuteDeletionJob,
+		wire.Struct(new(Module), "*"),
+	)
+	return new(Module), nil
+}
+
+var initOnce sync.Once
+
+func initExportDAO(db *egorm.Component) dao.ExportDAO {
+	initOnce.Do(func() {
+		err := dao.InitTables(db)
+		if err != nil {
+			panic(err)
+		}
+	})
+	return dao.NewGORMExportDAO(db)
+}
+
+func initDeletionService(repo repository.DeletionRepository,
+	exportRepo repository.ExportRepository, hooks []Hook) service.DeletionService {
+	// 冷静期七天
+	return service.NewDeletionService(repo, exportRepo, hooks, time.Hour*24*7)
+}
+
+func initExecuteDeletionJob(svc service.DeletionService) *ExecuteDeletionJob {
+	return job.NewExecuteDeletionJob(svc, 100)
+}
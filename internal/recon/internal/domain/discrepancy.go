@@ -0,0 +1,81 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type DiscrepancyType uint8
+
+func (t DiscrepancyType) ToUint8() uint8 {
+	return uint8(t)
+}
+
+const (
+	// DiscrepancyTypeMissingLocal 第三方账单中有,本地没有对应的支付记录
+	DiscrepancyTypeMissingLocal DiscrepancyType = 1
+	// DiscrepancyTypeMissingRemote 本地已支付,第三方账单中没有
+	DiscrepancyTypeMissingRemote DiscrepancyType = 2
+	// DiscrepancyTypeAmountMismatch 金额不一致
+	DiscrepancyTypeAmountMismatch DiscrepancyType = 3
+	// DiscrepancyTypeStatusMismatch 支付状态不一致
+	DiscrepancyTypeStatusMismatch DiscrepancyType = 4
+)
+
+type DiscrepancyStatus uint8
+
+func (s DiscrepancyStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	// DiscrepancyStatusPending 待处理
+	DiscrepancyStatusPending DiscrepancyStatus = 1
+	// DiscrepancyStatusRepaired 已按第三方状态修复
+	DiscrepancyStatusRepaired DiscrepancyStatus = 2
+	// DiscrepancyStatusIgnored 已人工处理或确认无需处理
+	DiscrepancyStatusIgnored DiscrepancyStatus = 3
+)
+
+// Discrepancy 对账差异,同一账单日同一订单同一类型只记录一次
+type Discrepancy struct {
+	ID int64
+	// BillDate 账单日期 2006-01-02
+	BillDate     string
+	Type         DiscrepancyType
+	Channel      uint8
+	OrderSN      string
+	PaymentNO3rd string
+	// 金额单位为分
+	LocalAmount  int64
+	RemoteAmount int64
+	// LocalStatus 本地支付渠道记录的状态,本地缺失时为 0
+	LocalStatus uint8
+	// RemoteStatus 第三方账单中的交易状态,比如微信的 SUCCESS、REFUND, 第三方缺失时为空
+	RemoteStatus string
+	Status       DiscrepancyStatus
+	Remark       string
+	Ctime        int64
+	Utime        int64
+}
+
+// BillRecord 第三方账单中的一笔订单,同一订单的支付和退款行会合并成一条
+type BillRecord struct {
+	OrderSN      string
+	PaymentNO3rd string
+	// TradeState 交易状态,有退款行时为 REFUND
+	TradeState string
+	// Amount 订单金额,单位为分
+	Amount int64
+	// RefundAmount 退款金额,单位为分
+	RefundAmount int64
+}
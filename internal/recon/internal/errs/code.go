@@ -0,0 +1,29 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+var (
+	SystemError = ErrorCode{Code: 523001, Msg: "系统错误"}
+
+	DiscrepancyNotFound       = ErrorCode{Code: 423001, Msg: "对账差异不存在"}
+	DiscrepancyStatusConflict = ErrorCode{Code: 423002, Msg: "对账差异已被处理"}
+	DiscrepancyNotRepairable  = ErrorCode{Code: 423003, Msg: "该差异不支持自动修复,请人工处理后忽略"}
+	InvalidBillDate           = ErrorCode{Code: 423004, Msg: "账单日期格式错误"}
+)
+
+type ErrorCode struct {
+	Code int
+	Msg  string
+}
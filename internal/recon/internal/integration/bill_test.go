@@ -0,0 +1,348 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/ekit/iox"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/payment"
+	paymentmocks "github.com/ecodeclub/webook/internal/payment/mocks"
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+	"github.com/ecodeclub/webook/internal/recon/internal/errs"
+	"github.com/ecodeclub/webook/internal/recon/internal/job"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/recon/internal/service"
+	"github.com/ecodeclub/webook/internal/recon/internal/web"
+	"github.com/ecodeclub/webook/internal/test"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ego-component/egorm"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/econf"
+	"github.com/gotomicro/ego/server/egin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+var cst = time.FixedZone("CST", 8*3600)
+
+func TestWechatBill(t *testing.T) {
+	suite.Run(t, new(WechatBillTestSuite))
+}
+
+type WechatBillTestSuite struct {
+	suite.Suite
+	db   *egorm.Component
+	dao  dao.DiscrepancyDAO
+	repo repository.DiscrepancyRepository
+}
+
+func (s *WechatBillTestSuite) SetupSuite() {
+	s.db = testioc.InitDB()
+	err := dao.InitTables(s.db)
+	require.NoError(s.T(), err)
+	s.dao = dao.NewDiscrepancyGORMDAO(s.db)
+	s.repo = repository.NewDiscrepancyRepository(s.dao)
+}
+
+func (s *WechatBillTestSuite) TearDownSuite() {
+	err := s.db.Exec("DROP TABLE `recon_discrepancies`").Error
+	require.NoError(s.T(), err)
+}
+
+func (s *WechatBillTestSuite) TearDownTest() {
+	err := s.db.Exec("TRUNCATE TABLE `recon_discrepancies`").Error
+	require.NoError(s.T(), err)
+}
+
+func (s *WechatBillTestSuite) newAdminGinServer(svc service.BillService) *egin.Component {
+	econf.Set("server", map[string]any{"contextTimeout": "1s"})
+	server := egin.Load("server").Build()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("_session", session.NewMemorySession(session.Claims{
+			Uid: 1,
+		}))
+	})
+	web.NewAdminHandler(svc, cst).PrivateRoutes(server.Engine)
+	return server
+}
+
+func (s *WechatBillTestSuite) TestJob_ReconcileWechatBill() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	yesterday := time.Now().In(cst).AddDate(0, 0, -1).Format(time.DateOnly)
+	bill := fixtureFetcher("微信订单号,商户订单号,交易状态,订单金额,退款金额\n" +
+		"`42001,`order-sn-1,`SUCCESS,`99.90,`0.00\n" +
+		"`42002,`order-sn-2,`SUCCESS,`19.90,`0.00\n" +
+		"总交易单数,应结订单总金额\n`2,`119.80\n")
+
+	paymentSvc := paymentmocks.NewMockService(ctrl)
+	paymentSvc.EXPECT().FindPaidPayments(gomock.Any(), 0, 100, gomock.Any(), gomock.Any()).
+		Return([]payment.Payment{
+			newWechatPayment("order-sn-1", "42001", 9990, payment.StatusPaidSuccess),
+			newWechatPayment("order-sn-3", "42003", 990, payment.StatusPaidSuccess),
+		}, nil).Times(2)
+	paymentSvc.EXPECT().FindPaymentByOrderSN(gomock.Any(), "order-sn-2").
+		Return(payment.Payment{}, payment.ErrRecordNotFound).Times(2)
+
+	svc := service.NewBillService(bill, paymentSvc, s.repo, 100)
+	j := job.NewReconcileWechatBillJob(svc, cst)
+	require.NoError(t, j.Run(context.Background()))
+	// 重复执行不会重复记录差异
+	require.NoError(t, j.Run(context.Background()))
+
+	ds, err := s.repo.List(context.Background(), yesterday, 0, 0, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, ds, 2)
+	for i := range ds {
+		ds[i].ID, ds[i].Ctime, ds[i].Utime = 0, 0, 0
+	}
+	assert.ElementsMatch(t, []domain.Discrepancy{
+		{
+			BillDate:     yesterday,
+			Type:         domain.DiscrepancyTypeMissingLocal,
+			Channel:      payment.ChannelTypeWechat.ToUnit8(),
+			OrderSN:      "order-sn-2",
+			PaymentNO3rd: "42002",
+			RemoteAmount: 1990,
+			RemoteStatus: "SUCCESS",
+			Status:       domain.DiscrepancyStatusPending,
+		},
+		{
+			BillDate:     yesterday,
+			Type:         domain.DiscrepancyTypeMissingRemote,
+			Channel:      payment.ChannelTypeWechat.ToUnit8(),
+			OrderSN:      "order-sn-3",
+			PaymentNO3rd: "42003",
+			LocalAmount:  990,
+			LocalStatus:  payment.StatusPaidSuccess.ToUint8(),
+			Status:       domain.DiscrepancyStatusPending,
+		},
+	}, ds)
+}
+
+func (s *WechatBillTestSuite) TestAdminHandler_List() {
+	t := s.T()
+	s.createDiscrepancy(t, "2024-05-01", "order-sn-1", domain.DiscrepancyTypeMissingLocal)
+	s.createDiscrepancy(t, "2024-05-01", "order-sn-2", domain.DiscrepancyTypeStatusMismatch)
+	s.createDiscrepancy(t, "2024-05-02", "order-sn-3", domain.DiscrepancyTypeStatusMismatch)
+
+	req, err := http.NewRequest(http.MethodPost,
+		"/recon/discrepancy/list", iox.NewJSONReader(web.ListDiscrepanciesReq{
+			BillDate: "2024-05-01",
+			Type:     domain.DiscrepancyTypeStatusMismatch.ToUint8(),
+			Limit:    10,
+		}))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(t, err)
+	recorder := test.NewJSONResponseRecorder[web.ListDiscrepanciesResp]()
+	s.newAdminGinServer(service.NewBillService(nil, nil, s.repo, 100)).ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+
+	resp := recorder.MustScan().Data
+	assert.Equal(t, int64(1), resp.Total)
+	require.Len(t, resp.Discrepancies, 1)
+	assert.Equal(t, "order-sn-2", resp.Discrepancies[0].OrderSN)
+}
+
+func (s *WechatBillTestSuite) TestAdminHandler_Repair() {
+	t := s.T()
+	testCases := []struct {
+		name          string
+		before        func(t *testing.T) int64
+		newPaymentSvc func(ctrl *gomock.Controller) payment.Service
+		wantResp      test.Result[any]
+		wantStatus    domain.DiscrepancyStatus
+	}{
+		{
+			name: "状态不一致_按微信同步",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createDiscrepancy(t, "2024-05-01", "order-sn-11", domain.DiscrepancyTypeStatusMismatch)
+			},
+			newPaymentSvc: func(ctrl *gomock.Controller) payment.Service {
+				pmt := newWechatPayment("order-sn-11", "", 990, payment.StatusUnpaid)
+				svc := paymentmocks.NewMockService(ctrl)
+				svc.EXPECT().FindPaymentByOrderSN(gomock.Any(), "order-sn-11").Return(pmt, nil)
+				svc.EXPECT().SyncWechatInfo(gomock.Any(), pmt).Return(nil)
+				return svc
+			},
+			wantResp:   test.Result[any]{Msg: "OK"},
+			wantStatus: domain.DiscrepancyStatusRepaired,
+		},
+		{
+			name: "同步失败_仍为待处理",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createDiscrepancy(t, "2024-05-01", "order-sn-12", domain.DiscrepancyTypeMissingRemote)
+			},
+			newPaymentSvc: func(ctrl *gomock.Controller) payment.Service {
+				pmt := newWechatPayment("order-sn-12", "42012", 990, payment.StatusPaidSuccess)
+				svc := paymentmocks.NewMockService(ctrl)
+				svc.EXPECT().FindPaymentByOrderSN(gomock.Any(), "order-sn-12").Return(pmt, nil)
+				svc.EXPECT().SyncWechatInfo(gomock.Any(), pmt).Return(errors.New("mock error"))
+				return svc
+			},
+			wantResp:   test.Result[any]{Code: errs.SystemError.Code, Msg: errs.SystemError.Msg},
+			wantStatus: domain.DiscrepancyStatusPending,
+		},
+		{
+			name: "金额不一致_不能自动修复",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				return s.createDiscrepancy(t, "2024-05-01", "order-sn-13", domain.DiscrepancyTypeAmountMismatch)
+			},
+			newPaymentSvc: func(ctrl *gomock.Controller) payment.Service {
+				return paymentmocks.NewMockService(ctrl)
+			},
+			wantResp:   test.Result[any]{Code: errs.DiscrepancyNotRepairable.Code, Msg: errs.DiscrepancyNotRepairable.Msg},
+			wantStatus: domain.DiscrepancyStatusPending,
+		},
+		{
+			name: "已忽略的差异不能修复",
+			before: func(t *testing.T) int64 {
+				t.Helper()
+				id := s.createDiscrepancy(t, "2024-05-01", "order-sn-14", domain.DiscrepancyTypeStatusMismatch)
+				require.NoError(t, s.dao.UpdateStatus(context.Background(), id, domain.DiscrepancyStatusIgnored.ToUint8(), "已线下退款"))
+				return id
+			},
+			newPaymentSvc: func(ctrl *gomock.Controller) payment.Service {
+				return paymentmocks.NewMockService(ctrl)
+			},
+			wantResp:   test.Result[any]{Code: errs.DiscrepancyStatusConflict.Code, Msg: errs.DiscrepancyStatusConflict.Msg},
+			wantStatus: domain.DiscrepancyStatusIgnored,
+		},
+		{
+			name: "差异不存在",
+			before: func(t *testing.T) int64 {
+				return 10000
+			},
+			newPaymentSvc: func(ctrl *gomock.Controller) payment.Service {
+				return paymentmocks.NewMockService(ctrl)
+			},
+			wantResp: test.Result[any]{Code: errs.DiscrepancyNotFound.Code, Msg: errs.DiscrepancyNotFound.Msg},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			id := tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/recon/discrepancy/repair", iox.NewJSONReader(web.DiscrepancyIDReq{ID: id}))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[any]()
+			svc := service.NewBillService(nil, tc.newPaymentSvc(ctrl), s.repo, 100)
+			s.newAdminGinServer(svc).ServeHTTP(recorder, req)
+			require.Equal(t, 200, recorder.Code)
+			assert.Equal(t, tc.wantResp, recorder.MustScan())
+			if tc.wantStatus > 0 {
+				s.requireDiscrepancyStatus(t, id, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func (s *WechatBillTestSuite) TestAdminHandler_Ignore() {
+	t := s.T()
+	id := s.createDiscrepancy(t, "2024-05-01", "order-sn-21", domain.DiscrepancyTypeAmountMismatch)
+	server := s.newAdminGinServer(service.NewBillService(nil, nil, s.repo, 100))
+
+	ignore := func() test.Result[any] {
+		req, err := http.NewRequest(http.MethodPost,
+			"/recon/discrepancy/ignore", iox.NewJSONReader(web.IgnoreDiscrepancyReq{ID: id, Remark: "已线下补差价"}))
+		req.Header.Set("content-type", "application/json")
+		require.NoError(t, err)
+		recorder := test.NewJSONResponseRecorder[any]()
+		server.ServeHTTP(recorder, req)
+		require.Equal(t, 200, recorder.Code)
+		return recorder.MustScan()
+	}
+
+	assert.Equal(t, test.Result[any]{Msg: "OK"}, ignore())
+	d := s.requireDiscrepancyStatus(t, id, domain.DiscrepancyStatusIgnored)
+	assert.Equal(t, "已线下补差价", d.Remark)
+
+	// 已经处理过的不能重复处理
+	assert.Equal(t, test.Result[any]{
+		Code: errs.DiscrepancyStatusConflict.Code,
+		Msg:  errs.DiscrepancyStatusConflict.Msg,
+	}, ignore())
+}
+
+func (s *WechatBillTestSuite) createDiscrepancy(t *testing.T, billDate, orderSN string, typ domain.DiscrepancyType) int64 {
+	t.Helper()
+	err := s.repo.BatchCreate(context.Background(), []domain.Discrepancy{
+		{
+			BillDate: billDate,
+			Type:     typ,
+			Channel:  payment.ChannelTypeWechat.ToUnit8(),
+			OrderSN:  orderSN,
+			Status:   domain.DiscrepancyStatusPending,
+		},
+	})
+	require.NoError(t, err)
+	var d dao.Discrepancy
+	err = s.db.Where("bill_date = ? AND order_sn = ? AND type = ?", billDate, orderSN, typ.ToUint8()).First(&d).Error
+	require.NoError(t, err)
+	return d.Id
+}
+
+func (s *WechatBillTestSuite) requireDiscrepancyStatus(t *testing.T, id int64, status domain.DiscrepancyStatus) domain.Discrepancy {
+	t.Helper()
+	d, err := s.repo.FindByID(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, status, d.Status)
+	return d
+}
+
+func newWechatPayment(orderSN, no3rd string, amount int64, status payment.PaymentStatus) payment.Payment {
+	return payment.Payment{
+		OrderSN:     orderSN,
+		TotalAmount: amount,
+		Status:      status,
+		Records: []payment.Record{
+			{
+				PaymentNO3rd: no3rd,
+				Channel:      payment.ChannelTypeWechat,
+				Amount:       amount,
+				Status:       status,
+			},
+		},
+	}
+}
+
+// fixtureFetcher 直接返回本地账单内容,代替微信账单下载
+type fixtureFetcher []byte
+
+func (f fixtureFetcher) DownloadTradeBill(ctx context.Context, billDate time.Time) ([]byte, error) {
+	return f, nil
+}
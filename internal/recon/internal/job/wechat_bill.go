@@ -0,0 +1,45 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/recon/internal/service"
+	"github.com/gotomicro/ego/task/ecron"
+)
+
+var _ ecron.NamedJob = (*ReconcileWechatBillJob)(nil)
+
+// ReconcileWechatBillJob 每天核对前一天的微信交易账单
+// 微信在次日 10 点之后才能保证生成前一天的账单,调度时间需要晚于这个时间
+type ReconcileWechatBillJob struct {
+	svc service.BillService
+	loc *time.Location
+}
+
+func NewReconcileWechatBillJob(svc service.BillService, loc *time.Location) *ReconcileWechatBillJob {
+	return &ReconcileWechatBillJob{svc: svc, loc: loc}
+}
+
+func (r *ReconcileWechatBillJob) Name() string {
+	return "reconcile_wechat_bill_job"
+}
+
+func (r *ReconcileWechatBillJob) Run(ctx context.Context) error {
+	billDate := time.Now().In(r.loc).AddDate(0, 0, -1)
+	return r.svc.ReconcileWechatBill(ctx, billDate)
+}
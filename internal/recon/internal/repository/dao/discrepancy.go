@@ -0,0 +1,130 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrDiscrepancyStatusConflict = errors.New("对账差异状态已变更")
+
+type DiscrepancyDAO interface {
+	// BatchCreate 批量保存差异,已经存在的(同一账单日、订单、类型)直接跳过,重复对账不会覆盖处理结果
+	BatchCreate(ctx context.Context, ds []Discrepancy) error
+	FindByID(ctx context.Context, id int64) (Discrepancy, error)
+	List(ctx context.Context, billDate string, typ uint8, status uint8, offset, limit int) ([]Discrepancy, error)
+	Count(ctx context.Context, billDate string, typ uint8, status uint8) (int64, error)
+	// UpdateStatus 只有“待处理”的差异可以被处理
+	UpdateStatus(ctx context.Context, id int64, status uint8, remark string) error
+}
+
+type DiscrepancyGORMDAO struct {
+	db *egorm.Component
+}
+
+func NewDiscrepancyGORMDAO(db *egorm.Component) DiscrepancyDAO {
+	return &DiscrepancyGORMDAO{db: db}
+}
+
+func (g *DiscrepancyGORMDAO) BatchCreate(ctx context.Context, ds []Discrepancy) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range ds {
+		ds[i].Ctime, ds[i].Utime = now, now
+	}
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&ds).Error
+}
+
+func (g *DiscrepancyGORMDAO) FindByID(ctx context.Context, id int64) (Discrepancy, error) {
+	var res Discrepancy
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (g *DiscrepancyGORMDAO) List(ctx context.Context, billDate string, typ uint8, status uint8, offset, limit int) ([]Discrepancy, error) {
+	var res []Discrepancy
+	err := g.where(ctx, billDate, typ, status).
+		Order("id DESC").Offset(offset).Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (g *DiscrepancyGORMDAO) Count(ctx context.Context, billDate string, typ uint8, status uint8) (int64, error) {
+	var res int64
+	err := g.where(ctx, billDate, typ, status).Count(&res).Error
+	return res, err
+}
+
+func (g *DiscrepancyGORMDAO) where(ctx context.Context, billDate string, typ uint8, status uint8) *gorm.DB {
+	query := g.db.WithContext(ctx).Model(&Discrepancy{})
+	if billDate != "" {
+		query = query.Where("bill_date = ?", billDate)
+	}
+	if typ > 0 {
+		query = query.Where("type = ?", typ)
+	}
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
+
+func (g *DiscrepancyGORMDAO) UpdateStatus(ctx context.Context, id int64, status uint8, remark string) error {
+	res := g.db.WithContext(ctx).Model(&Discrepancy{}).
+		Where("id = ? AND status = ?", id, domain.DiscrepancyStatusPending.ToUint8()).
+		Updates(map[string]any{
+			"status": status,
+			"remark": remark,
+			"utime":  time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrDiscrepancyStatusConflict)
+	}
+	return nil
+}
+
+// Discrepancy 对账差异
+type Discrepancy struct {
+	Id           int64  `gorm:"primaryKey;autoIncrement;comment:对账差异自增ID"`
+	BillDate     string `gorm:"type:varchar(16);not null;uniqueIndex:uniq_bill_date_order_sn_type;comment:账单日期 2006-01-02"`
+	Type         uint8  `gorm:"type:tinyint unsigned;not null;uniqueIndex:uniq_bill_date_order_sn_type;comment:差异类型 1=本地缺失 2=第三方缺失 3=金额不一致 4=状态不一致"`
+	Channel      uint8  `gorm:"type:tinyint unsigned;not null;comment:支付渠道 2=微信, 3=微信小程序"`
+	OrderSn      string `gorm:"type:varchar(255);not null;uniqueIndex:uniq_bill_date_order_sn_type;comment:订单序列号"`
+	PaymentNO3rd string `gorm:"column:payment_no_3rd;type:varchar(255);not null;default:'';comment:第三方支付单号"`
+	LocalAmount  int64  `gorm:"not null;default:0;comment:本地支付金额,单位为分"`
+	RemoteAmount int64  `gorm:"not null;default:0;comment:第三方账单金额,单位为分"`
+	LocalStatus  uint8  `gorm:"type:tinyint unsigned;not null;default:0;comment:本地支付渠道记录状态, 0表示本地缺失"`
+	RemoteStatus string `gorm:"type:varchar(32);not null;default:'';comment:第三方交易状态, 空表示第三方缺失"`
+	Status       uint8  `gorm:"type:tinyint unsigned;not null;default:1;index:idx_status;comment:处理状态 1=待处理 2=已修复 3=已忽略"`
+	Remark       string `gorm:"type:varchar(512);not null;default:'';comment:处理备注"`
+	Ctime        int64
+	Utime        int64
+}
+
+func (Discrepancy) TableName() string {
+	return "recon_discrepancies"
+}
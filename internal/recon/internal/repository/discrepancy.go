@@ -0,0 +1,110 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository/dao"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordNotFound            = gorm.ErrRecordNotFound
+	ErrDiscrepancyStatusConflict = dao.ErrDiscrepancyStatusConflict
+)
+
+type DiscrepancyRepository interface {
+	BatchCreate(ctx context.Context, ds []domain.Discrepancy) error
+	FindByID(ctx context.Context, id int64) (domain.Discrepancy, error)
+	List(ctx context.Context, billDate string, typ domain.DiscrepancyType, status domain.DiscrepancyStatus, offset, limit int) ([]domain.Discrepancy, error)
+	Total(ctx context.Context, billDate string, typ domain.DiscrepancyType, status domain.DiscrepancyStatus) (int64, error)
+	UpdateStatus(ctx context.Context, id int64, status domain.DiscrepancyStatus, remark string) error
+}
+
+type discrepancyRepository struct {
+	dao dao.DiscrepancyDAO
+}
+
+func NewDiscrepancyRepository(d dao.DiscrepancyDAO) DiscrepancyRepository {
+	return &discrepancyRepository{dao: d}
+}
+
+func (r *discrepancyRepository) BatchCreate(ctx context.Context, ds []domain.Discrepancy) error {
+	return r.dao.BatchCreate(ctx, slice.Map(ds, func(idx int, src domain.Discrepancy) dao.Discrepancy {
+		return r.toEntity(src)
+	}))
+}
+
+func (r *discrepancyRepository) FindByID(ctx context.Context, id int64) (domain.Discrepancy, error) {
+	d, err := r.dao.FindByID(ctx, id)
+	return r.toDomain(d), err
+}
+
+func (r *discrepancyRepository) List(ctx context.Context, billDate string, typ domain.DiscrepancyType, status domain.DiscrepancyStatus, offset, limit int) ([]domain.Discrepancy, error) {
+	ds, err := r.dao.List(ctx, billDate, typ.ToUint8(), status.ToUint8(), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(ds, func(idx int, src dao.Discrepancy) domain.Discrepancy {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *discrepancyRepository) Total(ctx context.Context, billDate string, typ domain.DiscrepancyType, status domain.DiscrepancyStatus) (int64, error) {
+	return r.dao.Count(ctx, billDate, typ.ToUint8(), status.ToUint8())
+}
+
+func (r *discrepancyRepository) UpdateStatus(ctx context.Context, id int64, status domain.DiscrepancyStatus, remark string) error {
+	return r.dao.UpdateStatus(ctx, id, status.ToUint8(), remark)
+}
+
+func (r *discrepancyRepository) toEntity(d domain.Discrepancy) dao.Discrepancy {
+	return dao.Discrepancy{
+		Id:           d.ID,
+		BillDate:     d.BillDate,
+		Type:         d.Type.ToUint8(),
+		Channel:      d.Channel,
+		OrderSn:      d.OrderSN,
+		PaymentNO3rd: d.PaymentNO3rd,
+		LocalAmount:  d.LocalAmount,
+		RemoteAmount: d.RemoteAmount,
+		LocalStatus:  d.LocalStatus,
+		RemoteStatus: d.RemoteStatus,
+		Status:       d.Status.ToUint8(),
+		Remark:       d.Remark,
+	}
+}
+
+func (r *discrepancyRepository) toDomain(d dao.Discrepancy) domain.Discrepancy {
+	return domain.Discrepancy{
+		ID:           d.Id,
+		BillDate:     d.BillDate,
+		Type:         domain.DiscrepancyType(d.Type),
+		Channel:      d.Channel,
+		OrderSN:      d.OrderSn,
+		PaymentNO3rd: d.PaymentNO3rd,
+		LocalAmount:  d.LocalAmount,
+		RemoteAmount: d.RemoteAmount,
+		LocalStatus:  d.LocalStatus,
+		RemoteStatus: d.RemoteStatus,
+		Status:       domain.DiscrepancyStatus(d.Status),
+		Remark:       d.Remark,
+		Ctime:        d.Ctime,
+		Utime:        d.Utime,
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/payment"
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository"
+)
+
+var (
+	ErrRecordNotFound            = repository.ErrRecordNotFound
+	ErrDiscrepancyStatusConflict = repository.ErrDiscrepancyStatusConflict
+	ErrDiscrepancyNotRepairable  = errors.New("对账差异不支持自动修复")
+)
+
+// BillLocation 微信账单按北京时间划分日期，和调用方传入的时区无关
+var BillLocation = func() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
+
+// BillFetcher 下载第三方支付的交易账单,返回账单原文
+// 线上使用 payment 模块的微信账单下载,测试可以直接返回本地账单文件
+type BillFetcher interface {
+	DownloadTradeBill(ctx context.Context, billDate time.Time) ([]byte, error)
+}
+
+type BillService interface {
+	// ReconcileWechatBill 下载 billDate 当天的微信交易账单,与本地支付记录逐笔核对,差异入库
+	// 重复执行是安全的,已经记录过的差异不会重复记录
+	ReconcileWechatBill(ctx context.Context, billDate time.Time) error
+	FindDiscrepancies(ctx context.Context, billDate string, typ domain.DiscrepancyType, status domain.DiscrepancyStatus, offset, limit int) ([]domain.Discrepancy, int64, error)
+	// RepairDiscrepancy 以微信为准重新同步支付状态,只支持状态不一致和微信缺失两类差异
+	RepairDiscrepancy(ctx context.Context, id int64) error
+	// IgnoreDiscrepancy 人工处理之后或者确认无需处理,标记为已忽略
+	IgnoreDiscrepancy(ctx context.Context, id int64, remark string) error
+}
+
+type billService struct {
+	fetcher    BillFetcher
+	paymentSvc payment.Service
+	repo       repository.DiscrepancyRepository
+	batchSize  int
+}
+
+func NewBillService(fetcher BillFetcher, paymentSvc payment.Service, repo repository.DiscrepancyRepository, batchSize int) BillService {
+	return &billService{
+		fetcher:    fetcher,
+		paymentSvc: paymentSvc,
+		repo:       repo,
+		batchSize:  batchSize,
+	}
+}
+
+func (s *billService) ReconcileWechatBill(ctx context.Context, billDate time.Time) error {
+	billDate = billDate.In(BillLocation)
+	data, err := s.fetcher.DownloadTradeBill(ctx, billDate)
+	if err != nil {
+		return fmt.Errorf("下载微信交易账单失败: %w", err)
+	}
+	records, err := parseWechatTradeBill(data)
+	if err != nil {
+		return err
+	}
+
+	stime := time.Date(billDate.Year(), billDate.Month(), billDate.Day(), 0, 0, 0, 0, BillLocation)
+	locals, err := s.findWechatPaidPayments(ctx, stime.UnixMilli(), stime.AddDate(0, 0, 1).UnixMilli())
+	if err != nil {
+		return fmt.Errorf("查找本地已支付记录失败: %w", err)
+	}
+
+	ds, err := s.match(ctx, stime.Format(time.DateOnly), records, locals)
+	if err != nil {
+		return err
+	}
+	return s.repo.BatchCreate(ctx, ds)
+}
+
+// findWechatPaidPayments 查找 [stime, etime) 内通过微信支付的已支付记录
+func (s *billService) findWechatPaidPayments(ctx context.Context, stime, etime int64) ([]payment.Payment, error) {
+	var res []payment.Payment
+	for offset := 0; ; offset += s.batchSize {
+		pmts, err := s.paymentSvc.FindPaidPayments(ctx, offset, s.batchSize, stime, etime)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, slice.FilterMap(pmts, func(idx int, src payment.Payment) (payment.Payment, bool) {
+			_, ok := s.wechatRecord(src)
+			return src, ok
+		})...)
+		if len(pmts) < s.batchSize {
+			return res, nil
+		}
+	}
+}
+
+// match 先按微信订单号再按订单SN匹配当天的本地记录,匹配不上的再按订单SN单独查找
+// 比如之前支付今天退款的订单,或者回调丢失导致本地没有支付时间的订单
+func (s *billService) match(ctx context.Context, billDate string, records []domain.BillRecord, locals []payment.Payment) ([]domain.Discrepancy, error) {
+	byNO3rd := make(map[string]int, len(locals))
+	bySN := make(map[string]int, len(locals))
+	for i, pmt := range locals {
+		r, _ := s.wechatRecord(pmt)
+		if r.PaymentNO3rd != "" {
+			byNO3rd[r.PaymentNO3rd] = i
+		}
+		bySN[pmt.OrderSN] = i
+	}
+
+	var res []domain.Discrepancy
+	matched := make([]bool, len(locals))
+	for _, r := range records {
+		idx, ok := byNO3rd[r.PaymentNO3rd]
+		if !ok {
+			idx, ok = bySN[r.OrderSN]
+		}
+		var pmt payment.Payment
+		if ok {
+			matched[idx] = true
+			pmt = locals[idx]
+		} else {
+			var err error
+			pmt, err = s.paymentSvc.FindPaymentByOrderSN(ctx, r.OrderSN)
+			if errors.Is(err, payment.ErrRecordNotFound) {
+				res = append(res, s.newRemoteDiscrepancy(billDate, domain.DiscrepancyTypeMissingLocal, r))
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("通过订单SN查找支付记录失败: %w, orderSN: %s", err, r.OrderSN)
+			}
+		}
+		res = append(res, s.compare(billDate, r, pmt)...)
+	}
+
+	for i, m := range matched {
+		if m {
+			continue
+		}
+		pr, _ := s.wechatRecord(locals[i])
+		res = append(res, domain.Discrepancy{
+			BillDate:     billDate,
+			Type:         domain.DiscrepancyTypeMissingRemote,
+			Channel:      pr.Channel.ToUnit8(),
+			OrderSN:      locals[i].OrderSN,
+			PaymentNO3rd: pr.PaymentNO3rd,
+			LocalAmount:  pr.Amount,
+			LocalStatus:  pr.Status.ToUint8(),
+			Status:       domain.DiscrepancyStatusPending,
+		})
+	}
+	return res, nil
+}
+
+func (s *billService) compare(billDate string, r domain.BillRecord, pmt payment.Payment) []domain.Discrepancy {
+	pr, ok := s.wechatRecord(pmt)
+	if !ok {
+		// 本地有支付但是没有微信渠道的记录
+		return []domain.Discrepancy{s.newRemoteDiscrepancy(billDate, domain.DiscrepancyTypeMissingLocal, r)}
+	}
+	newDiscrepancy := func(typ domain.DiscrepancyType) domain.Discrepancy {
+		d := s.newRemoteDiscrepancy(billDate, typ, r)
+		d.Channel = pr.Channel.ToUnit8()
+		d.LocalAmount = pr.Amount
+		d.LocalStatus = pr.Status.ToUint8()
+		return d
+	}
+	var res []domain.Discrepancy
+	if pr.Amount != r.Amount {
+		res = append(res, newDiscrepancy(domain.DiscrepancyTypeAmountMismatch))
+	}
+	if !s.isStatusMatched(r.TradeState, pr.Status) {
+		res = append(res, newDiscrepancy(domain.DiscrepancyTypeStatusMismatch))
+	}
+	return res
+}
+
+// isStatusMatched 账单中支付成功的订单之后可能会退款,所以本地为已退款也算一致
+func (s *billService) isStatusMatched(tradeState string, status payment.PaymentStatus) bool {
+	switch tradeState {
+	case wechatTradeStateSuccess:
+		return status == payment.StatusPaidSuccess || status == payment.StatusRefund
+	case wechatTradeStateRefund:
+		return status == payment.StatusRefund
+	default:
+		return false
+	}
+}
+
+func (s *billService) newRemoteDiscrepancy(billDate string, typ domain.DiscrepancyType, r domain.BillRecord) domain.Discrepancy {
+	return domain.Discrepancy{
+		BillDate:     billDate,
+		Type:         typ,
+		Channel:      payment.ChannelTypeWechat.ToUnit8(),
+		OrderSN:      r.OrderSN,
+		PaymentNO3rd: r.PaymentNO3rd,
+		RemoteAmount: r.Amount,
+		RemoteStatus: r.TradeState,
+		Status:       domain.DiscrepancyStatusPending,
+	}
+}
+
+// wechatRecord 找到微信渠道(Native、JSAPI 或者委托代扣)的支付记录
+func (s *billService) wechatRecord(pmt payment.Payment) (payment.Record, bool) {
+	return slice.Find(pmt.Records, func(src payment.Record) bool {
+		return src.Channel == payment.ChannelTypeWechat || src.Channel == payment.ChannelTypeWechatJS ||
+			src.Channel == payment.ChannelTypeWechatPapay
+	})
+}
+
+func (s *billService) FindDiscrepancies(ctx context.Context, billDate string, typ domain.DiscrepancyType, status domain.DiscrepancyStatus, offset, limit int) ([]domain.Discrepancy, int64, error) {
+	list, err := s.repo.List(ctx, billDate, typ, status, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.Total(ctx, billDate, typ, status)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (s *billService) RepairDiscrepancy(ctx context.Context, id int64) error {
+	d, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if d.Status != domain.DiscrepancyStatusPending {
+		return fmt.Errorf("%w", ErrDiscrepancyStatusConflict)
+	}
+	// 金额不一致和本地缺失都需要人工核实,不能直接以微信为准
+	if d.Type != domain.DiscrepancyTypeStatusMismatch && d.Type != domain.DiscrepancyTypeMissingRemote {
+		return fmt.Errorf("%w", ErrDiscrepancyNotRepairable)
+	}
+
+	pmt, err := s.paymentSvc.FindPaymentByOrderSN(ctx, d.OrderSN)
+	if err != nil {
+		return fmt.Errorf("通过订单SN查找支付记录失败: %w, orderSN: %s", err, d.OrderSN)
+	}
+	// 与同步微信订单的定时任务相同,状态变更后会发送支付事件,订单模块随之更新
+	err = s.paymentSvc.SyncWechatInfo(ctx, pmt)
+	if err != nil {
+		return fmt.Errorf("同步微信支付状态失败: %w, orderSN: %s", err, d.OrderSN)
+	}
+	return s.repo.UpdateStatus(ctx, id, domain.DiscrepancyStatusRepaired, "已按微信支付状态同步")
+}
+
+func (s *billService) IgnoreDiscrepancy(ctx context.Context, id int64, remark string) error {
+	return s.repo.UpdateStatus(ctx, id, domain.DiscrepancyStatusIgnored, remark)
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+)
+
+const (
+	wechatTradeStateSuccess = "SUCCESS"
+	wechatTradeStateRefund  = "REFUND"
+	wechatTradeStateRevoked = "REVOKED"
+
+	// wechatBillSummaryTitle 账单明细之后是汇总数据,以这一列开头
+	wechatBillSummaryTitle = "总交易单数"
+)
+
+const (
+	wechatColumnPaymentNO3rd = "微信订单号"
+	wechatColumnOrderSN      = "商户订单号"
+	wechatColumnTradeState   = "交易状态"
+	wechatColumnSettleAmount = "应结订单金额"
+	wechatColumnOrderAmount  = "订单金额"
+	wechatColumnRefundAmount = "退款金额"
+)
+
+var errInvalidWechatBill = errors.New("非法的微信交易账单")
+
+// parseWechatTradeBill 解析微信交易账单(bill_type=ALL)
+// 账单第一行是表头,之后每一行是一笔支付或者退款,每个字段都以 ` 开头,最后是汇总数据
+// 同一订单的支付行和退款行会合并,有退款行的订单状态为 REFUND,撤销的订单不参与对账
+func parseWechatTradeBill(data []byte) ([]domain.BillRecord, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: 读取表头失败: %w", errInvalidWechatBill, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[wechatBillField(h)] = i
+	}
+	// 优先使用“订单金额”,老版本账单没有这一列时退回使用“应结订单金额”
+	amountColumn := wechatColumnOrderAmount
+	if _, ok := columns[amountColumn]; !ok {
+		amountColumn = wechatColumnSettleAmount
+	}
+	for _, c := range []string{wechatColumnPaymentNO3rd, wechatColumnOrderSN, wechatColumnTradeState, amountColumn, wechatColumnRefundAmount} {
+		if _, ok := columns[c]; !ok {
+			return nil, fmt.Errorf("%w: 缺少列 %s", errInvalidWechatBill, c)
+		}
+	}
+
+	var res []domain.BillRecord
+	indexes := make(map[string]int)
+	for line := 2; ; line++ {
+		row, err1 := reader.Read()
+		if errors.Is(err1, io.EOF) {
+			break
+		}
+		if err1 != nil {
+			return nil, fmt.Errorf("%w: 第 %d 行: %w", errInvalidWechatBill, line, err1)
+		}
+		if wechatBillField(row[0]) == wechatBillSummaryTitle {
+			break
+		}
+		if len(row) < len(header) {
+			return nil, fmt.Errorf("%w: 第 %d 行列数不足", errInvalidWechatBill, line)
+		}
+
+		state := wechatBillField(row[columns[wechatColumnTradeState]])
+		if state == wechatTradeStateRevoked {
+			continue
+		}
+		orderSN := wechatBillField(row[columns[wechatColumnOrderSN]])
+		amount, err2 := parseYuan(wechatBillField(row[columns[amountColumn]]))
+		if err2 != nil {
+			return nil, fmt.Errorf("%w: 第 %d 行: %w", errInvalidWechatBill, line, err2)
+		}
+		refundAmount, err3 := parseYuan(wechatBillField(row[columns[wechatColumnRefundAmount]]))
+		if err3 != nil {
+			return nil, fmt.Errorf("%w: 第 %d 行: %w", errInvalidWechatBill, line, err3)
+		}
+
+		idx, ok := indexes[orderSN]
+		if !ok {
+			indexes[orderSN] = len(res)
+			res = append(res, domain.BillRecord{
+				OrderSN:      orderSN,
+				PaymentNO3rd: wechatBillField(row[columns[wechatColumnPaymentNO3rd]]),
+				TradeState:   state,
+				Amount:       amount,
+				RefundAmount: refundAmount,
+			})
+			continue
+		}
+		r := &res[idx]
+		if state == wechatTradeStateRefund {
+			r.TradeState = wechatTradeStateRefund
+			r.RefundAmount += refundAmount
+		}
+		if r.Amount == 0 {
+			r.Amount = amount
+		}
+	}
+	return res, nil
+}
+
+func wechatBillField(s string) string {
+	return strings.TrimPrefix(strings.TrimSpace(s), "`")
+}
+
+// parseYuan 将以元为单位的金额转换为分, 空字符串视为 0
+func parseYuan(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("非法的金额 %s", s)
+	}
+	return int64(math.Round(v * 100)), nil
+}
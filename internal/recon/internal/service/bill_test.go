@@ -0,0 +1,226 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/payment"
+	paymentmocks "github.com/ecodeclub/webook/internal/payment/mocks"
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseWechatTradeBill(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/wechat_tradebill.csv")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		data    []byte
+		want    []domain.BillRecord
+		wantErr error
+	}{
+		{
+			name: "合并退款行且跳过撤销订单和汇总数据",
+			data: fixture,
+			want: []domain.BillRecord{
+				{
+					OrderSN:      "order-sn-1",
+					PaymentNO3rd: "4200002101202405011234567801",
+					TradeState:   "SUCCESS",
+					Amount:       9990,
+				},
+				{
+					OrderSN:      "order-sn-2",
+					PaymentNO3rd: "4200002101202405011234567802",
+					TradeState:   "REFUND",
+					Amount:       1990,
+					RefundAmount: 1990,
+				},
+			},
+		},
+		{
+			name: "带BOM头",
+			data: append([]byte("\xef\xbb\xbf"), fixture...),
+			want: []domain.BillRecord{
+				{
+					OrderSN:      "order-sn-1",
+					PaymentNO3rd: "4200002101202405011234567801",
+					TradeState:   "SUCCESS",
+					Amount:       9990,
+				},
+				{
+					OrderSN:      "order-sn-2",
+					PaymentNO3rd: "4200002101202405011234567802",
+					TradeState:   "REFUND",
+					Amount:       1990,
+					RefundAmount: 1990,
+				},
+			},
+		},
+		{
+			name: "空账单",
+			data: nil,
+		},
+		{
+			name:    "缺少列",
+			data:    []byte("交易时间,微信订单号,商户订单号,交易状态\n`2024-05-01 09:12:33,`42000,`order-sn-1,`SUCCESS\n"),
+			wantErr: errInvalidWechatBill,
+		},
+		{
+			name: "非法金额",
+			data: []byte("微信订单号,商户订单号,交易状态,订单金额,退款金额\n" +
+				"`42000,`order-sn-1,`SUCCESS,`abc,`0.00\n"),
+			wantErr: errInvalidWechatBill,
+		},
+		{
+			name: "没有订单金额列时使用应结订单金额",
+			data: []byte("微信订单号,商户订单号,交易状态,应结订单金额,退款金额\n" +
+				"`42000,`order-sn-1,`SUCCESS,`0.01,`0.00\n" +
+				"总交易单数,应结订单总金额\n`1,`0.01\n"),
+			want: []domain.BillRecord{
+				{OrderSN: "order-sn-1", PaymentNO3rd: "42000", TradeState: "SUCCESS", Amount: 1},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseWechatTradeBill(tc.data)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestBillService_ReconcileWechatBill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// UTC 的 15 点是北京时间的 23 点，按北京时间的 5 月 1 日核对
+	billDate := time.Date(2024, 5, 1, 15, 0, 0, 0, time.UTC)
+	cst := time.FixedZone("CST", 8*3600)
+	stime := time.Date(2024, 5, 1, 0, 0, 0, 0, cst).UnixMilli()
+	etime := time.Date(2024, 5, 2, 0, 0, 0, 0, cst).UnixMilli()
+
+	bill := []byte("微信订单号,商户订单号,交易状态,订单金额,退款金额\n" +
+		// 一致
+		"`42001,`order-sn-1,`SUCCESS,`99.90,`0.00\n" +
+		// 金额不一致
+		"`42002,`order-sn-2,`SUCCESS,`19.90,`0.00\n" +
+		// 本地回调丢失仍为未支付, 不在当天已支付的记录中
+		"`42003,`order-sn-3,`SUCCESS,`9.90,`0.00\n" +
+		// 本地没有
+		"`42004,`order-sn-4,`SUCCESS,`9.90,`0.00\n" +
+		// 之前支付今天退款, 本地仍为支付成功
+		"`42006,`order-sn-6,`REFUND,`9.90,`9.90\n" +
+		"总交易单数,应结订单总金额\n`5,`149.50\n")
+
+	paid := []payment.Payment{
+		newWechatPayment("order-sn-1", "42001", 9990, payment.StatusPaidSuccess),
+		newWechatPayment("order-sn-2", "42002", 990, payment.StatusPaidSuccess),
+		// 微信没有
+		newWechatPayment("order-sn-5", "42005", 990, payment.StatusPaidSuccess),
+		// 纯积分支付不参与对账
+		{
+			OrderSN: "order-sn-7",
+			Status:  payment.StatusPaidSuccess,
+			Records: []payment.Record{{Channel: payment.ChannelTypeCredit, Amount: 990, Status: payment.StatusPaidSuccess}},
+		},
+	}
+	others := map[string]payment.Payment{
+		"order-sn-3": newWechatPayment("order-sn-3", "", 990, payment.StatusUnpaid),
+		"order-sn-6": newWechatPayment("order-sn-6", "42006", 990, payment.StatusPaidSuccess),
+	}
+
+	paymentSvc := paymentmocks.NewMockService(ctrl)
+	paymentSvc.EXPECT().FindPaidPayments(gomock.Any(), 0, 3, stime, etime).Return(paid[:3], nil)
+	paymentSvc.EXPECT().FindPaidPayments(gomock.Any(), 3, 3, stime, etime).Return(paid[3:], nil)
+	paymentSvc.EXPECT().FindPaymentByOrderSN(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, orderSN string) (payment.Payment, error) {
+			pmt, ok := others[orderSN]
+			if !ok {
+				return payment.Payment{}, fmt.Errorf("查找支付主记录失败: %w", payment.ErrRecordNotFound)
+			}
+			return pmt, nil
+		}).Times(3)
+
+	repo := &memDiscrepancyRepo{}
+	svc := NewBillService(fixtureFetcher(bill), paymentSvc, repo, 3)
+	require.NoError(t, svc.ReconcileWechatBill(context.Background(), billDate))
+
+	newDiscrepancy := func(typ domain.DiscrepancyType, sn, no3rd string, local, remote int64, localStatus payment.PaymentStatus, remoteStatus string) domain.Discrepancy {
+		return domain.Discrepancy{
+			BillDate:     "2024-05-01",
+			Type:         typ,
+			Channel:      payment.ChannelTypeWechat.ToUnit8(),
+			OrderSN:      sn,
+			PaymentNO3rd: no3rd,
+			LocalAmount:  local,
+			RemoteAmount: remote,
+			LocalStatus:  uint8(localStatus),
+			RemoteStatus: remoteStatus,
+			Status:       domain.DiscrepancyStatusPending,
+		}
+	}
+	assert.Equal(t, []domain.Discrepancy{
+		newDiscrepancy(domain.DiscrepancyTypeAmountMismatch, "order-sn-2", "42002", 990, 1990, payment.StatusPaidSuccess, "SUCCESS"),
+		newDiscrepancy(domain.DiscrepancyTypeStatusMismatch, "order-sn-3", "42003", 990, 990, payment.StatusUnpaid, "SUCCESS"),
+		newDiscrepancy(domain.DiscrepancyTypeMissingLocal, "order-sn-4", "42004", 0, 990, 0, "SUCCESS"),
+		newDiscrepancy(domain.DiscrepancyTypeStatusMismatch, "order-sn-6", "42006", 990, 990, payment.StatusPaidSuccess, "REFUND"),
+		newDiscrepancy(domain.DiscrepancyTypeMissingRemote, "order-sn-5", "42005", 990, 0, payment.StatusPaidSuccess, ""),
+	}, repo.ds)
+}
+
+func newWechatPayment(orderSN, no3rd string, amount int64, status payment.PaymentStatus) payment.Payment {
+	return payment.Payment{
+		OrderSN:     orderSN,
+		TotalAmount: amount,
+		Status:      status,
+		Records: []payment.Record{
+			{
+				PaymentNO3rd: no3rd,
+				Channel:      payment.ChannelTypeWechat,
+				Amount:       amount,
+				Status:       status,
+			},
+		},
+	}
+}
+
+// fixtureFetcher 直接返回本地账单内容
+type fixtureFetcher []byte
+
+func (f fixtureFetcher) DownloadTradeBill(ctx context.Context, billDate time.Time) ([]byte, error) {
+	return f, nil
+}
+
+type memDiscrepancyRepo struct {
+	repository.DiscrepancyRepository
+	ds []domain.Discrepancy
+}
+
+func (m *memDiscrepancyRepo) BatchCreate(ctx context.Context, ds []domain.Discrepancy) error {
+	m.ds = append(m.ds, ds...)
+	return nil
+}
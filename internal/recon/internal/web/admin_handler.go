@@ -0,0 +1,101 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/webook/internal/recon/internal/domain"
+	"github.com/ecodeclub/webook/internal/recon/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	svc service.BillService
+	loc *time.Location
+}
+
+func NewAdminHandler(svc service.BillService, loc *time.Location) *AdminHandler {
+	return &AdminHandler{svc: svc, loc: loc}
+}
+
+func (h *AdminHandler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/recon")
+	g.POST("/wechat/reconcile", ginx.B[ReconcileReq](h.Reconcile))
+	g.POST("/discrepancy/list", ginx.B[ListDiscrepanciesReq](h.List))
+	g.POST("/discrepancy/repair", ginx.B[DiscrepancyIDReq](h.Repair))
+	g.POST("/discrepancy/ignore", ginx.B[IgnoreDiscrepancyReq](h.Ignore))
+}
+
+// Reconcile 手动核对某一天的微信账单,比如修复之后重新核对
+func (h *AdminHandler) Reconcile(ctx *ginx.Context, req ReconcileReq) (ginx.Result, error) {
+	billDate, err := time.ParseInLocation(time.DateOnly, req.BillDate, h.loc)
+	if err != nil {
+		return invalidBillDateResult, nil
+	}
+	err = h.svc.ReconcileWechatBill(ctx.Request.Context(), billDate)
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("核对微信账单失败: %w, billDate: %s", err, req.BillDate)
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *AdminHandler) List(ctx *ginx.Context, req ListDiscrepanciesReq) (ginx.Result, error) {
+	list, total, err := h.svc.FindDiscrepancies(ctx.Request.Context(), req.BillDate,
+		domain.DiscrepancyType(req.Type), domain.DiscrepancyStatus(req.Status), req.Offset, req.Limit)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: ListDiscrepanciesResp{
+			Total: total,
+			Discrepancies: slice.Map(list, func(idx int, src domain.Discrepancy) Discrepancy {
+				return newDiscrepancy(src)
+			}),
+		},
+	}, nil
+}
+
+// Repair 以微信为准同步支付状态,订单状态随支付事件更新
+func (h *AdminHandler) Repair(ctx *ginx.Context, req DiscrepancyIDReq) (ginx.Result, error) {
+	err := h.svc.RepairDiscrepancy(ctx.Request.Context(), req.ID)
+	switch {
+	case err == nil:
+		return ginx.Result{Msg: "OK"}, nil
+	case errors.Is(err, service.ErrRecordNotFound):
+		return discrepancyNotFoundResult, nil
+	case errors.Is(err, service.ErrDiscrepancyStatusConflict):
+		return discrepancyStatusConflictResult, nil
+	case errors.Is(err, service.ErrDiscrepancyNotRepairable):
+		return discrepancyNotRepairableResult, nil
+	default:
+		return systemErrorResult, fmt.Errorf("修复对账差异失败: %w, id: %d", err, req.ID)
+	}
+}
+
+func (h *AdminHandler) Ignore(ctx *ginx.Context, req IgnoreDiscrepancyReq) (ginx.Result, error) {
+	err := h.svc.IgnoreDiscrepancy(ctx.Request.Context(), req.ID, req.Remark)
+	if errors.Is(err, service.ErrDiscrepancyStatusConflict) {
+		return discrepancyStatusConflictResult, nil
+	}
+	if err != nil {
+		return systemErrorResult, fmt.Errorf("忽略对账差异失败: %w, id: %d", err, req.ID)
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import "github.com/ecodeclub/webook/internal/recon/internal/domain"
+
+type ListDiscrepanciesReq struct {
+	// BillDate 账单日期 2006-01-02, 为空查询全部
+	BillDate string `json:"billDate,omitempty"`
+	Type     uint8  `json:"type,omitempty"`
+	Status   uint8  `json:"status,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+type ListDiscrepanciesResp struct {
+	Total         int64         `json:"total,omitempty"`
+	Discrepancies []Discrepancy `json:"discrepancies,omitempty"`
+}
+
+type Discrepancy struct {
+	ID           int64  `json:"id,omitempty"`
+	BillDate     string `json:"billDate,omitempty"`
+	Type         uint8  `json:"type,omitempty"`
+	Channel      uint8  `json:"channel,omitempty"`
+	OrderSN      string `json:"orderSN,omitempty"`
+	PaymentNO3rd string `json:"paymentNO3rd,omitempty"`
+	LocalAmount  int64  `json:"localAmount,omitempty"`
+	RemoteAmount int64  `json:"remoteAmount,omitempty"`
+	LocalStatus  uint8  `json:"localStatus,omitempty"`
+	RemoteStatus string `json:"remoteStatus,omitempty"`
+	Status       uint8  `json:"status,omitempty"`
+	Remark       string `json:"remark,omitempty"`
+	Ctime        int64  `json:"ctime,omitempty"`
+	Utime        int64  `json:"utime,omitempty"`
+}
+
+func newDiscrepancy(d domain.Discrepancy) Discrepancy {
+	return Discrepancy{
+		ID:           d.ID,
+		BillDate:     d.BillDate,
+		Type:         d.Type.ToUint8(),
+		Channel:      d.Channel,
+		OrderSN:      d.OrderSN,
+		PaymentNO3rd: d.PaymentNO3rd,
+		LocalAmount:  d.LocalAmount,
+		RemoteAmount: d.RemoteAmount,
+		LocalStatus:  d.LocalStatus,
+		RemoteStatus: d.RemoteStatus,
+		Status:       d.Status.ToUint8(),
+		Remark:       d.Remark,
+		Ctime:        d.Ctime,
+		Utime:        d.Utime,
+	}
+}
+
+// DiscrepancyIDReq 以微信为准修复差异
+type DiscrepancyIDReq struct {
+	ID int64 `json:"id"`
+}
+
+// IgnoreDiscrepancyReq 忽略差异, 备注处理方式
+type IgnoreDiscrepancyReq struct {
+	ID     int64  `json:"id"`
+	Remark string `json:"remark"`
+}
+
+// ReconcileReq 手动重新核对某一天的账单
+type ReconcileReq struct {
+	BillDate string `json:"billDate"`
+}
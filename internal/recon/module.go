@@ -17,4 +17,6 @@ package recon
 type Module struct {
 	Svc                    Service
 	SyncPaymentAndOrderJob *SyncPaymentAndOrderJob
+	ReconcileWechatBillJob *ReconcileWechatBillJob
+	AdminHdl               *AdminHandler
 }
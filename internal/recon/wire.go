@@ -17,27 +17,41 @@
 package recon
 
 import (
+	"sync"
 	"time"
 
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order"
 	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/ecodeclub/webook/internal/recon/internal/job"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/recon/internal/service"
+	"github.com/ecodeclub/webook/internal/recon/internal/web"
+	"github.com/ego-component/egorm"
 	"github.com/google/wire"
 )
 
 type (
 	Service                = service.Service
 	SyncPaymentAndOrderJob = job.SyncPaymentAndOrderJob
+	ReconcileWechatBillJob = job.ReconcileWechatBillJob
+	AdminHandler           = web.AdminHandler
 )
 
-func InitModule(o *order.Module, p *payment.Module, c *credit.Module) (*Module, error) {
+func InitModule(db *egorm.Component, o *order.Module, p *payment.Module, c *credit.Module) (*Module, error) {
 	wire.Build(
 		initService,
 		initSyncPaymentAndOrderJob,
+		initDAO,
+		repository.NewDiscrepancyRepository,
+		initBillService,
+		wire.Bind(new(service.BillFetcher), new(*payment.WechatBillDownloader)),
+		job.NewReconcileWechatBillJob,
+		web.NewAdminHandler,
+		initLocation,
 		wire.FieldsOf(new(*order.Module), "Svc"),
-		wire.FieldsOf(new(*payment.Module), "Svc"),
+		wire.FieldsOf(new(*payment.Module), "Svc", "WechatBillDownloader"),
 		wire.FieldsOf(new(*credit.Module), "Svc"),
 		wire.Struct(new(Module), "*"),
 	)
@@ -59,3 +73,25 @@ func initSyncPaymentAndOrderJob(svc service.Service) *SyncPaymentAndOrderJob {
 	limit := 100
 	return job.NewSyncPaymentAndOrderJob(svc, minutes, seconds, limit)
 }
+
+var (
+	once           = &sync.Once{}
+	discrepancyDAO dao.DiscrepancyDAO
+)
+
+func initDAO(db *egorm.Component) dao.DiscrepancyDAO {
+	once.Do(func() {
+		_ = dao.InitTables(db)
+		discrepancyDAO = dao.NewDiscrepancyGORMDAO(db)
+	})
+	return discrepancyDAO
+}
+
+func initBillService(fetcher service.BillFetcher, paymentSvc payment.Service, repo repository.DiscrepancyRepository) service.BillService {
+	batchSize := 100
+	return service.NewBillService(fetcher, paymentSvc, repo, batchSize)
+}
+
+func initLocation() *time.Location {
+	return service.BillLocation
+}
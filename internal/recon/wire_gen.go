@@ -7,26 +7,41 @@
 package recon
 
 import (
+	"sync"
 	"time"
 
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order"
 	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/ecodeclub/webook/internal/recon/internal/job"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository"
+	"github.com/ecodeclub/webook/internal/recon/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/recon/internal/service"
+	"github.com/ecodeclub/webook/internal/recon/internal/web"
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitModule(o *order.Module, p *payment.Module, c *credit.Module) (*Module, error) {
+func InitModule(db *gorm.DB, o *order.Module, p *payment.Module, c *credit.Module) (*Module, error) {
 	service := o.Svc
 	serviceService := p.Svc
 	service2 := c.Svc
 	service3 := initService(service, serviceService, service2)
 	syncPaymentAndOrderJob := initSyncPaymentAndOrderJob(service3)
+	tradeBillDownloader := p.WechatBillDownloader
+	daoDiscrepancyDAO := initDAO(db)
+	discrepancyRepository := repository.NewDiscrepancyRepository(daoDiscrepancyDAO)
+	billService := initBillService(tradeBillDownloader, serviceService, discrepancyRepository)
+	location := initLocation()
+	reconcileWechatBillJob := job.NewReconcileWechatBillJob(billService, location)
+	adminHandler := web.NewAdminHandler(billService, location)
 	module := &Module{
 		Svc:                    service3,
 		SyncPaymentAndOrderJob: syncPaymentAndOrderJob,
+		ReconcileWechatBillJob: reconcileWechatBillJob,
+		AdminHdl:               adminHandler,
 	}
 	return module, nil
 }
@@ -36,6 +51,8 @@ func InitModule(o *order.Module, p *payment.Module, c *credit.Module) (*Module,
 type (
 	Service                = service.Service
 	SyncPaymentAndOrderJob = job.SyncPaymentAndOrderJob
+	ReconcileWechatBillJob = job.ReconcileWechatBillJob
+	AdminHandler           = web.AdminHandler
 )
 
 func initService(orderSvc order.Service,
@@ -53,3 +70,25 @@ func initSyncPaymentAndOrderJob(svc service.Service) *SyncPaymentAndOrderJob {
 	limit := 100
 	return job.NewSyncPaymentAndOrderJob(svc, minutes, seconds, limit)
 }
+
+var (
+	once           = &sync.Once{}
+	discrepancyDAO dao.DiscrepancyDAO
+)
+
+func initDAO(db *egorm.Component) dao.DiscrepancyDAO {
+	once.Do(func() {
+		_ = dao.InitTables(db)
+		discrepancyDAO = dao.NewDiscrepancyGORMDAO(db)
+	})
+	return discrepancyDAO
+}
+
+func initBillService(fetcher service.BillFetcher, paymentSvc payment.Service, repo repository.DiscrepancyRepository) service.BillService {
+	batchSize := 100
+	return service.NewBillService(fetcher, paymentSvc, repo, batchSize)
+}
+
+func initLocation() *time.Location {
+	return service.BillLocation
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"testing"
 
 	"github.com/ecodeclub/ekit/iox"
@@ -13,6 +14,7 @@ import (
 	aimocks "github.com/ecodeclub/webook/internal/ai/mocks"
 	"github.com/ecodeclub/webook/internal/cases"
 	"github.com/ecodeclub/webook/internal/resume/internal/domain"
+	"github.com/ecodeclub/webook/internal/resume/internal/errs"
 	"github.com/ecodeclub/webook/internal/resume/internal/integration/startup"
 	"github.com/ecodeclub/webook/internal/resume/internal/web"
 	"github.com/ecodeclub/webook/internal/test"
@@ -34,6 +36,12 @@ func (a *AnalysisTestSuite) SetupSuite() {
 	ctrl := gomock.NewController(a.T())
 	aiSvc := aimocks.NewMockService(ctrl)
 	aiSvc.EXPECT().Invoke(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, req ai.LLMRequest) (ai.LLMResponse, error) {
+		if slices.Contains(req.Input, "敏感简历") {
+			return ai.LLMResponse{}, ai.ErrContentBlocked
+		}
+		if slices.Contains(req.Input, "限流简历") {
+			return ai.LLMResponse{}, ai.ErrRateLimited
+		}
 		switch req.Biz {
 		case domain.BizResumeSkillKeyPoints:
 			return ai.LLMResponse{
@@ -108,6 +116,28 @@ func (a *AnalysisTestSuite) TestAnalysis() {
 				},
 			},
 		},
+		{
+			name: "简历没有通过审核",
+			req: web.AnalysisReq{
+				Resume: "敏感简历",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.AnalysisResp]{
+				Code: errs.ContentBlocked.Code,
+				Msg:  errs.ContentBlocked.Msg,
+			},
+		},
+		{
+			name: "触发限流",
+			req: web.AnalysisReq{
+				Resume: "限流简历",
+			},
+			wantCode: 200,
+			wantResp: test.Result[web.AnalysisResp]{
+				Code: errs.RateLimited.Code,
+				Msg:  errs.RateLimited.Msg,
+			},
+		},
 	}
 	for _, tc := range testCases {
 		a.T().Run(tc.name, func(t *testing.T) {
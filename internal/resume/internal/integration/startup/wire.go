@@ -24,6 +24,7 @@ func InitModule(caModule *cases.Module, aiModule *ai.Module) *resume.Module {
 		service.NewService,
 		service.NewExperienceService,
 		service.NewAnalysisService,
+		service.NewPrivacyService,
 		wire.FieldsOf(new(*cases.Module), "ExamineSvc"),
 		wire.FieldsOf(new(*cases.Module), "Svc"),
 		wire.FieldsOf(new(*ai.Module), "Svc"),
@@ -34,10 +34,12 @@ func InitModule(caModule *cases.Module, aiModule *ai.Module) *resume.Module {
 	llmService := aiModule.Svc
 	analysisService := service.NewAnalysisService(llmService)
 	analysisHandler := web.NewAnalysisHandler(analysisService)
+	privacyService := service.NewPrivacyService(serviceService, experienceService)
 	module := &resume.Module{
 		PrjHdl:          projectHandler,
 		ExperienceHdl:   experienceHandler,
 		AnalysisHandler: analysisHandler,
+		PrivacySvc:      privacyService,
 	}
 	return module
 }
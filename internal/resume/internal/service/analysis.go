@@ -11,7 +11,11 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-var ErrInsufficientCredit = ai.ErrInsufficientCredit
+var (
+	ErrInsufficientCredit = ai.ErrInsufficientCredit
+	ErrContentBlocked     = ai.ErrContentBlocked
+	ErrRateLimited        = ai.ErrRateLimited
+)
 
 type AnalysisService interface {
 	Analysis(ctx context.Context, uid int64, resume string) (domain.ResumeAnalysis, error)
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/resume/internal/domain"
+)
+
+// PrivacyService 导出以及删除用户的简历项目和工作经历
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	svc    Service
+	expSvc ExperienceService
+}
+
+func NewPrivacyService(svc Service, expSvc ExperienceService) PrivacyService {
+	return &privacyService{
+		svc:    svc,
+		expSvc: expSvc,
+	}
+}
+
+func (p *privacyService) Name() string {
+	return "resume"
+}
+
+type resumeData struct {
+	Projects    []domain.Project    `json:"projects"`
+	Experiences []domain.Experience `json:"experiences"`
+}
+
+func (p *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	projects, err := p.svc.FindProjects(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	experiences, _, err := p.expSvc.List(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return resumeData{Projects: projects, Experiences: experiences}, nil
+}
+
+func (p *privacyService) Erase(ctx context.Context, uid int64) error {
+	projects, err := p.svc.FindProjects(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, pro := range projects {
+		// 会一并删除职责和难点
+		err = p.svc.DeleteProject(ctx, uid, pro.Id)
+		if err != nil {
+			return err
+		}
+	}
+	experiences, _, err := p.expSvc.List(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, exp := range experiences {
+		err = p.expSvc.Delete(ctx, uid, exp.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
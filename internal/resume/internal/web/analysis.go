@@ -33,7 +33,16 @@ func (h *AnalysisHandler) Analysis(ctx *ginx.Context, req AnalysisReq, sess sess
 			Code: errs.InsufficientCredit.Code,
 			Msg:  errs.InsufficientCredit.Msg,
 		}, nil
-
+	case errors.Is(err, service.ErrContentBlocked):
+		return ginx.Result{
+			Code: errs.ContentBlocked.Code,
+			Msg:  errs.ContentBlocked.Msg,
+		}, nil
+	case errors.Is(err, service.ErrRateLimited):
+		return ginx.Result{
+			Code: errs.RateLimited.Code,
+			Msg:  errs.RateLimited.Msg,
+		}, nil
 	case err == nil:
 		return ginx.Result{
 			Data: AnalysisResp{
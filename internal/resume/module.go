@@ -14,14 +14,19 @@
 
 package resume
 
-import "github.com/ecodeclub/webook/internal/resume/internal/web"
+import (
+	"github.com/ecodeclub/webook/internal/resume/internal/service"
+	"github.com/ecodeclub/webook/internal/resume/internal/web"
+)
 
 type ExperienceHandler = web.ExperienceHandler
 type ProjectHandler = web.ProjectHandler
 type AnalysisHandler = web.AnalysisHandler
+type PrivacyService = service.PrivacyService
 
 type Module struct {
 	PrjHdl          *ProjectHandler
 	ExperienceHdl   *ExperienceHandler
 	AnalysisHandler *AnalysisHandler
+	PrivacySvc      PrivacyService
 }
@@ -42,6 +42,7 @@ func InitModule(db *egorm.Component, caModule *cases.Module, aiModule *ai.Module
 		wire.FieldsOf(new(*cases.Module), "Svc"),
 		wire.FieldsOf(new(*ai.Module), "Svc"),
 		service.NewAnalysisService,
+		service.NewPrivacyService,
 		web.NewHandler,
 		web.NewAnalysisHandler,
 		web.NewExperienceHandler,
@@ -35,10 +35,12 @@ func InitModule(db *gorm.DB, caModule *cases.Module, aiModule *ai.Module) *Modul
 	llmService := aiModule.Svc
 	analysisService := service.NewAnalysisService(llmService)
 	analysisHandler := web.NewAnalysisHandler(analysisService)
+	privacyService := service.NewPrivacyService(serviceService, experienceService)
 	module := &Module{
 		PrjHdl:          projectHandler,
 		ExperienceHdl:   experienceHandler,
 		AnalysisHandler: analysisHandler,
+		PrivacySvc:      privacyService,
 	}
 	return module
 }
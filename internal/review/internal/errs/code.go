@@ -15,7 +15,8 @@
 package errs
 
 var (
-	SystemError = ErrorCode{Code: 516001, Msg: "系统错误"}
+	SystemError    = ErrorCode{Code: 516001, Msg: "系统错误"}
+	ContentBlocked = ErrorCode{Code: 516002, Msg: "面经包含敏感信息"}
 )
 
 type ErrorCode struct {
@@ -235,6 +235,50 @@ func (s *AdminHandlerTestSuite) TestSave() {
 				Data: 2,
 			},
 		},
+		{
+			name: "敏感词打码",
+			before: func(t *testing.T) {
+			},
+			after: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				review, err := s.reviewDao.Get(ctx, 3)
+				require.NoError(t, err)
+				assert.Equal(t, "加**内推", review.Title)
+				assert.Equal(t, "内推请加**", review.Content)
+			},
+			req: web.ReviewSaveReq{
+				Review: web.Review{
+					Title:   "加微信内推",
+					Content: "内推请加微信",
+					Company: web.Company{
+						ID: 1,
+					},
+				},
+			},
+			wantCode: 200,
+			wantResp: test.Result[int64]{
+				Data: 3,
+			},
+		},
+		{
+			name: "敏感词拦截",
+			before: func(t *testing.T) {
+			},
+			after: func(t *testing.T) {
+			},
+			req: web.ReviewSaveReq{
+				Review: web.Review{
+					Title:   "标题",
+					Content: "线上赌博",
+				},
+			},
+			wantCode: 200,
+			wantResp: test.Result[int64]{
+				Code: 516002,
+				Msg:  "面经包含敏感信息",
+			},
+		},
 	}
 	for _, tc := range testCases {
 		s.T().Run(tc.name, func(t *testing.T) {
@@ -22,6 +22,7 @@ import (
 	"github.com/ecodeclub/mq-api"
 	"github.com/ecodeclub/webook/internal/company"
 	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/review"
 	"github.com/ecodeclub/webook/internal/review/internal/event"
 	"github.com/ecodeclub/webook/internal/review/internal/repository"
@@ -39,6 +40,7 @@ func InitModule(db *egorm.Component, interSvc *interactive.Module,
 	wire.Build(
 		initReviewDao,
 		initIntrProducer,
+		initModerationFilter,
 		repository.NewReviewRepo,
 		cache.NewReviewCache,
 		service.NewReviewSvc,
@@ -65,3 +67,10 @@ func initIntrProducer(q mq.MQ) event.InteractiveEventProducer {
 	}
 	return producer
 }
+
+func initModerationFilter() moderation.Filter {
+	return moderation.NewDictFilter(moderation.Dict{
+		Block: []string{"赌博"},
+		Mask:  []string{"微信"},
+	}, nil)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ecodeclub/mq-api"
 	"github.com/ecodeclub/webook/internal/company"
 	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/review"
 	"github.com/ecodeclub/webook/internal/review/internal/event"
 	"github.com/ecodeclub/webook/internal/review/internal/repository"
@@ -30,7 +31,8 @@ func InitModule(db *gorm.DB, interSvc *interactive.Module, companySvc *company.M
 	reviewCache := cache.NewReviewCache(ec)
 	reviewRepo := repository.NewReviewRepo(reviewDAO, reviewCache)
 	interactiveEventProducer := initIntrProducer(q)
-	reviewSvc := service.NewReviewSvc(reviewRepo, interactiveEventProducer)
+	filter := initModerationFilter()
+	reviewSvc := service.NewReviewSvc(reviewRepo, interactiveEventProducer, filter)
 	serviceService := interSvc.Svc
 	companyService := companySvc.Svc
 	handler := web.NewHandler(reviewSvc, serviceService, companyService, sp)
@@ -59,3 +61,10 @@ func initIntrProducer(q mq.MQ) event.InteractiveEventProducer {
 	}
 	return producer
 }
+
+func initModerationFilter() moderation.Filter {
+	return moderation.NewDictFilter(moderation.Dict{
+		Block: []string{"赌博"},
+		Mask:  []string{"微信"},
+	}, nil)
+}
@@ -16,8 +16,10 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/review/internal/event"
 	"github.com/gotomicro/ego/core/elog"
 
@@ -37,11 +39,16 @@ type ReviewSvc interface {
 	PubInfo(ctx context.Context, id int64) (domain.Review, error)
 }
 
-func NewReviewSvc(repo repository.ReviewRepo, intrProducer event.InteractiveEventProducer) ReviewSvc {
+// ErrContentBlocked 面经没有通过内容审核
+var ErrContentBlocked = moderation.ErrBlocked
+
+func NewReviewSvc(repo repository.ReviewRepo, intrProducer event.InteractiveEventProducer,
+	filter moderation.Filter) ReviewSvc {
 	return &reviewSvc{
 		repo:         repo,
 		logger:       elog.DefaultLogger,
 		intrProducer: intrProducer,
+		filter:       filter,
 	}
 }
 
@@ -49,13 +56,33 @@ type reviewSvc struct {
 	repo         repository.ReviewRepo
 	logger       *elog.Component
 	intrProducer event.InteractiveEventProducer
+	filter       moderation.Filter
 }
 
 func (r *reviewSvc) Save(ctx context.Context, re domain.Review) (int64, error) {
+	re, err := r.moderate(ctx, re)
+	if err != nil {
+		return 0, err
+	}
 	re.Status = domain.UnPublishedStatus
 	return r.repo.Save(ctx, re)
 }
 
+// moderate 审核标题、简介和正文，命中打码词典的部分会被替换成打码之后的内容
+func (r *reviewSvc) moderate(ctx context.Context, re domain.Review) (domain.Review, error) {
+	for _, field := range []*string{&re.Title, &re.Desc, &re.Content} {
+		d, err := r.filter.Check(ctx, *field)
+		if err != nil {
+			return re, err
+		}
+		if d.Blocked() {
+			return re, fmt.Errorf("%w, 命中 %v", ErrContentBlocked, d.Hits)
+		}
+		*field = d.Text
+	}
+	return re, nil
+}
+
 func (r *reviewSvc) List(ctx context.Context, offset, limit int) (int64, []domain.Review, error) {
 	var eg errgroup.Group
 	var count int64
@@ -80,6 +107,10 @@ func (r *reviewSvc) Info(ctx context.Context, id int64) (domain.Review, error) {
 }
 
 func (r *reviewSvc) Publish(ctx context.Context, re domain.Review) (int64, error) {
+	re, err := r.moderate(ctx, re)
+	if err != nil {
+		return 0, err
+	}
 	re.Status = domain.PublishedStatus
 	return r.repo.Publish(ctx, re)
 }
@@ -15,6 +15,8 @@
 package web
 
 import (
+	"errors"
+
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/ecodeclub/ginx"
 	"github.com/ecodeclub/ginx/session"
@@ -51,7 +53,10 @@ func (h *AdminHandler) Save(ctx *ginx.Context, req ReviewSaveReq, sess session.S
 	review := req.Review.toDomain()
 	review.Uid = uid
 	id, err := h.svc.Save(ctx, review)
-	if err != nil {
+	switch {
+	case errors.Is(err, service.ErrContentBlocked):
+		return contentBlockedResult, nil
+	case err != nil:
 		return systemErrorResult, err
 	}
 	return ginx.Result{
@@ -110,7 +115,10 @@ func (h *AdminHandler) Publish(ctx *ginx.Context, req ReviewSaveReq, sess sessio
 	review := req.Review.toDomain()
 	review.Uid = uid
 	id, err := h.svc.Publish(ctx, review)
-	if err != nil {
+	switch {
+	case errors.Is(err, service.ErrContentBlocked):
+		return contentBlockedResult, nil
+	case err != nil:
 		return systemErrorResult, err
 	}
 	return ginx.Result{
@@ -24,4 +24,8 @@ var (
 		Code: errs.SystemError.Code,
 		Msg:  errs.SystemError.Msg,
 	}
+	contentBlockedResult = ginx.Result{
+		Code: errs.ContentBlocked.Code,
+		Msg:  errs.ContentBlocked.Msg,
+	}
 )
@@ -22,6 +22,7 @@ import (
 	"github.com/ecodeclub/mq-api"
 	"github.com/ecodeclub/webook/internal/company"
 	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/review/internal/event"
 	"github.com/ecodeclub/webook/internal/review/internal/repository"
 	"github.com/ecodeclub/webook/internal/review/internal/repository/cache"
@@ -38,6 +39,7 @@ func InitModule(db *egorm.Component,
 	q mq.MQ,
 	sp session.Provider,
 	ec ecache.Cache,
+	filter moderation.Filter,
 ) *Module {
 	wire.Build(
 		initReviewDao,
@@ -12,6 +12,7 @@ import (
 	"github.com/ecodeclub/mq-api"
 	"github.com/ecodeclub/webook/internal/company"
 	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
 	"github.com/ecodeclub/webook/internal/review/internal/event"
 	"github.com/ecodeclub/webook/internal/review/internal/repository"
 	"github.com/ecodeclub/webook/internal/review/internal/repository/cache"
@@ -24,12 +25,12 @@ import (
 
 // Injectors from wire.go:
 
-func InitModule(db *gorm.DB, interSvc *interactive.Module, companyModule *company.Module, q mq.MQ, sp session.Provider, ec ecache.Cache) *Module {
+func InitModule(db *gorm.DB, interSvc *interactive.Module, companyModule *company.Module, q mq.MQ, sp session.Provider, ec ecache.Cache, filter moderation.Filter) *Module {
 	reviewDAO := initReviewDao(db)
 	reviewCache := cache.NewReviewCache(ec)
 	reviewRepo := repository.NewReviewRepo(reviewDAO, reviewCache)
 	interactiveEventProducer := initIntrProducer(q)
-	reviewSvc := service.NewReviewSvc(reviewRepo, interactiveEventProducer)
+	reviewSvc := service.NewReviewSvc(reviewRepo, interactiveEventProducer, filter)
 	serviceService := interSvc.Svc
 	companyService := companyModule.Svc
 	handler := web.NewHandler(reviewSvc, serviceService, companyService, sp)
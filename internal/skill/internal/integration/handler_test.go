@@ -145,6 +145,19 @@ func (s *HandlerTestSuite) SetupSuite() {
 			}
 			return res, nil
 		}).AnyTimes()
+	caseExamSvc.EXPECT().Progress(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, uid int64, cids []int64) ([]cases.ExamineProgress, error) {
+			if len(cids) == 0 {
+				return []cases.ExamineProgress{}, nil
+			}
+			passed := 0
+			for _, cid := range cids {
+				passed += int(cid % 2)
+			}
+			return []cases.ExamineProgress{
+				{Date: time.UnixMilli(1000), Total: len(cids), Examined: len(cids), Passed: passed},
+			}, nil
+		}).AnyTimes()
 
 	s.ctrl = ctrl
 	s.producer = evemocks.NewMockSyncEventProducer(s.ctrl)
@@ -768,6 +781,46 @@ func (s *HandlerTestSuite) TestDetailRef() {
 	}, resp)
 }
 
+func (s *HandlerTestSuite) TestProgress() {
+	t := s.T()
+	now := time.Now().UnixMilli()
+	err := s.db.Create(&dao.Skill{
+		Id:    3,
+		Name:  "redis",
+		Ctime: now,
+		Utime: now,
+	}).Error
+	require.NoError(t, err)
+	err = s.db.Create([]*dao.SkillLevel{
+		{Id: 3, Sid: 3, Level: "basic", Ctime: now, Utime: now},
+		{Id: 4, Sid: 3, Level: "intermediate", Ctime: now, Utime: now},
+	}).Error
+	require.NoError(t, err)
+	err = s.db.Create([]*dao.SkillRef{
+		{Id: 6, Slid: 3, Sid: 3, Rtype: "case", Rid: 1, Ctime: now, Utime: now},
+		{Id: 7, Slid: 3, Sid: 3, Rtype: "case", Rid: 2, Ctime: now, Utime: now},
+		{Id: 8, Slid: 4, Sid: 3, Rtype: "caseSet", Rid: 1, Ctime: now, Utime: now},
+	}).Error
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost,
+		"/skill/progress", iox.NewJSONReader(web.Sid{Sid: 3}))
+	req.Header.Set("content-type", "application/json")
+	require.NoError(t, err)
+	recorder := test.NewJSONResponseRecorder[web.SkillProgress]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+	assert.Equal(t, web.SkillProgress{
+		Basic: []web.ExamineProgress{
+			{Date: 1000, Total: 2, Examined: 2, Passed: 1},
+		},
+		// 案例集 1 里面的案例是 11 和 12
+		Intermediate: []web.ExamineProgress{
+			{Date: 1000, Total: 2, Examined: 2, Passed: 1},
+		},
+		Advanced: []web.ExamineProgress{},
+	}, recorder.MustScan().Data)
+}
+
 func (s *HandlerTestSuite) TestList() {
 	skills := make([]*dao.Skill, 0, 100)
 	for i := 1; i <= 100; i++ {
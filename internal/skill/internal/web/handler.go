@@ -54,6 +54,7 @@ func (h *Handler) MemberRoutes(server *gin.Engine) {
 	server.POST("/skill/list", ginx.B[Page](h.List))
 	server.POST("/skill/detail-refs", ginx.B[Sid](h.DetailRefs))
 	server.POST("/skill/level-refs", ginx.BS(h.RefsByLevelIDs))
+	server.POST("/skill/progress", ginx.BS(h.Progress))
 }
 
 func (h *Handler) PublicRoutes(server *gin.Engine) {
@@ -213,6 +214,48 @@ func (h *Handler) RefsByLevelIDs(ctx *ginx.Context, req IDs, sess session.Sessio
 	}, nil
 }
 
+// Progress 技能每个等级的案例掌握情况随时间的变化
+func (h *Handler) Progress(ctx *ginx.Context, req Sid, sess session.Session) (ginx.Result, error) {
+	uid := sess.Claims().Uid
+	skill, err := h.svc.Info(ctx, req.Sid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	// 案例集里面的案例也算在对应的等级里面
+	csets, err := h.caseSetSvc.GetByIdsWithCases(ctx, skill.CaseSets())
+	if err != nil {
+		return systemErrorResult, err
+	}
+	cssm := slice.ToMap(csets, func(element cases.CaseSet) int64 {
+		return element.ID
+	})
+	levels := []domain.SkillLevel{skill.Basic, skill.Intermediate, skill.Advanced}
+	progress := make([][]cases.ExamineProgress, len(levels))
+	var eg errgroup.Group
+	for i, level := range levels {
+		cids := make([]int64, 0, len(level.Cases))
+		cids = append(cids, level.Cases...)
+		for _, csid := range level.CaseSets {
+			cids = append(cids, cssm[csid].Cids()...)
+		}
+		eg.Go(func() error {
+			var err1 error
+			progress[i], err1 = h.caseExamSvc.Progress(ctx, uid, cids)
+			return err1
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: SkillProgress{
+			Basic:        newExamineProgress(progress[0]),
+			Intermediate: newExamineProgress(progress[1]),
+			Advanced:     newExamineProgress(progress[2]),
+		},
+	}, nil
+}
+
 func (h *Handler) skillLevels(ctx context.Context, uid int64, levels []domain.SkillLevel) (
 	map[int64]cases.Case,
 	map[int64]cases.CaseSet,
@@ -116,6 +116,33 @@ type LevelInfoReq struct {
 	ID int64 `json:"id"`
 }
 
+// SkillProgress 每个等级的案例掌握情况
+type SkillProgress struct {
+	Basic        []ExamineProgress `json:"basic"`
+	Intermediate []ExamineProgress `json:"intermediate"`
+	Advanced     []ExamineProgress `json:"advanced"`
+}
+
+// ExamineProgress 某一天结束时的掌握情况
+type ExamineProgress struct {
+	// 当天零点
+	Date     int64 `json:"date"`
+	Total    int   `json:"total"`
+	Examined int   `json:"examined"`
+	Passed   int   `json:"passed"`
+}
+
+func newExamineProgress(ps []cases.ExamineProgress) []ExamineProgress {
+	return slice.Map(ps, func(idx int, src cases.ExamineProgress) ExamineProgress {
+		return ExamineProgress{
+			Date:     src.Date.UnixMilli(),
+			Total:    src.Total,
+			Examined: src.Examined,
+			Passed:   src.Passed,
+		}
+	})
+}
+
 type Sid struct {
 	Sid int64 `json:"sid"`
 }
@@ -0,0 +1,129 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type Status uint8
+
+func (s Status) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	// StatusSigning 已经发起签约, 等待微信通知签约结果
+	StatusSigning Status = 1
+	// StatusActive 签约成功, 到期之前自动续费
+	StatusActive Status = 2
+	// StatusRenewing 已经申请扣款, 等待扣款结果
+	StatusRenewing Status = 3
+	// StatusCanceled 用户取消或者在微信侧解约
+	StatusCanceled Status = 4
+	// StatusLapsed 超过宽限期仍然扣款失败, 自动解约
+	StatusLapsed Status = 5
+)
+
+// Subscription 会员自动续费, 一个用户同时只有一个生效的签约
+type Subscription struct {
+	ID  int64
+	Uid int64
+	// SKUSN 每次续费购买的会员商品
+	SKUSN string
+	// ContractCode 我们这边生成的签约协议号
+	ContractCode string
+	// ContractID 签约成功之后微信返回的委托代扣协议ID, 扣款和解约都用它
+	ContractID string
+	Status     Status
+	// NextRenewAt 下一次尝试续费的时间
+	NextRenewAt int64
+	// Failures 本轮续费连续失败的次数, 续费成功之后清零
+	Failures int64
+	// GraceEndAt 本轮续费的宽限期截止时间, 第一次失败的时候确定, 续费成功之后清零
+	GraceEndAt int64
+	Ctime      int64
+	Utime      int64
+}
+
+// Effective 签约中、生效中和续费中的签约都算有效, 同一个用户不能重复签约
+func (s Subscription) Effective() bool {
+	return s.Status == StatusSigning || s.Status == StatusActive || s.Status == StatusRenewing
+}
+
+type RenewalStatus uint8
+
+func (s RenewalStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	RenewalStatusProcessing RenewalStatus = 1
+	RenewalStatusSuccess    RenewalStatus = 2
+	RenewalStatusFailed     RenewalStatus = 3
+)
+
+// Renewal 一次自动续费, 对应一个订单
+type Renewal struct {
+	ID             int64
+	SubscriptionID int64
+	Uid            int64
+	OrderID        int64
+	OrderSN        string
+	Amount         int64
+	Days           uint64
+	// PrevEndAt 续费之前的会员截止时间, 用来估算下一次续费的时间
+	PrevEndAt int64
+	Status    RenewalStatus
+	// Reason 扣款失败的原因
+	Reason string
+	Ctime  int64
+	Utime  int64
+}
+
+type ContractChange uint8
+
+const (
+	ContractChangeSigned     ContractChange = 1
+	ContractChangeTerminated ContractChange = 2
+)
+
+// ContractResult 签约或者解约的结果
+type ContractResult struct {
+	ContractCode string
+	ContractID   string
+	Change       ContractChange
+}
+
+// Deduction 申请扣款
+type Deduction struct {
+	OrderSN    string
+	ContractID string
+	Amount     int64
+	Desc       string
+}
+
+type DeductStatus uint8
+
+const (
+	// DeductStatusProcessing 扣款还没有结果
+	DeductStatusProcessing DeductStatus = 1
+	DeductStatusSuccess    DeductStatus = 2
+	DeductStatusFailed     DeductStatus = 3
+)
+
+// DeductResult 扣款结果, 来自异步通知或者主动查询
+type DeductResult struct {
+	OrderSN       string
+	TransactionID string
+	Status        DeductStatus
+	Reason        string
+}
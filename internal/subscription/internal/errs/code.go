@@ -0,0 +1,28 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errs
+
+var (
+	SystemError = ErrorCode{Code: 525001, Msg: "系统错误"}
+
+	SubscriptionExists   = ErrorCode{Code: 425001, Msg: "已经开通了自动续费"}
+	SubscriptionNotFound = ErrorCode{Code: 425002, Msg: "没有开通自动续费"}
+	SKUNotRenewable      = ErrorCode{Code: 425003, Msg: "该商品不支持自动续费"}
+)
+
+type ErrorCode struct {
+	Code int
+	Msg  string
+}
@@ -0,0 +1,27 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+const MemberUpdateEventName = "member_update_events"
+
+// MemberEvent 续费成功之后通知会员模块延长会员, 字段和会员模块保持一致
+type MemberEvent struct {
+	Key    string `json:"key"`
+	Uid    int64  `json:"uid"`
+	Days   uint64 `json:"days"`
+	Biz    string `json:"biz"`
+	BizId  int64  `json:"biz_id"`
+	Action string `json:"action"`
+}
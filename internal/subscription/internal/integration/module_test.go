@@ -0,0 +1,406 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/member"
+	membermocks "github.com/ecodeclub/webook/internal/member/mocks"
+	"github.com/ecodeclub/webook/internal/order"
+	ordermocks "github.com/ecodeclub/webook/internal/order/mocks"
+	"github.com/ecodeclub/webook/internal/payment"
+	paymentmocks "github.com/ecodeclub/webook/internal/payment/mocks"
+	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
+	"github.com/ecodeclub/webook/internal/product"
+	productmocks "github.com/ecodeclub/webook/internal/product/mocks"
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	"github.com/ecodeclub/webook/internal/subscription/internal/event"
+	evtmocks "github.com/ecodeclub/webook/internal/subscription/internal/event/mocks"
+	"github.com/ecodeclub/webook/internal/subscription/internal/repository"
+	"github.com/ecodeclub/webook/internal/subscription/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/subscription/internal/service"
+	svcmocks "github.com/ecodeclub/webook/internal/subscription/internal/service/mocks"
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ego-component/egorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testUid   = int64(123)
+	testSKUSN = "sku-member-30"
+	day       = 24 * time.Hour
+)
+
+var policy = service.RenewPolicy{
+	Advance:       day,
+	RetryInterval: 12 * time.Hour,
+	GracePeriod:   3 * day,
+}
+
+func TestSubscriptionModule(t *testing.T) {
+	suite.Run(t, new(ModuleTestSuite))
+}
+
+type ModuleTestSuite struct {
+	suite.Suite
+	db   *egorm.Component
+	repo repository.SubscriptionRepository
+}
+
+func (s *ModuleTestSuite) SetupSuite() {
+	s.db = testioc.InitDB()
+	err := dao.InitTables(s.db)
+	require.NoError(s.T(), err)
+	s.repo = repository.NewSubscriptionRepository(dao.NewSubscriptionGORMDAO(s.db))
+}
+
+func (s *ModuleTestSuite) TearDownSuite() {
+	err := s.db.Exec("DROP TABLE `subscriptions`").Error
+	require.NoError(s.T(), err)
+	err = s.db.Exec("DROP TABLE `subscription_renewals`").Error
+	require.NoError(s.T(), err)
+}
+
+func (s *ModuleTestSuite) TearDownTest() {
+	err := s.db.Exec("TRUNCATE TABLE `subscriptions`").Error
+	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE TABLE `subscription_renewals`").Error
+	require.NoError(s.T(), err)
+}
+
+type mocks struct {
+	gateway  *svcmocks.MockContractGateway
+	order    *ordermocks.MockService
+	payment  *paymentmocks.MockService
+	product  *productmocks.MockService
+	member   *membermocks.MockService
+	producer *evtmocks.MockMemberEventProducer
+}
+
+func (s *ModuleTestSuite) newService(ctrl *gomock.Controller) (service.Service, mocks) {
+	m := mocks{
+		gateway:  svcmocks.NewMockContractGateway(ctrl),
+		order:    ordermocks.NewMockService(ctrl),
+		payment:  paymentmocks.NewMockService(ctrl),
+		product:  productmocks.NewMockService(ctrl),
+		member:   membermocks.NewMockService(ctrl),
+		producer: evtmocks.NewMockMemberEventProducer(ctrl),
+	}
+	svc := service.NewService(s.repo, m.gateway, m.order, m.payment, m.product, m.member, m.producer,
+		sequencenumber.NewGenerator(), policy)
+	return svc, m
+}
+
+// expectMemberSKU 30 天的会员商品
+func expectMemberSKU(m mocks) {
+	m.product.EXPECT().FindSKUBySN(gomock.Any(), testSKUSN).Return(product.SKU{
+		ID:     1,
+		SPUID:  2,
+		SN:     testSKUSN,
+		Name:   "月度会员",
+		Price:  990,
+		Attrs:  `{"days":30}`,
+		Status: product.StatusOnShelf,
+	}, nil).AnyTimes()
+	m.product.EXPECT().FindSPUByID(gomock.Any(), int64(2)).Return(product.SPU{
+		ID:        2,
+		Category0: "product",
+		Category1: "member",
+		Status:    product.StatusOnShelf,
+	}, nil).AnyTimes()
+}
+
+func expectCreateOrder(m mocks) {
+	m.order.EXPECT().CreateOrder(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, o order.Order) (order.Order, error) {
+			// 续费订单不能被超时关闭
+			if !o.AutoRenew {
+				return order.Order{}, errors.New("不是自动续费订单")
+			}
+			o.ID = 100
+			return o, nil
+		})
+}
+
+// expectRecordPayment 扣款成功之后记录委托代扣的支付, 并关联到续费订单上
+func expectRecordPayment(t *testing.T, m mocks, orderSN, transactionID string) {
+	m.payment.EXPECT().CreatePayment(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, pmt payment.Payment) (payment.Payment, error) {
+			assert.Equal(t, testUid, pmt.PayerID)
+			assert.Equal(t, int64(100), pmt.OrderID)
+			assert.Equal(t, orderSN, pmt.OrderSN)
+			assert.Equal(t, int64(990), pmt.TotalAmount)
+			assert.Equal(t, payment.StatusPaidSuccess, pmt.Status)
+			require.Len(t, pmt.Records, 1)
+			assert.Equal(t, payment.ChannelTypeWechatPapay, pmt.Records[0].Channel)
+			assert.Equal(t, transactionID, pmt.Records[0].PaymentNO3rd)
+			assert.Equal(t, int64(990), pmt.Records[0].Amount)
+			pmt.ID, pmt.SN = 200, "payment-sn-200"
+			return pmt, nil
+		})
+	m.order.EXPECT().UpdateUnpaidOrderPaymentInfo(gomock.Any(), testUid, int64(100), int64(200), "payment-sn-200").Return(nil)
+}
+
+// createActive 创建一个签约成功, 等待续费的签约
+func (s *ModuleTestSuite) createActive() domain.Subscription {
+	t := s.T()
+	_, err := s.repo.CreateSubscription(context.Background(), domain.Subscription{
+		Uid:          testUid,
+		SKUSN:        testSKUSN,
+		ContractCode: "contract-code-1",
+		Status:       domain.StatusSigning,
+	})
+	require.NoError(t, err)
+	err = s.repo.SetSigned(context.Background(), "contract-code-1", "contract-id-1", time.Now().UnixMilli())
+	require.NoError(t, err)
+	return s.findSubscription()
+}
+
+func (s *ModuleTestSuite) findSubscription() domain.Subscription {
+	sub, err := s.repo.FindLatestByUid(context.Background(), testUid)
+	require.NoError(s.T(), err)
+	return sub
+}
+
+func (s *ModuleTestSuite) TestSign() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc, m := s.newService(ctrl)
+	expectMemberSKU(m)
+
+	var contractCode string
+	m.gateway.EXPECT().SignURL(gomock.Any(), gomock.Any(), "月度会员").
+		DoAndReturn(func(ctx context.Context, code string, account string) (string, error) {
+			contractCode = code
+			return "https://sign.test/" + code, nil
+		})
+	u, err := svc.Sign(context.Background(), testUid, testSKUSN)
+	require.NoError(t, err)
+	assert.Equal(t, "https://sign.test/"+contractCode, u)
+	sub := s.findSubscription()
+	assert.Equal(t, domain.StatusSigning, sub.Status)
+	assert.Equal(t, contractCode, sub.ContractCode)
+
+	// 没有完成签约, 再次发起沿用原来的签约协议号
+	m.gateway.EXPECT().SignURL(gomock.Any(), contractCode, "月度会员").Return("https://sign.test/again", nil)
+	_, err = svc.Sign(context.Background(), testUid, testSKUSN)
+	require.NoError(t, err)
+
+	err = svc.HandleContractResult(context.Background(), domain.ContractResult{
+		ContractCode: contractCode,
+		ContractID:   "contract-id-1",
+		Change:       domain.ContractChangeSigned,
+	})
+	require.NoError(t, err)
+	sub = s.findSubscription()
+	assert.Equal(t, domain.StatusActive, sub.Status)
+	assert.Equal(t, "contract-id-1", sub.ContractID)
+	assert.NotZero(t, sub.NextRenewAt)
+
+	// 重复的签约通知直接忽略
+	err = svc.HandleContractResult(context.Background(), domain.ContractResult{
+		ContractCode: contractCode,
+		ContractID:   "contract-id-1",
+		Change:       domain.ContractChangeSigned,
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Sign(context.Background(), testUid, testSKUSN)
+	assert.ErrorIs(t, err, service.ErrSubscriptionExists)
+
+	m.product.EXPECT().FindSKUBySN(gomock.Any(), "sku-not-member").
+		Return(product.SKU{SPUID: 3, Attrs: `{"days":30}`, Status: product.StatusOnShelf}, nil)
+	m.product.EXPECT().FindSPUByID(gomock.Any(), int64(3)).
+		Return(product.SPU{Category0: "product", Category1: "code", Status: product.StatusOnShelf}, nil)
+	_, err = svc.Sign(context.Background(), testUid+1, "sku-not-member")
+	assert.ErrorIs(t, err, service.ErrSKUNotRenewable)
+}
+
+func (s *ModuleTestSuite) TestRenew_Reschedule() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc, m := s.newService(ctrl)
+
+	sub := s.createActive()
+	endAt := time.Now().Add(10 * day).UnixMilli()
+	m.member.EXPECT().GetMembershipInfo(gomock.Any(), testUid).Return(member.Member{Uid: testUid, EndAt: endAt}, nil)
+
+	subs, err := svc.FindDueSubscriptions(context.Background(), 0, 10)
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	require.NoError(t, svc.Renew(context.Background(), sub))
+
+	sub = s.findSubscription()
+	assert.Equal(t, domain.StatusActive, sub.Status)
+	assert.Equal(t, endAt-day.Milliseconds(), sub.NextRenewAt)
+	subs, err = svc.FindDueSubscriptions(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+}
+
+func (s *ModuleTestSuite) TestRenew_Success() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc, m := s.newService(ctrl)
+	expectMemberSKU(m)
+	expectCreateOrder(m)
+
+	sub := s.createActive()
+	endAt := time.Now().Add(time.Hour).UnixMilli()
+	m.member.EXPECT().GetMembershipInfo(gomock.Any(), testUid).
+		Return(member.Member{Uid: testUid, EndAt: endAt}, nil).Times(2)
+	var orderSN string
+	m.gateway.EXPECT().Deduct(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, d domain.Deduction) (domain.DeductResult, error) {
+			assert.Equal(t, "contract-id-1", d.ContractID)
+			assert.Equal(t, int64(990), d.Amount)
+			orderSN = d.OrderSN
+			return domain.DeductResult{OrderSN: d.OrderSN, Status: domain.DeductStatusProcessing}, nil
+		})
+	require.NoError(t, svc.Renew(context.Background(), sub))
+	assert.Equal(t, domain.StatusRenewing, s.findSubscription().Status)
+	// 续费中的不会被重复续费
+	assert.ErrorIs(t, svc.Renew(context.Background(), sub), service.ErrStatusChanged)
+
+	expectRecordPayment(t, m, orderSN, "tx-1")
+	m.order.EXPECT().SucceedOrder(gomock.Any(), testUid, orderSN).Return(nil)
+	m.producer.EXPECT().Produce(gomock.Any(), event.MemberEvent{
+		Key:    orderSN,
+		Uid:    testUid,
+		Days:   30,
+		Biz:    "order",
+		BizId:  100,
+		Action: "自动续费会员",
+	}).Return(nil)
+	res := domain.DeductResult{OrderSN: orderSN, TransactionID: "tx-1", Status: domain.DeductStatusSuccess}
+	require.NoError(t, svc.HandleDeductResult(context.Background(), res))
+	// 重复通知直接忽略
+	require.NoError(t, svc.HandleDeductResult(context.Background(), res))
+
+	sub = s.findSubscription()
+	assert.Equal(t, domain.StatusActive, sub.Status)
+	assert.Equal(t, endAt+29*day.Milliseconds(), sub.NextRenewAt)
+	renewal, err := s.repo.FindRenewalByOrderSN(context.Background(), orderSN)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RenewalStatusSuccess, renewal.Status)
+}
+
+func (s *ModuleTestSuite) TestRenew_FailureAndLapse() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc, m := s.newService(ctrl)
+	expectMemberSKU(m)
+
+	sub := s.createActive()
+	endAt := time.Now().Add(time.Hour).UnixMilli()
+	m.member.EXPECT().GetMembershipInfo(gomock.Any(), testUid).
+		Return(member.Member{Uid: testUid, EndAt: endAt}, nil).Times(2)
+	expectCreateOrder(m)
+	m.gateway.EXPECT().Deduct(gomock.Any(), gomock.Any()).
+		Return(domain.DeductResult{Status: domain.DeductStatusFailed, Reason: "NOTENOUGH: 余额不足"}, nil)
+	m.order.EXPECT().FailOrder(gomock.Any(), testUid, gomock.Any()).Return(nil)
+	require.NoError(t, svc.Renew(context.Background(), sub))
+
+	sub = s.findSubscription()
+	assert.Equal(t, domain.StatusActive, sub.Status)
+	assert.Equal(t, int64(1), sub.Failures)
+	assert.Equal(t, endAt+3*day.Milliseconds(), sub.GraceEndAt)
+	assert.Greater(t, sub.NextRenewAt, time.Now().UnixMilli())
+
+	// 宽限期已经过了, 再次失败就解约
+	err := s.db.Exec("UPDATE `subscriptions` SET `next_renew_at` = ?, `grace_end_at` = ? WHERE `id` = ?",
+		time.Now().UnixMilli()-1, time.Now().UnixMilli()-1, sub.ID).Error
+	require.NoError(t, err)
+	sub = s.findSubscription()
+	expectCreateOrder(m)
+	m.gateway.EXPECT().Deduct(gomock.Any(), gomock.Any()).
+		Return(domain.DeductResult{Status: domain.DeductStatusFailed}, nil)
+	m.order.EXPECT().FailOrder(gomock.Any(), testUid, gomock.Any()).Return(nil)
+	m.gateway.EXPECT().Terminate(gomock.Any(), "contract-id-1", gomock.Any()).Return(errors.New("mock error"))
+	require.NoError(t, svc.Renew(context.Background(), sub))
+	assert.Equal(t, domain.StatusLapsed, s.findSubscription().Status)
+}
+
+func (s *ModuleTestSuite) TestSyncRenewal() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc, m := s.newService(ctrl)
+	expectMemberSKU(m)
+	expectCreateOrder(m)
+
+	sub := s.createActive()
+	endAt := time.Now().Add(time.Hour).UnixMilli()
+	m.member.EXPECT().GetMembershipInfo(gomock.Any(), testUid).Return(member.Member{Uid: testUid, EndAt: endAt}, nil)
+	m.gateway.EXPECT().Deduct(gomock.Any(), gomock.Any()).Return(domain.DeductResult{}, errors.New("timeout"))
+	assert.Error(t, svc.Renew(context.Background(), sub))
+
+	// 不确定有没有受理, 保持续费中等待主动查询
+	assert.Equal(t, domain.StatusRenewing, s.findSubscription().Status)
+	rs, err := svc.FindProcessingRenewals(context.Background(), 0, time.Now().Add(time.Minute).UnixMilli(), 10)
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+
+	m.gateway.EXPECT().QueryDeduction(gomock.Any(), rs[0].OrderSN).
+		Return(domain.DeductResult{OrderSN: rs[0].OrderSN, Status: domain.DeductStatusSuccess}, nil)
+	expectRecordPayment(t, m, rs[0].OrderSN, "")
+	m.order.EXPECT().SucceedOrder(gomock.Any(), testUid, rs[0].OrderSN).Return(nil)
+	m.producer.EXPECT().Produce(gomock.Any(), gomock.Any()).Return(nil)
+	require.NoError(t, svc.SyncRenewal(context.Background(), rs[0]))
+	assert.Equal(t, domain.StatusActive, s.findSubscription().Status)
+
+	rs, err = svc.FindProcessingRenewals(context.Background(), 0, time.Now().Add(time.Minute).UnixMilli(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, rs)
+}
+
+func (s *ModuleTestSuite) TestCancel() {
+	t := s.T()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	svc, m := s.newService(ctrl)
+
+	err := svc.Cancel(context.Background(), testUid)
+	assert.ErrorIs(t, err, service.ErrSubscriptionNotFound)
+
+	s.createActive()
+	m.gateway.EXPECT().Terminate(gomock.Any(), "contract-id-1", gomock.Any()).Return(nil)
+	require.NoError(t, svc.Cancel(context.Background(), testUid))
+	assert.Equal(t, domain.StatusCanceled, s.findSubscription().Status)
+
+	// 之后收到的解约通知直接忽略
+	err = svc.HandleContractResult(context.Background(), domain.ContractResult{
+		ContractCode: "contract-code-1",
+		ContractID:   "contract-id-1",
+		Change:       domain.ContractChangeTerminated,
+	})
+	require.NoError(t, err)
+
+	err = svc.Cancel(context.Background(), testUid)
+	assert.ErrorIs(t, err, service.ErrSubscriptionNotFound)
+}
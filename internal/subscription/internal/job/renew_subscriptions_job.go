@@ -0,0 +1,91 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/subscription/internal/service"
+	"github.com/gotomicro/ego/core/elog"
+	"github.com/gotomicro/ego/task/ecron"
+)
+
+var _ ecron.NamedJob = (*RenewSubscriptionsJob)(nil)
+
+// RenewSubscriptionsJob 给快到期的会员申请扣款, 并主动查询迟迟没有收到通知的扣款结果
+type RenewSubscriptionsJob struct {
+	svc service.Service
+	// syncAfter 申请扣款之后多久还没有收到通知就主动查询
+	syncAfter time.Duration
+	limit     int
+	logger    *elog.Component
+}
+
+func NewRenewSubscriptionsJob(svc service.Service, syncAfter time.Duration, limit int) *RenewSubscriptionsJob {
+	return &RenewSubscriptionsJob{
+		svc:       svc,
+		syncAfter: syncAfter,
+		limit:     limit,
+		logger:    elog.DefaultLogger,
+	}
+}
+
+func (r *RenewSubscriptionsJob) Name() string {
+	return "RenewSubscriptionsJob"
+}
+
+// Run 单个签约失败不影响其他签约, 只记录日志
+func (r *RenewSubscriptionsJob) Run(ctx context.Context) error {
+	var minID int64
+	for {
+		subs, err := r.svc.FindDueSubscriptions(ctx, minID, r.limit)
+		if err != nil {
+			return fmt.Errorf("查找需要续费的签约失败: %w", err)
+		}
+		for _, sub := range subs {
+			err = r.svc.Renew(ctx, sub)
+			if err != nil && !errors.Is(err, service.ErrStatusChanged) {
+				r.logger.Error("自动续费失败", elog.FieldErr(err), elog.Int64("subscriptionID", sub.ID))
+			}
+		}
+		if len(subs) < r.limit {
+			break
+		}
+		minID = subs[len(subs)-1].ID
+	}
+
+	ctime := time.Now().Add(-r.syncAfter).UnixMilli()
+	minID = 0
+	for {
+		renewals, err := r.svc.FindProcessingRenewals(ctx, minID, ctime, r.limit)
+		if err != nil {
+			return fmt.Errorf("查找没有结果的扣款失败: %w", err)
+		}
+		for _, renewal := range renewals {
+			err = r.svc.SyncRenewal(ctx, renewal)
+			if err != nil {
+				r.logger.Error("同步扣款结果失败", elog.FieldErr(err), elog.String("orderSN", renewal.OrderSN))
+			}
+		}
+		if len(renewals) < r.limit {
+			break
+		}
+		minID = renewals[len(renewals)-1].ID
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	"github.com/ego-component/egorm"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordNotFound = gorm.ErrRecordNotFound
+	// ErrStatusChanged 状态已经被别的请求修改, 比如重复的通知或者并发的续费任务
+	ErrStatusChanged = errors.New("状态已经变更")
+)
+
+type SubscriptionDAO interface {
+	CreateSubscription(ctx context.Context, s Subscription) (int64, error)
+	// FindLatestByUid 查找用户最近的一次签约
+	FindLatestByUid(ctx context.Context, uid int64) (Subscription, error)
+	FindByID(ctx context.Context, id int64) (Subscription, error)
+	FindByContractCode(ctx context.Context, contractCode string) (Subscription, error)
+	// UpdateSigningSKU 签约还没有完成的时候用户换了商品, 复用原来的签约协议号
+	UpdateSigningSKU(ctx context.Context, id int64, skuSN string) error
+	// SetSigned 签约中 -> 生效中, 立刻进入续费检查
+	SetSigned(ctx context.Context, contractCode, contractID string, now int64) error
+	// SetCanceled 只有有效的签约才能取消
+	SetCanceled(ctx context.Context, id int64) error
+	// FindDueSubscriptions 按照 ID 升序查找需要续费的签约, 只返回 ID 大于 minID 的
+	FindDueSubscriptions(ctx context.Context, minID int64, now int64, limit int) ([]Subscription, error)
+	// Reschedule 会员还没有快到期, 推迟续费时间并开始新一轮续费
+	Reschedule(ctx context.Context, id int64, nextRenewAt int64) error
+	// StartRenewing 生效中 -> 续费中, 多个任务并发的时候只有一个能成功, 其余返回 ErrStatusChanged
+	StartRenewing(ctx context.Context, id int64, now int64) error
+	// SetRenewSucceeded 续费中 -> 生效中, 清空失败次数和宽限期
+	SetRenewSucceeded(ctx context.Context, id int64, nextRenewAt int64) error
+	// SetRenewFailed 续费中 -> 生效中, 失败次数加一, 等待下次重试
+	SetRenewFailed(ctx context.Context, id int64, nextRenewAt, graceEndAt int64) error
+	// SetLapsed 续费中 -> 已中断
+	SetLapsed(ctx context.Context, id int64) error
+
+	CreateRenewal(ctx context.Context, r Renewal) (int64, error)
+	FindRenewalByOrderSN(ctx context.Context, orderSN string) (Renewal, error)
+	// SetRenewalResult 扣款中 -> 成功/失败, 重复的结果返回 ErrStatusChanged
+	SetRenewalResult(ctx context.Context, orderSN string, status uint8, reason string) error
+	// FindProcessingRenewals 按照 ID 升序查找 ctime 之前发起并且还没有结果的扣款
+	FindProcessingRenewals(ctx context.Context, minID int64, ctime int64, limit int) ([]Renewal, error)
+}
+
+type SubscriptionGORMDAO struct {
+	db *egorm.Component
+}
+
+func NewSubscriptionGORMDAO(db *egorm.Component) SubscriptionDAO {
+	return &SubscriptionGORMDAO{db: db}
+}
+
+func (g *SubscriptionGORMDAO) CreateSubscription(ctx context.Context, s Subscription) (int64, error) {
+	now := time.Now().UnixMilli()
+	s.Ctime, s.Utime = now, now
+	err := g.db.WithContext(ctx).Create(&s).Error
+	return s.Id, err
+}
+
+func (g *SubscriptionGORMDAO) FindLatestByUid(ctx context.Context, uid int64) (Subscription, error) {
+	var res Subscription
+	err := g.db.WithContext(ctx).Where("uid = ?", uid).Order("id DESC").First(&res).Error
+	return res, err
+}
+
+func (g *SubscriptionGORMDAO) FindByID(ctx context.Context, id int64) (Subscription, error) {
+	var res Subscription
+	err := g.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (g *SubscriptionGORMDAO) FindByContractCode(ctx context.Context, contractCode string) (Subscription, error) {
+	var res Subscription
+	err := g.db.WithContext(ctx).Where("contract_code = ?", contractCode).First(&res).Error
+	return res, err
+}
+
+func (g *SubscriptionGORMDAO) UpdateSigningSKU(ctx context.Context, id int64, skuSN string) error {
+	return g.updateStatus(ctx, id, domain.StatusSigning, map[string]any{
+		"sku_sn": skuSN,
+	})
+}
+
+func (g *SubscriptionGORMDAO) SetSigned(ctx context.Context, contractCode, contractID string, now int64) error {
+	res := g.db.WithContext(ctx).Model(&Subscription{}).
+		Where("contract_code = ? AND status = ?", contractCode, domain.StatusSigning.ToUint8()).
+		Updates(map[string]any{
+			"contract_id":   contractID,
+			"status":        domain.StatusActive.ToUint8(),
+			"next_renew_at": now,
+			"utime":         now,
+		})
+	return g.checkAffected(res)
+}
+
+func (g *SubscriptionGORMDAO) SetCanceled(ctx context.Context, id int64) error {
+	res := g.db.WithContext(ctx).Model(&Subscription{}).
+		Where("id = ? AND status IN ?", id, []uint8{
+			domain.StatusSigning.ToUint8(),
+			domain.StatusActive.ToUint8(),
+			domain.StatusRenewing.ToUint8(),
+		}).
+		Updates(map[string]any{
+			"status": domain.StatusCanceled.ToUint8(),
+			"utime":  time.Now().UnixMilli(),
+		})
+	return g.checkAffected(res)
+}
+
+func (g *SubscriptionGORMDAO) FindDueSubscriptions(ctx context.Context, minID int64, now int64, limit int) ([]Subscription, error) {
+	var res []Subscription
+	err := g.db.WithContext(ctx).
+		Where("id > ? AND status = ? AND next_renew_at <= ?", minID, domain.StatusActive.ToUint8(), now).
+		Order("id ASC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+func (g *SubscriptionGORMDAO) Reschedule(ctx context.Context, id int64, nextRenewAt int64) error {
+	return g.updateStatus(ctx, id, domain.StatusActive, map[string]any{
+		"next_renew_at": nextRenewAt,
+		"failures":      0,
+		"grace_end_at":  0,
+	})
+}
+
+func (g *SubscriptionGORMDAO) StartRenewing(ctx context.Context, id int64, now int64) error {
+	res := g.db.WithContext(ctx).Model(&Subscription{}).
+		Where("id = ? AND status = ? AND next_renew_at <= ?", id, domain.StatusActive.ToUint8(), now).
+		Updates(map[string]any{
+			"status": domain.StatusRenewing.ToUint8(),
+			"utime":  time.Now().UnixMilli(),
+		})
+	return g.checkAffected(res)
+}
+
+func (g *SubscriptionGORMDAO) SetRenewSucceeded(ctx context.Context, id int64, nextRenewAt int64) error {
+	return g.updateStatus(ctx, id, domain.StatusRenewing, map[string]any{
+		"status":        domain.StatusActive.ToUint8(),
+		"next_renew_at": nextRenewAt,
+		"failures":      0,
+		"grace_end_at":  0,
+	})
+}
+
+func (g *SubscriptionGORMDAO) SetRenewFailed(ctx context.Context, id int64, nextRenewAt, graceEndAt int64) error {
+	return g.updateStatus(ctx, id, domain.StatusRenewing, map[string]any{
+		"status":        domain.StatusActive.ToUint8(),
+		"next_renew_at": nextRenewAt,
+		"failures":      gorm.Expr("failures + 1"),
+		"grace_end_at":  graceEndAt,
+	})
+}
+
+func (g *SubscriptionGORMDAO) SetLapsed(ctx context.Context, id int64) error {
+	return g.updateStatus(ctx, id, domain.StatusRenewing, map[string]any{
+		"status": domain.StatusLapsed.ToUint8(),
+	})
+}
+
+func (g *SubscriptionGORMDAO) updateStatus(ctx context.Context, id int64, from domain.Status, values map[string]any) error {
+	values["utime"] = time.Now().UnixMilli()
+	res := g.db.WithContext(ctx).Model(&Subscription{}).
+		Where("id = ? AND status = ?", id, from.ToUint8()).
+		Updates(values)
+	return g.checkAffected(res)
+}
+
+func (g *SubscriptionGORMDAO) checkAffected(res *gorm.DB) error {
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w", ErrStatusChanged)
+	}
+	return nil
+}
+
+func (g *SubscriptionGORMDAO) CreateRenewal(ctx context.Context, r Renewal) (int64, error) {
+	now := time.Now().UnixMilli()
+	r.Ctime, r.Utime = now, now
+	err := g.db.WithContext(ctx).Create(&r).Error
+	return r.Id, err
+}
+
+func (g *SubscriptionGORMDAO) FindRenewalByOrderSN(ctx context.Context, orderSN string) (Renewal, error) {
+	var res Renewal
+	err := g.db.WithContext(ctx).Where("order_sn = ?", orderSN).First(&res).Error
+	return res, err
+}
+
+func (g *SubscriptionGORMDAO) SetRenewalResult(ctx context.Context, orderSN string, status uint8, reason string) error {
+	res := g.db.WithContext(ctx).Model(&Renewal{}).
+		Where("order_sn = ? AND status = ?", orderSN, domain.RenewalStatusProcessing.ToUint8()).
+		Updates(map[string]any{
+			"status": status,
+			"reason": reason,
+			"utime":  time.Now().UnixMilli(),
+		})
+	return g.checkAffected(res)
+}
+
+func (g *SubscriptionGORMDAO) FindProcessingRenewals(ctx context.Context, minID int64, ctime int64, limit int) ([]Renewal, error) {
+	var res []Renewal
+	err := g.db.WithContext(ctx).
+		Where("id > ? AND status = ? AND ctime <= ?", minID, domain.RenewalStatusProcessing.ToUint8(), ctime).
+		Order("id ASC").Limit(limit).Find(&res).Error
+	return res, err
+}
+
+// Subscription 会员自动续费的签约
+type Subscription struct {
+	Id           int64  `gorm:"primaryKey;autoIncrement;comment:自动续费签约自增ID"`
+	Uid          int64  `gorm:"not null;index:idx_uid;comment:用户ID"`
+	SkuSn        string `gorm:"type:varchar(255);not null;comment:每次续费购买的会员商品SKU SN"`
+	ContractCode string `gorm:"type:varchar(64);not null;uniqueIndex:uniq_contract_code;comment:签约协议号,我们生成"`
+	ContractId   string `gorm:"type:varchar(64);not null;default:'';comment:委托代扣协议ID,签约成功后由微信返回"`
+	Status       uint8  `gorm:"type:tinyint unsigned;not null;default:1;index:idx_status_next_renew_at,priority:1;comment:状态 1=签约中 2=生效中 3=续费中 4=已取消 5=已中断"`
+	NextRenewAt  int64  `gorm:"not null;default:0;index:idx_status_next_renew_at,priority:2;comment:下一次尝试续费的时间"`
+	Failures     int64  `gorm:"not null;default:0;comment:本轮续费连续失败的次数"`
+	GraceEndAt   int64  `gorm:"not null;default:0;comment:本轮续费宽限期截止时间,0表示还没有失败过"`
+	Ctime        int64
+	Utime        int64
+}
+
+func (Subscription) TableName() string {
+	return "subscriptions"
+}
+
+// Renewal 自动续费的扣款记录
+type Renewal struct {
+	Id             int64  `gorm:"primaryKey;autoIncrement;comment:续费记录自增ID"`
+	SubscriptionId int64  `gorm:"not null;index:idx_subscription_id;comment:签约ID"`
+	Uid            int64  `gorm:"not null;comment:用户ID"`
+	OrderId        int64  `gorm:"not null;comment:续费订单ID"`
+	OrderSn        string `gorm:"type:varchar(255);not null;uniqueIndex:uniq_order_sn;comment:续费订单SN,也是扣款的商户订单号"`
+	Amount         int64  `gorm:"not null;comment:扣款金额,单位为分"`
+	Days           uint64 `gorm:"not null;comment:续费的会员天数"`
+	PrevEndAt      int64  `gorm:"not null;default:0;comment:续费之前的会员截止时间"`
+	Status         uint8  `gorm:"type:tinyint unsigned;not null;default:1;index:idx_status_ctime,priority:1;comment:状态 1=扣款中 2=成功 3=失败"`
+	Reason         string `gorm:"type:varchar(512);not null;default:'';comment:扣款失败的原因"`
+	Ctime          int64  `gorm:"index:idx_status_ctime,priority:2"`
+	Utime          int64
+}
+
+func (Renewal) TableName() string {
+	return "subscription_renewals"
+}
@@ -0,0 +1,211 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	"github.com/ecodeclub/webook/internal/subscription/internal/repository/dao"
+)
+
+var (
+	ErrRecordNotFound = dao.ErrRecordNotFound
+	ErrStatusChanged  = dao.ErrStatusChanged
+)
+
+type SubscriptionRepository interface {
+	CreateSubscription(ctx context.Context, s domain.Subscription) (int64, error)
+	FindLatestByUid(ctx context.Context, uid int64) (domain.Subscription, error)
+	FindByID(ctx context.Context, id int64) (domain.Subscription, error)
+	FindByContractCode(ctx context.Context, contractCode string) (domain.Subscription, error)
+	UpdateSigningSKU(ctx context.Context, id int64, skuSN string) error
+	SetSigned(ctx context.Context, contractCode, contractID string, now int64) error
+	SetCanceled(ctx context.Context, id int64) error
+	FindDueSubscriptions(ctx context.Context, minID int64, now int64, limit int) ([]domain.Subscription, error)
+	Reschedule(ctx context.Context, id int64, nextRenewAt int64) error
+	StartRenewing(ctx context.Context, id int64, now int64) error
+	SetRenewSucceeded(ctx context.Context, id int64, nextRenewAt int64) error
+	SetRenewFailed(ctx context.Context, id int64, nextRenewAt, graceEndAt int64) error
+	SetLapsed(ctx context.Context, id int64) error
+
+	CreateRenewal(ctx context.Context, r domain.Renewal) (int64, error)
+	FindRenewalByOrderSN(ctx context.Context, orderSN string) (domain.Renewal, error)
+	SetRenewalResult(ctx context.Context, orderSN string, status domain.RenewalStatus, reason string) error
+	FindProcessingRenewals(ctx context.Context, minID int64, ctime int64, limit int) ([]domain.Renewal, error)
+}
+
+type subscriptionRepository struct {
+	dao dao.SubscriptionDAO
+}
+
+func NewSubscriptionRepository(d dao.SubscriptionDAO) SubscriptionRepository {
+	return &subscriptionRepository{dao: d}
+}
+
+func (r *subscriptionRepository) CreateSubscription(ctx context.Context, s domain.Subscription) (int64, error) {
+	return r.dao.CreateSubscription(ctx, r.toEntity(s))
+}
+
+func (r *subscriptionRepository) FindLatestByUid(ctx context.Context, uid int64) (domain.Subscription, error) {
+	s, err := r.dao.FindLatestByUid(ctx, uid)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	return r.toDomain(s), nil
+}
+
+func (r *subscriptionRepository) FindByID(ctx context.Context, id int64) (domain.Subscription, error) {
+	s, err := r.dao.FindByID(ctx, id)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	return r.toDomain(s), nil
+}
+
+func (r *subscriptionRepository) FindByContractCode(ctx context.Context, contractCode string) (domain.Subscription, error) {
+	s, err := r.dao.FindByContractCode(ctx, contractCode)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	return r.toDomain(s), nil
+}
+
+func (r *subscriptionRepository) UpdateSigningSKU(ctx context.Context, id int64, skuSN string) error {
+	return r.dao.UpdateSigningSKU(ctx, id, skuSN)
+}
+
+func (r *subscriptionRepository) SetSigned(ctx context.Context, contractCode, contractID string, now int64) error {
+	return r.dao.SetSigned(ctx, contractCode, contractID, now)
+}
+
+func (r *subscriptionRepository) SetCanceled(ctx context.Context, id int64) error {
+	return r.dao.SetCanceled(ctx, id)
+}
+
+func (r *subscriptionRepository) FindDueSubscriptions(ctx context.Context, minID int64, now int64, limit int) ([]domain.Subscription, error) {
+	ss, err := r.dao.FindDueSubscriptions(ctx, minID, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(ss, func(idx int, src dao.Subscription) domain.Subscription {
+		return r.toDomain(src)
+	}), nil
+}
+
+func (r *subscriptionRepository) Reschedule(ctx context.Context, id int64, nextRenewAt int64) error {
+	return r.dao.Reschedule(ctx, id, nextRenewAt)
+}
+
+func (r *subscriptionRepository) StartRenewing(ctx context.Context, id int64, now int64) error {
+	return r.dao.StartRenewing(ctx, id, now)
+}
+
+func (r *subscriptionRepository) SetRenewSucceeded(ctx context.Context, id int64, nextRenewAt int64) error {
+	return r.dao.SetRenewSucceeded(ctx, id, nextRenewAt)
+}
+
+func (r *subscriptionRepository) SetRenewFailed(ctx context.Context, id int64, nextRenewAt, graceEndAt int64) error {
+	return r.dao.SetRenewFailed(ctx, id, nextRenewAt, graceEndAt)
+}
+
+func (r *subscriptionRepository) SetLapsed(ctx context.Context, id int64) error {
+	return r.dao.SetLapsed(ctx, id)
+}
+
+func (r *subscriptionRepository) CreateRenewal(ctx context.Context, rn domain.Renewal) (int64, error) {
+	return r.dao.CreateRenewal(ctx, dao.Renewal{
+		Id:             rn.ID,
+		SubscriptionId: rn.SubscriptionID,
+		Uid:            rn.Uid,
+		OrderId:        rn.OrderID,
+		OrderSn:        rn.OrderSN,
+		Amount:         rn.Amount,
+		Days:           rn.Days,
+		PrevEndAt:      rn.PrevEndAt,
+		Status:         rn.Status.ToUint8(),
+		Reason:         rn.Reason,
+	})
+}
+
+func (r *subscriptionRepository) FindRenewalByOrderSN(ctx context.Context, orderSN string) (domain.Renewal, error) {
+	rn, err := r.dao.FindRenewalByOrderSN(ctx, orderSN)
+	if err != nil {
+		return domain.Renewal{}, err
+	}
+	return r.toRenewalDomain(rn), nil
+}
+
+func (r *subscriptionRepository) SetRenewalResult(ctx context.Context, orderSN string, status domain.RenewalStatus, reason string) error {
+	return r.dao.SetRenewalResult(ctx, orderSN, status.ToUint8(), reason)
+}
+
+func (r *subscriptionRepository) FindProcessingRenewals(ctx context.Context, minID int64, ctime int64, limit int) ([]domain.Renewal, error) {
+	rs, err := r.dao.FindProcessingRenewals(ctx, minID, ctime, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(rs, func(idx int, src dao.Renewal) domain.Renewal {
+		return r.toRenewalDomain(src)
+	}), nil
+}
+
+func (r *subscriptionRepository) toEntity(s domain.Subscription) dao.Subscription {
+	return dao.Subscription{
+		Id:           s.ID,
+		Uid:          s.Uid,
+		SkuSn:        s.SKUSN,
+		ContractCode: s.ContractCode,
+		ContractId:   s.ContractID,
+		Status:       s.Status.ToUint8(),
+		NextRenewAt:  s.NextRenewAt,
+		Failures:     s.Failures,
+		GraceEndAt:   s.GraceEndAt,
+	}
+}
+
+func (r *subscriptionRepository) toDomain(s dao.Subscription) domain.Subscription {
+	return domain.Subscription{
+		ID:           s.Id,
+		Uid:          s.Uid,
+		SKUSN:        s.SkuSn,
+		ContractCode: s.ContractCode,
+		ContractID:   s.ContractId,
+		Status:       domain.Status(s.Status),
+		NextRenewAt:  s.NextRenewAt,
+		Failures:     s.Failures,
+		GraceEndAt:   s.GraceEndAt,
+		Ctime:        s.Ctime,
+		Utime:        s.Utime,
+	}
+}
+
+func (r *subscriptionRepository) toRenewalDomain(rn dao.Renewal) domain.Renewal {
+	return domain.Renewal{
+		ID:             rn.Id,
+		SubscriptionID: rn.SubscriptionId,
+		Uid:            rn.Uid,
+		OrderID:        rn.OrderId,
+		OrderSN:        rn.OrderSn,
+		Amount:         rn.Amount,
+		Days:           rn.Days,
+		PrevEndAt:      rn.PrevEndAt,
+		Status:         domain.RenewalStatus(rn.Status),
+		Reason:         rn.Reason,
+		Ctime:          rn.Ctime,
+		Utime:          rn.Utime,
+	}
+}
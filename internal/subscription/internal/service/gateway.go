@@ -0,0 +1,43 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+)
+
+// ContractGateway 委托代扣渠道, 负责签约、扣款和解约, 目前只有微信支付的实现
+// 签约和扣款的最终结果都通过异步通知回传, 扣款也可以主动查询
+//
+//go:generate mockgen -source=./gateway.go -package=svcmocks -destination=./mocks/gateway.mock.go -typed ContractGateway
+type ContractGateway interface {
+	// SignURL 生成用户签约的跳转链接, displayAccount 会展示在签约页面上
+	SignURL(ctx context.Context, contractCode string, displayAccount string) (string, error)
+	// Deduct 申请扣款, error 表示不确定有没有受理, 需要之后主动查询;
+	// 受理之后返回 DeductStatusProcessing, 直接被拒绝的返回 DeductStatusFailed
+	Deduct(ctx context.Context, d domain.Deduction) (domain.DeductResult, error)
+	// QueryDeduction 查询扣款结果
+	QueryDeduction(ctx context.Context, orderSN string) (domain.DeductResult, error)
+	// Terminate 解约
+	Terminate(ctx context.Context, contractID string, remark string) error
+
+	// ParseContractNotification 解析并验签签约、解约通知
+	ParseContractNotification(req *http.Request) (domain.ContractResult, error)
+	// ParseDeductNotification 解析并验签扣款结果通知
+	ParseDeductNotification(req *http.Request) (domain.DeductResult, error)
+}
@@ -0,0 +1,276 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./gateway.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./gateway.go -package=svcmocks -destination=./mocks/gateway.mock.go -typed ContractGateway
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+
+	domain "github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockContractGateway is a mock of ContractGateway interface.
+type MockContractGateway struct {
+	ctrl     *gomock.Controller
+	recorder *MockContractGatewayMockRecorder
+	isgomock struct{}
+}
+
+// MockContractGatewayMockRecorder is the mock recorder for MockContractGateway.
+type MockContractGatewayMockRecorder struct {
+	mock *MockContractGateway
+}
+
+// NewMockContractGateway creates a new mock instance.
+func NewMockContractGateway(ctrl *gomock.Controller) *MockContractGateway {
+	mock := &MockContractGateway{ctrl: ctrl}
+	mock.recorder = &MockContractGatewayMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContractGateway) EXPECT() *MockContractGatewayMockRecorder {
+	return m.recorder
+}
+
+// Deduct mocks base method.
+func (m *MockContractGateway) Deduct(ctx context.Context, d domain.Deduction) (domain.DeductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deduct", ctx, d)
+	ret0, _ := ret[0].(domain.DeductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deduct indicates an expected call of Deduct.
+func (mr *MockContractGatewayMockRecorder) Deduct(ctx, d any) *MockContractGatewayDeductCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deduct", reflect.TypeOf((*MockContractGateway)(nil).Deduct), ctx, d)
+	return &MockContractGatewayDeductCall{Call: call}
+}
+
+// MockContractGatewayDeductCall wrap *gomock.Call
+type MockContractGatewayDeductCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockContractGatewayDeductCall) Return(arg0 domain.DeductResult, arg1 error) *MockContractGatewayDeductCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockContractGatewayDeductCall) Do(f func(context.Context, domain.Deduction) (domain.DeductResult, error)) *MockContractGatewayDeductCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockContractGatewayDeductCall) DoAndReturn(f func(context.Context, domain.Deduction) (domain.DeductResult, error)) *MockContractGatewayDeductCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ParseContractNotification mocks base method.
+func (m *MockContractGateway) ParseContractNotification(req *http.Request) (domain.ContractResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseContractNotification", req)
+	ret0, _ := ret[0].(domain.ContractResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseContractNotification indicates an expected call of ParseContractNotification.
+func (mr *MockContractGatewayMockRecorder) ParseContractNotification(req any) *MockContractGatewayParseContractNotificationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseContractNotification", reflect.TypeOf((*MockContractGateway)(nil).ParseContractNotification), req)
+	return &MockContractGatewayParseContractNotificationCall{Call: call}
+}
+
+// MockContractGatewayParseContractNotificationCall wrap *gomock.Call
+type MockContractGatewayParseContractNotificationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockContractGatewayParseContractNotificationCall) Return(arg0 domain.ContractResult, arg1 error) *MockContractGatewayParseContractNotificationCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockContractGatewayParseContractNotificationCall) Do(f func(*http.Request) (domain.ContractResult, error)) *MockContractGatewayParseContractNotificationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockContractGatewayParseContractNotificationCall) DoAndReturn(f func(*http.Request) (domain.ContractResult, error)) *MockContractGatewayParseContractNotificationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ParseDeductNotification mocks base method.
+func (m *MockContractGateway) ParseDeductNotification(req *http.Request) (domain.DeductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseDeductNotification", req)
+	ret0, _ := ret[0].(domain.DeductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseDeductNotification indicates an expected call of ParseDeductNotification.
+func (mr *MockContractGatewayMockRecorder) ParseDeductNotification(req any) *MockContractGatewayParseDeductNotificationCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseDeductNotification", reflect.TypeOf((*MockContractGateway)(nil).ParseDeductNotification), req)
+	return &MockContractGatewayParseDeductNotificationCall{Call: call}
+}
+
+// MockContractGatewayParseDeductNotificationCall wrap *gomock.Call
+type MockContractGatewayParseDeductNotificationCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockContractGatewayParseDeductNotificationCall) Return(arg0 domain.DeductResult, arg1 error) *MockContractGatewayParseDeductNotificationCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockContractGatewayParseDeductNotificationCall) Do(f func(*http.Request) (domain.DeductResult, error)) *MockContractGatewayParseDeductNotificationCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockContractGatewayParseDeductNotificationCall) DoAndReturn(f func(*http.Request) (domain.DeductResult, error)) *MockContractGatewayParseDeductNotificationCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// QueryDeduction mocks base method.
+func (m *MockContractGateway) QueryDeduction(ctx context.Context, orderSN string) (domain.DeductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryDeduction", ctx, orderSN)
+	ret0, _ := ret[0].(domain.DeductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryDeduction indicates an expected call of QueryDeduction.
+func (mr *MockContractGatewayMockRecorder) QueryDeduction(ctx, orderSN any) *MockContractGatewayQueryDeductionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryDeduction", reflect.TypeOf((*MockContractGateway)(nil).QueryDeduction), ctx, orderSN)
+	return &MockContractGatewayQueryDeductionCall{Call: call}
+}
+
+// MockContractGatewayQueryDeductionCall wrap *gomock.Call
+type MockContractGatewayQueryDeductionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockContractGatewayQueryDeductionCall) Return(arg0 domain.DeductResult, arg1 error) *MockContractGatewayQueryDeductionCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockContractGatewayQueryDeductionCall) Do(f func(context.Context, string) (domain.DeductResult, error)) *MockContractGatewayQueryDeductionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockContractGatewayQueryDeductionCall) DoAndReturn(f func(context.Context, string) (domain.DeductResult, error)) *MockContractGatewayQueryDeductionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SignURL mocks base method.
+func (m *MockContractGateway) SignURL(ctx context.Context, contractCode, displayAccount string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignURL", ctx, contractCode, displayAccount)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignURL indicates an expected call of SignURL.
+func (mr *MockContractGatewayMockRecorder) SignURL(ctx, contractCode, displayAccount any) *MockContractGatewaySignURLCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignURL", reflect.TypeOf((*MockContractGateway)(nil).SignURL), ctx, contractCode, displayAccount)
+	return &MockContractGatewaySignURLCall{Call: call}
+}
+
+// MockContractGatewaySignURLCall wrap *gomock.Call
+type MockContractGatewaySignURLCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockContractGatewaySignURLCall) Return(arg0 string, arg1 error) *MockContractGatewaySignURLCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockContractGatewaySignURLCall) Do(f func(context.Context, string, string) (string, error)) *MockContractGatewaySignURLCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockContractGatewaySignURLCall) DoAndReturn(f func(context.Context, string, string) (string, error)) *MockContractGatewaySignURLCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Terminate mocks base method.
+func (m *MockContractGateway) Terminate(ctx context.Context, contractID, remark string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Terminate", ctx, contractID, remark)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Terminate indicates an expected call of Terminate.
+func (mr *MockContractGatewayMockRecorder) Terminate(ctx, contractID, remark any) *MockContractGatewayTerminateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Terminate", reflect.TypeOf((*MockContractGateway)(nil).Terminate), ctx, contractID, remark)
+	return &MockContractGatewayTerminateCall{Call: call}
+}
+
+// MockContractGatewayTerminateCall wrap *gomock.Call
+type MockContractGatewayTerminateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockContractGatewayTerminateCall) Return(arg0 error) *MockContractGatewayTerminateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockContractGatewayTerminateCall) Do(f func(context.Context, string, string) error) *MockContractGatewayTerminateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockContractGatewayTerminateCall) DoAndReturn(f func(context.Context, string, string) error) *MockContractGatewayTerminateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package papay
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+)
+
+const (
+	DefaultGatewayURL = "https://api.mch.weixin.qq.com"
+
+	codeSuccess          = "SUCCESS"
+	errCodeSystem        = "SYSTEMERROR"
+	errCodeOrderPaid     = "ORDERPAID"
+	errCodeOrderNotExist = "ORDERNOTEXIST"
+	tradeTypePAP         = "PAP"
+	version              = "1.0"
+)
+
+var (
+	errInvalidSign  = errors.New("微信支付签名校验失败")
+	errInvalidMchID = errors.New("微信支付商户号不匹配")
+)
+
+// Config 委托代扣只有 APIv2 接口, 使用 APIv2 密钥做 MD5 签名
+type Config struct {
+	AppID  string
+	MchID  string
+	APIKey string
+	// PlanID 商户平台上配置的扣费模板ID
+	PlanID string
+	// GatewayURL 为空时使用正式环境, 测试时可以指向本地的假网关
+	GatewayURL        string
+	ContractNotifyURL string
+	DeductNotifyURL   string
+	// ClientIP 调用扣款接口的机器IP
+	ClientIP string
+}
+
+// Client 微信支付委托代扣(papay)客户端, 只实现了公众号签约、申请扣款、查询扣款和解约
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.GatewayURL == "" {
+		cfg.GatewayURL = DefaultGatewayURL
+	}
+	return &Client{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SignURL 公众号签约, 只在本地签名生成跳转链接, 签约结果通过通知回传
+func (c *Client) SignURL(_ context.Context, contractCode string, displayAccount string) (string, error) {
+	params := map[string]string{
+		"appid":                    c.cfg.AppID,
+		"mch_id":                   c.cfg.MchID,
+		"plan_id":                  c.cfg.PlanID,
+		"contract_code":            contractCode,
+		"request_serial":           strconv.FormatInt(time.Now().UnixMilli(), 10),
+		"contract_display_account": displayAccount,
+		"notify_url":               c.cfg.ContractNotifyURL,
+		"version":                  version,
+		"timestamp":                strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	params["sign"] = Sign(params, c.cfg.APIKey)
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return c.cfg.GatewayURL + "/papay/entrustweb?" + values.Encode(), nil
+}
+
+func (c *Client) Deduct(ctx context.Context, d domain.Deduction) (domain.DeductResult, error) {
+	resp, err := c.do(ctx, "/pay/pappayapply", map[string]string{
+		"appid":            c.cfg.AppID,
+		"mch_id":           c.cfg.MchID,
+		"body":             d.Desc,
+		"out_trade_no":     d.OrderSN,
+		"total_fee":        strconv.FormatInt(d.Amount, 10),
+		"spbill_create_ip": c.cfg.ClientIP,
+		"notify_url":       c.cfg.DeductNotifyURL,
+		"trade_type":       tradeTypePAP,
+		"contract_id":      d.ContractID,
+	})
+	if err != nil {
+		return domain.DeductResult{}, err
+	}
+	res := domain.DeductResult{OrderSN: d.OrderSN, Status: domain.DeductStatusProcessing}
+	switch {
+	case resp["result_code"] == codeSuccess, resp["err_code"] == errCodeOrderPaid:
+		return res, nil
+	case resp["err_code"] == errCodeSystem:
+		return domain.DeductResult{}, fmt.Errorf("微信支付系统错误: %s", resp["err_code_des"])
+	default:
+		res.Status = domain.DeductStatusFailed
+		res.Reason = fmt.Sprintf("%s: %s", resp["err_code"], resp["err_code_des"])
+		return res, nil
+	}
+}
+
+func (c *Client) QueryDeduction(ctx context.Context, orderSN string) (domain.DeductResult, error) {
+	resp, err := c.do(ctx, "/pay/paporderquery", map[string]string{
+		"appid":        c.cfg.AppID,
+		"mch_id":       c.cfg.MchID,
+		"out_trade_no": orderSN,
+	})
+	if err != nil {
+		return domain.DeductResult{}, err
+	}
+	res := domain.DeductResult{OrderSN: orderSN}
+	if resp["result_code"] != codeSuccess {
+		if resp["err_code"] == errCodeOrderNotExist {
+			// 扣款申请没有被受理
+			res.Status = domain.DeductStatusFailed
+			res.Reason = resp["err_code_des"]
+			return res, nil
+		}
+		return domain.DeductResult{}, fmt.Errorf("查询扣款失败, err_code: %s, err_code_des: %s",
+			resp["err_code"], resp["err_code_des"])
+	}
+	res.TransactionID = resp["transaction_id"]
+	res.Status, res.Reason = tradeStatus(resp["trade_state"], resp["trade_state_desc"])
+	return res, nil
+}
+
+// tradeStatus 转入退款的也是扣款成功过的
+func tradeStatus(state, desc string) (domain.DeductStatus, string) {
+	switch state {
+	case "SUCCESS", "REFUND":
+		return domain.DeductStatusSuccess, ""
+	case "NOTPAY", "USERPAYING", "ACCEPT":
+		return domain.DeductStatusProcessing, ""
+	default:
+		return domain.DeductStatusFailed, fmt.Sprintf("%s: %s", state, desc)
+	}
+}
+
+func (c *Client) Terminate(ctx context.Context, contractID string, remark string) error {
+	resp, err := c.do(ctx, "/papay/deletecontract", map[string]string{
+		"appid":                       c.cfg.AppID,
+		"mch_id":                      c.cfg.MchID,
+		"plan_id":                     c.cfg.PlanID,
+		"contract_id":                 contractID,
+		"contract_termination_remark": remark,
+		"version":                     version,
+	})
+	if err != nil {
+		return err
+	}
+	if resp["result_code"] != codeSuccess {
+		return fmt.Errorf("解约失败, err_code: %s, err_code_des: %s", resp["err_code"], resp["err_code_des"])
+	}
+	return nil
+}
+
+// do 发送 XML 请求, 通信成功的响应都要验签, 业务结果由调用者判断
+func (c *Client) do(ctx context.Context, path string, params map[string]string) (map[string]string, error) {
+	params["nonce_str"] = nonceStr()
+	params["sign"] = Sign(params, c.cfg.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.GatewayURL+path, bytes.NewReader(EncodeXML(params)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求微信支付失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	res, err := DecodeXML(body)
+	if err != nil {
+		return nil, fmt.Errorf("解析微信支付响应失败: %w, body: %s", err, body)
+	}
+	if res["return_code"] != codeSuccess {
+		return nil, fmt.Errorf("调用微信支付接口%s失败, return_msg: %s", path, res["return_msg"])
+	}
+	if err = c.verify(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *Client) verify(params map[string]string) error {
+	if Sign(params, c.cfg.APIKey) != params["sign"] {
+		return fmt.Errorf("%w", errInvalidSign)
+	}
+	if params["mch_id"] != "" && params["mch_id"] != c.cfg.MchID {
+		return fmt.Errorf("%w: %s", errInvalidMchID, params["mch_id"])
+	}
+	return nil
+}
+
+// Sign APIv2 的 MD5 签名, 参数按照 key 升序排列, 忽略空值和 sign, 最后拼上密钥
+func Sign(params map[string]string, apiKey string) string {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		if v == "" || k == "sign" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(params[k])
+		sb.WriteByte('&')
+	}
+	sb.WriteString("key=")
+	sb.WriteString(apiKey)
+	sum := md5.Sum([]byte(sb.String()))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// EncodeXML 编码成 <xml><key><![CDATA[value]]></key></xml> 的形式
+func EncodeXML(params map[string]string) []byte {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.WriteString("<xml>")
+	for _, k := range keys {
+		buf.WriteString("<" + k + "><![CDATA[")
+		buf.WriteString(strings.ReplaceAll(params[k], "]]>", "]]]]><![CDATA[>"))
+		buf.WriteString("]]></" + k + ">")
+	}
+	buf.WriteString("</xml>")
+	return buf.Bytes()
+}
+
+// DecodeXML 解析只有一层的 XML
+func DecodeXML(data []byte) (map[string]string, error) {
+	res := make(map[string]string)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		key   string
+		depth int
+	)
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				key = t.Name.Local
+				res[key] = ""
+			}
+		case xml.CharData:
+			if depth == 2 {
+				res[key] += string(t)
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.New("XML 内容为空")
+	}
+	return res, nil
+}
+
+func nonceStr() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
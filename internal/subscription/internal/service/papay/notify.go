@@ -0,0 +1,94 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package papay
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+)
+
+const (
+	changeTypeAdd    = "ADD"
+	changeTypeDelete = "DELETE"
+)
+
+// ParseContractNotification 签约和解约共用一个通知地址, 用 change_type 区分
+func (c *Client) ParseContractNotification(req *http.Request) (domain.ContractResult, error) {
+	params, err := c.parseNotification(req)
+	if err != nil {
+		return domain.ContractResult{}, err
+	}
+	if params["result_code"] != codeSuccess {
+		return domain.ContractResult{}, fmt.Errorf("签约变更失败, err_code: %s, err_code_des: %s",
+			params["err_code"], params["err_code_des"])
+	}
+	res := domain.ContractResult{
+		ContractCode: params["contract_code"],
+		ContractID:   params["contract_id"],
+	}
+	switch params["change_type"] {
+	case changeTypeAdd:
+		res.Change = domain.ContractChangeSigned
+	case changeTypeDelete:
+		res.Change = domain.ContractChangeTerminated
+	default:
+		return domain.ContractResult{}, fmt.Errorf("未知的签约变更类型 %s", params["change_type"])
+	}
+	return res, nil
+}
+
+func (c *Client) ParseDeductNotification(req *http.Request) (domain.DeductResult, error) {
+	params, err := c.parseNotification(req)
+	if err != nil {
+		return domain.DeductResult{}, err
+	}
+	res := domain.DeductResult{
+		OrderSN:       params["out_trade_no"],
+		TransactionID: params["transaction_id"],
+	}
+	if params["result_code"] != codeSuccess {
+		res.Status = domain.DeductStatusFailed
+		res.Reason = fmt.Sprintf("%s: %s", params["err_code"], params["err_code_des"])
+		return res, nil
+	}
+	// 老版本的通知没有 trade_state, result_code 成功就是扣款成功
+	if params["trade_state"] == "" {
+		res.Status = domain.DeductStatusSuccess
+		return res, nil
+	}
+	res.Status, res.Reason = tradeStatus(params["trade_state"], params["trade_state_desc"])
+	return res, nil
+}
+
+func (c *Client) parseNotification(req *http.Request) (map[string]string, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取微信支付通知失败: %w", err)
+	}
+	params, err := DecodeXML(body)
+	if err != nil {
+		return nil, fmt.Errorf("解析微信支付通知失败: %w", err)
+	}
+	if params["return_code"] != codeSuccess {
+		return nil, fmt.Errorf("微信支付通知通信失败, return_msg: %s", params["return_msg"])
+	}
+	if err = c.verify(params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
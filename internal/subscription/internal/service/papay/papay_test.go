@@ -0,0 +1,290 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package papay_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	"github.com/ecodeclub/webook/internal/subscription/internal/service/papay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAppID  = "wx0000000000000001"
+	testMchID  = "1900000001"
+	testAPIKey = "192006250b4c09247ec02edce69f6a2d"
+)
+
+func TestSign(t *testing.T) {
+	// 微信支付文档中的签名示例
+	params := map[string]string{
+		"appid":       "wxd930ea5d5a258f4f",
+		"mch_id":      "10000100",
+		"device_info": "1000",
+		"body":        "test",
+		"nonce_str":   "ibuaiVcKdpRxkhJA",
+		"empty":       "",
+		"sign":        "ignored",
+	}
+	assert.Equal(t, "9A0A8659F005D6984697E2CA0A9CF3B7", papay.Sign(params, testAPIKey))
+}
+
+func TestXML(t *testing.T) {
+	params := map[string]string{
+		"return_code": "SUCCESS",
+		"body":        "面窝吧会员 <a&b> ]]> 续费",
+	}
+	res, err := papay.DecodeXML(papay.EncodeXML(params))
+	require.NoError(t, err)
+	assert.Equal(t, params, res)
+
+	_, err = papay.DecodeXML([]byte("<xml></xml>"))
+	assert.Error(t, err)
+}
+
+// newServer 校验请求签名, 用 resp 响应, resp 为 nil 的时候返回通信失败
+func newServer(t *testing.T, wantPath string, resp map[string]string) *papay.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, wantPath, r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := papay.DecodeXML(body)
+		require.NoError(t, err)
+		assert.Equal(t, papay.Sign(req, testAPIKey), req["sign"])
+		assert.Equal(t, testAppID, req["appid"])
+		if resp == nil {
+			_, _ = w.Write(papay.EncodeXML(map[string]string{"return_code": "FAIL", "return_msg": "签名错误"}))
+			return
+		}
+		_, _ = w.Write(signed(resp))
+	}))
+	t.Cleanup(server.Close)
+	return newClient(server.URL)
+}
+
+func newClient(gatewayURL string) *papay.Client {
+	return papay.NewClient(papay.Config{
+		AppID:             testAppID,
+		MchID:             testMchID,
+		APIKey:            testAPIKey,
+		PlanID:            "12535",
+		GatewayURL:        gatewayURL,
+		ContractNotifyURL: "https://webook.test/subscription/wechat/contract/notify",
+		DeductNotifyURL:   "https://webook.test/subscription/wechat/deduct/notify",
+		ClientIP:          "127.0.0.1",
+	})
+}
+
+func signed(params map[string]string) []byte {
+	res := map[string]string{"return_code": "SUCCESS", "mch_id": testMchID}
+	for k, v := range params {
+		res[k] = v
+	}
+	res["sign"] = papay.Sign(res, testAPIKey)
+	return papay.EncodeXML(res)
+}
+
+func TestClient_SignURL(t *testing.T) {
+	client := newClient("")
+	u, err := client.SignURL(context.Background(), "contract-code-1", "月度会员")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(u, papay.DefaultGatewayURL+"/papay/entrustweb?"))
+
+	parsed, err := url.Parse(u)
+	require.NoError(t, err)
+	params := map[string]string{}
+	for k := range parsed.Query() {
+		params[k] = parsed.Query().Get(k)
+	}
+	assert.Equal(t, "contract-code-1", params["contract_code"])
+	assert.Equal(t, "月度会员", params["contract_display_account"])
+	assert.Equal(t, "https://webook.test/subscription/wechat/contract/notify", params["notify_url"])
+	assert.Equal(t, papay.Sign(params, testAPIKey), params["sign"])
+}
+
+func TestClient_Deduct(t *testing.T) {
+	testCases := []struct {
+		name    string
+		resp    map[string]string
+		want    domain.DeductResult
+		wantErr bool
+	}{
+		{
+			name: "受理成功",
+			resp: map[string]string{"result_code": "SUCCESS"},
+			want: domain.DeductResult{OrderSN: "order-sn-1", Status: domain.DeductStatusProcessing},
+		},
+		{
+			name: "重复申请_等待结果",
+			resp: map[string]string{"result_code": "FAIL", "err_code": "ORDERPAID"},
+			want: domain.DeductResult{OrderSN: "order-sn-1", Status: domain.DeductStatusProcessing},
+		},
+		{
+			name: "直接拒绝",
+			resp: map[string]string{"result_code": "FAIL", "err_code": "CONTRACT_NOT_EXIST", "err_code_des": "协议不存在"},
+			want: domain.DeductResult{
+				OrderSN: "order-sn-1",
+				Status:  domain.DeductStatusFailed,
+				Reason:  "CONTRACT_NOT_EXIST: 协议不存在",
+			},
+		},
+		{
+			name:    "系统错误_不确定有没有受理",
+			resp:    map[string]string{"result_code": "FAIL", "err_code": "SYSTEMERROR"},
+			wantErr: true,
+		},
+		{
+			name:    "通信失败",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newServer(t, "/pay/pappayapply", tc.resp)
+			res, err := client.Deduct(context.Background(), domain.Deduction{
+				OrderSN:    "order-sn-1",
+				ContractID: "contract-id-1",
+				Amount:     990,
+				Desc:       "面窝吧会员自动续费",
+			})
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestClient_QueryDeduction(t *testing.T) {
+	testCases := []struct {
+		name    string
+		resp    map[string]string
+		want    domain.DeductResult
+		wantErr bool
+	}{
+		{
+			name: "扣款成功",
+			resp: map[string]string{"result_code": "SUCCESS", "trade_state": "SUCCESS", "transaction_id": "tx-1"},
+			want: domain.DeductResult{OrderSN: "order-sn-1", TransactionID: "tx-1", Status: domain.DeductStatusSuccess},
+		},
+		{
+			name: "扣款中",
+			resp: map[string]string{"result_code": "SUCCESS", "trade_state": "ACCEPT"},
+			want: domain.DeductResult{OrderSN: "order-sn-1", Status: domain.DeductStatusProcessing},
+		},
+		{
+			name: "扣款失败",
+			resp: map[string]string{"result_code": "SUCCESS", "trade_state": "PAY_FAIL", "trade_state_desc": "余额不足"},
+			want: domain.DeductResult{OrderSN: "order-sn-1", Status: domain.DeductStatusFailed, Reason: "PAY_FAIL: 余额不足"},
+		},
+		{
+			name: "订单不存在",
+			resp: map[string]string{"result_code": "FAIL", "err_code": "ORDERNOTEXIST", "err_code_des": "订单不存在"},
+			want: domain.DeductResult{OrderSN: "order-sn-1", Status: domain.DeductStatusFailed, Reason: "订单不存在"},
+		},
+		{
+			name:    "系统错误",
+			resp:    map[string]string{"result_code": "FAIL", "err_code": "SYSTEMERROR"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newServer(t, "/pay/paporderquery", tc.resp)
+			res, err := client.QueryDeduction(context.Background(), "order-sn-1")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestClient_Terminate(t *testing.T) {
+	client := newServer(t, "/papay/deletecontract", map[string]string{"result_code": "SUCCESS"})
+	assert.NoError(t, client.Terminate(context.Background(), "contract-id-1", "用户取消自动续费"))
+
+	client = newServer(t, "/papay/deletecontract", map[string]string{"result_code": "FAIL", "err_code": "CONTRACT_NOT_EXIST"})
+	assert.Error(t, client.Terminate(context.Background(), "contract-id-1", "用户取消自动续费"))
+}
+
+func TestClient_ParseNotification(t *testing.T) {
+	client := newClient("")
+	newRequest := func(body []byte) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/notify", bytes.NewReader(body))
+	}
+
+	r, err := client.ParseContractNotification(newRequest(signed(map[string]string{
+		"result_code":   "SUCCESS",
+		"change_type":   "ADD",
+		"contract_code": "contract-code-1",
+		"contract_id":   "contract-id-1",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ContractResult{
+		ContractCode: "contract-code-1",
+		ContractID:   "contract-id-1",
+		Change:       domain.ContractChangeSigned,
+	}, r)
+
+	r, err = client.ParseContractNotification(newRequest(signed(map[string]string{
+		"result_code":   "SUCCESS",
+		"change_type":   "DELETE",
+		"contract_code": "contract-code-1",
+		"contract_id":   "contract-id-1",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ContractChangeTerminated, r.Change)
+
+	d, err := client.ParseDeductNotification(newRequest(signed(map[string]string{
+		"result_code":    "SUCCESS",
+		"out_trade_no":   "order-sn-1",
+		"transaction_id": "tx-1",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeductResult{OrderSN: "order-sn-1", TransactionID: "tx-1", Status: domain.DeductStatusSuccess}, d)
+
+	d, err = client.ParseDeductNotification(newRequest(signed(map[string]string{
+		"result_code":  "FAIL",
+		"out_trade_no": "order-sn-1",
+		"err_code":     "NOTENOUGH",
+		"err_code_des": "余额不足",
+	})))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeductStatusFailed, d.Status)
+	assert.Equal(t, "NOTENOUGH: 余额不足", d.Reason)
+
+	// 签名不对
+	body := strings.Replace(string(signed(map[string]string{
+		"result_code":  "SUCCESS",
+		"out_trade_no": "order-sn-1",
+	})), "order-sn-1", "order-sn-2", 1)
+	_, err = client.ParseDeductNotification(newRequest([]byte(body)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,474 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/member"
+	"github.com/ecodeclub/webook/internal/order"
+	"github.com/ecodeclub/webook/internal/payment"
+	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
+	"github.com/ecodeclub/webook/internal/product"
+	"github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	"github.com/ecodeclub/webook/internal/subscription/internal/event"
+	"github.com/ecodeclub/webook/internal/subscription/internal/repository"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+var (
+	ErrRecordNotFound       = repository.ErrRecordNotFound
+	ErrStatusChanged        = repository.ErrStatusChanged
+	ErrSubscriptionExists   = errors.New("已经开通了自动续费")
+	ErrSubscriptionNotFound = errors.New("没有开通自动续费")
+	ErrSKUNotRenewable      = errors.New("商品不支持自动续费")
+)
+
+const (
+	// 会员商品的分类, 和营销模块处理会员订单时保持一致
+	memberCategory0 = "product"
+	memberCategory1 = "member"
+	biz             = "order"
+	deductDesc      = "面窝吧会员自动续费"
+)
+
+// RenewPolicy 续费策略
+type RenewPolicy struct {
+	// Advance 会员到期之前多久开始续费
+	Advance time.Duration
+	// RetryInterval 扣款失败之后多久重试
+	RetryInterval time.Duration
+	// GracePeriod 会员到期之后继续重试的时间, 超过之后自动解约
+	GracePeriod time.Duration
+}
+
+//go:generate mockgen -source=./service.go -package=subscriptionmocks -destination=../../mocks/subscription.mock.go -typed Service
+type Service interface {
+	// Sign 发起签约, 返回跳转到签约页面的链接 web调用
+	Sign(ctx context.Context, uid int64, skuSN string) (string, error)
+	// FindSubscription 查找用户最近一次签约 web调用
+	FindSubscription(ctx context.Context, uid int64) (domain.Subscription, error)
+	// Cancel 取消自动续费, 已经申请的扣款不受影响 web调用
+	Cancel(ctx context.Context, uid int64) error
+	// HandleContractResult 处理签约和解约通知 web调用
+	HandleContractResult(ctx context.Context, r domain.ContractResult) error
+	// HandleDeductResult 处理扣款结果, 重复的结果直接忽略 web/job调用
+	HandleDeductResult(ctx context.Context, r domain.DeductResult) error
+
+	// FindDueSubscriptions 按照 ID 升序查找需要续费的签约 job调用
+	FindDueSubscriptions(ctx context.Context, minID int64, limit int) ([]domain.Subscription, error)
+	// Renew 会员快到期的时候创建续费订单并申请扣款 job调用
+	Renew(ctx context.Context, sub domain.Subscription) error
+	// FindProcessingRenewals 按照 ID 升序查找 ctime 之前申请并且还没有结果的扣款 job调用
+	FindProcessingRenewals(ctx context.Context, minID int64, ctime int64, limit int) ([]domain.Renewal, error)
+	// SyncRenewal 主动查询扣款结果 job调用
+	SyncRenewal(ctx context.Context, r domain.Renewal) error
+}
+
+type service struct {
+	repo        repository.SubscriptionRepository
+	gateway     ContractGateway
+	orderSvc    order.Service
+	paymentSvc  payment.Service
+	productSvc  product.Service
+	memberSvc   member.Service
+	producer    event.MemberEventProducer
+	snGenerator *sequencenumber.Generator
+	policy      RenewPolicy
+	logger      *elog.Component
+}
+
+func NewService(repo repository.SubscriptionRepository,
+	gateway ContractGateway,
+	orderSvc order.Service,
+	paymentSvc payment.Service,
+	productSvc product.Service,
+	memberSvc member.Service,
+	producer event.MemberEventProducer,
+	snGenerator *sequencenumber.Generator,
+	policy RenewPolicy) Service {
+	return &service{
+		repo:        repo,
+		gateway:     gateway,
+		orderSvc:    orderSvc,
+		paymentSvc:  paymentSvc,
+		productSvc:  productSvc,
+		memberSvc:   memberSvc,
+		producer:    producer,
+		snGenerator: snGenerator,
+		policy:      policy,
+		logger:      elog.DefaultLogger.With(elog.FieldComponent("subscription")),
+	}
+}
+
+func (s *service) Sign(ctx context.Context, uid int64, skuSN string) (string, error) {
+	item, _, err := s.findRenewableItem(ctx, skuSN)
+	if err != nil {
+		return "", err
+	}
+	latest, err := s.repo.FindLatestByUid(ctx, uid)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return "", err
+	}
+	var contractCode string
+	switch {
+	case err == nil && (latest.Status == domain.StatusActive || latest.Status == domain.StatusRenewing):
+		return "", fmt.Errorf("%w, uid: %d", ErrSubscriptionExists, uid)
+	case err == nil && latest.Status == domain.StatusSigning:
+		// 上次没有完成签约, 沿用原来的签约协议号
+		contractCode = latest.ContractCode
+		err = s.repo.UpdateSigningSKU(ctx, latest.ID, skuSN)
+	default:
+		contractCode, err = s.snGenerator.Generate(uid)
+		if err != nil {
+			return "", fmt.Errorf("生成签约协议号失败: %w", err)
+		}
+		_, err = s.repo.CreateSubscription(ctx, domain.Subscription{
+			Uid:          uid,
+			SKUSN:        skuSN,
+			ContractCode: contractCode,
+			Status:       domain.StatusSigning,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.gateway.SignURL(ctx, contractCode, item.SKU.Name)
+}
+
+// findRenewableItem 只有上架的会员商品才能自动续费, 返回订单项以及续费的天数
+func (s *service) findRenewableItem(ctx context.Context, skuSN string) (order.Item, uint64, error) {
+	sku, err := s.productSvc.FindSKUBySN(ctx, skuSN)
+	if err != nil {
+		return order.Item{}, 0, fmt.Errorf("%w: %w, sn: %s", ErrSKUNotRenewable, err, skuSN)
+	}
+	spu, err := s.productSvc.FindSPUByID(ctx, sku.SPUID)
+	if err != nil {
+		return order.Item{}, 0, fmt.Errorf("%w: %w, sn: %s", ErrSKUNotRenewable, err, skuSN)
+	}
+	if spu.Category0 != memberCategory0 || spu.Category1 != memberCategory1 ||
+		sku.Status != product.StatusOnShelf || spu.Status != product.StatusOnShelf {
+		return order.Item{}, 0, fmt.Errorf("%w: 不是上架的会员商品, sn: %s", ErrSKUNotRenewable, skuSN)
+	}
+	item := order.Item{
+		SPU: order.SPU{
+			ID:        spu.ID,
+			Category0: spu.Category0,
+			Category1: spu.Category1,
+		},
+		SKU: order.SKU{
+			ID:            sku.ID,
+			SN:            sku.SN,
+			Image:         sku.Image,
+			Attrs:         sku.Attrs,
+			Name:          sku.Name,
+			Description:   sku.Desc,
+			OriginalPrice: sku.Price,
+			RealPrice:     sku.Price,
+			Quantity:      1,
+		},
+	}
+	var attrs struct {
+		Days uint64 `json:"days,omitempty"`
+	}
+	if err = item.SKU.UnmarshalAttrs(&attrs); err != nil || attrs.Days == 0 {
+		return order.Item{}, 0, fmt.Errorf("%w: 会员天数非法, sn: %s, attrs: %s", ErrSKUNotRenewable, skuSN, sku.Attrs)
+	}
+	return item, attrs.Days, nil
+}
+
+func (s *service) FindSubscription(ctx context.Context, uid int64) (domain.Subscription, error) {
+	return s.repo.FindLatestByUid(ctx, uid)
+}
+
+func (s *service) Cancel(ctx context.Context, uid int64) error {
+	sub, err := s.repo.FindLatestByUid(ctx, uid)
+	if errors.Is(err, ErrRecordNotFound) || (err == nil && !sub.Effective()) {
+		return fmt.Errorf("%w, uid: %d", ErrSubscriptionNotFound, uid)
+	}
+	if err != nil {
+		return err
+	}
+	// 还在签约中的没有协议ID, 直接取消就可以, 之后收到签约成功的通知也不会生效
+	if sub.ContractID != "" {
+		err = s.gateway.Terminate(ctx, sub.ContractID, "用户取消自动续费")
+		if err != nil {
+			return fmt.Errorf("解约失败: %w", err)
+		}
+	}
+	err = s.repo.SetCanceled(ctx, sub.ID)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		// 解约通知先到了
+		return nil
+	}
+	return err
+}
+
+func (s *service) HandleContractResult(ctx context.Context, r domain.ContractResult) error {
+	var err error
+	switch r.Change {
+	case domain.ContractChangeSigned:
+		// 立刻进入续费检查, 还不是会员的马上扣款开通, 否则推迟到快到期的时候
+		err = s.repo.SetSigned(ctx, r.ContractCode, r.ContractID, time.Now().UnixMilli())
+	case domain.ContractChangeTerminated:
+		var sub domain.Subscription
+		sub, err = s.repo.FindByContractCode(ctx, r.ContractCode)
+		if err != nil {
+			return err
+		}
+		err = s.repo.SetCanceled(ctx, sub.ID)
+	default:
+		return fmt.Errorf("未知的签约变更类型 %d", r.Change)
+	}
+	if errors.Is(err, repository.ErrStatusChanged) {
+		s.logger.Warn("忽略重复或者过期的签约通知", elog.Any("result", r))
+		return nil
+	}
+	return err
+}
+
+func (s *service) FindDueSubscriptions(ctx context.Context, minID int64, limit int) ([]domain.Subscription, error) {
+	return s.repo.FindDueSubscriptions(ctx, minID, time.Now().UnixMilli(), limit)
+}
+
+func (s *service) Renew(ctx context.Context, sub domain.Subscription) error {
+	now := time.Now().UnixMilli()
+	m, err := s.memberSvc.GetMembershipInfo(ctx, sub.Uid)
+	if err != nil {
+		return err
+	}
+	// 会员还没有快到期, 比如刚签约的老会员或者重试期间用户又单独买了会员
+	renewAt := m.EndAt - s.policy.Advance.Milliseconds()
+	if renewAt > now {
+		return s.repo.Reschedule(ctx, sub.ID, renewAt)
+	}
+	// 并发的任务只有一个能进入续费中
+	err = s.repo.StartRenewing(ctx, sub.ID, now)
+	if err != nil {
+		return err
+	}
+
+	renewal, err := s.createRenewal(ctx, sub, m.EndAt)
+	if err != nil {
+		// 比如商品下架了, 和扣款失败一样等待重试, 超过宽限期之后解约
+		s.logger.Error("创建续费订单失败", elog.FieldErr(err), elog.Int64("subscriptionID", sub.ID))
+		return s.renewFailed(ctx, sub, m.EndAt)
+	}
+	res, err := s.gateway.Deduct(ctx, domain.Deduction{
+		OrderSN:    renewal.OrderSN,
+		ContractID: sub.ContractID,
+		Amount:     renewal.Amount,
+		Desc:       deductDesc,
+	})
+	if err != nil {
+		// 不确定有没有受理, 等待通知或者之后主动查询
+		return fmt.Errorf("申请扣款失败: %w, orderSN: %s", err, renewal.OrderSN)
+	}
+	if res.Status == domain.DeductStatusFailed {
+		res.OrderSN = renewal.OrderSN
+		return s.HandleDeductResult(ctx, res)
+	}
+	return nil
+}
+
+func (s *service) createRenewal(ctx context.Context, sub domain.Subscription, prevEndAt int64) (domain.Renewal, error) {
+	item, days, err := s.findRenewableItem(ctx, sub.SKUSN)
+	if err != nil {
+		return domain.Renewal{}, err
+	}
+	sn, err := s.snGenerator.Generate(sub.Uid)
+	if err != nil {
+		return domain.Renewal{}, fmt.Errorf("生成订单序列号失败: %w", err)
+	}
+	amt := item.SKU.RealPrice * item.SKU.Quantity
+	o, err := s.orderSvc.CreateOrder(ctx, order.Order{
+		SN:               sn,
+		BuyerID:          sub.Uid,
+		OriginalTotalAmt: amt,
+		RealTotalAmt:     amt,
+		// 扣款结果可能很久之后才回来, 不能被超时关闭
+		AutoRenew: true,
+		Items:     []order.Item{item},
+	})
+	if err != nil {
+		return domain.Renewal{}, err
+	}
+	r := domain.Renewal{
+		SubscriptionID: sub.ID,
+		Uid:            sub.Uid,
+		OrderID:        o.ID,
+		OrderSN:        o.SN,
+		Amount:         amt,
+		Days:           days,
+		PrevEndAt:      prevEndAt,
+		Status:         domain.RenewalStatusProcessing,
+	}
+	r.ID, err = s.repo.CreateRenewal(ctx, r)
+	return r, err
+}
+
+func (s *service) HandleDeductResult(ctx context.Context, r domain.DeductResult) error {
+	renewal, err := s.repo.FindRenewalByOrderSN(ctx, r.OrderSN)
+	if err != nil {
+		return err
+	}
+	if renewal.Status != domain.RenewalStatusProcessing {
+		return nil
+	}
+	switch r.Status {
+	case domain.DeductStatusSuccess:
+		return s.handleDeductSuccess(ctx, renewal, r.TransactionID)
+	case domain.DeductStatusFailed:
+		return s.handleDeductFailure(ctx, renewal, r.Reason)
+	default:
+		return nil
+	}
+}
+
+// handleDeductSuccess 支付记录、订单和会员都是幂等的, 先处理它们, 中途失败了等待重复通知再来一遍
+func (s *service) handleDeductSuccess(ctx context.Context, renewal domain.Renewal, transactionID string) error {
+	err := s.recordPayment(ctx, renewal, transactionID)
+	if err != nil {
+		return err
+	}
+	err = s.orderSvc.SucceedOrder(ctx, renewal.Uid, renewal.OrderSN)
+	if err != nil {
+		return err
+	}
+	err = s.producer.Produce(ctx, event.MemberEvent{
+		Key:    renewal.OrderSN,
+		Uid:    renewal.Uid,
+		Days:   renewal.Days,
+		Biz:    biz,
+		BizId:  renewal.OrderID,
+		Action: "自动续费会员",
+	})
+	if err != nil {
+		return err
+	}
+	err = s.repo.SetRenewalResult(ctx, renewal.OrderSN, domain.RenewalStatusSuccess, "")
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// 会员模块异步延长会员, 这里按照续费之前的截止时间估算, 到时候会再核对一遍
+	endAt := max(renewal.PrevEndAt, time.Now().UnixMilli()) + int64(renewal.Days)*24*time.Hour.Milliseconds()
+	err = s.repo.SetRenewSucceeded(ctx, renewal.SubscriptionID, endAt-s.policy.Advance.Milliseconds())
+	if errors.Is(err, repository.ErrStatusChanged) {
+		// 扣款期间用户取消了自动续费
+		return nil
+	}
+	return err
+}
+
+// recordPayment 委托代扣不经过支付模块, 扣款成功之后补一条已支付的记录, 对账和退款都依赖它
+func (s *service) recordPayment(ctx context.Context, renewal domain.Renewal, transactionID string) error {
+	now := time.Now().UnixMilli()
+	pmt, err := s.paymentSvc.CreatePayment(ctx, payment.Payment{
+		PayerID:          renewal.Uid,
+		OrderID:          renewal.OrderID,
+		OrderSN:          renewal.OrderSN,
+		OrderDescription: deductDesc,
+		TotalAmount:      renewal.Amount,
+		PaidAt:           now,
+		Status:           payment.StatusPaidSuccess,
+		Records: []payment.Record{
+			{
+				PaymentNO3rd: transactionID,
+				Description:  deductDesc,
+				Channel:      payment.ChannelTypeWechatPapay,
+				Amount:       renewal.Amount,
+				PaidAt:       now,
+				Status:       payment.StatusPaidSuccess,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("记录自动续费支付失败: %w, orderSN: %s", err, renewal.OrderSN)
+	}
+	// 重复处理的时候订单已经不是未支付了, 不会再更新
+	return s.orderSvc.UpdateUnpaidOrderPaymentInfo(ctx, renewal.Uid, renewal.OrderID, pmt.ID, pmt.SN)
+}
+
+func (s *service) handleDeductFailure(ctx context.Context, renewal domain.Renewal, reason string) error {
+	err := s.orderSvc.FailOrder(ctx, renewal.Uid, renewal.OrderSN)
+	if err != nil {
+		return err
+	}
+	err = s.repo.SetRenewalResult(ctx, renewal.OrderSN, domain.RenewalStatusFailed, reason)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sub, err := s.repo.FindByID(ctx, renewal.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	return s.renewFailed(ctx, sub, renewal.PrevEndAt)
+}
+
+// renewFailed 宽限期从本轮第一次失败开始算, 会员到期之后还能再重试 GracePeriod 这么久,
+// 宽限期内扣款成功的, 会员从扣款当天重新开始计算
+func (s *service) renewFailed(ctx context.Context, sub domain.Subscription, prevEndAt int64) error {
+	now := time.Now().UnixMilli()
+	graceEndAt := sub.GraceEndAt
+	if graceEndAt == 0 {
+		graceEndAt = max(prevEndAt, now) + s.policy.GracePeriod.Milliseconds()
+	}
+	nextRenewAt := now + s.policy.RetryInterval.Milliseconds()
+	var err error
+	if nextRenewAt < graceEndAt {
+		err = s.repo.SetRenewFailed(ctx, sub.ID, nextRenewAt, graceEndAt)
+	} else {
+		err = s.lapse(ctx, sub)
+	}
+	if errors.Is(err, repository.ErrStatusChanged) {
+		// 用户已经取消了自动续费
+		return nil
+	}
+	return err
+}
+
+func (s *service) lapse(ctx context.Context, sub domain.Subscription) error {
+	err := s.repo.SetLapsed(ctx, sub.ID)
+	if err != nil {
+		return err
+	}
+	// 本地已经不会再续费了, 解约失败只影响用户在微信里看到的签约状态, 记录下来人工处理
+	err = s.gateway.Terminate(ctx, sub.ContractID, "超过宽限期仍然扣款失败")
+	if err != nil {
+		s.logger.Error("自动解约失败", elog.FieldErr(err), elog.Int64("subscriptionID", sub.ID))
+	}
+	return nil
+}
+
+func (s *service) FindProcessingRenewals(ctx context.Context, minID int64, ctime int64, limit int) ([]domain.Renewal, error) {
+	return s.repo.FindProcessingRenewals(ctx, minID, ctime, limit)
+}
+
+func (s *service) SyncRenewal(ctx context.Context, r domain.Renewal) error {
+	res, err := s.gateway.QueryDeduction(ctx, r.OrderSN)
+	if err != nil {
+		return err
+	}
+	return s.HandleDeductResult(ctx, res)
+}
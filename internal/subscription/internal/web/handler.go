@@ -0,0 +1,129 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/subscription/internal/service"
+	"github.com/ecodeclub/webook/internal/subscription/internal/service/papay"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+type Handler struct {
+	svc     service.Service
+	gateway service.ContractGateway
+	l       *elog.Component
+}
+
+func NewHandler(svc service.Service, gateway service.ContractGateway) *Handler {
+	return &Handler{
+		svc:     svc,
+		gateway: gateway,
+		l:       elog.DefaultLogger,
+	}
+}
+
+func (h *Handler) PublicRoutes(server *gin.Engine) {
+	// 微信支付要求响应 XML, 所以不走 ginx 的包装
+	server.POST("/subscription/wechat/contract/notify", h.HandleContractNotification)
+	server.POST("/subscription/wechat/deduct/notify", h.HandleDeductNotification)
+}
+
+func (h *Handler) PrivateRoutes(server *gin.Engine) {
+	g := server.Group("/subscription")
+	g.POST("/sign", ginx.BS[SignReq](h.Sign))
+	g.POST("/detail", ginx.S(h.Detail))
+	g.POST("/cancel", ginx.S(h.Cancel))
+}
+
+// Sign 开通自动续费, 前端跳转到返回的链接完成签约
+func (h *Handler) Sign(ctx *ginx.Context, req SignReq, sess session.Session) (ginx.Result, error) {
+	signURL, err := h.svc.Sign(ctx.Request.Context(), sess.Claims().Uid, req.SKUSN)
+	switch {
+	case errors.Is(err, service.ErrSubscriptionExists):
+		return subscriptionExistsResult, nil
+	case errors.Is(err, service.ErrSKUNotRenewable):
+		return skuNotRenewableResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: SignResp{SignURL: signURL}}, nil
+}
+
+// Detail 最近一次签约的状态, 从来没有签约过的返回零值
+func (h *Handler) Detail(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	sub, err := h.svc.FindSubscription(ctx.Request.Context(), sess.Claims().Uid)
+	if errors.Is(err, service.ErrRecordNotFound) {
+		return ginx.Result{Data: Subscription{}}, nil
+	}
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Data: newSubscription(sub)}, nil
+}
+
+func (h *Handler) Cancel(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	err := h.svc.Cancel(ctx.Request.Context(), sess.Claims().Uid)
+	if errors.Is(err, service.ErrSubscriptionNotFound) {
+		return subscriptionNotFoundResult, nil
+	}
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+// HandleContractNotification 签约和解约通知, 响应失败微信会重试
+func (h *Handler) HandleContractNotification(ctx *gin.Context) {
+	r, err := h.gateway.ParseContractNotification(ctx.Request)
+	if err != nil {
+		h.l.Error("解析签约通知失败", elog.FieldErr(err))
+		h.ack(ctx, err)
+		return
+	}
+	err = h.svc.HandleContractResult(ctx.Request.Context(), r)
+	if err != nil {
+		h.l.Error("处理签约通知失败", elog.FieldErr(err), elog.Any("result", r))
+	}
+	h.ack(ctx, err)
+}
+
+// HandleDeductNotification 扣款结果通知, 响应失败微信会重试
+func (h *Handler) HandleDeductNotification(ctx *gin.Context) {
+	r, err := h.gateway.ParseDeductNotification(ctx.Request)
+	if err != nil {
+		h.l.Error("解析扣款通知失败", elog.FieldErr(err))
+		h.ack(ctx, err)
+		return
+	}
+	err = h.svc.HandleDeductResult(ctx.Request.Context(), r)
+	if err != nil {
+		h.l.Error("处理扣款通知失败", elog.FieldErr(err), elog.Any("result", r))
+	}
+	h.ack(ctx, err)
+}
+
+func (h *Handler) ack(ctx *gin.Context, err error) {
+	resp := map[string]string{"return_code": "SUCCESS", "return_msg": "OK"}
+	if err != nil {
+		resp = map[string]string{"return_code": "FAIL", "return_msg": "处理失败"}
+	}
+	ctx.Data(http.StatusOK, "text/xml; charset=utf-8", papay.EncodeXML(resp))
+}
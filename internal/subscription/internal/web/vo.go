@@ -0,0 +1,45 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import "github.com/ecodeclub/webook/internal/subscription/internal/domain"
+
+type SignReq struct {
+	SKUSN string `json:"skuSN"` // 每次续费购买的会员商品
+}
+
+type SignResp struct {
+	SignURL string `json:"signURL"` // 跳转到微信签约页面
+}
+
+type Subscription struct {
+	SKUSN       string `json:"skuSN"`
+	Status      uint8  `json:"status"`      // 1 签约中, 2 生效中, 3 续费中, 4 已取消, 5 已中断
+	NextRenewAt int64  `json:"nextRenewAt"` // 下一次尝试续费的时间
+	Failures    int64  `json:"failures"`    // 本轮续费连续失败的次数
+	GraceEndAt  int64  `json:"graceEndAt"`  // 宽限期截止时间, 超过之后自动解约, 0 表示没有失败过
+	Ctime       int64  `json:"ctime"`
+}
+
+func newSubscription(s domain.Subscription) Subscription {
+	return Subscription{
+		SKUSN:       s.SKUSN,
+		Status:      s.Status.ToUint8(),
+		NextRenewAt: s.NextRenewAt,
+		Failures:    s.Failures,
+		GraceEndAt:  s.GraceEndAt,
+		Ctime:       s.Ctime,
+	}
+}
@@ -0,0 +1,388 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./service.go -package=subscriptionmocks -destination=../../mocks/subscription.mock.go -typed Service
+//
+
+// Package subscriptionmocks is a generated GoMock package.
+package subscriptionmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/ecodeclub/webook/internal/subscription/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockService) Cancel(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockServiceMockRecorder) Cancel(ctx, uid any) *MockServiceCancelCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockService)(nil).Cancel), ctx, uid)
+	return &MockServiceCancelCall{Call: call}
+}
+
+// MockServiceCancelCall wrap *gomock.Call
+type MockServiceCancelCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceCancelCall) Return(arg0 error) *MockServiceCancelCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceCancelCall) Do(f func(context.Context, int64) error) *MockServiceCancelCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceCancelCall) DoAndReturn(f func(context.Context, int64) error) *MockServiceCancelCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindDueSubscriptions mocks base method.
+func (m *MockService) FindDueSubscriptions(ctx context.Context, minID int64, limit int) ([]domain.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDueSubscriptions", ctx, minID, limit)
+	ret0, _ := ret[0].([]domain.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDueSubscriptions indicates an expected call of FindDueSubscriptions.
+func (mr *MockServiceMockRecorder) FindDueSubscriptions(ctx, minID, limit any) *MockServiceFindDueSubscriptionsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDueSubscriptions", reflect.TypeOf((*MockService)(nil).FindDueSubscriptions), ctx, minID, limit)
+	return &MockServiceFindDueSubscriptionsCall{Call: call}
+}
+
+// MockServiceFindDueSubscriptionsCall wrap *gomock.Call
+type MockServiceFindDueSubscriptionsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindDueSubscriptionsCall) Return(arg0 []domain.Subscription, arg1 error) *MockServiceFindDueSubscriptionsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindDueSubscriptionsCall) Do(f func(context.Context, int64, int) ([]domain.Subscription, error)) *MockServiceFindDueSubscriptionsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindDueSubscriptionsCall) DoAndReturn(f func(context.Context, int64, int) ([]domain.Subscription, error)) *MockServiceFindDueSubscriptionsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindProcessingRenewals mocks base method.
+func (m *MockService) FindProcessingRenewals(ctx context.Context, minID, ctime int64, limit int) ([]domain.Renewal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProcessingRenewals", ctx, minID, ctime, limit)
+	ret0, _ := ret[0].([]domain.Renewal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProcessingRenewals indicates an expected call of FindProcessingRenewals.
+func (mr *MockServiceMockRecorder) FindProcessingRenewals(ctx, minID, ctime, limit any) *MockServiceFindProcessingRenewalsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProcessingRenewals", reflect.TypeOf((*MockService)(nil).FindProcessingRenewals), ctx, minID, ctime, limit)
+	return &MockServiceFindProcessingRenewalsCall{Call: call}
+}
+
+// MockServiceFindProcessingRenewalsCall wrap *gomock.Call
+type MockServiceFindProcessingRenewalsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindProcessingRenewalsCall) Return(arg0 []domain.Renewal, arg1 error) *MockServiceFindProcessingRenewalsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindProcessingRenewalsCall) Do(f func(context.Context, int64, int64, int) ([]domain.Renewal, error)) *MockServiceFindProcessingRenewalsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindProcessingRenewalsCall) DoAndReturn(f func(context.Context, int64, int64, int) ([]domain.Renewal, error)) *MockServiceFindProcessingRenewalsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// FindSubscription mocks base method.
+func (m *MockService) FindSubscription(ctx context.Context, uid int64) (domain.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSubscription", ctx, uid)
+	ret0, _ := ret[0].(domain.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSubscription indicates an expected call of FindSubscription.
+func (mr *MockServiceMockRecorder) FindSubscription(ctx, uid any) *MockServiceFindSubscriptionCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSubscription", reflect.TypeOf((*MockService)(nil).FindSubscription), ctx, uid)
+	return &MockServiceFindSubscriptionCall{Call: call}
+}
+
+// MockServiceFindSubscriptionCall wrap *gomock.Call
+type MockServiceFindSubscriptionCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceFindSubscriptionCall) Return(arg0 domain.Subscription, arg1 error) *MockServiceFindSubscriptionCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceFindSubscriptionCall) Do(f func(context.Context, int64) (domain.Subscription, error)) *MockServiceFindSubscriptionCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceFindSubscriptionCall) DoAndReturn(f func(context.Context, int64) (domain.Subscription, error)) *MockServiceFindSubscriptionCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// HandleContractResult mocks base method.
+func (m *MockService) HandleContractResult(ctx context.Context, r domain.ContractResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleContractResult", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleContractResult indicates an expected call of HandleContractResult.
+func (mr *MockServiceMockRecorder) HandleContractResult(ctx, r any) *MockServiceHandleContractResultCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleContractResult", reflect.TypeOf((*MockService)(nil).HandleContractResult), ctx, r)
+	return &MockServiceHandleContractResultCall{Call: call}
+}
+
+// MockServiceHandleContractResultCall wrap *gomock.Call
+type MockServiceHandleContractResultCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceHandleContractResultCall) Return(arg0 error) *MockServiceHandleContractResultCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceHandleContractResultCall) Do(f func(context.Context, domain.ContractResult) error) *MockServiceHandleContractResultCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceHandleContractResultCall) DoAndReturn(f func(context.Context, domain.ContractResult) error) *MockServiceHandleContractResultCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// HandleDeductResult mocks base method.
+func (m *MockService) HandleDeductResult(ctx context.Context, r domain.DeductResult) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleDeductResult", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HandleDeductResult indicates an expected call of HandleDeductResult.
+func (mr *MockServiceMockRecorder) HandleDeductResult(ctx, r any) *MockServiceHandleDeductResultCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleDeductResult", reflect.TypeOf((*MockService)(nil).HandleDeductResult), ctx, r)
+	return &MockServiceHandleDeductResultCall{Call: call}
+}
+
+// MockServiceHandleDeductResultCall wrap *gomock.Call
+type MockServiceHandleDeductResultCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceHandleDeductResultCall) Return(arg0 error) *MockServiceHandleDeductResultCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceHandleDeductResultCall) Do(f func(context.Context, domain.DeductResult) error) *MockServiceHandleDeductResultCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceHandleDeductResultCall) DoAndReturn(f func(context.Context, domain.DeductResult) error) *MockServiceHandleDeductResultCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Renew mocks base method.
+func (m *MockService) Renew(ctx context.Context, sub domain.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", ctx, sub)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockServiceMockRecorder) Renew(ctx, sub any) *MockServiceRenewCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockService)(nil).Renew), ctx, sub)
+	return &MockServiceRenewCall{Call: call}
+}
+
+// MockServiceRenewCall wrap *gomock.Call
+type MockServiceRenewCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceRenewCall) Return(arg0 error) *MockServiceRenewCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceRenewCall) Do(f func(context.Context, domain.Subscription) error) *MockServiceRenewCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceRenewCall) DoAndReturn(f func(context.Context, domain.Subscription) error) *MockServiceRenewCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Sign mocks base method.
+func (m *MockService) Sign(ctx context.Context, uid int64, skuSN string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sign", ctx, uid, skuSN)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sign indicates an expected call of Sign.
+func (mr *MockServiceMockRecorder) Sign(ctx, uid, skuSN any) *MockServiceSignCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockService)(nil).Sign), ctx, uid, skuSN)
+	return &MockServiceSignCall{Call: call}
+}
+
+// MockServiceSignCall wrap *gomock.Call
+type MockServiceSignCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceSignCall) Return(arg0 string, arg1 error) *MockServiceSignCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceSignCall) Do(f func(context.Context, int64, string) (string, error)) *MockServiceSignCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceSignCall) DoAndReturn(f func(context.Context, int64, string) (string, error)) *MockServiceSignCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// SyncRenewal mocks base method.
+func (m *MockService) SyncRenewal(ctx context.Context, r domain.Renewal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncRenewal", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncRenewal indicates an expected call of SyncRenewal.
+func (mr *MockServiceMockRecorder) SyncRenewal(ctx, r any) *MockServiceSyncRenewalCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRenewal", reflect.TypeOf((*MockService)(nil).SyncRenewal), ctx, r)
+	return &MockServiceSyncRenewalCall{Call: call}
+}
+
+// MockServiceSyncRenewalCall wrap *gomock.Call
+type MockServiceSyncRenewalCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceSyncRenewalCall) Return(arg0 error) *MockServiceSyncRenewalCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceSyncRenewalCall) Do(f func(context.Context, domain.Renewal) error) *MockServiceSyncRenewalCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceSyncRenewalCall) DoAndReturn(f func(context.Context, domain.Renewal) error) *MockServiceSyncRenewalCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build wireinject
+
+package subscription
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/member"
+	"github.com/ecodeclub/webook/internal/order"
+	"github.com/ecodeclub/webook/internal/payment"
+	"github.com/ecodeclub/webook/internal/pkg/sequencenumber"
+	"github.com/ecodeclub/webook/internal/product"
+	"github.com/ecodeclub/webook/internal/subscription/internal/event"
+	"github.com/ecodeclub/webook/internal/subscription/internal/job"
+	"github.com/ecodeclub/webook/internal/subscription/internal/repository"
+	"github.com/ecodeclub/webook/internal/subscription/internal/repository/dao"
+	"github.com/ecodeclub/webook/internal/subscription/internal/service"
+	"github.com/ecodeclub/webook/internal/subscription/internal/service/papay"
+	"github.com/ecodeclub/webook/internal/subscription/internal/web"
+	"github.com/ecodeclub/webook/internal/subscription/ioc"
+	"github.com/ego-component/egorm"
+	"github.com/google/wire"
+)
+
+type (
+	Handler               = web.Handler
+	Service               = service.Service
+	RenewSubscriptionsJob = job.RenewSubscriptionsJob
+)
+
+func InitModule(db *egorm.Component,
+	q mq.MQ,
+	om *order.Module,
+	pym *payment.Module,
+	pm *product.Module,
+	mm *member.Module) (*Module, error) {
+	wire.Build(
+		ioc.InitPapayConfig,
+		ioc.InitPapayClient,
+		wire.Bind(new(service.ContractGateway), new(*papay.Client)),
+
+		initDAO,
+		repository.NewSubscriptionRepository,
+		event.NewMemberEventProducer,
+		sequencenumber.NewGenerator,
+		initRenewPolicy,
+		service.NewService,
+		wire.FieldsOf(new(*order.Module), "Svc"),
+		wire.FieldsOf(new(*payment.Module), "Svc"),
+		wire.FieldsOf(new(*product.Module), "Svc"),
+		wire.FieldsOf(new(*member.Module), "Svc"),
+
+		web.NewHandler,
+		initRenewSubscriptionsJob,
+		wire.Struct(new(Module), "*"),
+	)
+	return new(Module), nil
+}
+
+var (
+	once            = &sync.Once{}
+	subscriptionDAO dao.SubscriptionDAO
+)
+
+func initDAO(db *egorm.Component) dao.SubscriptionDAO {
+	once.Do(func() {
+		_ = dao.InitTables(db)
+		subscriptionDAO = dao.NewSubscriptionGORMDAO(db)
+	})
+	return subscriptionDAO
+}
+
+// initRenewPolicy 提前一天续费, 失败之后每 12 小时重试一次, 会员到期之后最多再重试 3 天
+func initRenewPolicy() service.RenewPolicy {
+	return service.RenewPolicy{
+		Advance:       24 * time.Hour,
+		RetryInterval: 12 * time.Hour,
+		GracePeriod:   3 * 24 * time.Hour,
+	}
+}
+
+func initRenewSubscriptionsJob(svc service.Service) *RenewSubscriptionsJob {
+	syncAfter := 30 * time.Minute
+	limit := 100
+	return job.NewRenewSubscriptionsJob(svc, syncAfter, limit)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
 	"github.com/ecodeclub/webook/internal/sms/client"
@@ -37,8 +38,25 @@ func iniHandler(
 	memberSvc member.Service,
 	sp session.Provider,
 	veriCodeSvc service.VerificationCodeSvc,
+	identitySvc service.IdentityService,
+	emailLoginSvc service.EmailLoginService,
+	deviceSvc service.DeviceService,
 	permissionSvc permission.Service, creators []string) *Handler {
-	return web.NewHandler(weSvc, weMiniSvc, userSvc, memberSvc, permissionSvc, sp, veriCodeSvc, creators)
+	return web.NewHandler(weSvc, weMiniSvc, userSvc, memberSvc, permissionSvc, sp,
+		veriCodeSvc, identitySvc, emailLoginSvc, deviceSvc, creators)
+}
+
+func initDeviceService(repo repository.DeviceRepository) service.DeviceService {
+	type Config struct {
+		// 同时在线的设备上限
+		MaxDevices int `yaml:"maxDevices"`
+	}
+	cfg := Config{MaxDevices: 5}
+	err := econf.UnmarshalKey("user.device", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return service.NewDeviceService(repo, cfg.MaxDevices)
 }
 
 func initWechatMiniOAuthService() wechatMiniOAuth2Service {
@@ -68,6 +86,22 @@ func initWechatWebOAuthService(cache ecache.Cache) wechatWebOAuth2Service {
 	return service.NewWechatService(cache, cfg.AppSecretID, cfg.AppSecretKey, cfg.LoginRedirectURL)
 }
 
+func initEmailLoginService(client email.Service,
+	repo repository.EmailCodeRepo) service.EmailLoginService {
+	type Config struct {
+		// 发件人
+		From string `yaml:"from"`
+		// 登录链接的地址，会在后面拼接上 token 参数
+		LinkURL string `yaml:"linkURL"`
+	}
+	var cfg Config
+	err := econf.UnmarshalKey("user.email", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return service.NewEmailLoginService(client, repo, cfg.From, cfg.LinkURL)
+}
+
 func initDAO(db *egorm.Component) dao.UserDAO {
 	err := dao.InitTables(db)
 	if err != nil {
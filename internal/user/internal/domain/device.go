@@ -0,0 +1,30 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+// Device 一个登录之后的会话，对应 session 里面的 SSID
+type Device struct {
+	Id   int64
+	Uid  int64
+	SSID string
+	// 登录的应用
+	App uint
+	// 设备名称，客户端没有上报的时候使用 User-Agent
+	Name string
+	IP   string
+	// 最近活跃的时间，有一定的延迟
+	LastActive int64
+	Ctime      int64
+}
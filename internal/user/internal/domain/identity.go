@@ -0,0 +1,42 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package domain
+
+type IdentityType string
+
+const (
+	// IdentityTypeWechat Value 是微信的 UnionId
+	IdentityTypeWechat IdentityType = "wechat"
+	IdentityTypePhone  IdentityType = "phone"
+	IdentityTypeEmail  IdentityType = "email"
+)
+
+func (t IdentityType) Valid() bool {
+	switch t {
+	case IdentityTypeWechat, IdentityTypePhone, IdentityTypeEmail:
+		return true
+	default:
+		return false
+	}
+}
+
+// Identity 用户的一种登录方式。同一个用户可以绑定多种身份，每种类型最多一个
+type Identity struct {
+	Id    int64
+	Uid   int64
+	Type  IdentityType
+	Value string
+	Ctime int64
+}
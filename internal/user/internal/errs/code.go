@@ -6,6 +6,13 @@ var (
 		Code: 501003,
 		Msg:  "手机号不存在",
 	}
+	IdentityBound    = ErrorCode{Code: 501004, Msg: "该登录方式已经绑定了其他账号"}
+	LastIdentity     = ErrorCode{Code: 501005, Msg: "至少需要保留一种登录方式"}
+	InvalidEmail     = ErrorCode{Code: 501006, Msg: "邮箱格式错误"}
+	InvalidEmailLink = ErrorCode{Code: 501007, Msg: "登录链接无效或者已经过期"}
+	CodeTooFrequent  = ErrorCode{Code: 501008, Msg: "验证码发送太频繁，请稍后再试"}
+	CodeTooManyTimes = ErrorCode{Code: 501009, Msg: "验证码错误次数过多，请重新获取"}
+	CodeExpired      = ErrorCode{Code: 501010, Msg: "验证码已过期，请重新获取"}
 )
 
 type ErrorCode struct {
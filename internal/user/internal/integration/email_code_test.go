@@ -0,0 +1,109 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailCodeCacheTestSuite struct {
+	suite.Suite
+	rdb   redis.Cmdable
+	cache cache.EmailCodeCache
+}
+
+func (s *EmailCodeCacheTestSuite) SetupSuite() {
+	s.rdb = testioc.InitRedis()
+	s.cache = cache.NewEmailCodeCache(testioc.InitCache(), s.rdb)
+}
+
+func (s *EmailCodeCacheTestSuite) TearDownTest() {
+	ctx := context.Background()
+	keys, err := s.rdb.Keys(ctx, "webook:user:email:*").Result()
+	require.NoError(s.T(), err)
+	if len(keys) > 0 {
+		err = s.rdb.Del(ctx, keys...).Err()
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *EmailCodeCacheTestSuite) TestSetCode() {
+	t := s.T()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	err := s.cache.SetCode(ctx, "a@meoying.com", "127.0.0.1", "123456")
+	require.NoError(t, err)
+	ttl, err := s.rdb.TTL(ctx, "webook:user:email:code:a@meoying.com").Result()
+	require.NoError(t, err)
+	assert.True(t, ttl > time.Minute*4)
+
+	// 同一个邮箱还在冷却
+	err = s.cache.SetCode(ctx, "a@meoying.com", "127.0.0.2", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+	// 同一个 IP 还在冷却
+	err = s.cache.SetCode(ctx, "b@meoying.com", "127.0.0.1", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+
+	// 超过每日上限
+	date := time.Now().Format("20060102")
+	err = s.rdb.Set(ctx, "webook:user:email:daily:email:c@meoying.com:"+date, 10, time.Hour).Err()
+	require.NoError(t, err)
+	err = s.cache.SetCode(ctx, "c@meoying.com", "", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+}
+
+func (s *EmailCodeCacheTestSuite) TestVerifyCode() {
+	t := s.T()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// 验证码只能使用一次
+	err := s.cache.SetCode(ctx, "d@meoying.com", "", "123456")
+	require.NoError(t, err)
+	ok, err := s.cache.VerifyCode(ctx, "d@meoying.com", "123456")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = s.cache.VerifyCode(ctx, "d@meoying.com", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeExpired)
+
+	// 错误次数用完之后作废，正确的验证码也不能用了
+	err = s.cache.SetCode(ctx, "e@meoying.com", "", "123456")
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		ok, err = s.cache.VerifyCode(ctx, "e@meoying.com", "000000")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	_, err = s.cache.VerifyCode(ctx, "e@meoying.com", "000000")
+	assert.ErrorIs(t, err, cache.ErrCodeVerifyTooManyTimes)
+	_, err = s.cache.VerifyCode(ctx, "e@meoying.com", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeVerifyTooManyTimes)
+}
+
+func TestEmailCodeCache(t *testing.T) {
+	suite.Run(t, new(EmailCodeCacheTestSuite))
+}
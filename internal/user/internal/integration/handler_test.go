@@ -23,9 +23,11 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
 
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/pkg/middleware"
 
 	"github.com/ecodeclub/webook/internal/pkg/snowflake"
@@ -33,6 +35,7 @@ import (
 
 	"github.com/ecodeclub/ekit/sqlx"
 
+	emailmocks "github.com/ecodeclub/webook/internal/email/mocks"
 	permissionmocks "github.com/ecodeclub/webook/internal/permission/mocks"
 	"github.com/ecodeclub/webook/internal/user/internal/domain"
 	"github.com/ecodeclub/webook/internal/user/internal/service"
@@ -55,6 +58,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gotomicro/ego/core/econf"
 	"github.com/gotomicro/ego/server/egin"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -62,17 +66,20 @@ import (
 type HandleTestSuite struct {
 	suite.Suite
 	db            *egorm.Component
-	cache         cache.VerificationCodeCache
+	rdb           redis.Cmdable
 	server        *egin.Component
 	mockWeSvc     *svcmocks.MockOAuth2Service
 	mockWeMiniSvc *svcmocks.MockOAuth2Service
 	mockPermSvc   *permissionmocks.MockService
+	emailCache    cache.EmailCodeCache
+	mockEmailSvc  *emailmocks.MockService
 }
 
 func (s *HandleTestSuite) SetupSuite() {
 	s.db = testioc.InitDB()
 	ca := testioc.InitCache()
-	s.cache = cache.NewVerificationCodeCache(ca)
+	s.rdb = testioc.InitRedis()
+	s.emailCache = cache.NewEmailCodeCache(ca, s.rdb)
 	err := dao.InitTables(s.db)
 	require.NoError(s.T(), err)
 	econf.Set("server", map[string]any{"debug": true})
@@ -91,14 +98,16 @@ func (s *HandleTestSuite) SetupSuite() {
 	s.mockPermSvc = permSvc
 	wesvc := svcmocks.NewMockOAuth2Service(ctrl)
 	weMiniSvc := svcmocks.NewMockOAuth2Service(ctrl)
+	emailSvc := emailmocks.NewMockService(ctrl)
 	hdl := startup.InitHandler(wesvc,
 		weMiniSvc,
 		&member.Module{Svc: memSvc},
 		&permission.Module{
 			Svc: permSvc,
-		}, session.DefaultProvider(), nil)
+		}, session.DefaultProvider(), emailSvc, nil)
 	s.mockWeSvc = wesvc
 	s.mockWeMiniSvc = weMiniSvc
+	s.mockEmailSvc = emailSvc
 	server.Use(func(ctx *gin.Context) {
 		path := ctx.FullPath()
 		if strings.Contains(path, "login") {
@@ -114,9 +123,37 @@ func (s *HandleTestSuite) SetupSuite() {
 	s.server = server
 }
 
+// setPhoneCode 绕开发送频率的限制，直接写入验证码
+func (s *HandleTestSuite) setPhoneCode(ctx context.Context, phone, code string) error {
+	key := "webook:user:sms:code:" + phone
+	err := s.rdb.HSet(ctx, key, "code", code, "cnt", 5).Err()
+	if err != nil {
+		return err
+	}
+	return s.rdb.Expire(ctx, key, time.Minute*5).Err()
+}
+
+// setEmailCode 绕开发送频率的限制，直接写入验证码
+func (s *HandleTestSuite) setEmailCode(ctx context.Context, email, code string) error {
+	key := emailCodeKey(email)
+	err := s.rdb.HSet(ctx, key, "code", code, "cnt", 5).Err()
+	if err != nil {
+		return err
+	}
+	return s.rdb.Expire(ctx, key, time.Minute*5).Err()
+}
+
+func emailCodeKey(email string) string {
+	return "webook:user:email:code:" + email
+}
+
 func (s *HandleTestSuite) TearDownSuite() {
 	err := s.db.Exec("TRUNCATE table `users`").Error
 	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE table `user_identities`").Error
+	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE table `user_devices`").Error
+	require.NoError(s.T(), err)
 }
 
 func (s *HandleTestSuite) TestEditProfile() {
@@ -556,7 +593,7 @@ func (s *HandleTestSuite) TestPhoneLogin() {
 					Phone:    sqlx.NewNullString("13812345678"),
 				}).Error
 				require.NoError(t, err)
-				err = s.cache.SetPhoneCode(s.T().Context(), "13812345678", "123456")
+				err = s.setPhoneCode(s.T().Context(), "13812345678", "123456")
 				require.NoError(t, err)
 				s.mockPermSvc.EXPECT().
 					FindPersonalPermissions(gomock.Any(), gomock.Any()).
@@ -603,6 +640,8 @@ func (s *HandleTestSuite) TestPhoneLogin() {
 					Phone:    sqlx.NewNullString("13812345679"),
 				}).Error
 				require.NoError(t, err)
+				err = s.setPhoneCode(s.T().Context(), "13812345679", "123456")
+				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
 				// 清理数据
@@ -623,7 +662,7 @@ func (s *HandleTestSuite) TestPhoneLogin() {
 			name: "手机号未注册",
 			before: func(t *testing.T) {
 				// 模拟验证码服务返回正确验证码
-				err := s.cache.SetPhoneCode(s.T().Context(), "18248862099", "234567")
+				err := s.setPhoneCode(s.T().Context(), "18248862099", "234567")
 				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
@@ -675,7 +714,7 @@ func (s *HandleTestSuite) TestPhoneRegister() {
 			name: "注册成功",
 			before: func(t *testing.T) {
 				// 设置验证码
-				err := s.cache.SetPhoneCode(context.Background(), "13912345678", "123456")
+				err := s.setPhoneCode(context.Background(), "13912345678", "123456")
 				require.NoError(t, err)
 
 				// 模拟权限服务
@@ -711,7 +750,7 @@ func (s *HandleTestSuite) TestPhoneRegister() {
 			name: "验证码错误",
 			before: func(t *testing.T) {
 				// 设置正确的验证码
-				err := s.cache.SetPhoneCode(context.Background(), "13912345679", "654321")
+				err := s.setPhoneCode(context.Background(), "13912345679", "654321")
 				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
@@ -739,7 +778,7 @@ func (s *HandleTestSuite) TestPhoneRegister() {
 				require.NoError(t, err)
 
 				// 设置验证码
-				err = s.cache.SetPhoneCode(context.Background(), "13912345681", "123456")
+				err = s.setPhoneCode(context.Background(), "13912345681", "123456")
 				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
@@ -785,6 +824,34 @@ func (s *HandleTestSuite) TestPhoneRegister() {
 	}
 }
 
+// TestPhoneLoginThenRegister 未注册的手机号登录失败之后，可以用同一个验证码注册
+func (s *HandleTestSuite) TestPhoneLoginThenRegister() {
+	t := s.T()
+	const phone = "13912345690"
+	err := s.setPhoneCode(t.Context(), phone, "123456")
+	require.NoError(t, err)
+	defer s.db.Exec("DELETE FROM users WHERE phone = ?", phone)
+	s.mockPermSvc.EXPECT().
+		FindPersonalPermissions(gomock.Any(), gomock.Any()).
+		Return(map[string][]permission.Permission{}, nil)
+
+	call := func(path string) test.Result[web.Profile] {
+		req, err := http.NewRequest(http.MethodPost, path,
+			iox.NewJSONReader(web.PhoneReq{Phone: phone, Code: "123456"}))
+		require.NoError(t, err)
+		req.Header.Set("content-type", "application/json")
+		recorder := test.NewJSONResponseRecorder[web.Profile]()
+		s.server.ServeHTTP(recorder, req)
+		return recorder.MustScan()
+	}
+
+	res := call("/oauth2/phone/login")
+	assert.Equal(t, 501003, res.Code)
+	res = call("/oauth2/phone/register")
+	assert.Equal(t, 0, res.Code)
+	assert.Equal(t, "139****5690", res.Data.Phone)
+}
+
 func (s *HandleTestSuite) TestBindPhone() {
 	testCases := []struct {
 		name     string
@@ -806,7 +873,7 @@ func (s *HandleTestSuite) TestBindPhone() {
 				require.NoError(t, err)
 
 				// 设置验证码
-				err = s.cache.SetPhoneCode(context.Background(), "13912345678", "123456")
+				err = s.setPhoneCode(context.Background(), "13912345678", "123456")
 				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
@@ -818,6 +885,7 @@ func (s *HandleTestSuite) TestBindPhone() {
 
 				// 清理数据
 				s.db.Exec("DELETE FROM users WHERE id = 123")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 123")
 			},
 			req: web.PhoneReq{
 				Phone: "13912345678",
@@ -837,7 +905,7 @@ func (s *HandleTestSuite) TestBindPhone() {
 				require.NoError(t, err)
 
 				// 设置正确的验证码
-				err = s.cache.SetPhoneCode(context.Background(), "13912345679", "654321")
+				err = s.setPhoneCode(context.Background(), "13912345679", "654321")
 				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
@@ -849,6 +917,7 @@ func (s *HandleTestSuite) TestBindPhone() {
 
 				// 清理数据
 				s.db.Exec("DELETE FROM users WHERE id = 123")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 123")
 			},
 			req: web.PhoneReq{
 				Phone: "13912345679",
@@ -880,14 +949,15 @@ func (s *HandleTestSuite) TestBindPhone() {
 
 				// 清理数据
 				s.db.Exec("DELETE FROM users WHERE id = 123")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 123")
 			},
 			req: web.PhoneReq{
 				Phone: "13912345680",
 				Code:  "123456",
 			},
 			wantResp: test.Result[any]{
-				Code: 501002,
-				Msg:  "验证码错误",
+				Code: 501010,
+				Msg:  "验证码已过期，请重新获取",
 			},
 			wantCode: 500,
 		},
@@ -903,7 +973,7 @@ func (s *HandleTestSuite) TestBindPhone() {
 				require.NoError(t, err)
 
 				// 设置验证码
-				err = s.cache.SetPhoneCode(context.Background(), "13912345681", "123456")
+				err = s.setPhoneCode(context.Background(), "13912345681", "123456")
 				require.NoError(t, err)
 			},
 			after: func(t *testing.T) {
@@ -915,6 +985,7 @@ func (s *HandleTestSuite) TestBindPhone() {
 
 				// 清理数据
 				s.db.Exec("DELETE FROM users WHERE id = 123")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 123")
 			},
 			req: web.PhoneReq{
 				Phone: "13912345681",
@@ -942,6 +1013,546 @@ func (s *HandleTestSuite) TestBindPhone() {
 	}
 }
 
+func (s *HandleTestSuite) TestSendEmailCode() {
+	testCases := []struct {
+		name     string
+		before   func(t *testing.T)
+		after    func(t *testing.T)
+		req      web.SendEmailCodeReq
+		wantResp test.Result[any]
+		wantCode int
+	}{
+		{
+			name: "发送成功",
+			before: func(t *testing.T) {
+				s.mockEmailSvc.EXPECT().SendMail(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, mail email.Mail) error {
+						assert.Equal(t, "noreply@meoying.com", mail.From)
+						assert.Equal(t, "foo@meoying.com", mail.To)
+						assert.Contains(t, string(mail.Body), "https://meoying.com/login/email?token=")
+						return nil
+					})
+			},
+			after: func(t *testing.T) {
+				// 邮箱统一转成小写
+				code, err := s.rdb.HGet(context.Background(), emailCodeKey("foo@meoying.com"), "code").Result()
+				require.NoError(t, err)
+				assert.Len(t, code, 6)
+			},
+			req: web.SendEmailCodeReq{
+				Email: " Foo@Meoying.com ",
+			},
+			wantResp: test.Result[any]{},
+			wantCode: 200,
+		},
+		{
+			name:   "发送太频繁",
+			before: func(t *testing.T) {},
+			after: func(t *testing.T) {
+				keys, err := s.rdb.Keys(context.Background(), "webook:user:email:*").Result()
+				require.NoError(t, err)
+				err = s.rdb.Del(context.Background(), keys...).Err()
+				require.NoError(t, err)
+			},
+			req: web.SendEmailCodeReq{
+				Email: "foo@meoying.com",
+			},
+			wantResp: test.Result[any]{
+				Code: 501008,
+				Msg:  "验证码发送太频繁，请稍后再试",
+			},
+			wantCode: 200,
+		},
+		{
+			name:   "邮箱格式错误",
+			before: func(t *testing.T) {},
+			after:  func(t *testing.T) {},
+			req: web.SendEmailCodeReq{
+				Email: "meoying.com",
+			},
+			wantResp: test.Result[any]{
+				Code: 501006,
+				Msg:  "邮箱格式错误",
+			},
+			wantCode: 500,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/oauth2/email/send", iox.NewJSONReader(tc.req))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[any]()
+			s.server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantResp, recorder.MustScan())
+			tc.after(t)
+		})
+	}
+}
+
+func (s *HandleTestSuite) TestEmailLogin() {
+	testCases := []struct {
+		name     string
+		before   func(t *testing.T)
+		after    func(t *testing.T)
+		req      web.EmailReq
+		wantResp test.Result[web.Profile]
+		wantCode int
+	}{
+		{
+			name: "已绑定的邮箱登录",
+			before: func(t *testing.T) {
+				err := s.db.Create(&dao.User{
+					Id:       126,
+					Nickname: "test user",
+					Avatar:   "test avatar",
+				}).Error
+				require.NoError(t, err)
+				err = s.db.Create(&dao.Identity{
+					Uid:   126,
+					Type:  dao.IdentityTypeEmail,
+					Value: "bar@meoying.com",
+				}).Error
+				require.NoError(t, err)
+				err = s.setEmailCode(context.Background(), "bar@meoying.com", "123456")
+				require.NoError(t, err)
+				s.mockPermSvc.EXPECT().FindPersonalPermissions(gomock.Any(), int64(126)).
+					Return(nil, nil)
+			},
+			after: func(t *testing.T) {
+				// 验证码只能使用一次
+				cnt, err := s.rdb.Exists(context.Background(), emailCodeKey("bar@meoying.com")).Result()
+				require.NoError(t, err)
+				assert.Zero(t, cnt)
+				s.db.Exec("DELETE FROM users WHERE id = 126")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 126")
+			},
+			req: web.EmailReq{
+				Email: "Bar@meoying.com",
+				Code:  "123456",
+			},
+			wantResp: test.Result[web.Profile]{
+				Data: web.Profile{
+					Id:        126,
+					Nickname:  "test user",
+					Avatar:    "test avatar",
+					MemberDDL: 1234,
+				},
+			},
+			wantCode: 200,
+		},
+		{
+			name: "未注册的邮箱直接注册",
+			before: func(t *testing.T) {
+				err := s.setEmailCode(context.Background(), "new@meoying.com", "123456")
+				require.NoError(t, err)
+				s.mockPermSvc.EXPECT().FindPersonalPermissions(gomock.Any(), gomock.Any()).
+					Return(nil, nil)
+			},
+			after: func(t *testing.T) {
+				var i dao.Identity
+				err := s.db.Where("type = ? AND value = ?", dao.IdentityTypeEmail, "new@meoying.com").
+					First(&i).Error
+				require.NoError(t, err)
+				var u dao.User
+				err = s.db.Where("id = ?", i.Uid).First(&u).Error
+				require.NoError(t, err)
+				s.db.Exec("DELETE FROM users WHERE id = ?", i.Uid)
+				s.db.Exec("DELETE FROM user_identities WHERE uid = ?", i.Uid)
+			},
+			req: web.EmailReq{
+				Email: "new@meoying.com",
+				Code:  "123456",
+			},
+			wantResp: test.Result[web.Profile]{
+				Data: web.Profile{
+					MemberDDL: 1234,
+				},
+			},
+			wantCode: 200,
+		},
+		{
+			name: "验证码错误",
+			before: func(t *testing.T) {
+				err := s.setEmailCode(context.Background(), "bar@meoying.com", "123456")
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				err := s.rdb.Del(context.Background(), emailCodeKey("bar@meoying.com")).Err()
+				require.NoError(t, err)
+			},
+			req: web.EmailReq{
+				Email: "bar@meoying.com",
+				Code:  "654321",
+			},
+			wantResp: test.Result[web.Profile]{
+				Code: 501002,
+				Msg:  "验证码错误",
+			},
+			wantCode: 500,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/oauth2/email/login", iox.NewJSONReader(tc.req))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[web.Profile]()
+			s.server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			val := recorder.MustScan()
+			if val.Data.Id != 126 {
+				// 新注册的用户，id 和昵称都是随机的
+				val.Data.Id = 0
+				val.Data.Nickname = ""
+			}
+			val.Data.SN = ""
+			assert.Equal(t, tc.wantResp, val)
+			tc.after(t)
+		})
+	}
+}
+
+func (s *HandleTestSuite) TestEmailLinkLogin() {
+	testCases := []struct {
+		name     string
+		before   func(t *testing.T)
+		after    func(t *testing.T)
+		req      web.EmailLinkReq
+		wantResp test.Result[web.Profile]
+		wantCode int
+	}{
+		{
+			name: "登录成功",
+			before: func(t *testing.T) {
+				err := s.db.Create(&dao.User{
+					Id:       127,
+					Nickname: "test user",
+				}).Error
+				require.NoError(t, err)
+				err = s.db.Create(&dao.Identity{
+					Uid:   127,
+					Type:  dao.IdentityTypeEmail,
+					Value: "link@meoying.com",
+				}).Error
+				require.NoError(t, err)
+				err = s.emailCache.SetToken(context.Background(), "token-127", "link@meoying.com")
+				require.NoError(t, err)
+				s.mockPermSvc.EXPECT().FindPersonalPermissions(gomock.Any(), int64(127)).
+					Return(nil, nil)
+			},
+			after: func(t *testing.T) {
+				// 链接只能使用一次
+				_, err := s.emailCache.TakeToken(context.Background(), "token-127")
+				assert.Error(t, err)
+				s.db.Exec("DELETE FROM users WHERE id = 127")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 127")
+			},
+			req: web.EmailLinkReq{
+				Token: "token-127",
+			},
+			wantResp: test.Result[web.Profile]{
+				Data: web.Profile{
+					Id:        127,
+					Nickname:  "test user",
+					MemberDDL: 1234,
+				},
+			},
+			wantCode: 200,
+		},
+		{
+			name:   "链接已经失效",
+			before: func(t *testing.T) {},
+			after:  func(t *testing.T) {},
+			req: web.EmailLinkReq{
+				Token: "not-exist",
+			},
+			wantResp: test.Result[web.Profile]{
+				Code: 501007,
+				Msg:  "登录链接无效或者已经过期",
+			},
+			wantCode: 500,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/oauth2/email/link/login", iox.NewJSONReader(tc.req))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[web.Profile]()
+			s.server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			val := recorder.MustScan()
+			val.Data.SN = ""
+			assert.Equal(t, tc.wantResp, val)
+			tc.after(t)
+		})
+	}
+}
+
+func (s *HandleTestSuite) TestBindEmail() {
+	testCases := []struct {
+		name     string
+		before   func(t *testing.T)
+		after    func(t *testing.T)
+		req      web.EmailReq
+		wantResp test.Result[any]
+		wantCode int
+	}{
+		{
+			name: "绑定成功",
+			before: func(t *testing.T) {
+				err := s.db.Create(&dao.User{
+					Id:       123,
+					Nickname: "test user",
+				}).Error
+				require.NoError(t, err)
+				err = s.setEmailCode(context.Background(), "bind@meoying.com", "123456")
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				var i dao.Identity
+				err := s.db.Where("type = ? AND value = ?", dao.IdentityTypeEmail, "bind@meoying.com").
+					First(&i).Error
+				require.NoError(t, err)
+				assert.Equal(t, int64(123), i.Uid)
+				s.db.Exec("DELETE FROM users WHERE id = 123")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 123")
+			},
+			req: web.EmailReq{
+				Email: "bind@meoying.com",
+				Code:  "123456",
+			},
+			wantResp: test.Result[any]{},
+			wantCode: 200,
+		},
+		{
+			name: "邮箱已经被其他账号绑定",
+			before: func(t *testing.T) {
+				err := s.db.Create(&dao.User{
+					Id:       123,
+					Nickname: "test user",
+				}).Error
+				require.NoError(t, err)
+				err = s.db.Create(&dao.Identity{
+					Uid:   128,
+					Type:  dao.IdentityTypeEmail,
+					Value: "bind@meoying.com",
+				}).Error
+				require.NoError(t, err)
+				err = s.setEmailCode(context.Background(), "bind@meoying.com", "123456")
+				require.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				var ids []dao.Identity
+				err := s.db.Where("uid = ?", 123).Find(&ids).Error
+				require.NoError(t, err)
+				assert.Empty(t, ids)
+				s.db.Exec("DELETE FROM users WHERE id = 123")
+				s.db.Exec("DELETE FROM user_identities WHERE uid = 128")
+			},
+			req: web.EmailReq{
+				Email: "bind@meoying.com",
+				Code:  "123456",
+			},
+			wantResp: test.Result[any]{
+				Code: 501004,
+				Msg:  "该登录方式已经绑定了其他账号",
+			},
+			wantCode: 500,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			tc.before(t)
+			req, err := http.NewRequest(http.MethodPost,
+				"/users/email/bind", iox.NewJSONReader(tc.req))
+			req.Header.Set("content-type", "application/json")
+			require.NoError(t, err)
+			recorder := test.NewJSONResponseRecorder[any]()
+			s.server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			assert.Equal(t, tc.wantResp, recorder.MustScan())
+			tc.after(t)
+		})
+	}
+}
+
+func (s *HandleTestSuite) TestIdentities() {
+	err := s.db.Create(&dao.User{
+		Id:       123,
+		Nickname: "test user",
+		// 引入身份表之前绑定的手机号
+		Phone: sqlx.NewNullString("13812345670"),
+	}).Error
+	require.NoError(s.T(), err)
+	err = s.db.Create(&dao.Identity{
+		Uid:   123,
+		Type:  dao.IdentityTypeEmail,
+		Value: "list@meoying.com",
+	}).Error
+	require.NoError(s.T(), err)
+	defer func() {
+		s.db.Exec("DELETE FROM users WHERE id = 123")
+		s.db.Exec("DELETE FROM user_identities WHERE uid = 123")
+	}()
+
+	list := func(t *testing.T) []web.Identity {
+		req, err := http.NewRequest(http.MethodPost, "/users/identity/list", nil)
+		require.NoError(t, err)
+		recorder := test.NewJSONResponseRecorder[[]web.Identity]()
+		s.server.ServeHTTP(recorder, req)
+		require.Equal(t, 200, recorder.Code)
+		return recorder.MustScan().Data
+	}
+	unbind := func(t *testing.T, typ string) test.Result[any] {
+		req, err := http.NewRequest(http.MethodPost, "/users/identity/unbind",
+			iox.NewJSONReader(web.UnbindReq{Type: typ}))
+		require.NoError(t, err)
+		req.Header.Set("content-type", "application/json")
+		recorder := test.NewJSONResponseRecorder[any]()
+		s.server.ServeHTTP(recorder, req)
+		return recorder.MustScan()
+	}
+
+	assert.ElementsMatch(s.T(), []web.Identity{
+		{Type: "email", Value: "l***@meoying.com"},
+		{Type: "phone", Value: "138****5670"},
+	}, list(s.T()))
+
+	assert.Equal(s.T(), test.Result[any]{}, unbind(s.T(), "phone"))
+	var u dao.User
+	err = s.db.Where("id = ?", 123).First(&u).Error
+	require.NoError(s.T(), err)
+	assert.False(s.T(), u.Phone.Valid)
+	assert.Equal(s.T(), []web.Identity{
+		{Type: "email", Value: "l***@meoying.com"},
+	}, list(s.T()))
+
+	// 最后一种登录方式不能解绑
+	assert.Equal(s.T(), test.Result[any]{
+		Code: 501005,
+		Msg:  "至少需要保留一种登录方式",
+	}, unbind(s.T(), "email"))
+	assert.Len(s.T(), list(s.T()), 1)
+}
+
+func (s *HandleTestSuite) TestDevices() {
+	t := s.T()
+	ctx := context.Background()
+	now := time.Now().UnixMilli()
+	devices := []dao.Device{
+		{Uid: 123, SSID: "ssid-123-1", Name: "Chrome", IP: "127.0.0.1", LastActive: now - 3000, Ctime: now - 3000},
+		{Uid: 123, SSID: "ssid-123-2", Name: "Safari", IP: "127.0.0.2", LastActive: now - 2000, Ctime: now - 2000},
+		{Uid: 123, SSID: "ssid-123-3", Name: "iPhone", IP: "127.0.0.3", LastActive: now - 1000, Ctime: now - 1000},
+		// 已经过期的会话
+		{Uid: 123, SSID: "ssid-123-4", Name: "Edge", IP: "127.0.0.4", LastActive: now - 1000, Ctime: now - time.Hour.Milliseconds()*24*31},
+	}
+	err := s.db.Create(&devices).Error
+	require.NoError(t, err)
+	defer s.db.Exec("DELETE FROM user_devices WHERE uid = 123")
+
+	list := func(t *testing.T) []web.Device {
+		req, err := http.NewRequest(http.MethodPost, "/users/device/list", nil)
+		require.NoError(t, err)
+		recorder := test.NewJSONResponseRecorder[[]web.Device]()
+		s.server.ServeHTTP(recorder, req)
+		require.Equal(t, 200, recorder.Code)
+		return recorder.MustScan().Data
+	}
+	revoked := func(t *testing.T, ssid string) bool {
+		cnt, err := s.rdb.Exists(ctx, "webook:user:device:revoked:"+ssid).Result()
+		require.NoError(t, err)
+		return cnt > 0
+	}
+
+	assert.Equal(t, []web.Device{
+		{Id: devices[2].Id, Name: "iPhone", IP: "127.0.0.3", LastActive: now - 1000, Ctime: now - 1000},
+		{Id: devices[1].Id, Name: "Safari", IP: "127.0.0.2", LastActive: now - 2000, Ctime: now - 2000},
+		{Id: devices[0].Id, Name: "Chrome", IP: "127.0.0.1", LastActive: now - 3000, Ctime: now - 3000},
+	}, list(t))
+
+	req, err := http.NewRequest(http.MethodPost, "/users/device/revoke",
+		iox.NewJSONReader(web.RevokeDeviceReq{Id: devices[1].Id}))
+	require.NoError(t, err)
+	req.Header.Set("content-type", "application/json")
+	recorder := test.NewJSONResponseRecorder[any]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+	assert.True(t, revoked(t, "ssid-123-2"))
+	assert.False(t, revoked(t, "ssid-123-1"))
+	assert.Len(t, list(t), 2)
+
+	req, err = http.NewRequest(http.MethodPost, "/users/device/revoke_all", nil)
+	require.NoError(t, err)
+	recorder = test.NewJSONResponseRecorder[any]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+	assert.True(t, revoked(t, "ssid-123-1"))
+	assert.True(t, revoked(t, "ssid-123-3"))
+	assert.Empty(t, list(t))
+}
+
+func (s *HandleTestSuite) TestDeviceLimit() {
+	t := s.T()
+	now := time.Now().UnixMilli()
+	err := s.db.Create(&dao.User{
+		Id:       129,
+		Nickname: "test user",
+		Phone:    sqlx.NewNullString("13812345690"),
+	}).Error
+	require.NoError(t, err)
+	// 测试环境最多同时登录两个设备
+	devices := []dao.Device{
+		{Uid: 129, SSID: "ssid-129-1", LastActive: now - 2000, Ctime: now - 2000},
+		{Uid: 129, SSID: "ssid-129-2", LastActive: now - 1000, Ctime: now - 1000},
+	}
+	err = s.db.Create(&devices).Error
+	require.NoError(t, err)
+	defer func() {
+		s.db.Exec("DELETE FROM users WHERE id = 129")
+		s.db.Exec("DELETE FROM user_devices WHERE uid = 129")
+	}()
+	err = s.setPhoneCode(context.Background(), "13812345690", "123456")
+	require.NoError(t, err)
+	s.mockPermSvc.EXPECT().FindPersonalPermissions(gomock.Any(), int64(129)).Return(nil, nil)
+
+	req, err := http.NewRequest(http.MethodPost, "/oauth2/phone/login",
+		iox.NewJSONReader(web.PhoneReq{Phone: "13812345690", Code: "123456"}))
+	require.NoError(t, err)
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("X-Device-Name", "test device")
+	recorder := test.NewJSONResponseRecorder[web.Profile]()
+	s.server.ServeHTTP(recorder, req)
+	require.Equal(t, 200, recorder.Code)
+
+	// 最久没有活跃的被踢掉了
+	var res []dao.Device
+	err = s.db.Where("uid = ?", 129).Order("id ASC").Find(&res).Error
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "ssid-129-2", res[0].SSID)
+	assert.Equal(t, "test device", res[1].Name)
+	cnt, err := s.rdb.Exists(context.Background(), "webook:user:device:revoked:ssid-129-1").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), cnt)
+}
+
 func TestUserHandler(t *testing.T) {
 	suite.Run(t, new(HandleTestSuite))
 }
@@ -984,7 +1595,7 @@ func (s *HandlerWithAppTestSuite) SetupSuite() {
 		&member.Module{Svc: memSvc},
 		&permission.Module{
 			Svc: permSvc,
-		}, session.DefaultProvider(), nil)
+		}, session.DefaultProvider(), nil, nil)
 	s.mockWeSvc = wesvc
 	s.mockWeMiniSvc = weMiniSvc
 	//
@@ -1009,6 +1620,10 @@ func (s *HandlerWithAppTestSuite) TearDownSuite() {
 	require.NoError(s.T(), err)
 	err = s.db.Exec("TRUNCATE table `users_ielts`").Error
 	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE table `user_identities`").Error
+	require.NoError(s.T(), err)
+	err = s.db.Exec("TRUNCATE table `user_devices`").Error
+	require.NoError(s.T(), err)
 }
 
 func (s *HandlerWithAppTestSuite) TestEditProfile() {
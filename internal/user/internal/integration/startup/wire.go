@@ -19,6 +19,7 @@ package startup
 import (
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
 	testioc "github.com/ecodeclub/webook/internal/test/ioc"
@@ -37,17 +38,37 @@ var verificationCodeRepoSet = wire.NewSet(
 	repository.NewVerificationCodeRepository,
 )
 
+var identitySet = wire.NewSet(
+	dao.NewGORMIdentityDAO,
+	repository.NewIdentityRepository,
+	service.NewIdentityService,
+)
+
+var deviceSet = wire.NewSet(
+	dao.NewGORMDeviceDAO,
+	cache.NewDeviceCache,
+	repository.NewDeviceRepository,
+	initDeviceService,
+)
+
 func InitHandler(weSvc wechatWebOAuth2Service,
 	weMiniSvc wechatMiniOAuth2Service,
 	mem *member.Module,
 	perm *permission.Module,
 	sp session.Provider,
+	emailClient email.Service,
 	creators []string) *user.Handler {
 	wire.Build(
 		iniHandler,
 		testioc.BaseSet,
+		testioc.InitRedis,
 		verificationCodeRepoSet,
 		initVerificationCodeSvc,
+		identitySet,
+		cache.NewEmailCodeCache,
+		repository.NewEmailCodeRepository,
+		initEmailLoginService,
+		deviceSet,
 		wire.FieldsOf(new(*member.Module), "Svc"),
 		wire.FieldsOf(new(*permission.Module), "Svc"),
 		initRegistrationEventProducer,
@@ -66,8 +87,12 @@ func iniHandler(
 	permissionSvc permission.Service,
 	sp session.Provider,
 	verificationCodeSvc service.VerificationCodeSvc,
+	identitySvc service.IdentityService,
+	emailLoginSvc service.EmailLoginService,
+	deviceSvc service.DeviceService,
 	creators []string) *web.Handler {
-	return web.NewHandler(weSvc, weMiniSvc, userSvc, memberSvc, permissionSvc, sp, verificationCodeSvc, creators)
+	return web.NewHandler(weSvc, weMiniSvc, userSvc, memberSvc, permissionSvc, sp,
+		verificationCodeSvc, identitySvc, emailLoginSvc, deviceSvc, creators)
 }
 func InitModule() *user.Module {
 	wire.Build(
@@ -77,6 +102,7 @@ func InitModule() *user.Module {
 		dao.NewGORMUserDAO,
 		cache.NewUserECache,
 		repository.NewCachedUserRepository,
+		identitySet,
 		wire.Struct(new(user.Module), "Svc"),
 	)
 	return new(user.Module)
@@ -90,6 +116,15 @@ func initRegistrationEventProducer(q mq.MQ) event.RegistrationEventProducer {
 	return p
 }
 
+func initEmailLoginService(client email.Service, repo repository.EmailCodeRepo) service.EmailLoginService {
+	return service.NewEmailLoginService(client, repo, "noreply@meoying.com", "https://meoying.com/login/email")
+}
+
+// initDeviceService 测试里面最多同时登录两个设备
+func initDeviceService(repo repository.DeviceRepository) service.DeviceService {
+	return service.NewDeviceService(repo, 2)
+}
+
 func initVerificationCodeSvc(repo repository.VerificationCodeRepo) service.VerificationCodeSvc {
 	return service.NewVerificationCodeSvc(nil, repo)
 }
@@ -9,6 +9,7 @@ package startup
 import (
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
 	testioc "github.com/ecodeclub/webook/internal/test/ioc"
@@ -24,21 +25,32 @@ import (
 
 // Injectors from wire.go:
 
-func InitHandler(weSvc wechatWebOAuth2Service, weMiniSvc wechatMiniOAuth2Service, mem *member.Module, perm *permission.Module, sp session.Provider, creators []string) *web.Handler {
+func InitHandler(weSvc wechatWebOAuth2Service, weMiniSvc wechatMiniOAuth2Service, mem *member.Module, perm *permission.Module, sp session.Provider, emailClient email.Service, creators []string) *web.Handler {
 	db := testioc.InitDB()
 	userDAO := dao.NewGORMUserDAO(db)
 	ecacheCache := testioc.InitCache()
 	userCache := cache.NewUserECache(ecacheCache)
 	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
+	identityDAO := dao.NewGORMIdentityDAO(db)
+	identityRepository := repository.NewIdentityRepository(identityDAO, userCache)
 	mq := testioc.InitMQ()
 	registrationEventProducer := initRegistrationEventProducer(mq)
-	userService := service.NewUserService(userRepository, registrationEventProducer)
+	userService := service.NewUserService(userRepository, identityRepository, registrationEventProducer)
 	serviceService := mem.Svc
 	service2 := perm.Svc
-	verificationCodeCache := cache.NewVerificationCodeCache(ecacheCache)
+	cmdable := testioc.InitRedis()
+	verificationCodeCache := cache.NewVerificationCodeCache(cmdable)
 	verificationCodeRepo := repository.NewVerificationCodeRepository(verificationCodeCache)
 	verificationCodeSvc := initVerificationCodeSvc(verificationCodeRepo)
-	handler := iniHandler(weSvc, weMiniSvc, userService, serviceService, service2, sp, verificationCodeSvc, creators)
+	identityService := service.NewIdentityService(identityRepository, userRepository)
+	emailCodeCache := cache.NewEmailCodeCache(ecacheCache, cmdable)
+	emailCodeRepo := repository.NewEmailCodeRepository(emailCodeCache)
+	emailLoginService := initEmailLoginService(emailClient, emailCodeRepo)
+	deviceDAO := dao.NewGORMDeviceDAO(db)
+	deviceCache := cache.NewDeviceCache(cmdable)
+	deviceRepository := repository.NewDeviceRepository(deviceDAO, deviceCache)
+	deviceService := initDeviceService(deviceRepository)
+	handler := iniHandler(weSvc, weMiniSvc, userService, serviceService, service2, sp, verificationCodeSvc, identityService, emailLoginService, deviceService, creators)
 	return handler
 }
 
@@ -48,9 +60,11 @@ func InitModule() *user.Module {
 	ecacheCache := testioc.InitCache()
 	userCache := cache.NewUserECache(ecacheCache)
 	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
+	identityDAO := dao.NewGORMIdentityDAO(db)
+	identityRepository := repository.NewIdentityRepository(identityDAO, userCache)
 	mq := testioc.InitMQ()
 	registrationEventProducer := initRegistrationEventProducer(mq)
-	userService := service.NewUserService(userRepository, registrationEventProducer)
+	userService := service.NewUserService(userRepository, identityRepository, registrationEventProducer)
 	module := &user.Module{
 		Svc: userService,
 	}
@@ -61,6 +75,10 @@ func InitModule() *user.Module {
 
 var verificationCodeRepoSet = wire.NewSet(cache.NewVerificationCodeCache, repository.NewVerificationCodeRepository)
 
+var identitySet = wire.NewSet(dao.NewGORMIdentityDAO, repository.NewIdentityRepository, service.NewIdentityService)
+
+var deviceSet = wire.NewSet(dao.NewGORMDeviceDAO, cache.NewDeviceCache, repository.NewDeviceRepository, initDeviceService)
+
 func iniHandler(
 	weSvc wechatWebOAuth2Service,
 	weMiniSvc wechatMiniOAuth2Service,
@@ -69,8 +87,12 @@ func iniHandler(
 	permissionSvc permission.Service,
 	sp session.Provider,
 	verificationCodeSvc service.VerificationCodeSvc,
+	identitySvc service.IdentityService,
+	emailLoginSvc service.EmailLoginService,
+	deviceSvc service.DeviceService,
 	creators []string) *web.Handler {
-	return web.NewHandler(weSvc, weMiniSvc, userSvc, memberSvc, permissionSvc, sp, verificationCodeSvc, creators)
+	return web.NewHandler(weSvc, weMiniSvc, userSvc, memberSvc, permissionSvc, sp,
+		verificationCodeSvc, identitySvc, emailLoginSvc, deviceSvc, creators)
 }
 
 func initRegistrationEventProducer(q mq.MQ) event.RegistrationEventProducer {
@@ -81,6 +103,15 @@ func initRegistrationEventProducer(q mq.MQ) event.RegistrationEventProducer {
 	return p
 }
 
+func initEmailLoginService(client email.Service, repo repository.EmailCodeRepo) service.EmailLoginService {
+	return service.NewEmailLoginService(client, repo, "noreply@meoying.com", "https://meoying.com/login/email")
+}
+
+// initDeviceService 测试里面最多同时登录两个设备
+func initDeviceService(repo repository.DeviceRepository) service.DeviceService {
+	return service.NewDeviceService(repo, 2)
+}
+
 func initVerificationCodeSvc(repo repository.VerificationCodeRepo) service.VerificationCodeSvc {
 	return service.NewVerificationCodeSvc(nil, repo)
 }
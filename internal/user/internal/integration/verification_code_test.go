@@ -0,0 +1,123 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testioc "github.com/ecodeclub/webook/internal/test/ioc"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type VerificationCodeCacheTestSuite struct {
+	suite.Suite
+	rdb   redis.Cmdable
+	cache cache.VerificationCodeCache
+}
+
+func (s *VerificationCodeCacheTestSuite) SetupSuite() {
+	s.rdb = testioc.InitRedis()
+	s.cache = cache.NewVerificationCodeCache(s.rdb)
+}
+
+func (s *VerificationCodeCacheTestSuite) TearDownTest() {
+	ctx := context.Background()
+	keys, err := s.rdb.Keys(ctx, "webook:user:sms:*").Result()
+	require.NoError(s.T(), err)
+	if len(keys) > 0 {
+		err = s.rdb.Del(ctx, keys...).Err()
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *VerificationCodeCacheTestSuite) TestSetPhoneCode() {
+	t := s.T()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	err := s.cache.SetPhoneCode(ctx, "13800000001", "127.0.0.1", "123456")
+	require.NoError(t, err)
+	ttl, err := s.rdb.TTL(ctx, "webook:user:sms:code:13800000001").Result()
+	require.NoError(t, err)
+	assert.True(t, ttl > time.Minute*4)
+
+	// 同一个手机号还在冷却
+	err = s.cache.SetPhoneCode(ctx, "13800000001", "127.0.0.2", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+	// 同一个 IP 还在冷却
+	err = s.cache.SetPhoneCode(ctx, "13800000002", "127.0.0.1", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+	// 被拒绝的请求不会占用另外一个维度的额度
+	err = s.cache.SetPhoneCode(ctx, "13800000002", "127.0.0.2", "123456")
+	require.NoError(t, err)
+
+	// 超过每日上限
+	date := time.Now().Format("20060102")
+	err = s.rdb.Set(ctx, "webook:user:sms:daily:phone:13800000003:"+date, 10, time.Hour).Err()
+	require.NoError(t, err)
+	err = s.cache.SetPhoneCode(ctx, "13800000003", "127.0.0.3", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+	err = s.rdb.Set(ctx, "webook:user:sms:daily:ip:127.0.0.4:"+date, 50, time.Hour).Err()
+	require.NoError(t, err)
+	err = s.cache.SetPhoneCode(ctx, "13800000004", "127.0.0.4", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeSendTooFrequent)
+	// 没有 IP 的时候只按照手机号限制
+	err = s.cache.SetPhoneCode(ctx, "13800000004", "", "123456")
+	require.NoError(t, err)
+}
+
+func (s *VerificationCodeCacheTestSuite) TestVerifyPhoneCode() {
+	t := s.T()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	// 验证码只能使用一次
+	err := s.cache.SetPhoneCode(ctx, "13800000011", "", "123456")
+	require.NoError(t, err)
+	ok, err := s.cache.VerifyPhoneCode(ctx, "13800000011", "123456")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = s.cache.VerifyPhoneCode(ctx, "13800000011", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeExpired)
+
+	// 错误次数用完之后作废，正确的验证码也不能用了
+	err = s.cache.SetPhoneCode(ctx, "13800000012", "", "123456")
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		ok, err = s.cache.VerifyPhoneCode(ctx, "13800000012", "000000")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	_, err = s.cache.VerifyPhoneCode(ctx, "13800000012", "000000")
+	assert.ErrorIs(t, err, cache.ErrCodeVerifyTooManyTimes)
+	_, err = s.cache.VerifyPhoneCode(ctx, "13800000012", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeVerifyTooManyTimes)
+
+	// 从来没有发送过
+	_, err = s.cache.VerifyPhoneCode(ctx, "13800000013", "123456")
+	assert.ErrorIs(t, err, cache.ErrCodeExpired)
+}
+
+func TestVerificationCodeCache(t *testing.T) {
+	suite.Run(t, new(VerificationCodeCacheTestSuite))
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrDeviceRevoked = errors.New("会话已经被踢下线")
+
+	//go:embed lua/touch_device.lua
+	luaTouchDevice string
+)
+
+// DeviceCache 记录被踢掉的会话。
+// JWT 在过期之前一直有效，所以只能在每个请求到来的时候检查一下
+type DeviceCache interface {
+	Revoke(ctx context.Context, ssids ...string) error
+	// Touch 会话被踢掉的时候返回 ErrDeviceRevoked。
+	// 距离上一次刷新超过一定的时间就返回 true，调用者需要刷新最近活跃的时间
+	Touch(ctx context.Context, ssid string) (bool, error)
+}
+
+type deviceCache struct {
+	cmd redis.Cmdable
+	// 踢掉的标记保留多久，和 session 的有效期保持一致
+	expiration time.Duration
+	// 多久刷新一次最近活跃的时间
+	touchInterval time.Duration
+}
+
+func NewDeviceCache(cmd redis.Cmdable) DeviceCache {
+	return &deviceCache{
+		cmd: cmd,
+		// 参考 ioc.InitSession，session 的有效期是一个月
+		expiration:    time.Hour * 24 * 30,
+		touchInterval: time.Minute * 5,
+	}
+}
+
+func (c *deviceCache) Revoke(ctx context.Context, ssids ...string) error {
+	if len(ssids) == 0 {
+		return nil
+	}
+	pipe := c.cmd.TxPipeline()
+	for _, ssid := range ssids {
+		pipe.Set(ctx, c.revokedKey(ssid), 1, c.expiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *deviceCache) Touch(ctx context.Context, ssid string) (bool, error) {
+	res, err := c.cmd.Eval(ctx, luaTouchDevice,
+		[]string{c.revokedKey(ssid), c.activeKey(ssid)},
+		int(c.touchInterval.Seconds())).Int()
+	if err != nil {
+		return false, err
+	}
+	switch res {
+	case -1:
+		return false, ErrDeviceRevoked
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("未知的返回值 %d", res)
+	}
+}
+
+func (c *deviceCache) revokedKey(ssid string) string {
+	return "webook:user:device:revoked:" + ssid
+}
+
+func (c *deviceCache) activeKey(ssid string) string {
+	return "webook:user:device:active:" + ssid
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ecodeclub/ecache"
+	"github.com/redis/go-redis/v9"
+)
+
+type EmailCodeCache interface {
+	// SetCode 和短信验证码一样，同一个邮箱或者 IP 在冷却时间内只能发送一次，并且每天有上限，
+	// 超过了返回 ErrCodeSendTooFrequent。ip 为空则只按照邮箱限制
+	SetCode(ctx context.Context, email string, ip string, code string) error
+	// VerifyCode 验证码只能使用一次，错误次数用完之后作废。
+	// 验证码不对的时候返回 false
+	VerifyCode(ctx context.Context, email string, code string) (bool, error)
+	SetToken(ctx context.Context, token string, email string) error
+	// TakeToken 取出登录链接中的 token 对应的邮箱，并且删除 token，保证只能使用一次
+	TakeToken(ctx context.Context, token string) (string, error)
+}
+
+type emailCodeCache struct {
+	cache ecache.Cache
+	// 验证码复用短信验证码的 lua 脚本
+	cmd redis.Cmdable
+	// 验证码的过期时间
+	codeExpiration time.Duration
+	// 两次发送之间至少间隔多久
+	interval time.Duration
+	// 每个邮箱每天最多发送多少次
+	emailDailyLimit int
+	// 每个 IP 每天最多发送多少次
+	ipDailyLimit int
+	// 一个验证码最多验证多少次
+	maxAttempts int
+	// 登录链接的过期时间
+	tokenExpiration time.Duration
+}
+
+// NewEmailCodeCache 注意缓存前缀
+func NewEmailCodeCache(c ecache.Cache, cmd redis.Cmdable) EmailCodeCache {
+	return &emailCodeCache{
+		cache: &ecache.NamespaceCache{
+			Namespace: "email:",
+			C:         c,
+		},
+		cmd:             cmd,
+		codeExpiration:  time.Minute * 5,
+		interval:        time.Minute,
+		emailDailyLimit: 10,
+		ipDailyLimit:    50,
+		maxAttempts:     5,
+		tokenExpiration: time.Minute * 15,
+	}
+}
+
+func (e *emailCodeCache) SetCode(ctx context.Context, email string, ip string, code string) error {
+	date := time.Now().Format("20060102")
+	keys := []string{
+		e.codeKey(email),
+		e.key("interval", "email", email),
+		e.key("daily", "email", email, date),
+	}
+	if ip != "" {
+		keys = append(keys,
+			e.key("interval", "ip", ip),
+			e.key("daily", "ip", ip, date))
+	}
+	res, err := e.cmd.Eval(ctx, luaSetPhoneCode, keys,
+		code,
+		int(e.codeExpiration.Seconds()),
+		int(e.interval.Seconds()),
+		int((time.Hour * 24).Seconds()),
+		e.emailDailyLimit,
+		e.ipDailyLimit,
+		e.maxAttempts,
+	).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		return nil
+	case -1, -2:
+		return ErrCodeSendTooFrequent
+	default:
+		return fmt.Errorf("未知的返回值 %d", res)
+	}
+}
+
+func (e *emailCodeCache) VerifyCode(ctx context.Context, email string, code string) (bool, error) {
+	res, err := e.cmd.Eval(ctx, luaVerifyPhoneCode, []string{e.codeKey(email)}, code).Int()
+	if err != nil {
+		return false, err
+	}
+	switch res {
+	case 0:
+		return true, nil
+	case -1:
+		return false, ErrCodeExpired
+	case -2:
+		return false, ErrCodeVerifyTooManyTimes
+	case -3:
+		return false, nil
+	default:
+		return false, fmt.Errorf("未知的返回值 %d", res)
+	}
+}
+
+func (e *emailCodeCache) SetToken(ctx context.Context, token string, email string) error {
+	return e.cache.Set(ctx, e.tokenKey(token), email, e.tokenExpiration)
+}
+
+func (e *emailCodeCache) TakeToken(ctx context.Context, token string) (string, error) {
+	key := e.tokenKey(token)
+	email, err := e.get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	cnt, err := e.cache.Delete(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	// 并发使用同一个链接，只有删除成功的那个才算数
+	if cnt == 0 {
+		return "", ErrKeyNotFound
+	}
+	return email, nil
+}
+
+func (e *emailCodeCache) get(ctx context.Context, key string) (string, error) {
+	val := e.cache.Get(ctx, key)
+	if val.KeyNotFound() {
+		return "", ErrKeyNotFound
+	}
+	if val.Err != nil {
+		return "", val.Err
+	}
+	return val.String()
+}
+
+func (e *emailCodeCache) codeKey(email string) string {
+	return e.key("code", email)
+}
+
+func (e *emailCodeCache) key(parts ...string) string {
+	return "webook:user:email:" + strings.Join(parts, ":")
+}
+
+func (e *emailCodeCache) tokenKey(token string) string {
+	return "token:" + token
+}
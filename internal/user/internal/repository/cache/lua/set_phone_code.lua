@@ -0,0 +1,29 @@
+-- 发送验证码之前检查冷却时间和每日上限，都通过了才写入新的验证码
+-- KEYS[1] 验证码
+-- KEYS[2]、KEYS[3] 手机号（邮箱验证码复用时是邮箱）的冷却标记和每日计数
+-- KEYS[4]、KEYS[5] IP 的冷却标记和每日计数，没有 IP 的时候不传
+-- ARGV[1] 验证码 ARGV[2] 验证码有效期（秒） ARGV[3] 冷却时间（秒）
+-- ARGV[4] 每日计数的有效期（秒） ARGV[5] 手机号（邮箱）每日上限 ARGV[6] IP 每日上限
+-- ARGV[7] 最多可以验证的次数
+-- 返回 0 成功，-1 冷却中，-2 超过每日上限
+local limits = { tonumber(ARGV[5]), tonumber(ARGV[6]) }
+for i = 2, #KEYS, 2 do
+    if redis.call("EXISTS", KEYS[i]) == 1 then
+        return -1
+    end
+    local cnt = tonumber(redis.call("GET", KEYS[i + 1]) or "0")
+    if cnt >= limits[i / 2] then
+        return -2
+    end
+end
+for i = 2, #KEYS, 2 do
+    redis.call("SET", KEYS[i], 1, "EX", ARGV[3])
+    if redis.call("INCR", KEYS[i + 1]) == 1 then
+        redis.call("EXPIRE", KEYS[i + 1], ARGV[4])
+    end
+end
+-- 新的验证码会覆盖旧的，验证次数也重新计算
+redis.call("DEL", KEYS[1])
+redis.call("HSET", KEYS[1], "code", ARGV[1], "cnt", ARGV[7])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+return 0
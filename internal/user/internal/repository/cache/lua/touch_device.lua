@@ -0,0 +1,11 @@
+-- 请求到来的时候检查会话有没有被踢掉，顺便判断要不要刷新最近活跃的时间
+-- KEYS[1] 踢掉的标记 KEYS[2] 最近活跃的标记
+-- ARGV[1] 多久刷新一次最近活跃的时间（秒）
+-- 返回 -1 已经被踢掉，1 需要刷新，0 不需要刷新
+if redis.call("EXISTS", KEYS[1]) == 1 then
+    return -1
+end
+if redis.call("SET", KEYS[2], 1, "NX", "EX", ARGV[1]) then
+    return 1
+end
+return 0
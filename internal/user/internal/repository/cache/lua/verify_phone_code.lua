@@ -0,0 +1,21 @@
+-- 校验验证码。验证成功之后删除，错误次数用完之后作废
+-- KEYS[1] 验证码
+-- ARGV[1] 用户输入的验证码
+-- 返回 0 成功，-1 不存在或者已经过期，-2 错误次数过多，-3 验证码错误
+if redis.call("EXISTS", KEYS[1]) == 0 then
+    return -1
+end
+local cnt = tonumber(redis.call("HGET", KEYS[1], "cnt"))
+if cnt <= 0 then
+    return -2
+end
+if redis.call("HGET", KEYS[1], "code") == ARGV[1] then
+    redis.call("DEL", KEYS[1])
+    return 0
+end
+-- 作废之后保留到过期，这期间再来验证都是错误次数过多
+redis.call("HINCRBY", KEYS[1], "cnt", -1)
+if cnt <= 1 then
+    return -2
+end
+return -3
@@ -2,48 +2,120 @@ package cache
 
 import (
 	"context"
+	_ "embed"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/ecodeclub/ecache"
+	"github.com/redis/go-redis/v9"
 )
 
-var ErrKeyNotFound = errors.New("key not found")
+var (
+	ErrKeyNotFound = errors.New("key not found")
+
+	ErrCodeSendTooFrequent    = errors.New("验证码发送太频繁")
+	ErrCodeVerifyTooManyTimes = errors.New("验证码错误次数过多")
+	ErrCodeExpired            = errors.New("验证码不存在或者已经过期")
+
+	//go:embed lua/set_phone_code.lua
+	luaSetPhoneCode string
+	//go:embed lua/verify_phone_code.lua
+	luaVerifyPhoneCode string
+)
 
 type VerificationCodeCache interface {
-	SetPhoneCode(ctx context.Context, phone string, code string) error
-	GetPhoneCode(ctx context.Context, phone string) (string, error)
+	// SetPhoneCode 同一个手机号或者 IP 在冷却时间内只能发送一次，并且每天有上限，
+	// 超过了返回 ErrCodeSendTooFrequent。ip 为空则只按照手机号限制
+	SetPhoneCode(ctx context.Context, phone string, ip string, code string) error
+	// VerifyPhoneCode 验证码只能使用一次，错误次数用完之后作废。
+	// 验证码不对的时候返回 false
+	VerifyPhoneCode(ctx context.Context, phone string, code string) (bool, error)
 }
 
 type verificationCodeCache struct {
-	cache ecache.Cache
+	cmd redis.Cmdable
 	// 过期时间
 	expiration time.Duration
+	// 两次发送之间至少间隔多久
+	interval time.Duration
+	// 每个手机号每天最多发送多少次
+	phoneDailyLimit int
+	// 每个 IP 每天最多发送多少次
+	ipDailyLimit int
+	// 一个验证码最多验证多少次
+	maxAttempts int
 }
 
-// NewVerificationCodeCache 注意缓存前缀
-func NewVerificationCodeCache(c ecache.Cache) VerificationCodeCache {
+func NewVerificationCodeCache(cmd redis.Cmdable) VerificationCodeCache {
 	return &verificationCodeCache{
-		cache: &ecache.NamespaceCache{
-			Namespace: "sms:",
-			C:         c,
-		},
+		cmd: cmd,
 		// 默认五分钟
-		expiration: time.Minute * 5,
+		expiration:      time.Minute * 5,
+		interval:        time.Minute,
+		phoneDailyLimit: 10,
+		ipDailyLimit:    50,
+		maxAttempts:     5,
 	}
 }
 
-func (s *verificationCodeCache) SetPhoneCode(ctx context.Context, phone string, code string) error {
-	return s.cache.Set(ctx, phone, code, s.expiration)
+func (s *verificationCodeCache) SetPhoneCode(ctx context.Context, phone string, ip string, code string) error {
+	date := time.Now().Format("20060102")
+	keys := []string{
+		s.codeKey(phone),
+		s.key("interval", "phone", phone),
+		s.key("daily", "phone", phone, date),
+	}
+	if ip != "" {
+		keys = append(keys,
+			s.key("interval", "ip", ip),
+			s.key("daily", "ip", ip, date))
+	}
+	res, err := s.cmd.Eval(ctx, luaSetPhoneCode, keys,
+		code,
+		int(s.expiration.Seconds()),
+		int(s.interval.Seconds()),
+		int((time.Hour * 24).Seconds()),
+		s.phoneDailyLimit,
+		s.ipDailyLimit,
+		s.maxAttempts,
+	).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		return nil
+	case -1, -2:
+		return ErrCodeSendTooFrequent
+	default:
+		return fmt.Errorf("未知的返回值 %d", res)
+	}
 }
 
-func (s *verificationCodeCache) GetPhoneCode(ctx context.Context, phone string) (string, error) {
-	val := s.cache.Get(ctx, phone)
-	if val.Err != nil {
-		return "", val.Err
+func (s *verificationCodeCache) VerifyPhoneCode(ctx context.Context, phone string, code string) (bool, error) {
+	res, err := s.cmd.Eval(ctx, luaVerifyPhoneCode, []string{s.codeKey(phone)}, code).Int()
+	if err != nil {
+		return false, err
 	}
-	if val.KeyNotFound() {
-		return "", ErrKeyNotFound
+	switch res {
+	case 0:
+		return true, nil
+	case -1:
+		return false, ErrCodeExpired
+	case -2:
+		return false, ErrCodeVerifyTooManyTimes
+	case -3:
+		return false, nil
+	default:
+		return false, fmt.Errorf("未知的返回值 %d", res)
 	}
-	return val.Val.(string), nil
+}
+
+func (s *verificationCodeCache) codeKey(phone string) string {
+	return s.key("code", phone)
+}
+
+func (s *verificationCodeCache) key(parts ...string) string {
+	return "webook:user:sms:" + strings.Join(parts, ":")
 }
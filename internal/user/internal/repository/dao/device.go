@@ -0,0 +1,86 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/ego-component/egorm"
+)
+
+type DeviceDAO interface {
+	Insert(ctx context.Context, d Device) (int64, error)
+	// FindByUid 按照最近活跃的时间倒序，只返回 ctime 晚于 since 的
+	FindByUid(ctx context.Context, uid int64, since int64) ([]Device, error)
+	Delete(ctx context.Context, uid int64, ids []int64) error
+	UpdateLastActive(ctx context.Context, ssid string, lastActive int64) error
+}
+
+type GORMDeviceDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMDeviceDAO(db *egorm.Component) DeviceDAO {
+	return &GORMDeviceDAO{db: db}
+}
+
+func (d *GORMDeviceDAO) Insert(ctx context.Context, dev Device) (int64, error) {
+	now := time.Now().UnixMilli()
+	dev.LastActive = now
+	dev.Ctime = now
+	dev.Utime = now
+	err := d.db.WithContext(ctx).Create(&dev).Error
+	return dev.Id, err
+}
+
+func (d *GORMDeviceDAO) FindByUid(ctx context.Context, uid int64, since int64) ([]Device, error) {
+	var res []Device
+	err := d.db.WithContext(ctx).Where("uid = ? AND ctime > ?", uid, since).
+		Order("last_active DESC, id DESC").Find(&res).Error
+	return res, err
+}
+
+func (d *GORMDeviceDAO) Delete(ctx context.Context, uid int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.db.WithContext(ctx).Where("uid = ? AND id IN ?", uid, ids).Delete(&Device{}).Error
+}
+
+func (d *GORMDeviceDAO) UpdateLastActive(ctx context.Context, ssid string, lastActive int64) error {
+	return d.db.WithContext(ctx).Model(&Device{}).Where("ssid = ?", ssid).
+		Updates(map[string]any{
+			"last_active": lastActive,
+			"utime":       time.Now().UnixMilli(),
+		}).Error
+}
+
+// Device 登录的设备，一次登录一条记录
+type Device struct {
+	Id         int64  `gorm:"primaryKey,autoIncrement"`
+	Uid        int64  `gorm:"index"`
+	SSID       string `gorm:"type:varchar(64);uniqueIndex"`
+	App        uint   `gorm:"comment:登录的应用"`
+	Name       string `gorm:"type:varchar(256);comment:设备名称"`
+	IP         string `gorm:"type:varchar(64)"`
+	LastActive int64  `gorm:"comment:最近活跃的时间"`
+	Ctime      int64
+	Utime      int64
+}
+
+func (Device) TableName() string {
+	return "user_devices"
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ecodeclub/webook/internal/pkg/ectx"
+	"github.com/ego-component/egorm"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrIdentityDuplicate 身份已经被别的用户绑定了
+var ErrIdentityDuplicate = errors.New("身份已经被其他用户绑定")
+
+const (
+	IdentityTypeWechat = "wechat"
+	IdentityTypePhone  = "phone"
+	IdentityTypeEmail  = "email"
+)
+
+// identityColumns 历史原因，微信和手机号在 users 表上也有一份，绑定和解绑的时候需要同步
+var identityColumns = map[string]string{
+	IdentityTypeWechat: "wechat_union_id",
+	IdentityTypePhone:  "phone",
+}
+
+type IdentityDAO interface {
+	// Bind 绑定身份。同一种类型的身份一个用户只能有一个，已经有了就替换掉
+	Bind(ctx context.Context, i Identity) error
+	Unbind(ctx context.Context, uid int64, typ string) error
+	FindByUid(ctx context.Context, uid int64) ([]Identity, error)
+	FindByValue(ctx context.Context, typ, value string) (Identity, error)
+}
+
+type GORMIdentityDAO struct {
+	db *egorm.Component
+}
+
+func NewGORMIdentityDAO(db *egorm.Component) IdentityDAO {
+	return &GORMIdentityDAO{db: db}
+}
+
+func (d *GORMIdentityDAO) Bind(ctx context.Context, i Identity) error {
+	i.App, _ = ectx.AppFromCtx(ctx)
+	i.Ctime = time.Now().UnixMilli()
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old Identity
+		err := tx.Where("app = ? AND type = ? AND value = ?", i.App, i.Type, i.Value).First(&old).Error
+		switch {
+		case err == nil && old.Uid != i.Uid:
+			return ErrIdentityDuplicate
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			// 同一种类型只能绑定一个，先删掉旧的
+			err = tx.Where("app = ? AND uid = ? AND type = ?", i.App, i.Uid, i.Type).Delete(&Identity{}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Create(&i).Error
+			if err != nil {
+				return d.duplicate(err)
+			}
+		case err != nil:
+			return err
+		}
+		// 已经绑定过的也同步一次，保证 users 表上的字段一致
+		col, ok := identityColumns[i.Type]
+		if !ok {
+			return nil
+		}
+		err = tx.Model(&User{}).Where("id = ?", i.Uid).Updates(map[string]any{
+			col:     i.Value,
+			"utime": i.Ctime,
+		}).Error
+		return d.duplicate(err)
+	})
+}
+
+func (d *GORMIdentityDAO) Unbind(ctx context.Context, uid int64, typ string) error {
+	app, _ := ectx.AppFromCtx(ctx)
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("app = ? AND uid = ? AND type = ?", app, uid, typ).Delete(&Identity{}).Error
+		if err != nil {
+			return err
+		}
+		col, ok := identityColumns[typ]
+		if !ok {
+			return nil
+		}
+		updates := map[string]any{
+			col:     nil,
+			"utime": time.Now().UnixMilli(),
+		}
+		if typ == IdentityTypeWechat {
+			updates["wechat_open_id"] = nil
+			updates["wechat_mini_open_id"] = nil
+		}
+		return tx.Model(&User{}).Where("id = ?", uid).Updates(updates).Error
+	})
+}
+
+func (d *GORMIdentityDAO) FindByUid(ctx context.Context, uid int64) ([]Identity, error) {
+	app, _ := ectx.AppFromCtx(ctx)
+	var res []Identity
+	err := d.db.WithContext(ctx).Where("app = ? AND uid = ?", app, uid).
+		Order("id ASC").Find(&res).Error
+	return res, err
+}
+
+func (d *GORMIdentityDAO) FindByValue(ctx context.Context, typ, value string) (Identity, error) {
+	app, _ := ectx.AppFromCtx(ctx)
+	var res Identity
+	err := d.db.WithContext(ctx).Where("app = ? AND type = ? AND value = ?", app, typ, value).
+		First(&res).Error
+	return res, err
+}
+
+func (d *GORMIdentityDAO) duplicate(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == uniqueIndexErrNo {
+		return ErrIdentityDuplicate
+	}
+	return err
+}
+
+// upsertIdentities 创建用户的时候同步写入身份。
+// users 表上的唯一索引已经保证了手机号和微信不会重复，
+// 这里能覆盖掉的只有已经删除的用户残留的记录
+func upsertIdentities(tx *gorm.DB, u User) error {
+	app, _ := ectx.AppFromCtx(tx.Statement.Context)
+	var ids []Identity
+	if u.Phone.Valid {
+		ids = append(ids, Identity{App: app, Uid: u.Id, Type: IdentityTypePhone, Value: u.Phone.String, Ctime: u.Ctime})
+	}
+	if u.WechatUnionId.Valid {
+		ids = append(ids, Identity{App: app, Uid: u.Id, Type: IdentityTypeWechat, Value: u.WechatUnionId.String, Ctime: u.Ctime})
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"uid", "ctime"}),
+	}).Create(&ids).Error
+}
+
+// Identity 用户绑定的身份，微信、手机号或者邮箱
+type Identity struct {
+	Id    int64  `gorm:"primaryKey,autoIncrement"`
+	App   uint   `gorm:"uniqueIndex:app_type_value;comment:所属的应用，和 users 表的分表规则一致"`
+	Uid   int64  `gorm:"index"`
+	Type  string `gorm:"type:varchar(32);uniqueIndex:app_type_value"`
+	Value string `gorm:"type:varchar(256);uniqueIndex:app_type_value"`
+	Ctime int64
+}
+
+func (Identity) TableName() string {
+	return "user_identities"
+}
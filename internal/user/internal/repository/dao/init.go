@@ -13,6 +13,8 @@ func InitTables(db *egorm.Component) error {
 	return db.AutoMigrate(
 		&User{},
 		&UsersIelts{},
+		&Identity{},
+		&Device{},
 	)
 }
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/ecodeclub/webook/internal/pkg/ectx"
 	"github.com/ego-component/egorm"
 	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
@@ -19,14 +20,21 @@ var ErrUserDuplicate = errors.New("用户已经注册")
 
 var ErrPhoneNotFound = errors.New("手机号没找到")
 
+const uniqueIndexErrNo uint16 = 1062
+
 //go:generate mockgen -source=./user.go -package=daomocks -destination=mocks/user.mock.go UserDAO
 type UserDAO interface {
 	Insert(ctx context.Context, u User) (int64, error)
+	// InsertWithIdentity 创建用户的同时绑定一个 users 表上没有对应字段的身份，例如邮箱
+	InsertWithIdentity(ctx context.Context, u User, i Identity) (int64, error)
 	UpdateNonZeroFields(ctx context.Context, u User) error
 	FindByWechat(ctx context.Context, unionId string) (User, error)
 	FindById(ctx context.Context, id int64) (User, error)
 	FindByIds(ctx context.Context, ids []int64) ([]User, error)
 	FindByPhone(ctx context.Context, phone string) (User, error)
+	// Anonymize 注销账号。昵称改成 nickname，清空头像以及所有的身份。
+	// 保留 id 和 sn，其他模块的数据还能找到这个（已经注销的）用户
+	Anonymize(ctx context.Context, id int64, nickname string) error
 }
 
 type GORMUserDAO struct {
@@ -53,12 +61,33 @@ func (ud *GORMUserDAO) UpdateNonZeroFields(ctx context.Context, u User) error {
 }
 
 func (ud *GORMUserDAO) Insert(ctx context.Context, u User) (int64, error) {
+	return ud.insert(ctx, u, nil)
+}
+
+func (ud *GORMUserDAO) InsertWithIdentity(ctx context.Context, u User, i Identity) (int64, error) {
+	return ud.insert(ctx, u, &i)
+}
+
+func (ud *GORMUserDAO) insert(ctx context.Context, u User, i *Identity) (int64, error) {
 	now := time.Now().UnixMilli()
 	u.Ctime = now
 	u.Utime = now
-	err := ud.db.WithContext(ctx).Create(&u).Error
+	err := ud.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&u).Error
+		if err != nil {
+			return err
+		}
+		err = upsertIdentities(tx, u)
+		if err != nil || i == nil {
+			return err
+		}
+		i.App, _ = ectx.AppFromCtx(ctx)
+		i.Uid = u.Id
+		i.Ctime = now
+		// 这里不能覆盖，重复说明别人已经用这个身份注册了
+		return tx.Create(i).Error
+	})
 	if me, ok := err.(*mysql.MySQLError); ok {
-		const uniqueIndexErrNo uint16 = 1062
 		if me.Number == uniqueIndexErrNo {
 			return 0, ErrUserDuplicate
 		}
@@ -84,6 +113,24 @@ func (ud *GORMUserDAO) FindByIds(ctx context.Context, ids []int64) ([]User, erro
 	return us, err
 }
 
+func (ud *GORMUserDAO) Anonymize(ctx context.Context, id int64, nickname string) error {
+	return ud.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", id).Updates(map[string]any{
+			"nickname":            nickname,
+			"avatar":              "",
+			"phone":               nil,
+			"wechat_open_id":      nil,
+			"wechat_union_id":     nil,
+			"wechat_mini_open_id": nil,
+			"utime":               time.Now().UnixMilli(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", id).Delete(&Identity{}).Error
+	})
+}
+
 type User struct {
 	Id       int64 `gorm:"primaryKey,autoIncrement"`
 	Nickname string
@@ -94,6 +94,10 @@ func (u *UserCallBackBuilder) Build() func(db *gorm.DB) {
 }
 
 func build(db *gorm.DB, logger *elog.Component) {
+	// 只有 users 表是按照应用分表的
+	if db.Statement.Table != UserTableName {
+		return
+	}
 	ctx := db.Statement.Context
 	appid, ok := ectx.AppFromCtx(ctx)
 	var tableName string
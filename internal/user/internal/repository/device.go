@@ -0,0 +1,95 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/user/internal/domain"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/dao"
+)
+
+var ErrDeviceRevoked = cache.ErrDeviceRevoked
+
+type DeviceRepository interface {
+	Create(ctx context.Context, d domain.Device) (int64, error)
+	// FindByUid 按照最近活跃的时间倒序，只返回 ctime 晚于 since 的
+	FindByUid(ctx context.Context, uid int64, since int64) ([]domain.Device, error)
+	// Revoke 删除设备，并且让对应的会话立刻失效
+	Revoke(ctx context.Context, uid int64, devices []domain.Device) error
+	// Touch 会话被踢掉的时候返回 ErrDeviceRevoked，否则按需刷新最近活跃的时间
+	Touch(ctx context.Context, ssid string, now int64) error
+}
+
+type deviceRepository struct {
+	dao   dao.DeviceDAO
+	cache cache.DeviceCache
+}
+
+func NewDeviceRepository(d dao.DeviceDAO, c cache.DeviceCache) DeviceRepository {
+	return &deviceRepository{dao: d, cache: c}
+}
+
+func (r *deviceRepository) Create(ctx context.Context, d domain.Device) (int64, error) {
+	return r.dao.Insert(ctx, dao.Device{
+		Uid:  d.Uid,
+		SSID: d.SSID,
+		App:  d.App,
+		Name: d.Name,
+		IP:   d.IP,
+	})
+}
+
+func (r *deviceRepository) FindByUid(ctx context.Context, uid int64, since int64) ([]domain.Device, error) {
+	devices, err := r.dao.FindByUid(ctx, uid, since)
+	return slice.Map(devices, func(idx int, src dao.Device) domain.Device {
+		return domain.Device{
+			Id:         src.Id,
+			Uid:        src.Uid,
+			SSID:       src.SSID,
+			App:        src.App,
+			Name:       src.Name,
+			IP:         src.IP,
+			LastActive: src.LastActive,
+			Ctime:      src.Ctime,
+		}
+	}), err
+}
+
+func (r *deviceRepository) Revoke(ctx context.Context, uid int64, devices []domain.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+	// 先让会话失效，删除记录失败也不会留下还能用的会话
+	err := r.cache.Revoke(ctx, slice.Map(devices, func(idx int, src domain.Device) string {
+		return src.SSID
+	})...)
+	if err != nil {
+		return err
+	}
+	return r.dao.Delete(ctx, uid, slice.Map(devices, func(idx int, src domain.Device) int64 {
+		return src.Id
+	}))
+}
+
+func (r *deviceRepository) Touch(ctx context.Context, ssid string, now int64) error {
+	refresh, err := r.cache.Touch(ctx, ssid)
+	if err != nil || !refresh {
+		return err
+	}
+	return r.dao.UpdateLastActive(ctx, ssid, now)
+}
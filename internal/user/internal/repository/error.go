@@ -3,3 +3,5 @@ package repository
 import "github.com/ecodeclub/webook/internal/user/internal/repository/dao"
 
 var ErrUserNotFound = dao.ErrDataNotFound
+
+var ErrUserDuplicate = dao.ErrUserDuplicate
@@ -0,0 +1,88 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"context"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/user/internal/domain"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/dao"
+)
+
+var (
+	ErrIdentityDuplicate = dao.ErrIdentityDuplicate
+	ErrIdentityNotFound  = dao.ErrDataNotFound
+)
+
+type IdentityRepository interface {
+	Bind(ctx context.Context, i domain.Identity) error
+	Unbind(ctx context.Context, uid int64, typ domain.IdentityType) error
+	FindByUid(ctx context.Context, uid int64) ([]domain.Identity, error)
+	FindByValue(ctx context.Context, typ domain.IdentityType, value string) (domain.Identity, error)
+}
+
+// identityRepository 绑定和解绑会修改 users 表上的字段，所以要删除用户的缓存
+type identityRepository struct {
+	dao   dao.IdentityDAO
+	cache cache.UserCache
+}
+
+func NewIdentityRepository(d dao.IdentityDAO, c cache.UserCache) IdentityRepository {
+	return &identityRepository{dao: d, cache: c}
+}
+
+func (r *identityRepository) Bind(ctx context.Context, i domain.Identity) error {
+	err := r.dao.Bind(ctx, dao.Identity{
+		Uid:   i.Uid,
+		Type:  string(i.Type),
+		Value: i.Value,
+	})
+	if err != nil {
+		return err
+	}
+	return r.cache.Delete(ctx, i.Uid)
+}
+
+func (r *identityRepository) Unbind(ctx context.Context, uid int64, typ domain.IdentityType) error {
+	err := r.dao.Unbind(ctx, uid, string(typ))
+	if err != nil {
+		return err
+	}
+	return r.cache.Delete(ctx, uid)
+}
+
+func (r *identityRepository) FindByUid(ctx context.Context, uid int64) ([]domain.Identity, error) {
+	ids, err := r.dao.FindByUid(ctx, uid)
+	return slice.Map(ids, func(idx int, src dao.Identity) domain.Identity {
+		return r.toDomain(src)
+	}), err
+}
+
+func (r *identityRepository) FindByValue(ctx context.Context, typ domain.IdentityType, value string) (domain.Identity, error) {
+	i, err := r.dao.FindByValue(ctx, string(typ), value)
+	return r.toDomain(i), err
+}
+
+func (r *identityRepository) toDomain(i dao.Identity) domain.Identity {
+	return domain.Identity{
+		Id:    i.Id,
+		Uid:   i.Uid,
+		Type:  domain.IdentityType(i.Type),
+		Value: i.Value,
+		Ctime: i.Ctime,
+	}
+}
@@ -14,6 +14,8 @@ import (
 //go:generate mockgen -source=./user.go -package=repomocks -destination=mocks/user.mock.go UserRepository
 type UserRepository interface {
 	Create(ctx context.Context, u domain.User) (int64, error)
+	// CreateWithIdentity 创建用户并且绑定身份，身份已经被绑定则返回 ErrUserDuplicate
+	CreateWithIdentity(ctx context.Context, u domain.User, i domain.Identity) (int64, error)
 	// Update 更新数据，只有非 0 值才会更新
 	Update(ctx context.Context, u domain.User) error
 	// FindByWechat 按照 unionId 来查询
@@ -21,6 +23,8 @@ type UserRepository interface {
 	FindById(ctx context.Context, id int64) (domain.User, error)
 	FindByIds(ctx context.Context, ids []int64) ([]domain.User, error)
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
+	// Anonymize 注销账号，匿名化用户的个人信息并且解绑所有的身份
+	Anonymize(ctx context.Context, id int64, nickname string) error
 }
 
 // CachedUserRepository 使用了缓存的 repository 实现
@@ -46,10 +50,25 @@ func (ur *CachedUserRepository) Update(ctx context.Context, u domain.User) error
 	return ur.cache.Delete(ctx, u.Id)
 }
 
+func (ur *CachedUserRepository) Anonymize(ctx context.Context, id int64, nickname string) error {
+	err := ur.dao.Anonymize(ctx, id, nickname)
+	if err != nil {
+		return err
+	}
+	return ur.cache.Delete(ctx, id)
+}
+
 func (ur *CachedUserRepository) Create(ctx context.Context, u domain.User) (int64, error) {
 	return ur.dao.Insert(ctx, ur.domainToEntity(u))
 }
 
+func (ur *CachedUserRepository) CreateWithIdentity(ctx context.Context, u domain.User, i domain.Identity) (int64, error) {
+	return ur.dao.InsertWithIdentity(ctx, ur.domainToEntity(u), dao.Identity{
+		Type:  string(i.Type),
+		Value: i.Value,
+	})
+}
+
 func (ur *CachedUserRepository) FindByWechat(ctx context.Context,
 	unionId string) (domain.User, error) {
 	u, err := ur.dao.FindByWechat(ctx, unionId)
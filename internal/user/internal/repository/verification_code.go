@@ -6,9 +6,15 @@ import (
 	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
 )
 
+var (
+	ErrCodeSendTooFrequent    = cache.ErrCodeSendTooFrequent
+	ErrCodeVerifyTooManyTimes = cache.ErrCodeVerifyTooManyTimes
+	ErrCodeExpired            = cache.ErrCodeExpired
+)
+
 type VerificationCodeRepo interface {
-	SetPhoneCode(ctx context.Context, phone string, code string) error
-	GetPhoneCode(ctx context.Context, phone string) (string, error)
+	SetPhoneCode(ctx context.Context, phone string, ip string, code string) error
+	VerifyPhoneCode(ctx context.Context, phone string, code string) (bool, error)
 }
 type verificationRepository struct {
 	cache.VerificationCodeCache
@@ -19,3 +25,17 @@ func NewVerificationCodeRepository(smsCache cache.VerificationCodeCache) Verific
 		VerificationCodeCache: smsCache,
 	}
 }
+
+type EmailCodeRepo interface {
+	cache.EmailCodeCache
+}
+
+type emailCodeRepository struct {
+	cache.EmailCodeCache
+}
+
+func NewEmailCodeRepository(c cache.EmailCodeCache) EmailCodeRepo {
+	return &emailCodeRepository{
+		EmailCodeCache: c,
+	}
+}
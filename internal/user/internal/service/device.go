@@ -0,0 +1,126 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/user/internal/domain"
+	"github.com/ecodeclub/webook/internal/user/internal/repository"
+)
+
+// ErrDeviceRevoked 会话已经被踢下线，即便 JWT 还没有过期也不能再使用
+var ErrDeviceRevoked = repository.ErrDeviceRevoked
+
+// DeviceService 管理用户在各个设备上的登录会话
+//
+//go:generate mockgen -source=./device.go -package=usermocks -typed=true -destination=../../mocks/device.mock.go DeviceService
+type DeviceService interface {
+	// Login 登记新的会话，同时在线的会话超过上限之后踢掉最久没有活跃的
+	Login(ctx context.Context, d domain.Device) error
+	List(ctx context.Context, uid int64) ([]domain.Device, error)
+	Revoke(ctx context.Context, uid int64, id int64) error
+	// RevokeAll 踢掉除了 except 以外的所有会话，except 为空则全部踢掉
+	RevokeAll(ctx context.Context, uid int64, except string) error
+	// Logout 当前会话退出登录
+	Logout(ctx context.Context, uid int64, ssid string) error
+	// Check 每个请求都会调用，会话被踢掉之后返回 ErrDeviceRevoked
+	Check(ctx context.Context, ssid string) error
+}
+
+type deviceService struct {
+	repo repository.DeviceRepository
+	// 同时在线的会话上限，防止一个会员账号被很多人共用
+	maxDevices int
+	// 和 session 的有效期保持一致，更早的会话已经过期了
+	expiration time.Duration
+}
+
+func NewDeviceService(repo repository.DeviceRepository, maxDevices int) DeviceService {
+	return &deviceService{
+		repo:       repo,
+		maxDevices: maxDevices,
+		// 参考 ioc.InitSession
+		expiration: time.Hour * 24 * 30,
+	}
+}
+
+func (s *deviceService) Login(ctx context.Context, d domain.Device) error {
+	_, err := s.repo.Create(ctx, d)
+	if err != nil {
+		return err
+	}
+	devices, err := s.List(ctx, d.Uid)
+	if err != nil || len(devices) <= s.maxDevices {
+		return err
+	}
+	// 已经按照最近活跃的时间倒序，新登录的排在最前面
+	return s.repo.Revoke(ctx, d.Uid, devices[s.maxDevices:])
+}
+
+func (s *deviceService) List(ctx context.Context, uid int64) ([]domain.Device, error) {
+	since := time.Now().Add(-s.expiration).UnixMilli()
+	return s.repo.FindByUid(ctx, uid, since)
+}
+
+func (s *deviceService) Revoke(ctx context.Context, uid int64, id int64) error {
+	return s.revoke(ctx, uid, func(d domain.Device) bool {
+		return d.Id == id
+	})
+}
+
+func (s *deviceService) RevokeAll(ctx context.Context, uid int64, except string) error {
+	return s.revoke(ctx, uid, func(d domain.Device) bool {
+		return d.SSID != except
+	})
+}
+
+func (s *deviceService) Logout(ctx context.Context, uid int64, ssid string) error {
+	targets, err := s.find(ctx, uid, func(d domain.Device) bool {
+		return d.SSID == ssid
+	})
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		// 引入设备管理之前登录的会话没有记录，也要让它失效
+		targets = []domain.Device{{Uid: uid, SSID: ssid}}
+	}
+	return s.repo.Revoke(ctx, uid, targets)
+}
+
+func (s *deviceService) revoke(ctx context.Context, uid int64, match func(d domain.Device) bool) error {
+	targets, err := s.find(ctx, uid, match)
+	if err != nil {
+		return err
+	}
+	return s.repo.Revoke(ctx, uid, targets)
+}
+
+func (s *deviceService) find(ctx context.Context, uid int64, match func(d domain.Device) bool) ([]domain.Device, error) {
+	devices, err := s.List(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.FilterMap(devices, func(idx int, src domain.Device) (domain.Device, bool) {
+		return src, match(src)
+	}), nil
+}
+
+func (s *deviceService) Check(ctx context.Context, ssid string) error {
+	return s.repo.Touch(ctx, ssid, time.Now().UnixMilli())
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/ecodeclub/webook/internal/email"
+	"github.com/ecodeclub/webook/internal/user/internal/repository"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+)
+
+var (
+	ErrInvalidEmail = errors.New("邮箱格式错误")
+	ErrInvalidLink  = errors.New("登录链接无效或者已经过期")
+)
+
+// EmailLoginService 邮箱免密登录。
+// 一封邮件里面同时包含验证码和登录链接，用户任选其一即可
+type EmailLoginService interface {
+	// Send 和短信验证码一样限制发送频率，ip 可以为空
+	Send(ctx context.Context, addr string, ip string) error
+	// VerifyCode 验证码只能使用一次，错误次数过多之后作废
+	VerifyCode(ctx context.Context, addr string, code string) error
+	// VerifyLink 校验登录链接中的 token，返回对应的邮箱。token 只能使用一次
+	VerifyLink(ctx context.Context, token string) (string, error)
+}
+
+type emailLoginService struct {
+	client email.Service
+	repo   repository.EmailCodeRepo
+	// 发件人的名称
+	from string
+	// 登录链接的地址，token 会作为查询参数附加在后面
+	linkURL string
+}
+
+func NewEmailLoginService(client email.Service, repo repository.EmailCodeRepo,
+	from string, linkURL string) EmailLoginService {
+	return &emailLoginService{
+		client:  client,
+		repo:    repo,
+		from:    from,
+		linkURL: linkURL,
+	}
+}
+
+func (s *emailLoginService) Send(ctx context.Context, addr string, ip string) error {
+	addr, err := NormalizeEmail(addr)
+	if err != nil {
+		return err
+	}
+	code := newVerificationCode()
+	err = s.repo.SetCode(ctx, addr, ip, code)
+	if err != nil {
+		return err
+	}
+	token, err := s.newToken()
+	if err != nil {
+		return err
+	}
+	err = s.repo.SetToken(ctx, token, addr)
+	if err != nil {
+		return err
+	}
+	link, err := s.link(token)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf(`<p>你的登录验证码是 <b>%s</b>，5 分钟内有效。</p>
+<p>也可以直接点击<a href="%s">这个链接</a>登录，15 分钟内有效。</p>
+<p>如果不是你本人操作，请忽略这封邮件。</p>`, code, link)
+	return s.client.SendMail(ctx, email.Mail{
+		From:    s.from,
+		To:      addr,
+		Subject: "登录验证码",
+		Body:    []byte(body),
+	})
+}
+
+func (s *emailLoginService) VerifyCode(ctx context.Context, addr string, code string) error {
+	addr, err := NormalizeEmail(addr)
+	if err != nil {
+		return err
+	}
+	ok, err := s.repo.VerifyCode(ctx, addr, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerificationCode
+	}
+	return nil
+}
+
+func (s *emailLoginService) VerifyLink(ctx context.Context, token string) (string, error) {
+	addr, err := s.repo.TakeToken(ctx, token)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return "", ErrInvalidLink
+	}
+	return addr, err
+}
+
+func (s *emailLoginService) link(token string) (string, error) {
+	u, err := url.Parse(s.linkURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (s *emailLoginService) newToken() (string, error) {
+	bytes := make([]byte, 16)
+	_, err := rand.Read(bytes)
+	return hex.EncodeToString(bytes), err
+}
+
+// NormalizeEmail 校验邮箱格式，并且统一转成小写，避免同一个邮箱注册出多个账号
+func NormalizeEmail(addr string) (string, error) {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil || parsed.Address != addr {
+		return "", ErrInvalidEmail
+	}
+	return addr, nil
+}
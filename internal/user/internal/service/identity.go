@@ -0,0 +1,107 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/webook/internal/user/internal/domain"
+	"github.com/ecodeclub/webook/internal/user/internal/repository"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	// ErrIdentityBound 身份已经被其他用户绑定了，不允许抢过来，否则会出现两个账号的数据混在一起
+	ErrIdentityBound = repository.ErrIdentityDuplicate
+	ErrLastIdentity  = errors.New("至少需要保留一种登录方式")
+)
+
+// IdentityService 管理用户绑定的登录方式
+type IdentityService interface {
+	List(ctx context.Context, uid int64) ([]domain.Identity, error)
+	// Bind 调用方需要先确认用户确实拥有这个身份，例如校验过验证码
+	Bind(ctx context.Context, i domain.Identity) error
+	Unbind(ctx context.Context, uid int64, typ domain.IdentityType) error
+}
+
+type identityService struct {
+	repo     repository.IdentityRepository
+	userRepo repository.UserRepository
+}
+
+func NewIdentityService(repo repository.IdentityRepository, userRepo repository.UserRepository) IdentityService {
+	return &identityService{repo: repo, userRepo: userRepo}
+}
+
+func (s *identityService) List(ctx context.Context, uid int64) ([]domain.Identity, error) {
+	var (
+		eg  errgroup.Group
+		ids []domain.Identity
+		u   domain.User
+	)
+	eg.Go(func() error {
+		var err error
+		ids, err = s.repo.FindByUid(ctx, uid)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		u, err = s.userRepo.FindById(ctx, uid)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	// 引入身份表之前注册的用户，微信和手机号只记录在 users 表上
+	legacy := []domain.Identity{
+		{Uid: uid, Type: domain.IdentityTypeWechat, Value: u.WechatInfo.UnionId},
+		{Uid: uid, Type: domain.IdentityTypePhone, Value: u.Phone},
+	}
+	for _, i := range legacy {
+		if i.Value == "" {
+			continue
+		}
+		found := slice.ContainsFunc(ids, func(src domain.Identity) bool {
+			return src.Type == i.Type
+		})
+		if !found {
+			ids = append(ids, i)
+		}
+	}
+	return ids, nil
+}
+
+func (s *identityService) Bind(ctx context.Context, i domain.Identity) error {
+	return s.repo.Bind(ctx, i)
+}
+
+func (s *identityService) Unbind(ctx context.Context, uid int64, typ domain.IdentityType) error {
+	ids, err := s.List(ctx, uid)
+	if err != nil {
+		return err
+	}
+	bound := slice.ContainsFunc(ids, func(src domain.Identity) bool {
+		return src.Type == typ
+	})
+	if !bound {
+		return nil
+	}
+	if len(ids) <= 1 {
+		return ErrLastIdentity
+	}
+	return s.repo.Unbind(ctx, uid, typ)
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+
+	"github.com/ecodeclub/webook/internal/user/internal/domain"
+	"github.com/ecodeclub/webook/internal/user/internal/repository"
+)
+
+// deletedNickname 注销之后展示的昵称
+const deletedNickname = "已注销用户"
+
+// PrivacyService 导出以及清除用户的个人资料，由 privacy 模块在导出数据和注销账号的时候调用
+type PrivacyService interface {
+	Name() string
+	Export(ctx context.Context, uid int64) (any, error)
+	// Erase 匿名化个人资料，解绑所有的身份并且踢掉所有的会话
+	Erase(ctx context.Context, uid int64) error
+}
+
+type privacyService struct {
+	repo         repository.UserRepository
+	identityRepo repository.IdentityRepository
+	deviceSvc    DeviceService
+}
+
+func NewPrivacyService(repo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	deviceSvc DeviceService) PrivacyService {
+	return &privacyService{
+		repo:         repo,
+		identityRepo: identityRepo,
+		deviceSvc:    deviceSvc,
+	}
+}
+
+func (s *privacyService) Name() string {
+	return "user"
+}
+
+type userData struct {
+	Profile    domain.User       `json:"profile"`
+	Identities []domain.Identity `json:"identities"`
+	Devices    []domain.Device   `json:"devices"`
+}
+
+func (s *privacyService) Export(ctx context.Context, uid int64) (any, error) {
+	u, err := s.repo.FindById(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.FindByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.deviceSvc.List(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return userData{Profile: u, Identities: identities, Devices: devices}, nil
+}
+
+func (s *privacyService) Erase(ctx context.Context, uid int64) error {
+	err := s.repo.Anonymize(ctx, uid, deletedNickname)
+	if err != nil {
+		return err
+	}
+	return s.deviceSvc.RevokeAll(ctx, uid, "")
+}
@@ -41,19 +41,25 @@ type UserService interface {
 	CreateWithPhone(ctx context.Context, phone string) (domain.User, error)
 
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
+	// FindOrCreateByEmail 邮箱必须是校验过的
+	FindOrCreateByEmail(ctx context.Context, email string) (domain.User, error)
 }
 
 type userService struct {
-	repo     repository.UserRepository
-	producer event.RegistrationEventProducer
-	logger   *elog.Component
+	repo         repository.UserRepository
+	identityRepo repository.IdentityRepository
+	producer     event.RegistrationEventProducer
+	logger       *elog.Component
 }
 
-func NewUserService(repo repository.UserRepository, p event.RegistrationEventProducer) UserService {
+func NewUserService(repo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	p event.RegistrationEventProducer) UserService {
 	return &userService{
-		repo:     repo,
-		producer: p,
-		logger:   elog.DefaultLogger,
+		repo:         repo,
+		identityRepo: identityRepo,
+		producer:     p,
+		logger:       elog.DefaultLogger,
 	}
 }
 
@@ -94,15 +100,7 @@ func (svc *userService) FindOrCreateByWechat(ctx context.Context,
 	if err != nil {
 		return domain.User{}, err
 	}
-	// 发送注册成功消息
-	evt := event.RegistrationEvent{Uid: id, InvitationCode: info.InvitationCode}
-	if e := svc.producer.Produce(ctx, evt); e != nil {
-		svc.logger.Error("发送注册成功消息失败",
-			elog.FieldErr(e),
-			elog.FieldKey("event"),
-			elog.FieldValueAny(evt),
-		)
-	}
+	svc.sendRegistrationEvent(ctx, event.RegistrationEvent{Uid: id, InvitationCode: info.InvitationCode})
 	u.Id = id
 	return u, nil
 }
@@ -129,8 +127,47 @@ func (svc *userService) CreateWithPhone(ctx context.Context, phone string) (doma
 	if err != nil {
 		return domain.User{}, err
 	}
-	// 发送注册成功消息
-	evt := event.RegistrationEvent{Uid: id, InvitationCode: ""}
+	svc.sendRegistrationEvent(ctx, event.RegistrationEvent{Uid: id, InvitationCode: ""})
+	u.Id = id
+	return u, nil
+}
+
+func (svc *userService) FindOrCreateByEmail(ctx context.Context, email string) (domain.User, error) {
+	u, err := svc.findByEmail(ctx, email)
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return u, err
+	}
+	sn := shortuuid.New()
+	u = domain.User{
+		SN:       sn,
+		Nickname: sn[:4],
+	}
+	id, err := svc.repo.CreateWithIdentity(ctx, u, domain.Identity{
+		Type:  domain.IdentityTypeEmail,
+		Value: email,
+	})
+	if errors.Is(err, repository.ErrUserDuplicate) {
+		// 并发登录，别的请求已经创建好了
+		return svc.findByEmail(ctx, email)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	svc.sendRegistrationEvent(ctx, event.RegistrationEvent{Uid: id, InvitationCode: ""})
+	u.Id = id
+	return u, nil
+}
+
+func (svc *userService) findByEmail(ctx context.Context, email string) (domain.User, error) {
+	i, err := svc.identityRepo.FindByValue(ctx, domain.IdentityTypeEmail, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return svc.repo.FindById(ctx, i.Uid)
+}
+
+// sendRegistrationEvent 发送注册成功消息
+func (svc *userService) sendRegistrationEvent(ctx context.Context, evt event.RegistrationEvent) {
 	if e := svc.producer.Produce(ctx, evt); e != nil {
 		svc.logger.Error("发送注册成功消息失败",
 			elog.FieldErr(e),
@@ -138,6 +175,4 @@ func (svc *userService) CreateWithPhone(ctx context.Context, phone string) (doma
 			elog.FieldValueAny(evt),
 		)
 	}
-	u.Id = id
-	return u, nil
 }
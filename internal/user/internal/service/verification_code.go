@@ -14,10 +14,18 @@ const templateID = "SMS_315530322"
 
 var (
 	ErrVerificationCode = errors.New("验证码错误")
+	// ErrCodeSendTooFrequent 还在冷却时间内，或者超过了每天的发送上限
+	ErrCodeSendTooFrequent = repository.ErrCodeSendTooFrequent
+	// ErrCodeVerifyTooManyTimes 错误次数过多，验证码已经作废
+	ErrCodeVerifyTooManyTimes = repository.ErrCodeVerifyTooManyTimes
+	// ErrCodeExpired 验证码过期或者已经使用过
+	ErrCodeExpired = repository.ErrCodeExpired
 )
 
 type VerificationCodeSvc interface {
-	Send(ctx context.Context, phone string) error
+	// Send ip 用于限制同一个来源的发送频率，可以为空
+	Send(ctx context.Context, phone string, ip string) error
+	// Verify 验证码只能使用一次
 	Verify(ctx context.Context, phone string, code string) error
 }
 
@@ -36,23 +44,19 @@ func NewVerificationCodeSvc(client client.Client,
 }
 
 func (s *smsServiceImpl) Verify(ctx context.Context, phone string, code string) error {
-	wantCode, err := s.repo.GetPhoneCode(ctx, phone)
+	ok, err := s.repo.VerifyPhoneCode(ctx, phone, code)
 	if err != nil {
 		return err
 	}
-	if wantCode != code {
+	if !ok {
 		return ErrVerificationCode
 	}
 	return nil
 }
 
-func (s *smsServiceImpl) GetCode(ctx context.Context, phone string) (string, error) {
-	return s.repo.GetPhoneCode(ctx, phone)
-}
-
-func (s *smsServiceImpl) Send(ctx context.Context, phone string) error {
+func (s *smsServiceImpl) Send(ctx context.Context, phone string, ip string) error {
 	code := s.generateCode()
-	err := s.repo.SetPhoneCode(ctx, phone, code)
+	err := s.repo.SetPhoneCode(ctx, phone, ip, code)
 	if err != nil {
 		return err
 	}
@@ -76,6 +80,11 @@ func (s *smsServiceImpl) Send(ctx context.Context, phone string) error {
 }
 
 func (s *smsServiceImpl) generateCode() string {
+	return newVerificationCode()
+}
+
+// newVerificationCode 生成六位数字验证码
+func newVerificationCode() string {
 	// 使用crypto/rand生成随机字节
 	bytes := make([]byte, 6)
 	_, _ = rand.Read(bytes)
@@ -0,0 +1,66 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ecodeclub/ginx"
+	"github.com/ecodeclub/ginx/session"
+	"github.com/ecodeclub/webook/internal/user/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/gotomicro/ego/core/elog"
+)
+
+// CheckDeviceMiddlewareBuilder 拒绝已经被踢下线的会话。
+// JWT 本身在过期之前一直有效，所以要放在登录校验的后面
+type CheckDeviceMiddlewareBuilder struct {
+	svc    service.DeviceService
+	sp     session.Provider
+	logger *elog.Component
+}
+
+func NewCheckDeviceMiddlewareBuilder(svc service.DeviceService) *CheckDeviceMiddlewareBuilder {
+	return &CheckDeviceMiddlewareBuilder{
+		svc:    svc,
+		logger: elog.DefaultLogger,
+	}
+}
+
+func (b *CheckDeviceMiddlewareBuilder) Build() gin.HandlerFunc {
+	if b.sp == nil {
+		b.sp = session.DefaultProvider()
+	}
+	return func(ctx *gin.Context) {
+		gctx := &ginx.Context{Context: ctx}
+		sess, err := b.sp.Get(gctx)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			b.logger.Debug("用户未登录", elog.FieldErr(err))
+			return
+		}
+		claims := sess.Claims()
+		err = b.svc.Check(ctx, claims.SSID)
+		switch {
+		case errors.Is(err, service.ErrDeviceRevoked):
+			ctx.AbortWithStatus(http.StatusUnauthorized)
+			b.logger.Debug("会话已经被踢下线", elog.Int64("uid", claims.Uid))
+		case err != nil:
+			// Redis 出问题的时候放行，不能因为设备管理影响正常的请求
+			b.logger.Error("检查会话状态失败", elog.Int64("uid", claims.Uid), elog.FieldErr(err))
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 ecodeclub
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ecodeclub/ginx/session"
+	sessmocks "github.com/ecodeclub/webook/internal/test/mocks"
+	"github.com/ecodeclub/webook/internal/user/internal/service"
+	usermocks "github.com/ecodeclub/webook/internal/user/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCheckDeviceMiddlewareBuilder(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) (service.DeviceService, session.Provider)
+		wantCode  int
+		wantAbort bool
+	}{
+		{
+			name: "未登录",
+			mock: func(ctrl *gomock.Controller) (service.DeviceService, session.Provider) {
+				p := sessmocks.NewMockProvider(ctrl)
+				p.EXPECT().Get(gomock.Any()).Return(nil, errors.New("mock no jwt"))
+				return nil, p
+			},
+			wantCode:  http.StatusUnauthorized,
+			wantAbort: true,
+		},
+		{
+			name: "会话正常",
+			mock: func(ctrl *gomock.Controller) (service.DeviceService, session.Provider) {
+				svc := usermocks.NewMockDeviceService(ctrl)
+				svc.EXPECT().Check(gomock.Any(), "ssid-1").Return(nil)
+				return svc, mockProvider(ctrl, "ssid-1")
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "会话已经被踢下线",
+			mock: func(ctrl *gomock.Controller) (service.DeviceService, session.Provider) {
+				svc := usermocks.NewMockDeviceService(ctrl)
+				svc.EXPECT().Check(gomock.Any(), "ssid-2").Return(service.ErrDeviceRevoked)
+				return svc, mockProvider(ctrl, "ssid-2")
+			},
+			wantCode:  http.StatusUnauthorized,
+			wantAbort: true,
+		},
+		{
+			name: "检查失败的时候放行",
+			mock: func(ctrl *gomock.Controller) (service.DeviceService, session.Provider) {
+				svc := usermocks.NewMockDeviceService(ctrl)
+				svc.EXPECT().Check(gomock.Any(), "ssid-3").Return(errors.New("mock redis error"))
+				return svc, mockProvider(ctrl, "ssid-3")
+			},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc, p := tc.mock(ctrl)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			builder := NewCheckDeviceMiddlewareBuilder(svc)
+			builder.sp = p
+			builder.Build()(c)
+			assert.Equal(t, tc.wantCode, c.Writer.Status())
+			assert.Equal(t, tc.wantAbort, c.IsAborted())
+		})
+	}
+}
+
+func mockProvider(ctrl *gomock.Controller, ssid string) session.Provider {
+	sess := sessmocks.NewMockSession(ctrl)
+	sess.EXPECT().Claims().Return(session.Claims{Uid: 123, SSID: ssid})
+	p := sessmocks.NewMockProvider(ctrl)
+	p.EXPECT().Get(gomock.Any()).Return(sess, nil)
+	return p
+}
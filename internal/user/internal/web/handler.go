@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
+	"github.com/ecodeclub/webook/internal/pkg/ectx"
 	"github.com/ecodeclub/webook/internal/user/internal/domain"
 	"github.com/ecodeclub/webook/internal/user/internal/errs"
 	"github.com/ecodeclub/webook/internal/user/internal/service"
@@ -47,6 +49,9 @@ type Handler struct {
 	memberSvc           member.Service
 	permissionSvc       permission.Service
 	verificationCodeSvc service.VerificationCodeSvc
+	identitySvc         service.IdentityService
+	emailLoginSvc       service.EmailLoginService
+	deviceSvc           service.DeviceService
 	// 白名单
 	creators []string
 	logger   *elog.Component
@@ -63,6 +68,9 @@ func NewHandler(
 	permissionSvc permission.Service,
 	sp session.Provider,
 	verificationCodeSvc service.VerificationCodeSvc,
+	identitySvc service.IdentityService,
+	emailLoginSvc service.EmailLoginService,
+	deviceSvc service.DeviceService,
 	creators []string) *Handler {
 	return &Handler{
 		weSvc:               weSvc,
@@ -72,6 +80,9 @@ func NewHandler(
 		permissionSvc:       permissionSvc,
 		creators:            creators,
 		verificationCodeSvc: verificationCodeSvc,
+		identitySvc:         identitySvc,
+		emailLoginSvc:       emailLoginSvc,
+		deviceSvc:           deviceSvc,
 		logger:              elog.DefaultLogger,
 		sp:                  sp,
 	}
@@ -84,6 +95,19 @@ func (h *Handler) PrivateRoutes(server *gin.Engine) {
 	users.POST("/profile", ginx.BS[EditReq](h.Edit))
 	// 绑定手机号
 	users.POST("/phone/bind", ginx.BS[PhoneReq](h.BindPhone))
+	// 绑定邮箱，验证码通过 /oauth2/email/send 发送
+	users.POST("/email/bind", ginx.BS[EmailReq](h.BindEmail))
+	// 绑定微信，前端走完扫码流程之后把 code 和 state 传过来
+	users.POST("/wechat/bind", ginx.BS[WechatCallback](h.BindWechat))
+	users.POST("/identity/list", ginx.S(h.Identities))
+	users.POST("/identity/unbind", ginx.BS[UnbindReq](h.Unbind))
+
+	// 登录的设备
+	users.POST("/device/list", ginx.S(h.Devices))
+	// 踢掉某个设备
+	users.POST("/device/revoke", ginx.BS[RevokeDeviceReq](h.RevokeDevice))
+	// 踢掉除了当前设备以外的所有设备
+	users.POST("/device/revoke_all", ginx.S(h.RevokeAllDevices))
 }
 
 func (h *Handler) PublicRoutes(server *gin.Engine) {
@@ -104,35 +128,158 @@ func (h *Handler) PublicRoutes(server *gin.Engine) {
 	oauth2.POST("/phone/login", appidFunc, ginx.B[PhoneReq](h.PhoneLogin))
 	// 注册
 	oauth2.POST("/phone/register", appidFunc, ginx.B[PhoneReq](h.PhoneRegister))
+
+	// 发送邮箱验证码，邮件里面同时包含登录链接
+	oauth2.POST("/email/send", appidFunc, ginx.B[SendEmailCodeReq](h.SendEmailCode))
+	// 邮箱验证码登录，没有注册过的邮箱会直接注册
+	oauth2.POST("/email/login", appidFunc, ginx.B[EmailReq](h.EmailLogin))
+	// 邮件中的登录链接
+	oauth2.POST("/email/link/login", appidFunc, ginx.B[EmailLinkReq](h.EmailLinkLogin))
 }
 
 func (h *Handler) BindPhone(ctx *ginx.Context, req PhoneReq, sess session.Session) (ginx.Result, error) {
-	err := h.verificationCodeSvc.Verify(ctx, req.Phone, req.Code)
+	if res, err := h.verifyPhoneCode(ctx, req.Phone, req.Code); err != nil {
+		return res, err
+	}
+	return h.bind(ctx, domain.Identity{
+		Uid:   sess.Claims().Uid,
+		Type:  domain.IdentityTypePhone,
+		Value: req.Phone,
+	})
+}
+
+func (h *Handler) BindEmail(ctx *ginx.Context, req EmailReq, sess session.Session) (ginx.Result, error) {
+	email, err := service.NormalizeEmail(req.Email)
 	if err != nil {
-		err = errors.New("验证码错误")
-		return newVerificationErr(err), err
+		return invalidEmailResult, nil
 	}
-	uid := sess.Claims().Uid
-	err = h.userSvc.UpdateNonSensitiveInfo(ctx, domain.User{
-		Phone: req.Phone,
-		Id:    uid,
+	if res, err := h.verifyEmailCode(ctx, email, req.Code); err != nil {
+		return res, err
+	}
+	return h.bind(ctx, domain.Identity{
+		Uid:   sess.Claims().Uid,
+		Type:  domain.IdentityTypeEmail,
+		Value: email,
+	})
+}
+
+func (h *Handler) BindWechat(ctx *ginx.Context, req WechatCallback, sess session.Session) (ginx.Result, error) {
+	info, err := h.weSvc.Verify(ctx, service.CallbackParams{
+		Code:  req.Code,
+		State: req.State,
 	})
 	if err != nil {
 		return systemErrorResult, err
 	}
-	return ginx.Result{}, nil
+	return h.bind(ctx, domain.Identity{
+		Uid:   sess.Claims().Uid,
+		Type:  domain.IdentityTypeWechat,
+		Value: info.UnionId,
+	})
 }
 
-func (h *Handler) PhoneLogin(ctx *ginx.Context, req PhoneReq) (ginx.Result, error) {
-	err := h.verificationCodeSvc.Verify(ctx, req.Phone, req.Code)
+func (h *Handler) bind(ctx context.Context, i domain.Identity) (ginx.Result, error) {
+	err := h.identitySvc.Bind(ctx, i)
+	switch {
+	case errors.Is(err, service.ErrIdentityBound):
+		return identityBoundResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	default:
+		return ginx.Result{}, nil
+	}
+}
+
+func (h *Handler) Identities(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	ids, err := h.identitySvc.List(ctx, sess.Claims().Uid)
 	if err != nil {
-		err = errors.New("验证码错误")
-		return newVerificationErr(err), err
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(ids, func(idx int, src domain.Identity) Identity {
+			return newIdentity(src)
+		}),
+	}, nil
+}
+
+func (h *Handler) Unbind(ctx *ginx.Context, req UnbindReq, sess session.Session) (ginx.Result, error) {
+	typ := domain.IdentityType(req.Type)
+	if !typ.Valid() {
+		return systemErrorResult, fmt.Errorf("未知的身份类型 %s", req.Type)
+	}
+	err := h.identitySvc.Unbind(ctx, sess.Claims().Uid, typ)
+	switch {
+	case errors.Is(err, service.ErrLastIdentity):
+		return lastIdentityResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	default:
+		return ginx.Result{}, nil
+	}
+}
+
+func (h *Handler) SendEmailCode(ctx *ginx.Context, req SendEmailCodeReq) (ginx.Result, error) {
+	err := h.emailLoginSvc.Send(ctx, req.Email, ctx.ClientIP())
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail):
+		return invalidEmailResult, nil
+	case errors.Is(err, service.ErrCodeSendTooFrequent):
+		return codeTooFrequentResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	default:
+		return ginx.Result{}, nil
+	}
+}
+
+func (h *Handler) EmailLogin(ctx *ginx.Context, req EmailReq) (ginx.Result, error) {
+	email, err := service.NormalizeEmail(req.Email)
+	if err != nil {
+		return invalidEmailResult, nil
+	}
+	if res, err := h.verifyEmailCode(ctx, email, req.Code); err != nil {
+		return res, err
+	}
+	return h.emailLogin(ctx, email)
+}
+
+func (h *Handler) EmailLinkLogin(ctx *ginx.Context, req EmailLinkReq) (ginx.Result, error) {
+	email, err := h.emailLoginSvc.VerifyLink(ctx, req.Token)
+	switch {
+	case errors.Is(err, service.ErrInvalidLink):
+		return invalidEmailLinkResult, nil
+	case err != nil:
+		return systemErrorResult, err
+	}
+	return h.emailLogin(ctx, email)
+}
+
+func (h *Handler) emailLogin(ctx *ginx.Context, email string) (ginx.Result, error) {
+	user, err := h.userSvc.FindOrCreateByEmail(ctx, email)
+	if err != nil {
+		return systemErrorResult, err
 	}
+	res, err := h.setupSession(ctx, user)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: res,
+	}, nil
+}
+
+func (h *Handler) PhoneLogin(ctx *ginx.Context, req PhoneReq) (ginx.Result, error) {
+	// 先查手机号再校验验证码，没注册的时候验证码还要留给注册用
 	user, err := h.userSvc.FindByPhone(ctx, req.Phone)
-	if errors.Is(err, dao.ErrPhoneNotFound) {
+	switch {
+	case errors.Is(err, dao.ErrPhoneNotFound):
 		// 返回特定错误
 		return phoneNotFoundResult, err
+	case err != nil:
+		return systemErrorResult, err
+	}
+	if res, err := h.verifyPhoneCode(ctx, req.Phone, req.Code); err != nil {
+		return res, err
 	}
 	res, err := h.setupSession(ctx, user)
 	if err != nil {
@@ -144,18 +291,45 @@ func (h *Handler) PhoneLogin(ctx *ginx.Context, req PhoneReq) (ginx.Result, erro
 }
 
 func (h *Handler) SendCode(ctx *ginx.Context, req SendCodeReq) (ginx.Result, error) {
-	err := h.verificationCodeSvc.Send(ctx, req.Phone)
-	if err != nil {
+	err := h.verificationCodeSvc.Send(ctx, req.Phone, ctx.ClientIP())
+	switch {
+	case errors.Is(err, service.ErrCodeSendTooFrequent):
+		return codeTooFrequentResult, nil
+	case err != nil:
 		return newVerificationErr(err), err
+	default:
+		return ginx.Result{}, nil
 	}
-	return ginx.Result{}, nil
 }
 
-func (h *Handler) PhoneRegister(ctx *ginx.Context, req PhoneReq) (ginx.Result, error) {
-	err := h.verificationCodeSvc.Verify(ctx, req.Phone, req.Code)
-	if err != nil {
-		err = errors.New("验证码错误")
+// verifyPhoneCode 校验不通过的时候返回对应的错误结果
+func (h *Handler) verifyPhoneCode(ctx context.Context, phone, code string) (ginx.Result, error) {
+	return h.verifyCodeResult(h.verificationCodeSvc.Verify(ctx, phone, code))
+}
+
+// verifyEmailCode 校验不通过的时候返回对应的错误结果
+func (h *Handler) verifyEmailCode(ctx context.Context, email, code string) (ginx.Result, error) {
+	return h.verifyCodeResult(h.emailLoginSvc.VerifyCode(ctx, email, code))
+}
+
+func (h *Handler) verifyCodeResult(err error) (ginx.Result, error) {
+	switch {
+	case err == nil:
+		return ginx.Result{}, nil
+	case errors.Is(err, service.ErrCodeExpired):
+		return codeExpiredResult, err
+	case errors.Is(err, service.ErrCodeVerifyTooManyTimes):
+		return codeTooManyTimesResult, err
+	case errors.Is(err, service.ErrVerificationCode):
 		return newVerificationErr(err), err
+	default:
+		return systemErrorResult, err
+	}
+}
+
+func (h *Handler) PhoneRegister(ctx *ginx.Context, req PhoneReq) (ginx.Result, error) {
+	if res, err := h.verifyPhoneCode(ctx, req.Phone, req.Code); err != nil {
+		return res, err
 	}
 	user, err := h.userSvc.CreateWithPhone(ctx, req.Phone)
 	if err != nil {
@@ -171,6 +345,13 @@ func (h *Handler) PhoneRegister(ctx *ginx.Context, req PhoneReq) (ginx.Result, e
 }
 
 func (h *Handler) Logout(ctx *ginx.Context) (ginx.Result, error) {
+	if sess, err := h.sp.Get(ctx); err == nil {
+		claims := sess.Claims()
+		err = h.deviceSvc.Logout(ctx, claims.Uid, claims.SSID)
+		if err != nil {
+			h.logger.Error("注销设备失败", elog.Int64("uid", claims.Uid), elog.FieldErr(err))
+		}
+	}
 	err := h.sp.Destroy(ctx)
 	if err != nil {
 		return systemErrorResult, nil
@@ -206,9 +387,10 @@ func (h *Handler) RefreshAccessToken(ctx *ginx.Context) (ginx.Result, error) {
 
 func (h *Handler) Profile(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
 	var (
-		eg errgroup.Group
-		u  domain.User
-		m  member.Member
+		eg  errgroup.Group
+		u   domain.User
+		m   member.Member
+		ids []domain.Identity
 	)
 	uid := sess.Claims().Uid
 	eg.Go(func() error {
@@ -226,6 +408,15 @@ func (h *Handler) Profile(ctx *ginx.Context, sess session.Session) (ginx.Result,
 		return nil
 	})
 
+	eg.Go(func() error {
+		var err error
+		ids, err = h.identitySvc.List(ctx, uid)
+		if err != nil {
+			h.logger.Error("查找用户绑定的登录方式失败", elog.FieldErr(err))
+		}
+		return nil
+	})
+
 	err := eg.Wait()
 	if err != nil {
 		return systemErrorResult, err
@@ -235,6 +426,9 @@ func (h *Handler) Profile(ctx *ginx.Context, sess session.Session) (ginx.Result,
 		Get("creator").
 		StringOrDefault("") == "true"
 	res.MemberDDL = m.EndAt
+	res.Identities = slice.Map(ids, func(idx int, src domain.Identity) Identity {
+		return newIdentity(src)
+	})
 	return ginx.Result{
 		Data: res,
 	}, nil
@@ -303,16 +497,69 @@ func (h *Handler) setupSession(ctx *ginx.Context, user domain.User) (Profile, er
 	permsVal, _ := json.Marshal(perms)
 	// redis 不能处理 map[string]map[string][string] 这种二层结构
 	sessData := map[string]any{"permission": permsVal}
-	_, err = session.NewSessionBuilder(ctx, user.Id).SetJwtData(jwtData).SetSessData(sessData).Build()
+	sess, err := session.NewSessionBuilder(ctx, user.Id).SetJwtData(jwtData).SetSessData(sessData).Build()
 	if err != nil {
 		return Profile{}, err
 	}
+	h.registerDevice(ctx, user.Id, sess.Claims().SSID)
 	res := newProfile(user)
 	res.IsCreator = isCreator
 	res.MemberDDL = memberDDL
 	return res, nil
 }
 
+// registerDevice 登记登录的设备，失败了也不影响登录
+func (h *Handler) registerDevice(ctx *ginx.Context, uid int64, ssid string) {
+	app, _ := ectx.AppFromCtx(ctx)
+	name := ctx.GetHeader("X-Device-Name")
+	if name == "" {
+		name = ctx.Request.UserAgent()
+	}
+	if runes := []rune(name); len(runes) > 256 {
+		name = string(runes[:256])
+	}
+	err := h.deviceSvc.Login(ctx, domain.Device{
+		Uid:  uid,
+		SSID: ssid,
+		App:  app,
+		Name: name,
+		IP:   ctx.ClientIP(),
+	})
+	if err != nil {
+		h.logger.Error("登记登录设备失败", elog.Int64("uid", uid), elog.FieldErr(err))
+	}
+}
+
+func (h *Handler) Devices(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	claims := sess.Claims()
+	devices, err := h.deviceSvc.List(ctx, claims.Uid)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{
+		Data: slice.Map(devices, func(idx int, src domain.Device) Device {
+			return newDevice(src, claims.SSID)
+		}),
+	}, nil
+}
+
+func (h *Handler) RevokeDevice(ctx *ginx.Context, req RevokeDeviceReq, sess session.Session) (ginx.Result, error) {
+	err := h.deviceSvc.Revoke(ctx, sess.Claims().Uid, req.Id)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
+func (h *Handler) RevokeAllDevices(ctx *ginx.Context, sess session.Session) (ginx.Result, error) {
+	claims := sess.Claims()
+	err := h.deviceSvc.RevokeAll(ctx, claims.Uid, claims.SSID)
+	if err != nil {
+		return systemErrorResult, err
+	}
+	return ginx.Result{}, nil
+}
+
 func (h *Handler) getMemberDDL(ctx context.Context, userID int64) int64 {
 	mem, err := h.memberSvc.GetMembershipInfo(ctx, userID)
 	if err != nil {
@@ -14,6 +14,34 @@ var (
 		Code: errs.PhoneNotFound.Code,
 		Msg:  errs.PhoneNotFound.Msg,
 	}
+	identityBoundResult = ginx.Result{
+		Code: errs.IdentityBound.Code,
+		Msg:  errs.IdentityBound.Msg,
+	}
+	lastIdentityResult = ginx.Result{
+		Code: errs.LastIdentity.Code,
+		Msg:  errs.LastIdentity.Msg,
+	}
+	invalidEmailResult = ginx.Result{
+		Code: errs.InvalidEmail.Code,
+		Msg:  errs.InvalidEmail.Msg,
+	}
+	invalidEmailLinkResult = ginx.Result{
+		Code: errs.InvalidEmailLink.Code,
+		Msg:  errs.InvalidEmailLink.Msg,
+	}
+	codeTooFrequentResult = ginx.Result{
+		Code: errs.CodeTooFrequent.Code,
+		Msg:  errs.CodeTooFrequent.Msg,
+	}
+	codeTooManyTimesResult = ginx.Result{
+		Code: errs.CodeTooManyTimes.Code,
+		Msg:  errs.CodeTooManyTimes.Msg,
+	}
+	codeExpiredResult = ginx.Result{
+		Code: errs.CodeExpired.Code,
+		Msg:  errs.CodeExpired.Msg,
+	}
 )
 
 func newVerificationErr(err error) ginx.Result {
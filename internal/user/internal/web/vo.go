@@ -29,6 +29,35 @@ type Profile struct {
 	// 毫秒数
 	MemberDDL int64  `json:"memberDDL,omitempty"`
 	Phone     string `json:"phone,omitempty"`
+	// 绑定的登录方式
+	Identities []Identity `json:"identities,omitempty"`
+}
+
+type Identity struct {
+	// wechat、phone 或者 email
+	Type string `json:"type"`
+	// 脱敏之后的值，微信不返回
+	Value string `json:"value,omitempty"`
+}
+
+func newIdentity(i domain.Identity) Identity {
+	res := Identity{Type: string(i.Type)}
+	switch i.Type {
+	case domain.IdentityTypePhone:
+		res.Value = maskPhoneNumber(i.Value)
+	case domain.IdentityTypeEmail:
+		res.Value = maskEmail(i.Value)
+	}
+	return res
+}
+
+// maskEmail 只保留用户名的第一个字符和域名，例如 a***@example.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	return email[:1] + "***" + email[at:]
 }
 
 func newProfile(u domain.User) Profile {
@@ -73,3 +102,49 @@ type PhoneReq struct {
 	Phone string `json:"phone"`
 	Code  string `json:"code"`
 }
+
+type SendEmailCodeReq struct {
+	Email string `json:"email"`
+}
+
+type EmailReq struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+type EmailLinkReq struct {
+	Token string `json:"token"`
+}
+
+type UnbindReq struct {
+	// wechat、phone 或者 email
+	Type string `json:"type"`
+}
+
+type Device struct {
+	Id   int64  `json:"id"`
+	App  uint   `json:"app"`
+	Name string `json:"name"`
+	IP   string `json:"ip"`
+	// 毫秒数
+	LastActive int64 `json:"lastActive"`
+	Ctime      int64 `json:"ctime"`
+	// 是不是发起请求的这个设备
+	Current bool `json:"current"`
+}
+
+func newDevice(d domain.Device, currentSSID string) Device {
+	return Device{
+		Id:         d.Id,
+		App:        d.App,
+		Name:       d.Name,
+		IP:         d.IP,
+		LastActive: d.LastActive,
+		Ctime:      d.Ctime,
+		Current:    d.SSID == currentSSID,
+	}
+}
+
+type RevokeDeviceReq struct {
+	Id int64 `json:"id"`
+}
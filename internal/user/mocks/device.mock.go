@@ -0,0 +1,271 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./device.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./device.go -package=usermocks -typed=true -destination=../../mocks/device.mock.go DeviceService
+//
+
+// Package usermocks is a generated GoMock package.
+package usermocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/ecodeclub/webook/internal/user/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDeviceService is a mock of DeviceService interface.
+type MockDeviceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceServiceMockRecorder is the mock recorder for MockDeviceService.
+type MockDeviceServiceMockRecorder struct {
+	mock *MockDeviceService
+}
+
+// NewMockDeviceService creates a new mock instance.
+func NewMockDeviceService(ctrl *gomock.Controller) *MockDeviceService {
+	mock := &MockDeviceService{ctrl: ctrl}
+	mock.recorder = &MockDeviceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceService) EXPECT() *MockDeviceServiceMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockDeviceService) Check(ctx context.Context, ssid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, ssid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockDeviceServiceMockRecorder) Check(ctx, ssid any) *MockDeviceServiceCheckCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockDeviceService)(nil).Check), ctx, ssid)
+	return &MockDeviceServiceCheckCall{Call: call}
+}
+
+// MockDeviceServiceCheckCall wrap *gomock.Call
+type MockDeviceServiceCheckCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeviceServiceCheckCall) Return(arg0 error) *MockDeviceServiceCheckCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeviceServiceCheckCall) Do(f func(context.Context, string) error) *MockDeviceServiceCheckCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeviceServiceCheckCall) DoAndReturn(f func(context.Context, string) error) *MockDeviceServiceCheckCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// List mocks base method.
+func (m *MockDeviceService) List(ctx context.Context, uid int64) ([]domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, uid)
+	ret0, _ := ret[0].([]domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockDeviceServiceMockRecorder) List(ctx, uid any) *MockDeviceServiceListCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockDeviceService)(nil).List), ctx, uid)
+	return &MockDeviceServiceListCall{Call: call}
+}
+
+// MockDeviceServiceListCall wrap *gomock.Call
+type MockDeviceServiceListCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeviceServiceListCall) Return(arg0 []domain.Device, arg1 error) *MockDeviceServiceListCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeviceServiceListCall) Do(f func(context.Context, int64) ([]domain.Device, error)) *MockDeviceServiceListCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeviceServiceListCall) DoAndReturn(f func(context.Context, int64) ([]domain.Device, error)) *MockDeviceServiceListCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Login mocks base method.
+func (m *MockDeviceService) Login(ctx context.Context, d domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockDeviceServiceMockRecorder) Login(ctx, d any) *MockDeviceServiceLoginCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockDeviceService)(nil).Login), ctx, d)
+	return &MockDeviceServiceLoginCall{Call: call}
+}
+
+// MockDeviceServiceLoginCall wrap *gomock.Call
+type MockDeviceServiceLoginCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeviceServiceLoginCall) Return(arg0 error) *MockDeviceServiceLoginCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeviceServiceLoginCall) Do(f func(context.Context, domain.Device) error) *MockDeviceServiceLoginCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeviceServiceLoginCall) DoAndReturn(f func(context.Context, domain.Device) error) *MockDeviceServiceLoginCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Logout mocks base method.
+func (m *MockDeviceService) Logout(ctx context.Context, uid int64, ssid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, uid, ssid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockDeviceServiceMockRecorder) Logout(ctx, uid, ssid any) *MockDeviceServiceLogoutCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockDeviceService)(nil).Logout), ctx, uid, ssid)
+	return &MockDeviceServiceLogoutCall{Call: call}
+}
+
+// MockDeviceServiceLogoutCall wrap *gomock.Call
+type MockDeviceServiceLogoutCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeviceServiceLogoutCall) Return(arg0 error) *MockDeviceServiceLogoutCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeviceServiceLogoutCall) Do(f func(context.Context, int64, string) error) *MockDeviceServiceLogoutCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeviceServiceLogoutCall) DoAndReturn(f func(context.Context, int64, string) error) *MockDeviceServiceLogoutCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Revoke mocks base method.
+func (m *MockDeviceService) Revoke(ctx context.Context, uid, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, uid, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockDeviceServiceMockRecorder) Revoke(ctx, uid, id any) *MockDeviceServiceRevokeCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockDeviceService)(nil).Revoke), ctx, uid, id)
+	return &MockDeviceServiceRevokeCall{Call: call}
+}
+
+// MockDeviceServiceRevokeCall wrap *gomock.Call
+type MockDeviceServiceRevokeCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeviceServiceRevokeCall) Return(arg0 error) *MockDeviceServiceRevokeCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeviceServiceRevokeCall) Do(f func(context.Context, int64, int64) error) *MockDeviceServiceRevokeCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeviceServiceRevokeCall) DoAndReturn(f func(context.Context, int64, int64) error) *MockDeviceServiceRevokeCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// RevokeAll mocks base method.
+func (m *MockDeviceService) RevokeAll(ctx context.Context, uid int64, except string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAll", ctx, uid, except)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAll indicates an expected call of RevokeAll.
+func (mr *MockDeviceServiceMockRecorder) RevokeAll(ctx, uid, except any) *MockDeviceServiceRevokeAllCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAll", reflect.TypeOf((*MockDeviceService)(nil).RevokeAll), ctx, uid, except)
+	return &MockDeviceServiceRevokeAllCall{Call: call}
+}
+
+// MockDeviceServiceRevokeAllCall wrap *gomock.Call
+type MockDeviceServiceRevokeAllCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeviceServiceRevokeAllCall) Return(arg0 error) *MockDeviceServiceRevokeAllCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeviceServiceRevokeAllCall) Do(f func(context.Context, int64, string) error) *MockDeviceServiceRevokeAllCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeviceServiceRevokeAllCall) DoAndReturn(f func(context.Context, int64, string) error) *MockDeviceServiceRevokeAllCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -158,6 +158,45 @@ func (c *MockUserServiceFindByPhoneCall) DoAndReturn(f func(context.Context, str
 	return c
 }
 
+// FindOrCreateByEmail mocks base method.
+func (m *MockUserService) FindOrCreateByEmail(ctx context.Context, email string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOrCreateByEmail", ctx, email)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOrCreateByEmail indicates an expected call of FindOrCreateByEmail.
+func (mr *MockUserServiceMockRecorder) FindOrCreateByEmail(ctx, email any) *MockUserServiceFindOrCreateByEmailCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrCreateByEmail", reflect.TypeOf((*MockUserService)(nil).FindOrCreateByEmail), ctx, email)
+	return &MockUserServiceFindOrCreateByEmailCall{Call: call}
+}
+
+// MockUserServiceFindOrCreateByEmailCall wrap *gomock.Call
+type MockUserServiceFindOrCreateByEmailCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockUserServiceFindOrCreateByEmailCall) Return(arg0 domain.User, arg1 error) *MockUserServiceFindOrCreateByEmailCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockUserServiceFindOrCreateByEmailCall) Do(f func(context.Context, string) (domain.User, error)) *MockUserServiceFindOrCreateByEmailCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockUserServiceFindOrCreateByEmailCall) DoAndReturn(f func(context.Context, string) (domain.User, error)) *MockUserServiceFindOrCreateByEmailCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // FindOrCreateByWechat mocks base method.
 func (m *MockUserService) FindOrCreateByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error) {
 	m.ctrl.T.Helper()
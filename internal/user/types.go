@@ -22,15 +22,23 @@ import (
 
 // Handler 暴露出去给 ioc 使用
 type Handler = web.Handler
+
+// CheckDeviceMiddlewareBuilder 放在登录校验之后，拒绝已经被踢下线的会话
+type CheckDeviceMiddlewareBuilder = web.CheckDeviceMiddlewareBuilder
 type User = domain.User
 type WechatInfo = domain.WechatInfo
 
 // UserService 方便测试
 type UserService = service.UserService
 
+// PrivacyService 导出和注销账号的时候使用
+type PrivacyService = service.PrivacyService
+
 type Module struct {
-	Hdl *Handler
-	Svc UserService
+	Hdl              *Handler
+	Svc              UserService
+	DeviceMiddleware *CheckDeviceMiddlewareBuilder
+	PrivacySvc       PrivacyService
 }
 
 // 规避 wire 的坑
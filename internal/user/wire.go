@@ -20,14 +20,18 @@ import (
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
 	"github.com/ecodeclub/webook/internal/sms/client"
 	"github.com/ecodeclub/webook/internal/user/internal/repository"
 	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/user/internal/service"
+	"github.com/ecodeclub/webook/internal/user/internal/web"
 	"github.com/ego-component/egorm"
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 )
 
 var ProviderSet = wire.NewSet(
@@ -38,24 +42,46 @@ var ProviderSet = wire.NewSet(
 	initWechatMiniOAuthService,
 	initRegistrationEventProducer,
 	service.NewUserService,
-	repository.NewCachedUserRepository)
+	repository.NewCachedUserRepository,
+	dao.NewGORMIdentityDAO,
+	repository.NewIdentityRepository,
+	service.NewIdentityService)
+
+var deviceSet = wire.NewSet(
+	dao.NewGORMDeviceDAO,
+	cache.NewDeviceCache,
+	repository.NewDeviceRepository,
+	initDeviceService,
+	web.NewCheckDeviceMiddlewareBuilder,
+)
 
 var verificationCodeRepoSet = wire.NewSet(
 	cache.NewVerificationCodeCache,
 	repository.NewVerificationCodeRepository,
 )
 
+var emailLoginSet = wire.NewSet(
+	cache.NewEmailCodeCache,
+	repository.NewEmailCodeRepository,
+	initEmailLoginService,
+)
+
 func InitModule(db *egorm.Component,
 	cache ecache.Cache,
+	cmd redis.Cmdable,
 	q mq.MQ, creators []string,
 	memberSvc *member.Module,
 	sp session.Provider,
 	permissionSvc *permission.Module,
 	smsClient client.Client,
+	emailClient email.Service,
 ) *Module {
 	wire.Build(
 		verificationCodeRepoSet,
+		emailLoginSet,
+		deviceSet,
 		initVerificationCodeSvc,
+		service.NewPrivacyService,
 		ProviderSet,
 		wire.FieldsOf(new(*member.Module), "Svc"),
 		wire.FieldsOf(new(*permission.Module), "Svc"),
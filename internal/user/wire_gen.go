@@ -10,35 +10,53 @@ import (
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
 	"github.com/ecodeclub/webook/internal/sms/client"
 	"github.com/ecodeclub/webook/internal/user/internal/repository"
 	"github.com/ecodeclub/webook/internal/user/internal/repository/cache"
+	"github.com/ecodeclub/webook/internal/user/internal/repository/dao"
 	"github.com/ecodeclub/webook/internal/user/internal/service"
+	"github.com/ecodeclub/webook/internal/user/internal/web"
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 // Injectors from wire.go:
 
-func InitModule(db *gorm.DB, cache2 ecache.Cache, q mq.MQ, creators []string, memberSvc *member.Module, sp session.Provider, permissionSvc *permission.Module, smsClient client.Client) *Module {
+func InitModule(db *gorm.DB, cache2 ecache.Cache, cmd redis.Cmdable, q mq.MQ, creators []string, memberSvc *member.Module, sp session.Provider, permissionSvc *permission.Module, smsClient client.Client, emailClient email.Service) *Module {
 	userWechatWebOAuth2Service := initWechatWebOAuthService(cache2)
 	userWechatMiniOAuth2Service := initWechatMiniOAuthService()
 	userDAO := initDAO(db)
 	userCache := cache.NewUserECache(cache2)
 	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
+	identityDAO := dao.NewGORMIdentityDAO(db)
+	identityRepository := repository.NewIdentityRepository(identityDAO, userCache)
 	registrationEventProducer := initRegistrationEventProducer(q)
-	userService := service.NewUserService(userRepository, registrationEventProducer)
+	userService := service.NewUserService(userRepository, identityRepository, registrationEventProducer)
 	serviceService := memberSvc.Svc
-	verificationCodeCache := cache.NewVerificationCodeCache(cache2)
+	verificationCodeCache := cache.NewVerificationCodeCache(cmd)
 	verificationCodeRepo := repository.NewVerificationCodeRepository(verificationCodeCache)
 	verificationCodeSvc := initVerificationCodeSvc(smsClient, verificationCodeRepo)
+	identityService := service.NewIdentityService(identityRepository, userRepository)
+	emailCodeCache := cache.NewEmailCodeCache(cache2, cmd)
+	emailCodeRepo := repository.NewEmailCodeRepository(emailCodeCache)
+	emailLoginService := initEmailLoginService(emailClient, emailCodeRepo)
+	deviceDAO := dao.NewGORMDeviceDAO(db)
+	deviceCache := cache.NewDeviceCache(cmd)
+	deviceRepository := repository.NewDeviceRepository(deviceDAO, deviceCache)
+	deviceService := initDeviceService(deviceRepository)
 	service2 := permissionSvc.Svc
-	handler := iniHandler(userWechatWebOAuth2Service, userWechatMiniOAuth2Service, userService, serviceService, sp, verificationCodeSvc, service2, creators)
+	handler := iniHandler(userWechatWebOAuth2Service, userWechatMiniOAuth2Service, userService, serviceService, sp, verificationCodeSvc, identityService, emailLoginService, deviceService, service2, creators)
+	checkDeviceMiddlewareBuilder := web.NewCheckDeviceMiddlewareBuilder(deviceService)
+	privacyService := service.NewPrivacyService(userRepository, identityRepository, deviceService)
 	module := &Module{
-		Hdl: handler,
-		Svc: userService,
+		Hdl:              handler,
+		Svc:              userService,
+		DeviceMiddleware: checkDeviceMiddlewareBuilder,
+		PrivacySvc:       privacyService,
 	}
 	return module
 }
@@ -49,7 +67,11 @@ var ProviderSet = wire.NewSet(
 	iniHandler, cache.NewUserECache, initDAO,
 	initWechatWebOAuthService,
 	initWechatMiniOAuthService,
-	initRegistrationEventProducer, service.NewUserService, repository.NewCachedUserRepository,
+	initRegistrationEventProducer, service.NewUserService, repository.NewCachedUserRepository, dao.NewGORMIdentityDAO, repository.NewIdentityRepository, service.NewIdentityService,
 )
 
+var deviceSet = wire.NewSet(dao.NewGORMDeviceDAO, cache.NewDeviceCache, repository.NewDeviceRepository, initDeviceService, web.NewCheckDeviceMiddlewareBuilder)
+
 var verificationCodeRepoSet = wire.NewSet(cache.NewVerificationCodeCache, repository.NewVerificationCodeRepository)
+
+var emailLoginSet = wire.NewSet(cache.NewEmailCodeCache, repository.NewEmailCodeRepository, initEmailLoginService)
@@ -18,10 +18,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/ecodeclub/webook/internal/comment"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/kbase"
 	"github.com/ecodeclub/webook/internal/label"
 
 	"github.com/ecodeclub/webook/internal/order"
+	"github.com/ecodeclub/webook/internal/recon"
 
 	"github.com/ecodeclub/webook/internal/search"
 
@@ -66,6 +69,9 @@ func InitAdminServer(prj *project.AdminHandler,
 	searchHdl *search.AdminHandler,
 	labelHdl *label.AdminHandler,
 	kbaseHdl *kbase.AdminHandler,
+	commentHdl *comment.AdminHandler,
+	reconHdl *recon.AdminHandler,
+	couponHdl *coupon.AdminHandler,
 ) AdminServer {
 	res := egin.Load("admin").Build()
 	res.Use(cors.New(cors.Config{
@@ -106,6 +112,9 @@ func InitAdminServer(prj *project.AdminHandler,
 	searchHdl.PrivateRoutes(res.Engine)
 	labelHdl.PrivateRoutes(res.Engine)
 	kbaseHdl.PrivateRoutes(res.Engine)
+	commentHdl.PrivateRoutes(res.Engine)
+	reconHdl.PrivateRoutes(res.Engine)
+	couponHdl.PrivateRoutes(res.Engine)
 	return res
 }
 
@@ -0,0 +1,25 @@
+package ioc
+
+import (
+	"github.com/ecodeclub/webook/internal/email"
+	"github.com/ecodeclub/webook/internal/email/aliyun"
+	"github.com/gotomicro/ego/core/econf"
+)
+
+func initAliEmailClient() email.Service {
+	type Config struct {
+		AccessID     string `yaml:"accessId"`
+		AccessSecret string `yaml:"accessSecret"`
+		AccountName  string `yaml:"accountName"`
+	}
+	var cfg Config
+	err := econf.UnmarshalKey("email.ali", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	cli, err := aliyun.NewAliyunDirectMailAPI(cfg.AccessID, cfg.AccessSecret, cfg.AccountName)
+	if err != nil {
+		panic(err)
+	}
+	return cli
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ecodeclub/ginx/middlewares/activelimit/locallimit"
 	"github.com/ecodeclub/webook/internal/interactive"
 
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/marketing"
 	"github.com/ecodeclub/webook/internal/payment"
@@ -47,8 +48,11 @@ import (
 	"github.com/ecodeclub/webook/internal/order"
 	"github.com/ecodeclub/webook/internal/product"
 
+	"github.com/ecodeclub/webook/internal/notification"
 	"github.com/ecodeclub/webook/internal/pkg/middleware"
+	"github.com/ecodeclub/webook/internal/privacy"
 	"github.com/ecodeclub/webook/internal/skill"
+	"github.com/ecodeclub/webook/internal/subscription"
 
 	"github.com/ecodeclub/webook/internal/cases"
 
@@ -68,6 +72,7 @@ import (
 
 func initGinxServer(sp session.Provider,
 	checkMembershipMiddleware *middleware.CheckMembershipMiddlewareBuilder,
+	checkDeviceMiddleware *user.CheckDeviceMiddlewareBuilder,
 	localActiveLimiterMiddleware *locallimit.LocalActiveLimit,
 	// 这个暂时用不上
 	checkPermissionMiddleware *middleware.CheckPermissionMiddlewareBuilder,
@@ -101,6 +106,10 @@ func initGinxServer(sp session.Provider,
 	journeyHdl *interview.JourneyHandler,
 	offerHdl *interview.OfferHandler,
 	companyHdl *company.Handler,
+	privacyHdl *privacy.Handler,
+	notificationHdl *notification.Handler,
+	couponHdl *coupon.Handler,
+	subscriptionHdl *subscription.Handler,
 ) *egin.Component {
 	session.SetDefaultProvider(sp)
 	res := egin.Load("web").Build()
@@ -111,6 +120,7 @@ func initGinxServer(sp session.Provider,
 		AllowCredentials: true,
 		AllowHeaders: []string{"X-Timestamp",
 			"X-APP",
+			"X-Device-Name",
 			"Authorization", "Content-Type"},
 		AllowOriginFunc: func(origin string) bool {
 			if strings.HasPrefix(origin, "http://localhost") {
@@ -128,6 +138,7 @@ func initGinxServer(sp session.Provider,
 
 	// 微信支付的回调不需要安全校验机制
 	paymentHdl.PublicRoutes(res.Engine)
+	subscriptionHdl.PublicRoutes(res.Engine)
 	// 虽然叫做 NonSense，但是我还是得告诉你，这是一个安全校验机制
 	// 但是我并不能在开源里面放出来，因为知道了如何校验，就知道了如何破解
 	// 虽然理论上可以用 plugin 机制，但是 plugin 机制比较容易遇到不兼容的问题
@@ -148,6 +159,8 @@ func initGinxServer(sp session.Provider,
 
 	// 登录校验
 	res.Use(session.CheckLoginMiddleware())
+	// 被踢下线的会话在 JWT 过期之前也不能再用
+	res.Use(checkDeviceMiddleware.Build())
 	user.PrivateRoutes(res.Engine)
 	mockInterviewHdl.PrivateRoutes(res.Engine)
 	pHdl.PrivateRoutes(res.Engine)
@@ -165,6 +178,10 @@ func initGinxServer(sp session.Provider,
 	materialHdl.PrivateRoutes(res.Engine)
 	journeyHdl.PrivateRoutes(res.Engine)
 	companyHdl.PrivateRoutes(res.Engine)
+	privacyHdl.PrivateRoutes(res.Engine)
+	notificationHdl.PrivateRoutes(res.Engine)
+	couponHdl.PrivateRoutes(res.Engine)
+	subscriptionHdl.PrivateRoutes(res.Engine)
 
 	// 权限校验
 
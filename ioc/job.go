@@ -21,7 +21,9 @@ import (
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/order"
 	"github.com/ecodeclub/webook/internal/payment"
+	"github.com/ecodeclub/webook/internal/privacy"
 	"github.com/ecodeclub/webook/internal/recon"
+	"github.com/ecodeclub/webook/internal/subscription"
 	"github.com/gotomicro/ego/core/elog"
 	"github.com/gotomicro/ego/task/ecron"
 )
@@ -29,15 +31,23 @@ import (
 // initCronJobs 定时任务
 func initCronJobs(
 	oJob *order.CloseTimeoutOrdersJob,
+	orJob *order.SendOrderRefundEventsJob,
 	cJob *credit.CloseTimeoutLockedCreditsJob,
 	pJob *payment.SyncWechatOrderJob,
 	rJob *recon.SyncPaymentAndOrderJob,
+	wbJob *recon.ReconcileWechatBillJob,
+	dJob *privacy.ExecuteDeletionJob,
+	sJob *subscription.RenewSubscriptionsJob,
 ) []ecron.Ecron {
 	return []ecron.Ecron{
 		ecron.Load("cron.closeTimeoutOrder").Build(ecron.WithJob(funcJobWrapper(oJob))),
+		ecron.Load("cron.sendOrderRefundEvent").Build(ecron.WithJob(funcJobWrapper(orJob))),
 		ecron.Load("cron.unlockTimeoutCredit").Build(ecron.WithJob(funcJobWrapper(cJob))),
 		ecron.Load("cron.syncWechatOrder").Build(ecron.WithJob(funcJobWrapper(pJob))),
 		ecron.Load("cron.syncPaymentAndOrder").Build(ecron.WithJob(funcJobWrapper(rJob))),
+		ecron.Load("cron.reconcileWechatBill").Build(ecron.WithJob(funcJobWrapper(wbJob))),
+		ecron.Load("cron.executeAccountDeletion").Build(ecron.WithJob(funcJobWrapper(dJob))),
+		ecron.Load("cron.renewSubscriptions").Build(ecron.WithJob(funcJobWrapper(sJob))),
 	}
 }
 
@@ -0,0 +1,28 @@
+package ioc
+
+import (
+	"github.com/ecodeclub/webook/internal/pkg/moderation"
+	"github.com/gotomicro/ego/core/econf"
+)
+
+// initModerationFilter 评论、面经以及 AI 共用的内容审核。
+// 没有配置 provider.endpoint 的时候只使用本地词典
+func initModerationFilter() moderation.Filter {
+	type Config struct {
+		Dict     moderation.Dict `yaml:"dict"`
+		Provider struct {
+			Endpoint string `yaml:"endpoint"`
+			Token    string `yaml:"token"`
+		} `yaml:"provider"`
+	}
+	var cfg Config
+	err := econf.UnmarshalKey("moderation", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	var provider moderation.Provider
+	if cfg.Provider.Endpoint != "" {
+		provider = moderation.NewHTTPProvider(cfg.Provider.Endpoint, cfg.Provider.Token)
+	}
+	return moderation.NewDictFilter(cfg.Dict, provider)
+}
@@ -0,0 +1,41 @@
+package ioc
+
+import (
+	"github.com/ecodeclub/webook/internal/ai"
+	"github.com/ecodeclub/webook/internal/comment"
+	"github.com/ecodeclub/webook/internal/credit"
+	"github.com/ecodeclub/webook/internal/interactive"
+	"github.com/ecodeclub/webook/internal/interview"
+	"github.com/ecodeclub/webook/internal/notification"
+	"github.com/ecodeclub/webook/internal/order"
+	"github.com/ecodeclub/webook/internal/privacy"
+	"github.com/ecodeclub/webook/internal/resume"
+	"github.com/ecodeclub/webook/internal/user"
+)
+
+// initPrivacyHooks 导出个人数据以及注销账号涉及的模块。
+// 注销的时候按照这个顺序清除数据，user 必须放在最后，
+// 它会踢掉所有的设备并且匿名化账号，前面的模块失败了还可以重试
+func initPrivacyHooks(
+	resumeModule *resume.Module,
+	interviewModule *interview.Module,
+	commentModule *comment.Module,
+	intrModule *interactive.Module,
+	creditModule *credit.Module,
+	orderModule *order.Module,
+	aiModule *ai.Module,
+	notificationModule *notification.Module,
+	userModule *user.Module,
+) []privacy.Hook {
+	return []privacy.Hook{
+		resumeModule.PrivacySvc,
+		interviewModule.PrivacySvc,
+		commentModule.PrivacySvc,
+		intrModule.PrivacySvc,
+		creditModule.PrivacySvc,
+		orderModule.PrivacySvc,
+		aiModule.PrivacySvc,
+		notificationModule.PrivacySvc,
+		userModule.PrivacySvc,
+	}
+}
@@ -18,20 +18,24 @@ import (
 	"github.com/ecodeclub/ecache"
 	"github.com/ecodeclub/ginx/session"
 	"github.com/ecodeclub/mq-api"
+	"github.com/ecodeclub/webook/internal/email"
 	"github.com/ecodeclub/webook/internal/member"
 	"github.com/ecodeclub/webook/internal/permission"
 	"github.com/ecodeclub/webook/internal/sms/client"
 	"github.com/ecodeclub/webook/internal/user"
 	"github.com/ego-component/egorm"
 	"github.com/gotomicro/ego/core/econf"
+	"github.com/redis/go-redis/v9"
 )
 
 func InitUserModule(db *egorm.Component,
 	sp session.Provider,
 	ec ecache.Cache,
+	cmd redis.Cmdable,
 	q mq.MQ,
 	memModule *member.Module,
 	smsClient client.Client,
+	emailClient email.Service,
 	perm *permission.Module) *user.Module {
 	type UserConfig struct {
 		Creators []string `json:"creators"`
@@ -41,5 +45,5 @@ func InitUserModule(db *egorm.Component,
 	if err != nil {
 		panic(err)
 	}
-	return user.InitModule(db, ec, q, cfg.Creators, memModule, sp, perm, smsClient)
+	return user.InitModule(db, ec, cmd, q, cfg.Creators, memModule, sp, perm, smsClient, emailClient)
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/ecodeclub/webook/internal/comment"
 	"github.com/ecodeclub/webook/internal/company"
 	"github.com/ecodeclub/webook/internal/cos"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/feedback"
 	"github.com/ecodeclub/webook/internal/interactive"
@@ -32,10 +33,12 @@ import (
 	"github.com/ecodeclub/webook/internal/marketing"
 	"github.com/ecodeclub/webook/internal/material"
 	"github.com/ecodeclub/webook/internal/member"
+	"github.com/ecodeclub/webook/internal/notification"
 	"github.com/ecodeclub/webook/internal/order"
 	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/ecodeclub/webook/internal/permission"
 	"github.com/ecodeclub/webook/internal/pkg/middleware"
+	"github.com/ecodeclub/webook/internal/privacy"
 	"github.com/ecodeclub/webook/internal/product"
 	"github.com/ecodeclub/webook/internal/project"
 	baguwen "github.com/ecodeclub/webook/internal/question"
@@ -45,6 +48,7 @@ import (
 	"github.com/ecodeclub/webook/internal/roadmap"
 	"github.com/ecodeclub/webook/internal/search"
 	"github.com/ecodeclub/webook/internal/skill"
+	"github.com/ecodeclub/webook/internal/subscription"
 	"github.com/ecodeclub/webook/internal/user"
 	"github.com/google/wire"
 )
@@ -58,10 +62,11 @@ func InitApp() (*App, error) {
 		cos.InitHandler,
 		baguwen.InitModule,
 		initAliSMSClient,
+		initAliEmailClient,
 		wire.FieldsOf(new(*baguwen.Module),
 			"AdminHdl", "AdminSetHdl", "Hdl", "QsHdl"),
 		InitUserModule,
-		wire.FieldsOf(new(*user.Module), "Hdl"),
+		wire.FieldsOf(new(*user.Module), "Hdl", "DeviceMiddleware"),
 		label.InitModule,
 		wire.FieldsOf(new(*label.Module), "AdminHandler", "Handler"),
 		cases.InitModule,
@@ -74,8 +79,10 @@ func InitApp() (*App, error) {
 		middleware.NewCheckMembershipMiddlewareBuilder,
 		product.InitModule,
 		wire.FieldsOf(new(*product.Module), "Hdl"),
+		coupon.InitModule,
+		wire.FieldsOf(new(*coupon.Module), "Hdl", "AdminHdl"),
 		order.InitModule,
-		wire.FieldsOf(new(*order.Module), "Hdl", "AdminHandler", "CloseTimeoutOrdersJob"),
+		wire.FieldsOf(new(*order.Module), "Hdl", "AdminHandler", "CloseTimeoutOrdersJob", "SendOrderRefundEventsJob"),
 		payment.InitModule,
 		wire.FieldsOf(new(*payment.Module), "Hdl", "SyncWechatOrderJob"),
 		credit.InitModule,
@@ -83,7 +90,9 @@ func InitApp() (*App, error) {
 		project.InitModule,
 		wire.FieldsOf(new(*project.Module), "AdminHdl", "Hdl"),
 		recon.InitModule,
-		wire.FieldsOf(new(*recon.Module), "SyncPaymentAndOrderJob"),
+		wire.FieldsOf(new(*recon.Module), "SyncPaymentAndOrderJob", "ReconcileWechatBillJob", "AdminHdl"),
+		subscription.InitModule,
+		wire.FieldsOf(new(*subscription.Module), "Hdl", "RenewSubscriptionsJob"),
 		marketing.InitModule,
 		wire.FieldsOf(new(*marketing.Module), "AdminHdl", "Hdl"),
 		interactive.InitModule,
@@ -96,6 +105,7 @@ func InitApp() (*App, error) {
 		roadmap.InitModule,
 		wire.FieldsOf(new(*roadmap.Module), "Hdl", "AdminHdl"),
 		InitGrpcClient,
+		initModerationFilter,
 		ai.InitModule,
 		bff.InitModule,
 		wire.FieldsOf(new(*bff.Module), "Hdl"),
@@ -105,7 +115,7 @@ func InitApp() (*App, error) {
 		review.InitModule,
 		wire.FieldsOf(new(*review.Module), "Hdl", "AdminHdl"),
 		comment.InitModule,
-		wire.FieldsOf(new(*comment.Module), "Hdl"),
+		wire.FieldsOf(new(*comment.Module), "Hdl", "AdminHdl"),
 		material.InitModule,
 		wire.FieldsOf(new(*material.Module), "Hdl", "AdminHdl"),
 		interview.InitModule,
@@ -114,6 +124,11 @@ func InitApp() (*App, error) {
 		wire.FieldsOf(new(*company.Module), "Hdl", "AdminHdl"),
 		kbase.InitModule,
 		wire.FieldsOf(new(*kbase.Module), "AdminHdl"),
+		notification.InitModule,
+		wire.FieldsOf(new(*notification.Module), "Hdl"),
+		initPrivacyHooks,
+		privacy.InitModule,
+		wire.FieldsOf(new(*privacy.Module), "Hdl", "ExecuteDeletionJob"),
 
 		initLocalActiveLimiterBuilder,
 		initCronJobs,
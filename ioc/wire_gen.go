@@ -13,6 +13,7 @@ import (
 	"github.com/ecodeclub/webook/internal/comment"
 	"github.com/ecodeclub/webook/internal/company"
 	"github.com/ecodeclub/webook/internal/cos"
+	"github.com/ecodeclub/webook/internal/coupon"
 	"github.com/ecodeclub/webook/internal/credit"
 	"github.com/ecodeclub/webook/internal/feedback"
 	"github.com/ecodeclub/webook/internal/interactive"
@@ -22,10 +23,12 @@ import (
 	"github.com/ecodeclub/webook/internal/marketing"
 	"github.com/ecodeclub/webook/internal/material"
 	"github.com/ecodeclub/webook/internal/member"
+	"github.com/ecodeclub/webook/internal/notification"
 	"github.com/ecodeclub/webook/internal/order"
 	"github.com/ecodeclub/webook/internal/payment"
 	"github.com/ecodeclub/webook/internal/permission"
 	"github.com/ecodeclub/webook/internal/pkg/middleware"
+	"github.com/ecodeclub/webook/internal/privacy"
 	"github.com/ecodeclub/webook/internal/product"
 	"github.com/ecodeclub/webook/internal/project"
 	baguwen "github.com/ecodeclub/webook/internal/question"
@@ -35,6 +38,7 @@ import (
 	"github.com/ecodeclub/webook/internal/roadmap"
 	"github.com/ecodeclub/webook/internal/search"
 	"github.com/ecodeclub/webook/internal/skill"
+	"github.com/ecodeclub/webook/internal/subscription"
 	"github.com/google/wire"
 )
 
@@ -51,18 +55,22 @@ func InitApp() (*App, error) {
 	}
 	service := module.Svc
 	checkMembershipMiddlewareBuilder := middleware.NewCheckMembershipMiddlewareBuilder(service)
-	localActiveLimit := initLocalActiveLimiterBuilder()
+	cache := InitCache(cmdable)
+	client := initAliSMSClient()
+	emailService := initAliEmailClient()
 	permissionModule, err := permission.InitModule(db, mq)
 	if err != nil {
 		return nil, err
 	}
+	userModule := InitUserModule(db, provider, cache, cmdable, mq, module, client, emailService, permissionModule)
+	checkDeviceMiddlewareBuilder := userModule.DeviceMiddleware
+	localActiveLimit := initLocalActiveLimiterBuilder()
 	serviceService := permissionModule.Svc
 	checkPermissionMiddlewareBuilder := middleware.NewCheckPermissionMiddlewareBuilder(serviceService)
 	interactiveModule, err := interactive.InitModule(db, mq)
 	if err != nil {
 		return nil, err
 	}
-	cache := InitCache(cmdable)
 	typedClient := InitES()
 	baguwenModule, err := baguwen.InitModule(db, interactiveModule, cache, typedClient, permissionModule, module, provider, mq)
 	if err != nil {
@@ -72,8 +80,6 @@ func InitApp() (*App, error) {
 	questionSetHandler := baguwenModule.QsHdl
 	labelModule := label.InitModule(db)
 	webHandler := labelModule.Handler
-	client := initAliSMSClient()
-	userModule := InitUserModule(db, provider, cache, mq, module, client, permissionModule)
 	handler2 := userModule.Hdl
 	config := InitCosConfig()
 	handler3 := cos.InitHandler(config)
@@ -85,7 +91,8 @@ func InitApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	aiModule, err := ai.InitModule(db, creditModule, mq, serviceClient)
+	filter := initModerationFilter()
+	aiModule, err := ai.InitModule(db, cache, cmdable, creditModule, module, mq, serviceClient, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +118,11 @@ func InitApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	orderModule, err := order.InitModule(db, cache, mq, paymentModule, productModule, creditModule)
+	couponModule, err := coupon.InitModule(db, mq)
+	if err != nil {
+		return nil, err
+	}
+	orderModule, err := order.InitModule(db, cache, mq, paymentModule, productModule, creditModule, couponModule)
 	if err != nil {
 		return nil, err
 	}
@@ -152,9 +163,9 @@ func InitApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
-	reviewModule := review.InitModule(db, interactiveModule, companyModule, mq, provider, cache)
+	reviewModule := review.InitModule(db, interactiveModule, companyModule, mq, provider, cache, filter)
 	handler18 := reviewModule.Hdl
-	commentModule, err := comment.InitModule(db, mq, userModule)
+	commentModule, err := comment.InitModule(db, mq, userModule, interactiveModule, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +182,24 @@ func InitApp() (*App, error) {
 	interviewJourneyHandler := interviewModule.JourneyHdl
 	offerHandler := interviewModule.OfferHdl
 	handler21 := companyModule.Hdl
-	component := initGinxServer(provider, checkMembershipMiddlewareBuilder, localActiveLimit, checkPermissionMiddlewareBuilder, handler, questionSetHandler, webHandler, handler2, handler3, handler4, handler5, handler6, handler7, handler8, handler9, handler10, handler11, handler12, handler13, handler14, handler15, handler16, caseSetHandler, examineHandler, projectHandler, analysisHandler, handler17, mockInterviewHandler, handler18, handler19, handler20, interviewJourneyHandler, offerHandler, handler21)
+	notificationModule, err := notification.InitModule(db, mq, userModule)
+	if err != nil {
+		return nil, err
+	}
+	v := initPrivacyHooks(resumeModule, interviewModule, commentModule, interactiveModule, creditModule, orderModule, aiModule, notificationModule, userModule)
+	privacyModule, err := privacy.InitModule(db, v)
+	if err != nil {
+		return nil, err
+	}
+	handler22 := privacyModule.Hdl
+	handler23 := notificationModule.Hdl
+	handler24 := couponModule.Hdl
+	subscriptionModule, err := subscription.InitModule(db, mq, orderModule, paymentModule, productModule, module)
+	if err != nil {
+		return nil, err
+	}
+	handler25 := subscriptionModule.Hdl
+	component := initGinxServer(provider, checkMembershipMiddlewareBuilder, checkDeviceMiddlewareBuilder, localActiveLimit, checkPermissionMiddlewareBuilder, handler, questionSetHandler, webHandler, handler2, handler3, handler4, handler5, handler6, handler7, handler8, handler9, handler10, handler11, handler12, handler13, handler14, handler15, handler16, caseSetHandler, examineHandler, projectHandler, analysisHandler, handler17, mockInterviewHandler, handler18, handler19, handler20, interviewJourneyHandler, offerHandler, handler21, handler22, handler23, handler24, handler25)
 	adminHandler := projectModule.AdminHdl
 	webAdminHandler := roadmapModule.AdminHdl
 	adminHandler2 := baguwenModule.AdminHdl
@@ -189,22 +217,29 @@ func InitApp() (*App, error) {
 	adminHandler9 := labelModule.AdminHandler
 	kbaseModule := kbase.InitModule(baguwenModule, roadmapModule)
 	adminHandler10 := kbaseModule.AdminHdl
-	adminServer := InitAdminServer(adminHandler, webAdminHandler, adminHandler2, adminQuestionSetHandler, adminCaseHandler, adminCaseSetHandler, adminHandler3, adminHandler4, adminHandler5, knowledgeBaseHandler, adminHandler6, companyHandler, adminHandler7, adminHandler8, adminHandler9, adminHandler10)
-	closeTimeoutOrdersJob := orderModule.CloseTimeoutOrdersJob
-	closeTimeoutLockedCreditsJob := creditModule.CloseTimeoutLockedCreditsJob
-	syncWechatOrderJob := paymentModule.SyncWechatOrderJob
-	reconModule, err := recon.InitModule(orderModule, paymentModule, creditModule)
+	adminHandler11 := commentModule.AdminHdl
+	reconModule, err := recon.InitModule(db, orderModule, paymentModule, creditModule)
 	if err != nil {
 		return nil, err
 	}
+	adminHandler12 := reconModule.AdminHdl
+	adminHandler13 := couponModule.AdminHdl
+	adminServer := InitAdminServer(adminHandler, webAdminHandler, adminHandler2, adminQuestionSetHandler, adminCaseHandler, adminCaseSetHandler, adminHandler3, adminHandler4, adminHandler5, knowledgeBaseHandler, adminHandler6, companyHandler, adminHandler7, adminHandler8, adminHandler9, adminHandler10, adminHandler11, adminHandler12, adminHandler13)
+	closeTimeoutOrdersJob := orderModule.CloseTimeoutOrdersJob
+	sendOrderRefundEventsJob := orderModule.SendOrderRefundEventsJob
+	closeTimeoutLockedCreditsJob := creditModule.CloseTimeoutLockedCreditsJob
+	syncWechatOrderJob := paymentModule.SyncWechatOrderJob
 	syncPaymentAndOrderJob := reconModule.SyncPaymentAndOrderJob
-	v := initCronJobs(closeTimeoutOrdersJob, closeTimeoutLockedCreditsJob, syncWechatOrderJob, syncPaymentAndOrderJob)
-	v2 := initMQConsumers(mq)
+	reconcileWechatBillJob := reconModule.ReconcileWechatBillJob
+	executeDeletionJob := privacyModule.ExecuteDeletionJob
+	renewSubscriptionsJob := subscriptionModule.RenewSubscriptionsJob
+	v2 := initCronJobs(closeTimeoutOrdersJob, sendOrderRefundEventsJob, closeTimeoutLockedCreditsJob, syncWechatOrderJob, syncPaymentAndOrderJob, reconcileWechatBillJob, executeDeletionJob, renewSubscriptionsJob)
+	v3 := initMQConsumers(mq)
 	app := &App{
 		Web:       component,
 		Admin:     adminServer,
-		Crons:     v,
-		Consumers: v2,
+		Crons:     v2,
+		Consumers: v3,
 	}
 	return app, nil
 }